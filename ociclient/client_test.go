@@ -320,6 +320,58 @@ var _ = Describe("client", func() {
 			testutils.CompareRemoteManifest(ctx, client, manifest2TgtRef, manifest2Desc, manifest2Bytes, configData2, layersData2)
 		}, 20)
 
+		It("should copy only the manifests of the selected platforms of a multi arch image", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			untaggedSrcRef := testenv.Addr + "/multi-arch-tests/5/src/img"
+			untaggedTgtRef := testenv.Addr + "/multi-arch-tests/5/tgt/img"
+
+			configData := []byte("config-data")
+			layersData := [][]byte{[]byte("layer-1-data")}
+			_, manifest1Desc, blobMap := testutils.CreateImage(ocispecv1.MediaTypeImageManifest, configData, layersData)
+			store := ociclient.GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
+				_, err := writer.Write(blobMap[desc.Digest])
+				return err
+			})
+			manifest1Bytes := blobMap[manifest1Desc.Digest]
+			Expect(client.PushRawManifest(ctx, fmt.Sprintf("%s@%s", untaggedSrcRef, manifest1Desc.Digest), manifest1Desc, manifest1Bytes, ociclient.WithStore(store))).To(Succeed())
+
+			configData2 := []byte("config-data2")
+			layersData2 := [][]byte{[]byte("layer-1-data2")}
+			_, manifest2Desc, blobMap2 := testutils.CreateImage(ocispecv1.MediaTypeImageManifest, configData2, layersData2)
+			store = ociclient.GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
+				_, err := writer.Write(blobMap2[desc.Digest])
+				return err
+			})
+			Expect(client.PushRawManifest(ctx, fmt.Sprintf("%s@%s", untaggedSrcRef, manifest2Desc.Digest), manifest2Desc, blobMap2[manifest2Desc.Digest], ociclient.WithStore(store))).To(Succeed())
+
+			manifest1IndexDesc := manifest1Desc
+			manifest1IndexDesc.Platform = &ocispecv1.Platform{Architecture: "amd64", OS: "linux"}
+			manifest2IndexDesc := manifest2Desc
+			manifest2IndexDesc.Platform = &ocispecv1.Platform{Architecture: "arm64", OS: "linux"}
+			index := ocispecv1.Index{
+				Versioned: specs.Versioned{SchemaVersion: 2},
+				Manifests: []ocispecv1.Descriptor{manifest1IndexDesc, manifest2IndexDesc},
+			}
+			multiArchSrcRef := untaggedSrcRef + ":v0.1.0"
+			testutils.UploadTestIndex(ctx, client, multiArchSrcRef, ocispecv1.MediaTypeImageIndex, index)
+
+			multiArchTgtRef := untaggedTgtRef + ":v0.1.0"
+			platforms, err := ociclient.ParsePlatforms([]string{"linux/amd64"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ociclient.CopyPlatforms(ctx, client, multiArchSrcRef, multiArchTgtRef, platforms)).To(Succeed())
+
+			_, actualIndexBytes, err := client.GetRawManifest(ctx, multiArchTgtRef)
+			Expect(err).ToNot(HaveOccurred())
+			actualIndex := ocispecv1.Index{}
+			Expect(json.Unmarshal(actualIndexBytes, &actualIndex)).To(Succeed())
+			Expect(actualIndex.Manifests).To(ConsistOf(manifest1IndexDesc))
+
+			manifest1TgtRef := fmt.Sprintf("%s@%s", untaggedTgtRef, manifest1Desc.Digest)
+			testutils.CompareRemoteManifest(ctx, client, manifest1TgtRef, manifest1Desc, manifest1Bytes, configData, layersData)
+		}, 20)
+
 	})
 
 	Context("ExtendedClient", func() {
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/opencontainers/go-digest"
 	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
@@ -17,6 +18,14 @@ import (
 // The artifact is copied without any modification.
 // This function does directly stream the blobs from the upstream it does not use any cache.
 func Copy(ctx context.Context, client Client, srcRef, tgtRef string) error {
+	return CopyPlatforms(ctx, client, srcRef, tgtRef, nil)
+}
+
+// CopyPlatforms copies a oci artifact from one location to a target ref.
+// If platforms are given, only the manifests of an image index that match one of the platforms are copied
+// and the image index is rewritten to only contain these manifests.
+// Single manifests are always copied without any modification.
+func CopyPlatforms(ctx context.Context, client Client, srcRef, tgtRef string, platforms []ocispecv1.Platform) error {
 	desc, rawManifest, err := client.GetRawManifest(ctx, srcRef)
 	if err != nil {
 		return fmt.Errorf("unable to get manifest: %w", err)
@@ -31,6 +40,24 @@ func Copy(ctx context.Context, client Client, srcRef, tgtRef string) error {
 		if err := json.Unmarshal(rawManifest, &index); err != nil {
 			return fmt.Errorf("unable to unmarshal image index: %w", err)
 		}
+		if len(platforms) != 0 {
+			index, err = FilterIndex(index, platforms)
+			if err != nil {
+				return fmt.Errorf("unable to filter image index of %q: %w", srcRef, err)
+			}
+			if len(index.MediaType) == 0 {
+				index.MediaType = desc.MediaType
+			}
+			rawManifest, err = json.Marshal(index)
+			if err != nil {
+				return fmt.Errorf("unable to marshal image index: %w", err)
+			}
+			desc = ocispecv1.Descriptor{
+				MediaType: desc.MediaType,
+				Digest:    digest.FromBytes(rawManifest),
+				Size:      int64(len(rawManifest)),
+			}
+		}
 
 		srcRepo, _, err := ParseImageRef(srcRef)
 		if err != nil {
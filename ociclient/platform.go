@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"fmt"
+	"strings"
+
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ParsePlatform parses a platform in the form "os/architecture[/variant]", e.g. "linux/arm64/v8".
+func ParsePlatform(platform string) (ocispecv1.Platform, error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return ocispecv1.Platform{}, fmt.Errorf("invalid platform %q, expected os/architecture[/variant]", platform)
+	}
+	for _, part := range parts {
+		if len(part) == 0 {
+			return ocispecv1.Platform{}, fmt.Errorf("invalid platform %q, expected os/architecture[/variant]", platform)
+		}
+	}
+	p := ocispecv1.Platform{
+		OS:           parts[0],
+		Architecture: parts[1],
+	}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// ParsePlatforms parses a list of platforms in the form "os/architecture[/variant]".
+func ParsePlatforms(platforms []string) ([]ocispecv1.Platform, error) {
+	res := make([]ocispecv1.Platform, 0, len(platforms))
+	for _, platform := range platforms {
+		p, err := ParsePlatform(platform)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+// PlatformMatches checks whether the platform matches one of the given platforms.
+// The variant is only compared if it is defined by the given platform.
+func PlatformMatches(platform *ocispecv1.Platform, platforms []ocispecv1.Platform) bool {
+	if platform == nil {
+		return false
+	}
+	for _, p := range platforms {
+		if p.OS != platform.OS || p.Architecture != platform.Architecture {
+			continue
+		}
+		if len(p.Variant) != 0 && p.Variant != platform.Variant {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// FilterIndex returns a copy of the image index that only contains the manifests of the given platforms.
+// An error is returned if no manifest matches.
+func FilterIndex(index ocispecv1.Index, platforms []ocispecv1.Platform) (ocispecv1.Index, error) {
+	manifests := make([]ocispecv1.Descriptor, 0, len(index.Manifests))
+	for _, manifest := range index.Manifests {
+		if PlatformMatches(manifest.Platform, platforms) {
+			manifests = append(manifests, manifest)
+		}
+	}
+	if len(manifests) == 0 {
+		return ocispecv1.Index{}, fmt.Errorf("the image index contains no manifest for the platforms %s", PlatformsString(platforms))
+	}
+	index.Manifests = manifests
+	return index, nil
+}
+
+// PlatformsString formats the platforms as comma separated list.
+func PlatformsString(platforms []ocispecv1.Platform) string {
+	res := make([]string, 0, len(platforms))
+	for _, p := range platforms {
+		s := p.OS + "/" + p.Architecture
+		if len(p.Variant) != 0 {
+			s += "/" + p.Variant
+		}
+		res = append(res, s)
+	}
+	return strings.Join(res, ",")
+}
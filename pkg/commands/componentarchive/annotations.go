@@ -0,0 +1,237 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+const (
+	// AnnotationFieldName selects the component name annotations.
+	AnnotationFieldName = "name"
+	// AnnotationFieldVersion selects the component version annotations.
+	AnnotationFieldVersion = "version"
+	// AnnotationFieldProvider selects the component provider annotation.
+	AnnotationFieldProvider = "provider"
+	// AnnotationFieldRepositoryContext selects the repository context annotation.
+	AnnotationFieldRepositoryContext = "repository-context"
+	// AnnotationFieldSource selects the annotations of the first git source.
+	AnnotationFieldSource = "source"
+	// AnnotationFieldLabels selects the component label annotations.
+	AnnotationFieldLabels = "labels"
+)
+
+// AllAnnotationFields are all fields that can be exported as annotations.
+var AllAnnotationFields = []string{
+	AnnotationFieldName,
+	AnnotationFieldVersion,
+	AnnotationFieldProvider,
+	AnnotationFieldRepositoryContext,
+	AnnotationFieldSource,
+	AnnotationFieldLabels,
+}
+
+const (
+	ociAnnotationTitle    = "org.opencontainers.image.title"
+	ociAnnotationVersion  = "org.opencontainers.image.version"
+	ociAnnotationSource   = "org.opencontainers.image.source"
+	ociAnnotationRevision = "org.opencontainers.image.revision"
+
+	componentAnnotationPrefix            = "component.gardener.cloud/"
+	componentAnnotationName              = componentAnnotationPrefix + "name"
+	componentAnnotationVersion           = componentAnnotationPrefix + "version"
+	componentAnnotationProvider          = componentAnnotationPrefix + "provider"
+	componentAnnotationRepositoryContext = componentAnnotationPrefix + "repository-context"
+	componentAnnotationLabelPrefix       = componentAnnotationPrefix + "label."
+)
+
+// AnnotationsOptions defines all options for the annotations command.
+type AnnotationsOptions struct {
+	// ComponentArchivePath defines the path to the component archive
+	ComponentArchivePath string
+	// OutputPath defines the path where the annotations are written to.
+	// The annotations are written to stdout if no path is defined.
+	OutputPath string
+	// Fields defines the component descriptor fields that are exported as annotations.
+	Fields []string
+}
+
+// NewAnnotationsCommand creates a new command that exports a component descriptor as oci annotations.
+func NewAnnotationsCommand(ctx context.Context) *cobra.Command {
+	opts := &AnnotationsOptions{}
+	cmd := &cobra.Command{
+		Use:   "annotations COMPONENT_ARCHIVE_PATH [-o file] [--fields name,version,...]",
+		Args:  cobra.ExactArgs(1),
+		Short: "Exports the component descriptor of a component archive as oci annotations",
+		Long: `
+Annotations command converts the key fields of the component descriptor of a component archive
+into a json map of oci annotations that can be used for image manifests or configs.
+
+The following fields are supported and can be selected with --fields:
+- name: "org.opencontainers.image.title" and "component.gardener.cloud/name"
+- version: "org.opencontainers.image.version" and "component.gardener.cloud/version"
+- provider: "component.gardener.cloud/provider"
+- repository-context: "component.gardener.cloud/repository-context" with the base url of the effective repository context
+- source: "org.opencontainers.image.source" and "org.opencontainers.image.revision" of the first source with a github access
+- labels: "component.gardener.cloud/label.<label name>" for every label of the component
+
+The annotations are written to stdout unless an output file is given.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run exports the component descriptor as oci annotations.
+func (o *AnnotationsOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ca, _, err := componentarchive.Parse(fs, o.ComponentArchivePath)
+	if err != nil {
+		return err
+	}
+
+	annotations, err := ComponentDescriptorAnnotations(ca.ComponentDescriptor, o.Fields)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(annotations, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal annotations: %w", err)
+	}
+	data = append(data, '\n')
+
+	if len(o.OutputPath) == 0 {
+		log.V(3).Info("write annotations to stdout")
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := vfs.WriteFile(fs, o.OutputPath, data, 0664); err != nil {
+		return fmt.Errorf("unable to write annotations to %q: %w", o.OutputPath, err)
+	}
+	log.V(3).Info("wrote annotations", "path", o.OutputPath)
+	return nil
+}
+
+// ComponentDescriptorAnnotations returns the oci annotations for the given fields of a component descriptor.
+func ComponentDescriptorAnnotations(cd *cdv2.ComponentDescriptor, fields []string) (map[string]string, error) {
+	annotations := map[string]string{}
+	for _, field := range fields {
+		switch field {
+		case AnnotationFieldName:
+			annotations[ociAnnotationTitle] = cd.GetName()
+			annotations[componentAnnotationName] = cd.GetName()
+		case AnnotationFieldVersion:
+			annotations[ociAnnotationVersion] = cd.GetVersion()
+			annotations[componentAnnotationVersion] = cd.GetVersion()
+		case AnnotationFieldProvider:
+			if len(cd.Provider) != 0 {
+				annotations[componentAnnotationProvider] = string(cd.Provider)
+			}
+		case AnnotationFieldRepositoryContext:
+			repoCtx := cd.GetEffectiveRepositoryContext()
+			if repoCtx == nil || repoCtx.GetType() != cdv2.OCIRegistryType {
+				continue
+			}
+			ociRepoCtx := cdv2.OCIRegistryRepository{}
+			if err := repoCtx.DecodeInto(&ociRepoCtx); err != nil {
+				return nil, fmt.Errorf("unable to decode repository context: %w", err)
+			}
+			annotations[componentAnnotationRepositoryContext] = ociRepoCtx.BaseURL
+		case AnnotationFieldSource:
+			for _, src := range cd.Sources {
+				if src.Access == nil || src.Access.GetType() != cdv2.GitHubAccessType {
+					continue
+				}
+				access := cdv2.GitHubAccess{}
+				if err := src.Access.DecodeInto(&access); err != nil {
+					return nil, fmt.Errorf("unable to decode access of source %q: %w", src.GetName(), err)
+				}
+				annotations[ociAnnotationSource] = access.RepoURL
+				if len(access.Commit) != 0 {
+					annotations[ociAnnotationRevision] = access.Commit
+				}
+				break
+			}
+		case AnnotationFieldLabels:
+			for _, label := range cd.Labels {
+				annotations[componentAnnotationLabelPrefix+label.Name] = labelValue(label.Value)
+			}
+		default:
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+	}
+	return annotations, nil
+}
+
+// labelValue returns json string values unquoted and all other values as raw json.
+func labelValue(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+	return string(value)
+}
+
+// Complete parses the given command arguments and applies default options.
+func (o *AnnotationsOptions) Complete(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one argument that contains the path to the component archive")
+	}
+	o.ComponentArchivePath = args[0]
+
+	if len(o.Fields) == 0 {
+		o.Fields = AllAnnotationFields
+	}
+
+	return o.validate()
+}
+
+func (o *AnnotationsOptions) validate() error {
+	for _, field := range o.Fields {
+		if !isAnnotationField(field) {
+			return fmt.Errorf("unknown field %q, expected one of %s", field, strings.Join(AllAnnotationFields, ", "))
+		}
+	}
+	return nil
+}
+
+func isAnnotationField(field string) bool {
+	for _, f := range AllAnnotationFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *AnnotationsOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.OutputPath, "output", "o", "", "[OPTIONAL] path where the annotations are written to. Defaults to stdout")
+	fs.StringSliceVar(&o.Fields, "fields", AllAnnotationFields,
+		fmt.Sprintf("comma separated list of component descriptor fields that are exported. One of %s", strings.Join(AllAnnotationFields, ", ")))
+}
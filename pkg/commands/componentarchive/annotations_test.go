@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"context"
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	"github.com/gardener/component-cli/pkg/testutils"
+)
+
+var _ = Describe("Annotations", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
+	})
+
+	It("should write all annotations of a component archive to a file", func() {
+		opts := &componentarchive.AnnotationsOptions{
+			ComponentArchivePath: "00-ca",
+			OutputPath:           "annotations.json",
+		}
+		Expect(opts.Complete([]string{"00-ca"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, "annotations.json")
+		Expect(err).ToNot(HaveOccurred())
+		annotations := map[string]string{}
+		Expect(json.Unmarshal(data, &annotations)).To(Succeed())
+		Expect(annotations).To(Equal(map[string]string{
+			"org.opencontainers.image.title":              "example.com/component",
+			"org.opencontainers.image.version":            "v0.0.0",
+			"component.gardener.cloud/name":               "example.com/component",
+			"component.gardener.cloud/version":            "v0.0.0",
+			"component.gardener.cloud/provider":           "internal",
+			"component.gardener.cloud/repository-context": "eu.gcr.io/gardener-project/components/dev",
+		}))
+	})
+
+	It("should only export the selected fields", func() {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/component"
+		cd.Version = "v0.0.1"
+		cd.Labels = cdv2.Labels{
+			{Name: "team", Value: json.RawMessage(`"my-team"`)},
+			{Name: "config", Value: json.RawMessage(`{"a":1}`)},
+		}
+		src, err := cdv2.NewUnstructured(cdv2.NewGitHubAccess("https://github.com/gardener/component-cli", "refs/heads/main", "abc"))
+		Expect(err).ToNot(HaveOccurred())
+		cd.Sources = []cdv2.Source{{Access: &src}}
+
+		annotations, err := componentarchive.ComponentDescriptorAnnotations(cd, []string{
+			componentarchive.AnnotationFieldVersion,
+			componentarchive.AnnotationFieldSource,
+			componentarchive.AnnotationFieldLabels,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(annotations).To(Equal(map[string]string{
+			"org.opencontainers.image.version":      "v0.0.1",
+			"component.gardener.cloud/version":      "v0.0.1",
+			"org.opencontainers.image.source":       "https://github.com/gardener/component-cli",
+			"org.opencontainers.image.revision":     "abc",
+			"component.gardener.cloud/label.team":   "my-team",
+			"component.gardener.cloud/label.config": `{"a":1}`,
+		}))
+	})
+
+	It("should fail for unknown fields", func() {
+		opts := &componentarchive.AnnotationsOptions{
+			Fields: []string{"unknown"},
+		}
+		Expect(opts.Complete([]string{"00-ca"})).ToNot(Succeed())
+	})
+
+})
@@ -71,6 +71,13 @@ func NewComponentArchiveCommand(ctx context.Context) *cobra.Command {
 	opts.AddFlags(cmd.Flags())
 	cmd.AddCommand(NewCreateCommand(ctx))
 	cmd.AddCommand(NewExportCommand(ctx))
+	cmd.AddCommand(NewAnnotationsCommand(ctx))
+	cmd.AddCommand(NewMergeCommand(ctx))
+	cmd.AddCommand(NewDiffCommand(ctx))
+	cmd.AddCommand(NewGetCommand(ctx))
+	cmd.AddCommand(NewValidateCommand(ctx))
+	cmd.AddCommand(NewConvertCommand(ctx))
+	cmd.AddCommand(NewSetLabelCommand(ctx))
 	cmd.AddCommand(remote.NewRemoteCommand(ctx))
 	cmd.AddCommand(resources.NewResourcesCommand(ctx))
 	cmd.AddCommand(componentreferences.NewCompRefCommand(ctx))
@@ -104,14 +111,16 @@ func (o *ComponentArchiveOptions) Run(ctx context.Context, log logr.Logger, fs v
 	// directly add the given component archive to the ctf
 	if len(o.ResourcesPaths) == 0 && len(o.SourcesPaths) == 0 && len(o.ComponentReferencesPaths) == 0 {
 		ctfAdd := &ctfcmd.AddOptions{
-			CTFPath:           o.CTFPath,
-			ArchiveFormat:     o.ArchiveFormat,
-			ComponentArchives: []string{o.ComponentArchivePath},
+			CTFPath:            o.CTFPath,
+			ArchiveFormat:      o.ArchiveFormat,
+			ComponentArchives:  []string{o.ComponentArchivePath},
+			SkipReferenceCheck: true,
 		}
 		if err := ctfAdd.Run(ctx, log, fs); err != nil {
 			return fmt.Errorf("unable to add component archive to ctf: %w", err)
 		}
 		log.Info("Successfully added ctf\n")
+		return nil
 	}
 
 	// only copy essential files to the temp dir
@@ -153,9 +162,10 @@ func (o *ComponentArchiveOptions) Run(ctx context.Context, log logr.Logger, fs v
 	}
 
 	ctfAdd := &ctfcmd.AddOptions{
-		CTFPath:           o.CTFPath,
-		ArchiveFormat:     o.ArchiveFormat,
-		ComponentArchives: []string{o.TempDir},
+		CTFPath:            o.CTFPath,
+		ArchiveFormat:      o.ArchiveFormat,
+		ComponentArchives:  []string{o.TempDir},
+		SkipReferenceCheck: true,
 	}
 	if err := ctfAdd.Run(ctx, log, fs); err != nil {
 		return fmt.Errorf("unable to add component archive to ctf: %w", err)
@@ -197,6 +207,7 @@ func (o *ComponentArchiveOptions) AddFlags(fs *pflag.FlagSet) {
 		componentarchive.ArchiveOutputFormatUsage)
 	fs.StringVar(&o.TempDir, "temp-dir", "", "temporary directory where the component archive is build. Defaults to a os-specific temp dir")
 	o.BuilderOptions.AddFlags(fs)
+	o.TemplateOptions.AddFlags(fs)
 }
 
 func (o *ComponentArchiveOptions) Complete(args []string) error {
@@ -11,16 +11,13 @@ import (
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/go-logr/logr"
-	"github.com/mandelsoft/vfs/pkg/layerfs"
-	"github.com/mandelsoft/vfs/pkg/memoryfs"
-	"github.com/mandelsoft/vfs/pkg/osfs"
-	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 
 	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	"github.com/gardener/component-cli/pkg/testutils"
 )
 
 var _ = Describe("Add", func() {
@@ -28,15 +25,11 @@ var _ = Describe("Add", func() {
 	var testdataFs vfs.FileSystem
 
 	BeforeEach(func() {
-		baseFs, err := projectionfs.New(osfs.New(), "./")
-		Expect(err).ToNot(HaveOccurred())
-		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+		testdataFs = testutils.NewTestdataFileSystem("./")
 	})
 
 	It("should add a component descriptor from file to the ctf archive", func() {
-		baseFs, err := projectionfs.New(osfs.New(), "./testdata")
-		Expect(err).ToNot(HaveOccurred())
-		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
 
 		ctx := context.Background()
 		defer ctx.Done()
@@ -7,15 +7,17 @@ package componentreferences
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
-	"path/filepath"
+	"sort"
+	"time"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
-	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/ghodss/yaml"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
@@ -28,6 +30,14 @@ import (
 	"github.com/gardener/component-cli/pkg/componentarchive"
 	"github.com/gardener/component-cli/pkg/logger"
 	"github.com/gardener/component-cli/pkg/template"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+const (
+	// TextOutput logs the result of the command.
+	TextOutput = "text"
+	// JSONOutput prints a json summary of the added component references.
+	JSONOutput = "json"
 )
 
 // Options defines the options that are used to add resources to a component descriptor
@@ -42,18 +52,82 @@ type Options struct {
 	// ComponentReferenceObjectPath defines the path to the resources defined as yaml or json
 	// DEPRECATED
 	ComponentReferenceObjectPath string
+
+	// RefName, RefComponentName and RefVersion define a single component reference by input flags.
+	// Either all or none of them have to be defined.
+	RefName          string
+	RefComponentName string
+	RefVersion       string
+
+	// Overrides defines dotted-path overrides of the form "path=value"
+	// that are applied to every parsed component reference.
+	Overrides []string
+
+	// ResourceEntry defines the name of the entry that contains the component references
+	// if a component reference path points to a tar archive.
+	ResourceEntry string
+
+	// ArchivesDir defines a directory whose component archives are all modified (batch mode).
+	// All arguments are treated as component reference paths if set.
+	ArchivesDir string
+	// Parallel defines the number of component archives that are modified concurrently in batch mode.
+	Parallel int
+
+	// MergeLabels merges the labels of an existing component reference with the labels of the added component reference
+	// instead of replacing the whole component reference.
+	MergeLabels bool
+
+	// Sort sorts the component references, resources and sources of the component descriptor
+	// by name and version before it is written, so that the output does not depend on the order of the inputs.
+	Sort bool
+
+	// HTTPTimeout defines the timeout for fetching component references from a http(s) url.
+	HTTPTimeout time.Duration
+	// HTTPClient is the client that is used to fetch component references from a http(s) url.
+	// A client with the HTTPTimeout is used if not set.
+	HTTPClient *http.Client
+
+	// Backup keeps the previous component descriptor as component-descriptor.yaml.bak.
+	Backup bool
+
+	// DryRun prints the resulting component descriptor instead of writing it to the component archive.
+	DryRun bool
+	// Diff prints the changes of the component descriptor as diff instead of the resulting component descriptor in dry-run mode.
+	Diff bool
+	// OutputFormat defines the output format of the command.
+	// Either TextOutput or JSONOutput.
+	OutputFormat string
+	// Output is the writer the component descriptor is printed to in dry-run mode
+	// and the summary is printed to with the json output format.
+	// Defaults to stdout.
+	Output io.Writer
+}
+
+// AddSummary summarizes the component references that were added to a component archive.
+type AddSummary struct {
+	// ComponentArchive is the path to the modified component archive.
+	ComponentArchive string `json:"componentArchive"`
+	// Added are the names of the component references that were not part of the component descriptor.
+	Added []string `json:"added"`
+	// Updated are the names of the component references that replaced an existing component reference.
+	Updated []string `json:"updated"`
+	// Valid defines whether the resulting component descriptor passed the validation.
+	Valid bool `json:"valid"`
 }
 
 // NewAddCommand creates a command to add additional resources to a component descriptor.
 func NewAddCommand(ctx context.Context) *cobra.Command {
 	opts := &Options{}
 	cmd := &cobra.Command{
-		Use:   "add COMPONENT_ARCHIVE_PATH [COMPONENT_REFERENCE_PATH...]",
-		Args:  cobra.MinimumNArgs(1),
+		Use:   "add [COMPONENT_ARCHIVE_PATH] [COMPONENT_REFERENCE_PATH...]",
+		Args:  cobra.ArbitraryArgs,
 		Short: "Adds a component reference to a component descriptor",
 		Long: fmt.Sprintf(`
 adds component references to the defined component descriptor.
-The component references can be defined in a file or given through stdin.
+The component references can be defined in a file, given through stdin or defined by input flags.
+They are read from the given paths and the files defined by "--file".
+Stdin is only read if it is explicitly requested with "-" as path, e.g. "--file -". The command fails if stdin is an interactive terminal.
+The component archive can be a directory or a tar or tgz file, which is written back in its original format.
 
 The component references are expected to be a multidoc yaml of the following form
 
@@ -72,6 +146,59 @@ version: 'v0.0.2'
 
 </pre>
 
+A single component reference can also be defined with the "--ref-name", "--ref-component-name" and "--ref-version" flags.
+The reference is added in addition to the component references of the given paths.
+
+<pre>
+
+component-cli ca component-references add ./my-ca --ref-name ubuntu --ref-component-name github.com/gardener/ubuntu --ref-version v0.0.1
+
+</pre>
+
+A component reference path may also be a http or https url from which the component references are fetched.
+
+A component reference path may also point to a tar archive (detected by the ".tar" extension or the tar header).
+Then the component references are read from the archive entry that is defined by "--resource-entry".
+
+<pre>
+
+component-cli ca component-references add ./my-ca ./artifacts.tar --resource-entry refs/component-references.yaml
+
+</pre>
+
+The same component references can be added to all component archives of a directory using the "--archives-dir" flag (batch mode).
+Then all arguments are treated as component reference paths. Every component archive is modified independently and
+is only written if all component references could be added. With "--parallel" multiple archives are modified concurrently.
+The command fails if any archive could not be modified.
+
+<pre>
+
+component-cli ca component-references add --archives-dir ./components --parallel 4 ./refs.yaml
+
+</pre>
+
+A component reference with the same identity as an existing component reference replaces the existing one.
+With "--merge-labels" the labels of the existing component reference are kept, labels of the added component reference win on name collisions.
+
+With "--sort" the component references, resources and sources of the resulting component descriptor are sorted
+by name and version, so that the written component descriptor does not depend on the order of the given component references.
+
+With "--dry-run" the resulting component descriptor is printed to stdout instead of being written to the component archive.
+With "--diff" the changes of the component descriptor are printed as unified diff instead.
+
+With "-o json" a summary is printed to stdout that contains the names of the added and updated component references
+and whether the resulting component descriptor is valid. In batch mode a list with a summary per component archive is printed.
+
+Single fields of all parsed component references can be overwritten using the "--set" flag.
+Supported paths are "name", "componentName", "version", "extraIdentity.<key>" and "labels.<name>".
+Label values are automatically converted to booleans or numbers if possible.
+
+<pre>
+
+--set version=v1.2.3 --set labels.team=infra
+
+</pre>
+
 %s
 `, opts.TemplateOptions.Usage()),
 		Run: func(cmd *cobra.Command, args []string) {
@@ -93,56 +220,155 @@ version: 'v0.0.2'
 }
 
 func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
-	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
+	if err := o.TemplateOptions.LoadValuesFiles(fs); err != nil {
+		return err
+	}
+	refs, err := o.generateComponentReferences(log, fs)
+	if err != nil {
+		return err
+	}
 
-	archive, err := o.BuilderOptions.Build(fs)
+	overrides, err := parseOverrides(o.Overrides)
 	if err != nil {
 		return err
 	}
 
-	refs, err := o.generateComponentReferences(log, fs)
+	if len(o.ArchivesDir) != 0 {
+		return o.runBatch(log, fs, refs, overrides)
+	}
+	data, summary, err := o.addComponentReferences(log, fs, o.BuilderOptions, refs, overrides)
+	if o.OutputFormat == JSONOutput {
+		if err := o.writeJSON(summary); err != nil {
+			return err
+		}
+	}
 	if err != nil {
 		return err
 	}
+	if o.DryRun {
+		_, err := o.output().Write(data)
+		return err
+	}
+	log.V(1).Info("Successfully added all component references to component descriptor")
+	return nil
+}
 
-	for _, ref := range refs {
+// output returns the writer for the dry-run output.
+func (o *Options) output() io.Writer {
+	if o.Output == nil {
+		return os.Stdout
+	}
+	return o.Output
+}
+
+// writeJSON prints the given value as json to the output.
+func (o *Options) writeJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal summary: %w", err)
+	}
+	_, err = fmt.Fprintln(o.output(), string(data))
+	return err
+}
+
+// addComponentReferences adds the component references to the component archive that is defined by the builder options
+// and returns the encoded component descriptor and a summary of the added component references.
+// The component descriptor is only written if all component references could be added and dry-run is not set.
+func (o *Options) addComponentReferences(log logr.Logger, fs vfs.FileSystem, builderOpts componentarchive.BuilderOptions, refs []componentReference, overrides []override) ([]byte, AddSummary, error) {
+	summary := AddSummary{
+		ComponentArchive: builderOpts.ComponentArchivePath,
+		Added:            []string{},
+		Updated:          []string{},
+	}
+
+	fs, repack, err := componentarchive.Unpack(fs, builderOpts.ComponentArchivePath)
+	if err != nil {
+		return nil, summary, err
+	}
+
+	var oldData []byte
+	if o.DryRun && o.Diff {
+		oldData, err = componentarchive.ReadComponentDescriptorData(fs, builderOpts.ComponentArchivePath)
+		if err != nil {
+			return nil, summary, err
+		}
+	}
+
+	archive, err := builderOpts.Build(fs)
+	if err != nil {
+		return nil, summary, err
+	}
+
+	for _, parsedRef := range refs {
+		ref := parsedRef.ComponentReference
+		if err := applyOverrides(&ref, overrides); err != nil {
+			return nil, summary, err
+		}
 		if errList := cdvalidation.ValidateComponentReference(field.NewPath(""), ref); len(errList) != 0 {
-			return fmt.Errorf("invalid component reference: %w", errList.ToAggregate())
+			if len(ref.Name) != 0 {
+				return nil, summary, fmt.Errorf("%s: invalid component reference %q: %w", parsedRef.origin, ref.Name, errList.ToAggregate())
+			}
+			return nil, summary, fmt.Errorf("%s: invalid component reference: %w", parsedRef.origin, errList.ToAggregate())
 		}
 		id := archive.ComponentDescriptor.GetComponentReferenceIndex(ref)
 		if id != -1 {
+			if o.MergeLabels {
+				ref.Labels = mergeLabels(archive.ComponentDescriptor.ComponentReferences[id].Labels, ref.Labels)
+			}
 			archive.ComponentDescriptor.ComponentReferences[id] = ref
+			summary.Updated = append(summary.Updated, ref.Name)
 		} else {
 			archive.ComponentDescriptor.ComponentReferences = append(archive.ComponentDescriptor.ComponentReferences, ref)
+			summary.Added = append(summary.Added, ref.Name)
 		}
 		log.V(3).Info(fmt.Sprintf("Successfully added component references %q of component %q to component descriptor", ref.Name, ref.ComponentName))
 	}
 
 	if err := cdvalidation.Validate(archive.ComponentDescriptor); err != nil {
-		return fmt.Errorf("invalid component descriptor: %w", err)
+		return nil, summary, fmt.Errorf("invalid component descriptor: %w", err)
 	}
+	summary.Valid = true
 
+	if o.Sort {
+		sortComponentDescriptor(archive.ComponentDescriptor)
+	}
 	data, err := yaml.Marshal(archive.ComponentDescriptor)
 	if err != nil {
-		return fmt.Errorf("unable to encode component descriptor: %w", err)
+		return nil, summary, fmt.Errorf("unable to encode component descriptor: %w", err)
 	}
-	if err := vfs.WriteFile(fs, compDescFilePath, data, 0664); err != nil {
-		return fmt.Errorf("unable to write modified comonent descriptor: %w", err)
+	if o.DryRun && o.Diff {
+		var diff bytes.Buffer
+		if err := componentarchive.WriteComponentDescriptorDiff(&diff, builderOpts.ComponentArchivePath, oldData, data); err != nil {
+			return nil, summary, err
+		}
+		return diff.Bytes(), summary, nil
 	}
-	log.V(1).Info("Successfully added all component references to component descriptor")
-	return nil
+	if o.DryRun {
+		return data, summary, nil
+	}
+	if err := componentarchive.WriteComponentDescriptor(fs, builderOpts.ComponentArchivePath, data, o.Backup); err != nil {
+		return nil, summary, fmt.Errorf("unable to write modified comonent descriptor: %w", err)
+	}
+	if err := repack(); err != nil {
+		return nil, summary, err
+	}
+	return data, summary, nil
 }
 
 func (o *Options) Complete(args []string) error {
 	args = o.TemplateOptions.Parse(args)
-	if len(args) == 0 {
-		return errors.New("at least a component archive path argument has to be defined")
-	}
-	o.BuilderOptions.ComponentArchivePath = args[0]
-	o.BuilderOptions.Default()
+	if len(o.ArchivesDir) != 0 {
+		o.ComponentReferenceObjectPaths = append(o.ComponentReferenceObjectPaths, args...)
+	} else {
+		if len(args) == 0 {
+			return errors.New("at least a component archive path argument has to be defined")
+		}
+		o.BuilderOptions.ComponentArchivePath = args[0]
+		o.BuilderOptions.Default()
 
-	if len(args) > 1 {
-		o.ComponentReferenceObjectPaths = append(o.ComponentReferenceObjectPaths, args[1:]...)
+		if len(args) > 1 {
+			o.ComponentReferenceObjectPaths = append(o.ComponentReferenceObjectPaths, args[1:]...)
+		}
 	}
 	if len(o.ComponentReferenceObjectPath) != 0 {
 		o.ComponentReferenceObjectPaths = append(o.ComponentReferenceObjectPaths, o.ComponentReferenceObjectPath)
@@ -151,49 +377,131 @@ func (o *Options) Complete(args []string) error {
 }
 
 func (o *Options) validate() error {
+	if _, err := parseOverrides(o.Overrides); err != nil {
+		return err
+	}
+	if err := o.validateRefFlags(); err != nil {
+		return err
+	}
+	if len(o.OutputFormat) == 0 {
+		o.OutputFormat = TextOutput
+	}
+	if o.OutputFormat != TextOutput && o.OutputFormat != JSONOutput {
+		return fmt.Errorf("unknown output format %q, expected one of %q, %q", o.OutputFormat, TextOutput, JSONOutput)
+	}
+	if o.OutputFormat == JSONOutput && o.DryRun {
+		return errors.New("the json output cannot be combined with --dry-run")
+	}
+	if o.Diff && !o.DryRun {
+		return errors.New("--diff can only be used with --dry-run")
+	}
+	if len(o.ArchivesDir) != 0 {
+		if len(o.BuilderOptions.ComponentArchivePath) != 0 {
+			return errors.New("a component archive path and an archives directory cannot be defined at the same time")
+		}
+		if o.Parallel < 1 {
+			return errors.New("parallel must be at least 1")
+		}
+		return nil
+	}
 	return o.BuilderOptions.Validate()
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	o.BuilderOptions.AddFlags(fs)
+	o.TemplateOptions.AddFlags(fs)
 	// specify the resource
-	fs.StringVarP(&o.ComponentReferenceObjectPath, "resource", "r", "", "The path to the resources defined as yaml or json")
+	fs.StringArrayVarP(&o.ComponentReferenceObjectPaths, "file", "f", nil, "[OPTIONAL] path or http(s) url to a file with component references. Use \"-\" to read from stdin. Can be defined multiple times")
+	fs.StringVarP(&o.ComponentReferenceObjectPath, "resource", "r", "", "The path or http(s) url to the resources defined as yaml or json")
+	fs.StringVar(&o.RefName, "ref-name", "", "[OPTIONAL] name of a component reference that is defined by flags. Requires --ref-component-name and --ref-version")
+	fs.StringVar(&o.RefComponentName, "ref-component-name", "", "[OPTIONAL] component name of a component reference that is defined by flags")
+	fs.StringVar(&o.RefVersion, "ref-version", "", "[OPTIONAL] version of a component reference that is defined by flags")
+	fs.StringVar(&o.ResourceEntry, "resource-entry", "", "[OPTIONAL] name of the entry that contains the component references if a resource path points to a tar archive")
+	fs.BoolVar(&o.MergeLabels, "merge-labels", false, "[OPTIONAL] keeps the labels of existing component references. Labels of the added component references win on name collisions")
+	fs.DurationVar(&o.HTTPTimeout, "http-timeout", 30*time.Second, "[OPTIONAL] timeout for fetching component references from a http(s) url")
+	fs.BoolVar(&o.Sort, "sort", false, "[OPTIONAL] sorts the component references, resources and sources of the component descriptor by name and version")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "[OPTIONAL] prints the resulting component descriptor to stdout instead of writing it")
+	fs.BoolVar(&o.Diff, "diff", false, "[OPTIONAL] prints the changes of the component descriptor as diff instead of the resulting component descriptor in dry-run mode")
+	fs.StringVarP(&o.OutputFormat, "output", "o", TextOutput, fmt.Sprintf("[OPTIONAL] output format. One of %q, %q", TextOutput, JSONOutput))
+	fs.StringVar(&o.ArchivesDir, "archives-dir", "", "[OPTIONAL] directory whose component archives are all modified. All arguments are treated as component reference paths")
+	fs.IntVar(&o.Parallel, "parallel", 1, "[OPTIONAL] number of component archives that are modified concurrently if --archives-dir is set")
+	fs.StringArrayVar(&o.Overrides, "set", []string{}, "[OPTIONAL] overrides a field of all component references in the form path=value (e.g. version=v1.2.3 or labels.team=infra)")
+	fs.BoolVar(&o.Backup, "backup", false, "[OPTIONAL] keeps the previous component descriptor as component-descriptor.yaml.bak if the component archive is a directory")
 }
 
-// generateComponentReferences parses component references from the given path and stdin.
-func (o *Options) generateComponentReferences(log logr.Logger, fs vfs.FileSystem) ([]cdv2.ComponentReference, error) {
-	if len(o.ComponentReferenceObjectPaths) == 0 {
-		// try to read from stdin if no resources are defined
-		componentReferences := make([]cdv2.ComponentReference, 0)
-		stdinInfo, err := os.Stdin.Stat()
-		if err != nil {
-			log.V(3).Info("unable to read from stdin", "error", err.Error())
-			return nil, nil
+// validateRefFlags validates that the component reference flags are either all or none defined.
+func (o *Options) validateRefFlags() error {
+	defined := 0
+	for _, value := range []string{o.RefName, o.RefComponentName, o.RefVersion} {
+		if len(value) != 0 {
+			defined++
 		}
-		if (stdinInfo.Mode()&os.ModeNamedPipe != 0) || stdinInfo.Size() != 0 {
-			stdinResources, err := o.generateComponentReferenceFromReader(os.Stdin)
+	}
+	if defined != 0 && defined != 3 {
+		return errors.New("a component reference defined by flags requires --ref-name, --ref-component-name and --ref-version")
+	}
+	return nil
+}
+
+// generateComponentReferences parses component references from the given paths and stdin
+// and adds the component reference that is defined by flags.
+func (o *Options) generateComponentReferences(log logr.Logger, fs vfs.FileSystem) ([]componentReference, error) {
+	if err := o.validateRefFlags(); err != nil {
+		return nil, err
+	}
+	refs, err := o.readComponentReferences(log, fs)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.RefName) != 0 {
+		refs = append(refs, componentReference{
+			ComponentReference: cdv2.ComponentReference{
+				Name:          o.RefName,
+				ComponentName: o.RefComponentName,
+				Version:       o.RefVersion,
+			},
+			origin: "--ref-* flags",
+		})
+	}
+	return refs, nil
+}
+
+// readComponentReferences parses component references from the given paths and stdin.
+func (o *Options) readComponentReferences(log logr.Logger, fs vfs.FileSystem) ([]componentReference, error) {
+	componentReferences := make([]componentReference, 0)
+	for _, resourcePath := range o.ComponentReferenceObjectPaths {
+		if resourcePath == utils.StdinPath {
+			stdin, err := utils.Stdin()
+			if err != nil {
+				return nil, err
+			}
+			stdinResources, err := o.generateComponentReferenceFromReader(stdin, "stdin")
 			if err != nil {
 				return nil, fmt.Errorf("unable to read from stdin: %w", err)
 			}
 			componentReferences = append(componentReferences, stdinResources...)
+			continue
 		}
-		return componentReferences, nil
-	}
 
-	componentReferences := make([]cdv2.ComponentReference, 0)
-	for _, resourcePath := range o.ComponentReferenceObjectPaths {
-		if resourcePath == "-" {
-			stdinInfo, err := os.Stdin.Stat()
+		if isHTTPURL(resourcePath) {
+			newResources, err := o.generateComponentReferencesFromURL(resourcePath)
 			if err != nil {
-				return nil, fmt.Errorf("unable to read from stdin: %w", err)
+				return nil, fmt.Errorf("unable to read component reference from %s: %w", resourcePath, err)
 			}
-			if (stdinInfo.Mode()&os.ModeNamedPipe != 0) || stdinInfo.Size() != 0 {
-				stdinResources, err := o.generateComponentReferenceFromReader(os.Stdin)
-				if err != nil {
-					return nil, fmt.Errorf("unable to read from stdin: %w", err)
-				}
-				componentReferences = append(componentReferences, stdinResources...)
+			componentReferences = append(componentReferences, newResources...)
+			continue
+		}
+
+		isTar, err := isTarArchive(fs, resourcePath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read component reference from %s: %w", resourcePath, err)
+		}
+		if isTar {
+			newResources, err := o.generateComponentReferencesFromTar(fs, resourcePath)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read component reference from %s: %w", resourcePath, err)
 			}
+			componentReferences = append(componentReferences, newResources...)
 			continue
 		}
 
@@ -201,7 +509,7 @@ func (o *Options) generateComponentReferences(log logr.Logger, fs vfs.FileSystem
 		if err != nil {
 			return nil, fmt.Errorf("unable to read component reference from %s: %w", resourcePath, err)
 		}
-		newResources, err := o.generateComponentReferenceFromReader(resourceObjectReader)
+		newResources, err := o.generateComponentReferenceFromReader(resourceObjectReader, resourcePath)
 		if err != nil {
 			if err2 := resourceObjectReader.Close(); err2 != nil {
 				log.Error(err, "unable to close file reader", "path", resourcePath)
@@ -216,7 +524,50 @@ func (o *Options) generateComponentReferences(log logr.Logger, fs vfs.FileSystem
 	return componentReferences, nil
 }
 
-func (o *Options) generateComponentReferenceFromReader(reader io.Reader) ([]cdv2.ComponentReference, error) {
+// sortComponentDescriptor sorts the component references, resources and sources by name and version.
+func sortComponentDescriptor(cd *cdv2.ComponentDescriptor) {
+	sort.SliceStable(cd.ComponentReferences, func(i, j int) bool {
+		return lessNameVersion(cd.ComponentReferences[i].GetName(), cd.ComponentReferences[i].GetVersion(),
+			cd.ComponentReferences[j].GetName(), cd.ComponentReferences[j].GetVersion())
+	})
+	sort.SliceStable(cd.Resources, func(i, j int) bool {
+		return lessNameVersion(cd.Resources[i].GetName(), cd.Resources[i].GetVersion(),
+			cd.Resources[j].GetName(), cd.Resources[j].GetVersion())
+	})
+	sort.SliceStable(cd.Sources, func(i, j int) bool {
+		return lessNameVersion(cd.Sources[i].GetName(), cd.Sources[i].GetVersion(),
+			cd.Sources[j].GetName(), cd.Sources[j].GetVersion())
+	})
+}
+
+func lessNameVersion(name1, version1, name2, version2 string) bool {
+	if name1 != name2 {
+		return name1 < name2
+	}
+	return version1 < version2
+}
+
+// mergeLabels returns the existing labels with the added labels.
+// Added labels replace existing labels with the same name.
+func mergeLabels(existing, added cdv2.Labels) cdv2.Labels {
+	merged := make(cdv2.Labels, 0, len(existing)+len(added))
+	for _, label := range existing {
+		if _, ok := added.Get(label.Name); ok {
+			continue
+		}
+		merged = append(merged, label)
+	}
+	return append(merged, added...)
+}
+
+// componentReference is a parsed component reference together with the location it is defined at.
+type componentReference struct {
+	cdv2.ComponentReference
+	// origin describes where the component reference is defined, e.g. "document #1 in refs.yaml".
+	origin string
+}
+
+func (o *Options) generateComponentReferenceFromReader(reader io.Reader, source string) ([]componentReference, error) {
 	var data bytes.Buffer
 	if _, err := io.Copy(&data, reader); err != nil {
 		return nil, err
@@ -225,22 +576,26 @@ func (o *Options) generateComponentReferenceFromReader(reader io.Reader) ([]cdv2
 	if err != nil {
 		return nil, err
 	}
-	return generateComponentReferenceFromReader(bytes.NewBufferString(tmplData))
+	return generateComponentReferenceFromReader(bytes.NewBufferString(tmplData), source)
 }
 
-// generateComponentReferenceFromReader generates a resource given resource options and a resource template file.
-func generateComponentReferenceFromReader(reader io.Reader) ([]cdv2.ComponentReference, error) {
-	refs := make([]cdv2.ComponentReference, 0)
+// generateComponentReferenceFromReader generates component references from a multidoc yaml or json reader.
+// The index of the document and the source are recorded for every component reference.
+func generateComponentReferenceFromReader(reader io.Reader, source string) ([]componentReference, error) {
+	refs := make([]componentReference, 0)
 	yamldecoder := yamlutil.NewYAMLOrJSONDecoder(reader, 1024)
-	for {
+	for i := 0; ; i++ {
 		ref := cdv2.ComponentReference{}
 		if err := yamldecoder.Decode(&ref); err != nil {
 			if err == io.EOF {
 				break
 			}
-			return nil, fmt.Errorf("unable to decode ref: %w", err)
+			return nil, fmt.Errorf("unable to decode ref of document #%d in %s: %w", i, source, err)
 		}
-		refs = append(refs, ref)
+		refs = append(refs, componentReference{
+			ComponentReference: ref,
+			origin:             fmt.Sprintf("document #%d in %s", i, source),
+		})
 	}
 
 	return refs, nil
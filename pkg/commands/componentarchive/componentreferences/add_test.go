@@ -5,27 +5,32 @@
 package componentreferences_test
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/codec"
 	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/go-logr/logr"
-	"github.com/mandelsoft/vfs/pkg/layerfs"
-	"github.com/mandelsoft/vfs/pkg/memoryfs"
-	"github.com/mandelsoft/vfs/pkg/osfs"
-	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 
 	"github.com/gardener/component-cli/pkg/commands/componentarchive/componentreferences"
 	"github.com/gardener/component-cli/pkg/componentarchive"
 	"github.com/gardener/component-cli/pkg/template"
+	"github.com/gardener/component-cli/pkg/testutils"
 )
 
 func TestConfig(t *testing.T) {
@@ -38,9 +43,7 @@ var _ = Describe("Add", func() {
 	var testdataFs vfs.FileSystem
 
 	BeforeEach(func() {
-		fs, err := projectionfs.New(osfs.New(), "./testdata")
-		Expect(err).ToNot(HaveOccurred())
-		testdataFs = layerfs.New(memoryfs.New(), fs)
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
 	})
 
 	It("should add a reference defined by a file", func() {
@@ -85,6 +88,61 @@ var _ = Describe("Add", func() {
 		}))
 	})
 
+	It("should add a reference defined by input flags", func() {
+		opts := &componentreferences.Options{
+			RefName:          "myref",
+			RefComponentName: "github.com/gardener/other",
+			RefVersion:       "v0.0.2",
+		}
+		Expect(opts.Complete([]string{"./00-component"})).To(Succeed())
+
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+
+		Expect(cd.ComponentReferences).To(HaveLen(1))
+		Expect(cd.ComponentReferences[0]).To(MatchFields(IgnoreExtras, Fields{
+			"Name":          Equal("myref"),
+			"ComponentName": Equal("github.com/gardener/other"),
+			"Version":       Equal("v0.0.2"),
+		}))
+	})
+
+	It("should add a reference defined by input flags in addition to a file", func() {
+		opts := &componentreferences.Options{
+			RefName:          "myref",
+			RefComponentName: "github.com/gardener/other",
+			RefVersion:       "v0.0.2",
+		}
+		Expect(opts.Complete([]string{"./00-component", "./resources/00-ref.yaml"})).To(Succeed())
+
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+
+		Expect(cd.ComponentReferences).To(HaveLen(2))
+		Expect(cd.ComponentReferences[0].Name).To(Equal("ubuntu"))
+		Expect(cd.ComponentReferences[1].Name).To(Equal("myref"))
+	})
+
+	It("should throw an error if not all input flags of a reference are defined", func() {
+		opts := &componentreferences.Options{
+			RefName:    "myref",
+			RefVersion: "v0.0.2",
+		}
+		err := opts.Complete([]string{"./00-component"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("--ref-component-name"))
+	})
+
 	It("should add a component reference from stdin defined by '-'", func() {
 		input, err := os.Open("./testdata/resources/00-ref.yaml")
 		Expect(err).ToNot(HaveOccurred())
@@ -117,7 +175,7 @@ var _ = Describe("Add", func() {
 		}))
 	})
 
-	It("should add a component reference from stdin if no other paths are defined", func() {
+	It("should not read stdin if it is not requested", func() {
 		input, err := os.Open("./testdata/resources/00-ref.yaml")
 		Expect(err).ToNot(HaveOccurred())
 		defer input.Close()
@@ -140,12 +198,44 @@ var _ = Describe("Add", func() {
 		cd := &cdv2.ComponentDescriptor{}
 		Expect(codec.Decode(data, cd)).To(Succeed())
 
+		Expect(cd.ComponentReferences).To(HaveLen(0))
+
+		offset, err := input.Seek(0, io.SeekCurrent)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(offset).To(BeZero())
+	})
+
+	It("should read stdin that is redirected from a character device", func() {
+		// /dev/null is a character device but no interactive terminal, so it is read as empty input
+		input, err := os.Open(os.DevNull)
+		Expect(err).ToNot(HaveOccurred())
+		defer input.Close()
+
+		oldstdin := os.Stdin
+		defer func() {
+			os.Stdin = oldstdin
+		}()
+		os.Stdin = input
+
+		opts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"-", "./resources/00-ref.yaml"},
+		}
+
+		done := make(chan error)
+		go func() {
+			done <- opts.Run(context.TODO(), logr.Discard(), testdataFs)
+		}()
+		Eventually(done, 5*time.Second).Should(Receive(BeNil()))
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+
 		Expect(cd.ComponentReferences).To(HaveLen(1))
-		Expect(cd.ComponentReferences[0]).To(MatchFields(IgnoreExtras, Fields{
-			"Name":          Equal("ubuntu"),
-			"ComponentName": Equal("github.com/gardener/ubuntu"),
-			"Version":       Equal("v0.0.1"),
-		}))
+		Expect(cd.ComponentReferences[0].Name).To(Equal("ubuntu"))
 	})
 
 	It("should add multiple reference defined by a multi doc file", func() {
@@ -176,6 +266,18 @@ var _ = Describe("Add", func() {
 		}))
 	})
 
+	It("should write the component descriptor with a non-executable file mode", func() {
+		opts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"./resources/00-ref.yaml"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		info, err := testdataFs.Stat(filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0664)))
+	})
+
 	It("should throw an error if an invalid resource is defined", func() {
 		opts := &componentreferences.Options{
 			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
@@ -191,6 +293,61 @@ var _ = Describe("Add", func() {
 		Expect(cd.ComponentReferences).To(HaveLen(0))
 	})
 
+	It("should report the document and name of an invalid reference", func() {
+		opts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"./resources/11-multi-doc-invalid.yaml"},
+		}
+
+		err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`document #1 in ./resources/11-multi-doc-invalid.yaml`))
+		Expect(err.Error()).To(ContainSubstring(`"myref"`))
+	})
+
+	It("should apply overrides defined by --set to the parsed references", func() {
+		opts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"./resources/00-ref.yaml"},
+			Overrides: []string{
+				"version=v1.2.3",
+				"labels.team=infra",
+				"labels.replicas=3",
+				"labels.enabled=true",
+			},
+		}
+
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+
+		Expect(cd.ComponentReferences).To(HaveLen(1))
+		Expect(cd.ComponentReferences[0]).To(MatchFields(IgnoreExtras, Fields{
+			"Name":          Equal("ubuntu"),
+			"ComponentName": Equal("github.com/gardener/ubuntu"),
+			"Version":       Equal("v1.2.3"),
+		}))
+		Expect(cd.ComponentReferences[0].Labels).To(ConsistOf(
+			cdv2.Label{Name: "team", Value: json.RawMessage(`"infra"`)},
+			cdv2.Label{Name: "replicas", Value: json.RawMessage(`3`)},
+			cdv2.Label{Name: "enabled", Value: json.RawMessage(`true`)},
+		))
+	})
+
+	It("should throw an error if an override references an unknown field", func() {
+		opts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"./resources/00-ref.yaml"},
+			Overrides:                     []string{"unknown=abc"},
+		}
+
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+	})
+
 	It("should add a reference defined by a file with a template", func() {
 		opts := &componentreferences.Options{
 			BuilderOptions: componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
@@ -217,4 +374,412 @@ var _ = Describe("Add", func() {
 		}))
 	})
 
+	It("should print the component descriptor instead of writing it in dry-run mode", func() {
+		var buf bytes.Buffer
+		opts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"./resources/00-ref.yaml"},
+			DryRun:                        true,
+			Output:                        &buf,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(buf.Bytes(), cd)).To(Succeed())
+		Expect(cd.ComponentReferences).To(HaveLen(1))
+		Expect(cd.ComponentReferences[0].Name).To(Equal("ubuntu"))
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd = &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.ComponentReferences).To(BeEmpty())
+	})
+
+	It("should print the changes as diff in dry-run mode", func() {
+		var buf bytes.Buffer
+		opts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"./resources/00-ref.yaml"},
+			DryRun:                        true,
+			Diff:                          true,
+			Output:                        &buf,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(buf.String()).To(HavePrefix("--- 00-component/component-descriptor.yaml\n+++ 00-component/component-descriptor.yaml\n"))
+		Expect(buf.String()).To(ContainSubstring("+    name: ubuntu\n"))
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.ComponentReferences).To(BeEmpty())
+	})
+
+	It("should not allow --diff without dry-run", func() {
+		opts := &componentreferences.Options{Diff: true}
+		err := opts.Complete([]string{"./00-component", "./resources/00-ref.yaml"})
+		Expect(err).To(MatchError("--diff can only be used with --dry-run"))
+	})
+
+	It("should fail in dry-run mode if the resulting component descriptor is invalid", func() {
+		var buf bytes.Buffer
+		opts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"./resources/10-invalid.yaml"},
+			DryRun:                        true,
+			Output:                        &buf,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+		Expect(buf.Len()).To(Equal(0))
+	})
+
+	It("should print a json summary if the resulting component descriptor is invalid", func() {
+		var buf bytes.Buffer
+		opts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"./resources/10-invalid.yaml"},
+			OutputFormat:                  componentreferences.JSONOutput,
+			Output:                        &buf,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+		summary := componentreferences.AddSummary{}
+		Expect(json.Unmarshal(buf.Bytes(), &summary)).To(Succeed())
+		Expect(summary.Valid).To(BeFalse())
+	})
+
+	It("should write identical component descriptors for differently ordered references if sorted", func() {
+		Expect(vfs.WriteFile(testdataFs, "./resources/ordered.yaml", []byte(`---
+name: 'ubuntu'
+componentName: 'github.com/gardener/ubuntu'
+version: 'v0.0.1'
+---
+name: 'myref'
+componentName: 'github.com/gardener/other'
+version: 'v0.0.2'
+`), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/reversed.yaml", []byte(`---
+name: 'myref'
+componentName: 'github.com/gardener/other'
+version: 'v0.0.2'
+---
+name: 'ubuntu'
+componentName: 'github.com/gardener/ubuntu'
+version: 'v0.0.1'
+`), os.ModePerm)).To(Succeed())
+
+		run := func(path string, sort bool) []byte {
+			var buf bytes.Buffer
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{path},
+				Sort:                          sort,
+				DryRun:                        true,
+				Output:                        &buf,
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+			return buf.Bytes()
+		}
+
+		Expect(run("./resources/ordered.yaml", false)).ToNot(Equal(run("./resources/reversed.yaml", false)))
+		sorted := run("./resources/ordered.yaml", true)
+		Expect(sorted).To(Equal(run("./resources/reversed.yaml", true)))
+
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(sorted, cd)).To(Succeed())
+		Expect(cd.ComponentReferences).To(HaveLen(2))
+		Expect(cd.ComponentReferences[0].Name).To(Equal("myref"))
+		Expect(cd.ComponentReferences[1].Name).To(Equal("ubuntu"))
+	})
+
+	It("should not combine the json output with dry-run mode", func() {
+		opts := &componentreferences.Options{
+			DryRun:       true,
+			OutputFormat: componentreferences.JSONOutput,
+		}
+		err := opts.Complete([]string{"./00-component", "./resources/00-ref.yaml"})
+		Expect(err).To(MatchError("the json output cannot be combined with --dry-run"))
+	})
+
+	Context("existing component reference", func() {
+
+		BeforeEach(func() {
+			Expect(vfs.WriteFile(testdataFs, "existing.yaml", []byte(`
+name: 'ubuntu'
+componentName: 'github.com/gardener/ubuntu'
+version: 'v0.0.1'
+labels:
+- name: a
+  value: 1
+- name: b
+  value: 2
+`), os.ModePerm)).To(Succeed())
+			Expect(vfs.WriteFile(testdataFs, "update.yaml", []byte(`
+name: 'ubuntu'
+componentName: 'github.com/gardener/ubuntu'
+version: 'v0.0.2'
+labels:
+- name: b
+  value: 3
+- name: c
+  value: 4
+`), os.ModePerm)).To(Succeed())
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{"existing.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		})
+
+		readComponentReference := func() cdv2.ComponentReference {
+			data, err := vfs.ReadFile(testdataFs, filepath.Join("./00-component", ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.ComponentReferences).To(HaveLen(1))
+			return cd.ComponentReferences[0]
+		}
+
+		It("should replace the whole component reference by default", func() {
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{"update.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			ref := readComponentReference()
+			Expect(ref.Version).To(Equal("v0.0.2"))
+			Expect(ref.Labels).To(Equal(cdv2.Labels{
+				{Name: "b", Value: json.RawMessage(`3`)},
+				{Name: "c", Value: json.RawMessage(`4`)},
+			}))
+		})
+
+		It("should merge the labels with --merge-labels", func() {
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{"update.yaml"},
+				MergeLabels:                   true,
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			ref := readComponentReference()
+			Expect(ref.Version).To(Equal("v0.0.2"))
+			Expect(ref.Labels).To(Equal(cdv2.Labels{
+				{Name: "a", Value: json.RawMessage(`1`)},
+				{Name: "b", Value: json.RawMessage(`3`)},
+				{Name: "c", Value: json.RawMessage(`4`)},
+			}))
+		})
+
+		It("should print a json summary of added and updated component references", func() {
+			var buf bytes.Buffer
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{"update.yaml"},
+				RefName:                       "other",
+				RefComponentName:              "github.com/gardener/other",
+				RefVersion:                    "v1.0.0",
+				OutputFormat:                  componentreferences.JSONOutput,
+				Output:                        &buf,
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			Expect(buf.String()).To(MatchJSON(`{
+  "componentArchive": "./00-component",
+  "added": ["other"],
+  "updated": ["ubuntu"],
+  "valid": true
+}`))
+			data, err := vfs.ReadFile(testdataFs, filepath.Join("./00-component", ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.ComponentReferences).To(HaveLen(2))
+		})
+
+	})
+
+	Context("url", func() {
+
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/refs.yaml" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				data, err := vfs.ReadFile(testdataFs, "./resources/00-ref.yaml")
+				Expect(err).ToNot(HaveOccurred())
+				_, _ = w.Write(data)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should add a reference fetched from a url", func() {
+			opts := &componentreferences.Options{
+				BuilderOptions:               componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPath: server.URL + "/refs.yaml",
+				HTTPClient:                   server.Client(),
+			}
+			Expect(opts.Complete([]string{"./00-component"})).To(Succeed())
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.ComponentReferences).To(HaveLen(1))
+			Expect(cd.ComponentReferences[0].Name).To(Equal("ubuntu"))
+		})
+
+		It("should fail with the status code if the url cannot be fetched", func() {
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{server.URL + "/missing.yaml"},
+				HTTPClient:                    server.Client(),
+			}
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("404"))
+		})
+
+	})
+
+	Context("tar archive", func() {
+
+		writeTar := func(tarPath string) {
+			data, err := vfs.ReadFile(testdataFs, "./resources/00-ref.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			file, err := testdataFs.Create(tarPath)
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close()
+			tw := tar.NewWriter(file)
+			Expect(tw.WriteHeader(&tar.Header{Name: "other.txt", Mode: 0644, Size: 3, Typeflag: tar.TypeReg})).To(Succeed())
+			_, err = tw.Write([]byte("foo"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tw.WriteHeader(&tar.Header{Name: "refs/00-ref.yaml", Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg})).To(Succeed())
+			_, err = tw.Write(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tw.Close()).To(Succeed())
+		}
+
+		DescribeTable("should add a reference defined by a tar entry", func(tarPath string) {
+			writeTar(tarPath)
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{tarPath},
+				ResourceEntry:                 "./refs/00-ref.yaml",
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+
+			Expect(cd.ComponentReferences).To(HaveLen(1))
+			Expect(cd.ComponentReferences[0]).To(MatchFields(IgnoreExtras, Fields{
+				"Name":          Equal("ubuntu"),
+				"ComponentName": Equal("github.com/gardener/ubuntu"),
+				"Version":       Equal("v0.0.1"),
+			}))
+		},
+			Entry("detected by extension", "artifacts.tar"),
+			Entry("detected by magic bytes", "artifacts"),
+		)
+
+		It("should fail if no entry is defined", func() {
+			writeTar("artifacts.tar")
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{"artifacts.tar"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+		})
+
+		It("should fail if the entry does not exist", func() {
+			writeTar("artifacts.tar")
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{"artifacts.tar"},
+				ResourceEntry:                 "refs/missing.yaml",
+			}
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not found"))
+		})
+
+	})
+
+	Context("batch mode", func() {
+
+		writeArchives := func(names ...string) {
+			data, err := vfs.ReadFile(testdataFs, "./00-component/component-descriptor.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			for _, name := range names {
+				Expect(testdataFs.MkdirAll(filepath.Join("components", name), os.ModePerm)).To(Succeed())
+				Expect(vfs.WriteFile(testdataFs, filepath.Join("components", name, ctf.ComponentDescriptorFileName), data, os.ModePerm)).To(Succeed())
+			}
+		}
+
+		readComponentReferences := func(name string) []cdv2.ComponentReference {
+			data, err := vfs.ReadFile(testdataFs, filepath.Join("components", name, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			return cd.ComponentReferences
+		}
+
+		It("should add the references to all component archives of a directory", func() {
+			writeArchives("a", "b", "c", "d", "e")
+			opts := &componentreferences.Options{
+				ArchivesDir: "components",
+				Parallel:    3,
+			}
+			Expect(opts.Complete([]string{"./resources/00-ref.yaml"})).To(Succeed())
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			for _, name := range []string{"a", "b", "c", "d", "e"} {
+				refs := readComponentReferences(name)
+				Expect(refs).To(HaveLen(1))
+				Expect(refs[0].Name).To(Equal("ubuntu"))
+			}
+		})
+
+		It("should write successful archives and aggregate the errors of failed archives", func() {
+			writeArchives("a", "b")
+			Expect(testdataFs.MkdirAll("components/broken", os.ModePerm)).To(Succeed())
+			Expect(vfs.WriteFile(testdataFs, "components/broken/component-descriptor.yaml", []byte("invalid"), os.ModePerm)).To(Succeed())
+
+			opts := &componentreferences.Options{
+				ArchivesDir: "components",
+				Parallel:    2,
+			}
+			Expect(opts.Complete([]string{"./resources/00-ref.yaml"})).To(Succeed())
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("1 of 3"))
+			Expect(err.Error()).To(ContainSubstring("components/broken"))
+
+			Expect(readComponentReferences("a")).To(HaveLen(1))
+			Expect(readComponentReferences("b")).To(HaveLen(1))
+		})
+
+		It("should fail if parallel is less than 1", func() {
+			opts := &componentreferences.Options{
+				ArchivesDir: "components",
+			}
+			Expect(opts.Complete([]string{"./resources/00-ref.yaml"})).ToNot(Succeed())
+		})
+
+	})
+
 })
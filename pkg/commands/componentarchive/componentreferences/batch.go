@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentreferences
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// runBatch adds the component references to all component archives of the archives directory.
+// The archives are modified by a pool of o.Parallel workers. Failed archives do not stop the modification of the other archives,
+// the errors of all failed archives are aggregated.
+func (o *Options) runBatch(log logr.Logger, fs vfs.FileSystem, refs []componentReference, overrides []override) error {
+	archivePaths, err := listComponentArchives(fs, o.ArchivesDir)
+	if err != nil {
+		return err
+	}
+	if len(archivePaths) == 0 {
+		return fmt.Errorf("no component archives found in %q", o.ArchivesDir)
+	}
+
+	var (
+		errs      = make([]error, len(archivePaths))
+		data      = make([][]byte, len(archivePaths))
+		summaries = make([]AddSummary, len(archivePaths))
+		jobs      = make(chan int)
+		wg        sync.WaitGroup
+	)
+	workers := o.Parallel
+	if workers > len(archivePaths) {
+		workers = len(archivePaths)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				builderOpts := o.BuilderOptions
+				builderOpts.ComponentArchivePath = archivePaths[i]
+				// component references are copied as the overrides modify them in place
+				archiveRefs := make([]componentReference, len(refs))
+				for j := range refs {
+					archiveRefs[j].origin = refs[j].origin
+					refs[j].ComponentReference.DeepCopyInto(&archiveRefs[j].ComponentReference)
+				}
+				data[i], summaries[i], errs[i] = o.addComponentReferences(log.WithValues("archive", archivePaths[i]), fs, builderOpts, archiveRefs, overrides)
+			}
+		}()
+	}
+	for i := range archivePaths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// results are reported after all workers finished to keep the output in a stable order.
+	failed := []error{}
+	for i, archivePath := range archivePaths {
+		if errs[i] != nil {
+			log.Info("unable to add component references", "archive", archivePath, "error", errs[i].Error())
+			failed = append(failed, fmt.Errorf("%s: %w", archivePath, errs[i]))
+			continue
+		}
+		if o.DryRun && o.Diff {
+			// the diff already contains the path of the component descriptor
+			if _, err := o.output().Write(data[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		if o.DryRun {
+			if _, err := fmt.Fprintf(o.output(), "---\n# %s\n%s", archivePath, data[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		log.V(1).Info("Successfully added all component references to component descriptor", "archive", archivePath)
+	}
+	if o.OutputFormat == JSONOutput {
+		if err := o.writeJSON(summaries); err != nil {
+			return err
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("unable to add component references to %d of %d component archives: %w", len(failed), len(archivePaths), utilerrors.NewAggregate(failed))
+	}
+	return nil
+}
+
+// listComponentArchives returns the paths of all component archives in the given directory in lexical order.
+// A component archive is a directory that contains a component descriptor.
+func listComponentArchives(fs vfs.FileSystem, dir string) ([]string, error) {
+	infos, err := vfs.ReadDir(fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read archives directory %q: %w", dir, err)
+	}
+	archivePaths := []string{}
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		archivePath := filepath.Join(dir, info.Name())
+		if _, err := fs.Stat(filepath.Join(archivePath, ctf.ComponentDescriptorFileName)); err != nil {
+			continue
+		}
+		archivePaths = append(archivePaths, archivePath)
+	}
+	return archivePaths, nil
+}
@@ -18,5 +18,7 @@ func NewCompRefCommand(ctx context.Context) *cobra.Command {
 		Short:   "command to modify component references of a component descriptor",
 	}
 	cmd.AddCommand(NewAddCommand(ctx))
+	cmd.AddCommand(NewListCommand(ctx))
+	cmd.AddCommand(NewRemoveCommand(ctx))
 	return cmd
 }
@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentreferences
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/ghodss/yaml"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// YAMLOutput prints the component references as yaml.
+const YAMLOutput = "yaml"
+
+// ListOptions defines the options that are used to list the component references of a component descriptor.
+type ListOptions struct {
+	// ComponentArchivePath is the path to the component archive or component descriptor.
+	ComponentArchivePath string
+	// Output defines the output format.
+	Output string
+
+	// Resolve checks whether the referenced components exist in the repository context.
+	Resolve bool
+	// BaseUrl is the oci registry the component references are resolved against.
+	// Defaults to the effective repository context of the component descriptor.
+	BaseUrl string
+	// ComponentNameMapping is the component name mapping of the oci registry.
+	ComponentNameMapping string
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+	// ComponentResolver is used to resolve the referenced components.
+	// A resolver for the oci registry is created if not set.
+	ComponentResolver ctf.ComponentResolver
+}
+
+// ListEntry describes a component reference of a component descriptor.
+type ListEntry struct {
+	Name          string `json:"name"`
+	ComponentName string `json:"componentName"`
+	Version       string `json:"version"`
+	// Exists defines whether the referenced component could be resolved.
+	// It is only set if the component references are resolved.
+	Exists *bool `json:"exists,omitempty"`
+	// Error is the reason why the referenced component could not be resolved.
+	Error string `json:"error,omitempty"`
+}
+
+// NewListCommand creates a new command to list the component references of a component descriptor.
+func NewListCommand(ctx context.Context) *cobra.Command {
+	opts := &ListOptions{}
+	cmd := &cobra.Command{
+		Use:   "list COMPONENT_ARCHIVE_PATH [--resolve [--repo-ctx BASE_URL]]",
+		Args:  cobra.ExactArgs(1),
+		Short: "Lists the component references of a component descriptor",
+		Long: `
+Lists the name, component name and version of all component references of a component descriptor in the order they are defined.
+
+The path can point to a component archive (directory, tar or tgz) or to a component descriptor file (.yaml, .yml or .json).
+
+With "--resolve" every referenced component is resolved against the effective repository context of the component descriptor
+or the oci registry defined by "--repo-ctx" and the output shows whether the referenced component exists.
+The command fails if a referenced component cannot be resolved.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New(), os.Stdout); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run lists the component references of the component descriptor and writes them to the given writer.
+func (o *ListOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem, w io.Writer) error {
+	cd, err := componentarchive.ReadComponentDescriptor(fs, o.ComponentArchivePath)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]ListEntry, 0, len(cd.ComponentReferences))
+	for _, ref := range cd.ComponentReferences {
+		entries = append(entries, ListEntry{
+			Name:          ref.GetName(),
+			ComponentName: ref.ComponentName,
+			Version:       ref.GetVersion(),
+		})
+	}
+
+	missing := 0
+	if o.Resolve {
+		repoCtx, err := o.repositoryContext(cd)
+		if err != nil {
+			return err
+		}
+		resolver, err := o.resolver(log, fs)
+		if err != nil {
+			return err
+		}
+		for i := range entries {
+			exists := true
+			if _, err := resolver.Resolve(ctx, repoCtx, entries[i].ComponentName, entries[i].Version); err != nil {
+				log.V(3).Info("unable to resolve component reference", "name", entries[i].Name, "error", err.Error())
+				exists = false
+				entries[i].Error = err.Error()
+				missing++
+			}
+			entries[i].Exists = &exists
+		}
+	}
+
+	if err := o.write(w, entries); err != nil {
+		return err
+	}
+	if missing != 0 {
+		return fmt.Errorf("%d of %d referenced components could not be resolved", missing, len(entries))
+	}
+	return nil
+}
+
+func (o *ListOptions) write(w io.Writer, entries []ListEntry) error {
+	switch o.Output {
+	case JSONOutput:
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal component references: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case YAMLOutput:
+		data, err := yaml.Marshal(entries)
+		if err != nil {
+			return fmt.Errorf("unable to marshal component references: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		header := "NAME\tCOMPONENT\tVERSION"
+		if o.Resolve {
+			header += "\tEXISTS"
+		}
+		if _, err := fmt.Fprintln(tw, header); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			line := fmt.Sprintf("%s\t%s\t%s", entry.Name, entry.ComponentName, entry.Version)
+			if entry.Exists != nil {
+				line += "\t" + strconv.FormatBool(*entry.Exists)
+			}
+			if _, err := fmt.Fprintln(tw, line); err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	}
+}
+
+// repositoryContext returns the repository context the component references are resolved against.
+func (o *ListOptions) repositoryContext(cd *cdv2.ComponentDescriptor) (*cdv2.UnstructuredTypedObject, error) {
+	if len(o.BaseUrl) != 0 {
+		repoCtx, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryRepository(o.BaseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping)))
+		if err != nil {
+			return nil, fmt.Errorf("unable to create repository context: %w", err)
+		}
+		return &repoCtx, nil
+	}
+	repoCtx := cd.GetEffectiveRepositoryContext()
+	if repoCtx == nil {
+		return nil, errors.New("the component descriptor has no repository context, define one with --repo-ctx")
+	}
+	return repoCtx, nil
+}
+
+func (o *ListOptions) resolver(log logr.Logger, fs vfs.FileSystem) (ctf.ComponentResolver, error) {
+	if o.ComponentResolver != nil {
+		return o.ComponentResolver, nil
+	}
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	return cdoci.NewResolver(ociClient), nil
+}
+
+func (o *ListOptions) Complete(args []string) error {
+	if len(args) != 1 {
+		return errors.New("a component archive path argument has to be defined")
+	}
+	o.ComponentArchivePath = args[0]
+
+	if o.Resolve && o.ComponentResolver == nil {
+		cliHomeDir, err := constants.CliHomeDir()
+		if err != nil {
+			return err
+		}
+		o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+		if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
+		}
+	}
+	return o.Validate()
+}
+
+// Validate validates the list options.
+func (o *ListOptions) Validate() error {
+	if len(o.ComponentArchivePath) == 0 {
+		return errors.New("a component archive path must be provided")
+	}
+	if o.Output != TextOutput && o.Output != JSONOutput && o.Output != YAMLOutput {
+		return fmt.Errorf("unsupported output format %q, expected one of %q, %q, %q", o.Output, TextOutput, JSONOutput, YAMLOutput)
+	}
+	if len(o.BaseUrl) != 0 && !o.Resolve {
+		return errors.New("--repo-ctx can only be used with --resolve")
+	}
+	return nil
+}
+
+func (o *ListOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.Output, "output", "o", TextOutput, fmt.Sprintf("output format. One of %q, %q, %q", TextOutput, JSONOutput, YAMLOutput))
+	fs.BoolVar(&o.Resolve, "resolve", false, "[OPTIONAL] checks whether the referenced components exist in the repository context")
+	fs.StringVar(&o.BaseUrl, "repo-ctx", "", "[OPTIONAL] base url of the oci registry the component references are resolved against. Defaults to the effective repository context of the component descriptor")
+	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] repository context name mapping")
+	o.OciOptions.AddFlags(fs)
+}
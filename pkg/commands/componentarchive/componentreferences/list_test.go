@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentreferences_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/componentreferences"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/testutils"
+)
+
+// fakeResolver resolves only the components it knows and records the requested repository contexts.
+type fakeResolver struct {
+	components map[string]bool
+	repoCtxs   []cdv2.Repository
+}
+
+func (r *fakeResolver) Resolve(_ context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	r.repoCtxs = append(r.repoCtxs, repoCtx)
+	if !r.components[name+":"+version] {
+		return nil, ctf.NotFoundError
+	}
+	cd := &cdv2.ComponentDescriptor{}
+	cd.Name = name
+	cd.Version = version
+	return cd, nil
+}
+
+func (r *fakeResolver) ResolveWithBlobResolver(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	cd, err := r.Resolve(ctx, repoCtx, name, version)
+	return cd, nil, err
+}
+
+var _ = Describe("List", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
+
+		addOpts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"./resources/01-multi-doc.yaml"},
+		}
+		Expect(addOpts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+	})
+
+	run := func(opts *componentreferences.ListOptions, w io.Writer) error {
+		if err := opts.Complete([]string{"./00-component"}); err != nil {
+			return err
+		}
+		return opts.Run(context.TODO(), logr.Discard(), testdataFs, w)
+	}
+
+	It("should list all component references as table", func() {
+		var buf bytes.Buffer
+		Expect(run(&componentreferences.ListOptions{Output: componentreferences.TextOutput}, &buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`NAME    COMPONENT                   VERSION
+ubuntu  github.com/gardener/ubuntu  v0.0.1
+myref   github.com/gardener/other   v0.0.2
+`))
+	})
+
+	It("should list all component references as json", func() {
+		var buf bytes.Buffer
+		Expect(run(&componentreferences.ListOptions{Output: componentreferences.JSONOutput}, &buf)).To(Succeed())
+		entries := []componentreferences.ListEntry{}
+		Expect(json.Unmarshal(buf.Bytes(), &entries)).To(Succeed())
+		Expect(entries).To(Equal([]componentreferences.ListEntry{
+			{Name: "ubuntu", ComponentName: "github.com/gardener/ubuntu", Version: "v0.0.1"},
+			{Name: "myref", ComponentName: "github.com/gardener/other", Version: "v0.0.2"},
+		}))
+	})
+
+	It("should resolve the component references against the repository context of the component descriptor", func() {
+		resolver := &fakeResolver{components: map[string]bool{
+			"github.com/gardener/ubuntu:v0.0.1": true,
+			"github.com/gardener/other:v0.0.2":  true,
+		}}
+		var buf bytes.Buffer
+		Expect(run(&componentreferences.ListOptions{
+			Output:            componentreferences.TextOutput,
+			Resolve:           true,
+			ComponentResolver: resolver,
+		}, &buf)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring("EXISTS"))
+		Expect(buf.String()).To(ContainSubstring("v0.0.1   true"))
+
+		Expect(resolver.repoCtxs).To(HaveLen(2))
+		repoCtx, ok := resolver.repoCtxs[0].(*cdv2.UnstructuredTypedObject)
+		Expect(ok).To(BeTrue())
+		Expect(repoCtx.Object["baseUrl"]).To(Equal("eu.gcr.io/gardener-project/components/dev"))
+	})
+
+	It("should report component references that cannot be resolved", func() {
+		resolver := &fakeResolver{components: map[string]bool{
+			"github.com/gardener/ubuntu:v0.0.1": true,
+		}}
+		var buf bytes.Buffer
+		err := run(&componentreferences.ListOptions{
+			Output:            componentreferences.JSONOutput,
+			Resolve:           true,
+			BaseUrl:           "example.com/components",
+			ComponentResolver: resolver,
+		}, &buf)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("1 of 2 referenced components could not be resolved"))
+
+		entries := []componentreferences.ListEntry{}
+		Expect(json.Unmarshal(buf.Bytes(), &entries)).To(Succeed())
+		Expect(entries).To(HaveLen(2))
+		Expect(*entries[0].Exists).To(BeTrue())
+		Expect(*entries[1].Exists).To(BeFalse())
+		Expect(entries[1].Error).ToNot(BeEmpty())
+
+		repoCtx, ok := resolver.repoCtxs[0].(*cdv2.UnstructuredTypedObject)
+		Expect(ok).To(BeTrue())
+		Expect(repoCtx.Object["baseUrl"]).To(Equal("example.com/components"))
+	})
+
+	It("should fail if a repository context is defined without resolving", func() {
+		Expect(run(&componentreferences.ListOptions{
+			Output:  componentreferences.TextOutput,
+			BaseUrl: "example.com/components",
+		}, io.Discard)).ToNot(Succeed())
+	})
+
+	It("should fail for an unknown output format", func() {
+		Expect(run(&componentreferences.ListOptions{Output: "xml"}, io.Discard)).ToNot(Succeed())
+	})
+
+})
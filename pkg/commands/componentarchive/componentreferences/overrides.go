@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentreferences
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+)
+
+// override describes a single dotted-path override of the form "path=value".
+type override struct {
+	Path  []string
+	Value string
+}
+
+// parseOverrides parses overrides of the form "path=value".
+func parseOverrides(overrides []string) ([]override, error) {
+	parsed := make([]override, 0, len(overrides))
+	for _, o := range overrides {
+		i := strings.Index(o, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid override %q: expected the form path=value", o)
+		}
+		path := strings.Split(o[:i], ".")
+		for _, segment := range path {
+			if len(segment) == 0 {
+				return nil, fmt.Errorf("invalid override %q: path must not contain empty segments", o)
+			}
+		}
+		parsed = append(parsed, override{
+			Path:  path,
+			Value: o[i+1:],
+		})
+	}
+	return parsed, nil
+}
+
+// applyOverrides applies the given overrides to the component reference.
+// The overrides are applied in order so that a later override wins over a former one.
+func applyOverrides(ref *cdv2.ComponentReference, overrides []override) error {
+	for _, o := range overrides {
+		if err := applyOverride(ref, o); err != nil {
+			return fmt.Errorf("unable to apply override %q: %w", strings.Join(o.Path, "."), err)
+		}
+	}
+	return nil
+}
+
+func applyOverride(ref *cdv2.ComponentReference, o override) error {
+	switch o.Path[0] {
+	case "name", "componentName", "version":
+		if len(o.Path) != 1 {
+			return fmt.Errorf("%q does not have any subfields", o.Path[0])
+		}
+		switch o.Path[0] {
+		case "name":
+			ref.Name = o.Value
+		case "componentName":
+			ref.ComponentName = o.Value
+		case "version":
+			ref.Version = o.Value
+		}
+	case "extraIdentity":
+		if len(o.Path) != 2 {
+			return fmt.Errorf("expected the form extraIdentity.<key>")
+		}
+		if ref.ExtraIdentity == nil {
+			ref.ExtraIdentity = cdv2.Identity{}
+		}
+		ref.ExtraIdentity[o.Path[1]] = o.Value
+	case "labels":
+		// label names commonly contain dots so everything after "labels." is the label name.
+		if len(o.Path) < 2 {
+			return fmt.Errorf("expected the form labels.<name>")
+		}
+		value, err := json.Marshal(coerceValue(o.Value))
+		if err != nil {
+			return fmt.Errorf("unable to encode label value: %w", err)
+		}
+		setLabel(&ref.Labels, cdv2.Label{
+			Name:  strings.Join(o.Path[1:], "."),
+			Value: value,
+		})
+	default:
+		return fmt.Errorf("unknown field %q", o.Path[0])
+	}
+	return nil
+}
+
+// setLabel replaces the label with the same name or appends it if no such label exists.
+func setLabel(labels *cdv2.Labels, label cdv2.Label) {
+	for i, l := range *labels {
+		if l.Name == label.Name {
+			(*labels)[i] = label
+			return
+		}
+	}
+	*labels = append(*labels, label)
+}
+
+// coerceValue converts the given string into a bool or number if possible.
+// All other values are returned as string.
+func coerceValue(value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
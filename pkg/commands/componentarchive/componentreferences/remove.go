@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentreferences
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/ghodss/yaml"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// RemoveOptions defines the options that are used to remove component references from a component descriptor
+type RemoveOptions struct {
+	// ComponentArchivePath defines the path to the component archive.
+	ComponentArchivePath string
+	// Names defines the names of the component references that are removed.
+	Names []string
+	// Version optionally defines the version the removed component references must have.
+	Version string
+	// Backup keeps the previous component descriptor as component-descriptor.yaml.bak.
+	Backup bool
+}
+
+// NewRemoveCommand creates a command to remove component references from a component descriptor.
+func NewRemoveCommand(ctx context.Context) *cobra.Command {
+	opts := &RemoveOptions{}
+	cmd := &cobra.Command{
+		Use:   "remove COMPONENT_ARCHIVE_PATH --name NAME [--name NAME...] [--version VERSION]",
+		Args:  cobra.ExactArgs(1),
+		Short: "Removes component references from a component descriptor",
+		Long: `
+removes component references from the defined component descriptor.
+The component references are selected by their name. If a version is given, only component references with that version are removed.
+The version has to be given if multiple component references with the same name and different extra identities exist.
+
+The command fails without modifying the component descriptor if any of the selected component references does not exist.
+The component archive can be a directory or a tar or tgz file, which is written back in its original format.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *RemoveOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	fs, repack, err := componentarchive.Unpack(fs, o.ComponentArchivePath)
+	if err != nil {
+		return err
+	}
+
+	archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
+	if err != nil {
+		return fmt.Errorf("unable to create projectionfilesystem: %w", err)
+	}
+	archive, err := ctf.NewComponentArchiveFromFilesystem(archiveFs, codec.DisableValidation(true))
+	if err != nil {
+		return fmt.Errorf("unable to parse component archive from %s: %w", o.ComponentArchivePath, err)
+	}
+	cd := archive.ComponentDescriptor
+
+	for _, name := range o.Names {
+		id, err := o.componentReferenceIndex(cd, name)
+		if err != nil {
+			return err
+		}
+		cd.ComponentReferences = append(cd.ComponentReferences[:id], cd.ComponentReferences[id+1:]...)
+		log.V(3).Info(fmt.Sprintf("Successfully removed component reference %q from component descriptor", name))
+	}
+
+	if err := cdvalidation.Validate(cd); err != nil {
+		return fmt.Errorf("invalid component descriptor: %w", err)
+	}
+
+	data, err := yaml.Marshal(cd)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	if err := componentarchive.WriteComponentDescriptor(fs, o.ComponentArchivePath, data, o.Backup); err != nil {
+		return fmt.Errorf("unable to write modified comonent descriptor: %w", err)
+	}
+	log.V(1).Info("Successfully removed all component references from component descriptor")
+	return repack()
+}
+
+// componentReferenceIndex returns the index of the component reference with the given name and the optional version.
+// Component references are matched independent of their extra identity, so the match has to be unique.
+func (o *RemoveOptions) componentReferenceIndex(cd *cdv2.ComponentDescriptor, name string) (int, error) {
+	matches := []int{}
+	for i, ref := range cd.ComponentReferences {
+		if ref.Name == name && (len(o.Version) == 0 || ref.Version == o.Version) {
+			matches = append(matches, i)
+		}
+	}
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) == 0 && len(o.Version) != 0:
+		return -1, fmt.Errorf("component reference %q with version %q not found", name, o.Version)
+	case len(matches) == 0:
+		return -1, fmt.Errorf("component reference %q not found", name)
+	case len(o.Version) != 0:
+		return -1, fmt.Errorf("%d component references %q with version %q found", len(matches), name, o.Version)
+	default:
+		return -1, fmt.Errorf("%d component references %q found, define the version of the component reference that is removed", len(matches), name)
+	}
+}
+
+func (o *RemoveOptions) Complete(args []string) error {
+	if len(args) != 1 {
+		return errors.New("a component archive path argument has to be defined")
+	}
+	o.ComponentArchivePath = args[0]
+	return o.validate()
+}
+
+func (o *RemoveOptions) validate() error {
+	if len(o.ComponentArchivePath) == 0 {
+		return errors.New("a component archive path must be provided")
+	}
+	if len(o.Names) == 0 {
+		return errors.New("at least one component reference name must be provided")
+	}
+	return nil
+}
+
+func (o *RemoveOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringSliceVar(&o.Names, "name", []string{}, "name of the component reference that is removed. Can be repeated or comma separated to remove multiple component references")
+	fs.StringVar(&o.Version, "version", "", "[OPTIONAL] version the removed component references must have")
+	fs.BoolVar(&o.Backup, "backup", false, "[OPTIONAL] keeps the previous component descriptor as component-descriptor.yaml.bak if the component archive is a directory")
+}
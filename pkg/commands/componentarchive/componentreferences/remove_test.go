@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentreferences_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/componentreferences"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/testutils"
+)
+
+var _ = Describe("Remove", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
+
+		addOpts := &componentreferences.Options{
+			BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ComponentReferenceObjectPaths: []string{"./resources/01-multi-doc.yaml"},
+		}
+		Expect(addOpts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+	})
+
+	readComponentReferences := func() []cdv2.ComponentReference {
+		data, err := vfs.ReadFile(testdataFs, filepath.Join("./00-component", ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		return cd.ComponentReferences
+	}
+
+	It("should remove a component reference", func() {
+		opts := &componentreferences.RemoveOptions{
+			Names: []string{"ubuntu"},
+		}
+		Expect(opts.Complete([]string{"./00-component"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		refs := readComponentReferences()
+		Expect(refs).To(HaveLen(1))
+		Expect(refs[0].Name).To(Equal("myref"))
+	})
+
+	It("should remove multiple component references", func() {
+		opts := &componentreferences.RemoveOptions{
+			ComponentArchivePath: "./00-component",
+			Names:                []string{"ubuntu", "myref"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		Expect(readComponentReferences()).To(BeEmpty())
+	})
+
+	It("should remove a component reference with a matching version", func() {
+		opts := &componentreferences.RemoveOptions{
+			ComponentArchivePath: "./00-component",
+			Names:                []string{"myref"},
+			Version:              "v0.0.2",
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		Expect(readComponentReferences()).To(HaveLen(1))
+	})
+
+	It("should fail without modifying the component descriptor if a component reference does not exist", func() {
+		opts := &componentreferences.RemoveOptions{
+			ComponentArchivePath: "./00-component",
+			Names:                []string{"ubuntu", "unknown"},
+		}
+		err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`"unknown" not found`))
+		Expect(readComponentReferences()).To(HaveLen(2))
+	})
+
+	It("should fail if the version does not match", func() {
+		opts := &componentreferences.RemoveOptions{
+			ComponentArchivePath: "./00-component",
+			Names:                []string{"ubuntu"},
+			Version:              "v0.0.2",
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+		Expect(readComponentReferences()).To(HaveLen(2))
+	})
+
+	Context("extra identity", func() {
+
+		BeforeEach(func() {
+			Expect(vfs.WriteFile(testdataFs, "./resources/03-extra-identity.yaml", []byte(`---
+name: 'platform'
+componentName: 'github.com/gardener/platform'
+version: 'v1.0.0'
+extraIdentity:
+  platform: linux
+---
+name: 'platform'
+componentName: 'github.com/gardener/platform'
+version: 'v2.0.0'
+extraIdentity:
+  platform: windows
+`), os.ModePerm)).To(Succeed())
+			addOpts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{"./resources/03-extra-identity.yaml"},
+			}
+			Expect(addOpts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+			Expect(readComponentReferences()).To(HaveLen(4))
+		})
+
+		It("should remove a component reference with an extra identity by its name and version", func() {
+			opts := &componentreferences.RemoveOptions{
+				ComponentArchivePath: "./00-component",
+				Names:                []string{"platform"},
+				Version:              "v2.0.0",
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			refs := readComponentReferences()
+			Expect(refs).To(HaveLen(3))
+			Expect(refs[2].Name).To(Equal("platform"))
+			Expect(refs[2].ExtraIdentity).To(Equal(cdv2.Identity{"platform": "linux"}))
+
+			opts.Version = ""
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+			Expect(readComponentReferences()).To(HaveLen(2))
+		})
+
+		It("should fail if multiple component references match the name", func() {
+			opts := &componentreferences.RemoveOptions{
+				ComponentArchivePath: "./00-component",
+				Names:                []string{"platform"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(MatchError(
+				`2 component references "platform" found, define the version of the component reference that is removed`))
+			Expect(readComponentReferences()).To(HaveLen(4))
+		})
+
+	})
+
+	It("should fail if no name is defined", func() {
+		opts := &componentreferences.RemoveOptions{}
+		Expect(opts.Complete([]string{"./00-component"})).ToNot(Succeed())
+	})
+
+})
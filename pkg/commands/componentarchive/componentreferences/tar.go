@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentreferences
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// tarMagicOffset is the offset of the magic bytes in a tar header.
+const tarMagicOffset = 257
+
+// isTarArchive checks whether the file at the given path is a tar archive.
+// The file is detected by its ".tar" extension or by the magic bytes of the tar header.
+func isTarArchive(fs vfs.FileSystem, filePath string) (bool, error) {
+	if strings.HasSuffix(filePath, ".tar") {
+		return true, nil
+	}
+	file, err := fs.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header := make([]byte, tarMagicOffset+5)
+	if _, err := io.ReadFull(file, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return bytes.Equal(header[tarMagicOffset:], []byte("ustar")), nil
+}
+
+// generateComponentReferencesFromTar reads the component references from the configured entry of a tar archive.
+func (o *Options) generateComponentReferencesFromTar(fs vfs.FileSystem, tarPath string) ([]componentReference, error) {
+	if len(o.ResourceEntry) == 0 {
+		return nil, fmt.Errorf("%q is a tar archive but no entry is defined. Use --resource-entry to select the entry that contains the component references", tarPath)
+	}
+	file, err := fs.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entryName := path.Clean(strings.TrimPrefix(o.ResourceEntry, "/"))
+	tr := tar.NewReader(file)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("entry %q not found in tar archive", o.ResourceEntry)
+			}
+			return nil, fmt.Errorf("unable to read tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || path.Clean(header.Name) != entryName {
+			continue
+		}
+		return o.generateComponentReferenceFromReader(tr, fmt.Sprintf("%s:%s", tarPath, header.Name))
+	}
+}
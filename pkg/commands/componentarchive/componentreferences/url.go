@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentreferences
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// isHTTPURL checks whether the given path is a http or https url.
+func isHTTPURL(path string) bool {
+	u, err := url.Parse(path)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) != 0
+}
+
+// generateComponentReferencesFromURL fetches the component references from the given http(s) url.
+func (o *Options) generateComponentReferencesFromURL(u string) ([]componentReference, error) {
+	client := o.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: o.HTTPTimeout}
+	}
+
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch %q: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch %q: unexpected status code %d (%s)", u, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return o.generateComponentReferenceFromReader(resp.Body, u)
+}
@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/ghodss/yaml"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// ConvertOptions defines the options that are used to convert the schema version of a component descriptor.
+type ConvertOptions struct {
+	// ComponentArchivePath defines the path to the component archive or component descriptor.
+	ComponentArchivePath string
+	// SchemaVersion is the schema version the component descriptor is converted to.
+	SchemaVersion string
+	// InPlace writes the converted component descriptor back instead of printing it.
+	InPlace bool
+	// Backup keeps the previous component descriptor as component-descriptor.yaml.bak if the component archive is converted in place.
+	Backup bool
+}
+
+// NewConvertCommand creates a new command to convert the schema version of a component descriptor.
+func NewConvertCommand(ctx context.Context) *cobra.Command {
+	opts := &ConvertOptions{}
+	cmd := &cobra.Command{
+		Use:   "convert COMPONENT_ARCHIVE_PATH [--schema-version v3alpha1|v2] [--in-place]",
+		Args:  cobra.ExactArgs(1),
+		Short: "Converts a component descriptor between the schema versions v2 and ocm.software/v3alpha1",
+		Long: `
+Converts the component descriptor of a component archive between the schema version v2 and the OCM schema version ocm.software/v3alpha1,
+so that component archives can be used with OCM tooling and vice versa.
+The schema version of the component descriptor is detected automatically.
+
+The path can point to a component archive directory or to a component descriptor file (.yaml, .yml or .json).
+The converted component descriptor is printed to stdout. With "--in-place" it replaces the component descriptor instead.
+
+The access types are translated as follows:
+- ociRegistry <-> ociArtifact
+- localFilesystemBlob <-> localBlob (the "filename" is the "localReference")
+- localOciBlob -> localBlob (the "digest" is the "localReference")
+- github <-> gitHub
+All other access types are kept. Repository contexts of type "ociRegistry" are translated to "OCIRegistry" and vice versa.
+
+The conversion fails if the component descriptor contains information that cannot be represented in the target schema version,
+e.g. labels of the provider in v2.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, osfs.New(), os.Stdout); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run converts the component descriptor and writes it to the given writer or back to the component archive.
+func (o *ConvertOptions) Run(_ context.Context, fs vfs.FileSystem, w io.Writer) error {
+	compDescFilePath, isArchive, err := o.componentDescriptorPath(fs)
+	if err != nil {
+		return err
+	}
+	data, err := vfs.ReadFile(fs, compDescFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to read component descriptor from %q: %w", compDescFilePath, err)
+	}
+	converted, err := componentarchive.ConvertComponentDescriptor(data, o.SchemaVersion)
+	if err != nil {
+		return fmt.Errorf("unable to convert component descriptor %q: %w", compDescFilePath, err)
+	}
+	if filepath.Ext(compDescFilePath) == ".json" {
+		converted, err = yamlToIndentedJSON(converted)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !o.InPlace {
+		_, err := w.Write(converted)
+		return err
+	}
+	if isArchive {
+		return componentarchive.WriteComponentDescriptor(fs, o.ComponentArchivePath, converted, o.Backup)
+	}
+	info, err := fs.Stat(compDescFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to read component descriptor from %q: %w", compDescFilePath, err)
+	}
+	if err := utils.WriteFileAtomic(fs, compDescFilePath, converted, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("unable to write component descriptor to %q: %w", compDescFilePath, err)
+	}
+	return nil
+}
+
+// componentDescriptorPath returns the path to the component descriptor file
+// and whether it is part of a component archive directory.
+func (o *ConvertOptions) componentDescriptorPath(fs vfs.FileSystem) (string, bool, error) {
+	switch filepath.Ext(o.ComponentArchivePath) {
+	case ".yaml", ".yml", ".json":
+		return o.ComponentArchivePath, false, nil
+	}
+	info, err := fs.Stat(o.ComponentArchivePath)
+	if err != nil {
+		return "", false, fmt.Errorf("unable to read component archive at %q: %w", o.ComponentArchivePath, err)
+	}
+	if !info.IsDir() {
+		return "", false, fmt.Errorf("%q is not a component archive directory, only component archives in filesystem format can be converted", o.ComponentArchivePath)
+	}
+	return filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName), true, nil
+}
+
+func yamlToIndentedJSON(data []byte) ([]byte, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode component descriptor as json: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return nil, fmt.Errorf("unable to encode component descriptor as json: %w", err)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+func (o *ConvertOptions) Complete(args []string) error {
+	if len(args) != 1 {
+		return errors.New("a component archive path argument has to be defined")
+	}
+	o.ComponentArchivePath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the convert options.
+func (o *ConvertOptions) Validate() error {
+	if len(o.ComponentArchivePath) == 0 {
+		return errors.New("a component archive path must be provided")
+	}
+	if err := componentarchive.ValidateSchemaVersion(o.SchemaVersion); err != nil {
+		return err
+	}
+	if o.Backup && !o.InPlace {
+		return errors.New("--backup can only be used with --in-place")
+	}
+	return nil
+}
+
+func (o *ConvertOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.SchemaVersion, "schema-version", componentarchive.SchemaVersionV3Alpha1,
+		fmt.Sprintf("schema version the component descriptor is converted to. One of %q, %q", componentarchive.SchemaVersionV3Alpha1, componentarchive.SchemaVersionV2))
+	fs.BoolVar(&o.InPlace, "in-place", false, "[OPTIONAL] replaces the component descriptor with the converted one instead of printing it")
+	fs.BoolVar(&o.Backup, "backup", false, "[OPTIONAL] keeps the previous component descriptor as component-descriptor.yaml.bak if a component archive directory is converted in place")
+}
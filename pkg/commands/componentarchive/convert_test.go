@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/ghodss/yaml"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	ca "github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/testutils"
+)
+
+var _ = Describe("Convert", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testutils.NewTestdataFileSystem("./testdata/convert")
+	})
+
+	// readYAML decodes yaml or json, so that documents can be compared independent of their formatting.
+	readYAML := func(data []byte) interface{} {
+		var obj interface{}
+		Expect(yaml.Unmarshal(data, &obj)).To(Succeed())
+		return obj
+	}
+
+	readFixture := func(version string) interface{} {
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(version, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		return readYAML(data)
+	}
+
+	convert := func(path, schemaVersion string) []byte {
+		opts := &componentarchive.ConvertOptions{SchemaVersion: schemaVersion}
+		Expect(opts.Complete([]string{path})).To(Succeed())
+		var out bytes.Buffer
+		Expect(opts.Run(context.TODO(), testdataFs, &out)).To(Succeed())
+		return out.Bytes()
+	}
+
+	It("should convert a component descriptor v2 to v3alpha1", func() {
+		Expect(readYAML(convert("/v2", ca.SchemaVersionV3Alpha1))).To(Equal(readFixture("v3alpha1")))
+	})
+
+	It("should convert a component descriptor v3alpha1 to v2", func() {
+		Expect(readYAML(convert("/v3alpha1", ca.SchemaVersionV2))).To(Equal(readFixture("v2")))
+	})
+
+	It("should keep a component descriptor that already has the schema version", func() {
+		Expect(readYAML(convert("/v3alpha1", ca.SchemaVersionV3Alpha1))).To(Equal(readFixture("v3alpha1")))
+	})
+
+	It("should convert a component archive in place and keep a backup", func() {
+		opts := &componentarchive.ConvertOptions{SchemaVersion: ca.SchemaVersionV3Alpha1, InPlace: true, Backup: true}
+		Expect(opts.Complete([]string{"/v2"})).To(Succeed())
+		var out bytes.Buffer
+		Expect(opts.Run(context.TODO(), testdataFs, &out)).To(Succeed())
+		Expect(out.Len()).To(BeZero())
+
+		Expect(readFixture("v2")).To(Equal(readFixture("v3alpha1")))
+		data, err := vfs.ReadFile(testdataFs, filepath.Join("/v2", ca.ComponentDescriptorBackupFileName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readYAML(data)).To(HaveKey("meta"))
+	})
+
+	It("should convert a json component descriptor file to json", func() {
+		data, err := vfs.ReadFile(testdataFs, filepath.Join("/v2", ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		data, err = yaml.YAMLToJSON(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testdataFs, "/cd.json", data, 0644)).To(Succeed())
+
+		out := convert("/cd.json", ca.SchemaVersionV3Alpha1)
+		Expect(json.Valid(out)).To(BeTrue())
+		Expect(readYAML(out)).To(Equal(readFixture("v3alpha1")))
+	})
+
+	It("should fail if the provider has labels that cannot be represented in v2", func() {
+		data, err := vfs.ReadFile(testdataFs, filepath.Join("/v3alpha1", ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		data = bytes.Replace(data, []byte("    name: internal\n"), []byte("    name: internal\n    labels:\n    - name: team\n      value: infra\n"), 1)
+		Expect(vfs.WriteFile(testdataFs, "/cd.yaml", data, 0644)).To(Succeed())
+
+		opts := &componentarchive.ConvertOptions{SchemaVersion: ca.SchemaVersionV2}
+		Expect(opts.Complete([]string{"/cd.yaml"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), testdataFs, &bytes.Buffer{})).To(MatchError(ContainSubstring("labels of the provider cannot be converted")))
+	})
+
+	It("should reject an unknown schema version", func() {
+		opts := &componentarchive.ConvertOptions{SchemaVersion: "v4"}
+		Expect(opts.Complete([]string{"/v2"})).To(MatchError(ContainSubstring(`unsupported schema version "v4"`)))
+	})
+
+})
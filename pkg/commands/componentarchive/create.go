@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
@@ -33,7 +34,9 @@ func NewCreateCommand(ctx context.Context) *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Short: "Creates a component archive with a component descriptor",
 		Long: `
-Create command creates a new component archive directory with a "component-descriptor.yaml" file.
+Create command creates a new component archive directory with a minimal "component-descriptor.yaml" file
+and an empty "blobs" directory, so that resources, sources and component references can be added
+with the respective add commands.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
@@ -85,4 +88,5 @@ func (o *CreateOptions) validate() error {
 func (o *CreateOptions) AddFlags(fs *pflag.FlagSet) {
 	o.BuilderOptions.AddFlags(fs)
 	fs.BoolVarP(&o.BuilderOptions.Overwrite, "overwrite", "w", false, "overwrites the existing component")
+	fs.StringVar(&o.BuilderOptions.Provider, "provider", string(cdv2.InternalProvider), "provider type of the component. Can be \"internal\" or \"external\"")
 }
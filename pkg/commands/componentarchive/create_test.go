@@ -10,10 +10,6 @@ import (
 	"path/filepath"
 
 	"github.com/go-logr/logr"
-	"github.com/mandelsoft/vfs/pkg/layerfs"
-	"github.com/mandelsoft/vfs/pkg/memoryfs"
-	"github.com/mandelsoft/vfs/pkg/osfs"
-	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -23,6 +19,7 @@ import (
 	"github.com/gardener/component-spec/bindings-go/ctf"
 
 	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	"github.com/gardener/component-cli/pkg/testutils"
 )
 
 var _ = Describe("Create", func() {
@@ -30,9 +27,7 @@ var _ = Describe("Create", func() {
 	var testdataFs vfs.FileSystem
 
 	BeforeEach(func() {
-		baseFs, err := projectionfs.New(osfs.New(), "./testdata")
-		Expect(err).ToNot(HaveOccurred())
-		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
 	})
 
 	Context("Create", func() {
@@ -63,6 +58,38 @@ var _ = Describe("Create", func() {
 			Expect(ociRepoCtx.BaseURL).To(Equal(opts.BaseUrl))
 		})
 
+		It("should create a component archive with the given provider and an empty blobs directory", func() {
+			opts := &componentarchive.CreateOptions{}
+			opts.Name = "example.com/component/name"
+			opts.Version = "v0.0.1"
+			opts.Provider = string(cdv2.ExternalProvider)
+			opts.ComponentArchivePath = "./create-provider-test"
+			Expect(testdataFs.Mkdir(opts.ComponentArchivePath, os.ModePerm)).To(Succeed())
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed(), "Should create a component archive")
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Provider).To(Equal(cdv2.ExternalProvider))
+			Expect(cd.RepositoryContexts).To(BeEmpty())
+
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(BeEmpty())
+		})
+
+		It("should fail if the provider type is unknown", func() {
+			opts := &componentarchive.CreateOptions{}
+			opts.Name = "example.com/component/name"
+			opts.Version = "v0.0.1"
+			opts.Provider = "somebody"
+			opts.ComponentArchivePath = "./create-invalid-provider-test"
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(MatchError(ContainSubstring(`unknown provider type "somebody"`)))
+		})
+
 	})
 
 	Context("Overwrite", func() {
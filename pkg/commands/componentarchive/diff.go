@@ -0,0 +1,214 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// YAMLOutput prints the diff as yaml.
+const YAMLOutput = "yaml"
+
+// DiffOptions defines the options that are used to compare two component descriptors.
+type DiffOptions struct {
+	// OldPath is the path to the old component archive or component descriptor.
+	// It is empty if the new component archive is compared against a remote component.
+	OldPath string
+	// NewPath is the path to the new component archive or component descriptor.
+	NewPath string
+	// Output defines the output format.
+	Output string
+
+	// BaseUrl is the oci registry of the remote component the component archive is compared against.
+	BaseUrl string
+	// ComponentNameMapping is the component name mapping of the oci registry.
+	ComponentNameMapping string
+	// Version is the version of the remote component.
+	// Defaults to the version of the component archive.
+	Version string
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// ComponentDiff describes the differences between two component descriptors.
+type ComponentDiff = componentarchive.ComponentDiff
+
+// ElementDiff describes the resources, sources or component references that were added, removed or changed.
+type ElementDiff = componentarchive.ElementDiff
+
+// ElementChange describes an element that exists in both component descriptors but has different attributes.
+type ElementChange = componentarchive.ElementChange
+
+// LabelDiff describes the names of the component labels that were added, removed or changed.
+type LabelDiff = componentarchive.LabelDiff
+
+// NewDiffCommand creates a new command that compares two component descriptors.
+func NewDiffCommand(ctx context.Context) *cobra.Command {
+	opts := &DiffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff OLD_PATH NEW_PATH | diff PATH --repo-ctx BASE_URL",
+		Args:  cobra.RangeArgs(1, 2),
+		Short: "Compares two component descriptors",
+		Long: `
+Compares two component descriptors and reports the added, removed and changed resources, sources,
+component references and component labels.
+Resources, sources and component references are matched by their identity (name and extra identity).
+For changed elements the names of the changed attributes are reported.
+
+The paths can point to component archives (directory, tar or tgz) or to component descriptor files (.yaml, .yml or .json).
+
+If --repo-ctx is set, only one path is expected and the component descriptor is compared against the
+component with the same name in the oci registry. The version of the remote component defaults to the version
+of the local component and can be set with --component-version.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New(), os.Stdout); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run compares the component descriptors and writes the diff to the given writer.
+func (o *DiffOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem, w io.Writer) error {
+	newCd, err := componentarchive.ReadComponentDescriptor(fs, o.NewPath)
+	if err != nil {
+		return err
+	}
+	var oldCd *cdv2.ComponentDescriptor
+	if len(o.BaseUrl) != 0 {
+		oldCd, err = o.resolveRemote(ctx, log, fs, newCd)
+	} else {
+		oldCd, err = componentarchive.ReadComponentDescriptor(fs, o.OldPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	diff := DiffComponentDescriptors(oldCd, newCd)
+	switch o.Output {
+	case JSONOutput:
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal diff: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case YAMLOutput:
+		data, err := yaml.Marshal(diff)
+		if err != nil {
+			return fmt.Errorf("unable to marshal diff: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		return writeComponentDiffText(w, diff)
+	}
+}
+
+// resolveRemote fetches the remote component that the given component descriptor is compared against.
+func (o *DiffOptions) resolveRemote(ctx context.Context, log logr.Logger, fs vfs.FileSystem, cd *cdv2.ComponentDescriptor) (*cdv2.ComponentDescriptor, error) {
+	version := o.Version
+	if len(version) == 0 {
+		version = cd.GetVersion()
+	}
+	repoCtx := cdv2.NewOCIRegistryRepository(o.BaseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping))
+
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	remoteCd, err := cdoci.NewResolver(ociClient).Resolve(ctx, repoCtx, cd.GetName(), version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to to fetch component descriptor %s:%s: %w", cd.GetName(), version, err)
+	}
+	return remoteCd, nil
+}
+
+// DiffComponentDescriptors compares two component descriptors.
+func DiffComponentDescriptors(oldCd, newCd *cdv2.ComponentDescriptor) ComponentDiff {
+	return componentarchive.DiffComponentDescriptors(oldCd, newCd)
+}
+
+func writeComponentDiffText(w io.Writer, diff ComponentDiff) error {
+	lines := componentarchive.ComponentDiffLines(diff)
+	if len(lines) == 0 {
+		_, err := fmt.Fprintln(w, "No differences")
+		return err
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func (o *DiffOptions) Complete(args []string) error {
+	if len(o.BaseUrl) != 0 {
+		if len(args) != 1 {
+			return errors.New("expected exactly one argument that contains the path to the component descriptor if --repo-ctx is set")
+		}
+		o.NewPath = args[0]
+
+		cliHomeDir, err := constants.CliHomeDir()
+		if err != nil {
+			return err
+		}
+		o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+		if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
+		}
+	} else {
+		if len(args) != 2 {
+			return errors.New("expected exactly two arguments that contain the paths to the old and new component descriptor")
+		}
+		o.OldPath = args[0]
+		o.NewPath = args[1]
+	}
+	return o.validate()
+}
+
+func (o *DiffOptions) validate() error {
+	if o.Output != TextOutput && o.Output != JSONOutput && o.Output != YAMLOutput {
+		return fmt.Errorf("unsupported output format %q, expected one of %q, %q, %q", o.Output, TextOutput, JSONOutput, YAMLOutput)
+	}
+	return nil
+}
+
+func (o *DiffOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.Output, "output", "o", TextOutput, fmt.Sprintf("output format. One of %q, %q, %q", TextOutput, JSONOutput, YAMLOutput))
+	fs.StringVar(&o.BaseUrl, "repo-ctx", "", "[OPTIONAL] base url of the oci registry of the remote component the component descriptor is compared against")
+	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] repository context name mapping")
+	fs.StringVar(&o.Version, "component-version", "", "[OPTIONAL] version of the remote component. Defaults to the version of the local component")
+	o.OciOptions.AddFlags(fs)
+}
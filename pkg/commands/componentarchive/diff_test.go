@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+)
+
+var _ = Describe("Diff", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		var err error
+		testdataFs, err = projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	runDiff := func(output string, args ...string) []byte {
+		opts := &componentarchive.DiffOptions{Output: output}
+		Expect(opts.Complete(args)).To(Succeed())
+		var buf bytes.Buffer
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs, &buf)).To(Succeed())
+		return buf.Bytes()
+	}
+
+	It("should report added, removed and changed elements and labels", func() {
+		diff := componentarchive.ComponentDiff{}
+		Expect(json.Unmarshal(runDiff(componentarchive.JSONOutput, "./diff/old.yaml", "./diff/new.yaml"), &diff)).To(Succeed())
+
+		Expect(diff.Resources).To(Equal(componentarchive.ElementDiff{
+			Added:   []cdv2.Identity{{"name": "config"}},
+			Removed: []cdv2.Identity{{"name": "chart"}},
+			Changed: []componentarchive.ElementChange{{Identity: cdv2.Identity{"name": "image"}, Fields: []string{"access", "version"}}},
+		}))
+		// the source label only differs in the order of its keys
+		Expect(diff.Sources).To(Equal(componentarchive.ElementDiff{
+			Added:   []cdv2.Identity{},
+			Removed: []cdv2.Identity{},
+			Changed: []componentarchive.ElementChange{},
+		}))
+		Expect(diff.ComponentReferences.Changed).To(Equal([]componentarchive.ElementChange{
+			{Identity: cdv2.Identity{"name": "dep"}, Fields: []string{"version"}},
+		}))
+		Expect(diff.Labels).To(Equal(componentarchive.LabelDiff{
+			Added:   []string{"owner"},
+			Removed: []string{"deprecated"},
+			Changed: []string{"team"},
+		}))
+	})
+
+	It("should print the diff as yaml", func() {
+		diff := componentarchive.ComponentDiff{}
+		Expect(yaml.Unmarshal(runDiff(componentarchive.YAMLOutput, "./diff/old.yaml", "./diff/new.yaml"), &diff)).To(Succeed())
+		Expect(diff.Labels.Changed).To(ConsistOf("team"))
+	})
+
+	It("should print the diff as text", func() {
+		Expect(string(runDiff(componentarchive.TextOutput, "./diff/old.yaml", "./diff/new.yaml"))).To(Equal(`resources:
+  + name=config
+  - name=chart
+  ~ name=image (access, version)
+componentReferences:
+  ~ name=dep (version)
+labels:
+  + owner
+  - deprecated
+  ~ team
+`))
+	})
+
+	It("should report no differences for the same component descriptor", func() {
+		Expect(string(runDiff(componentarchive.TextOutput, "./diff/old.yaml", "./diff/old.yaml"))).To(Equal("No differences\n"))
+	})
+
+	It("should require two paths if no remote repository is defined", func() {
+		opts := &componentarchive.DiffOptions{Output: componentarchive.TextOutput}
+		Expect(opts.Complete([]string{"./diff/old.yaml"})).To(HaveOccurred())
+	})
+
+})
@@ -28,6 +28,8 @@ type ExportOptions struct {
 	OutputPath string
 	// OutputFormat defines the output format of the component archive.
 	OutputFormat ctf.ArchiveFormat
+	// Reproducible writes tar and gzipped tar archives with normalized entry metadata.
+	Reproducible bool
 }
 
 // NewExportCommand creates a new export command that packages a component archive and
@@ -44,6 +46,10 @@ If the given component-archive path points to a directory, the archive is expect
 Then it is exported as tar or optionally as compressed tar.
 
 If the given path points to a file, the archive is read as tar or compressed tar (tar.gz) and exported as filesystem to the given location.
+
+With --reproducible the same component archive is always exported to the same tar or compressed tar.
+All entries get the modification time defined by the SOURCE_DATE_EPOCH environment variable
+(defaults to the unix epoch) and no user or group ownership.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
@@ -79,6 +85,13 @@ func (o *ExportOptions) export(fs vfs.FileSystem, ca *ctf.ComponentArchive, defa
 		o.OutputFormat = defaultFormat
 	}
 
+	if o.Reproducible && o.OutputFormat != ctf.ArchiveFormatFilesystem {
+		modTime, err := componentarchive.ReproducibleModTime()
+		if err != nil {
+			return err
+		}
+		return componentarchive.WriteReproducible(fs, o.OutputPath, ca, o.OutputFormat, modTime)
+	}
 	return componentarchive.Write(fs, o.OutputPath, ca, o.OutputFormat)
 }
 
@@ -103,4 +116,5 @@ func (o *ExportOptions) validate() error {
 func (o *ExportOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVarP(&o.OutputPath, "out", "o", "", "writes the resulting archive to the given path")
 	componentarchive.OutputFormatVar(fs, &o.OutputFormat, "format", "", componentarchive.DefaultOutputFormatUsage)
+	fs.BoolVar(&o.Reproducible, "reproducible", false, "write tar and compressed tar archives reproducibly with normalized timestamps and ownership")
 }
@@ -5,19 +5,23 @@
 package componentarchive_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
+	"io"
+	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gardener/component-spec/bindings-go/ctf"
-	"github.com/mandelsoft/vfs/pkg/layerfs"
-	"github.com/mandelsoft/vfs/pkg/memoryfs"
-	"github.com/mandelsoft/vfs/pkg/osfs"
-	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	"github.com/gardener/component-cli/pkg/testutils"
 	"github.com/gardener/component-cli/pkg/utils"
 )
 
@@ -26,9 +30,7 @@ var _ = Describe("Export", func() {
 	var testdataFs vfs.FileSystem
 
 	BeforeEach(func() {
-		baseFs, err := projectionfs.New(osfs.New(), "./testdata")
-		Expect(err).ToNot(HaveOccurred())
-		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
 	})
 
 	Context("From Filesystem", func() {
@@ -59,6 +61,60 @@ var _ = Describe("Export", func() {
 			Expect(mediatype).To(Equal("application/x-gzip"))
 		})
 
+		It("should export a component archive reproducibly", func() {
+			export := func(out string) []byte {
+				opts := &componentarchive.ExportOptions{
+					ComponentArchivePath: "01-ca-blob",
+					OutputPath:           out,
+					OutputFormat:         ctf.ArchiveFormatTarGzip,
+					Reproducible:         true,
+				}
+				Expect(opts.Run(context.TODO(), testdataFs)).To(Succeed())
+				data, err := vfs.ReadFile(testdataFs, out)
+				Expect(err).ToNot(HaveOccurred())
+				return data
+			}
+			first := export("first.tar.gz")
+			time.Sleep(1100 * time.Millisecond)
+			Expect(export("second.tar.gz")).To(Equal(first))
+
+			zr, err := gzip.NewReader(bytes.NewReader(first))
+			Expect(err).ToNot(HaveOccurred())
+			tr := tar.NewReader(zr)
+			names := []string{}
+			for {
+				header, err := tr.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				Expect(err).ToNot(HaveOccurred())
+				Expect(header.ModTime.Unix()).To(BeZero())
+				names = append(names, header.Name)
+			}
+			Expect(names).To(ContainElement(ctf.ComponentDescriptorFileName))
+		})
+
+		It("should use SOURCE_DATE_EPOCH as modification time of reproducible archives", func() {
+			Expect(os.Setenv("SOURCE_DATE_EPOCH", "1650000000")).To(Succeed())
+			defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+			opts := &componentarchive.ExportOptions{
+				ComponentArchivePath: "00-ca",
+				OutputPath:           "ca.tar",
+				OutputFormat:         ctf.ArchiveFormatTar,
+				Reproducible:         true,
+			}
+			Expect(opts.Run(context.TODO(), testdataFs)).To(Succeed())
+
+			file, err := testdataFs.Open("ca.tar")
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close()
+			tr := tar.NewReader(file)
+			header, err := tr.Next()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(header.ModTime.Unix()).To(Equal(int64(1650000000)))
+		})
+
 	})
 
 	Context("From tar", func() {
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// Parts of a component descriptor that can be selected by the get command.
+const (
+	ResourcesPart           = "resources"
+	SourcesPart             = "sources"
+	ComponentReferencesPart = "componentReferences"
+)
+
+// GetOptions defines the options that are used to print a component descriptor.
+type GetOptions struct {
+	// ComponentArchivePath defines the path to the component archive or component descriptor.
+	ComponentArchivePath string
+	// Select defines the part of the component descriptor that is printed.
+	// The complete component descriptor is printed if empty.
+	Select string
+	// Output defines the output format.
+	Output string
+	// Template is a go template that is executed on the selected object instead of printing it.
+	Template string
+	// JSONPath is a jsonpath expression whose results are printed instead of the selected object.
+	JSONPath string
+}
+
+// NewGetCommand creates a new command that prints a component descriptor.
+func NewGetCommand(ctx context.Context) *cobra.Command {
+	opts := &GetOptions{}
+	cmd := &cobra.Command{
+		Use:     "get COMPONENT_ARCHIVE_PATH",
+		Aliases: []string{"show"},
+		Args:    cobra.ExactArgs(1),
+		Short:   "Prints a component descriptor or parts of it",
+		Long: fmt.Sprintf(`
+Prints the component descriptor of a component archive or a component descriptor file as yaml or json.
+The path can point to a component archive (directory, tar or tgz) or to a component descriptor file (.yaml, .yml or .json).
+
+Only the resources, sources or component references are printed if --select is set to %q, %q or %q.
+
+With --template the go template is executed on the selected object, with --jsonpath all values that match
+the jsonpath expression are printed one per line. Both use the json field names of the component descriptor, e.g.
+  --jsonpath '{.component.resources[*].name}'
+  --select resources --template '{{ range . }}{{ .name }}:{{ .version }}{{ "\n" }}{{ end }}'
+The jsonpath expression supports field selectors, list indices and wildcards. Missing fields result in an error.
+`, ResourcesPart, SourcesPart, ComponentReferencesPart),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, osfs.New(), os.Stdout); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run prints the selected part of the component descriptor to the given writer.
+func (o *GetOptions) Run(_ context.Context, fs vfs.FileSystem, w io.Writer) error {
+	cd, err := componentarchive.ReadComponentDescriptor(fs, o.ComponentArchivePath)
+	if err != nil {
+		return err
+	}
+
+	// work on the generic json representation so that templates and jsonpath use the json field names.
+	data, err := json.Marshal(cd)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	var obj interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("unable to decode component descriptor: %w", err)
+	}
+	if len(o.Select) != 0 {
+		selected, err := utils.JSONPath(obj, ".component."+o.Select)
+		if err != nil {
+			return err
+		}
+		obj = selected[0]
+	}
+
+	switch {
+	case len(o.Template) != 0:
+		tmpl, err := template.New("get").Option("missingkey=error").Parse(o.Template)
+		if err != nil {
+			return fmt.Errorf("unable to parse template: %w", err)
+		}
+		if err := tmpl.Execute(w, obj); err != nil {
+			return fmt.Errorf("unable to execute template: %w", err)
+		}
+		return nil
+	case len(o.JSONPath) != 0:
+		values, err := utils.JSONPath(obj, o.JSONPath)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			formatted, err := utils.FormatJSONPathValue(value)
+			if err != nil {
+				return fmt.Errorf("unable to format value: %w", err)
+			}
+			if _, err := fmt.Fprintln(w, formatted); err != nil {
+				return err
+			}
+		}
+		return nil
+	case o.Output == JSONOutput:
+		data, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal component descriptor: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	default:
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("unable to marshal component descriptor: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	}
+}
+
+func (o *GetOptions) Complete(args []string) error {
+	if len(args) != 1 {
+		return errors.New("a component archive path argument has to be defined")
+	}
+	o.ComponentArchivePath = args[0]
+	return o.validate()
+}
+
+func (o *GetOptions) validate() error {
+	if len(o.ComponentArchivePath) == 0 {
+		return errors.New("a component archive path must be provided")
+	}
+	switch o.Select {
+	case "", ResourcesPart, SourcesPart, ComponentReferencesPart:
+	default:
+		return fmt.Errorf("unsupported selection %q, expected one of %q, %q, %q", o.Select, ResourcesPart, SourcesPart, ComponentReferencesPart)
+	}
+	if o.Output != YAMLOutput && o.Output != JSONOutput {
+		return fmt.Errorf("unsupported output format %q, expected one of %q, %q", o.Output, YAMLOutput, JSONOutput)
+	}
+	if len(o.Template) != 0 && len(o.JSONPath) != 0 {
+		return errors.New("--template and --jsonpath cannot be used together")
+	}
+	return nil
+}
+
+func (o *GetOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Select, "select", "", fmt.Sprintf("[OPTIONAL] part of the component descriptor that is printed. One of %q, %q, %q", ResourcesPart, SourcesPart, ComponentReferencesPart))
+	fs.StringVarP(&o.Output, "output", "o", YAMLOutput, fmt.Sprintf("output format. One of %q, %q", YAMLOutput, JSONOutput))
+	fs.StringVar(&o.Template, "template", "", "[OPTIONAL] go template that is executed on the selected object")
+	fs.StringVar(&o.JSONPath, "jsonpath", "", "[OPTIONAL] jsonpath expression whose results are printed one per line")
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"bytes"
+	"context"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+)
+
+var _ = Describe("Get", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		var err error
+		testdataFs, err = projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	runGet := func(opts *componentarchive.GetOptions, path string) string {
+		if len(opts.Output) == 0 {
+			opts.Output = componentarchive.YAMLOutput
+		}
+		Expect(opts.Complete([]string{path})).To(Succeed())
+		var buf bytes.Buffer
+		Expect(opts.Run(context.TODO(), testdataFs, &buf)).To(Succeed())
+		return buf.String()
+	}
+
+	It("should print the complete component descriptor of a component archive", func() {
+		out := runGet(&componentarchive.GetOptions{}, "./00-ca")
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode([]byte(out), cd)).To(Succeed())
+		Expect(cd.GetName()).ToNot(BeEmpty())
+	})
+
+	It("should print the selected part as json", func() {
+		out := runGet(&componentarchive.GetOptions{Select: componentarchive.ComponentReferencesPart, Output: componentarchive.JSONOutput}, "./diff/old.yaml")
+		Expect(out).To(MatchJSON(`[{"name": "dep", "componentName": "example.com/dep", "version": "v1.0.0"}]`))
+	})
+
+	It("should print the results of a jsonpath expression", func() {
+		out := runGet(&componentarchive.GetOptions{JSONPath: "{.component.resources[*].name}"}, "./diff/old.yaml")
+		Expect(out).To(Equal("image\nchart\n"))
+	})
+
+	It("should execute a template on the selected part", func() {
+		out := runGet(&componentarchive.GetOptions{
+			Select:   componentarchive.ResourcesPart,
+			Template: `{{ range . }}{{ .name }}:{{ .version }};{{ end }}`,
+		}, "./diff/old.yaml")
+		Expect(out).To(Equal("image:v0.1.0;chart:v0.1.0;"))
+	})
+
+	It("should fail if a template and a jsonpath are defined", func() {
+		opts := &componentarchive.GetOptions{Output: componentarchive.YAMLOutput, Template: "{{ . }}", JSONPath: "."}
+		Expect(opts.Complete([]string{"./diff/old.yaml"})).To(MatchError("--template and --jsonpath cannot be used together"))
+	})
+
+})
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package input
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// MediaTypeZstd is the media type for a zstd compressed file.
+const MediaTypeZstd = "application/zstd"
+
+// Compression is the algorithm that is used to compress a blob input.
+type Compression string
+
+const (
+	// CompressionNone adds the blob uncompressed.
+	CompressionNone Compression = "none"
+	// CompressionGzip compresses the blob using gzip.
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd compresses the blob using zstd.
+	CompressionZstd Compression = "zstd"
+)
+
+// UnmarshalJSON parses the compression from a string or a boolean.
+// For backwards compatibility true is treated as gzip and false as no compression.
+func (c *Compression) UnmarshalJSON(data []byte) error {
+	var compress bool
+	if err := json.Unmarshal(data, &compress); err == nil {
+		if compress {
+			*c = CompressionGzip
+		} else {
+			*c = CompressionNone
+		}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("compress must be a boolean or one of %q, %q, %q", CompressionNone, CompressionGzip, CompressionZstd)
+	}
+	switch Compression(s) {
+	case "", CompressionNone, CompressionGzip, CompressionZstd:
+		*c = Compression(s)
+		return nil
+	default:
+		return fmt.Errorf("unknown compression %q, expected one of %q, %q, %q", s, CompressionNone, CompressionGzip, CompressionZstd)
+	}
+}
+
+// Enabled returns whether the blob is compressed.
+func (c Compression) Enabled() bool {
+	return c == CompressionGzip || c == CompressionZstd
+}
+
+// MediaType returns the media type of the compressed blob.
+// The given media type of the uncompressed blob is returned if the blob is not compressed.
+func (c Compression) MediaType(uncompressed string) string {
+	switch c {
+	case CompressionGzip:
+		return MediaTypeGZip
+	case CompressionZstd:
+		return MediaTypeZstd
+	default:
+		return uncompressed
+	}
+}
+
+// Writer returns a writer that compresses all data written to it into w.
+// The writer must be closed to flush the compressed data.
+func (c Compression) Writer(w io.Writer) (io.WriteCloser, error) {
+	switch c {
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create zstd writer: %w", err)
+		}
+		return zw, nil
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
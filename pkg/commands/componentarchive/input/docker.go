@@ -0,0 +1,304 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package input
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+const (
+	// DockerInputType reads an image from the local docker daemon.
+	DockerInputType = "docker"
+
+	// MediaTypeOCIArtifactTar is the media type of a serialized oci artifact.
+	// The tar contains the oci manifest as "manifest.json" and the config and layers in a "blobs" directory.
+	MediaTypeOCIArtifactTar = "application/vnd.oci.image.manifest.v1+tar"
+
+	// DockerHostEnvName is the name of the environment variable that defines the address of the docker daemon.
+	DockerHostEnvName = "DOCKER_HOST"
+	// DefaultDockerHost is the address of the docker daemon if no DOCKER_HOST is defined.
+	DefaultDockerHost = "unix:///var/run/docker.sock"
+)
+
+// dockerArchiveManifest is an entry of the manifest.json of a tar that is exported by the docker daemon.
+type dockerArchiveManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// readDockerImage exports the image from the local docker daemon and converts it into a serialized oci artifact.
+func (input *BlobInput) readDockerImage(ctx context.Context) (*BlobOutput, error) {
+	if len(input.Path) == 0 {
+		return nil, errors.New("the image of a docker input has to be defined as path")
+	}
+	input.SetMediaTypeIfNotDefined(MediaTypeOCIArtifactTar)
+
+	client, baseURL, err := newDockerClient(os.Getenv(DockerHostEnvName))
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/images/get?names="+url.QueryEscape(input.Path), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create docker request: %w", err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to the docker daemon: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return nil, fmt.Errorf("unable to export image %q from the docker daemon (%s): %s", input.Path, res.Status, strings.TrimSpace(string(msg)))
+	}
+
+	artifact, err := ioutil.TempFile("", "docker-image-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create tempfile: %w", err)
+	}
+	blob := &tempFileReadCloser{File: artifact}
+	if err := ConvertDockerArchive(res.Body, artifact); err != nil {
+		_ = blob.Close()
+		return nil, fmt.Errorf("unable to convert image %q: %w", input.Path, err)
+	}
+	if _, err := artifact.Seek(0, io.SeekStart); err != nil {
+		_ = blob.Close()
+		return nil, fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+	}
+	blobDigest, err := digest.FromReader(artifact)
+	if err != nil {
+		_ = blob.Close()
+		return nil, fmt.Errorf("unable to calculate digest of image %q: %w", input.Path, err)
+	}
+	size, err := artifact.Seek(0, io.SeekCurrent)
+	if err != nil {
+		_ = blob.Close()
+		return nil, fmt.Errorf("unable to get size of image %q: %w", input.Path, err)
+	}
+	if _, err := artifact.Seek(0, io.SeekStart); err != nil {
+		_ = blob.Close()
+		return nil, fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+	}
+
+	return &BlobOutput{
+		Digest: blobDigest.String(),
+		Size:   size,
+		Reader: blob,
+	}, nil
+}
+
+// newDockerClient creates a http client for the docker daemon at the given host.
+// Unix sockets ("unix://") and unencrypted tcp connections ("tcp://") are supported.
+func newDockerClient(host string) (*http.Client, string, error) {
+	if len(host) == 0 {
+		host = DefaultDockerHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}
+		return &http.Client{Transport: transport}, "http://docker", nil
+	case "tcp", "http":
+		return http.DefaultClient, "http://" + u.Host, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported docker host %q, only unix and tcp hosts are supported", host)
+	}
+}
+
+// ConvertDockerArchive converts a tar as it is exported by "docker save" into a serialized oci artifact.
+// The archive must contain exactly one image.
+func ConvertDockerArchive(r io.Reader, w io.Writer) error {
+	tmpDir, err := ioutil.TempDir("", "docker-archive-")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files, err := extractDockerArchive(r, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	manifestFile, ok := files["manifest.json"]
+	if !ok {
+		return errors.New("manifest.json not found in docker archive")
+	}
+	data, err := ioutil.ReadFile(manifestFile)
+	if err != nil {
+		return fmt.Errorf("unable to read manifest.json: %w", err)
+	}
+	manifests := []dockerArchiveManifest{}
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return fmt.Errorf("unable to decode manifest.json: %w", err)
+	}
+	if len(manifests) != 1 {
+		return fmt.Errorf("expected exactly one image in the docker archive but found %d", len(manifests))
+	}
+
+	configDesc, err := describeFile(files, manifests[0].Config, ocispecv1.MediaTypeImageConfig)
+	if err != nil {
+		return fmt.Errorf("unable to read config: %w", err)
+	}
+	manifest := ocispecv1.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    configDesc,
+		Layers:    make([]ocispecv1.Descriptor, 0, len(manifests[0].Layers)),
+	}
+	blobs := map[digest.Digest]string{
+		configDesc.Digest: files[path.Clean(manifests[0].Config)],
+	}
+	for _, layer := range manifests[0].Layers {
+		layerDesc, err := describeFile(files, layer, ocispecv1.MediaTypeImageLayer)
+		if err != nil {
+			return fmt.Errorf("unable to read layer: %w", err)
+		}
+		manifest.Layers = append(manifest.Layers, layerDesc)
+		blobs[layerDesc.Digest] = files[path.Clean(layer)]
+	}
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("unable to marshal manifest: %w", err)
+	}
+	tw := tar.NewWriter(w)
+	if err := utils.WriteFileToTARArchive("manifest.json", bytes.NewReader(manifestBytes), tw); err != nil {
+		return fmt.Errorf("unable to write manifest: %w", err)
+	}
+	written := map[digest.Digest]bool{}
+	for _, desc := range append([]ocispecv1.Descriptor{manifest.Config}, manifest.Layers...) {
+		// identical layers are only added once
+		if written[desc.Digest] {
+			continue
+		}
+		written[desc.Digest] = true
+		if err := writeBlob(tw, path.Join("blobs", desc.Digest.Encoded()), blobs[desc.Digest]); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// extractDockerArchive extracts all files of the tar into the directory.
+// It returns the path of the extracted file for every file name of the tar.
+// Symlinks are resolved to the file they point to.
+func extractDockerArchive(r io.Reader, dir string) (map[string]string, error) {
+	files := map[string]string{}
+	links := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("unable to read docker archive: %w", err)
+		}
+		name := path.Clean(header.Name)
+		switch header.Typeflag {
+		case tar.TypeReg:
+			filePath := filepath.Join(dir, strconv.Itoa(len(files)))
+			file, err := os.Create(filePath)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create file for %q: %w", name, err)
+			}
+			if _, err := io.Copy(file, tr); err != nil {
+				_ = file.Close()
+				return nil, fmt.Errorf("unable to extract %q: %w", name, err)
+			}
+			if err := file.Close(); err != nil {
+				return nil, fmt.Errorf("unable to close file for %q: %w", name, err)
+			}
+			files[name] = filePath
+		case tar.TypeSymlink:
+			links[name] = path.Join(path.Dir(name), header.Linkname)
+		}
+	}
+	for name, target := range links {
+		if filePath, ok := files[target]; ok {
+			files[name] = filePath
+		}
+	}
+	return files, nil
+}
+
+// describeFile creates a descriptor for the extracted file with the given name.
+func describeFile(files map[string]string, name, mediaType string) (ocispecv1.Descriptor, error) {
+	filePath, ok := files[path.Clean(name)]
+	if !ok {
+		return ocispecv1.Descriptor{}, fmt.Errorf("%q not found in docker archive", name)
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to open %q: %w", name, err)
+	}
+	defer file.Close()
+	dig, err := digest.FromReader(file)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to calculate digest of %q: %w", name, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to get info of %q: %w", name, err)
+	}
+	return ocispecv1.Descriptor{
+		MediaType: mediaType,
+		Digest:    dig,
+		Size:      info.Size(),
+	}, nil
+}
+
+func writeBlob(tw *tar.Writer, name, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("unable to open blob %q: %w", name, err)
+	}
+	defer file.Close()
+	if err := utils.WriteFileToTARArchive(name, file, tw); err != nil {
+		return fmt.Errorf("unable to write blob %q: %w", name, err)
+	}
+	return nil
+}
+
+// tempFileReadCloser removes the temporary file when it is closed.
+type tempFileReadCloser struct {
+	*os.File
+}
+
+func (f *tempFileReadCloser) Close() error {
+	err := f.File.Close()
+	if rmErr := os.Remove(f.File.Name()); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	return err
+}
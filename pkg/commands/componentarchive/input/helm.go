@@ -0,0 +1,360 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package input
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// HelmInputType packages a helm chart directory.
+	HelmInputType = "helm"
+
+	// MediaTypeHelmChart is the media type of a packaged helm chart.
+	MediaTypeHelmChart = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+	// HelmChartResourceType is the resource type of helm charts.
+	HelmChartResourceType = "helm.io/chart"
+
+	helmChartFile       = "Chart.yaml"
+	helmRequirementFile = "requirements.yaml"
+	helmIgnoreFile      = ".helmignore"
+	helmChartsDir       = "charts"
+	helmFileRepoPrefix  = "file://"
+)
+
+// helmChart contains the fields of a Chart.yaml that are needed to package a chart.
+type helmChart struct {
+	APIVersion   string           `json:"apiVersion"`
+	Name         string           `json:"name"`
+	Version      string           `json:"version"`
+	Dependencies []helmDependency `json:"dependencies,omitempty"`
+}
+
+// helmDependency is a dependency of a helm chart.
+type helmDependency struct {
+	Name       string `json:"name"`
+	Version    string `json:"version,omitempty"`
+	Repository string `json:"repository,omitempty"`
+}
+
+// readHelmChart packages the helm chart in the given directory.
+func (input *BlobInput) readHelmChart(ctx context.Context, fs vfs.FileSystem, chartPath string) (*BlobOutput, error) {
+	input.SetMediaTypeIfNotDefined(MediaTypeHelmChart)
+	artifact, err := ioutil.TempFile("", "helm-chart-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create tempfile: %w", err)
+	}
+	blob := &tempFileReadCloser{File: artifact}
+	digester := digest.Canonical.Digester()
+	if err := PackageHelmChart(ctx, fs, chartPath, io.MultiWriter(artifact, digester.Hash())); err != nil {
+		_ = blob.Close()
+		return nil, fmt.Errorf("unable to package helm chart %q: %w", chartPath, err)
+	}
+	size, err := artifact.Seek(0, io.SeekCurrent)
+	if err != nil {
+		_ = blob.Close()
+		return nil, fmt.Errorf("unable to get size of helm chart %q: %w", chartPath, err)
+	}
+	if _, err := artifact.Seek(0, io.SeekStart); err != nil {
+		_ = blob.Close()
+		return nil, fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+	}
+	return &BlobOutput{
+		Digest: digester.Digest().String(),
+		Size:   size,
+		Reader: blob,
+	}, nil
+}
+
+// PackageHelmChart packages the helm chart in the given directory as gzipped tar like "helm package".
+// Dependencies must either be part of the charts directory or reference a local chart with a "file://" repository.
+// Local charts are packaged and added to the charts directory of the package.
+// Files that match a pattern of the .helmignore file are not packaged.
+func PackageHelmChart(ctx context.Context, fs vfs.FileSystem, chartPath string, w io.Writer) error {
+	return packageHelmChart(ctx, fs, chartPath, w, sets.NewString())
+}
+
+// packageHelmChart packages the helm chart in the given directory.
+// parents contains the canonical paths of all charts that depend on the chart, to detect cyclic dependencies.
+func packageHelmChart(ctx context.Context, fs vfs.FileSystem, chartPath string, w io.Writer, parents sets.String) error {
+	chart, err := readHelmChartFile(fs, chartPath)
+	if err != nil {
+		return err
+	}
+	canonicalPath, err := vfs.Canonical(fs, chartPath, true)
+	if err != nil {
+		return fmt.Errorf("unable to resolve path of chart %q: %w", chart.Name, err)
+	}
+	if parents.Has(canonicalPath) {
+		return fmt.Errorf("chart %q in %q has a cyclic dependency to itself", chart.Name, chartPath)
+	}
+	parents.Insert(canonicalPath)
+	defer parents.Delete(canonicalPath)
+
+	dependencies, err := resolveHelmDependencies(ctx, fs, chartPath, chart, parents)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, dep := range dependencies {
+			_ = dep.Close()
+		}
+	}()
+	ignore, err := readHelmIgnore(fs, chartPath)
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	err = vfs.Walk(fs, chartPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		relPath, err := filepath.Rel(chartPath, filePath)
+		if err != nil {
+			return fmt.Errorf("unable to calculate relative path for %s: %w", filePath, err)
+		}
+		relPath = filepath.ToSlash(relPath)
+		if relPath == "." {
+			return nil
+		}
+		if ignore.ignored(relPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// helm packages only regular files
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := fs.Open(filePath)
+		if err != nil {
+			return fmt.Errorf("unable to open file %q: %w", relPath, err)
+		}
+		defer file.Close()
+		return writeHelmChartFile(tw, path.Join(chart.Name, relPath), info.Size(), file)
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range sets.StringKeySet(dependencies).List() {
+		dep := dependencies[name]
+		info, err := dep.Stat()
+		if err != nil {
+			return fmt.Errorf("unable to get size of dependency %q: %w", name, err)
+		}
+		if err := writeHelmChartFile(tw, path.Join(chart.Name, helmChartsDir, name), info.Size(), dep); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("unable to close tar writer: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("unable to close gzip writer: %w", err)
+	}
+	return nil
+}
+
+func readHelmChartFile(fs vfs.FileSystem, chartPath string) (*helmChart, error) {
+	data, err := vfs.ReadFile(fs, filepath.Join(chartPath, helmChartFile))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", helmChartFile, err)
+	}
+	chart := &helmChart{}
+	if err := yaml.Unmarshal(data, chart); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", helmChartFile, err)
+	}
+	if len(chart.Name) == 0 {
+		return nil, fmt.Errorf("the name of the chart must be defined in %s", helmChartFile)
+	}
+	if len(chart.Version) == 0 {
+		return nil, fmt.Errorf("the version of the chart must be defined in %s", helmChartFile)
+	}
+
+	// the dependencies of v1 charts are defined in a separate file
+	if chart.APIVersion == "v1" {
+		data, err := vfs.ReadFile(fs, filepath.Join(chartPath, helmRequirementFile))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return chart, nil
+			}
+			return nil, fmt.Errorf("unable to read %s: %w", helmRequirementFile, err)
+		}
+		requirements := &helmChart{}
+		if err := yaml.Unmarshal(data, requirements); err != nil {
+			return nil, fmt.Errorf("unable to decode %s: %w", helmRequirementFile, err)
+		}
+		chart.Dependencies = requirements.Dependencies
+	}
+	return chart, nil
+}
+
+// resolveHelmDependencies checks that all dependencies of the chart are available.
+// Local dependencies that are not part of the charts directory are packaged into temporary files
+// and returned by their file name. The caller has to close the returned files.
+func resolveHelmDependencies(ctx context.Context, fs vfs.FileSystem, chartPath string, chart *helmChart, parents sets.String) (map[string]*tempFileReadCloser, error) {
+	packaged := map[string]*tempFileReadCloser{}
+	fail := func(err error) (map[string]*tempFileReadCloser, error) {
+		for _, dep := range packaged {
+			_ = dep.Close()
+		}
+		return nil, err
+	}
+	for _, dep := range chart.Dependencies {
+		found, err := hasHelmDependency(fs, chartPath, dep)
+		if err != nil {
+			return fail(err)
+		}
+		if found {
+			continue
+		}
+		if !strings.HasPrefix(dep.Repository, helmFileRepoPrefix) {
+			return fail(fmt.Errorf("dependency %q of chart %q is missing in the %s directory, run \"helm dependency update\" to download it", dep.Name, chart.Name, helmChartsDir))
+		}
+		depPath := strings.TrimPrefix(dep.Repository, helmFileRepoPrefix)
+		if !filepath.IsAbs(depPath) {
+			depPath = filepath.Join(chartPath, depPath)
+		}
+		depChart, err := readHelmChartFile(fs, depPath)
+		if err != nil {
+			return fail(fmt.Errorf("unable to read dependency %q of chart %q: %w", dep.Name, chart.Name, err))
+		}
+		file, err := ioutil.TempFile("", "helm-chart-")
+		if err != nil {
+			return fail(fmt.Errorf("unable to create tempfile: %w", err))
+		}
+		name := fmt.Sprintf("%s-%s.tgz", depChart.Name, depChart.Version)
+		if existing, ok := packaged[name]; ok {
+			_ = existing.Close()
+		}
+		packaged[name] = &tempFileReadCloser{File: file}
+		if err := packageHelmChart(ctx, fs, depPath, file, parents); err != nil {
+			return fail(fmt.Errorf("unable to package dependency %q of chart %q: %w", dep.Name, chart.Name, err))
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return fail(fmt.Errorf("unable to seek to beginning of tempfile: %w", err))
+		}
+	}
+	return packaged, nil
+}
+
+// hasHelmDependency checks whether the dependency is part of the charts directory
+// either as chart directory or as packaged chart.
+func hasHelmDependency(fs vfs.FileSystem, chartPath string, dep helmDependency) (bool, error) {
+	chartsDir := filepath.Join(chartPath, helmChartsDir)
+	entries, err := vfs.ReadDir(fs, chartsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("unable to read %s directory: %w", helmChartsDir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			chart, err := readHelmChartFile(fs, filepath.Join(chartsDir, entry.Name()))
+			if err == nil && chart.Name == dep.Name {
+				return true, nil
+			}
+			continue
+		}
+		if strings.HasPrefix(entry.Name(), dep.Name+"-") && strings.HasSuffix(entry.Name(), ".tgz") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func writeHelmChartFile(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("unable to write header for %q: %w", name, err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("unable to add file to tar %q: %w", name, err)
+	}
+	return nil
+}
+
+// helmIgnore contains the patterns of a .helmignore file.
+type helmIgnore []string
+
+// readHelmIgnore reads the .helmignore file of the chart.
+// The .helmignore file itself is always ignored.
+func readHelmIgnore(fs vfs.FileSystem, chartPath string) (helmIgnore, error) {
+	ignore := helmIgnore{helmIgnoreFile}
+	file, err := fs.Open(filepath.Join(chartPath, helmIgnoreFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ignore, nil
+		}
+		return nil, fmt.Errorf("unable to read %s: %w", helmIgnoreFile, err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := path.Match(strings.TrimSuffix(line, "/"), ""); err != nil {
+			return nil, fmt.Errorf("malformed pattern %q in %s", line, helmIgnoreFile)
+		}
+		ignore = append(ignore, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", helmIgnoreFile, err)
+	}
+	return ignore, nil
+}
+
+// ignored checks whether the file with the given path relative to the chart directory is ignored.
+// Patterns without a slash match the file name in all directories,
+// patterns that end with a slash only match directories.
+func (ignore helmIgnore) ignored(relPath string, isDir bool) bool {
+	for _, pattern := range ignore {
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		name := relPath
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(relPath)
+		}
+		if match, _ := path.Match(strings.TrimPrefix(pattern, "/"), name); match {
+			return true
+		}
+	}
+	return false
+}
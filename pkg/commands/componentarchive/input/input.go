@@ -7,7 +7,6 @@ package input
 import (
 	"archive/tar"
 	"bytes"
-	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
@@ -50,14 +49,19 @@ const (
 type BlobInput struct {
 	// Type defines the input type of the blob to be added.
 	// Note that a input blob of type "dir" is automatically tarred.
+	// A input blob of type "docker" is read from the local docker daemon and converted into an oci artifact.
+	// A input blob of type "helm" is a chart directory that is packaged like "helm package".
+	// A input blob of type "ociLayout" is an oci image layout directory or tar whose image or image index is added.
 	Type BlobInputType `json:"type"`
 	// MediaType is the mediatype of the defined file that is also added to the oci layer.
 	// Should be a custom media type in the form of "application/vnd.<mydomain>.<my description>"
 	MediaType string `json:"mediaType,omitempty"`
 	// Path is the path that points to the blob to be added.
+	// For type "docker" it is the image reference in the local docker daemon.
 	Path string `json:"path"`
-	// CompressWithGzip defines that the blob should be automatically compressed using gzip.
-	CompressWithGzip *bool `json:"compress,omitempty"`
+	// Compression defines the algorithm that is used to automatically compress the blob.
+	// One of "none", "gzip" or "zstd". For backwards compatibility true is treated as "gzip".
+	Compression Compression `json:"compress,omitempty"`
 	// PreserveDir defines that the directory specified in the Path field should be included in the blob.
 	// Only supported for Type dir.
 	PreserveDir bool `json:"preserveDir,omitempty"`
@@ -66,21 +70,22 @@ type BlobInput struct {
 	// Only relevant for blobinput type "dir".
 	IncludeFiles []string `json:"includeFiles,omitempty"`
 	// ExcludeFiles is a list of shell file name patterns that describe the files that should be excluded from the resulting tar.
-	// Excluded files always overwrite included files.
+	// Excluded files always overwrite included files. The content of excluded directories is excluded as well.
 	// Only relevant for blobinput type "dir".
 	ExcludeFiles []string `json:"excludeFiles,omitempty"`
 	// FollowSymlinks configures to follow and resolve symlinks when a directory is tarred.
 	// This options will include the content of the symlink directly in the tar.
 	// This option should be used with care.
 	FollowSymlinks bool `json:"followSymlinks,omitempty"`
+	// Platforms restricts the manifests of a multi-arch image that are added.
+	// The platforms are defined as "os/architecture[/variant]".
+	// Only relevant for blobinput type "ociLayout".
+	Platforms []string `json:"platforms,omitempty"`
 }
 
-// Compress returns if the blob should be compressed using gzip.
+// Compress returns if the blob should be compressed.
 func (input BlobInput) Compress() bool {
-	if input.CompressWithGzip == nil {
-		return false
-	}
-	return *input.CompressWithGzip
+	return input.Compression.Enabled()
 }
 
 // SetMediaTypeIfNotDefined sets the media type of the input blob if its not defined
@@ -93,6 +98,10 @@ func (input *BlobInput) SetMediaTypeIfNotDefined(mediaType string) {
 
 // Read reads the configured blob and returns a reader to the given file.
 func (input *BlobInput) Read(ctx context.Context, fs vfs.FileSystem, inputFilePath string) (*BlobOutput, error) {
+	// the path of a docker input is an image of the local docker daemon
+	if input.Type == DockerInputType {
+		return input.readDockerImage(ctx)
+	}
 	inputPath := input.Path
 	if !filepath.IsAbs(input.Path) {
 		var wd string
@@ -113,39 +122,39 @@ func (input *BlobInput) Read(ctx context.Context, fs vfs.FileSystem, inputFilePa
 		return nil, fmt.Errorf("unable to get info for input blob from %q, %w", inputPath, err)
 	}
 
+	if input.Type == OCILayoutInputType {
+		return input.readOCILayout(fs, inputPath, inputInfo)
+	}
+
+	if input.Type == HelmInputType {
+		if !inputInfo.IsDir() {
+			return nil, fmt.Errorf("resource type is helm but a file was provided")
+		}
+		return input.readHelmChart(ctx, fs, inputPath)
+	}
+
 	// automatically tar the input artifact if it is a directory
 	if input.Type == DirInputType {
 		if !inputInfo.IsDir() {
 			return nil, fmt.Errorf("resource type is dir but a file was provided")
 		}
 
-		var (
-			data bytes.Buffer
-		)
-		if input.Compress() {
-			input.SetMediaTypeIfNotDefined(MediaTypeGZip)
-			gw := gzip.NewWriter(&data)
-			if err := TarFileSystem(ctx, fs, inputPath, gw, TarFileSystemOptions{
-				IncludeFiles:   input.IncludeFiles,
-				ExcludeFiles:   input.ExcludeFiles,
-				PreserveDir:    input.PreserveDir,
-				FollowSymlinks: input.FollowSymlinks,
-			}); err != nil {
-				return nil, fmt.Errorf("unable to tar input artifact: %w", err)
-			}
-			if err := gw.Close(); err != nil {
-				return nil, fmt.Errorf("unable to close gzip writer: %w", err)
-			}
-		} else {
-			input.SetMediaTypeIfNotDefined(MediaTypeTar)
-			if err := TarFileSystem(ctx, fs, inputPath, &data, TarFileSystemOptions{
-				IncludeFiles:   input.IncludeFiles,
-				ExcludeFiles:   input.ExcludeFiles,
-				PreserveDir:    input.PreserveDir,
-				FollowSymlinks: input.FollowSymlinks,
-			}); err != nil {
-				return nil, fmt.Errorf("unable to tar input artifact: %w", err)
-			}
+		input.SetMediaTypeIfNotDefined(input.Compression.MediaType(MediaTypeTar))
+		var data bytes.Buffer
+		cw, err := input.Compression.Writer(&data)
+		if err != nil {
+			return nil, err
+		}
+		if err := TarFileSystem(ctx, fs, inputPath, cw, TarFileSystemOptions{
+			IncludeFiles:   input.IncludeFiles,
+			ExcludeFiles:   input.ExcludeFiles,
+			PreserveDir:    input.PreserveDir,
+			FollowSymlinks: input.FollowSymlinks,
+		}); err != nil {
+			return nil, fmt.Errorf("unable to tar input artifact: %w", err)
+		}
+		if err := cw.Close(); err != nil {
+			return nil, fmt.Errorf("unable to close %s writer: %w", input.Compression, err)
 		}
 
 		return &BlobOutput{
@@ -170,15 +179,18 @@ func (input *BlobInput) Read(ctx context.Context, fs vfs.FileSystem, inputFilePa
 			return nil, fmt.Errorf("unable to reset input file: %s", err)
 		}
 
-		if input.Compress() {
-			input.SetMediaTypeIfNotDefined(MediaTypeGZip)
+		if input.Compression.Enabled() {
+			input.SetMediaTypeIfNotDefined(input.Compression.MediaType(MediaTypeOctetStream))
 			var data bytes.Buffer
-			gw := gzip.NewWriter(&data)
-			if _, err := io.Copy(gw, inputBlob); err != nil {
+			cw, err := input.Compression.Writer(&data)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := io.Copy(cw, inputBlob); err != nil {
 				return nil, fmt.Errorf("unable to compress input file %q: %w", inputPath, err)
 			}
-			if err := gw.Close(); err != nil {
-				return nil, fmt.Errorf("unable to close gzip writer: %w", err)
+			if err := cw.Close(); err != nil {
+				return nil, fmt.Errorf("unable to close %s writer: %w", input.Compression, err)
 			}
 
 			return &BlobOutput{
@@ -211,26 +223,36 @@ type TarFileSystemOptions struct {
 
 // Included determines whether a file should be included.
 func (opts *TarFileSystemOptions) Included(path string) (bool, error) {
-	// if a root path is given remove it rom the path to be checked
-	if len(opts.root) != 0 {
-		path = strings.TrimPrefix(path, opts.root)
+	excluded, err := opts.excluded(path)
+	if err != nil || excluded {
+		return false, err
 	}
-	// first check if a exclude regex matches
+	return opts.included(path)
+}
+
+// excluded determines whether a file matches one of the exclude patterns.
+// The content of excluded directories is excluded as well.
+func (opts *TarFileSystemOptions) excluded(path string) (bool, error) {
+	path = opts.trimRoot(path)
 	for _, ex := range opts.ExcludeFiles {
 		match, err := filepath.Match(ex, path)
 		if err != nil {
 			return false, fmt.Errorf("malformed filepath syntax %q", ex)
 		}
 		if match {
-			return false, nil
+			return true, nil
 		}
 	}
+	return false, nil
+}
 
-	// if no includes are defined, include all files
+// included determines whether a file matches one of the include patterns.
+// All files are included if no include patterns are defined.
+func (opts *TarFileSystemOptions) included(path string) (bool, error) {
 	if len(opts.IncludeFiles) == 0 {
 		return true, nil
 	}
-	// otherwise check if the file should be included
+	path = opts.trimRoot(path)
 	for _, in := range opts.IncludeFiles {
 		match, err := filepath.Match(in, path)
 		if err != nil {
@@ -243,6 +265,14 @@ func (opts *TarFileSystemOptions) Included(path string) (bool, error) {
 	return false, nil
 }
 
+// trimRoot removes the root path from the path to be checked.
+func (opts *TarFileSystemOptions) trimRoot(path string) string {
+	if len(opts.root) != 0 {
+		return strings.TrimPrefix(path, opts.root)
+	}
+	return path
+}
+
 // TarFileSystem creates a tar archive from a filesystem.
 func TarFileSystem(ctx context.Context, fs vfs.FileSystem, root string, writer io.Writer, opts TarFileSystemOptions) error {
 	tw := tar.NewWriter(writer)
@@ -261,14 +291,20 @@ func addFileToTar(ctx context.Context, fs vfs.FileSystem, tw *tar.Writer, path s
 	}
 	log := logr.FromContextOrDiscard(ctx)
 
-	if len(path) != 0 { // do not check the root
-		include, err := opts.Included(path)
+	// do not check the root
+	include := true
+	if len(path) != 0 {
+		excluded, err := opts.excluded(path)
 		if err != nil {
 			return err
 		}
-		if !include {
+		if excluded {
 			return nil
 		}
+		include, err = opts.included(path)
+		if err != nil {
+			return err
+		}
 	}
 	info, err := fs.Lstat(realPath)
 	if err != nil {
@@ -283,26 +319,25 @@ func addFileToTar(ctx context.Context, fs vfs.FileSystem, tw *tar.Writer, path s
 
 	switch {
 	case info.IsDir():
-		// do not write root header
-		if len(path) != 0 {
+		// do not write root header.
+		// Directories that are not included are still traversed as their files may be included.
+		if len(path) != 0 && include {
 			if err := tw.WriteHeader(header); err != nil {
 				return fmt.Errorf("unable to write header for %q: %w", path, err)
 			}
 		}
-		err := vfs.Walk(fs, realPath, func(subFilePath string, info os.FileInfo, err error) error {
-			if subFilePath == realPath {
-				return nil
-			}
-			if err != nil {
+		entries, err := vfs.ReadDir(fs, realPath)
+		if err != nil {
+			return fmt.Errorf("unable to read directory %q: %w", realPath, err)
+		}
+		for _, entry := range entries {
+			if err := addFileToTar(ctx, fs, tw, pathutil.Join(path, entry.Name()), filepath.Join(realPath, entry.Name()), opts); err != nil {
 				return err
 			}
-			relPath, err := filepath.Rel(realPath, subFilePath)
-			if err != nil {
-				return fmt.Errorf("unable to calculate relative path for %s: %w", subFilePath, err)
-			}
-			return addFileToTar(ctx, fs, tw, pathutil.Join(path, relPath), subFilePath, opts)
-		})
-		return err
+		}
+		return nil
+	case !include:
+		return nil
 	case info.Mode().IsRegular():
 		if err := tw.WriteHeader(header); err != nil {
 			return fmt.Errorf("unable to write header for %q: %w", path, err)
@@ -0,0 +1,249 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package input
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+const (
+	// OCILayoutInputType reads an image or image index from an oci image layout directory or tar.
+	OCILayoutInputType = "ociLayout"
+
+	// MediaTypeOCIImageIndexTar is the media type of a serialized oci image index.
+	// The tar contains the image index as "index.json" and the manifests, configs and layers in a "blobs" directory.
+	MediaTypeOCIImageIndexTar = "application/vnd.oci.image.index.v1+tar"
+
+	ociLayoutIndexFile = "index.json"
+)
+
+// readOCILayout reads the image of an oci image layout and converts it into a serialized oci artifact.
+// Image indexes are preserved unless only a single manifest is left after the platforms are selected.
+func (input *BlobInput) readOCILayout(fs vfs.FileSystem, layoutPath string, info os.FileInfo) (*BlobOutput, error) {
+	platforms, err := ociclient.ParsePlatforms(input.Platforms)
+	if err != nil {
+		return nil, err
+	}
+
+	layoutFs := fs
+	if !info.IsDir() {
+		// extract the oci layout tar so that it can be read like a directory
+		layoutFs = memoryfs.New()
+		file, err := fs.Open(layoutPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open oci layout %q: %w", layoutPath, err)
+		}
+		defer file.Close()
+		if err := extractOCILayout(file, layoutFs); err != nil {
+			return nil, fmt.Errorf("unable to extract oci layout %q: %w", layoutPath, err)
+		}
+		layoutPath = "/"
+	}
+
+	var data bytes.Buffer
+	isIndex, err := ConvertOCILayout(layoutFs, layoutPath, platforms, &data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert oci layout %q: %w", input.Path, err)
+	}
+	if isIndex {
+		input.SetMediaTypeIfNotDefined(MediaTypeOCIImageIndexTar)
+	} else {
+		input.SetMediaTypeIfNotDefined(MediaTypeOCIArtifactTar)
+	}
+	return &BlobOutput{
+		Digest: digest.FromBytes(data.Bytes()).String(),
+		Size:   int64(data.Len()),
+		Reader: ioutil.NopCloser(&data),
+	}, nil
+}
+
+// ConvertOCILayout converts the image of an oci image layout directory into a serialized oci artifact.
+// Nested image indexes are flattened. If platforms are given only the manifests of these platforms are kept.
+// A single remaining manifest is written as image, otherwise an image index is written.
+// It returns whether an image index was written.
+func ConvertOCILayout(fs vfs.FileSystem, layoutPath string, platforms []ocispecv1.Platform, w io.Writer) (bool, error) {
+	layout := ociLayout{fs: fs, path: layoutPath}
+	indexData, err := vfs.ReadFile(fs, filepath.Join(layoutPath, ociLayoutIndexFile))
+	if err != nil {
+		return false, fmt.Errorf("unable to read %s: %w", ociLayoutIndexFile, err)
+	}
+	index := ocispecv1.Index{}
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		return false, fmt.Errorf("unable to decode %s: %w", ociLayoutIndexFile, err)
+	}
+	manifests, annotations, err := layout.collectManifests(index)
+	if err != nil {
+		return false, err
+	}
+	if len(manifests) == 0 {
+		return false, errors.New("the oci layout contains no image")
+	}
+	if len(platforms) != 0 {
+		filtered, err := ociclient.FilterIndex(ocispecv1.Index{Manifests: manifests}, platforms)
+		if err != nil {
+			return false, err
+		}
+		manifests = filtered.Manifests
+	}
+
+	tw := tar.NewWriter(w)
+	written := map[digest.Digest]bool{}
+	isIndex := len(manifests) != 1
+	for _, desc := range manifests {
+		manifestData, err := layout.readBlob(desc)
+		if err != nil {
+			return false, err
+		}
+		manifestFile := "manifest.json"
+		if isIndex {
+			manifestFile = path.Join("blobs", desc.Digest.Encoded())
+		}
+		if err := utils.WriteFileToTARArchive(manifestFile, bytes.NewReader(manifestData), tw); err != nil {
+			return false, fmt.Errorf("unable to write manifest: %w", err)
+		}
+		manifest := ocispecv1.Manifest{}
+		if err := json.Unmarshal(manifestData, &manifest); err != nil {
+			return false, fmt.Errorf("unable to decode manifest %s: %w", desc.Digest, err)
+		}
+		for _, blob := range append([]ocispecv1.Descriptor{manifest.Config}, manifest.Layers...) {
+			// blobs that are shared by manifests are only added once
+			if written[blob.Digest] {
+				continue
+			}
+			written[blob.Digest] = true
+			if err := layout.copyBlob(tw, blob); err != nil {
+				return false, err
+			}
+		}
+	}
+	if isIndex {
+		indexData, err := json.Marshal(ocispecv1.Index{
+			Versioned:   specs.Versioned{SchemaVersion: 2},
+			MediaType:   ocispecv1.MediaTypeImageIndex,
+			Manifests:   manifests,
+			Annotations: annotations,
+		})
+		if err != nil {
+			return false, fmt.Errorf("unable to marshal image index: %w", err)
+		}
+		if err := utils.WriteFileToTARArchive(ociLayoutIndexFile, bytes.NewReader(indexData), tw); err != nil {
+			return false, fmt.Errorf("unable to write image index: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return false, fmt.Errorf("unable to close tar writer: %w", err)
+	}
+	return isIndex, nil
+}
+
+// ociLayout reads blobs of an oci image layout directory.
+type ociLayout struct {
+	fs   vfs.FileSystem
+	path string
+}
+
+// collectManifests returns the descriptors of all manifests that are referenced by the index or nested indexes.
+// The annotations of the innermost index are returned.
+func (l ociLayout) collectManifests(index ocispecv1.Index) ([]ocispecv1.Descriptor, map[string]string, error) {
+	manifests := []ocispecv1.Descriptor{}
+	annotations := index.Annotations
+	for _, desc := range index.Manifests {
+		if !ociclient.IsMultiArchImage(desc.MediaType) {
+			manifests = append(manifests, desc)
+			continue
+		}
+		data, err := l.readBlob(desc)
+		if err != nil {
+			return nil, nil, err
+		}
+		nested := ocispecv1.Index{}
+		if err := json.Unmarshal(data, &nested); err != nil {
+			return nil, nil, fmt.Errorf("unable to decode image index %s: %w", desc.Digest, err)
+		}
+		nestedManifests, nestedAnnotations, err := l.collectManifests(nested)
+		if err != nil {
+			return nil, nil, err
+		}
+		manifests = append(manifests, nestedManifests...)
+		if len(nestedAnnotations) != 0 {
+			annotations = nestedAnnotations
+		}
+	}
+	return manifests, annotations, nil
+}
+
+func (l ociLayout) blobPath(desc ocispecv1.Descriptor) string {
+	return filepath.Join(l.path, "blobs", desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+}
+
+func (l ociLayout) readBlob(desc ocispecv1.Descriptor) ([]byte, error) {
+	data, err := vfs.ReadFile(l.fs, l.blobPath(desc))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read blob %s: %w", desc.Digest, err)
+	}
+	return data, nil
+}
+
+func (l ociLayout) copyBlob(tw *tar.Writer, desc ocispecv1.Descriptor) error {
+	data, err := l.readBlob(desc)
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFileToTARArchive(path.Join("blobs", desc.Digest.Encoded()), bytes.NewReader(data), tw); err != nil {
+		return fmt.Errorf("unable to write blob %s: %w", desc.Digest, err)
+	}
+	return nil
+}
+
+// extractOCILayout extracts the files of an oci layout tar into the filesystem.
+// Parent directories are created as they are not necessarily part of the tar.
+func extractOCILayout(r io.Reader, fs vfs.FileSystem) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("unable to read tar header: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Join("/", header.Name)
+		if err := fs.MkdirAll(path.Dir(name), os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create directory for %s: %w", header.Name, err)
+		}
+		file, err := fs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("unable to open file %s: %w", header.Name, err)
+		}
+		if _, err := io.Copy(file, tr); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("unable to extract %s: %w", header.Name, err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("unable to close file %s: %w", header.Name, err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// MergeOptions defines all options for the merge command.
+type MergeOptions struct {
+	// BaseDescriptorPath is the path to the base component descriptor.
+	BaseDescriptorPath string
+	// OverlayDescriptorPaths are the paths to the component descriptors that are merged into the base in the given order.
+	OverlayDescriptorPaths []string
+	// OutputPath is the path where the merged component descriptor is written to.
+	// The component descriptor is written to stdout if no path is defined.
+	OutputPath string
+}
+
+// NewMergeCommand creates a new command that merges component descriptors.
+func NewMergeCommand(ctx context.Context) *cobra.Command {
+	opts := &MergeOptions{}
+	cmd := &cobra.Command{
+		Use:   "merge BASE_DESCRIPTOR_PATH OVERLAY_DESCRIPTOR_PATH... [-o path]",
+		Args:  cobra.MinimumNArgs(2),
+		Short: "Merges component descriptors",
+		Long: `
+Merge merges the resources, sources and component references of one or more overlay component descriptors
+into a base component descriptor. The overlays are merged in the given order.
+
+Entries are matched by their identity. An overlay entry replaces the entry of the base with the same identity
+at its position, new entries are appended. All other fields are taken from the base component descriptor.
+Replaced entries that differ from the overlay entry are logged with a verbosity of at least 3.
+
+The merged component descriptor is validated and written to stdout unless an output path is given.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run merges the overlay component descriptors into the base component descriptor and writes the result.
+func (o *MergeOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	base, err := readComponentDescriptorFile(fs, o.BaseDescriptorPath)
+	if err != nil {
+		return err
+	}
+	overlays := make([]*cdv2.ComponentDescriptor, len(o.OverlayDescriptorPaths))
+	for i, path := range o.OverlayDescriptorPaths {
+		overlays[i], err = readComponentDescriptorFile(fs, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	cd, err := MergeComponentDescriptors(log, base, overlays...)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(cd)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	if len(o.OutputPath) == 0 {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := vfs.WriteFile(fs, o.OutputPath, data, 0664); err != nil {
+		return fmt.Errorf("unable to write merged component descriptor to %q: %w", o.OutputPath, err)
+	}
+	log.V(1).Info(fmt.Sprintf("Successfully written merged component descriptor to %q", o.OutputPath))
+	return nil
+}
+
+// MergeComponentDescriptors merges the resources, sources and component references of the overlays into a copy of the base.
+// Entries with the same identity are replaced by the overlay entry, new entries are appended in the order of the overlays.
+// The merged component descriptor is validated.
+func MergeComponentDescriptors(log logr.Logger, base *cdv2.ComponentDescriptor, overlays ...*cdv2.ComponentDescriptor) (*cdv2.ComponentDescriptor, error) {
+	cd := base.DeepCopy()
+	for _, overlay := range overlays {
+		for _, res := range overlay.Resources {
+			if id := cd.GetResourceIndex(res); id != -1 {
+				if !reflect.DeepEqual(cd.Resources[id], res) {
+					log.V(3).Info(fmt.Sprintf("Overwriting differing resource %q of component descriptor", res.GetName()))
+				}
+				cd.Resources[id] = *res.DeepCopy()
+				continue
+			}
+			cd.Resources = append(cd.Resources, *res.DeepCopy())
+		}
+		for _, src := range overlay.Sources {
+			if id := cd.GetSourceIndex(src); id != -1 {
+				if !reflect.DeepEqual(cd.Sources[id], src) {
+					log.V(3).Info(fmt.Sprintf("Overwriting differing source %q of component descriptor", src.GetName()))
+				}
+				cd.Sources[id] = *src.DeepCopy()
+				continue
+			}
+			cd.Sources = append(cd.Sources, *src.DeepCopy())
+		}
+		for _, ref := range overlay.ComponentReferences {
+			if id := cd.GetComponentReferenceIndex(ref); id != -1 {
+				if !reflect.DeepEqual(cd.ComponentReferences[id], ref) {
+					log.V(3).Info(fmt.Sprintf("Overwriting differing component reference %q of component descriptor", ref.GetName()))
+				}
+				cd.ComponentReferences[id] = *ref.DeepCopy()
+				continue
+			}
+			cd.ComponentReferences = append(cd.ComponentReferences, *ref.DeepCopy())
+		}
+	}
+
+	if err := cdvalidation.Validate(cd); err != nil {
+		return nil, fmt.Errorf("invalid merged component descriptor: %w", err)
+	}
+	return cd, nil
+}
+
+// readComponentDescriptorFile reads a component descriptor from a file without validating it,
+// as overlays may only contain fragments of a component descriptor.
+func readComponentDescriptorFile(fs vfs.FileSystem, path string) (*cdv2.ComponentDescriptor, error) {
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component descriptor from %q: %w", path, err)
+	}
+	cd := &cdv2.ComponentDescriptor{}
+	if err := codec.Decode(data, cd, codec.DisableValidation(true)); err != nil {
+		return nil, fmt.Errorf("unable to decode component descriptor from %q: %w", path, err)
+	}
+	return cd, nil
+}
+
+// Complete parses the given command arguments.
+func (o *MergeOptions) Complete(args []string) error {
+	if len(args) < 2 {
+		return errors.New("expected a base and at least one overlay component descriptor")
+	}
+	o.BaseDescriptorPath = args[0]
+	o.OverlayDescriptorPaths = args[1:]
+	return nil
+}
+
+func (o *MergeOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.OutputPath, "output", "o", "", "[OPTIONAL] path where the merged component descriptor is written to. Defaults to stdout")
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"context"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+)
+
+const mergeBaseDescriptor = `meta:
+  schemaVersion: 'v2'
+component:
+  name: 'example.com/component'
+  version: 'v0.0.1'
+  repositoryContexts: []
+  provider: 'internal'
+  sources:
+  - name: 'repo'
+    version: 'v0.0.1'
+    type: 'git'
+    access:
+      type: 'github'
+      repoUrl: 'github.com/example/component'
+      commit: 'abc'
+  componentReferences:
+  - name: 'dep'
+    componentName: 'example.com/dep'
+    version: 'v1.0.0'
+  resources:
+  - name: 'image'
+    version: 'v0.0.1'
+    type: 'ociImage'
+    relation: 'external'
+    access:
+      type: 'ociRegistry'
+      imageReference: 'example.com/image:v0.0.1'
+  - name: 'chart'
+    version: 'v0.0.1'
+    type: 'helm'
+    relation: 'external'
+    access:
+      type: 'ociRegistry'
+      imageReference: 'example.com/chart:v0.0.1'
+`
+
+const mergeOverlayDescriptor = `meta:
+  schemaVersion: 'v2'
+component:
+  name: 'example.com/component'
+  version: 'v0.0.1'
+  componentReferences:
+  - name: 'other'
+    componentName: 'example.com/other'
+    version: 'v2.0.0'
+  resources:
+  - name: 'image'
+    version: 'v0.0.1'
+    type: 'ociImage'
+    relation: 'external'
+    access:
+      type: 'ociRegistry'
+      imageReference: 'example.com/image:v0.0.1-patched'
+  - name: 'docs'
+    version: 'v0.0.1'
+    type: 'blob'
+    relation: 'external'
+    access:
+      type: 'ociRegistry'
+      imageReference: 'example.com/docs:v0.0.1'
+`
+
+var _ = Describe("Merge", func() {
+
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(vfs.WriteFile(fs, "/base.yaml", []byte(mergeBaseDescriptor), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/overlay.yaml", []byte(mergeOverlayDescriptor), os.ModePerm)).To(Succeed())
+	})
+
+	It("should merge overlapping and new entries of the overlay into the base", func() {
+		opts := &componentarchive.MergeOptions{OutputPath: "/merged.yaml"}
+		Expect(opts.Complete([]string{"/base.yaml", "/overlay.yaml"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		data, err := vfs.ReadFile(fs, "/merged.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+
+		Expect(cd.GetName()).To(Equal("example.com/component"))
+		Expect(cd.Provider).To(Equal(cdv2.InternalProvider))
+		Expect(cd.Resources).To(HaveLen(3))
+		Expect(cd.Resources[0].GetName()).To(Equal("image"))
+		Expect(cd.Resources[1].GetName()).To(Equal("chart"))
+		Expect(cd.Resources[2].GetName()).To(Equal("docs"))
+		imageAccess := &cdv2.OCIRegistryAccess{}
+		Expect(cd.Resources[0].Access.DecodeInto(imageAccess)).To(Succeed())
+		Expect(imageAccess.ImageReference).To(Equal("example.com/image:v0.0.1-patched"))
+
+		Expect(cd.Sources).To(HaveLen(1))
+		Expect(cd.ComponentReferences).To(HaveLen(2))
+		Expect(cd.ComponentReferences[0].GetName()).To(Equal("dep"))
+		Expect(cd.ComponentReferences[1].GetName()).To(Equal("other"))
+	})
+
+	It("should apply multiple overlays in order and not modify the base", func() {
+		base := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode([]byte(mergeBaseDescriptor), base)).To(Succeed())
+		overlay := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode([]byte(mergeOverlayDescriptor), overlay, codec.DisableValidation(true))).To(Succeed())
+		second := overlay.DeepCopy()
+		second.Resources = second.Resources[:1]
+		second.Resources[0].Version = "v0.0.2"
+		second.ComponentReferences = nil
+
+		cd, err := componentarchive.MergeComponentDescriptors(logr.Discard(), base, overlay, second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cd.Resources).To(HaveLen(3))
+		Expect(cd.Resources[0].GetVersion()).To(Equal("v0.0.2"))
+		Expect(base.Resources).To(HaveLen(2))
+		Expect(base.Resources[0].GetVersion()).To(Equal("v0.0.1"))
+
+		again, err := componentarchive.MergeComponentDescriptors(logr.Discard(), base, overlay, second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(again).To(Equal(cd))
+	})
+
+	It("should fail if the merged component descriptor is invalid", func() {
+		Expect(vfs.WriteFile(fs, "/invalid.yaml", []byte(`meta:
+  schemaVersion: 'v2'
+component:
+  resources:
+  - name: 'image'
+    version: 'v0.0.1'
+    type: 'ociImage'
+    relation: 'unknown'
+`), os.ModePerm)).To(Succeed())
+
+		opts := &componentarchive.MergeOptions{OutputPath: "/merged.yaml"}
+		Expect(opts.Complete([]string{"/base.yaml", "/invalid.yaml"})).To(Succeed())
+		err := opts.Run(context.TODO(), logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid merged component descriptor"))
+		_, err = fs.Stat("/merged.yaml")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+})
@@ -11,7 +11,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/apis/v2/cdutils"
@@ -19,6 +18,7 @@ import (
 	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -33,6 +33,18 @@ import (
 	"github.com/gardener/component-cli/pkg/utils"
 )
 
+// ConflictPolicy defines how a resource is added if the component descriptor already contains a resource with the same identity.
+type ConflictPolicy string
+
+const (
+	// ConflictMerge merges the attributes and labels of the added resource into the existing resource.
+	ConflictMerge ConflictPolicy = "merge"
+	// ConflictReplace replaces the existing resource with the added resource.
+	ConflictReplace ConflictPolicy = "replace"
+	// ConflictFail fails if the resource already exists.
+	ConflictFail ConflictPolicy = "fail"
+)
+
 // Options defines the options that are used to add resources to a component descriptor
 type Options struct {
 	componentarchive.BuilderOptions
@@ -45,6 +57,29 @@ type Options struct {
 	// ResourceObjectPaths contains paths to read the yaml resource template from.
 	// If "-" is provided, the resource is read from stdin
 	ResourceObjectPaths []string
+
+	// OnConflict defines how a resource is added if a resource with the same identity already exists.
+	// Defaults to replace for resources with an input and to merge for all other resources.
+	OnConflict string
+	// Merge is a shorthand for the merge conflict policy.
+	Merge bool
+	// Replace is a shorthand for the replace conflict policy.
+	Replace bool
+
+	// DigestAlgorithm is the hash algorithm that is used to calculate the digest of input blobs.
+	// Defaults to sha256.
+	DigestAlgorithm string
+	// Verify verifies the local blobs of the component archive against their recorded digests before resources are added.
+	Verify bool
+
+	// Backup keeps the previous component descriptor as component-descriptor.yaml.bak.
+	Backup bool
+
+	// DryRun prints the changes of the component descriptor as diff instead of writing the component archive.
+	DryRun bool
+	// Output is the writer the diff is printed to in dry-run mode.
+	// Defaults to stdout.
+	Output io.Writer
 }
 
 // ResourceOptions contains options that are used to describe a resource
@@ -74,12 +109,24 @@ func NewAddCommand(ctx context.Context) *cobra.Command {
 		Short: "Adds a resource to an component archive",
 		Long: fmt.Sprintf(`
 add generates resources from a resource template and adds it to the given component descriptor in the component archive.
-If the resource is already defined (quality by identity) in the component-descriptor it will be overwritten.
+If the resource is already defined (equality by identity) in the component-descriptor the policy defined by "--on-conflict" is applied:
+- merge ("--merge"): the attributes and labels of the added resource are merged into the existing resource.
+- replace ("--replace"): the existing resource is replaced.
+- fail: the command fails.
+By default resources with an input are replaced and all other resources are merged.
+Blobs of input resources are stored by their digest, so identical blobs are only stored once in the component archive.
+The blob of an overwritten resource is removed if it is not referenced by another resource or source.
+The digest of the blob is calculated with the algorithm defined by "--digest-algorithm" and stored in the resource.
+With "--verify" the local blobs of the component archive are verified against their recorded digests before any resource is added.
+
+With "--dry-run" the changes of the component descriptor are printed to stdout as unified diff
+and neither the component descriptor nor blobs are written to the component archive.
 
 The component archive can be specified by the first argument, the flag "--archive" or as env var "COMPONENT_ARCHIVE_PATH".
-The component archive is expected to be a filesystem archive. If the archive is given as tar please use the export command.
+The component archive can be a directory or a tar or tgz file. A tar or tgz file is unpacked in memory and written back in its original format.
 
-The resource template can be defined by specifying a file with the template with "resource" or it can be given through stdin.
+The resource templates are read from the given paths and the files defined by "--file".
+Stdin is only read if it is explicitly requested with "-" as path, e.g. "--file -". The command fails if stdin is an interactive terminal.
 
 The resource template is a multidoc yaml file so multiple templates can be defined.
 
@@ -101,7 +148,7 @@ relation: 'local'
 input:
   type: "file"
   path: "some/path"
-  mediaType: "application/octet-stream" # optional, defaulted to "application/octet-stream" or "application/gzip" if compress=true 
+  mediaType: "application/octet-stream" # optional, defaulted to "application/octet-stream" or "application/gzip"/"application/zstd" if compressed 
 ...
 ---
 name: 'myconfig'
@@ -110,18 +157,58 @@ relation: 'local'
 input:
   type: "dir"
   path: /my/path
-  compress: true # defaults to false
+  compress: gzip # optional; one of "none", "gzip" or "zstd", defaults to "none". true is treated as "gzip"
   includeFiles: # optional; list of shell file patterns
   - "*.txt"
   excludeFiles: # optional; list of shell file patterns
   - "*.txt"
-  mediaType: "application/gzip" # optional, defaulted to "application/x-tar" or "application/gzip" if compress=true 
+  mediaType: "application/gzip" # optional, defaulted to "application/x-tar" or "application/gzip"/"application/zstd" if compressed 
   preserveDir: true # optional, defaulted to false; if true, the top level folder "my/path" is included
   followSymlinks: true # optional, defaulted to false; if true, symlinks are resolved and the content is included in the tar
 ...
+---
+name: 'myimage'
+type: 'ociImage' # optional, defaulted to "ociImage"
+relation: 'local'
+input:
+  type: "docker"
+  path: "myimage:dev" # image of the local docker daemon
+  mediaType: "application/vnd.oci.image.manifest.v1+tar" # optional, defaulted to "application/vnd.oci.image.manifest.v1+tar"
+...
+---
+name: 'myimage'
+type: 'ociImage' # optional, defaulted to "ociImage"
+relation: 'local'
+input:
+  type: "ociLayout"
+  path: /my/image # oci image layout directory or tar, e.g. created with "docker buildx build --output type=oci"
+  platforms: # optional; only the manifests of these platforms are added
+  - linux/amd64
+  - linux/arm64
+...
+---
+name: 'mychart'
+type: 'helm.io/chart' # optional, defaulted to "helm.io/chart"
+relation: 'local'
+input:
+  type: "helm"
+  path: /my/chart # chart directory that contains the Chart.yaml
+  mediaType: "application/vnd.cncf.helm.chart.content.v1.tar+gzip" # optional, defaulted to "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+...
 
 </pre>
 
+An input of type "docker" exports the image from the local docker daemon (defined by the env var "DOCKER_HOST",
+defaults to "unix:///var/run/docker.sock") and adds it as oci artifact blob to the component archive.
+The blob is uploaded as "localOciBlob" when the component archive is pushed to an oci registry.
+
+An input of type "ociLayout" adds the image of an oci image layout as oci artifact blob.
+Multi-arch images are added with their image index unless a single manifest is selected with "platforms".
+
+An input of type "helm" packages the chart directory like "helm package" and respects the ".helmignore" file.
+Dependencies have to be part of the "charts" directory of the chart (e.g. with "helm dependency update")
+or reference a local chart with a "file://" repository, which is then packaged into the "charts" directory.
+
 Alternativly the resources can also be defined as list of resources (both methods can also be combined).
 
 <pre>
@@ -142,7 +229,7 @@ resources:
   input:
     type: "file"
     path: "some/path"
-    mediaType: "application/octet-stream" # optional, defaulted to "application/octet-stream" or "application/gzip" if compress=true
+    mediaType: "application/octet-stream" # optional, defaulted to "application/octet-stream" or "application/gzip"/"application/zstd" if compressed
 
 </pre>
 
@@ -167,33 +254,80 @@ resources:
 }
 
 func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
-	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
+	if err := o.TemplateOptions.LoadValuesFiles(fs); err != nil {
+		return err
+	}
+
+	fs, repack, err := componentarchive.Unpack(fs, o.ComponentArchivePath)
+	if err != nil {
+		return err
+	}
+
+	var oldData []byte
+	if o.DryRun {
+		oldData, err = componentarchive.ReadComponentDescriptorData(fs, o.ComponentArchivePath)
+		if err != nil {
+			return err
+		}
+		// all modifications are only written to memory in dry-run mode
+		fs, err = utils.NewOverlayFS(fs)
+		if err != nil {
+			return fmt.Errorf("unable to create in-memory filesystem: %w", err)
+		}
+	}
 
 	archive, err := o.BuilderOptions.Build(fs)
 	if err != nil {
 		return err
 	}
 
+	if o.Verify {
+		archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
+		if err != nil {
+			return fmt.Errorf("unable to create projectionfilesystem: %w", err)
+		}
+		if err := componentarchive.VerifyLocalBlobDigests(archiveFs, archive.ComponentDescriptor); err != nil {
+			return fmt.Errorf("unable to verify local blobs: %w", err)
+		}
+		log.V(3).Info("Successfully verified local blobs")
+	}
+
 	resources, err := o.generateResources(log, fs, archive.ComponentDescriptor)
 	if err != nil {
 		return err
 	}
 
 	log.V(3).Info(fmt.Sprintf("Adding %d resources...", len(resources)))
+	// replaced are the accesses of the replaced resources whose blobs are removed if they are no longer referenced
+	replaced := []*cdv2.UnstructuredTypedObject{}
 	for _, resource := range resources {
 		log := log.WithValues("resource-name", resource.Name, "resource-version", resource.Version)
 		utils.PrintPrettyYaml(resource, log.V(5).Enabled())
 
+		policy := o.conflictPolicy(resource.Input != nil)
+		if policy == ConflictFail && archive.ComponentDescriptor.GetResourceIndex(resource.Resource) != -1 {
+			return fmt.Errorf("resource %q already exists in the component descriptor", resource.Name)
+		}
+
 		if resource.Input != nil {
 			log.Info(fmt.Sprintf("add input blob from %q", resource.Input.Path))
-			if err := o.addInputBlob(ctx, fs, archive, &resource); err != nil {
+			replacedAccess, err := o.addInputBlob(ctx, fs, archive, &resource)
+			if err != nil {
 				return err
 			}
+			if replacedAccess != nil {
+				replaced = append(replaced, replacedAccess)
+			}
 		} else {
 			id := archive.ComponentDescriptor.GetResourceIndex(resource.Resource)
 			if id != -1 {
-				log.V(5).Info("Found existing resource in component descriptor, attempt merge...")
-				mergedRes := cdutils.MergeResources(archive.ComponentDescriptor.Resources[id], resource.Resource)
+				mergedRes := resource.Resource
+				if policy == ConflictMerge {
+					log.V(5).Info("Found existing resource in component descriptor, attempt merge...")
+					mergedRes = cdutils.MergeResources(archive.ComponentDescriptor.Resources[id], resource.Resource)
+				} else {
+					log.V(5).Info("Found existing resource in component descriptor, replace it...")
+				}
 				if errList := cdvalidation.ValidateResource(field.NewPath(""), mergedRes); len(errList) != 0 {
 					return errList.ToAggregate()
 				}
@@ -214,13 +348,42 @@ func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) e
 		if err != nil {
 			return fmt.Errorf("unable to encode component descriptor: %w", err)
 		}
-		if err := vfs.WriteFile(fs, compDescFilePath, data, 0664); err != nil {
+		if err := componentarchive.WriteComponentDescriptor(fs, o.ComponentArchivePath, data, o.Backup); err != nil {
 			return fmt.Errorf("unable to write modified comonent descriptor: %w", err)
 		}
 		log.V(2).Info("Successfully added resource to component descriptor")
 	}
+
+	// the blobs of replaced resources are only removed after the modified component descriptor has been written,
+	// so that an invalid component descriptor or a failed write never leaves a resource without its blob.
+	if len(replaced) != 0 {
+		archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
+		if err != nil {
+			return fmt.Errorf("unable to create projectionfilesystem: %w", err)
+		}
+		for _, access := range replaced {
+			if err := componentarchive.RemoveUnreferencedBlob(log, archiveFs, archive.ComponentDescriptor, access); err != nil {
+				return err
+			}
+		}
+	}
+	if o.DryRun {
+		data, err := yaml.Marshal(archive.ComponentDescriptor)
+		if err != nil {
+			return fmt.Errorf("unable to encode component descriptor: %w", err)
+		}
+		return componentarchive.WriteComponentDescriptorDiff(o.output(), o.ComponentArchivePath, oldData, data)
+	}
 	log.V(2).Info("Successfully added all resources to component descriptor")
-	return nil
+	return repack()
+}
+
+// output returns the writer for the dry-run output.
+func (o *Options) output() io.Writer {
+	if o.Output == nil {
+		return os.Stdout
+	}
+	return o.Output
 }
 
 func (o *Options) Complete(args []string) error {
@@ -241,49 +404,58 @@ func (o *Options) Complete(args []string) error {
 }
 
 func (o *Options) validate() error {
+	if o.Merge && o.Replace {
+		return errors.New("only one of --merge and --replace can be defined")
+	}
+	if len(o.OnConflict) != 0 {
+		if o.Merge || o.Replace {
+			return errors.New("--on-conflict cannot be combined with --merge or --replace")
+		}
+		switch ConflictPolicy(o.OnConflict) {
+		case ConflictMerge, ConflictReplace, ConflictFail:
+		default:
+			return fmt.Errorf("unknown conflict policy %q, expected one of %q, %q, %q", o.OnConflict, ConflictMerge, ConflictReplace, ConflictFail)
+		}
+	}
+	if len(o.DigestAlgorithm) != 0 {
+		if err := componentarchive.ValidateDigestAlgorithm(o.DigestAlgorithm); err != nil {
+			return err
+		}
+	}
 	return o.BuilderOptions.Validate()
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	o.BuilderOptions.AddFlags(fs)
+	o.TemplateOptions.AddFlags(fs)
 	// specify the resource
+	fs.StringArrayVarP(&o.ResourceObjectPaths, "file", "f", nil, "[OPTIONAL] path to a file with resource templates. Use \"-\" to read from stdin. Can be defined multiple times")
 	fs.StringVarP(&o.ResourceObjectPath, "resource", "r", "", "The path to the resources defined as yaml or json")
 	_ = fs.MarkDeprecated("resource", "the flag r is deprecated use command args instead")
+	fs.StringVar(&o.OnConflict, "on-conflict", "",
+		fmt.Sprintf("[OPTIONAL] policy for resources that already exist in the component descriptor. One of %q, %q, %q. Defaults to %q for resources with an input and %q for all other resources", ConflictMerge, ConflictReplace, ConflictFail, ConflictReplace, ConflictMerge))
+	fs.BoolVar(&o.Merge, "merge", false, "[OPTIONAL] merges resources that already exist in the component descriptor. Shorthand for --on-conflict=merge")
+	fs.BoolVar(&o.Replace, "replace", false, "[OPTIONAL] replaces resources that already exist in the component descriptor. Shorthand for --on-conflict=replace")
+	fs.StringVar(&o.DigestAlgorithm, "digest-algorithm", componentarchive.SHA256,
+		fmt.Sprintf("[OPTIONAL] hash algorithm that is used to calculate the digest of input blobs. One of %q, %q", componentarchive.SHA256, componentarchive.SHA512))
+	fs.BoolVar(&o.Verify, "verify", false, "[OPTIONAL] verifies the local blobs of the component archive against their recorded digests before resources are added")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "[OPTIONAL] prints the changes of the component descriptor as diff instead of writing the component archive")
+	fs.BoolVar(&o.Backup, "backup", false, "[OPTIONAL] keeps the previous component descriptor as component-descriptor.yaml.bak if the component archive is a directory")
 }
 
 func (o *Options) generateResources(log logr.Logger, fs vfs.FileSystem, cd *cdv2.ComponentDescriptor) ([]InternalResourceOptions, error) {
-	if len(o.ResourceObjectPaths) == 0 {
-		// try to read from stdin if no resources are defined
-		resources := make([]InternalResourceOptions, 0)
-		stdinInfo, err := os.Stdin.Stat()
-		if err != nil {
-			log.V(3).Info("unable to read from stdin", "error", err.Error())
-			return nil, nil
-		}
-		if (stdinInfo.Mode()&os.ModeNamedPipe != 0) || stdinInfo.Size() != 0 {
-			stdinResources, err := o.generateResourcesFromReader(log, cd, os.Stdin)
-			if err != nil {
-				return nil, fmt.Errorf("unable to read from stdin: %w", err)
-			}
-			resources = append(resources, convertToInternalResourceOptions(stdinResources, "")...)
-		}
-		return resources, nil
-	}
-
 	resources := make([]InternalResourceOptions, 0)
 	for _, resourcePath := range o.ResourceObjectPaths {
-		if resourcePath == "-" {
-			stdinInfo, err := os.Stdin.Stat()
+		if resourcePath == utils.StdinPath {
+			stdin, err := utils.Stdin()
 			if err != nil {
-				return nil, fmt.Errorf("unable to read from stdin: %w", err)
+				return nil, err
 			}
-			if (stdinInfo.Mode()&os.ModeNamedPipe != 0) || stdinInfo.Size() != 0 {
-				stdinResources, err := o.generateResourcesFromReader(log, cd, os.Stdin)
-				if err != nil {
-					return nil, fmt.Errorf("unable to read from stdin: %w", err)
-				}
-				resources = append(resources, convertToInternalResourceOptions(stdinResources, "")...)
+			stdinResources, err := o.generateResourcesFromReader(log, cd, stdin)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read from stdin: %w", err)
 			}
+			resources = append(resources, convertToInternalResourceOptions(stdinResources, "")...)
 			continue
 		}
 
@@ -370,14 +542,33 @@ func generateResourcesFromReader(cd *cdv2.ComponentDescriptor, reader io.Reader)
 	return resources, nil
 }
 
-func (o *Options) addInputBlob(ctx context.Context, fs vfs.FileSystem, archive *ctf.ComponentArchive, resource *InternalResourceOptions) error {
+// addInputBlob adds the blob of the input of the resource to the component archive.
+// It returns the access of the resource that is replaced by the added resource, its blob is kept.
+func (o *Options) addInputBlob(ctx context.Context, fs vfs.FileSystem, archive *ctf.ComponentArchive, resource *InternalResourceOptions) (*cdv2.UnstructuredTypedObject, error) {
 	blob, err := resource.Input.Read(ctx, fs, resource.Path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// default media type to binary data if nothing else is defined
 	resource.Input.SetMediaTypeIfNotDefined(input.MediaTypeOctetStream)
+	// default the resource type of images and helm charts
+	if len(resource.Type) == 0 {
+		switch resource.Input.Type {
+		case input.DockerInputType, input.OCILayoutInputType:
+			resource.Type = cdv2.OCIImageType
+		case input.HelmInputType:
+			resource.Type = input.HelmChartResourceType
+		}
+	}
 
+	// remember the resource that is replaced so that it can be merged and its blob can be removed if it is not shared
+	var replaced *cdv2.Resource
+	if id := archive.ComponentDescriptor.GetResourceIndex(resource.Resource); id != -1 {
+		existing := archive.ComponentDescriptor.Resources[id]
+		replaced = &existing
+	}
+
+	// blobs are stored by their digest so identical blobs are only stored once
 	err = archive.AddResource(&resource.Resource, ctf.BlobInfo{
 		MediaType: resource.Input.MediaType,
 		Digest:    blob.Digest,
@@ -385,12 +576,53 @@ func (o *Options) addInputBlob(ctx context.Context, fs vfs.FileSystem, archive *
 	}, blob.Reader)
 	if err != nil {
 		blob.Reader.Close()
-		return fmt.Errorf("unable to add input blob to archive: %w", err)
+		return nil, fmt.Errorf("unable to add input blob to archive: %w", err)
 	}
 	if err := blob.Reader.Close(); err != nil {
-		return fmt.Errorf("unable to close input file: %w", err)
+		return nil, fmt.Errorf("unable to close input file: %w", err)
+	}
+
+	id := archive.ComponentDescriptor.GetResourceIndex(resource.Resource)
+	if replaced != nil && o.conflictPolicy(true) == ConflictMerge {
+		archive.ComponentDescriptor.Resources[id] = cdutils.MergeResources(*replaced, archive.ComponentDescriptor.Resources[id])
+	}
+	archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create projectionfilesystem: %w", err)
+	}
+	digest, err := componentarchive.LocalBlobDigest(archiveFs, blob.Digest, o.digestAlgorithm())
+	if err != nil {
+		return nil, fmt.Errorf("unable to calculate digest of input blob: %w", err)
+	}
+	archive.ComponentDescriptor.Resources[id].Digest = digest
+	if replaced == nil {
+		return nil, nil
+	}
+	return replaced.Access, nil
+}
+
+// digestAlgorithm returns the hash algorithm for the digest of input blobs.
+func (o *Options) digestAlgorithm() string {
+	if len(o.DigestAlgorithm) == 0 {
+		return componentarchive.SHA256
+	}
+	return o.DigestAlgorithm
+}
+
+// conflictPolicy returns the policy for a resource that already exists in the component descriptor.
+func (o *Options) conflictPolicy(hasInput bool) ConflictPolicy {
+	switch {
+	case len(o.OnConflict) != 0:
+		return ConflictPolicy(o.OnConflict)
+	case o.Merge:
+		return ConflictMerge
+	case o.Replace:
+		return ConflictReplace
+	case hasInput:
+		return ConflictReplace
+	default:
+		return ConflictMerge
 	}
-	return nil
 }
 
 func convertToInternalResourceOptions(resOpts []ResourceOptions, filepath string) []InternalResourceOptions {
@@ -7,9 +7,16 @@ package resources_test
 import (
 	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -18,18 +25,21 @@ import (
 	"github.com/gardener/component-spec/bindings-go/codec"
 	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/go-logr/logr"
-	"github.com/mandelsoft/vfs/pkg/layerfs"
-	"github.com/mandelsoft/vfs/pkg/memoryfs"
-	"github.com/mandelsoft/vfs/pkg/osfs"
-	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/klauspost/compress/zstd"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/input"
 	"github.com/gardener/component-cli/pkg/commands/componentarchive/resources"
 	"github.com/gardener/component-cli/pkg/componentarchive"
 	"github.com/gardener/component-cli/pkg/template"
+	"github.com/gardener/component-cli/pkg/testutils"
 	"github.com/gardener/component-cli/pkg/utils"
 )
 
@@ -43,9 +53,7 @@ var _ = Describe("Add", func() {
 	var testdataFs vfs.FileSystem
 
 	BeforeEach(func() {
-		fs, err := projectionfs.New(osfs.New(), "./testdata")
-		Expect(err).ToNot(HaveOccurred())
-		testdataFs = layerfs.New(memoryfs.New(), fs)
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
 	})
 
 	It("should add a resource defined by a file", func() {
@@ -166,7 +174,7 @@ var _ = Describe("Add", func() {
 		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("imageReference", "ubuntu:18.0"))
 	})
 
-	It("should add a resource defined by stdin if nothing is defined", func() {
+	It("should not read stdin if it is not requested", func() {
 		input, err := os.Open("./testdata/resources/00-res.yaml")
 		Expect(err).ToNot(HaveOccurred())
 		defer input.Close()
@@ -187,18 +195,37 @@ var _ = Describe("Add", func() {
 
 		cd := &cdv2.ComponentDescriptor{}
 		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.Resources).To(HaveLen(0))
 
-		Expect(cd.Resources).To(HaveLen(1))
-		Expect(cd.Resources[0].IdentityObjectMeta).To(MatchFields(IgnoreExtras, Fields{
-			"Name":    Equal("ubuntu"),
-			"Version": Equal("v0.0.1"),
-			"Type":    Equal("ociImage"),
-		}))
-		Expect(cd.Resources[0]).To(MatchFields(IgnoreExtras, Fields{
-			"Relation": Equal(cdv2.ResourceRelation("external")),
-		}))
-		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("type", "ociRegistry"))
-		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("imageReference", "ubuntu:18.0"))
+		offset, err := input.Seek(0, io.SeekCurrent)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(offset).To(BeZero())
+	})
+
+	It("should read empty stdin that is redirected from a character device", func() {
+		// /dev/null is a character device but no interactive terminal, so it is read as empty input
+		input, err := os.Open(os.DevNull)
+		Expect(err).ToNot(HaveOccurred())
+		defer input.Close()
+		oldstdin := os.Stdin
+		defer func() {
+			os.Stdin = oldstdin
+		}()
+		os.Stdin = input
+
+		opts := &resources.Options{
+			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ResourceObjectPaths: []string{"-"},
+		}
+
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.Resources).To(HaveLen(0))
 	})
 
 	It("should automatically set the version for a local resource", func() {
@@ -304,6 +331,90 @@ var _ = Describe("Add", func() {
 		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("imageReference", "ubuntu:18.0"))
 	})
 
+	Context("Conflicts", func() {
+
+		BeforeEach(func() {
+			Expect(vfs.WriteFile(testdataFs, "./resources/labeled.yaml", []byte(`
+name: 'ubuntu'
+version: 'v0.0.1'
+type: 'ociImage'
+relation: 'external'
+labels:
+- name: 'existing'
+  value: true
+access:
+  type: 'ociRegistry'
+  imageReference: 'ubuntu:18.0'
+`), os.ModePerm)).To(Succeed())
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./01-component"},
+				ResourceObjectPaths: []string{"./resources/labeled.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		})
+
+		readResources := func() []cdv2.Resource {
+			data, err := vfs.ReadFile(testdataFs, filepath.Join("./01-component", ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			return cd.Resources
+		}
+
+		labelNames := func(labels cdv2.Labels) []string {
+			names := []string{}
+			for _, label := range labels {
+				names = append(names, label.Name)
+			}
+			return names
+		}
+
+		It("should merge an existing resource", func() {
+			opts := &resources.Options{Merge: true}
+			Expect(opts.Complete([]string{"./01-component", "./resources/30-res-update.yaml"})).To(Succeed())
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			res := readResources()
+			Expect(res).To(HaveLen(1))
+			Expect(res[0].Version).To(Equal("v0.0.2"))
+			Expect(res[0].Access.Object).To(HaveKeyWithValue("imageReference", "ubuntu:20.04"))
+			Expect(labelNames(res[0].Labels)).To(ConsistOf("existing", "update"))
+		})
+
+		It("should replace an existing resource", func() {
+			opts := &resources.Options{Replace: true}
+			Expect(opts.Complete([]string{"./01-component", "./resources/30-res-update.yaml"})).To(Succeed())
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			res := readResources()
+			Expect(res).To(HaveLen(1))
+			Expect(res[0].Version).To(Equal("v0.0.2"))
+			Expect(labelNames(res[0].Labels)).To(ConsistOf("update"))
+		})
+
+		It("should fail if the resource already exists", func() {
+			opts := &resources.Options{OnConflict: string(resources.ConflictFail)}
+			Expect(opts.Complete([]string{"./01-component", "./resources/30-res-update.yaml"})).To(Succeed())
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(MatchError(`resource "ubuntu" already exists in the component descriptor`))
+
+			res := readResources()
+			Expect(res).To(HaveLen(1))
+			Expect(res[0].Version).To(Equal("v0.0.1"))
+		})
+
+		It("should not allow an unknown conflict policy", func() {
+			opts := &resources.Options{OnConflict: "skip"}
+			Expect(opts.Complete([]string{"./01-component"})).To(HaveOccurred())
+		})
+
+		It("should not allow --merge and --replace at the same time", func() {
+			opts := &resources.Options{Merge: true, Replace: true}
+			Expect(opts.Complete([]string{"./01-component"})).To(HaveOccurred())
+		})
+
+	})
+
 	It("should throw an error if an invalid resource is defined", func() {
 		opts := &resources.Options{
 			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
@@ -351,6 +462,241 @@ var _ = Describe("Add", func() {
 			Expect(blobs).To(HaveLen(1))
 		})
 
+		It("should store identical blobs only once", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/29-res-shared-blob.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+
+			Expect(cd.Resources).To(HaveLen(2))
+			Expect(cd.Resources[0].Access.Object["filename"]).To(Equal(cd.Resources[1].Access.Object["filename"]))
+
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+		})
+
+		It("should remove the blob of a replaced resource", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/20-res-json.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			opts = &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/21-res-file-zstd.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(HaveLen(1))
+
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+			Expect(blobs[0].Name()).To(Equal(cd.Resources[0].Access.Object["filename"]))
+		})
+
+		It("should keep the blob of a replaced resource if the component descriptor is invalid", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/20-res-json.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+			oldData, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+
+			invalid := `name: 'myconfig'
+version: 'v0.0.1'
+type: 'jsonschema'
+relation: 'unknown'
+input:
+  type: file
+  path: "./21-jsonschema.json"
+  compress: true
+`
+			Expect(vfs.WriteFile(testdataFs, "./resources/invalid.yaml", []byte(invalid), os.ModePerm)).To(Succeed())
+			opts = &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/invalid.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(oldData))
+			_, err = testdataFs.Stat(filepath.Join(opts.ComponentArchivePath, ctf.BlobPath(blobs[0].Name())))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should store the sha256 digest of an input blob by default", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/20-res-json.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(HaveLen(1))
+			Expect(cd.Resources[0].Digest).ToNot(BeNil())
+			Expect(cd.Resources[0].Digest.HashAlgorithm).To(Equal(componentarchive.SHA256))
+			Expect(cd.Resources[0].Digest.NormalisationAlgorithm).To(Equal(string(cdv2.GenericBlobDigestV1)))
+			Expect(cd.Resources[0].Access.Object["filename"]).To(Equal("sha256:" + cd.Resources[0].Digest.Value))
+		})
+
+		It("should store the sha512 digest of an input blob", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/20-res-json.yaml"},
+				DigestAlgorithm:     componentarchive.SHA512,
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(HaveLen(1))
+
+			blob, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobPath(cd.Resources[0].Access.Object["filename"].(string))))
+			Expect(err).ToNot(HaveOccurred())
+			sum := sha512.Sum512(blob)
+			Expect(cd.Resources[0].Digest).ToNot(BeNil())
+			Expect(cd.Resources[0].Digest.HashAlgorithm).To(Equal(componentarchive.SHA512))
+			Expect(cd.Resources[0].Digest.Value).To(Equal(hex.EncodeToString(sum[:])))
+		})
+
+		It("should fail to verify a modified blob", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/20-res-json.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			opts = &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/21-res-file-zstd.yaml"},
+				Verify:              true,
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			blobPath := filepath.Join(opts.ComponentArchivePath, ctf.BlobPath(cd.Resources[0].Access.Object["filename"].(string)))
+			Expect(vfs.WriteFile(testdataFs, blobPath, []byte("modified"), os.ModePerm)).To(Succeed())
+
+			err = opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unable to verify local blobs"))
+		})
+
+		It("should not allow an unknown digest algorithm", func() {
+			opts := &resources.Options{DigestAlgorithm: "md5"}
+			Expect(opts.Complete([]string{"./00-component", "./resources/20-res-json.yaml"})).ToNot(Succeed())
+		})
+
+		It("should add a resource to a tgz component archive", func() {
+			ca, _, err := componentarchive.Parse(testdataFs, "./00-component")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(componentarchive.Write(testdataFs, "./ca.tgz", ca, ctf.ArchiveFormatTarGzip)).To(Succeed())
+
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./ca.tgz"},
+				ResourceObjectPaths: []string{"./resources/20-res-json.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			ca, format, err := componentarchive.Parse(testdataFs, "./ca.tgz")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(format).To(Equal(ctf.ArchiveFormatTarGzip))
+			Expect(ca.ComponentDescriptor.Resources).To(HaveLen(1))
+			var blob bytes.Buffer
+			_, err = ca.Resolve(context.TODO(), ca.ComponentDescriptor.Resources[0], &blob)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blob.Len()).ToNot(BeZero())
+		})
+
+		It("should print the changes as diff in dry-run mode without writing blobs", func() {
+			var buf bytes.Buffer
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/20-res-json.yaml"},
+				DryRun:              true,
+				Output:              &buf,
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			Expect(buf.String()).To(HavePrefix("--- 00-component/component-descriptor.yaml\n+++ 00-component/component-descriptor.yaml\n"))
+			Expect(buf.String()).To(ContainSubstring("+  - access:\n"))
+			Expect(buf.String()).To(ContainSubstring("+    name: myconfig\n"))
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(BeEmpty())
+			_, err = testdataFs.Stat(filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("should merge the labels of an existing resource with an input", func() {
+			Expect(vfs.WriteFile(testdataFs, "./resources/labeled.yaml", []byte(`
+name: 'myconfig'
+version: 'v0.0.1'
+type: 'jsonschema'
+relation: 'external'
+labels:
+- name: 'existing'
+  value: true
+input:
+  type: file
+  path: "./21-jsonschema.json"
+`), os.ModePerm)).To(Succeed())
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/labeled.yaml"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			opts = &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/21-res-file-zstd.yaml"},
+				Merge:               true,
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(HaveLen(1))
+			Expect(cd.Resources[0].Labels).To(HaveLen(1))
+			Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("mediaType", input.MediaTypeZstd))
+
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+		})
+
 		It("should automatically tar a directory input and add it as resource", func() {
 			opts := &resources.Options{
 				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
@@ -415,6 +761,69 @@ var _ = Describe("Add", func() {
 			Expect(mimetype).To(Equal("application/x-gzip"))
 		})
 
+		It("should compress a directory input blob with zstd", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/21-res-dir-zstd.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(HaveLen(1))
+			Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("mediaType", input.MediaTypeZstd))
+
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+			blob, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName, blobs[0].Name()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs[0].Size()).To(Equal(int64(len(blob))))
+			files, err := untar(unzstd(blob))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveKey("21-jsonschema.json"))
+		})
+
+		It("should compress a file input blob with zstd", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/21-res-file-zstd.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(HaveLen(1))
+			Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("mediaType", input.MediaTypeZstd))
+
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+			blob, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName, blobs[0].Name()))
+			Expect(err).ToNot(HaveOccurred())
+			original, err := vfs.ReadFile(testdataFs, "./resources/21-jsonschema.json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(unzstd(blob)).To(Equal(original))
+		})
+
+		It("should fail on an unknown compression", func() {
+			Expect(vfs.WriteFile(testdataFs, "./resources/21-res-unknown-compression.yaml", []byte("name: myconfig\nversion: v0.0.1\ntype: jsonschema\nrelation: external\ninput:\n  type: file\n  path: ./21-jsonschema.json\n  compress: lz4\n"), 0644)).To(Succeed())
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/21-res-unknown-compression.yaml"},
+			}
+
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`unknown compression "lz4"`))
+		})
+
 		It("should automatically tar a directory input and add it as resource and include ", func() {
 			opts := &resources.Options{
 				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
@@ -475,6 +884,300 @@ var _ = Describe("Add", func() {
 			}))
 		})
 
+		It("should add every file of nested directories exactly once", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/26-res-nested-dir.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+
+			blob, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName, blobs[0].Name()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tarEntries(blob)).To(ConsistOf(
+				"file3.txt",
+				"nested",
+				"nested/file2.txt",
+				"nested/deep",
+				"nested/deep/file1.txt",
+			))
+		})
+
+		It("should exclude the content of excluded directories", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/26-res-nested-dir-exclude.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+
+			blob, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName, blobs[0].Name()))
+			Expect(err).ToNot(HaveOccurred())
+			files, err := untar(blob)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(files).To(MatchKeys(0, Keys{
+				"file3.txt":        Equal([]byte("val3")),
+				"nested":           Equal([]byte("dir")),
+				"nested/file2.txt": Equal([]byte("val2")),
+			}))
+		})
+
+	})
+
+	Context("Docker", func() {
+
+		var (
+			server   *httptest.Server
+			oldHost  string
+			hadHost  bool
+			imageRef string
+		)
+
+		BeforeEach(func() {
+			layer := tarFiles(map[string]string{"file.txt": "content"})
+			dockerArchive := tarFiles(map[string]string{
+				"manifest.json":    `[{"Config":"config.json","RepoTags":["myimage:dev"],"Layers":["layer1/layer.tar"]}]`,
+				"config.json":      `{"architecture":"amd64","os":"linux"}`,
+				"layer1/layer.tar": string(layer),
+			})
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				imageRef = r.URL.Query().Get("names")
+				if r.URL.Path != "/images/get" || imageRef != "myimage:dev" {
+					http.Error(w, `{"message":"no such image"}`, http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write(dockerArchive)
+			}))
+			oldHost, hadHost = os.LookupEnv(input.DockerHostEnvName)
+			Expect(os.Setenv(input.DockerHostEnvName, "tcp://"+server.Listener.Addr().String())).To(Succeed())
+		})
+
+		AfterEach(func() {
+			server.Close()
+			if hadHost {
+				Expect(os.Setenv(input.DockerHostEnvName, oldHost)).To(Succeed())
+			} else {
+				Expect(os.Unsetenv(input.DockerHostEnvName)).To(Succeed())
+			}
+		})
+
+		It("should add an image of the docker daemon as oci artifact", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/27-docker.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+			Expect(imageRef).To(Equal("myimage:dev"))
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(HaveLen(1))
+			Expect(cd.Resources[0].Type).To(Equal(cdv2.OCIImageType))
+			Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("mediaType", input.MediaTypeOCIArtifactTar))
+
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+			blob, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName, blobs[0].Name()))
+			Expect(err).ToNot(HaveOccurred())
+			files, err := untar(blob)
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest := ocispecv1.Manifest{}
+			Expect(json.Unmarshal(files["manifest.json"], &manifest)).To(Succeed())
+			Expect(manifest.Config.MediaType).To(Equal(ocispecv1.MediaTypeImageConfig))
+			Expect(manifest.Layers).To(HaveLen(1))
+			Expect(manifest.Layers[0].MediaType).To(Equal(ocispecv1.MediaTypeImageLayer))
+			Expect(files).To(HaveKeyWithValue("blobs/"+manifest.Config.Digest.Encoded(), []byte(`{"architecture":"amd64","os":"linux"}`)))
+			layer := files["blobs/"+manifest.Layers[0].Digest.Encoded()]
+			Expect(digest.FromBytes(layer)).To(Equal(manifest.Layers[0].Digest))
+			Expect(untar(layer)).To(HaveKeyWithValue("file.txt", []byte("content")))
+		})
+
+		It("should fail if the image is not known to the docker daemon", func() {
+			Expect(vfs.WriteFile(testdataFs, "./resources/27-docker-unknown.yaml", []byte("name: myimage\nrelation: local\ninput:\n  type: docker\n  path: unknown:dev\n"), 0644)).To(Succeed())
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/27-docker-unknown.yaml"},
+			}
+
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no such image"))
+		})
+
+	})
+
+	Context("OCI Layout", func() {
+
+		BeforeEach(func() {
+			writeOCILayout(testdataFs, "./resources/29-layout")
+		})
+
+		It("should add a multi arch image with its image index", func() {
+			Expect(vfs.WriteFile(testdataFs, "./resources/29-layout.yaml", []byte("name: myimage\nrelation: local\ninput:\n  type: ociLayout\n  path: ./29-layout\n"), 0644)).To(Succeed())
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/29-layout.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(HaveLen(1))
+			Expect(cd.Resources[0].Type).To(Equal(cdv2.OCIImageType))
+			Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("mediaType", input.MediaTypeOCIImageIndexTar))
+
+			files := readSingleBlob(testdataFs, opts.ComponentArchivePath)
+			index := ocispecv1.Index{}
+			Expect(json.Unmarshal(files["index.json"], &index)).To(Succeed())
+			Expect(index.Manifests).To(HaveLen(2))
+			for _, desc := range index.Manifests {
+				Expect(files).To(HaveKey("blobs/" + desc.Digest.Encoded()))
+				manifest := ocispecv1.Manifest{}
+				Expect(json.Unmarshal(files["blobs/"+desc.Digest.Encoded()], &manifest)).To(Succeed())
+				Expect(files).To(HaveKey("blobs/" + manifest.Config.Digest.Encoded()))
+				Expect(files).To(HaveKey("blobs/" + manifest.Layers[0].Digest.Encoded()))
+			}
+		})
+
+		It("should only add the manifest of the selected platform", func() {
+			Expect(vfs.WriteFile(testdataFs, "./resources/29-layout.yaml", []byte("name: myimage\nrelation: local\ninput:\n  type: ociLayout\n  path: ./29-layout\n  platforms:\n  - linux/arm64\n"), 0644)).To(Succeed())
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/29-layout.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("mediaType", input.MediaTypeOCIArtifactTar))
+
+			files := readSingleBlob(testdataFs, opts.ComponentArchivePath)
+			Expect(files).ToNot(HaveKey("index.json"))
+			manifest := ocispecv1.Manifest{}
+			Expect(json.Unmarshal(files["manifest.json"], &manifest)).To(Succeed())
+			Expect(files).To(HaveKeyWithValue("blobs/"+manifest.Config.Digest.Encoded(), []byte(`{"architecture":"arm64","os":"linux"}`)))
+			Expect(files).To(HaveLen(3))
+		})
+
+		It("should add a multi arch image of an oci layout tar", func() {
+			var layoutTar bytes.Buffer
+			Expect(input.TarFileSystem(context.TODO(), testdataFs, "./resources/29-layout", &layoutTar, input.TarFileSystemOptions{})).To(Succeed())
+			Expect(vfs.WriteFile(testdataFs, "./resources/29-layout.tar", layoutTar.Bytes(), 0644)).To(Succeed())
+			Expect(vfs.WriteFile(testdataFs, "./resources/29-layout.yaml", []byte("name: myimage\nrelation: local\ninput:\n  type: ociLayout\n  path: ./29-layout.tar\n"), 0644)).To(Succeed())
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/29-layout.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			files := readSingleBlob(testdataFs, opts.ComponentArchivePath)
+			index := ocispecv1.Index{}
+			Expect(json.Unmarshal(files["index.json"], &index)).To(Succeed())
+			Expect(index.Manifests).To(HaveLen(2))
+		})
+
+		It("should fail if no manifest matches the selected platforms", func() {
+			Expect(vfs.WriteFile(testdataFs, "./resources/29-layout.yaml", []byte("name: myimage\nrelation: local\ninput:\n  type: ociLayout\n  path: ./29-layout\n  platforms:\n  - windows/amd64\n"), 0644)).To(Succeed())
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/29-layout.yaml"},
+			}
+
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no manifest for the platforms windows/amd64"))
+		})
+
+	})
+
+	Context("Helm", func() {
+
+		It("should package a helm chart with its local dependencies", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/28-helm.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(HaveLen(1))
+			Expect(cd.Resources[0].Type).To(Equal(input.HelmChartResourceType))
+			Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("mediaType", input.MediaTypeHelmChart))
+
+			blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+			blob, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName, blobs[0].Name()))
+			Expect(err).ToNot(HaveOccurred())
+			files, err := untar(gunzip(blob))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(files).To(MatchAllKeys(Keys{
+				"mychart/Chart.yaml":              Not(BeEmpty()),
+				"mychart/values.yaml":             Equal([]byte("replicas: 1\n")),
+				"mychart/templates/cm.yaml":       Not(BeEmpty()),
+				"mychart/charts/common-0.2.0.tgz": Not(BeEmpty()),
+			}))
+			dep, err := untar(gunzip(files["mychart/charts/common-0.2.0.tgz"]))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dep).To(HaveKey("common/Chart.yaml"))
+		})
+
+		It("should fail if a remote dependency is missing", func() {
+			Expect(testdataFs.MkdirAll("./resources/28-remote", os.ModePerm)).To(Succeed())
+			Expect(vfs.WriteFile(testdataFs, "./resources/28-remote/Chart.yaml", []byte("apiVersion: v2\nname: remote\nversion: 0.1.0\ndependencies:\n- name: redis\n  version: 1.0.0\n  repository: https://charts.example.com\n"), 0644)).To(Succeed())
+			Expect(vfs.WriteFile(testdataFs, "./resources/28-helm-remote.yaml", []byte("name: remote\nrelation: local\ninput:\n  type: helm\n  path: ./28-remote\n"), 0644)).To(Succeed())
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/28-helm-remote.yaml"},
+			}
+
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`dependency "redis" of chart "remote" is missing`))
+		})
+
+		It("should fail if local dependencies are cyclic", func() {
+			Expect(testdataFs.MkdirAll("./resources/28-cycle-a", os.ModePerm)).To(Succeed())
+			Expect(testdataFs.MkdirAll("./resources/28-cycle-b", os.ModePerm)).To(Succeed())
+			Expect(vfs.WriteFile(testdataFs, "./resources/28-cycle-a/Chart.yaml", []byte("apiVersion: v2\nname: a\nversion: 0.1.0\ndependencies:\n- name: b\n  version: 0.1.0\n  repository: file://../28-cycle-b\n"), 0644)).To(Succeed())
+			Expect(vfs.WriteFile(testdataFs, "./resources/28-cycle-b/Chart.yaml", []byte("apiVersion: v2\nname: b\nversion: 0.1.0\ndependencies:\n- name: a\n  version: 0.1.0\n  repository: file://../28-cycle-a\n"), 0644)).To(Succeed())
+			Expect(vfs.WriteFile(testdataFs, "./resources/28-helm-cycle.yaml", []byte("name: cycle\nrelation: local\ninput:\n  type: helm\n  path: ./28-cycle-a\n"), 0644)).To(Succeed())
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/28-helm-cycle.yaml"},
+			}
+
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`chart "a" in "resources/28-cycle-a" has a cyclic dependency to itself`))
+		})
+
 	})
 
 	It("should add a resource defined by a file with a template", func() {
@@ -605,3 +1308,105 @@ func untar(data []byte) (map[string][]byte, error) {
 		files[header.Name] = d.Bytes()
 	}
 }
+
+// tarEntries returns the names of all entries of a tar in the order of the tar.
+func tarEntries(data []byte) ([]string, error) {
+	names := []string{}
+	tr := tar.NewReader(bytes.NewBuffer(data))
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return names, nil
+			}
+			return nil, err
+		}
+		names = append(names, header.Name)
+	}
+}
+
+// tarFiles creates a tar that contains the given files.
+func tarFiles(files map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range sets.StringKeySet(files).List() {
+		Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})).To(Succeed())
+		_, err := tw.Write([]byte(files[name]))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(tw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+func gunzip(data []byte) []byte {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	Expect(err).ToNot(HaveOccurred())
+	res, err := io.ReadAll(gr)
+	Expect(err).ToNot(HaveOccurred())
+	return res
+}
+
+func unzstd(data []byte) []byte {
+	zr, err := zstd.NewReader(bytes.NewReader(data))
+	Expect(err).ToNot(HaveOccurred())
+	defer zr.Close()
+	res, err := io.ReadAll(zr)
+	Expect(err).ToNot(HaveOccurred())
+	return res
+}
+
+// writeOCILayout writes an oci image layout with a nested image index of a linux/amd64 and a linux/arm64 image.
+func writeOCILayout(fs vfs.FileSystem, dir string) {
+	writeBlob := func(data []byte) ocispecv1.Descriptor {
+		dig := digest.FromBytes(data)
+		Expect(fs.MkdirAll(filepath.Join(dir, "blobs", "sha256"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, filepath.Join(dir, "blobs", "sha256", dig.Encoded()), data, 0644)).To(Succeed())
+		return ocispecv1.Descriptor{Digest: dig, Size: int64(len(data))}
+	}
+	writeJSON := func(mediaType string, obj interface{}) ocispecv1.Descriptor {
+		data, err := json.Marshal(obj)
+		Expect(err).ToNot(HaveOccurred())
+		desc := writeBlob(data)
+		desc.MediaType = mediaType
+		return desc
+	}
+
+	manifests := []ocispecv1.Descriptor{}
+	for _, arch := range []string{"amd64", "arm64"} {
+		config := writeBlob([]byte(fmt.Sprintf(`{"architecture":%q,"os":"linux"}`, arch)))
+		config.MediaType = ocispecv1.MediaTypeImageConfig
+		layer := writeBlob(tarFiles(map[string]string{"arch": arch}))
+		layer.MediaType = ocispecv1.MediaTypeImageLayer
+		desc := writeJSON(ocispecv1.MediaTypeImageManifest, ocispecv1.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			Config:    config,
+			Layers:    []ocispecv1.Descriptor{layer},
+		})
+		desc.Platform = &ocispecv1.Platform{OS: "linux", Architecture: arch}
+		manifests = append(manifests, desc)
+	}
+	nested := writeJSON(ocispecv1.MediaTypeImageIndex, ocispecv1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispecv1.MediaTypeImageIndex,
+		Manifests: manifests,
+	})
+	data, err := json.Marshal(ocispecv1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispecv1.Descriptor{nested},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(vfs.WriteFile(fs, filepath.Join(dir, "index.json"), data, 0644)).To(Succeed())
+	Expect(vfs.WriteFile(fs, filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644)).To(Succeed())
+}
+
+// readSingleBlob reads the files of the only blob of the component archive, which must be a tar.
+func readSingleBlob(fs vfs.FileSystem, archivePath string) map[string][]byte {
+	blobs, err := vfs.ReadDir(fs, filepath.Join(archivePath, ctf.BlobsDirectoryName))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(blobs).To(HaveLen(1))
+	blob, err := vfs.ReadFile(fs, filepath.Join(archivePath, ctf.BlobsDirectoryName, blobs[0].Name()))
+	Expect(err).ToNot(HaveOccurred())
+	files, err := untar(blob)
+	Expect(err).ToNot(HaveOccurred())
+	return files
+}
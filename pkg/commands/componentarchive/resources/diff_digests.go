@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+const (
+	// TextOutput prints the digest diff in a human readable format.
+	TextOutput = "text"
+	// JSONOutput prints the digest diff as json.
+	JSONOutput = "json"
+)
+
+// DiffDigestsOptions defines the options that are used to compare the resource digests of two component descriptors.
+type DiffDigestsOptions struct {
+	// OldPath is the path to the old component archive or component descriptor.
+	OldPath string
+	// NewPath is the path to the new component archive or component descriptor.
+	NewPath string
+	// Output defines the output format.
+	Output string
+}
+
+// ResourceDigest describes the digest of a resource that is identified by its identity.
+type ResourceDigest struct {
+	Identity cdv2.Identity    `json:"identity"`
+	Digest   *cdv2.DigestSpec `json:"digest,omitempty"`
+}
+
+// ResourceDigestChange describes a resource whose digest changed.
+type ResourceDigestChange struct {
+	Identity  cdv2.Identity    `json:"identity"`
+	OldDigest *cdv2.DigestSpec `json:"oldDigest,omitempty"`
+	NewDigest *cdv2.DigestSpec `json:"newDigest,omitempty"`
+}
+
+// DigestDiff describes the resources that were added, removed or whose content changed.
+type DigestDiff struct {
+	Added   []ResourceDigest       `json:"added"`
+	Removed []ResourceDigest       `json:"removed"`
+	Changed []ResourceDigestChange `json:"changed"`
+}
+
+// NewDiffDigestsCommand creates a new command that compares the resource digests of two component descriptors.
+func NewDiffDigestsCommand(ctx context.Context) *cobra.Command {
+	opts := &DiffDigestsOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff-digests OLD_PATH NEW_PATH",
+		Args:  cobra.ExactArgs(2),
+		Short: "Compares the resource digests of two component descriptors",
+		Long: `
+Compares the resource digests of two component descriptors and reports the resources
+whose content changed, that were added or that were removed.
+Resources are matched by their identity (name and extra identity), metadata-only changes like
+a different version, labels or access are ignored.
+
+The paths can point to component archives (directory, tar or tgz) or to component descriptor files (.yaml, .yml or .json).
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, osfs.New(), os.Stdout); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run compares the resource digests and writes the diff to the given writer.
+func (o *DiffDigestsOptions) Run(_ context.Context, fs vfs.FileSystem, w io.Writer) error {
+	oldCd, err := componentarchive.ReadComponentDescriptor(fs, o.OldPath)
+	if err != nil {
+		return err
+	}
+	newCd, err := componentarchive.ReadComponentDescriptor(fs, o.NewPath)
+	if err != nil {
+		return err
+	}
+
+	diff := DiffDigests(oldCd, newCd)
+	switch o.Output {
+	case JSONOutput:
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal diff: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	default:
+		return writeDigestDiffText(w, diff)
+	}
+}
+
+// DiffDigests compares the resource digests of two component descriptors.
+func DiffDigests(oldCd, newCd *cdv2.ComponentDescriptor) DigestDiff {
+	diff := DigestDiff{
+		Added:   []ResourceDigest{},
+		Removed: []ResourceDigest{},
+		Changed: []ResourceDigestChange{},
+	}
+
+	oldResources := map[string]cdv2.Resource{}
+	for _, res := range oldCd.Resources {
+		oldResources[identityKey(res.GetIdentity())] = res
+	}
+
+	newKeys := map[string]bool{}
+	for _, res := range newCd.Resources {
+		key := identityKey(res.GetIdentity())
+		newKeys[key] = true
+		oldRes, ok := oldResources[key]
+		if !ok {
+			diff.Added = append(diff.Added, ResourceDigest{Identity: res.GetIdentity(), Digest: res.Digest})
+			continue
+		}
+		if !digestsEqual(oldRes.Digest, res.Digest) {
+			diff.Changed = append(diff.Changed, ResourceDigestChange{
+				Identity:  res.GetIdentity(),
+				OldDigest: oldRes.Digest,
+				NewDigest: res.Digest,
+			})
+		}
+	}
+	for _, res := range oldCd.Resources {
+		if !newKeys[identityKey(res.GetIdentity())] {
+			diff.Removed = append(diff.Removed, ResourceDigest{Identity: res.GetIdentity(), Digest: res.Digest})
+		}
+	}
+	return diff
+}
+
+func digestsEqual(a, b *cdv2.DigestSpec) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.HashAlgorithm == b.HashAlgorithm && a.Value == b.Value
+}
+
+// identityKey returns a stable string representation of an identity.
+func identityKey(identity cdv2.Identity) string {
+	keys := make([]string, 0, len(identity))
+	for k := range identity {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, identity[k]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func digestString(digest *cdv2.DigestSpec) string {
+	if digest == nil {
+		return "<none>"
+	}
+	return fmt.Sprintf("%s:%s", digest.HashAlgorithm, digest.Value)
+}
+
+func writeDigestDiffText(w io.Writer, diff DigestDiff) error {
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0 {
+		_, err := fmt.Fprintln(w, "No resource digests changed")
+		return err
+	}
+	for _, res := range diff.Changed {
+		if _, err := fmt.Fprintf(w, "~ %s: %s -> %s\n", identityKey(res.Identity), digestString(res.OldDigest), digestString(res.NewDigest)); err != nil {
+			return err
+		}
+	}
+	for _, res := range diff.Added {
+		if _, err := fmt.Fprintf(w, "+ %s: %s\n", identityKey(res.Identity), digestString(res.Digest)); err != nil {
+			return err
+		}
+	}
+	for _, res := range diff.Removed {
+		if _, err := fmt.Fprintf(w, "- %s: %s\n", identityKey(res.Identity), digestString(res.Digest)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *DiffDigestsOptions) Complete(args []string) error {
+	if len(args) != 2 {
+		return errors.New("expected exactly two arguments that contain the paths to the old and new component descriptor")
+	}
+	o.OldPath = args[0]
+	o.NewPath = args[1]
+	return o.Validate()
+}
+
+// Validate validates the diff digests options.
+func (o *DiffDigestsOptions) Validate() error {
+	if o.Output != TextOutput && o.Output != JSONOutput {
+		return fmt.Errorf("unsupported output format %q, expected one of %q, %q", o.Output, TextOutput, JSONOutput)
+	}
+	return nil
+}
+
+func (o *DiffDigestsOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.Output, "output", "o", TextOutput, fmt.Sprintf("output format. One of %q, %q", TextOutput, JSONOutput))
+}
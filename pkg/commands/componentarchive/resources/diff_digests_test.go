@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/resources"
+)
+
+var _ = Describe("DiffDigests", func() {
+
+	newResource := func(name, version, digest string, extraIdentity cdv2.Identity) cdv2.Resource {
+		res := cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:          name,
+				Version:       version,
+				Type:          cdv2.OCIImageType,
+				ExtraIdentity: extraIdentity,
+			},
+			Relation: cdv2.LocalRelation,
+		}
+		acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("example.com/" + name + ":" + version))
+		Expect(err).ToNot(HaveOccurred())
+		res.Access = &acc
+		if len(digest) != 0 {
+			res.Digest = &cdv2.DigestSpec{
+				HashAlgorithm:          "sha256",
+				NormalisationAlgorithm: string(cdv2.OciArtifactDigestV1),
+				Value:                  digest,
+			}
+		}
+		return res
+	}
+
+	newComponentDescriptor := func(version string, res ...cdv2.Resource) *cdv2.ComponentDescriptor {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Metadata.Version = cdv2.SchemaVersion
+		cd.Name = "example.com/component"
+		cd.Version = version
+		cd.Provider = cdv2.InternalProvider
+		cd.RepositoryContexts = []*cdv2.UnstructuredTypedObject{}
+		cd.Sources = []cdv2.Source{}
+		cd.ComponentReferences = []cdv2.ComponentReference{}
+		cd.Resources = res
+		return cd
+	}
+
+	var oldCd, newCd *cdv2.ComponentDescriptor
+
+	BeforeEach(func() {
+		oldCd = newComponentDescriptor("v0.1.0",
+			newResource("unchanged", "v0.1.0", "aaa", nil),
+			newResource("changed", "v0.1.0", "bbb", nil),
+			newResource("removed", "v0.1.0", "ccc", nil),
+			newResource("multi", "v0.1.0", "ddd", cdv2.Identity{"platform": "amd64"}),
+		)
+		newCd = newComponentDescriptor("v0.2.0",
+			newResource("unchanged", "v0.2.0", "aaa", nil),
+			newResource("changed", "v0.2.0", "bbb2", nil),
+			newResource("added", "v0.2.0", "eee", nil),
+			newResource("multi", "v0.2.0", "ddd", cdv2.Identity{"platform": "arm64"}),
+		)
+	})
+
+	It("should report changed, added and removed resources", func() {
+		diff := resources.DiffDigests(oldCd, newCd)
+		Expect(diff.Changed).To(HaveLen(1))
+		Expect(diff.Changed[0].Identity).To(Equal(cdv2.Identity{"name": "changed"}))
+		Expect(diff.Changed[0].OldDigest.Value).To(Equal("bbb"))
+		Expect(diff.Changed[0].NewDigest.Value).To(Equal("bbb2"))
+
+		Expect(diff.Added).To(ConsistOf(
+			resources.ResourceDigest{Identity: cdv2.Identity{"name": "added"}, Digest: newCd.Resources[2].Digest},
+			resources.ResourceDigest{Identity: cdv2.Identity{"name": "multi", "platform": "arm64"}, Digest: newCd.Resources[3].Digest},
+		))
+		Expect(diff.Removed).To(ConsistOf(
+			resources.ResourceDigest{Identity: cdv2.Identity{"name": "removed"}, Digest: oldCd.Resources[2].Digest},
+			resources.ResourceDigest{Identity: cdv2.Identity{"name": "multi", "platform": "amd64"}, Digest: oldCd.Resources[3].Digest},
+		))
+	})
+
+	It("should ignore metadata-only changes", func() {
+		diff := resources.DiffDigests(
+			newComponentDescriptor("v0.1.0", newResource("res", "v0.1.0", "aaa", nil)),
+			newComponentDescriptor("v0.2.0", newResource("res", "v0.2.0", "aaa", nil)),
+		)
+		Expect(diff.Changed).To(BeEmpty())
+		Expect(diff.Added).To(BeEmpty())
+		Expect(diff.Removed).To(BeEmpty())
+	})
+
+	It("should report a resource that got a digest as changed", func() {
+		diff := resources.DiffDigests(
+			newComponentDescriptor("v0.1.0", newResource("res", "v0.1.0", "", nil)),
+			newComponentDescriptor("v0.2.0", newResource("res", "v0.2.0", "aaa", nil)),
+		)
+		Expect(diff.Changed).To(HaveLen(1))
+		Expect(diff.Changed[0].OldDigest).To(BeNil())
+	})
+
+	Context("Run", func() {
+
+		var fs vfs.FileSystem
+
+		BeforeEach(func() {
+			fs = memoryfs.New()
+			for path, cd := range map[string]*cdv2.ComponentDescriptor{"old.json": oldCd, "new.json": newCd} {
+				data, err := json.Marshal(cd)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(vfs.WriteFile(fs, path, data, 0664)).To(Succeed())
+			}
+		})
+
+		It("should print the diff as text", func() {
+			opts := &resources.DiffDigestsOptions{Output: resources.TextOutput}
+			Expect(opts.Complete([]string{"old.json", "new.json"})).To(Succeed())
+
+			var buf bytes.Buffer
+			Expect(opts.Run(context.TODO(), fs, &buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("~ name=changed: sha256:bbb -> sha256:bbb2"))
+			Expect(buf.String()).To(ContainSubstring("+ name=added: sha256:eee"))
+			Expect(buf.String()).To(ContainSubstring("- name=removed: sha256:ccc"))
+			Expect(buf.String()).ToNot(ContainSubstring("unchanged"))
+		})
+
+		It("should print the diff as json", func() {
+			opts := &resources.DiffDigestsOptions{Output: resources.JSONOutput}
+			Expect(opts.Complete([]string{"old.json", "new.json"})).To(Succeed())
+
+			var buf bytes.Buffer
+			Expect(opts.Run(context.TODO(), fs, &buf)).To(Succeed())
+			diff := resources.DigestDiff{}
+			Expect(json.Unmarshal(buf.Bytes(), &diff)).To(Succeed())
+			Expect(diff.Changed).To(HaveLen(1))
+			Expect(diff.Added).To(HaveLen(2))
+			Expect(diff.Removed).To(HaveLen(2))
+		})
+
+		It("should fail for an unknown output format", func() {
+			opts := &resources.DiffDigestsOptions{Output: "yaml"}
+			Expect(opts.Complete([]string{"old.json", "new.json"})).ToNot(Succeed())
+		})
+
+	})
+
+})
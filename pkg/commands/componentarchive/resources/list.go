@@ -0,0 +1,287 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// YAMLOutput prints the resources as yaml.
+const YAMLOutput = "yaml"
+
+// ListOptions defines the options that are used to list the resources of a component descriptor.
+type ListOptions struct {
+	// ComponentArchivePath is the path to the component archive or component descriptor.
+	// It is empty if the resources of a remote component are listed.
+	ComponentArchivePath string
+	// Output defines the output format.
+	Output string
+
+	// Types filters the resources by their type.
+	// A resource matches if it has one of the types.
+	Types []string
+	// Relation filters the resources by their relation.
+	Relation string
+	// Labels filters the resources by their labels.
+	// A label is defined as NAME or NAME=VALUE and a resource matches if it has all labels.
+	Labels []string
+
+	// BaseUrl is the oci registry of the remote component whose resources are listed.
+	BaseUrl string
+	// ComponentNameMapping is the component name mapping of the oci registry.
+	ComponentNameMapping string
+	// ComponentName is the name of the remote component.
+	ComponentName string
+	// Version is the version of the remote component.
+	Version string
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// ListEntry describes a resource of a component descriptor.
+type ListEntry struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Version  string      `json:"version"`
+	Relation string      `json:"relation,omitempty"`
+	Labels   cdv2.Labels `json:"labels,omitempty"`
+}
+
+// NewListCommand creates a new command to list the resources of a component descriptor.
+func NewListCommand(ctx context.Context) *cobra.Command {
+	opts := &ListOptions{}
+	cmd := &cobra.Command{
+		Use:   "list [COMPONENT_ARCHIVE_PATH | --repo-ctx BASE_URL COMPONENT_NAME VERSION]",
+		Args:  cobra.RangeArgs(1, 2),
+		Short: "Lists the resources of a component descriptor",
+		Long: `
+Lists the name, type, version and relation of all resources of a component descriptor in the order they are defined.
+
+The path can point to a component archive (directory, tar or tgz) or to a component descriptor file (.yaml, .yml or .json).
+With "--repo-ctx" the resources of the remote component with the given name and version are listed.
+
+The resources can be filtered by their type, relation and labels:
+- "--type" lists only resources with one of the given types.
+- "--relation" lists only resources with the given relation.
+- "--label NAME" or "--label NAME=VALUE" lists only resources that have the label.
+  The value is compared with json values and unquoted json strings. All given labels have to match.
+
+The json and yaml output formats also contain the labels of the resources.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New(), os.Stdout); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run lists the resources of the component descriptor and writes them to the given writer.
+func (o *ListOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem, w io.Writer) error {
+	cd, err := o.componentDescriptor(ctx, log, fs)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]ListEntry, 0, len(cd.Resources))
+	for _, res := range cd.Resources {
+		if !o.matches(res) {
+			continue
+		}
+		entries = append(entries, ListEntry{
+			Name:     res.GetName(),
+			Type:     res.GetType(),
+			Version:  res.GetVersion(),
+			Relation: string(res.Relation),
+			Labels:   res.Labels,
+		})
+	}
+
+	switch o.Output {
+	case JSONOutput:
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal resources: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case YAMLOutput:
+		data, err := yaml.Marshal(entries)
+		if err != nil {
+			return fmt.Errorf("unable to marshal resources: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		if _, err := fmt.Fprintln(tw, "NAME\tTYPE\tVERSION\tRELATION"); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Name, entry.Type, entry.Version, entry.Relation); err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	}
+}
+
+// componentDescriptor reads the local component descriptor or fetches the remote component descriptor.
+func (o *ListOptions) componentDescriptor(ctx context.Context, log logr.Logger, fs vfs.FileSystem) (*cdv2.ComponentDescriptor, error) {
+	if len(o.BaseUrl) == 0 {
+		return componentarchive.ReadComponentDescriptor(fs, o.ComponentArchivePath)
+	}
+	repoCtx := cdv2.NewOCIRegistryRepository(o.BaseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping))
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	cd, err := cdoci.NewResolver(ociClient).Resolve(ctx, repoCtx, o.ComponentName, o.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to to fetch component descriptor %s:%s: %w", o.ComponentName, o.Version, err)
+	}
+	return cd, nil
+}
+
+// matches checks whether the resource matches all filters.
+func (o *ListOptions) matches(res cdv2.Resource) bool {
+	if len(o.Types) != 0 && !containsString(o.Types, res.GetType()) {
+		return false
+	}
+	if len(o.Relation) != 0 && string(res.Relation) != o.Relation {
+		return false
+	}
+	for _, label := range o.Labels {
+		if !hasLabel(res.Labels, label) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasLabel checks whether the labels contain a label that is defined as NAME or NAME=VALUE.
+func hasLabel(labels cdv2.Labels, label string) bool {
+	splitLabel := strings.SplitN(label, "=", 2)
+	value, ok := labels.Get(splitLabel[0])
+	if !ok {
+		return false
+	}
+	if len(splitLabel) == 1 {
+		return true
+	}
+	expected := splitLabel[1]
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s == expected
+	}
+	var actual, expectedValue bytes.Buffer
+	if err := json.Compact(&actual, value); err != nil {
+		return false
+	}
+	if err := json.Compact(&expectedValue, []byte(expected)); err != nil {
+		return false
+	}
+	return actual.String() == expectedValue.String()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *ListOptions) Complete(args []string) error {
+	if len(o.BaseUrl) != 0 {
+		if len(args) != 2 {
+			return errors.New("expected exactly two arguments that contain the name and version of the component if --repo-ctx is set")
+		}
+		o.ComponentName = args[0]
+		o.Version = args[1]
+
+		cliHomeDir, err := constants.CliHomeDir()
+		if err != nil {
+			return err
+		}
+		o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+		if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
+		}
+		return o.Validate()
+	}
+	if len(args) != 1 {
+		return errors.New("a component archive path argument has to be defined")
+	}
+	o.ComponentArchivePath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the list options.
+func (o *ListOptions) Validate() error {
+	if len(o.BaseUrl) != 0 {
+		if len(o.ComponentName) == 0 {
+			return errors.New("a component name must be provided")
+		}
+		if len(o.Version) == 0 {
+			return errors.New("a component version must be provided")
+		}
+	} else if len(o.ComponentArchivePath) == 0 {
+		return errors.New("a component archive path must be provided")
+	}
+	if o.Output != TextOutput && o.Output != JSONOutput && o.Output != YAMLOutput {
+		return fmt.Errorf("unsupported output format %q, expected one of %q, %q, %q", o.Output, TextOutput, JSONOutput, YAMLOutput)
+	}
+	for _, label := range o.Labels {
+		if len(strings.SplitN(label, "=", 2)[0]) == 0 {
+			return fmt.Errorf("invalid label filter %q, expected NAME or NAME=VALUE", label)
+		}
+	}
+	return nil
+}
+
+func (o *ListOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.Output, "output", "o", TextOutput, fmt.Sprintf("output format. One of %q, %q, %q", TextOutput, JSONOutput, YAMLOutput))
+	fs.StringSliceVar(&o.Types, "type", nil, "[OPTIONAL] lists only resources with one of the given types")
+	fs.StringVar(&o.Relation, "relation", "", "[OPTIONAL] lists only resources with the given relation")
+	fs.StringArrayVar(&o.Labels, "label", nil, "[OPTIONAL] lists only resources with the given label. Can be defined multiple times as NAME or NAME=VALUE")
+	fs.StringVar(&o.BaseUrl, "repo-ctx", "", "[OPTIONAL] base url of the oci registry of the remote component whose resources are listed")
+	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] repository context name mapping")
+	o.OciOptions.AddFlags(fs)
+}
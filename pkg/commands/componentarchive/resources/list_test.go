@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/resources"
+)
+
+var _ = Describe("List", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs, err := projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = fs
+	})
+
+	It("should list all resources in a table", func() {
+		opts := &resources.ListOptions{Output: resources.TextOutput}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+
+		out := &bytes.Buffer{}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs, out)).To(Succeed())
+		Expect(out.String()).To(Equal(`NAME    TYPE      VERSION  RELATION
+ubuntu  ociImage  v0.0.1   external
+nginx   ociImage  v1.21.0  external
+chart   helm      v0.1.0   external
+`))
+	})
+
+	It("should list all resources as json", func() {
+		opts := &resources.ListOptions{Output: resources.JSONOutput}
+		Expect(opts.Complete([]string{"./02-component/component-descriptor.yaml"})).To(Succeed())
+
+		out := &bytes.Buffer{}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs, out)).To(Succeed())
+		entries := []resources.ListEntry{}
+		Expect(json.Unmarshal(out.Bytes(), &entries)).To(Succeed())
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0]).To(Equal(resources.ListEntry{Name: "ubuntu", Type: "ociImage", Version: "v0.0.1", Relation: "external"}))
+		Expect(entries[1].Name).To(Equal("nginx"))
+		Expect(entries[1].Labels).To(HaveLen(2))
+		Expect(entries[2]).To(Equal(resources.ListEntry{Name: "chart", Type: "helm", Version: "v0.1.0", Relation: "external"}))
+	})
+
+	It("should list all resources as yaml", func() {
+		opts := &resources.ListOptions{Output: resources.YAMLOutput}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+
+		out := &bytes.Buffer{}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs, out)).To(Succeed())
+		entries := []resources.ListEntry{}
+		Expect(yaml.Unmarshal(out.Bytes(), &entries)).To(Succeed())
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[1].Name).To(Equal("nginx"))
+	})
+
+	It("should filter the resources by type and relation", func() {
+		opts := &resources.ListOptions{Output: resources.TextOutput, Types: []string{"helm"}, Relation: "external"}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+
+		out := &bytes.Buffer{}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs, out)).To(Succeed())
+		Expect(out.String()).To(Equal(`NAME   TYPE  VERSION  RELATION
+chart  helm  v0.1.0   external
+`))
+
+		opts = &resources.ListOptions{Output: resources.TextOutput, Relation: "local"}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+		out.Reset()
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs, out)).To(Succeed())
+		Expect(out.String()).To(Equal("NAME  TYPE  VERSION  RELATION\n"))
+	})
+
+	DescribeTable("should filter the resources by labels", func(labels []string, expected []string) {
+		opts := &resources.ListOptions{Output: resources.JSONOutput, Labels: labels}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+
+		out := &bytes.Buffer{}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs, out)).To(Succeed())
+		entries := []resources.ListEntry{}
+		Expect(json.Unmarshal(out.Bytes(), &entries)).To(Succeed())
+		names := []string{}
+		for _, entry := range entries {
+			names = append(names, entry.Name)
+		}
+		Expect(names).To(Equal(expected))
+	},
+		Entry("by name", []string{"team"}, []string{"nginx"}),
+		Entry("by string value", []string{"team=web"}, []string{"nginx"}),
+		Entry("by json value", []string{`config={"replicas": 2}`}, []string{"nginx"}),
+		Entry("by multiple labels", []string{"team=web", `config={"replicas":3}`}, []string{}),
+		Entry("by unknown label", []string{"unknown"}, []string{}),
+	)
+
+	It("should reject an unknown output format", func() {
+		opts := &resources.ListOptions{Output: "xml"}
+		Expect(opts.Complete([]string{"./02-component"})).To(HaveOccurred())
+	})
+
+	It("should require the component name and version if a repository context is defined", func() {
+		opts := &resources.ListOptions{Output: resources.TextOutput, BaseUrl: "example.com/components"}
+		Expect(opts.Complete([]string{"./02-component"})).To(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/ghodss/yaml"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// RemoveOptions defines the options that are used to remove a resource from a component descriptor
+type RemoveOptions struct {
+	// ComponentArchivePath defines the path to the component archive.
+	ComponentArchivePath string
+	// Name defines the name of the resource that is removed.
+	Name string
+	// Version defines the version of the resource that is removed.
+	Version string
+	// ExtraIdentity defines the extra identity of the resource that is removed.
+	ExtraIdentity map[string]string
+	// RemoveBlob defines whether the local blob of the resource is removed from the component archive
+	// if it is not referenced by another resource or source.
+	RemoveBlob bool
+	// Backup keeps the previous component descriptor as component-descriptor.yaml.bak.
+	Backup bool
+}
+
+// NewRemoveCommand creates a command to remove a resource from a component descriptor.
+func NewRemoveCommand(ctx context.Context) *cobra.Command {
+	opts := &RemoveOptions{}
+	cmd := &cobra.Command{
+		Use:   "remove COMPONENT_ARCHIVE_PATH --name NAME --version VERSION [--extra-identity key=value]...",
+		Args:  cobra.ExactArgs(1),
+		Short: "Removes a resource from a component descriptor",
+		Long: `
+removes the resource with the given name, version and extra identity from the defined component descriptor.
+The identity of the resource has to match exactly, so the extra identity has to be defined
+for all resources that have one.
+
+The command fails without modifying the component descriptor if the resource does not exist.
+Blobs of local resources are only removed from the component archive if --remove-blob is set
+and the blob is not referenced by another resource or source.
+The component archive can be a directory or a tar or tgz file, which is written back in its original format.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *RemoveOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	fs, repack, err := componentarchive.Unpack(fs, o.ComponentArchivePath)
+	if err != nil {
+		return err
+	}
+
+	archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
+	if err != nil {
+		return fmt.Errorf("unable to create projectionfilesystem: %w", err)
+	}
+	archive, err := ctf.NewComponentArchiveFromFilesystem(archiveFs, codec.DisableValidation(true))
+	if err != nil {
+		return fmt.Errorf("unable to parse component archive from %s: %w", o.ComponentArchivePath, err)
+	}
+	cd := archive.ComponentDescriptor
+
+	// the identity of a resource consists of its name and extra identity, the version has to match additionally.
+	id := cd.GetResourceIndex(cdv2.Resource{
+		IdentityObjectMeta: cdv2.IdentityObjectMeta{
+			Name:          o.Name,
+			Version:       o.Version,
+			ExtraIdentity: cdv2.Identity(o.ExtraIdentity),
+		},
+	})
+	if id == -1 || cd.Resources[id].GetVersion() != o.Version {
+		if len(o.ExtraIdentity) != 0 {
+			return fmt.Errorf("resource %q with version %q and extra identity %v not found", o.Name, o.Version, o.ExtraIdentity)
+		}
+		return fmt.Errorf("resource %q with version %q not found", o.Name, o.Version)
+	}
+	removed := cd.Resources[id]
+	cd.Resources = append(cd.Resources[:id], cd.Resources[id+1:]...)
+
+	if err := cdvalidation.Validate(cd); err != nil {
+		return fmt.Errorf("invalid component descriptor: %w", err)
+	}
+
+	data, err := yaml.Marshal(cd)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	if err := componentarchive.WriteComponentDescriptor(fs, o.ComponentArchivePath, data, o.Backup); err != nil {
+		return fmt.Errorf("unable to write modified component descriptor: %w", err)
+	}
+	log.V(1).Info(fmt.Sprintf("Successfully removed resource %q from component descriptor", o.Name))
+
+	if o.RemoveBlob {
+		if err := componentarchive.RemoveUnreferencedBlob(log, archiveFs, cd, removed.Access); err != nil {
+			return err
+		}
+	}
+	return repack()
+}
+
+func (o *RemoveOptions) Complete(args []string) error {
+	if len(args) != 1 {
+		return errors.New("a component archive path argument has to be defined")
+	}
+	o.ComponentArchivePath = args[0]
+	return o.validate()
+}
+
+func (o *RemoveOptions) validate() error {
+	if len(o.ComponentArchivePath) == 0 {
+		return errors.New("a component archive path must be provided")
+	}
+	if len(o.Name) == 0 {
+		return errors.New("a resource name must be provided")
+	}
+	if len(o.Version) == 0 {
+		return errors.New("a resource version must be provided")
+	}
+	return nil
+}
+
+func (o *RemoveOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Name, "name", "", "name of the resource that is removed")
+	fs.StringVar(&o.Version, "version", "", "version of the resource that is removed")
+	fs.StringToStringVar(&o.ExtraIdentity, "extra-identity", nil, "extra identity of the resource that is removed. Can be defined multiple times as key=value")
+	fs.BoolVar(&o.RemoveBlob, "remove-blob", false, "remove the local blob of the resource from the component archive if it is not referenced by another resource or source")
+	fs.BoolVar(&o.Backup, "backup", false, "[OPTIONAL] keeps the previous component descriptor as component-descriptor.yaml.bak if the component archive is a directory")
+}
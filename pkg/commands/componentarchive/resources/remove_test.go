@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources_test
+
+import (
+	"context"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/resources"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/testutils"
+)
+
+var _ = Describe("Remove", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
+	})
+
+	readResourceNames := func() []string {
+		data, err := vfs.ReadFile(testdataFs, filepath.Join("./02-component", ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		names := []string{}
+		for _, res := range cd.Resources {
+			names = append(names, res.GetName())
+		}
+		return names
+	}
+
+	It("should remove a resource", func() {
+		opts := &resources.RemoveOptions{
+			Name:    "nginx",
+			Version: "v1.21.0",
+		}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(readResourceNames()).To(Equal([]string{"ubuntu", "chart"}))
+	})
+
+	It("should keep the previous component descriptor as backup", func() {
+		compDescFilePath := filepath.Join("./02-component", ctf.ComponentDescriptorFileName)
+		previous, err := vfs.ReadFile(testdataFs, compDescFilePath)
+		Expect(err).ToNot(HaveOccurred())
+
+		opts := &resources.RemoveOptions{
+			Name:    "nginx",
+			Version: "v1.21.0",
+			Backup:  true,
+		}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(readResourceNames()).To(Equal([]string{"ubuntu", "chart"}))
+		backup, err := vfs.ReadFile(testdataFs, filepath.Join("./02-component", componentarchive.ComponentDescriptorBackupFileName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backup).To(Equal(previous))
+	})
+
+	It("should fail if the resource does not exist", func() {
+		opts := &resources.RemoveOptions{
+			ComponentArchivePath: "./02-component",
+			Name:                 "unknown",
+			Version:              "v0.0.1",
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(MatchError(`resource "unknown" with version "v0.0.1" not found`))
+		Expect(readResourceNames()).To(Equal([]string{"ubuntu", "nginx", "chart"}))
+	})
+
+	It("should fail if the resource exists with another version", func() {
+		opts := &resources.RemoveOptions{
+			ComponentArchivePath: "./02-component",
+			Name:                 "chart",
+			Version:              "v0.2.0",
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(MatchError(`resource "chart" with version "v0.2.0" not found`))
+		Expect(readResourceNames()).To(Equal([]string{"ubuntu", "nginx", "chart"}))
+	})
+
+	It("should require a name and a version", func() {
+		opts := &resources.RemoveOptions{Name: "chart"}
+		Expect(opts.Complete([]string{"./02-component"})).To(MatchError("a resource version must be provided"))
+	})
+
+	Context("identity and blobs", func() {
+
+		readResourceIdentities := func() []string {
+			data, err := vfs.ReadFile(testdataFs, filepath.Join("./03-component", ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			ids := []string{}
+			for _, res := range cd.Resources {
+				ids = append(ids, res.GetName()+res.ExtraIdentity["platform"])
+			}
+			return ids
+		}
+
+		blobExists := func(name string) bool {
+			_, err := testdataFs.Stat(filepath.Join("./03-component", ctf.BlobPath(name)))
+			return err == nil
+		}
+
+		It("should remove the resource with the matching extra identity and its blob", func() {
+			opts := &resources.RemoveOptions{
+				ComponentArchivePath: "./03-component",
+				Name:                 "config",
+				Version:              "v0.1.0",
+				ExtraIdentity:        map[string]string{"platform": "windows"},
+				RemoveBlob:           true,
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			Expect(readResourceIdentities()).To(Equal([]string{"configlinux", "shared-a", "shared-b"}))
+			Expect(blobExists("config-windows")).To(BeFalse())
+			Expect(blobExists("config-linux")).To(BeTrue())
+		})
+
+		It("should keep the blob of a removed resource by default", func() {
+			opts := &resources.RemoveOptions{
+				ComponentArchivePath: "./03-component",
+				Name:                 "config",
+				Version:              "v0.1.0",
+				ExtraIdentity:        map[string]string{"platform": "linux"},
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			Expect(readResourceIdentities()).To(Equal([]string{"configwindows", "shared-a", "shared-b"}))
+			Expect(blobExists("config-linux")).To(BeTrue())
+		})
+
+		It("should keep a blob that is still referenced by another resource", func() {
+			opts := &resources.RemoveOptions{
+				ComponentArchivePath: "./03-component",
+				Name:                 "shared-a",
+				Version:              "v0.1.0",
+				RemoveBlob:           true,
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+			Expect(blobExists("shared")).To(BeTrue())
+
+			opts.Name = "shared-b"
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+			Expect(blobExists("shared")).To(BeFalse())
+			Expect(readResourceIdentities()).To(Equal([]string{"configlinux", "configwindows"}))
+		})
+
+		It("should fail if the extra identity does not match exactly", func() {
+			opts := &resources.RemoveOptions{
+				ComponentArchivePath: "./03-component",
+				Name:                 "config",
+				Version:              "v0.1.0",
+			}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(MatchError(`resource "config" with version "v0.1.0" not found`))
+
+			opts.ExtraIdentity = map[string]string{"platform": "darwin"}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(MatchError(`resource "config" with version "v0.1.0" and extra identity map[platform:darwin] not found`))
+
+			opts.ExtraIdentity = map[string]string{"platform": "linux", "arch": "amd64"}
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(MatchError(`resource "config" with version "v0.1.0" and extra identity map[arch:amd64 platform:linux] not found`))
+			Expect(readResourceIdentities()).To(HaveLen(4))
+		})
+
+	})
+
+})
@@ -18,5 +18,8 @@ func NewResourcesCommand(ctx context.Context) *cobra.Command {
 		Short:   "command to modify resources of a component descriptor",
 	}
 	cmd.AddCommand(NewAddCommand(ctx))
+	cmd.AddCommand(NewDiffDigestsCommand(ctx))
+	cmd.AddCommand(NewListCommand(ctx))
+	cmd.AddCommand(NewRemoveCommand(ctx))
 	return cmd
 }
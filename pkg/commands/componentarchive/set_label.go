@@ -0,0 +1,296 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// SetLabelOptions defines the options that are used to set labels of a component descriptor.
+type SetLabelOptions struct {
+	// ComponentArchivePath defines the path to the component archive.
+	ComponentArchivePath string
+	// Labels defines the labels that are set as NAME=VALUE.
+	Labels []string
+	// Delete defines the names of the labels that are removed.
+	Delete []string
+
+	// Resource defines the name of the resource whose labels are modified.
+	Resource string
+	// Source defines the name of the source whose labels are modified.
+	Source string
+	// ComponentReference defines the name of the component reference whose labels are modified.
+	ComponentReference string
+	// ExtraIdentity selects the resource, source or component reference if the name is ambiguous.
+	ExtraIdentity map[string]string
+	// Backup keeps the previous component descriptor as component-descriptor.yaml.bak.
+	Backup bool
+
+	// labels are the parsed labels.
+	labels []cdv2.Label
+}
+
+// NewSetLabelCommand creates a command to set labels of a component descriptor.
+func NewSetLabelCommand(ctx context.Context) *cobra.Command {
+	opts := &SetLabelOptions{}
+	cmd := &cobra.Command{
+		Use:   "set-label COMPONENT_ARCHIVE_PATH [NAME=VALUE]... [--delete NAME]... [--resource NAME | --source NAME | --component-reference NAME]",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "Sets, updates or deletes labels of a component descriptor",
+		Long: `
+set-label sets, updates or deletes labels of the component descriptor of a component archive.
+By default the labels of the component itself are modified.
+The labels of a resource, source or component reference are modified if its name is given with
+--resource, --source or --component-reference. Elements with the same name can be selected
+with their extra identity.
+The component archive can be a directory or a tar or tgz file, which is written back in its original format.
+
+Labels are defined as NAME=VALUE. Values that are valid json are added as json,
+all other values are added as string. Existing labels with the same name are updated.
+
+  set-label ./ca team=my-team 'config={"replicas": 2}'
+  set-label ./ca --resource my-image --delete deprecated
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *SetLabelOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSystem) error {
+
+	fs, repack, err := componentarchive.Unpack(fs, o.ComponentArchivePath)
+	if err != nil {
+		return err
+	}
+	archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
+	if err != nil {
+		return fmt.Errorf("unable to create projectionfilesystem: %w", err)
+	}
+	archive, err := ctf.NewComponentArchiveFromFilesystem(archiveFs, codec.DisableValidation(true))
+	if err != nil {
+		return fmt.Errorf("unable to parse component archive from %s: %w", o.ComponentArchivePath, err)
+	}
+	cd := archive.ComponentDescriptor
+
+	obj, err := o.target(cd)
+	if err != nil {
+		return err
+	}
+	labels := obj.GetLabels()
+	for _, name := range o.Delete {
+		var ok bool
+		labels, ok = removeLabel(labels, name)
+		if !ok {
+			log.V(1).Info(fmt.Sprintf("Label %q does not exist", name))
+		}
+	}
+	for _, label := range o.labels {
+		labels = setLabel(labels, label)
+	}
+	obj.SetLabels(labels)
+
+	if err := cdvalidation.Validate(cd); err != nil {
+		return fmt.Errorf("invalid component descriptor: %w", err)
+	}
+
+	data, err := yaml.Marshal(cd)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	if err := componentarchive.WriteComponentDescriptor(fs, o.ComponentArchivePath, data, o.Backup); err != nil {
+		return fmt.Errorf("unable to write modified component descriptor: %w", err)
+	}
+	log.V(1).Info("Successfully modified labels of component descriptor")
+	return repack()
+}
+
+// target returns the element of the component descriptor whose labels are modified.
+func (o *SetLabelOptions) target(cd *cdv2.ComponentDescriptor) (cdv2.LabelsAccessor, error) {
+	var (
+		kind       string
+		name       string
+		candidates []cdv2.LabelsAccessor
+		identities []cdv2.Identity
+	)
+	switch {
+	case len(o.Resource) != 0:
+		kind, name = "resource", o.Resource
+		for i := range cd.Resources {
+			candidates = append(candidates, &cd.Resources[i])
+			identities = append(identities, cd.Resources[i].GetIdentity())
+		}
+	case len(o.Source) != 0:
+		kind, name = "source", o.Source
+		for i := range cd.Sources {
+			candidates = append(candidates, &cd.Sources[i])
+			identities = append(identities, cd.Sources[i].GetIdentity())
+		}
+	case len(o.ComponentReference) != 0:
+		kind, name = "component reference", o.ComponentReference
+		for i := range cd.ComponentReferences {
+			candidates = append(candidates, &cd.ComponentReferences[i])
+			identities = append(identities, cd.ComponentReferences[i].GetIdentity())
+		}
+	default:
+		return cd, nil
+	}
+
+	var found []cdv2.LabelsAccessor
+	for i, id := range identities {
+		if id[cdv2.SystemIdentityName] == name && matchesExtraIdentity(id, o.ExtraIdentity) {
+			found = append(found, candidates[i])
+		}
+	}
+	switch len(found) {
+	case 0:
+		if len(o.ExtraIdentity) != 0 {
+			return nil, fmt.Errorf("%s %q with extra identity %v not found", kind, name, o.ExtraIdentity)
+		}
+		return nil, fmt.Errorf("%s %q not found", kind, name)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("%s %q is ambiguous, select it with --extra-identity", kind, name)
+	}
+}
+
+// matchesExtraIdentity returns whether the identity contains all given attributes.
+func matchesExtraIdentity(id cdv2.Identity, extraIdentity map[string]string) bool {
+	for key, value := range extraIdentity {
+		if v, ok := id[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// setLabel updates the label with the same name or appends the label.
+func setLabel(labels cdv2.Labels, label cdv2.Label) cdv2.Labels {
+	for i := range labels {
+		if labels[i].Name == label.Name {
+			labels[i].Value = label.Value
+			return labels
+		}
+	}
+	return append(labels, label)
+}
+
+// removeLabel removes the label with the given name.
+// It returns whether the label existed.
+func removeLabel(labels cdv2.Labels, name string) (cdv2.Labels, bool) {
+	for i := range labels {
+		if labels[i].Name == name {
+			return append(labels[:i], labels[i+1:]...), true
+		}
+	}
+	return labels, false
+}
+
+// ParseLabel parses a label that is defined as NAME=VALUE.
+// The value is used as raw json if it is valid json, otherwise it is encoded as json string.
+func ParseLabel(label string) (cdv2.Label, error) {
+	splitLabel := strings.SplitN(label, "=", 2)
+	if len(splitLabel) != 2 || len(splitLabel[0]) == 0 {
+		return cdv2.Label{}, fmt.Errorf("invalid label %q, expected NAME=VALUE", label)
+	}
+	name, value := splitLabel[0], splitLabel[1]
+	if len(value) != 0 && json.Valid([]byte(value)) {
+		return cdv2.Label{Name: name, Value: json.RawMessage(value)}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return cdv2.Label{}, fmt.Errorf("unable to encode value of label %q: %w", name, err)
+	}
+	return cdv2.Label{Name: name, Value: data}, nil
+}
+
+func (o *SetLabelOptions) Complete(args []string) error {
+	if len(args) == 0 {
+		return errors.New("a component archive path argument has to be defined")
+	}
+	o.ComponentArchivePath = args[0]
+	o.Labels = append(o.Labels, args[1:]...)
+
+	o.labels = make([]cdv2.Label, 0, len(o.Labels))
+	for _, l := range o.Labels {
+		label, err := ParseLabel(l)
+		if err != nil {
+			return err
+		}
+		o.labels = append(o.labels, label)
+	}
+	return o.validate()
+}
+
+func (o *SetLabelOptions) validate() error {
+	if len(o.ComponentArchivePath) == 0 {
+		return errors.New("a component archive path must be provided")
+	}
+	if len(o.labels) == 0 && len(o.Delete) == 0 {
+		return errors.New("at least one label has to be set or deleted")
+	}
+	targets := 0
+	for _, name := range []string{o.Resource, o.Source, o.ComponentReference} {
+		if len(name) != 0 {
+			targets++
+		}
+	}
+	if targets > 1 {
+		return errors.New("only one of --resource, --source and --component-reference can be defined")
+	}
+	if targets == 0 && len(o.ExtraIdentity) != 0 {
+		return errors.New("an extra identity can only be defined for a resource, source or component reference")
+	}
+	for _, label := range o.labels {
+		for _, name := range o.Delete {
+			if label.Name == name {
+				return fmt.Errorf("label %q cannot be set and deleted at the same time", name)
+			}
+		}
+	}
+	return nil
+}
+
+func (o *SetLabelOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&o.Delete, "delete", nil, "name of a label that is deleted. Can be defined multiple times")
+	fs.StringVar(&o.Resource, "resource", "", "name of the resource whose labels are modified")
+	fs.StringVar(&o.Source, "source", "", "name of the source whose labels are modified")
+	fs.StringVar(&o.ComponentReference, "component-reference", "", "name of the component reference whose labels are modified")
+	fs.StringToStringVar(&o.ExtraIdentity, "extra-identity", nil, "extra identity of the resource, source or component reference. Can be defined multiple times as key=value")
+	fs.BoolVar(&o.Backup, "backup", false, "[OPTIONAL] keeps the previous component descriptor as component-descriptor.yaml.bak if the component archive is a directory")
+}
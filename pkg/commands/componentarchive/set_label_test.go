@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"context"
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	"github.com/gardener/component-cli/pkg/testutils"
+)
+
+var _ = Describe("SetLabel", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
+	})
+
+	writeComponentDescriptor := func(modify func(cd *cdv2.ComponentDescriptor)) {
+		data, err := vfs.ReadFile(testdataFs, "00-ca/component-descriptor.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		modify(cd)
+		data, err = yaml.Marshal(cd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testdataFs, "00-ca/component-descriptor.yaml", data, 0664)).To(Succeed())
+	}
+
+	readComponentDescriptor := func() *cdv2.ComponentDescriptor {
+		data, err := vfs.ReadFile(testdataFs, "00-ca/component-descriptor.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		return cd
+	}
+
+	run := func(opts *componentarchive.SetLabelOptions, args ...string) error {
+		if err := opts.Complete(append([]string{"00-ca"}, args...)); err != nil {
+			return err
+		}
+		return opts.Run(context.TODO(), logr.Discard(), testdataFs)
+	}
+
+	It("should set string and json labels of the component", func() {
+		Expect(run(&componentarchive.SetLabelOptions{}, "team=my-team", `config={"replicas":2}`)).To(Succeed())
+
+		cd := readComponentDescriptor()
+		Expect(cd.Labels).To(HaveLen(2))
+		Expect(cd.Labels[0].Name).To(Equal("team"))
+		Expect(cd.Labels[0].Value).To(MatchJSON(`"my-team"`))
+		Expect(cd.Labels[1].Name).To(Equal("config"))
+		Expect(cd.Labels[1].Value).To(MatchJSON(`{"replicas":2}`))
+	})
+
+	It("should update and delete labels of the component", func() {
+		writeComponentDescriptor(func(cd *cdv2.ComponentDescriptor) {
+			cd.Labels = cdv2.Labels{
+				{Name: "a", Value: json.RawMessage(`"1"`)},
+				{Name: "b", Value: json.RawMessage(`"2"`)},
+			}
+		})
+		Expect(run(&componentarchive.SetLabelOptions{Delete: []string{"a"}}, "b=true")).To(Succeed())
+
+		cd := readComponentDescriptor()
+		Expect(cd.Labels).To(HaveLen(1))
+		Expect(cd.Labels[0].Name).To(Equal("b"))
+		Expect(cd.Labels[0].Value).To(MatchJSON(`true`))
+	})
+
+	It("should set labels of a resource selected by its extra identity", func() {
+		writeComponentDescriptor(func(cd *cdv2.ComponentDescriptor) {
+			for _, arch := range []string{"amd64", "arm64"} {
+				res := cdv2.Resource{}
+				res.Name = "image"
+				res.Version = "v0.0.1"
+				res.Type = cdv2.OCIImageType
+				res.Relation = cdv2.ExternalRelation
+				res.ExtraIdentity = cdv2.Identity{"arch": arch}
+				access, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("example.com/image:" + arch))
+				Expect(err).ToNot(HaveOccurred())
+				res.Access = &access
+				cd.Resources = append(cd.Resources, res)
+			}
+		})
+
+		err := run(&componentarchive.SetLabelOptions{Resource: "image"}, "team=my-team")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("ambiguous"))
+
+		Expect(run(&componentarchive.SetLabelOptions{
+			Resource:      "image",
+			ExtraIdentity: map[string]string{"arch": "arm64"},
+		}, "team=my-team")).To(Succeed())
+
+		cd := readComponentDescriptor()
+		Expect(cd.Resources[0].Labels).To(BeEmpty())
+		Expect(cd.Resources[1].Labels).To(HaveLen(1))
+		Expect(cd.Resources[1].Labels[0].Value).To(MatchJSON(`"my-team"`))
+	})
+
+	It("should fail if the resource does not exist", func() {
+		err := run(&componentarchive.SetLabelOptions{Resource: "unknown"}, "team=my-team")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("not found"))
+	})
+
+	It("should fail if multiple targets are defined", func() {
+		err := run(&componentarchive.SetLabelOptions{Resource: "a", Source: "b"}, "team=my-team")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail if a label is malformed", func() {
+		Expect(run(&componentarchive.SetLabelOptions{}, "team")).To(HaveOccurred())
+	})
+
+})
@@ -32,6 +32,9 @@ type CheckDigestsOptions struct {
 	// Version is the component Version in the oci registry.
 	Version string
 
+	// DigestCheckOptions configures how the resource digests are checked.
+	DigestCheckOptions verify.DigestCheckOptions
+
 	// OciOptions contains all exposed options to configure the oci client.
 	OciOptions ociopts.Options
 }
@@ -75,7 +78,7 @@ func (o *CheckDigestsOptions) Run(ctx context.Context, log logr.Logger, fs vfs.F
 	}
 
 	// check componentReferences and resources
-	if err := verify.CheckCdDigests(cd, *repoCtx, ociClient, context.TODO()); err != nil {
+	if err := verify.CheckCdDigestsWithOptions(cd, *repoCtx, ociClient, ctx, o.DigestCheckOptions); err != nil {
 		return fmt.Errorf("unable to check component descriptor digests: %w", err)
 	}
 
@@ -111,5 +114,6 @@ func (o *CheckDigestsOptions) Complete(args []string) error {
 }
 
 func (o *CheckDigestsOptions) AddFlags(fs *pflag.FlagSet) {
+	o.DigestCheckOptions.AddFlags(fs)
 	o.OciOptions.AddFlags(fs)
 }
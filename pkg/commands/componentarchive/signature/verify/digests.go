@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	remoteserrors "github.com/containerd/containerd/remotes/errors"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/spf13/pflag"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/signatures"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// DigestCheckOptions configures how the digests of resources are checked.
+type DigestCheckOptions struct {
+	// Workers is the number of resources whose digests are calculated in parallel.
+	Workers int
+	// MaxRetries is the maximum number of retries if the registry rate limits the requests.
+	MaxRetries uint64
+	// BackoffFactor is the backoff factor between retries: backoff = backoff-factor * 2^retries.
+	BackoffFactor time.Duration
+}
+
+// DefaultDigestCheckOptions checks the digests of resources one by one.
+var DefaultDigestCheckOptions = DigestCheckOptions{
+	Workers:       1,
+	MaxRetries:    3,
+	BackoffFactor: 1 * time.Second,
+}
+
+func (o *DigestCheckOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&o.Workers, "workers", DefaultDigestCheckOptions.Workers, "number of resource digests that are fetched and calculated in parallel")
+	fs.Uint64Var(&o.MaxRetries, "max-retries", DefaultDigestCheckOptions.MaxRetries, "maximum number of retries if the registry rate limits requests")
+	fs.DurationVar(&o.BackoffFactor, "backoff-factor", DefaultDigestCheckOptions.BackoffFactor, "a backoff factor to apply between retry attempts: backoff = backoff-factor * 2^retries. e.g. if backoff-factor is 1s, then the timeouts will be [1s, 2s, 4s, …]")
+}
+
+// checkResourceDigests calculates the digests of all resources of the component descriptor with the configured
+// number of workers and compares them to the digests of the component descriptor.
+// All mismatches are aggregated in the order of the resources.
+func checkResourceDigests(ctx context.Context, cd *cdv2.ComponentDescriptor, ociClient ociclient.Client, opts DigestCheckOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, workers)
+		errs = make([]error, len(cd.Resources))
+	)
+	for i := range cd.Resources {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = checkResourceDigest(ctx, cd, cd.Resources[i], ociClient, opts)
+		}(i)
+	}
+	wg.Wait()
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func checkResourceDigest(ctx context.Context, cd *cdv2.ComponentDescriptor, resource cdv2.Resource, ociClient ociclient.Client, opts DigestCheckOptions) error {
+	if resource.Access == nil || resource.Access.Type == "None" {
+		if resource.Digest != nil {
+			return fmt.Errorf("found access == nil or access.type == None in resource %s:%s", resource.Name, resource.Version)
+		}
+		return nil
+	}
+
+	if resource.Digest == nil || resource.Digest.HashAlgorithm == "" || resource.Digest.NormalisationAlgorithm == "" || resource.Digest.Value == "" {
+		return fmt.Errorf("missing digest in resource %s:%s", resource.Name, resource.Version)
+	}
+
+	var (
+		digest *cdv2.DigestSpec
+		err    error
+	)
+	for retries := uint64(0); ; retries++ {
+		// the hasher is not safe for reuse so a new one is created for every attempt.
+		hasher, hasherErr := cdv2Sign.HasherForName(resource.Digest.HashAlgorithm)
+		if hasherErr != nil {
+			return fmt.Errorf("unable to create hasher for resource %s:%s: %w", resource.Name, resource.Version, hasherErr)
+		}
+		digester := signatures.NewDigester(ociClient, *hasher)
+
+		digest, err = digester.DigestForResource(ctx, *cd, resource)
+		if err == nil || !isRateLimitError(err) || retries >= opts.MaxRetries {
+			break
+		}
+
+		select {
+		case <-time.After(utils.ExponentialBackoff(opts.BackoffFactor, retries)):
+		case <-ctx.Done():
+			return fmt.Errorf("unable to calculate digest for resource %s:%s: %w", resource.Name, resource.Version, ctx.Err())
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to calculate digest for resource %s:%s: %w", resource.Name, resource.Version, err)
+	}
+
+	if !reflect.DeepEqual(resource.Digest, digest) {
+		return fmt.Errorf("calculated digest mismatches existing digest for resource %s:%s", resource.Name, resource.Version)
+	}
+	return nil
+}
+
+// isRateLimitError checks whether the error was caused by a registry that rate limits requests.
+func isRateLimitError(err error) bool {
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) {
+		return transportErr.StatusCode == http.StatusTooManyRequests
+	}
+	var statusErr remoteserrors.ErrUnexpectedStatus
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
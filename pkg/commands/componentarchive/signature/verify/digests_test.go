@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package verify_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	mock_ociclient "github.com/gardener/component-cli/ociclient/mock"
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/signature/verify"
+)
+
+var _ = Describe("CheckCdDigests", func() {
+
+	var (
+		mockCtrl      *gomock.Controller
+		mockOCIClient *mock_ociclient.MockClient
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockOCIClient = mock_ociclient.NewMockClient(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	manifest := func(ref string) []byte {
+		return []byte(fmt.Sprintf(`{"ref": %q}`, ref))
+	}
+
+	// newComponentDescriptor creates a component descriptor with ociRegistry resources.
+	// The resources with the given indices get an invalid digest.
+	newComponentDescriptor := func(resources int, invalid ...int) *cdv2.ComponentDescriptor {
+		cd := &cdv2.ComponentDescriptor{}
+		for i := 0; i < resources; i++ {
+			ref := fmt.Sprintf("example.com/image-%d:1.0.0", i)
+			hash := sha256.Sum256(manifest(ref))
+			value := hex.EncodeToString(hash[:])
+			for _, j := range invalid {
+				if i == j {
+					value = "invalid"
+				}
+			}
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(ref))
+			Expect(err).ToNot(HaveOccurred())
+			cd.Resources = append(cd.Resources, cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    fmt.Sprintf("res-%d", i),
+					Version: "v0.1.0",
+					Type:    cdv2.OCIImageType,
+				},
+				Access: &acc,
+				Digest: &cdv2.DigestSpec{
+					HashAlgorithm:          cdv2Sign.SHA256,
+					NormalisationAlgorithm: string(cdv2.OciArtifactDigestV1),
+					Value:                  value,
+				},
+			})
+		}
+		return cd
+	}
+
+	It("should check the digests of all resources in parallel and aggregate mismatches in order", func() {
+		mockOCIClient.EXPECT().GetRawManifest(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, ref string) (ocispecv1.Descriptor, []byte, error) {
+				return ocispecv1.Descriptor{}, manifest(ref), nil
+			}).Times(10)
+
+		cd := newComponentDescriptor(10, 7, 2)
+		err := verify.CheckCdDigestsWithOptions(cd, cdv2.OCIRegistryRepository{}, mockOCIClient, context.TODO(), verify.DigestCheckOptions{
+			Workers: 4,
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("res-2"))
+		Expect(err.Error()).To(ContainSubstring("res-7"))
+		Expect(strings.Index(err.Error(), "res-2")).To(BeNumerically("<", strings.Index(err.Error(), "res-7")))
+	})
+
+	It("should succeed if all digests match", func() {
+		mockOCIClient.EXPECT().GetRawManifest(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, ref string) (ocispecv1.Descriptor, []byte, error) {
+				return ocispecv1.Descriptor{}, manifest(ref), nil
+			}).Times(5)
+
+		cd := newComponentDescriptor(5)
+		Expect(verify.CheckCdDigestsWithOptions(cd, cdv2.OCIRegistryRepository{}, mockOCIClient, context.TODO(), verify.DigestCheckOptions{
+			Workers: 2,
+		})).To(Succeed())
+	})
+
+	It("should retry if the registry rate limits requests", func() {
+		gomock.InOrder(
+			mockOCIClient.EXPECT().GetRawManifest(gomock.Any(), gomock.Any()).
+				Return(ocispecv1.Descriptor{}, nil, fmt.Errorf("unable to get manifest: %w", &transport.Error{StatusCode: http.StatusTooManyRequests})),
+			mockOCIClient.EXPECT().GetRawManifest(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, ref string) (ocispecv1.Descriptor, []byte, error) {
+					return ocispecv1.Descriptor{}, manifest(ref), nil
+				}),
+		)
+
+		cd := newComponentDescriptor(1)
+		Expect(verify.CheckCdDigestsWithOptions(cd, cdv2.OCIRegistryRepository{}, mockOCIClient, context.TODO(), verify.DigestCheckOptions{
+			Workers:       1,
+			MaxRetries:    1,
+			BackoffFactor: time.Millisecond,
+		})).To(Succeed())
+	})
+
+	It("should not retry errors that only mention the rate limit status code", func() {
+		mockOCIClient.EXPECT().GetRawManifest(gomock.Any(), gomock.Any()).
+			Return(ocispecv1.Descriptor{}, nil, errors.New("manifest example.com/image:v1.429 not found")).Times(1)
+
+		cd := newComponentDescriptor(1)
+		err := verify.CheckCdDigestsWithOptions(cd, cdv2.OCIRegistryRepository{}, mockOCIClient, context.TODO(), verify.DigestCheckOptions{
+			Workers:       1,
+			MaxRetries:    3,
+			BackoffFactor: time.Millisecond,
+		})
+		Expect(err).To(MatchError(ContainSubstring("manifest example.com/image:v1.429 not found")))
+	})
+
+	It("should not fetch further resources if the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		mockOCIClient.EXPECT().GetRawManifest(gomock.Any(), gomock.Any()).
+			Return(ocispecv1.Descriptor{}, nil, context.Canceled).AnyTimes()
+
+		cd := newComponentDescriptor(3)
+		err := verify.CheckCdDigestsWithOptions(cd, cdv2.OCIRegistryRepository{}, mockOCIClient, ctx, verify.DigestCheckOptions{
+			Workers: 1,
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+	})
+
+})
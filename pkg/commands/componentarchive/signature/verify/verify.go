@@ -50,6 +50,9 @@ type GenericVerifyOptions struct {
 	// SignatureName selects the matching signature to verify
 	SignatureName string
 
+	// DigestCheckOptions configures how the resource digests are checked.
+	DigestCheckOptions DigestCheckOptions
+
 	// OciOptions contains all exposed options to configure the oci client.
 	OciOptions ociopts.Options
 }
@@ -87,6 +90,7 @@ func (o *GenericVerifyOptions) Complete(args []string) error {
 
 func (o *GenericVerifyOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.SignatureName, "signature-name", "", "name of the signature to verify")
+	o.DigestCheckOptions.AddFlags(fs)
 	o.OciOptions.AddFlags(fs)
 }
 
@@ -105,7 +109,7 @@ func (o *GenericVerifyOptions) VerifyWithVerifier(ctx context.Context, log logr.
 	}
 
 	// check componentReferences and resources
-	if err := CheckCdDigests(cd, *repoCtx, ociClient, context.TODO()); err != nil {
+	if err := CheckCdDigestsWithOptions(cd, *repoCtx, ociClient, ctx, o.DigestCheckOptions); err != nil {
 		return fmt.Errorf("unable to check component descriptor digests: %w", err)
 	}
 
@@ -119,6 +123,12 @@ func (o *GenericVerifyOptions) VerifyWithVerifier(ctx context.Context, log logr.
 }
 
 func CheckCdDigests(cd *cdv2.ComponentDescriptor, repoContext cdv2.OCIRegistryRepository, ociClient ociclient.Client, ctx context.Context) error {
+	return CheckCdDigestsWithOptions(cd, repoContext, ociClient, ctx, DefaultDigestCheckOptions)
+}
+
+// CheckCdDigestsWithOptions checks the digests of the component references and resources of the component descriptor.
+// The resource digests are checked concurrently as configured by the options.
+func CheckCdDigestsWithOptions(cd *cdv2.ComponentDescriptor, repoContext cdv2.OCIRegistryRepository, ociClient ociclient.Client, ctx context.Context, opts DigestCheckOptions) error {
 	for _, reference := range cd.ComponentReferences {
 		ociRef, err := cdoci.OCIRef(repoContext, reference.Name, reference.Version)
 		if err != nil {
@@ -150,35 +160,7 @@ func CheckCdDigests(cd *cdv2.ComponentDescriptor, repoContext cdv2.OCIRegistryRe
 		}
 	}
 
-	for _, resource := range cd.Resources {
-		if resource.Access == nil || resource.Access.Type == "None" {
-			if resource.Digest != nil {
-				return fmt.Errorf("found access == nil or access.type == None in resource %s:%s", resource.Name, resource.Version)
-			}
-			continue
-		}
-
-		if resource.Digest == nil || resource.Digest.HashAlgorithm == "" || resource.Digest.NormalisationAlgorithm == "" || resource.Digest.Value == "" {
-			return fmt.Errorf("missing digest in resource %s:%s", resource.Name, resource.Version)
-		}
-
-		hasher, err := cdv2Sign.HasherForName(resource.Digest.HashAlgorithm)
-		if err != nil {
-			return fmt.Errorf("unable to create hasher for resource %s:%s: %w", resource.Name, resource.Version, err)
-		}
-		digester := signatures.NewDigester(ociClient, *hasher)
-
-		digest, err := digester.DigestForResource(ctx, *cd, resource)
-		if err != nil {
-			return fmt.Errorf("unable to calculate digest for resource %s:%s: %w", resource.Name, resource.Version, err)
-		}
-
-		if !reflect.DeepEqual(resource.Digest, digest) {
-			return fmt.Errorf("calculated digest mismatches existing digest for resource %s:%s", resource.Name, resource.Version)
-		}
-	}
-
-	return nil
+	return checkResourceDigests(ctx, cd, ociClient, opts)
 }
 
 func recursivelyCheckCdsDigests(cd *cdv2.ComponentDescriptor, repoContext cdv2.OCIRegistryRepository, ociClient ociclient.Client, ctx context.Context, hasherForCd *cdv2Sign.Hasher) (*cdv2.DigestSpec, error) {
@@ -11,7 +11,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/apis/v2/cdutils"
@@ -20,6 +19,7 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -30,6 +30,7 @@ import (
 	"github.com/gardener/component-cli/pkg/componentarchive"
 	"github.com/gardener/component-cli/pkg/logger"
 	"github.com/gardener/component-cli/pkg/template"
+	"github.com/gardener/component-cli/pkg/utils"
 )
 
 // Options defines the options that are used to add resources to a component descriptor
@@ -44,6 +45,15 @@ type Options struct {
 	// SourceObjectPath defines the path to the resources defined as yaml or json
 	// DEPRECATED
 	SourceObjectPath string
+
+	// Backup keeps the previous component descriptor as component-descriptor.yaml.bak.
+	Backup bool
+
+	// DryRun prints the changes of the component descriptor as diff instead of writing the component archive.
+	DryRun bool
+	// Output is the writer the diff is printed to in dry-run mode.
+	// Defaults to stdout.
+	Output io.Writer
 }
 
 // SourceOptions contains options that are used to describe a source
@@ -68,7 +78,13 @@ func NewAddCommand(ctx context.Context) *cobra.Command {
 		Short: "Adds a source to a component descriptor",
 		Long: fmt.Sprintf(`
 add adds sources to the defined component descriptor.
-The sources can be defined in a file or given through stdin.
+The sources are read from the given paths and the files defined by "--file".
+Stdin is only read if it is explicitly requested with "-" as path, e.g. "--file -". The command fails if stdin is an interactive terminal.
+The component archive can be a directory or a tar or tgz file, which is written back in its original format.
+Blobs of input sources are stored by their digest, so identical blobs are only stored once in the component archive.
+
+With "--dry-run" the changes of the component descriptor are printed to stdout as unified diff
+and neither the component descriptor nor blobs are written to the component archive.
 
 The source definitions are expected to be a multidoc yaml of the following form
 
@@ -94,7 +110,7 @@ type: 'git'
 input:
   type: "dir"
   path: /my/path
-  compress: true # defaults to false
+  compress: gzip # optional; one of "none", "gzip" or "zstd", defaults to "none". true is treated as "gzip"
   exclude: "*.txt"
   preserveDir: true # optional, defaulted to false; if true, the top level folder "my/path" is included
 ...
@@ -122,7 +138,27 @@ input:
 }
 
 func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
-	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
+	if err := o.TemplateOptions.LoadValuesFiles(fs); err != nil {
+		return err
+	}
+
+	fs, repack, err := componentarchive.Unpack(fs, o.ComponentArchivePath)
+	if err != nil {
+		return err
+	}
+
+	var oldData []byte
+	if o.DryRun {
+		oldData, err = componentarchive.ReadComponentDescriptorData(fs, o.ComponentArchivePath)
+		if err != nil {
+			return err
+		}
+		// all modifications are only written to memory in dry-run mode
+		fs, err = utils.NewOverlayFS(fs)
+		if err != nil {
+			return fmt.Errorf("unable to create in-memory filesystem: %w", err)
+		}
+	}
 
 	archive, err := o.BuilderOptions.Build(fs)
 	if err != nil {
@@ -134,12 +170,18 @@ func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) e
 		return err
 	}
 
+	// replaced are the accesses of the replaced sources whose blobs are removed if they are no longer referenced
+	replaced := []*cdv2.UnstructuredTypedObject{}
 	for _, src := range sources {
 		if src.Input != nil {
 			log.Info(fmt.Sprintf("add input blob from %q", src.Input.Path))
-			if err := o.addInputBlob(ctx, fs, archive, src); err != nil {
+			replacedAccess, err := o.addInputBlob(ctx, fs, archive, src)
+			if err != nil {
 				return err
 			}
+			if replacedAccess != nil {
+				replaced = append(replaced, replacedAccess)
+			}
 		} else {
 			id := archive.ComponentDescriptor.GetSourceIndex(src.Source)
 			if id != -1 {
@@ -166,11 +208,36 @@ func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) e
 	if err != nil {
 		return fmt.Errorf("unable to encode component descriptor: %w", err)
 	}
-	if err := vfs.WriteFile(fs, compDescFilePath, data, 0664); err != nil {
+	if o.DryRun {
+		return componentarchive.WriteComponentDescriptorDiff(o.output(), o.ComponentArchivePath, oldData, data)
+	}
+	if err := componentarchive.WriteComponentDescriptor(fs, o.ComponentArchivePath, data, o.Backup); err != nil {
 		return fmt.Errorf("unable to write modified comonent descriptor: %w", err)
 	}
 	log.V(1).Info("Successfully added all sources to component descriptor")
-	return nil
+
+	// the blobs of replaced sources are only removed after the modified component descriptor has been written,
+	// so that an invalid component descriptor or a failed write never leaves a source without its blob.
+	if len(replaced) != 0 {
+		archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
+		if err != nil {
+			return fmt.Errorf("unable to create projectionfilesystem: %w", err)
+		}
+		for _, access := range replaced {
+			if err := componentarchive.RemoveUnreferencedBlob(log, archiveFs, archive.ComponentDescriptor, access); err != nil {
+				return err
+			}
+		}
+	}
+	return repack()
+}
+
+// output returns the writer for the dry-run output.
+func (o *Options) output() io.Writer {
+	if o.Output == nil {
+		return os.Stdout
+	}
+	return o.Output
 }
 
 func (o *Options) Complete(args []string) error {
@@ -197,45 +264,29 @@ func (o *Options) validate() error {
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	o.BuilderOptions.AddFlags(fs)
+	o.TemplateOptions.AddFlags(fs)
 	// specify the resource
+	fs.StringArrayVarP(&o.SourceObjectPaths, "file", "f", nil, "[OPTIONAL] path to a file with sources. Use \"-\" to read from stdin. Can be defined multiple times")
 	fs.StringVarP(&o.SourceObjectPath, "resource", "r", "", "The path to the resources defined as yaml or json")
 	_ = fs.MarkDeprecated("resource", "the resources flag is deprecated use the arguments instead.")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "[OPTIONAL] prints the changes of the component descriptor as diff instead of writing the component archive")
+	fs.BoolVar(&o.Backup, "backup", false, "[OPTIONAL] keeps the previous component descriptor as component-descriptor.yaml.bak if the component archive is a directory")
 }
 
-// generateSources parses component references from the given path and stdin.
+// generateSources parses sources from the given paths and stdin.
 func (o *Options) generateSources(log logr.Logger, fs vfs.FileSystem) ([]InternalSourceOptions, error) {
-	if len(o.SourceObjectPaths) == 0 {
-		// try to read from stdin if no resources are defined
-		sourceOptions := make([]InternalSourceOptions, 0)
-		stdinInfo, err := os.Stdin.Stat()
-		if err != nil {
-			log.V(3).Info("unable to read from stdin", "error", err.Error())
-			return nil, nil
-		}
-		if (stdinInfo.Mode()&os.ModeNamedPipe != 0) || stdinInfo.Size() != 0 {
-			stdinResources, err := o.generateSourcesFromReader(os.Stdin)
-			if err != nil {
-				return nil, fmt.Errorf("unable to read from stdin: %w", err)
-			}
-			sourceOptions = append(sourceOptions, convertToInternalSourceOptions(stdinResources, "")...)
-		}
-		return sourceOptions, nil
-	}
-
 	sourceOptions := make([]InternalSourceOptions, 0)
 	for _, resourcePath := range o.SourceObjectPaths {
-		if resourcePath == "-" {
-			stdinInfo, err := os.Stdin.Stat()
+		if resourcePath == utils.StdinPath {
+			stdin, err := utils.Stdin()
 			if err != nil {
-				return nil, fmt.Errorf("unable to read from stdin: %w", err)
+				return nil, err
 			}
-			if (stdinInfo.Mode()&os.ModeNamedPipe != 0) || stdinInfo.Size() != 0 {
-				stdinResources, err := o.generateSourcesFromReader(os.Stdin)
-				if err != nil {
-					return nil, fmt.Errorf("unable to read from stdin: %w", err)
-				}
-				sourceOptions = append(sourceOptions, convertToInternalSourceOptions(stdinResources, "")...)
+			stdinResources, err := o.generateSourcesFromReader(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read from stdin: %w", err)
 			}
+			sourceOptions = append(sourceOptions, convertToInternalSourceOptions(stdinResources, "")...)
 			continue
 		}
 
@@ -288,12 +339,21 @@ func generateSourcesFromReader(reader io.Reader) ([]SourceOptions, error) {
 	return sources, nil
 }
 
-func (o *Options) addInputBlob(ctx context.Context, fs vfs.FileSystem, archive *ctf.ComponentArchive, src InternalSourceOptions) error {
+// addInputBlob adds the blob of the input of the source to the component archive.
+// It returns the access of the source that is replaced by the added source, its blob is kept.
+func (o *Options) addInputBlob(ctx context.Context, fs vfs.FileSystem, archive *ctf.ComponentArchive, src InternalSourceOptions) (*cdv2.UnstructuredTypedObject, error) {
 	blob, err := src.Input.Read(ctx, fs, src.Path)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// remember the blob of a source that is replaced so that it can be removed if it is not shared
+	var replacedAccess *cdv2.UnstructuredTypedObject
+	if id := archive.ComponentDescriptor.GetSourceIndex(src.Source); id != -1 {
+		replacedAccess = archive.ComponentDescriptor.Sources[id].Access
 	}
 
+	// blobs are stored by their digest so identical blobs are only stored once
 	err = archive.AddSource(&src.Source, ctf.BlobInfo{
 		MediaType: src.Type,
 		Digest:    blob.Digest,
@@ -301,12 +361,12 @@ func (o *Options) addInputBlob(ctx context.Context, fs vfs.FileSystem, archive *
 	}, blob.Reader)
 	if err != nil {
 		blob.Reader.Close()
-		return fmt.Errorf("unable to add input blob to archive: %w", err)
+		return nil, fmt.Errorf("unable to add input blob to archive: %w", err)
 	}
 	if err := blob.Reader.Close(); err != nil {
-		return fmt.Errorf("unable to close input file: %w", err)
+		return nil, fmt.Errorf("unable to close input file: %w", err)
 	}
-	return nil
+	return replacedAccess, nil
 }
 
 func convertToInternalSourceOptions(srcOpts []SourceOptions, filepath string) []InternalSourceOptions {
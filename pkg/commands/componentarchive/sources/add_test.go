@@ -5,7 +5,10 @@
 package sources_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -14,10 +17,6 @@ import (
 	"github.com/gardener/component-spec/bindings-go/codec"
 	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/go-logr/logr"
-	"github.com/mandelsoft/vfs/pkg/layerfs"
-	"github.com/mandelsoft/vfs/pkg/memoryfs"
-	"github.com/mandelsoft/vfs/pkg/osfs"
-	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -26,6 +25,7 @@ import (
 	"github.com/gardener/component-cli/pkg/commands/componentarchive/sources"
 	"github.com/gardener/component-cli/pkg/componentarchive"
 	"github.com/gardener/component-cli/pkg/template"
+	"github.com/gardener/component-cli/pkg/testutils"
 )
 
 func TestConfig(t *testing.T) {
@@ -38,9 +38,7 @@ var _ = Describe("Add", func() {
 	var testdataFs vfs.FileSystem
 
 	BeforeEach(func() {
-		fs, err := projectionfs.New(osfs.New(), "./testdata")
-		Expect(err).ToNot(HaveOccurred())
-		testdataFs = layerfs.New(memoryfs.New(), fs)
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
 	})
 
 	It("should add a source defined by a file", func() {
@@ -138,7 +136,7 @@ var _ = Describe("Add", func() {
 		}))
 	})
 
-	It("should add a source defined by stdin when no other inputs are defined.", func() {
+	It("should not read stdin if it is not requested", func() {
 		input, err := os.Open("./testdata/resources/00-src.yaml")
 		Expect(err).ToNot(HaveOccurred())
 		defer input.Close()
@@ -159,13 +157,37 @@ var _ = Describe("Add", func() {
 
 		cd := &cdv2.ComponentDescriptor{}
 		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.Sources).To(HaveLen(0))
 
-		Expect(cd.Sources).To(HaveLen(1))
-		Expect(cd.Sources[0].IdentityObjectMeta).To(MatchFields(IgnoreExtras, Fields{
-			"Name":    Equal("repo"),
-			"Version": Equal("v0.0.1"),
-			"Type":    Equal("git"),
-		}))
+		offset, err := input.Seek(0, io.SeekCurrent)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(offset).To(BeZero())
+	})
+
+	It("should read empty stdin that is redirected from a character device", func() {
+		// /dev/null is a character device but no interactive terminal, so it is read as empty input
+		input, err := os.Open(os.DevNull)
+		Expect(err).ToNot(HaveOccurred())
+		defer input.Close()
+		oldstdin := os.Stdin
+		defer func() {
+			os.Stdin = oldstdin
+		}()
+		os.Stdin = input
+
+		opts := &sources.Options{
+			BuilderOptions:    componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			SourceObjectPaths: []string{"-"},
+		}
+
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.Sources).To(HaveLen(0))
 	})
 
 	It("should add multiple sources defined by a multi doc file", func() {
@@ -234,6 +256,36 @@ var _ = Describe("Add", func() {
 		}))
 	})
 
+	It("should keep the blob of a replaced source if the component descriptor is invalid", func() {
+		Expect(vfs.WriteFile(testdataFs, "./src.txt", []byte("source"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./new-src.txt", []byte("new source"), os.ModePerm)).To(Succeed())
+		src := `name: 'blob'
+version: 'v0.0.1'
+type: '%s'
+input:
+  type: file
+  path: "%s"
+`
+		Expect(vfs.WriteFile(testdataFs, "./resources/blob.yaml", []byte(fmt.Sprintf(src, "git", "../src.txt")), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/invalid-blob.yaml", []byte(fmt.Sprintf(src, "", "../new-src.txt")), os.ModePerm)).To(Succeed())
+
+		opts := &sources.Options{
+			BuilderOptions:    componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			SourceObjectPaths: []string{"./resources/blob.yaml"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobs).To(HaveLen(1))
+
+		opts.SourceObjectPaths = []string{"./resources/invalid-blob.yaml"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobPath(blobs[0].Name())))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("source"))
+	})
+
 	It("should add a templated source defined by a file", func() {
 		opts := &sources.Options{
 			BuilderOptions: componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
@@ -261,4 +313,24 @@ var _ = Describe("Add", func() {
 		}))
 	})
 
+	It("should print the changes as diff in dry-run mode without modifying the component archive", func() {
+		var buf bytes.Buffer
+		opts := &sources.Options{
+			BuilderOptions:    componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			SourceObjectPaths: []string{"./resources/00-src.yaml"},
+			DryRun:            true,
+			Output:            &buf,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(buf.String()).To(HavePrefix("--- 00-component/component-descriptor.yaml\n+++ 00-component/component-descriptor.yaml\n"))
+		Expect(buf.String()).To(ContainSubstring("+    name: repo\n"))
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.Sources).To(BeEmpty())
+	})
+
 })
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/ghodss/yaml"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// RemoveOptions defines the options that are used to remove a source from a component descriptor
+type RemoveOptions struct {
+	// ComponentArchivePath defines the path to the component archive.
+	ComponentArchivePath string
+	// Name defines the name of the source that is removed.
+	Name string
+	// Version defines the version of the source that is removed.
+	Version string
+	// ExtraIdentity defines the extra identity of the source that is removed.
+	ExtraIdentity map[string]string
+	// Backup keeps the previous component descriptor as component-descriptor.yaml.bak.
+	Backup bool
+}
+
+// NewRemoveCommand creates a command to remove a source from a component descriptor.
+func NewRemoveCommand(ctx context.Context) *cobra.Command {
+	opts := &RemoveOptions{}
+	cmd := &cobra.Command{
+		Use:   "remove COMPONENT_ARCHIVE_PATH --name NAME --version VERSION [--extra-identity key=value]...",
+		Args:  cobra.ExactArgs(1),
+		Short: "Removes a source from a component descriptor",
+		Long: `
+removes the source with the given name, version and extra identity from the defined component descriptor.
+The identity of the source has to match exactly, so the extra identity has to be defined
+for all sources that have one.
+
+The command fails without modifying the component descriptor if the source does not exist.
+The component archive can be a directory or a tar or tgz file, which is written back in its original format.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *RemoveOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	fs, repack, err := componentarchive.Unpack(fs, o.ComponentArchivePath)
+	if err != nil {
+		return err
+	}
+
+	archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
+	if err != nil {
+		return fmt.Errorf("unable to create projectionfilesystem: %w", err)
+	}
+	archive, err := ctf.NewComponentArchiveFromFilesystem(archiveFs, codec.DisableValidation(true))
+	if err != nil {
+		return fmt.Errorf("unable to parse component archive from %s: %w", o.ComponentArchivePath, err)
+	}
+	cd := archive.ComponentDescriptor
+
+	id := -1
+	for i, src := range cd.Sources {
+		if src.GetName() == o.Name && src.GetVersion() == o.Version && equalIdentity(src.ExtraIdentity, o.ExtraIdentity) {
+			id = i
+			break
+		}
+	}
+	if id == -1 {
+		if len(o.ExtraIdentity) != 0 {
+			return fmt.Errorf("source %q with version %q and extra identity %v not found", o.Name, o.Version, o.ExtraIdentity)
+		}
+		return fmt.Errorf("source %q with version %q not found", o.Name, o.Version)
+	}
+	cd.Sources = append(cd.Sources[:id], cd.Sources[id+1:]...)
+
+	if err := cdvalidation.Validate(cd); err != nil {
+		return fmt.Errorf("invalid component descriptor: %w", err)
+	}
+
+	data, err := yaml.Marshal(cd)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	if err := componentarchive.WriteComponentDescriptor(fs, o.ComponentArchivePath, data, o.Backup); err != nil {
+		return fmt.Errorf("unable to write modified component descriptor: %w", err)
+	}
+	log.V(1).Info(fmt.Sprintf("Successfully removed source %q from component descriptor", o.Name))
+	return repack()
+}
+
+// equalIdentity returns whether both identities contain the same attributes.
+// Nil and empty identities are equal.
+func equalIdentity(a cdv2.Identity, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range b {
+		if v, ok := a[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *RemoveOptions) Complete(args []string) error {
+	if len(args) != 1 {
+		return errors.New("a component archive path argument has to be defined")
+	}
+	o.ComponentArchivePath = args[0]
+	return o.validate()
+}
+
+func (o *RemoveOptions) validate() error {
+	if len(o.ComponentArchivePath) == 0 {
+		return errors.New("a component archive path must be provided")
+	}
+	if len(o.Name) == 0 {
+		return errors.New("a source name must be provided")
+	}
+	if len(o.Version) == 0 {
+		return errors.New("a source version must be provided")
+	}
+	return nil
+}
+
+func (o *RemoveOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Name, "name", "", "name of the source that is removed")
+	fs.StringVar(&o.Version, "version", "", "version of the source that is removed")
+	fs.StringToStringVar(&o.ExtraIdentity, "extra-identity", nil, "extra identity of the source that is removed. Can be defined multiple times as key=value")
+	fs.BoolVar(&o.Backup, "backup", false, "[OPTIONAL] keeps the previous component descriptor as component-descriptor.yaml.bak if the component archive is a directory")
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources_test
+
+import (
+	"context"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/sources"
+	"github.com/gardener/component-cli/pkg/testutils"
+)
+
+var _ = Describe("Remove", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
+	})
+
+	readSourceIdentities := func() []string {
+		data, err := vfs.ReadFile(testdataFs, filepath.Join("./02-component", ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		ids := []string{}
+		for _, src := range cd.Sources {
+			ids = append(ids, src.GetName()+src.ExtraIdentity["language"])
+		}
+		return ids
+	}
+
+	It("should remove a source", func() {
+		opts := &sources.RemoveOptions{
+			Name:    "repo",
+			Version: "v0.0.1",
+		}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(readSourceIdentities()).To(Equal([]string{"docsen", "docsde"}))
+	})
+
+	It("should remove the source with the matching extra identity", func() {
+		opts := &sources.RemoveOptions{
+			ComponentArchivePath: "./02-component",
+			Name:                 "docs",
+			Version:              "v0.0.1",
+			ExtraIdentity:        map[string]string{"language": "de"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(readSourceIdentities()).To(Equal([]string{"repo", "docsen"}))
+	})
+
+	It("should fail without modifying the component descriptor if the identity does not match exactly", func() {
+		opts := &sources.RemoveOptions{
+			ComponentArchivePath: "./02-component",
+			Name:                 "docs",
+			Version:              "v0.0.1",
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(MatchError(`source "docs" with version "v0.0.1" not found`))
+
+		opts.ExtraIdentity = map[string]string{"language": "fr"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(MatchError(`source "docs" with version "v0.0.1" and extra identity map[language:fr] not found`))
+		Expect(readSourceIdentities()).To(Equal([]string{"repo", "docsen", "docsde"}))
+	})
+
+	It("should require a name and a version", func() {
+		opts := &sources.RemoveOptions{Name: "repo"}
+		Expect(opts.Complete([]string{"./02-component"})).To(MatchError("a source version must be provided"))
+	})
+
+})
@@ -18,5 +18,6 @@ func NewSourcesCommand(ctx context.Context) *cobra.Command {
 		Short:   "command to modify sources of a component descriptor",
 	}
 	cmd.AddCommand(NewAddCommand(ctx))
+	cmd.AddCommand(NewRemoveCommand(ctx))
 	return cmd
 }
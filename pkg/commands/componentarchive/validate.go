@@ -0,0 +1,312 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+const (
+	// TextOutput prints the findings as table.
+	TextOutput = "text"
+	// JSONOutput prints the validation result as json.
+	JSONOutput = "json"
+)
+
+// Severity is the severity of a validation finding.
+type Severity string
+
+const (
+	// SeverityError marks findings that make the component archive invalid.
+	SeverityError Severity = "error"
+	// SeverityWarning marks findings that only make the component archive invalid in strict mode.
+	SeverityWarning Severity = "warning"
+)
+
+// Validation checks that can result in a finding.
+const (
+	SchemaCheck     = "schema"
+	BlobCheck       = "blob"
+	DigestCheck     = "digest"
+	AccessTypeCheck = "accessType"
+)
+
+// knownAccessTypes are the access types that are defined by the component spec.
+var knownAccessTypes = map[string]bool{
+	cdv2.OCIRegistryType:          true,
+	cdv2.RelativeOciReferenceType: true,
+	cdv2.OCIBlobType:              true,
+	cdv2.LocalOCIBlobType:         true,
+	cdv2.LocalFilesystemBlobType:  true,
+	cdv2.WebType:                  true,
+	cdv2.GitHubAccessType:         true,
+	cdv2.S3AccessType:             true,
+	"None":                        true,
+}
+
+// ValidateOptions defines the options that are used to validate a component archive.
+type ValidateOptions struct {
+	// ComponentArchivePath defines the path to the component archive.
+	ComponentArchivePath string
+	// Strict treats warnings as errors.
+	Strict bool
+	// WarnOnly reports all findings but never fails.
+	WarnOnly bool
+	// Output defines the output format.
+	Output string
+}
+
+// Finding describes a single problem of a component archive.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Check    string   `json:"check"`
+	Message  string   `json:"message"`
+}
+
+// ValidationResult is the result of the validation of a component archive.
+type ValidationResult struct {
+	Valid    bool      `json:"valid"`
+	Findings []Finding `json:"findings"`
+}
+
+// NewValidateCommand creates a new command to validate a component archive.
+func NewValidateCommand(ctx context.Context) *cobra.Command {
+	opts := &ValidateOptions{}
+	cmd := &cobra.Command{
+		Use:   "validate COMPONENT_ARCHIVE_PATH",
+		Args:  cobra.ExactArgs(1),
+		Short: "Validates a component archive",
+		Long: `
+Validates a component archive. The component archive can be a directory or a tar or tgz file.
+
+The following checks are performed:
+- schema: the component descriptor must be valid according to the component descriptor schema.
+- blob: the blobs of all resources and sources with a local filesystem blob access must be part of the archive.
+- digest: the digests of local blobs must match the digest defined in the component descriptor.
+- accessType: resources and sources should only use access types that are defined by the component spec.
+
+Unknown access types are reported as warnings, all other findings are errors.
+The command fails if at least one error is found. With --strict warnings also fail the validation,
+with --warn-only all findings are reported but the command never fails.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, osfs.New(), os.Stdout); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run validates the component archive and writes the result to the given writer.
+// An error is returned if the component archive is invalid.
+func (o *ValidateOptions) Run(_ context.Context, fs vfs.FileSystem, w io.Writer) error {
+	result, err := o.validateArchive(fs)
+	if err != nil {
+		return err
+	}
+
+	switch o.Output {
+	case JSONOutput:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal validation result: %w", err)
+		}
+		if _, err := fmt.Fprintln(w, string(data)); err != nil {
+			return err
+		}
+	default:
+		if err := printFindings(w, result.Findings); err != nil {
+			return err
+		}
+	}
+
+	if !result.Valid && !o.WarnOnly {
+		return fmt.Errorf("component archive %q is invalid", o.ComponentArchivePath)
+	}
+	return nil
+}
+
+// validateArchive runs all checks on the component archive.
+func (o *ValidateOptions) validateArchive(fs vfs.FileSystem) (*ValidationResult, error) {
+	if _, err := fs.Stat(o.ComponentArchivePath); err != nil {
+		return nil, fmt.Errorf("unable to read component archive at %q: %w", o.ComponentArchivePath, err)
+	}
+	// tar and tgz component archives are unpacked in memory so that the archive layout can be checked
+	fs, _, err := componentarchive.Unpack(fs, o.ComponentArchivePath)
+	if err != nil {
+		return nil, err
+	}
+	archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create projectionfilesystem: %w", err)
+	}
+	archive, err := ctf.NewComponentArchiveFromFilesystem(archiveFs, codec.DisableValidation(true))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse component archive from %s: %w", o.ComponentArchivePath, err)
+	}
+	cd := archive.ComponentDescriptor
+
+	findings := []Finding{}
+	findings = append(findings, validateSchema(cd)...)
+	for _, res := range cd.Resources {
+		findings = append(findings, validateAccess(archiveFs, fmt.Sprintf("resource %q", res.GetName()), res.Access, res.Digest)...)
+	}
+	for _, src := range cd.Sources {
+		findings = append(findings, validateAccess(archiveFs, fmt.Sprintf("source %q", src.GetName()), src.Access, nil)...)
+	}
+
+	result := &ValidationResult{Valid: true, Findings: findings}
+	for _, finding := range findings {
+		if finding.Severity == SeverityError || o.Strict {
+			result.Valid = false
+		}
+	}
+	return result, nil
+}
+
+// validateSchema returns a finding for every schema violation of the component descriptor.
+func validateSchema(cd *cdv2.ComponentDescriptor) []Finding {
+	err := cdvalidation.Validate(cd)
+	if err == nil {
+		return nil
+	}
+	errs := []error{err}
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		errs = agg.Errors()
+	}
+	findings := make([]Finding, 0, len(errs))
+	for _, err := range errs {
+		findings = append(findings, Finding{Severity: SeverityError, Check: SchemaCheck, Message: err.Error()})
+	}
+	return findings
+}
+
+// validateAccess validates the access of a resource or source that is described by the given subject.
+// The digest is only validated for local filesystem blobs.
+func validateAccess(archiveFs vfs.FileSystem, subject string, access *cdv2.UnstructuredTypedObject, digest *cdv2.DigestSpec) []Finding {
+	if access == nil {
+		return nil
+	}
+	if !knownAccessTypes[access.GetType()] {
+		return []Finding{{
+			Severity: SeverityWarning,
+			Check:    AccessTypeCheck,
+			Message:  fmt.Sprintf("%s has the unknown access type %q", subject, access.GetType()),
+		}}
+	}
+	if access.GetType() != cdv2.LocalFilesystemBlobType {
+		return nil
+	}
+
+	blobAccess := &cdv2.LocalFilesystemBlobAccess{}
+	if err := access.DecodeInto(blobAccess); err != nil {
+		return []Finding{{
+			Severity: SeverityError,
+			Check:    BlobCheck,
+			Message:  fmt.Sprintf("unable to decode local filesystem blob access of %s: %s", subject, err.Error()),
+		}}
+	}
+	if _, err := archiveFs.Stat(ctf.BlobPath(blobAccess.Filename)); err != nil {
+		return []Finding{{
+			Severity: SeverityError,
+			Check:    BlobCheck,
+			Message:  fmt.Sprintf("local blob %q of %s is not part of the component archive", blobAccess.Filename, subject),
+		}}
+	}
+
+	if digest == nil || digest.NormalisationAlgorithm != string(cdv2.GenericBlobDigestV1) {
+		return nil
+	}
+	actual, err := componentarchive.LocalBlobDigest(archiveFs, blobAccess.Filename, digest.HashAlgorithm)
+	if err != nil {
+		return []Finding{{
+			Severity: SeverityError,
+			Check:    DigestCheck,
+			Message:  fmt.Sprintf("unable to verify the digest of %s: %s", subject, err.Error()),
+		}}
+	}
+	if actual.Value != digest.Value {
+		return []Finding{{
+			Severity: SeverityError,
+			Check:    DigestCheck,
+			Message:  fmt.Sprintf("digest of local blob %q of %s is %s:%s but expected %s:%s", blobAccess.Filename, subject, digest.HashAlgorithm, actual.Value, digest.HashAlgorithm, digest.Value),
+		}}
+	}
+	return nil
+}
+
+func printFindings(w io.Writer, findings []Finding) error {
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No findings")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "SEVERITY\tCHECK\tMESSAGE"); err != nil {
+		return err
+	}
+	for _, finding := range findings {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", finding.Severity, finding.Check, finding.Message); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func (o *ValidateOptions) Complete(args []string) error {
+	if len(args) != 1 {
+		return errors.New("a component archive path argument has to be defined")
+	}
+	o.ComponentArchivePath = args[0]
+	return o.validate()
+}
+
+func (o *ValidateOptions) validate() error {
+	if len(o.ComponentArchivePath) == 0 {
+		return errors.New("a component archive path must be provided")
+	}
+	if o.Strict && o.WarnOnly {
+		return errors.New("--strict and --warn-only cannot be used together")
+	}
+	if o.Output != TextOutput && o.Output != JSONOutput {
+		return fmt.Errorf("unsupported output format %q, expected one of %q, %q", o.Output, TextOutput, JSONOutput)
+	}
+	return nil
+}
+
+func (o *ValidateOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Strict, "strict", false, "treat warnings as errors")
+	fs.BoolVar(&o.WarnOnly, "warn-only", false, "report all findings but do not fail")
+	fs.StringVarP(&o.Output, "output", "o", TextOutput, fmt.Sprintf("output format. One of %q, %q", TextOutput, JSONOutput))
+}
@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+)
+
+// blobDigest is the sha256 digest of the content "blob".
+const blobDigest = "fa2c8cc4f28176bbeed4b736df569a34c79cd3723e9ec42f9674b4d46ac6b8b8"
+
+var _ = Describe("Validate", func() {
+
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+	})
+
+	// writeArchive writes a component archive with a local blob "blob" and the given resources to /ca.
+	writeArchive := func(resources string) {
+		Expect(fs.MkdirAll(filepath.Join("/ca", ctf.BlobsDirectoryName), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, filepath.Join("/ca", ctf.BlobPath("blob")), []byte("blob"), os.ModePerm)).To(Succeed())
+		cd := fmt.Sprintf(`meta:
+  schemaVersion: 'v2'
+component:
+  name: 'example.com/a'
+  version: 'v1.0.0'
+  repositoryContexts: []
+  provider: 'internal'
+  sources: []
+  componentReferences: []
+  resources:%s
+`, resources)
+		Expect(vfs.WriteFile(fs, filepath.Join("/ca", ctf.ComponentDescriptorFileName), []byte(cd), os.ModePerm)).To(Succeed())
+	}
+
+	localResource := func(name, filename, digest string) string {
+		return fmt.Sprintf(`
+  - name: '%s'
+    version: 'v1.0.0'
+    type: 'plain-text'
+    relation: 'local'
+    access:
+      type: 'localFilesystemBlob'
+      filename: '%s'
+    digest:
+      hashAlgorithm: 'sha256'
+      normalisationAlgorithm: 'genericBlobDigest/v1'
+      value: '%s'`, name, filename, digest)
+	}
+
+	customResource := `
+  - name: 'custom'
+    version: 'v1.0.0'
+    type: 'plain-text'
+    relation: 'external'
+    access:
+      type: 'myCustomAccess'`
+
+	runValidate := func(opts *componentarchive.ValidateOptions) (*componentarchive.ValidationResult, error) {
+		if len(opts.Output) == 0 {
+			opts.Output = componentarchive.JSONOutput
+		}
+		Expect(opts.Complete([]string{"/ca"})).To(Succeed())
+		var buf bytes.Buffer
+		err := opts.Run(context.TODO(), fs, &buf)
+		result := &componentarchive.ValidationResult{}
+		Expect(json.Unmarshal(buf.Bytes(), result)).To(Succeed())
+		return result, err
+	}
+
+	It("should succeed for a valid component archive", func() {
+		writeArchive(localResource("res-a", "blob", blobDigest))
+		result, err := runValidate(&componentarchive.ValidateOptions{Strict: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Valid).To(BeTrue())
+		Expect(result.Findings).To(BeEmpty())
+	})
+
+	It("should report missing local blobs and wrong digests", func() {
+		writeArchive(localResource("res-a", "missing", blobDigest) + localResource("res-b", "blob", "abc"))
+		result, err := runValidate(&componentarchive.ValidateOptions{})
+		Expect(err).To(MatchError(`component archive "/ca" is invalid`))
+		Expect(result.Valid).To(BeFalse())
+		Expect(result.Findings).To(ConsistOf(
+			componentarchive.Finding{
+				Severity: componentarchive.SeverityError,
+				Check:    componentarchive.BlobCheck,
+				Message:  `local blob "missing" of resource "res-a" is not part of the component archive`,
+			},
+			componentarchive.Finding{
+				Severity: componentarchive.SeverityError,
+				Check:    componentarchive.DigestCheck,
+				Message:  fmt.Sprintf(`digest of local blob "blob" of resource "res-b" is sha256:%s but expected sha256:abc`, blobDigest),
+			},
+		))
+	})
+
+	It("should report schema violations", func() {
+		writeArchive(localResource("res-a", "blob", blobDigest) + localResource("res-a", "blob", blobDigest))
+		result, err := runValidate(&componentarchive.ValidateOptions{})
+		Expect(err).To(HaveOccurred())
+		Expect(result.Findings).To(HaveLen(1))
+		Expect(result.Findings[0].Check).To(Equal(componentarchive.SchemaCheck))
+		Expect(result.Findings[0].Message).To(ContainSubstring("duplicated resource"))
+	})
+
+	It("should only fail for unknown access types in strict mode", func() {
+		writeArchive(customResource)
+		result, err := runValidate(&componentarchive.ValidateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Valid).To(BeTrue())
+		Expect(result.Findings).To(ConsistOf(componentarchive.Finding{
+			Severity: componentarchive.SeverityWarning,
+			Check:    componentarchive.AccessTypeCheck,
+			Message:  `resource "custom" has the unknown access type "myCustomAccess"`,
+		}))
+
+		result, err = runValidate(&componentarchive.ValidateOptions{Strict: true})
+		Expect(err).To(HaveOccurred())
+		Expect(result.Valid).To(BeFalse())
+	})
+
+	It("should report errors without failing in warn-only mode", func() {
+		writeArchive(localResource("res-a", "missing", blobDigest))
+		result, err := runValidate(&componentarchive.ValidateOptions{WarnOnly: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Valid).To(BeFalse())
+		Expect(result.Findings).To(HaveLen(1))
+	})
+
+	It("should print the findings as table", func() {
+		writeArchive(customResource)
+		opts := &componentarchive.ValidateOptions{Output: componentarchive.TextOutput}
+		Expect(opts.Complete([]string{"/ca"})).To(Succeed())
+		var buf bytes.Buffer
+		Expect(opts.Run(context.TODO(), fs, &buf)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring("SEVERITY"))
+		Expect(buf.String()).To(ContainSubstring(`warning   accessType  resource "custom" has the unknown access type "myCustomAccess"`))
+	})
+
+	It("should not allow strict and warn-only at the same time", func() {
+		opts := &componentarchive.ValidateOptions{Strict: true, WarnOnly: true, Output: componentarchive.TextOutput}
+		Expect(opts.Complete([]string{"/ca"})).To(MatchError("--strict and --warn-only cannot be used together"))
+	})
+
+})
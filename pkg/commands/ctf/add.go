@@ -9,14 +9,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
 
 	"github.com/gardener/component-cli/pkg/componentarchive"
 	"github.com/gardener/component-cli/pkg/logger"
@@ -30,15 +38,101 @@ type AddOptions struct {
 	ArchiveFormat ctf.ArchiveFormat
 
 	ComponentArchives []string
+	// ArchivesFile is the path to a file that contains a newline-delimited or yaml list of component archives.
+	// The archives are added in addition to the explicitly defined component archives.
+	ArchivesFile string
+	// Rewrite forces a full rewrite of the ctf instead of appending the component archives.
+	Rewrite bool
+	// Overwrite replaces component archives with the same component name and version.
+	// By default adding a component that is already part of the ctf results in an error.
+	Overwrite bool
+	// SkipExisting keeps component archives with the same component name and version
+	// and skips the added component archive.
+	SkipExisting bool
+	// FailOnExisting fails if a component is already part of the ctf, which is the default behavior.
+	FailOnExisting bool
+	// Parallel defines the number of component archives that are read and parsed concurrently.
+	Parallel int
+	// SkipReferenceCheck disables the check that all component references resolve within the ctf.
+	SkipReferenceCheck bool
+	// SkipBlobCheck disables the check that all local blobs of the resources are part of their component archive.
+	SkipBlobCheck bool
+	// Compress writes the ctf as gzipped tar.
+	// Compressed ctfs are always written compressed again, new ctfs with a .tar.gz or .tgz extension are gzipped by default.
+	Compress bool
+	// Compression is the compression of the written ctf, either gzip or zstd.
+	// It takes precedence over Compress, new ctfs with a .tar.zst or .tzst extension are zstd compressed by default.
+	Compression string
+	// Directory creates a new ctf in the directory layout.
+	// Existing directories are always used as ctf in the directory layout.
+	Directory bool
+	// Index writes an index of the component archives to the plain ctf.
+	// The index of an already indexed ctf is always updated.
+	Index bool
 }
 
 // NewAddCommand creates a new definition command to push definitions
 func NewAddCommand(ctx context.Context) *cobra.Command {
 	opts := &AddOptions{}
 	cmd := &cobra.Command{
-		Use:   "add CTF_PATH [-f component-archive]...",
-		Args:  cobra.RangeArgs(1, 4),
+		Use:   "add CTF_PATH [COMPONENT_ARCHIVE_PATH...] [-f component-archive]... [--archives-file path]",
+		Args:  cobra.MinimumNArgs(1),
 		Short: "Adds component archives to a ctf",
+		Long: `
+Adds component archives to a ctf. If the ctf does not exist, a new one is created.
+Component archives can be given as expanded directories, tar or gzipped tar archives.
+The first argument is the path to the ctf, the component archives are defined by all further arguments,
+any number of -f flags and --archives-file.
+
+Every component archive path can be a glob pattern as supported by filepath.Match, e.g. "./components/*.tar".
+A directory that is not a component archive itself, i.e. it does not contain a component-descriptor.yaml,
+is scanned recursively for component archives: directories with a component-descriptor.yaml and files with
+a .tar, .tgz or .tar.gz extension. Hidden files and directories are ignored.
+Component archives that are found multiple times are only added once.
+
+New component archives are appended to the end of the ctf so that the already contained component archives
+do not have to be read and rewritten. This considerably speeds up adding components to large ctfs,
+e.g. adding a component to a ctf with 1000 components is about 15 times faster (see BenchmarkAdd).
+The complete ctf is rewritten if one of the component archives is replaced,
+the ctf cannot be safely appended or if --rewrite is set.
+
+How a component name and version that is already part of the ctf or that is defined multiple times is handled
+is defined by one of the following flags:
+- --fail-on-existing: adding the component fails. This is the default.
+- --overwrite: the last defined component archive replaces the others.
+- --skip-existing: the component archive of the ctf or the first defined component archive is kept,
+  all others are skipped. The ctf is not modified if all component archives are skipped.
+
+The component archives are read and parsed concurrently by --parallel workers.
+They are always added to the ctf in the order they are defined.
+
+All component references of the components in the ctf have to resolve to a component of the ctf.
+Use --skip-reference-check to add components with dangling references, e.g. for partial transports.
+
+The blobs of all resources with a local filesystem blob access have to be part of their component archive.
+Use --skip-blob-check to add component archives with missing local blobs.
+
+The ctf is written as gzipped tar if --compress is set, if the ctf is already gzipped or
+if a new ctf is created with a .tar.gz or .tgz extension.
+The ctf is written as zstd compressed tar with --compression zstd, if the ctf is already zstd compressed or
+if a new ctf is created with a .tar.zst or .tzst extension.
+The compression of an existing ctf is detected by its magic bytes and kept unless --compression is set.
+Compressed ctfs cannot be appended, they are rewritten as a stream that is compressed on the fly.
+
+Component archives are streamed into the ctf so that even multi-gigabyte ctfs and component archives
+are never held in memory. Tar and gzipped tar component archives are only scanned for their component descriptor
+and copied into the ctf without modification if they already have the format given by --format.
+Rewritten ctfs are written to a temporary file next to the ctf that replaces the ctf only if it has been written completely.
+
+A ctf can also be a directory that contains every component archive in the filesystem format in its own subdirectory.
+New ctfs are created in this directory layout with --directory, existing directories are always used in the directory layout.
+Component archives are added to and replaced in the directory without touching the other component archives,
+so that a ctf can be assembled incrementally on disk without rewriting a tar.
+
+A plain ctf is indexed with --index: an index of all component archives with their offset and digest
+is written to the file "<CTF_PATH>` + ctfIndexFileSuffix + `" next to the ctf, so that "ctf list", "ctf get" and "ctf push" can read single component archives
+without scanning the whole ctf (see "ctf index"). The index of an already indexed ctf is always updated.
+`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
 				fmt.Println(err.Error())
@@ -59,7 +153,11 @@ func NewAddCommand(ctx context.Context) *cobra.Command {
 	return cmd
 }
 
-func (o *AddOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSystem) error {
+func (o *AddOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	compress, err := parseCompression(o.Compress, o.Compression)
+	if err != nil {
+		return err
+	}
 	info, err := fs.Stat(o.CTFPath)
 	if err != nil {
 		if !errors.Is(err, os.ErrNotExist) {
@@ -67,16 +165,20 @@ func (o *AddOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSystem)
 		}
 		log.Info("CTF Archive does not exist creating a new one")
 
-		file, err := fs.OpenFile(o.CTFPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
-		if err != nil {
-			return fmt.Errorf("unable to open file for %s: %w", o.CTFPath, err)
+		if o.Directory {
+			if o.Index {
+				return fmt.Errorf("%q is a ctf in the directory layout that cannot be indexed", o.CTFPath)
+			}
+			if err := fs.MkdirAll(o.CTFPath, os.ModePerm); err != nil {
+				return fmt.Errorf("unable to create ctf directory %q: %w", o.CTFPath, err)
+			}
+			return o.add(ctx, log, fs, o.CTFPath, true, noCompression)
 		}
-		tw := tar.NewWriter(file)
-		if err := tw.Close(); err != nil {
-			return fmt.Errorf("unable to close tarwriter for emtpy tar: %w", err)
+		if compress == noCompression {
+			compress = compressionFromExtension(o.CTFPath)
 		}
-		if err := file.Close(); err != nil {
-			return fmt.Errorf("unable to close tarwriter for emtpy tar: %w", err)
+		if err := writeEmptyCTF(fs, o.CTFPath, compress); err != nil {
+			return err
 		}
 		info, err = fs.Stat(o.CTFPath)
 		if err != nil {
@@ -84,38 +186,214 @@ func (o *AddOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSystem)
 		}
 	}
 	if info.IsDir() {
-		return fmt.Errorf(`%q is a directory. 
-It is expected that the given path points to a CTF Archive`, o.CTFPath)
+		if compress != noCompression {
+			return fmt.Errorf("%q is a ctf in the directory layout that cannot be compressed", o.CTFPath)
+		}
+		if o.Index {
+			return fmt.Errorf("%q is a ctf in the directory layout that cannot be indexed", o.CTFPath)
+		}
+		return o.add(ctx, log, fs, o.CTFPath, true, noCompression)
 	}
 
-	ctfArchive, err := ctf.NewCTF(fs, o.CTFPath)
+	current, err := detectCompression(fs, o.CTFPath)
+	if err != nil {
+		return err
+	}
+	if compress == noCompression {
+		compress = current
+	}
+	if o.Index && compress != noCompression {
+		return fmt.Errorf("%q is a %s compressed ctf that cannot be indexed", o.CTFPath, compress)
+	}
+	return o.add(ctx, log, fs, o.CTFPath, false, compress)
+}
+
+// add adds the component archives to the tar ctf or the ctf in the directory layout at the given path.
+// A tar ctf is written with the given compression, only plain ctfs that keep their compression can be appended.
+func (o *AddOptions) add(ctx context.Context, log logr.Logger, fs vfs.FileSystem, ctfPath string, directory bool, c compression) error {
+	componentArchives := append([]string{}, o.ComponentArchives...)
+	if len(o.ArchivesFile) != 0 {
+		fileArchives, err := readArchivesFile(fs, o.ArchivesFile)
+		if err != nil {
+			return err
+		}
+		componentArchives = append(componentArchives, fileArchives...)
+	}
+	componentArchives, err := expandComponentArchives(fs, componentArchives)
+	if err != nil {
+		return err
+	}
+	if len(componentArchives) == 0 {
+		return errors.New("no archives to add")
+	}
+
+	entryName := utils.CTFComponentArchiveFilename
+	existing := sets.NewString()
+	if directory {
+		entryName = ctfDirectoryEntryName
+		names, err := directoryCTFEntries(fs, ctfPath)
+		if err != nil {
+			return err
+		}
+		existing.Insert(names...)
+	} else {
+		names, err := ctfEntryNames(fs, ctfPath)
+		if err != nil {
+			return err
+		}
+		existing = names
+	}
+	archives, err := openComponentArchiveSources(fs, componentArchives, o.Parallel)
 	if err != nil {
-		return fmt.Errorf("unable to open ctf at %q: %s", o.CTFPath, err.Error())
+		return err
+	}
+	var (
+		added     = sets.NewString()
+		kept      = make([]*componentArchiveSource, 0, len(archives))
+		keptPaths = make([]string, 0, len(archives))
+		filenames = make([]string, 0, len(archives))
+		cds       = make([]*cdv2.ComponentDescriptor, 0, len(archives))
+		replaced  = 0
+		skipped   = 0
+	)
+	for i, src := range archives {
+		caPath := componentArchives[i]
+		name, version := src.cd.GetName(), src.cd.GetVersion()
+		filename := entryName(name, version)
+		if added.Has(filename) || existing.Has(filename) {
+			switch {
+			case o.Overwrite:
+				log.V(3).Info(fmt.Sprintf("Overwriting component %q in version %q with the archive from %q", name, version, caPath))
+				replaced++
+			case o.SkipExisting:
+				log.V(3).Info(fmt.Sprintf("Skipping the archive from %q as component %q in version %q is already part of the ctf", caPath, name, version))
+				skipped++
+				continue
+			default:
+				return fmt.Errorf("component %q in version %q is already part of the ctf. Use --overwrite to replace it or --skip-existing to keep it", name, version)
+			}
+		}
+		added.Insert(filename)
+		kept = append(kept, src)
+		keptPaths = append(keptPaths, caPath)
+		filenames = append(filenames, filename)
+		cds = append(cds, src.cd)
+	}
+	archives, componentArchives = kept, keptPaths
+	if len(archives) == 0 {
+		log.Info("All component archives are already part of the ctf", "skipped", skipped)
+		return nil
+	}
+
+	// logProgress logs the component archive with the given index after it has been added to the ctf.
+	logProgress := func(i int) {
+		cd := archives[i].cd
+		log.Info("Added component archive",
+			"component", cd.GetName(),
+			"version", cd.GetVersion(),
+			"path", componentArchives[i],
+			"progress", fmt.Sprintf("%d/%d", i+1, len(archives)))
+	}
+	logSummary := func() {
+		keysAndValues := []interface{}{"added", len(archives), "replaced", replaced}
+		if skipped != 0 {
+			keysAndValues = append(keysAndValues, "skipped", skipped)
+		}
+		log.Info("Successfully added component archives to the ctf", keysAndValues...)
+	}
+
+	if !o.SkipBlobCheck {
+		if err := validateLocalBlobs(ctx, archives); err != nil {
+			return err
+		}
+	}
+
+	if !o.SkipReferenceCheck {
+		existingCDs, err := ctfComponentDescriptors(fs, ctfPath)
+		if err != nil {
+			return err
+		}
+		if err := validateReferences(componentDescriptorsAfterAdd(existingCDs, cds, filenames)); err != nil {
+			return err
+		}
+	}
+
+	if directory {
+		// component archives in the directory layout are added and replaced independently of the other archives.
+		for i, src := range archives {
+			if err := src.writeDirectoryEntry(fs, ctfPath); err != nil {
+				return fmt.Errorf("unable to add component archive %q to ctf: %w", src.cd.GetName(), err)
+			}
+			logProgress(i)
+		}
+		logSummary()
+		return nil
 	}
 
-	for _, caPath := range o.ComponentArchives {
-		ca, _, err := componentarchive.Parse(fs, caPath)
+	// the index of an indexed ctf is read before the ctf is modified,
+	// so that the digests of the unchanged component archives are reused when the index is updated.
+	var previous *ctfIndex
+	if c == noCompression {
+		previous, err = readCTFIndex(fs, ctfPath)
 		if err != nil {
 			return err
 		}
-		if err := ctfArchive.AddComponentArchiveWithName(
-			utils.CTFComponentArchiveFilename(ca.ComponentDescriptor.GetName(),
-				ca.ComponentDescriptor.GetVersion()),
-			ca,
-			o.ArchiveFormat,
-		); err != nil {
-			return fmt.Errorf("unable to add component archive %q to ctf: %s", ca.ComponentDescriptor.GetName(), err.Error())
+	}
+	writeIndex := func() error {
+		if !o.Index && previous == nil {
+			return nil
+		}
+		if err := writeCTFIndex(fs, ctfPath, previous, added); err != nil {
+			return fmt.Errorf("unable to write the index of the ctf: %w", err)
+		}
+		return nil
+	}
+
+	if !o.Rewrite && c == noCompression {
+		appended, err := appendComponentArchives(fs, ctfPath, archives, o.ArchiveFormat, logProgress)
+		if err != nil {
+			return fmt.Errorf("unable to append component archives to ctf: %w", err)
+		}
+		if appended {
+			if err := writeIndex(); err != nil {
+				return err
+			}
+			logSummary()
+			return nil
+		}
+		log.V(3).Info("Component archives cannot be appended to the ctf, rewriting the complete ctf")
+	}
+
+	// the ctf is rewritten as stream that copies the kept entries and adds the component archives.
+	// Only the last component archive of every entry is written as it replaces the others.
+	last := map[string]int{}
+	for i, filename := range filenames {
+		last[filename] = i
+	}
+	err = rewriteTarCTF(fs, ctfPath, c, added, func(tw *tar.Writer) error {
+		for i, src := range archives {
+			if last[filenames[i]] == i {
+				if err := src.writeEntry(fs, tw, filenames[i], o.ArchiveFormat); err != nil {
+					return fmt.Errorf("unable to add component archive %q to ctf: %w", src.cd.GetName(), err)
+				}
+			}
+			logProgress(i)
 		}
-		log.Info(fmt.Sprintf("Successfully added component archive from %q", caPath))
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	if err := ctfArchive.Write(); err != nil {
-		return fmt.Errorf("unable to write modified ctf archive: %s", err.Error())
+	if err := writeIndex(); err != nil {
+		return err
 	}
-	return ctfArchive.Close()
+	logSummary()
+	return nil
 }
 
 func (o *AddOptions) Complete(args []string) error {
 	o.CTFPath = args[0]
+	o.ComponentArchives = append(o.ComponentArchives, args[1:]...)
 
 	if err := o.Validate(); err != nil {
 		return err
@@ -130,7 +408,7 @@ func (o *AddOptions) Validate() error {
 		return errors.New("a path to the component descriptor must be provided")
 	}
 
-	if len(o.ComponentArchives) == 0 {
+	if len(o.ComponentArchives) == 0 && len(o.ArchivesFile) == 0 {
 		return errors.New("no archives to add")
 	}
 
@@ -138,12 +416,240 @@ func (o *AddOptions) Validate() error {
 		o.ArchiveFormat != ctf.ArchiveFormatTarGzip {
 		return fmt.Errorf("unsupported archive format %q", o.ArchiveFormat)
 	}
+	policies := 0
+	for _, set := range []bool{o.Overwrite, o.SkipExisting, o.FailOnExisting} {
+		if set {
+			policies++
+		}
+	}
+	if policies > 1 {
+		return errors.New("only one of --overwrite, --skip-existing and --fail-on-existing can be set")
+	}
+	if o.Parallel < 1 {
+		return errors.New("parallel must be at least 1")
+	}
+	compress, err := parseCompression(o.Compress, o.Compression)
+	if err != nil {
+		return err
+	}
+	if o.Directory && compress != noCompression {
+		return errors.New("a ctf in the directory layout cannot be compressed")
+	}
+	if o.Index && (o.Directory || compress != noCompression) {
+		return errors.New("only plain ctfs can be indexed")
+	}
 	return nil
 }
 
 func (o *AddOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringArrayVarP(&o.ComponentArchives, "component-archive", "f", []string{},
-		"path to the component archives to be added. Note that the component archives have to be tar archives.")
+		"path to the component archives to be added. Component archives can be expanded directories or tar/tgz archives. Glob patterns and directories that contain component archives are expanded.")
 	componentarchive.OutputFormatVar(fs, &o.ArchiveFormat, "format", ctf.ArchiveFormatTar,
 		componentarchive.ArchiveOutputFormatUsage)
+	fs.StringVar(&o.ArchivesFile, "archives-file", "",
+		"path to a file that contains a newline-delimited or yaml list of component archives to be added. Lines starting with '#' are ignored.")
+	fs.BoolVar(&o.Rewrite, "rewrite", false,
+		"always rewrite the complete ctf. By default new component archives are appended to the ctf if none of them is already part of it.")
+	fs.BoolVar(&o.Overwrite, "overwrite", false,
+		"overwrite component archives with the same component name and version instead of failing")
+	fs.BoolVar(&o.SkipExisting, "skip-existing", false,
+		"skip component archives whose component name and version is already part of the ctf instead of failing")
+	fs.BoolVar(&o.FailOnExisting, "fail-on-existing", false,
+		"fail if a component name and version is already part of the ctf. This is the default behavior")
+	fs.IntVar(&o.Parallel, "parallel", 4, "number of component archives that are read and parsed concurrently")
+	fs.BoolVar(&o.SkipReferenceCheck, "skip-reference-check", false,
+		"do not check that all component references resolve to a component of the ctf")
+	fs.BoolVar(&o.SkipBlobCheck, "skip-blob-check", false,
+		"do not check that the local blobs of all resources are part of their component archive")
+	fs.BoolVar(&o.Compress, "compress", false,
+		"write the ctf as gzipped tar. Gzipped ctfs and new ctfs with a .tar.gz or .tgz extension are always compressed")
+	fs.StringVar(&o.Compression, "compression", "",
+		"compression of the ctf, either gzip or zstd. Defaults to the compression of an existing ctf or the .tar.gz, .tgz, .tar.zst or .tzst extension of a new ctf")
+	fs.BoolVar(&o.Directory, "directory", false,
+		"create a new ctf in the directory layout with one subdirectory per component archive. Existing directories are always used in the directory layout")
+	fs.BoolVar(&o.Index, "index", false,
+		"write an index of the component archives to the plain ctf. The index of an already indexed ctf is always updated")
+}
+
+// openComponentArchiveSources opens the component archives with a pool of parallel workers.
+// The archives are returned in the order of the given paths. The errors of all failed archives are aggregated.
+func openComponentArchiveSources(fs vfs.FileSystem, paths []string, parallel int) ([]*componentArchiveSource, error) {
+	var (
+		archives = make([]*componentArchiveSource, len(paths))
+		errs     = make([]error, len(paths))
+		jobs     = make(chan int)
+		wg       sync.WaitGroup
+	)
+	workers := parallel
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				archives[i], errs[i] = openComponentArchiveSource(fs, paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := []error{}
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) != 0 {
+		return nil, utilerrors.NewAggregate(failed)
+	}
+	return archives, nil
+}
+
+// ctfEntryNames returns the names of all component archive entries of the plain or compressed ctf at the given path.
+func ctfEntryNames(fs vfs.FileSystem, ctfPath string) (sets.String, error) {
+	file, err := fs.Open(ctfPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open ctf at %q: %w", ctfPath, err)
+	}
+	defer file.Close()
+	r, err := newTarReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ctf at %q: %w", ctfPath, err)
+	}
+	defer r.Close()
+
+	names := sets.NewString()
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return names, nil
+			}
+			return nil, fmt.Errorf("unable to read ctf at %q: %w", ctfPath, err)
+		}
+		names.Insert(header.Name)
+	}
+}
+
+// expandComponentArchives resolves the glob patterns and the directories that are not component archives themselves
+// to the paths of the contained component archives. The order of the paths is kept, duplicates are removed.
+// Paths that do not exist are kept so that they are reported when the component archive is parsed.
+func expandComponentArchives(fs vfs.FileSystem, paths []string) ([]string, error) {
+	var (
+		expanded []string
+		seen     = sets.NewString()
+	)
+	add := func(paths ...string) {
+		for _, path := range paths {
+			if key := filepath.Clean(path); !seen.Has(key) {
+				seen.Insert(key)
+				expanded = append(expanded, path)
+			}
+		}
+	}
+	for _, path := range paths {
+		matches := []string{path}
+		if utils.HasGlobMeta(path) {
+			var err error
+			matches, err = utils.Glob(fs, path)
+			if err != nil {
+				return nil, fmt.Errorf("invalid component archive pattern %q: %w", path, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("the pattern %q does not match any component archive", path)
+			}
+		}
+		for _, match := range matches {
+			info, err := fs.Stat(match)
+			if err != nil || !info.IsDir() {
+				add(match)
+				continue
+			}
+			if isComponentArchiveDirectory(fs, match) {
+				add(match)
+				continue
+			}
+			found, err := findComponentArchives(fs, match)
+			if err != nil {
+				return nil, err
+			}
+			if len(found) == 0 {
+				return nil, fmt.Errorf("the directory %q does not contain any component archive", match)
+			}
+			add(found...)
+		}
+	}
+	return expanded, nil
+}
+
+// findComponentArchives recursively searches the directory for component archives in the filesystem format
+// and tar archives. The directories of found component archives are not searched any further.
+func findComponentArchives(fs vfs.FileSystem, dir string) ([]string, error) {
+	infos, err := vfs.ReadDir(fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to search %q for component archives: %w", dir, err)
+	}
+	var found []string
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, info.Name())
+		if !info.IsDir() {
+			if strings.HasSuffix(path, ".tar") || strings.HasSuffix(path, ".tgz") || strings.HasSuffix(path, ".tar.gz") {
+				found = append(found, path)
+			}
+			continue
+		}
+		if isComponentArchiveDirectory(fs, path) {
+			found = append(found, path)
+			continue
+		}
+		nested, err := findComponentArchives(fs, path)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, nested...)
+	}
+	return found, nil
+}
+
+// isComponentArchiveDirectory returns whether the directory is a component archive in the filesystem format.
+func isComponentArchiveDirectory(fs vfs.FileSystem, path string) bool {
+	info, err := fs.Stat(filepath.Join(path, ctf.ComponentDescriptorFileName))
+	return err == nil && !info.IsDir()
+}
+
+// readArchivesFile reads a list of component archive paths from a file.
+// The file is either a yaml list or contains one path per line. Lines starting with "#" are comments.
+func readArchivesFile(fs vfs.FileSystem, path string) ([]string, error) {
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read archives file %q: %w", path, err)
+	}
+
+	var archives []string
+	if err := yaml.Unmarshal(data, &archives); err == nil {
+		return archives, nil
+	}
+
+	archives = []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		archives = append(archives, line)
+	}
+	return archives, nil
 }
@@ -5,11 +5,22 @@
 package ctf_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
 
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/go-logr/logr"
-	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/klauspost/compress/zstd"
 	"github.com/mandelsoft/vfs/pkg/memoryfs"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/projectionfs"
@@ -18,6 +29,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+	"github.com/gardener/component-cli/pkg/componentarchive"
 )
 
 var _ = Describe("Add", func() {
@@ -25,9 +37,7 @@ var _ = Describe("Add", func() {
 	var testdataFs vfs.FileSystem
 
 	BeforeEach(func() {
-		baseFs, err := projectionfs.New(osfs.New(), "./testdata")
-		Expect(err).ToNot(HaveOccurred())
-		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+		testdataFs = testdataFileSystem()
 	})
 
 	It("should add a component descriptor from file to the ctf archive", func() {
@@ -50,4 +60,1133 @@ var _ = Describe("Add", func() {
 		Expect(err).ToNot(HaveOccurred())
 	})
 
+	It("should add component archives defined in an archives file", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		Expect(vfs.WriteFile(testdataFs, "/archives.txt", []byte("# archives\n./00-ca\n\n"), os.ModePerm)).To(Succeed())
+		opts := cmd.AddOptions{
+			CTFPath:       "/component.ctf",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			ArchivesFile:  "/archives.txt",
+		}
+
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		ctfArchive, err := ctf.NewCTF(testdataFs, opts.CTFPath)
+		Expect(err).ToNot(HaveOccurred())
+		names := []string{}
+		err = ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			names = append(names, ca.ComponentDescriptor.Name)
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names).To(ConsistOf("example.com/component"))
+	})
+
+	It("should combine a yaml archives file with explicitly defined component archives", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		Expect(writeComponentArchive(testdataFs, "/01-ca", "example.com/other-component", "v0.0.1")).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "/archives.yaml", []byte("- /01-ca\n"), os.ModePerm)).To(Succeed())
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca"},
+			ArchivesFile:      "/archives.yaml",
+		}
+
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		Expect(opts.ComponentArchives).To(Equal([]string{"./00-ca"}))
+
+		ctfArchive, err := ctf.NewCTF(testdataFs, opts.CTFPath)
+		Expect(err).ToNot(HaveOccurred())
+		names := []string{}
+		err = ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			names = append(names, ca.ComponentDescriptor.Name)
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names).To(ConsistOf("example.com/component", "example.com/other-component"))
+	})
+
+	It("should add more than four component archives in one invocation", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		archives := []string{}
+		expectedNames := []string{}
+		for i := 0; i < 5; i++ {
+			name := fmt.Sprintf("example.com/component-%d", i)
+			path := fmt.Sprintf("/ca-%d", i)
+			Expect(writeComponentArchive(testdataFs, path, name, "v0.0.1")).To(Succeed())
+			archives = append(archives, path)
+			expectedNames = append(expectedNames, name)
+		}
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: archives,
+		}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		ctfArchive, err := ctf.NewCTF(testdataFs, opts.CTFPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		names := []string{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			names = append(names, ca.ComponentDescriptor.Name)
+			return nil
+		})).To(Succeed())
+		Expect(names).To(ConsistOf(expectedNames))
+	})
+
+	It("should add many component archives in parallel in a stable order", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		archives := []string{}
+		for i := 0; i < 20; i++ {
+			path := fmt.Sprintf("/ca-%02d", i)
+			Expect(writeComponentArchive(testdataFs, path, fmt.Sprintf("example.com/component-%02d", i), "v0.0.1")).To(Succeed())
+			archives = append(archives, path)
+		}
+
+		serialOpts := cmd.AddOptions{
+			CTFPath:           "/serial.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: archives,
+			Parallel:          1,
+		}
+		Expect(serialOpts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		parallelOpts := cmd.AddOptions{
+			CTFPath:           "/parallel.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: archives,
+			Parallel:          4,
+		}
+		Expect(parallelOpts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		entries := tarEntries(testdataFs, parallelOpts.CTFPath)
+		Expect(entries).To(HaveLen(20))
+		Expect(entries).To(Equal(tarEntries(testdataFs, serialOpts.CTFPath)))
+		for i, entry := range entries {
+			Expect(entry).To(Equal(fmt.Sprintf("example.com_component-%02d-v0.0.1.tar", i)))
+		}
+	})
+
+	It("should aggregate the errors of all archives that cannot be parsed", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca", "/missing-1", "/missing-2"},
+			Parallel:          4,
+		}
+		err := opts.Run(ctx, logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("/missing-1"))
+		Expect(err.Error()).To(ContainSubstring("/missing-2"))
+	})
+
+	It("should accept any number of component archives after the ctf path as arguments", func() {
+		addCmd := cmd.NewAddCommand(context.Background())
+		Expect(addCmd.Args(addCmd, []string{})).ToNot(Succeed())
+		Expect(addCmd.Args(addCmd, []string{"/component.ctf"})).To(Succeed())
+		Expect(addCmd.Args(addCmd, []string{"/component.ctf", "/ca-0", "/ca-1", "/ca-2", "/ca-3", "/ca-4"})).To(Succeed())
+	})
+
+	It("should add expanded component archive directories and tar archives to the same ctf", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		Expect(writeComponentArchive(testdataFs, "/dir-ca", "example.com/dir-component", "v0.0.1")).To(Succeed())
+		Expect(testdataFs.MkdirAll("/dir-ca/blobs", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "/dir-ca/blobs/data.txt", []byte("blob data"), os.ModePerm)).To(Succeed())
+
+		Expect(writeComponentArchive(testdataFs, "/tar-ca-src", "example.com/tar-component", "v0.0.1")).To(Succeed())
+		tarCa, _, err := componentarchive.Parse(testdataFs, "/tar-ca-src")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(componentarchive.Write(testdataFs, "/tar-ca.tar", tarCa, ctf.ArchiveFormatTar)).To(Succeed())
+
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/dir-ca", "/tar-ca.tar"},
+		}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		ctfArchive, err := ctf.NewCTF(testdataFs, opts.CTFPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		names := []string{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			names = append(names, ca.ComponentDescriptor.Name)
+			if ca.ComponentDescriptor.Name != "example.com/dir-component" {
+				return nil
+			}
+			acc, err := cdv2.NewUnstructured(cdv2.NewLocalFilesystemBlobAccess("data.txt", "text/plain"))
+			Expect(err).ToNot(HaveOccurred())
+			var buf bytes.Buffer
+			_, err = ca.Resolve(ctx, cdv2.Resource{Access: &acc}, &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("blob data"))
+			return nil
+		})).To(Succeed())
+		Expect(names).To(ConsistOf("example.com/dir-component", "example.com/tar-component"))
+	})
+
+})
+
+var _ = Describe("Add incrementally", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testdataFileSystem()
+		Expect(writeComponentArchive(testdataFs, "/01-ca", "example.com/other-component", "v0.0.1")).To(Succeed())
+	})
+
+	It("should append a new component archive to an existing ctf", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca"},
+		}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		opts.ComponentArchives = []string{"/01-ca"}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(tarEntries(testdataFs, opts.CTFPath)).To(ConsistOf(
+			"example.com_component-v0.0.0.tar",
+			"example.com_other-component-v0.0.1.tar",
+		))
+		ctfArchive, err := ctf.NewCTF(testdataFs, opts.CTFPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		names := []string{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			names = append(names, ca.ComponentDescriptor.Name)
+			return nil
+		})).To(Succeed())
+		Expect(names).To(ConsistOf("example.com/component", "example.com/other-component"))
+	})
+
+	It("should restore the ctf if a component archive cannot be appended", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca"},
+		}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		Expect(writeComponentArchiveWithLocalBlob(testdataFs, "/02-ca", "example.com/blob-component", "v0.0.1", "blob", true)).To(Succeed())
+
+		failingFs := &failingAppendFS{FileSystem: testdataFs, ctfPath: opts.CTFPath, failPrefix: "/02-ca/blobs"}
+		opts.ComponentArchives = []string{"/01-ca", "/02-ca"}
+		err := opts.Run(ctx, logr.Discard(), failingFs)
+		Expect(err).To(HaveOccurred())
+		Expect(failingFs.appending).To(BeTrue())
+
+		Expect(tarEntries(testdataFs, opts.CTFPath)).To(Equal([]string{"example.com_component-v0.0.0.tar"}))
+		ctfArchive, err := ctf.NewCTF(testdataFs, opts.CTFPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		names := []string{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			names = append(names, ca.ComponentDescriptor.Name)
+			return nil
+		})).To(Succeed())
+		Expect(names).To(Equal([]string{"example.com/component"}))
+	})
+
+	It("should rewrite the ctf if a component archive is overwritten", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca", "/01-ca"},
+		}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		opts.ComponentArchives = []string{"/01-ca"}
+		opts.Overwrite = true
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(tarEntries(testdataFs, opts.CTFPath)).To(ConsistOf(
+			"example.com_component-v0.0.0.tar",
+			"example.com_other-component-v0.0.1.tar",
+		))
+	})
+
+	It("should fail if a component archive is already part of the ctf", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca", "/01-ca"},
+		}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		opts.ComponentArchives = []string{"/01-ca"}
+		err := opts.Run(ctx, logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`component "example.com/other-component" in version "v0.0.1" is already part of the ctf`))
+	})
+
+	It("should fail if a component is defined multiple times", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		Expect(writeComponentArchive(testdataFs, "/02-ca", "example.com/other-component", "v0.0.1")).To(Succeed())
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/01-ca", "/02-ca"},
+		}
+		err := opts.Run(ctx, logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("example.com/other-component"))
+		Expect(tarEntries(testdataFs, opts.CTFPath)).To(BeEmpty())
+	})
+
+	It("should overwrite a component that is defined multiple times with the last archive", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		Expect(writeComponentArchive(testdataFs, "/02-ca", "example.com/other-component", "v0.0.1")).To(Succeed())
+		Expect(testdataFs.MkdirAll("/02-ca/blobs", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "/02-ca/blobs/data.txt", []byte("new"), os.ModePerm)).To(Succeed())
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/01-ca", "/02-ca"},
+			Overwrite:         true,
+		}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(tarEntries(testdataFs, opts.CTFPath)).To(ConsistOf("example.com_other-component-v0.0.1.tar"))
+		ctfArchive, err := ctf.NewCTF(testdataFs, opts.CTFPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			acc, err := cdv2.NewUnstructured(cdv2.NewLocalFilesystemBlobAccess("data.txt", "text/plain"))
+			Expect(err).ToNot(HaveOccurred())
+			var buf bytes.Buffer
+			_, err = ca.Resolve(ctx, cdv2.Resource{Access: &acc}, &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("new"))
+			return nil
+		})).To(Succeed())
+	})
+
+	It("should skip components that are already part of the ctf with --skip-existing", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		Expect(testdataFs.MkdirAll("/01-ca/blobs", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "/01-ca/blobs/data.txt", []byte("old"), os.ModePerm)).To(Succeed())
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/01-ca"},
+		}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(writeComponentArchive(testdataFs, "/02-ca", "example.com/other-component", "v0.0.1")).To(Succeed())
+		Expect(testdataFs.MkdirAll("/02-ca/blobs", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "/02-ca/blobs/data.txt", []byte("new"), os.ModePerm)).To(Succeed())
+		opts.ComponentArchives = []string{"/02-ca", "./00-ca"}
+		opts.SkipExisting = true
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		Expect(tarEntries(testdataFs, opts.CTFPath)).To(ConsistOf(
+			"example.com_other-component-v0.0.1.tar",
+			"example.com_component-v0.0.0.tar",
+		))
+		ctfArchive, err := ctf.NewCTF(testdataFs, opts.CTFPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			if ca.ComponentDescriptor.GetName() != "example.com/other-component" {
+				return nil
+			}
+			acc, err := cdv2.NewUnstructured(cdv2.NewLocalFilesystemBlobAccess("data.txt", "text/plain"))
+			Expect(err).ToNot(HaveOccurred())
+			var buf bytes.Buffer
+			_, err = ca.Resolve(ctx, cdv2.Resource{Access: &acc}, &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("old"))
+			return nil
+		})).To(Succeed())
+	})
+
+	It("should keep the first of multiple component archives of a component with --skip-existing", func() {
+		Expect(writeComponentArchive(testdataFs, "/02-ca", "example.com/other-component", "v0.0.1")).To(Succeed())
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/01-ca", "/02-ca"},
+			SkipExisting:      true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		Expect(tarEntries(testdataFs, opts.CTFPath)).To(ConsistOf("example.com_other-component-v0.0.1.tar"))
+
+		// the ctf is not modified if all component archives are skipped
+		before, err := vfs.ReadFile(testdataFs, opts.CTFPath)
+		Expect(err).ToNot(HaveOccurred())
+		opts.ComponentArchives = []string{"/02-ca"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		after, err := vfs.ReadFile(testdataFs, opts.CTFPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(after).To(Equal(before))
+	})
+
+	It("should fail with --fail-on-existing and reject multiple conflict policies", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/01-ca"},
+			FailOnExisting:    true,
+			Parallel:          1,
+		}
+		Expect(opts.Validate()).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(MatchError(ContainSubstring("is already part of the ctf")))
+
+		opts.SkipExisting = true
+		Expect(opts.Validate()).To(MatchError("only one of --overwrite, --skip-existing and --fail-on-existing can be set"))
+		opts.FailOnExisting = false
+		opts.Overwrite = true
+		Expect(opts.Validate()).To(HaveOccurred())
+	})
+
+})
+
+var _ = Describe("Add reference check", func() {
+
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchiveWithReferences(fs, "/a", "example.com/a", "v1.0.0", "example.com/b@v1.0.0", "example.com/c@v1.0.0")).To(Succeed())
+		Expect(writeComponentArchiveWithReferences(fs, "/b", "example.com/b", "v1.0.0", "example.com/c@v1.0.0")).To(Succeed())
+		Expect(writeComponentArchive(fs, "/c", "example.com/c", "v1.0.0")).To(Succeed())
+	})
+
+	It("should add components whose references resolve within the ctf", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/c", "/b"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		// the references of a are satisfied by the components that are already part of the ctf
+		opts.ComponentArchives = []string{"/a"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(tarEntries(fs, opts.CTFPath)).To(HaveLen(3))
+	})
+
+	It("should fail with all dangling references and the components that requested them", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/a", "/b"},
+		}
+		err := opts.Run(context.TODO(), logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("component example.com/a@v1.0.0 references example.com/c@v1.0.0"))
+		Expect(err.Error()).To(ContainSubstring("component example.com/b@v1.0.0 references example.com/c@v1.0.0"))
+		Expect(err.Error()).ToNot(ContainSubstring("references example.com/b@v1.0.0"))
+		Expect(tarEntries(fs, opts.CTFPath)).To(BeEmpty())
+	})
+
+	It("should detect a dangling reference of a component that is already part of the ctf", func() {
+		opts := cmd.AddOptions{
+			CTFPath:            "/component.ctf",
+			ArchiveFormat:      ctf.ArchiveFormatTar,
+			ComponentArchives:  []string{"/b"},
+			SkipReferenceCheck: true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		Expect(writeComponentArchive(fs, "/d", "example.com/d", "v1.0.0")).To(Succeed())
+		opts.ComponentArchives = []string{"/d"}
+		opts.SkipReferenceCheck = false
+		err := opts.Run(context.TODO(), logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("component example.com/b@v1.0.0 references example.com/c@v1.0.0"))
+	})
+
+	It("should add components with dangling references if the reference check is skipped", func() {
+		opts := cmd.AddOptions{
+			CTFPath:            "/component.ctf",
+			ArchiveFormat:      ctf.ArchiveFormatTarGzip,
+			ComponentArchives:  []string{"/a"},
+			SkipReferenceCheck: true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(tarEntries(fs, opts.CTFPath)).To(ConsistOf("example.com_a-v1.0.0.tar"))
+
+		// the gzipped component archive of a is read to validate its references
+		opts.ComponentArchives = []string{"/b", "/c"}
+		opts.SkipReferenceCheck = false
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	})
+
+})
+
+var _ = Describe("Add blob check", func() {
+
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+	})
+
+	It("should add component archives that contain all local blobs", func() {
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a", "example.com/a", "v1.0.0", "sha256:abc", true)).To(Succeed())
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/a"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(tarEntries(fs, opts.CTFPath)).To(ConsistOf("example.com_a-v1.0.0.tar"))
+	})
+
+	It("should fail with the component and the digest of a missing local blob", func() {
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a", "example.com/a", "v1.0.0", "sha256:abc", false)).To(Succeed())
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/a"},
+		}
+		err := opts.Run(context.TODO(), logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`component example.com/a@v1.0.0: local blob "sha256:abc" of resource "blob"`))
+		Expect(tarEntries(fs, opts.CTFPath)).To(BeEmpty())
+	})
+
+	It("should add component archives with missing local blobs if the blob check is skipped", func() {
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a", "example.com/a", "v1.0.0", "sha256:abc", false)).To(Succeed())
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/a"},
+			SkipBlobCheck:     true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(tarEntries(fs, opts.CTFPath)).To(ConsistOf("example.com_a-v1.0.0.tar"))
+	})
+
+})
+
+var _ = Describe("Add progress", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = memoryfs.New()
+		for i := 0; i < 4; i++ {
+			Expect(writeComponentArchive(testdataFs, fmt.Sprintf("/ca-%d", i), fmt.Sprintf("example.com/component-%d", i), "v0.0.1")).To(Succeed())
+		}
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/ca-0"},
+			Parallel:          1,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+	})
+
+	// expectLogs runs ctf add for the component archives with the given indices
+	// and checks that every archive and the summary is logged.
+	expectLogs := func(indices []int, replaced int) {
+		sink := &recordingLogSink{}
+		opts := cmd.AddOptions{
+			CTFPath:       "/component.ctf",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			Parallel:      1,
+			Overwrite:     true,
+		}
+		for _, i := range indices {
+			opts.ComponentArchives = append(opts.ComponentArchives, fmt.Sprintf("/ca-%d", i))
+		}
+		Expect(opts.Run(context.TODO(), logr.New(sink), testdataFs)).To(Succeed())
+
+		progress := sink.entriesWithMessage("Added component archive")
+		Expect(progress).To(HaveLen(len(indices)))
+		for n, i := range indices {
+			Expect(progress[n].keysAndValues).To(Equal([]interface{}{
+				"component", fmt.Sprintf("example.com/component-%d", i),
+				"version", "v0.0.1",
+				"path", fmt.Sprintf("/ca-%d", i),
+				"progress", fmt.Sprintf("%d/%d", n+1, len(indices)),
+			}))
+		}
+		summary := sink.entriesWithMessage("Successfully added component archives to the ctf")
+		Expect(summary).To(HaveLen(1))
+		Expect(summary[0].keysAndValues).To(Equal([]interface{}{"added", len(indices), "replaced", replaced}))
+	}
+
+	It("should log every appended component archive and a summary", func() {
+		expectLogs([]int{1, 2, 3}, 0)
+		Expect(tarEntries(testdataFs, "/component.ctf")).To(HaveLen(4))
+	})
+
+	It("should log every component archive and a summary if the ctf is rewritten", func() {
+		expectLogs([]int{0, 1, 2}, 1)
+		Expect(tarEntries(testdataFs, "/component.ctf")).To(HaveLen(3))
+	})
+
+})
+
+// recordingLogEntry is a log line that was recorded by a recordingLogSink.
+type recordingLogEntry struct {
+	msg           string
+	keysAndValues []interface{}
+}
+
+// recordingLogSink records all info log lines.
+type recordingLogSink struct {
+	entries []recordingLogEntry
+}
+
+func (s *recordingLogSink) Init(logr.RuntimeInfo) {}
+
+func (s *recordingLogSink) Enabled(int) bool { return true }
+
+func (s *recordingLogSink) Info(_ int, msg string, keysAndValues ...interface{}) {
+	s.entries = append(s.entries, recordingLogEntry{msg: msg, keysAndValues: keysAndValues})
+}
+
+func (s *recordingLogSink) Error(error, string, ...interface{}) {}
+
+func (s *recordingLogSink) WithValues(...interface{}) logr.LogSink { return s }
+
+func (s *recordingLogSink) WithName(string) logr.LogSink { return s }
+
+func (s *recordingLogSink) entriesWithMessage(msg string) []recordingLogEntry {
+	entries := []recordingLogEntry{}
+	for _, entry := range s.entries {
+		if entry.msg == msg {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+var _ = Describe("Add component archive patterns", func() {
+
+	var fs vfs.FileSystem
+
+	listComponents := func(ctfPath string) []string {
+		opts := cmd.ListOptions{CTFPath: ctfPath}
+		entries, err := opts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		components := []string{}
+		for _, entry := range entries {
+			components = append(components, entry.Name+"@"+entry.Version)
+		}
+		return components
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchive(fs, "/cas/a", "example.com/a", "v0.0.1")).To(Succeed())
+		Expect(writeComponentArchive(fs, "/cas/nested/b", "example.com/b", "v0.0.1")).To(Succeed())
+		Expect(writeComponentArchive(fs, "/cas/nested/.hidden", "example.com/hidden", "v0.0.1")).To(Succeed())
+		Expect(writeComponentArchive(fs, "/other/c", "example.com/c", "v0.0.1")).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/cas/README.md", []byte("docs"), os.ModePerm)).To(Succeed())
+
+		Expect(writeComponentArchive(fs, "/src/d", "example.com/d", "v0.0.1")).To(Succeed())
+		ca, _, err := componentarchive.Parse(fs, "/src/d")
+		Expect(err).ToNot(HaveOccurred())
+		file, err := fs.Create("/cas/nested/d.tar")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ca.WriteTar(file)).To(Succeed())
+		Expect(file.Close()).To(Succeed())
+	})
+
+	It("should accept component archives as positional arguments", func() {
+		opts := cmd.AddOptions{
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/cas/a"},
+			Parallel:          1,
+		}
+		Expect(opts.Complete([]string{"/component.ctf", "/cas/nested/b", "/other/c"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(listComponents("/component.ctf")).To(Equal([]string{"example.com/a@v0.0.1", "example.com/b@v0.0.1", "example.com/c@v0.0.1"}))
+	})
+
+	It("should scan directories recursively for component archives", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/cas"},
+			Parallel:          1,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(listComponents("/component.ctf")).To(Equal([]string{"example.com/a@v0.0.1", "example.com/b@v0.0.1", "example.com/d@v0.0.1"}))
+	})
+
+	It("should expand glob patterns and add duplicates only once", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/cas/nested/*.tar", "/other/[c]", "/other/c/"},
+			Parallel:          1,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(listComponents("/component.ctf")).To(Equal([]string{"example.com/c@v0.0.1", "example.com/d@v0.0.1"}))
+	})
+
+	It("should fail if a pattern or directory does not contain component archives", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/cas/*.tgz"},
+			Parallel:          1,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(MatchError(`the pattern "/cas/*.tgz" does not match any component archive`))
+
+		Expect(fs.MkdirAll("/empty", os.ModePerm)).To(Succeed())
+		opts.ComponentArchives = []string{"/empty"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(MatchError(`the directory "/empty" does not contain any component archive`))
+	})
+
 })
+
+var _ = Describe("Add compressed", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testdataFileSystem()
+		Expect(writeComponentArchive(testdataFs, "/01-ca", "example.com/other-component", "v0.0.1")).To(Succeed())
+	})
+
+	It("should write a gzipped ctf that can be read and extended again", func() {
+		ctx := context.Background()
+		defer ctx.Done()
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca"},
+			Compress:          true,
+		}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		Expect(gzipTarEntries(testdataFs, opts.CTFPath)).To(ConsistOf("example.com_component-v0.0.0.tar"))
+
+		// the compression of an existing ctf is detected without --compress
+		opts.Compress = false
+		opts.ComponentArchives = []string{"/01-ca"}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		Expect(gzipTarEntries(testdataFs, opts.CTFPath)).To(ConsistOf(
+			"example.com_component-v0.0.0.tar",
+			"example.com_other-component-v0.0.1.tar",
+		))
+
+		listOpts := cmd.ListOptions{CTFPath: opts.CTFPath, Output: cmd.TextOutput}
+		entries, err := listOpts.List(testdataFs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Name).To(Equal("example.com/component"))
+		Expect(entries[1].Name).To(Equal("example.com/other-component"))
+	})
+
+	It("should compress a new ctf with a .tar.gz extension", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.tar.gz",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		Expect(gzipTarEntries(testdataFs, opts.CTFPath)).To(ConsistOf("example.com_component-v0.0.0.tar"))
+	})
+
+	It("should create a valid empty gzipped ctf", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./does-not-exist"},
+			Compress:          true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+		Expect(gzipTarEntries(testdataFs, opts.CTFPath)).To(BeEmpty())
+	})
+
+	It("should write a zstd compressed ctf that can be read and extended again", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.tar.zst",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		Expect(zstdTarEntries(testdataFs, opts.CTFPath)).To(ConsistOf("example.com_component-v0.0.0.tar"))
+
+		opts.ComponentArchives = []string{"/01-ca"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		Expect(zstdTarEntries(testdataFs, opts.CTFPath)).To(ConsistOf(
+			"example.com_component-v0.0.0.tar",
+			"example.com_other-component-v0.0.1.tar",
+		))
+
+		getOpts := cmd.GetOptions{CTFPath: opts.CTFPath, Name: "example.com/other-component"}
+		ca, err := getOpts.Get(testdataFs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ca.ComponentDescriptor.GetVersion()).To(Equal("v0.0.1"))
+	})
+
+	It("should detect the compression of an existing ctf by its magic bytes", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca"},
+			Compression:       "zstd",
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		opts.Compression = ""
+		opts.ComponentArchives = []string{"/01-ca"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		Expect(zstdTarEntries(testdataFs, opts.CTFPath)).To(HaveLen(2))
+
+		listOpts := cmd.ListOptions{CTFPath: opts.CTFPath}
+		entries, err := listOpts.List(testdataFs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+	})
+
+	It("should convert a gzipped ctf to zstd with --compression", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca"},
+			Compress:          true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		opts.Compression = "zstd"
+		opts.ComponentArchives = []string{"/01-ca"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		Expect(zstdTarEntries(testdataFs, opts.CTFPath)).To(HaveLen(2))
+	})
+
+	It("should reject an unsupported compression", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca"},
+			Parallel:          1,
+			Compression:       "xz",
+		}
+		Expect(opts.Validate()).To(MatchError(ContainSubstring(`unsupported compression "xz"`)))
+	})
+
+})
+
+var _ = Describe("Add streamed component archives", func() {
+
+	var fs vfs.FileSystem
+
+	// writeArchive writes the component archive in the filesystem format at the given path as tar or gzipped tar.
+	writeArchive := func(caPath, path string, format ctf.ArchiveFormat) {
+		ca, _, err := componentarchive.Parse(fs, caPath)
+		Expect(err).ToNot(HaveOccurred())
+		file, err := fs.Create(path)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+		if format == ctf.ArchiveFormatTarGzip {
+			Expect(ca.WriteTarGzip(file)).To(Succeed())
+			return
+		}
+		Expect(ca.WriteTar(file)).To(Succeed())
+	}
+
+	// tarEntry returns the content of the entry with the given name of the tar at the given path.
+	tarEntry := func(path, name string) []byte {
+		file, err := fs.Open(path)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+		tr := tar.NewReader(file)
+		for {
+			header, err := tr.Next()
+			Expect(err).ToNot(HaveOccurred())
+			if header.Name == name {
+				data, err := io.ReadAll(tr)
+				Expect(err).ToNot(HaveOccurred())
+				return data
+			}
+		}
+	}
+
+	add := func(opts cmd.AddOptions) error {
+		opts.ArchiveFormat = ctf.ArchiveFormatTar
+		opts.Parallel = 2
+		return opts.Run(context.TODO(), logr.Discard(), fs)
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a", "example.com/a", "v1.0.0", "blob", true)).To(Succeed())
+		writeArchive("/a", "/a.tar", ctf.ArchiveFormatTar)
+	})
+
+	It("should copy tar component archives into the ctf without modification", func() {
+		Expect(add(cmd.AddOptions{CTFPath: "/component.ctf", ComponentArchives: []string{"/a.tar"}, Rewrite: true})).To(Succeed())
+
+		data, err := vfs.ReadFile(fs, "/a.tar")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tarEntry("/component.ctf", "example.com_a-v1.0.0.tar")).To(Equal(data))
+	})
+
+	It("should convert gzipped component archives to the tar format", func() {
+		writeArchive("/a", "/a.tgz", ctf.ArchiveFormatTarGzip)
+		Expect(add(cmd.AddOptions{CTFPath: "/component.ctf", ComponentArchives: []string{"/a.tgz"}})).To(Succeed())
+
+		entry := tarEntry("/component.ctf", "example.com_a-v1.0.0.tar")
+		Expect(entry[:2]).ToNot(Equal([]byte{0x1f, 0x8b}))
+		ca, err := ctf.NewComponentArchiveFromTarReader(bytes.NewReader(entry))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = ca.Info(context.TODO(), ca.ComponentDescriptor.Resources[0])
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should detect missing local blobs of tar component archives", func() {
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/b", "example.com/b", "v1.0.0", "blob", false)).To(Succeed())
+		writeArchive("/b", "/b.tar", ctf.ArchiveFormatTar)
+		err := add(cmd.AddOptions{CTFPath: "/component.ctf", ComponentArchives: []string{"/a.tar", "/b.tar"}})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`component example.com/b@v1.0.0: local blob "blob" of resource "blob"`))
+		Expect(err.Error()).ToNot(ContainSubstring("example.com/a"))
+	})
+
+	It("should replace a component archive of a compressed ctf without temporary leftovers", func() {
+		Expect(add(cmd.AddOptions{CTFPath: "/component.tar.zst", ComponentArchives: []string{"/a.tar"}})).To(Succeed())
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a", "example.com/a", "v1.0.0", "other-blob", true)).To(Succeed())
+		Expect(add(cmd.AddOptions{CTFPath: "/component.tar.zst", ComponentArchives: []string{"/a"}, Overwrite: true})).To(Succeed())
+		Expect(zstdTarEntries(fs, "/component.tar.zst")).To(ConsistOf("example.com_a-v1.0.0.tar"))
+
+		listOpts := cmd.ListOptions{CTFPath: "/component.tar.zst"}
+		entries, err := listOpts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+
+		infos, err := vfs.ReadDir(fs, "/")
+		Expect(err).ToNot(HaveOccurred())
+		for _, info := range infos {
+			Expect(info.Name()).ToNot(HavePrefix(".ctf-"))
+		}
+	})
+
+	It("should write the last of multiple component archives of the same component once", func() {
+		Expect(add(cmd.AddOptions{CTFPath: "/component.ctf", ComponentArchives: []string{"/a.tar", "/a"}, Overwrite: true})).To(Succeed())
+		Expect(tarEntries(fs, "/component.ctf")).To(ConsistOf("example.com_a-v1.0.0.tar"))
+	})
+
+	It("should extract tar component archives into a ctf in the directory layout", func() {
+		Expect(add(cmd.AddOptions{CTFPath: "/component", ComponentArchives: []string{"/a.tar"}, Directory: true})).To(Succeed())
+		data, err := vfs.ReadFile(fs, "/component/example.com_a-v1.0.0/blobs/blob")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("blob"))
+		_, err = fs.Stat("/component/example.com_a-v1.0.0/" + ctf.ComponentDescriptorFileName)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+})
+
+// zstdTarEntries returns the names of all entries of the zstd compressed tar at the given path.
+func zstdTarEntries(fs vfs.FileSystem, path string) []string {
+	data, err := vfs.ReadFile(fs, path)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(data[:4]).To(Equal([]byte{0x28, 0xb5, 0x2f, 0xfd}))
+	zr, err := zstd.NewReader(bytes.NewReader(data))
+	Expect(err).ToNot(HaveOccurred())
+	defer zr.Close()
+	plain, err := io.ReadAll(zr)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(vfs.WriteFile(fs, "/plain.tar", plain, os.ModePerm)).To(Succeed())
+	return tarEntries(fs, "/plain.tar")
+}
+
+// gzipTarEntries returns the names of all entries of the gzipped tar at the given path.
+func gzipTarEntries(fs vfs.FileSystem, path string) []string {
+	data, err := vfs.ReadFile(fs, path)
+	Expect(err).ToNot(HaveOccurred())
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	Expect(err).ToNot(HaveOccurred())
+	plain, err := io.ReadAll(zr)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(vfs.WriteFile(fs, "/plain.tar", plain, os.ModePerm)).To(Succeed())
+	return tarEntries(fs, "/plain.tar")
+}
+
+// writeComponentArchive writes a minimal component archive to the given path.
+func writeComponentArchive(fs vfs.FileSystem, path, name, version string) error {
+	return writeComponentArchiveWithReferences(fs, path, name, version)
+}
+
+// writeComponentArchiveWithReferences writes a minimal component archive with references
+// to the given components in the form "name@version" to the given path.
+func writeComponentArchiveWithReferences(fs vfs.FileSystem, path, name, version string, refs ...string) error {
+	if err := fs.MkdirAll(path, os.ModePerm); err != nil {
+		return err
+	}
+	refsYaml := " []"
+	if len(refs) != 0 {
+		refsYaml = ""
+		for i, ref := range refs {
+			parts := strings.SplitN(ref, "@", 2)
+			refsYaml += fmt.Sprintf("\n  - name: 'ref-%d'\n    componentName: '%s'\n    version: '%s'", i, parts[0], parts[1])
+		}
+	}
+	cd := fmt.Sprintf(`meta:
+  schemaVersion: 'v2'
+component:
+  name: '%s'
+  version: '%s'
+  repositoryContexts: []
+  provider: 'internal'
+  sources: []
+  componentReferences:%s
+  resources: []
+`, name, version, refsYaml)
+	return vfs.WriteFile(fs, filepath.Join(path, ctf.ComponentDescriptorFileName), []byte(cd), os.ModePerm)
+}
+
+// writeComponentArchiveWithLocalBlob writes a minimal component archive with one resource
+// that is accessed by a local filesystem blob with the given filename.
+// The blob itself is only written if writeBlob is set.
+func writeComponentArchiveWithLocalBlob(fs vfs.FileSystem, path, name, version, filename string, writeBlob bool) error {
+	if err := fs.MkdirAll(filepath.Join(path, ctf.BlobsDirectoryName), os.ModePerm); err != nil {
+		return err
+	}
+	if writeBlob {
+		if err := vfs.WriteFile(fs, filepath.Join(path, ctf.BlobPath(filename)), []byte("blob"), os.ModePerm); err != nil {
+			return err
+		}
+	}
+	cd := fmt.Sprintf(`meta:
+  schemaVersion: 'v2'
+component:
+  name: '%s'
+  version: '%s'
+  repositoryContexts: []
+  provider: 'internal'
+  sources: []
+  componentReferences: []
+  resources:
+  - name: 'blob'
+    version: '%s'
+    type: 'plain-text'
+    relation: 'local'
+    access:
+      type: 'localFilesystemBlob'
+      filename: '%s'
+`, name, version, version, filename)
+	return vfs.WriteFile(fs, filepath.Join(path, ctf.ComponentDescriptorFileName), []byte(cd), os.ModePerm)
+}
+
+// failingAppendFS fails to open the files with the given prefix once the ctf is opened for appending.
+type failingAppendFS struct {
+	vfs.FileSystem
+	ctfPath    string
+	failPrefix string
+	appending  bool
+}
+
+func (f *failingAppendFS) Open(name string) (vfs.File, error) {
+	return f.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (f *failingAppendFS) OpenFile(name string, flags int, perm os.FileMode) (vfs.File, error) {
+	if name == f.ctfPath && flags&os.O_RDWR != 0 {
+		f.appending = true
+	}
+	if f.appending && strings.HasPrefix(name, f.failPrefix) {
+		return nil, errors.New("read error")
+	}
+	return f.FileSystem.OpenFile(name, flags, perm)
+}
+
+// testdataFileSystem returns an in-memory filesystem with a copy of the testdata directory.
+// Other than a layered filesystem, it supports the renames that replace a ctf.
+func testdataFileSystem() vfs.FileSystem {
+	baseFs, err := projectionfs.New(osfs.New(), "./testdata")
+	Expect(err).ToNot(HaveOccurred())
+	fs := memoryfs.New()
+	Expect(vfs.CopyDir(baseFs, "/", fs, "/")).To(Succeed())
+	return fs
+}
+
+// tarEntries returns the names of all entries of the tar at the given path.
+func tarEntries(fs vfs.FileSystem, path string) []string {
+	file, err := fs.Open(path)
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+	names := []string{}
+	tr := tar.NewReader(file)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		Expect(err).ToNot(HaveOccurred())
+		names = append(names, header.Name)
+	}
+	return names
+}
+
+// BenchmarkAdd compares appending a component archive to a large ctf with a full rewrite of the ctf.
+// Run with: go test ./pkg/commands/ctf -run '^$' -bench BenchmarkAdd
+func BenchmarkAdd(b *testing.B) {
+	const components = 1000
+	for _, rewrite := range []bool{false, true} {
+		b.Run(fmt.Sprintf("rewrite=%t", rewrite), func(b *testing.B) {
+			dir, err := os.MkdirTemp("", "ctf-bench-")
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			fs, err := projectionfs.New(osfs.New(), dir)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			paths := make([]string, components)
+			for i := range paths {
+				paths[i] = fmt.Sprintf("/ca-%d", i)
+				if err := writeComponentArchive(fs, paths[i], fmt.Sprintf("example.com/component-%d", i), "v0.0.0"); err != nil {
+					b.Fatal(err)
+				}
+			}
+			opts := cmd.AddOptions{
+				CTFPath:           "/component.ctf",
+				ArchiveFormat:     ctf.ArchiveFormatTar,
+				ComponentArchives: paths,
+			}
+			if err := opts.Run(context.TODO(), logr.Discard(), fs); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				path := fmt.Sprintf("/new-ca-%d", i)
+				if err := writeComponentArchive(fs, path, fmt.Sprintf("example.com/new-component-%d", i), "v0.0.0"); err != nil {
+					b.Fatal(err)
+				}
+				opts := cmd.AddOptions{
+					CTFPath:           "/component.ctf",
+					ArchiveFormat:     ctf.ArchiveFormatTar,
+					ComponentArchives: []string{path},
+					Rewrite:           rewrite,
+				}
+				b.StartTimer()
+				if err := opts.Run(context.TODO(), logr.Discard(), fs); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// tarEndOfArchiveSize is the size of the two zero blocks that mark the end of a tar archive.
+const tarEndOfArchiveSize = 2 * 512
+
+// appendComponentArchives appends the component archives to the ctf at the given path
+// without reading and rewriting the already contained component archives.
+// The new entries overwrite the end-of-archive marker of the existing tar.
+//
+// False is returned without modifying the ctf if appending is not safe,
+// e.g. because a component archive is already part of the ctf and has to be replaced.
+// Then the caller is expected to fall back to a full rewrite of the ctf.
+// The optional progress func is called with the index of every component archive after it has been appended.
+func appendComponentArchives(fs vfs.FileSystem, ctfPath string, archives []*componentArchiveSource, format ctf.ArchiveFormat, progress func(i int)) (bool, error) {
+	file, err := fs.OpenFile(ctfPath, os.O_RDWR, 0)
+	if err != nil {
+		// the underlying storage does not support appends
+		return false, nil
+	}
+	defer file.Close()
+
+	end, existing, ok, err := readTarIndex(file)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	names := make([]string, len(archives))
+	for i, src := range archives {
+		names[i] = utils.CTFComponentArchiveFilename(src.cd.GetName(), src.cd.GetVersion())
+		if existing.Has(names[i]) {
+			return false, nil
+		}
+		existing.Insert(names[i])
+	}
+
+	if err := appendEntries(fs, file, end, archives, names, format, progress); err != nil {
+		// the appended entries have overwritten the end-of-archive marker, so the ctf is restored to stay readable.
+		if restoreErr := restoreEndOfArchive(file, end); restoreErr != nil {
+			return false, fmt.Errorf("%s: unable to restore the ctf: %w", err.Error(), restoreErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// appendEntries writes the component archives as entries at the given offset of the end-of-archive marker.
+func appendEntries(fs vfs.FileSystem, file vfs.File, end int64, archives []*componentArchiveSource, names []string, format ctf.ArchiveFormat, progress func(i int)) error {
+	if _, err := file.Seek(end, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to the end of the ctf: %w", err)
+	}
+	cw := &countingWriter{w: file}
+	tw := tar.NewWriter(cw)
+	for i, src := range archives {
+		if err := src.writeEntry(fs, tw, names[i], format); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(i)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("unable to close tar writer: %w", err)
+	}
+
+	// remove possible leftovers of a previously padded archive.
+	if err := file.Truncate(end + cw.n); err != nil {
+		return fmt.Errorf("unable to truncate the ctf: %w", err)
+	}
+	return nil
+}
+
+// restoreEndOfArchive removes all data after the given offset and writes a new end-of-archive marker.
+func restoreEndOfArchive(file vfs.File, end int64) error {
+	if err := file.Truncate(end); err != nil {
+		return fmt.Errorf("unable to truncate the ctf: %w", err)
+	}
+	if _, err := file.Seek(end, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to the end of the ctf: %w", err)
+	}
+	if _, err := file.Write(make([]byte, tarEndOfArchiveSize)); err != nil {
+		return fmt.Errorf("unable to write the end-of-archive marker: %w", err)
+	}
+	return nil
+}
+
+// readTarIndex reads the names of all entries of the tar and returns the offset of the end-of-archive marker.
+// ok is false if the tar has no well-defined end-of-archive marker or contains other entries than regular files.
+func readTarIndex(file vfs.File) (end int64, names sets.String, ok bool, err error) {
+	entries, end, err := scanTar(file)
+	if err != nil {
+		return 0, nil, false, nil
+	}
+	names = sets.NewString()
+	for _, entry := range entries {
+		if entry.typeflag != tar.TypeReg {
+			return 0, nil, false, nil
+		}
+		names.Insert(entry.name)
+	}
+	return end, names, true, nil
+}
+
+// offsetReader keeps track of the current offset of the reader.
+// The offset is tracked explicitly as some filesystems do not support seeking to the end of a file.
+type offsetReader struct {
+	r      io.ReadSeeker
+	offset int64
+}
+
+func (o *offsetReader) Read(p []byte) (int, error) {
+	n, err := o.r.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *offsetReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := o.r.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+	o.offset = pos
+	return pos, nil
+}
+
+// countingWriter counts the written bytes.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// validateLocalBlobs validates that the blobs of all resources with a local filesystem blob access
+// are part of their component archive.
+func validateLocalBlobs(ctx context.Context, archives []*componentArchiveSource) error {
+	errs := []error{}
+	for _, src := range archives {
+		cd := src.cd
+		for _, res := range cd.Resources {
+			if res.Access == nil || res.Access.GetType() != cdv2.LocalFilesystemBlobType {
+				continue
+			}
+			blobAccess := &cdv2.LocalFilesystemBlobAccess{}
+			if err := res.Access.DecodeInto(blobAccess); err != nil {
+				errs = append(errs, fmt.Errorf("component %s: unable to decode access of resource %q: %w",
+					referenceKey(cd.GetName(), cd.GetVersion()), res.GetName(), err))
+				continue
+			}
+			ok, err := src.hasBlob(ctx, res, blobAccess.Filename)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("component %s: unable to read local blob %q of resource %q: %w",
+					referenceKey(cd.GetName(), cd.GetVersion()), blobAccess.Filename, res.GetName(), err))
+				continue
+			}
+			if !ok {
+				errs = append(errs, fmt.Errorf("component %s: local blob %q of resource %q is not part of the component archive",
+					referenceKey(cd.GetName(), cd.GetVersion()), blobAccess.Filename, res.GetName()))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid component archives, use --skip-blob-check to ignore them: %w", utilerrors.NewAggregate(errs))
+}
@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/klauspost/compress/zstd"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// compression is the compression of a ctf archive.
+type compression string
+
+const (
+	noCompression   compression = ""
+	gzipCompression compression = "gzip"
+	zstdCompression compression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// parseCompression parses the name of a compression as it is given by the --compression flag.
+// The --compress flag is kept for compatibility and selects gzip if no compression is given.
+func parseCompression(compress bool, name string) (compression, error) {
+	switch compression(name) {
+	case noCompression:
+		if compress {
+			return gzipCompression, nil
+		}
+		return noCompression, nil
+	case gzipCompression, zstdCompression:
+		return compression(name), nil
+	default:
+		return noCompression, fmt.Errorf("unsupported compression %q, expected %q or %q", name, gzipCompression, zstdCompression)
+	}
+}
+
+// compressionFromExtension returns the compression that is implied by the extension of the path.
+func compressionFromExtension(path string) compression {
+	switch {
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return gzipCompression
+	case strings.HasSuffix(path, ".tar.zst"), strings.HasSuffix(path, ".tzst"):
+		return zstdCompression
+	default:
+		return noCompression
+	}
+}
+
+// compressionFromMagic returns the compression that is identified by the leading bytes of a file.
+func compressionFromMagic(magic []byte) compression {
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzipCompression
+	case bytes.HasPrefix(magic, zstdMagic):
+		return zstdCompression
+	default:
+		return noCompression
+	}
+}
+
+// detectCompression returns the compression of the ctf at the given path.
+// The compression is detected by the magic bytes of the file.
+func detectCompression(fs vfs.FileSystem, path string) (compression, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return noCompression, fmt.Errorf("unable to open ctf at %q: %w", path, err)
+	}
+	defer file.Close()
+	magic := make([]byte, len(zstdMagic))
+	n, err := io.ReadFull(file, magic)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return noCompression, fmt.Errorf("unable to read ctf at %q: %w", path, err)
+	}
+	return compressionFromMagic(magic[:n]), nil
+}
+
+// newTarReader returns a reader for the plain tar of a plain, gzipped or zstd compressed tar.
+// The compression is detected by the magic bytes.
+func newTarReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	switch compressionFromMagic(magic) {
+	case gzipCompression:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open gzip reader: %w", err)
+		}
+		return zr, nil
+	case zstdCompression:
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open zstd reader: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+// nopWriteCloser is a writer that does nothing on close.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// newCompressedWriter returns a writer that compresses the written data with the given compression.
+// The zstd options are only used for the zstd compression.
+// Closing the writer flushes the compressed data but does not close the underlying writer.
+func newCompressedWriter(w io.Writer, c compression, zstdOpts ...zstd.EOption) (io.WriteCloser, error) {
+	switch c {
+	case gzipCompression:
+		return gzip.NewWriter(w), nil
+	case zstdCompression:
+		zw, err := zstd.NewWriter(w, zstdOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create zstd writer: %w", err)
+		}
+		return zw, nil
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+// decompressCTF writes the plain tar of the ctf at the given path to a temporary file and returns its path.
+// The caller is responsible for removing the file.
+func decompressCTF(fs vfs.FileSystem, path string) (string, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to open ctf at %q: %w", path, err)
+	}
+	defer file.Close()
+	r, err := newTarReader(file)
+	if err != nil {
+		return "", fmt.Errorf("unable to read ctf at %q: %w", path, err)
+	}
+	defer r.Close()
+
+	tmpFile, err := vfs.TempFile(fs, "", "ctf-")
+	if err != nil {
+		return "", fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	if _, err := io.Copy(tmpFile, r); err != nil {
+		_ = tmpFile.Close()
+		_ = fs.Remove(tmpFile.Name())
+		return "", fmt.Errorf("unable to decompress ctf at %q: %w", path, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = fs.Remove(tmpFile.Name())
+		return "", fmt.Errorf("unable to close temporary file: %w", err)
+	}
+	return tmpFile.Name(), nil
+}
+
+// compressCTF writes the plain ctf at src with the given compression to a temporary file that replaces the file at dst.
+// The replaced file keeps its permissions.
+func compressCTF(fs vfs.FileSystem, src, dst string, c compression, zstdOpts ...zstd.EOption) error {
+	mode := os.FileMode(0644)
+	if info, err := fs.Stat(dst); err == nil {
+		mode = info.Mode().Perm()
+	}
+	in, err := fs.Open(src)
+	if err != nil {
+		return fmt.Errorf("unable to open ctf at %q: %w", src, err)
+	}
+	defer in.Close()
+
+	tmpFile, err := vfs.TempFile(fs, filepath.Dir(dst), ".ctf-")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer fs.Remove(tmpPath)
+	zw, err := newCompressedWriter(tmpFile, c, zstdOpts...)
+	if err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if _, err := io.Copy(zw, in); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("unable to compress ctf at %q: %w", src, err)
+	}
+	if err := zw.Close(); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("unable to close %s writer: %w", c, err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("unable to sync temporary file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("unable to close temporary file: %w", err)
+	}
+	if err := fs.Chmod(tmpPath, mode); err != nil {
+		return fmt.Errorf("unable to set permissions of temporary file: %w", err)
+	}
+	return utils.ReplaceFile(fs, tmpPath, dst)
+}
+
+// tempFilePath creates an empty temporary file in the given directory and returns its path.
+// The caller is responsible for removing the file.
+func tempFilePath(fs vfs.FileSystem, dir, prefix string) (string, error) {
+	file, err := vfs.TempFile(fs, dir, prefix)
+	if err != nil {
+		return "", fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		_ = fs.Remove(file.Name())
+		return "", fmt.Errorf("unable to close temporary file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// openCTF opens a plain or compressed ctf for reading.
+// Compressed ctfs are opened from a decompressed copy, so modifications must not be written back.
+func openCTF(fs vfs.FileSystem, path string) (*ctf.CTF, error) {
+	c, err := detectCompression(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	if c == noCompression {
+		return ctf.NewCTF(fs, path)
+	}
+	plainPath, err := decompressCTF(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Remove(plainPath)
+	return ctf.NewCTF(fs, plainPath)
+}
+
+// writeEmptyCTF creates or truncates the file at the given path with an empty ctf archive with the given compression.
+func writeEmptyCTF(fs vfs.FileSystem, path string, c compression) error {
+	file, err := fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("unable to open file for %s: %w", path, err)
+	}
+	zw, err := newCompressedWriter(file, c)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	tw := tar.NewWriter(zw)
+	if err := tw.Close(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("unable to close tarwriter for emtpy tar: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("unable to close %s writer for empty tar: %w", c, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("unable to close file %s: %w", path, err)
+	}
+	return nil
+}
@@ -17,5 +17,20 @@ func NewCTFCommand(ctx context.Context) *cobra.Command {
 	}
 	cmd.AddCommand(NewPushCommand(ctx))
 	cmd.AddCommand(NewAddCommand(ctx))
+	cmd.AddCommand(NewSignCommand(ctx))
+	cmd.AddCommand(NewResignCommand(ctx))
+	cmd.AddCommand(NewRetagCommand(ctx))
+	cmd.AddCommand(NewRepackCommand(ctx))
+	cmd.AddCommand(NewTreeCommand(ctx))
+	cmd.AddCommand(NewMergeCommand(ctx))
+	cmd.AddCommand(NewTransformCommand(ctx))
+	cmd.AddCommand(NewListCommand(ctx))
+	cmd.AddCommand(NewGetCommand(ctx))
+	cmd.AddCommand(NewPullCommand(ctx))
+	cmd.AddCommand(NewRemoveCommand(ctx))
+	cmd.AddCommand(NewDiffCommand(ctx))
+	cmd.AddCommand(NewIndexCommand(ctx))
+	cmd.AddCommand(NewVerifyDigestsCommand(ctx))
+	cmd.AddCommand(NewPruneCommand(ctx))
 	return cmd
 }
@@ -0,0 +1,307 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+// DiffOptions defines the options that are used to compare two ctfs.
+type DiffOptions struct {
+	// OldPath is the path to the old ctf.
+	OldPath string
+	// NewPath is the path to the new ctf.
+	NewPath string
+	// Output defines the output format of the diff.
+	Output string
+	// ExitCode exits with status 1 if the ctfs differ.
+	ExitCode bool
+}
+
+// CTFDiff describes the differences between the components of two ctfs.
+type CTFDiff struct {
+	// Added are the components that are only part of the new ctf.
+	Added []ComponentVersion `json:"added"`
+	// Removed are the components that are only part of the old ctf.
+	Removed []ComponentVersion `json:"removed"`
+	// Changed are the components that are part of both ctfs but differ.
+	Changed []ComponentChange `json:"changed"`
+}
+
+// ComponentVersion identifies a component of a ctf.
+type ComponentVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ComponentChange describes a component whose component descriptors differ between both ctfs.
+type ComponentChange struct {
+	Name       string `json:"name"`
+	OldVersion string `json:"oldVersion"`
+	NewVersion string `json:"newVersion"`
+	// Diff contains the differences of the resources, sources, component references and labels.
+	Diff componentarchive.ComponentDiff `json:"diff"`
+}
+
+// Empty returns whether the ctfs do not differ.
+func (d CTFDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// NewDiffCommand creates a new command that compares two ctfs.
+func NewDiffCommand(ctx context.Context) *cobra.Command {
+	opts := &DiffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff OLD_CTF_PATH NEW_CTF_PATH",
+		Args:  cobra.ExactArgs(2),
+		Short: "Compares the components of two ctfs",
+		Long: `
+Compares the components of two ctfs and reports the components that were added or removed
+and the resources, sources, component references and labels that differ for components contained in both ctfs.
+
+Components are matched by their name and version.
+A component that is contained in exactly one version in both ctfs is compared even if its version changed.
+
+Only the component descriptors are read, the ctfs can be plain, gzipped or zstd compressed tars or directories.
+With --exit-code the command exits with status 1 if the ctfs differ, e.g. to gate a release on an expected diff.
+`,
+		Example: `
+component-cli ctf diff ./release-1.0.ctf ./release-1.1.ctf -o json --exit-code
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			diff, err := opts.Diff(osfs.New())
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			if err := WriteDiff(os.Stdout, diff, opts.Output); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			if opts.ExitCode && !diff.Empty() {
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *DiffOptions) Run(_ context.Context, _ logr.Logger, fs vfs.FileSystem, w io.Writer) error {
+	diff, err := o.Diff(fs)
+	if err != nil {
+		return err
+	}
+	return WriteDiff(w, diff, o.Output)
+}
+
+// Diff compares the component descriptors of both ctfs.
+func (o *DiffOptions) Diff(fs vfs.FileSystem) (CTFDiff, error) {
+	oldCds, err := ctfComponentDescriptors(fs, o.OldPath)
+	if err != nil {
+		return CTFDiff{}, err
+	}
+	newCds, err := ctfComponentDescriptors(fs, o.NewPath)
+	if err != nil {
+		return CTFDiff{}, err
+	}
+	return DiffCTFs(componentDescriptorList(oldCds), componentDescriptorList(newCds)), nil
+}
+
+// DiffCTFs compares the component descriptors of an old and a new ctf.
+// All lists of the diff are sorted by component name and version.
+func DiffCTFs(oldCds, newCds []*cdv2.ComponentDescriptor) CTFDiff {
+	diff := CTFDiff{
+		Added:   []ComponentVersion{},
+		Removed: []ComponentVersion{},
+		Changed: []ComponentChange{},
+	}
+	oldByKey := componentDescriptorsByKey(oldCds)
+	newByKey := componentDescriptorsByKey(newCds)
+
+	// components that are not contained in the same version in both ctfs, by their name
+	oldOnly := map[string][]*cdv2.ComponentDescriptor{}
+	for key, oldCd := range oldByKey {
+		newCd, ok := newByKey[key]
+		if !ok {
+			oldOnly[oldCd.GetName()] = append(oldOnly[oldCd.GetName()], oldCd)
+			continue
+		}
+		if cdDiff := componentarchive.DiffComponentDescriptors(oldCd, newCd); !cdDiff.Empty() {
+			diff.Changed = append(diff.Changed, ComponentChange{
+				Name:       oldCd.GetName(),
+				OldVersion: oldCd.GetVersion(),
+				NewVersion: newCd.GetVersion(),
+				Diff:       cdDiff,
+			})
+		}
+	}
+	newOnly := map[string][]*cdv2.ComponentDescriptor{}
+	for key, newCd := range newByKey {
+		if _, ok := oldByKey[key]; !ok {
+			newOnly[newCd.GetName()] = append(newOnly[newCd.GetName()], newCd)
+		}
+	}
+
+	oldVersions := componentVersionCounts(oldCds)
+	newVersions := componentVersionCounts(newCds)
+	for name, cds := range oldOnly {
+		if oldVersions[name] == 1 && newVersions[name] == 1 && len(newOnly[name]) == 1 {
+			oldCd, newCd := cds[0], newOnly[name][0]
+			diff.Changed = append(diff.Changed, ComponentChange{
+				Name:       name,
+				OldVersion: oldCd.GetVersion(),
+				NewVersion: newCd.GetVersion(),
+				Diff:       componentarchive.DiffComponentDescriptors(oldCd, newCd),
+			})
+			delete(newOnly, name)
+			continue
+		}
+		for _, cd := range cds {
+			diff.Removed = append(diff.Removed, ComponentVersion{Name: name, Version: cd.GetVersion()})
+		}
+	}
+	for name, cds := range newOnly {
+		for _, cd := range cds {
+			diff.Added = append(diff.Added, ComponentVersion{Name: name, Version: cd.GetVersion()})
+		}
+	}
+
+	sortComponentVersions(diff.Added)
+	sortComponentVersions(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		if diff.Changed[i].Name != diff.Changed[j].Name {
+			return diff.Changed[i].Name < diff.Changed[j].Name
+		}
+		return diff.Changed[i].NewVersion < diff.Changed[j].NewVersion
+	})
+	return diff
+}
+
+// componentDescriptorList returns the component descriptors of the map as list.
+func componentDescriptorList(cds map[string]*cdv2.ComponentDescriptor) []*cdv2.ComponentDescriptor {
+	list := make([]*cdv2.ComponentDescriptor, 0, len(cds))
+	for _, cd := range cds {
+		list = append(list, cd)
+	}
+	return list
+}
+
+// componentDescriptorsByKey returns the component descriptors by their component name and version.
+func componentDescriptorsByKey(cds []*cdv2.ComponentDescriptor) map[string]*cdv2.ComponentDescriptor {
+	byKey := make(map[string]*cdv2.ComponentDescriptor, len(cds))
+	for _, cd := range cds {
+		byKey[componentKey(cd.GetName(), cd.GetVersion())] = cd
+	}
+	return byKey
+}
+
+// componentVersionCounts returns the number of versions of every component.
+func componentVersionCounts(cds []*cdv2.ComponentDescriptor) map[string]int {
+	counts := map[string]int{}
+	for _, cd := range cds {
+		counts[cd.GetName()]++
+	}
+	return counts
+}
+
+func sortComponentVersions(components []ComponentVersion) {
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Name != components[j].Name {
+			return components[i].Name < components[j].Name
+		}
+		return components[i].Version < components[j].Version
+	})
+}
+
+// WriteDiff writes the diff in the given output format to the writer.
+func WriteDiff(w io.Writer, diff CTFDiff, output string) error {
+	if output == JSONOutput {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal diff: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	if diff.Empty() {
+		_, err := fmt.Fprintln(w, "No differences")
+		return err
+	}
+	lines := []string{}
+	if len(diff.Added) != 0 {
+		lines = append(lines, "added:")
+		for _, component := range diff.Added {
+			lines = append(lines, "  + "+componentKey(component.Name, component.Version))
+		}
+	}
+	if len(diff.Removed) != 0 {
+		lines = append(lines, "removed:")
+		for _, component := range diff.Removed {
+			lines = append(lines, "  - "+componentKey(component.Name, component.Version))
+		}
+	}
+	if len(diff.Changed) != 0 {
+		lines = append(lines, "changed:")
+		for _, change := range diff.Changed {
+			line := "  ~ " + componentKey(change.Name, change.OldVersion)
+			if change.OldVersion != change.NewVersion {
+				line += " -> " + change.NewVersion
+			}
+			lines = append(lines, line)
+			for _, diffLine := range componentarchive.ComponentDiffLines(change.Diff) {
+				lines = append(lines, "    "+diffLine)
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func (o *DiffOptions) Complete(args []string) error {
+	o.OldPath = args[0]
+	o.NewPath = args[1]
+	return o.Validate()
+}
+
+// Validate validates the diff options
+func (o *DiffOptions) Validate() error {
+	if len(o.OldPath) == 0 || len(o.NewPath) == 0 {
+		return errors.New("the paths to the old and the new ctf must be provided")
+	}
+	if o.Output != TextOutput && o.Output != JSONOutput {
+		return fmt.Errorf("unknown output format %q, expected one of %q, %q", o.Output, TextOutput, JSONOutput)
+	}
+	return nil
+}
+
+func (o *DiffOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.Output, "output", "o", TextOutput, fmt.Sprintf("output format of the diff. One of %q, %q", TextOutput, JSONOutput))
+	fs.BoolVar(&o.ExitCode, "exit-code", false, "exit with status 1 if the ctfs differ")
+}
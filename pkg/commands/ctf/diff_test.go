@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+var _ = Describe("Diff", func() {
+
+	var fs vfs.FileSystem
+
+	newComponentDescriptor := func(name, version string, resources ...string) *cdv2.ComponentDescriptor {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Name = name
+		cd.Version = version
+		for _, res := range resources {
+			cd.Resources = append(cd.Resources, cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: res, Version: version, Type: "plain-text"},
+				Relation:           cdv2.LocalRelation,
+			})
+		}
+		return cd
+	}
+
+	addComponents := func(ctfPath string, archives ...string) {
+		opts := cmd.AddOptions{
+			CTFPath:           ctfPath,
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: archives,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a-1", "example.com/a", "v1.0.0", "blob", true)).To(Succeed())
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a-2", "example.com/a", "v1.1.0", "blob", true)).To(Succeed())
+		Expect(writeComponentArchive(fs, "/b", "example.com/b", "v1.0.0")).To(Succeed())
+		Expect(writeComponentArchive(fs, "/c", "example.com/c", "v1.0.0")).To(Succeed())
+		addComponents("/old.ctf", "/a-1", "/b")
+		addComponents("/new.tar.zst", "/a-2", "/c")
+	})
+
+	It("should report added, removed and changed components as json", func() {
+		opts := cmd.DiffOptions{OldPath: "/old.ctf", NewPath: "/new.tar.zst", Output: cmd.JSONOutput}
+		var buf bytes.Buffer
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs, &buf)).To(Succeed())
+
+		diff := cmd.CTFDiff{}
+		Expect(json.Unmarshal(buf.Bytes(), &diff)).To(Succeed())
+		Expect(diff.Added).To(Equal([]cmd.ComponentVersion{{Name: "example.com/c", Version: "v1.0.0"}}))
+		Expect(diff.Removed).To(Equal([]cmd.ComponentVersion{{Name: "example.com/b", Version: "v1.0.0"}}))
+		Expect(diff.Changed).To(HaveLen(1))
+		Expect(diff.Changed[0].Name).To(Equal("example.com/a"))
+		Expect(diff.Changed[0].OldVersion).To(Equal("v1.0.0"))
+		Expect(diff.Changed[0].NewVersion).To(Equal("v1.1.0"))
+		Expect(diff.Changed[0].Diff.Resources.Changed).To(Equal([]componentarchive.ElementChange{
+			{Identity: cdv2.Identity{"name": "blob"}, Fields: []string{"version"}},
+		}))
+	})
+
+	It("should print the diff as text", func() {
+		opts := cmd.DiffOptions{OldPath: "/old.ctf", NewPath: "/new.tar.zst", Output: cmd.TextOutput}
+		var buf bytes.Buffer
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs, &buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`added:
+  + example.com/c:v1.0.0
+removed:
+  - example.com/b:v1.0.0
+changed:
+  ~ example.com/a:v1.0.0 -> v1.1.0
+    resources:
+      ~ name=blob (version)
+`))
+	})
+
+	It("should report no differences for equal ctfs", func() {
+		addComponents("/copy.ctf", "/a-1", "/b")
+		opts := cmd.DiffOptions{OldPath: "/old.ctf", NewPath: "/copy.ctf", Output: cmd.TextOutput}
+		diff, err := opts.Diff(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diff.Empty()).To(BeTrue())
+
+		var buf bytes.Buffer
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs, &buf)).To(Succeed())
+		Expect(buf.String()).To(Equal("No differences\n"))
+	})
+
+	It("should compare the resources of components with the same version", func() {
+		diff := cmd.DiffCTFs(
+			[]*cdv2.ComponentDescriptor{newComponentDescriptor("example.com/a", "v1.0.0", "image"), newComponentDescriptor("example.com/b", "v1.0.0")},
+			[]*cdv2.ComponentDescriptor{newComponentDescriptor("example.com/a", "v1.0.0", "image", "chart"), newComponentDescriptor("example.com/b", "v1.0.0")},
+		)
+		Expect(diff.Added).To(BeEmpty())
+		Expect(diff.Removed).To(BeEmpty())
+		Expect(diff.Changed).To(HaveLen(1))
+		Expect(diff.Changed[0].Name).To(Equal("example.com/a"))
+		Expect(diff.Changed[0].Diff.Resources.Added).To(Equal([]cdv2.Identity{{"name": "chart"}}))
+	})
+
+	It("should not pair versions of components that are contained in multiple versions", func() {
+		diff := cmd.DiffCTFs(
+			[]*cdv2.ComponentDescriptor{newComponentDescriptor("example.com/a", "v1.0.0"), newComponentDescriptor("example.com/a", "v2.0.0")},
+			[]*cdv2.ComponentDescriptor{newComponentDescriptor("example.com/a", "v2.0.0"), newComponentDescriptor("example.com/a", "v3.0.0")},
+		)
+		Expect(diff.Added).To(Equal([]cmd.ComponentVersion{{Name: "example.com/a", Version: "v3.0.0"}}))
+		Expect(diff.Removed).To(Equal([]cmd.ComponentVersion{{Name: "example.com/a", Version: "v1.0.0"}}))
+		Expect(diff.Changed).To(BeEmpty())
+	})
+
+})
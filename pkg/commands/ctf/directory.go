@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// A ctf in the directory layout is a directory that contains every component archive in the filesystem format
+// in a subdirectory named after the component name and version.
+// Component archives can be added and replaced without reading or rewriting the other component archives.
+
+// isDirectoryCTF returns whether the ctf at the given path uses the directory layout.
+func isDirectoryCTF(fs vfs.FileSystem, path string) (bool, error) {
+	info, err := fs.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("unable to get info for %s: %w", path, err)
+	}
+	return info.IsDir(), nil
+}
+
+// ctfDirectoryEntryName returns the name of the subdirectory of a component in a ctf in the directory layout.
+func ctfDirectoryEntryName(name, version string) string {
+	return strings.TrimSuffix(utils.CTFComponentArchiveFilename(name, version), ".tar")
+}
+
+// directoryCTFEntries returns the sorted names of all component archives of the ctf in the directory layout.
+// Hidden directories, e.g. component archives that are currently written, are ignored.
+func directoryCTFEntries(fs vfs.FileSystem, ctfPath string) ([]string, error) {
+	infos, err := vfs.ReadDir(fs, ctfPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ctf at %q: %w", ctfPath, err)
+	}
+	names := []string{}
+	for _, info := range infos {
+		if !info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// readDirectoryComponentArchive reads the component archive of a ctf in the directory layout.
+// The blobs of the component archive are read from the directory when they are accessed.
+func readDirectoryComponentArchive(fs vfs.FileSystem, path string) (*ctf.ComponentArchive, error) {
+	caFs, err := projectionfs.New(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create projection filesystem for %q: %w", path, err)
+	}
+	ca, err := ctf.NewComponentArchiveFromFilesystem(caFs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component archive at %q: %w", path, err)
+	}
+	return ca, nil
+}
+
+// walkCTF calls the function for every component archive of the ctf at the given path.
+// The ctf can be a plain, gzipped or zstd compressed tar or a directory.
+func walkCTF(fs vfs.FileSystem, ctfPath string, fn func(ca *ctf.ComponentArchive) error) error {
+	isDir, err := isDirectoryCTF(fs, ctfPath)
+	if err != nil {
+		return err
+	}
+	if !isDir {
+		// the component archives of an indexed ctf are read directly at their offset.
+		file, index, err := openIndexedCTF(fs, ctfPath)
+		if err != nil {
+			return err
+		}
+		if index != nil {
+			defer file.Close()
+			return index.walk(file, fn)
+		}
+		c, err := detectCompression(fs, ctfPath)
+		if err != nil {
+			return err
+		}
+		if c == noCompression {
+			return walkPlainTarCTF(fs, ctfPath, fn)
+		}
+		ctfArchive, err := openCTF(fs, ctfPath)
+		if err != nil {
+			return fmt.Errorf("unable to open ctf at %q: %s", ctfPath, err.Error())
+		}
+		err = ctfArchive.Walk(fn)
+		if closeErr := ctfArchive.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("unable to close ctf %q: %w", ctfPath, closeErr)
+		}
+		return err
+	}
+
+	names, err := directoryCTFEntries(fs, ctfPath)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		ca, err := readDirectoryComponentArchive(fs, filepath.Join(ctfPath, name))
+		if err != nil {
+			return err
+		}
+		if err := fn(ca); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkPlainTarCTF calls the function for every component archive of the plain ctf at the given path
+// in the order of their entry names. The component archives are read one after another at their offsets,
+// an outdated index of the ctf is skipped.
+func walkPlainTarCTF(fs vfs.FileSystem, ctfPath string, fn func(ca *ctf.ComponentArchive) error) error {
+	file, err := fs.Open(ctfPath)
+	if err != nil {
+		return fmt.Errorf("unable to open ctf at %q: %w", ctfPath, err)
+	}
+	defer file.Close()
+	entries, _, err := scanTar(file)
+	if err != nil {
+		return fmt.Errorf("unable to read ctf at %q: %w", ctfPath, err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	for _, entry := range entries {
+		if entry.typeflag != tar.TypeReg {
+			continue
+		}
+		archive, err := newTarReader(io.NewSectionReader(file, entry.dataOffset, entry.size))
+		if err != nil {
+			return fmt.Errorf("unable to read component archive %q: %w", entry.name, err)
+		}
+		ca, err := ctf.NewComponentArchiveFromTarReader(archive)
+		_ = archive.Close()
+		if err != nil {
+			return fmt.Errorf("unable to read component archive %q: %w", entry.name, err)
+		}
+		if err := fn(ca); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDirectoryComponentArchive writes the component archive to its subdirectory of the ctf in the directory layout.
+// An existing component archive of the same component name and version is replaced.
+func writeDirectoryComponentArchive(fs vfs.FileSystem, ctfPath string, ca *ctf.ComponentArchive) error {
+	return writeDirectoryEntry(fs, ctfPath, ca.ComponentDescriptor, func(dir string) error {
+		return ca.WriteToFilesystem(fs, dir)
+	})
+}
+
+// writeDirectoryEntry writes the subdirectory of the component to the ctf in the directory layout with the given func.
+// The component archive is written to a hidden directory first, so that a failed write does not corrupt the ctf.
+func writeDirectoryEntry(fs vfs.FileSystem, ctfPath string, cd *cdv2.ComponentDescriptor, write func(dir string) error) error {
+	name := ctfDirectoryEntryName(cd.GetName(), cd.GetVersion())
+	entryPath := filepath.Join(ctfPath, name)
+	tmpPath := filepath.Join(ctfPath, "."+name+".tmp")
+	if err := removeAll(fs, tmpPath); err != nil {
+		return fmt.Errorf("unable to remove %q: %w", tmpPath, err)
+	}
+	if err := write(tmpPath); err != nil {
+		_ = fs.RemoveAll(tmpPath)
+		return fmt.Errorf("unable to write component archive %q: %w", name, err)
+	}
+	if err := removeAll(fs, entryPath); err != nil {
+		_ = fs.RemoveAll(tmpPath)
+		return fmt.Errorf("unable to remove component archive %q: %w", name, err)
+	}
+	if err := fs.Rename(tmpPath, entryPath); err != nil {
+		_ = fs.RemoveAll(tmpPath)
+		return fmt.Errorf("unable to write component archive %q: %w", name, err)
+	}
+	return nil
+}
+
+// removeAll removes the path and all its children.
+// Other than some in-memory filesystems, a path that does not exist is not an error.
+func removeAll(fs vfs.FileSystem, path string) error {
+	if err := fs.RemoveAll(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+)
+
+var _ = Describe("Directory layout", func() {
+
+	var fs vfs.FileSystem
+
+	add := func(ctfPath string, directory bool, archives ...string) error {
+		opts := cmd.AddOptions{
+			CTFPath:           ctfPath,
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: archives,
+			Parallel:          1,
+			Directory:         directory,
+		}
+		return opts.Run(context.TODO(), logr.Discard(), fs)
+	}
+
+	listComponents := func(ctfPath string) []string {
+		opts := cmd.ListOptions{CTFPath: ctfPath}
+		entries, err := opts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		components := []string{}
+		for _, entry := range entries {
+			components = append(components, entry.Name+"@"+entry.Version)
+		}
+		return components
+	}
+
+	dirNames := func(path string) []string {
+		infos, err := vfs.ReadDir(fs, path)
+		Expect(err).ToNot(HaveOccurred())
+		names := []string{}
+		for _, info := range infos {
+			names = append(names, info.Name())
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchive(fs, "/a", "example.com/a", "v0.0.1")).To(Succeed())
+		Expect(writeComponentArchiveWithReferences(fs, "/b", "example.com/b", "v1.0.0", "example.com/a@v0.0.1")).To(Succeed())
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/c", "example.com/c", "v2.0.0", "blob", true)).To(Succeed())
+	})
+
+	It("should create a ctf directory and add component archives incrementally", func() {
+		Expect(add("/ctf", true, "/a")).To(Succeed())
+		Expect(add("/ctf", false, "/b", "/c")).To(Succeed())
+
+		Expect(dirNames("/ctf")).To(ConsistOf("example.com_a-v0.0.1", "example.com_b-v1.0.0", "example.com_c-v2.0.0"))
+		Expect(dirNames("/ctf/example.com_c-v2.0.0")).To(ConsistOf(ctf.ComponentDescriptorFileName, ctf.BlobsDirectoryName))
+		data, err := vfs.ReadFile(fs, filepath.Join("/ctf/example.com_c-v2.0.0", ctf.BlobPath("blob")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("blob"))
+
+		Expect(listComponents("/ctf")).To(Equal([]string{"example.com/a@v0.0.1", "example.com/b@v1.0.0", "example.com/c@v2.0.0"}))
+	})
+
+	It("should only replace existing component archives with overwrite", func() {
+		Expect(add("/ctf", true, "/a")).To(Succeed())
+		Expect(add("/ctf", false, "/a")).To(MatchError(ContainSubstring("is already part of the ctf")))
+
+		opts := cmd.AddOptions{
+			CTFPath:           "/ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/a"},
+			Parallel:          1,
+			Overwrite:         true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(dirNames("/ctf")).To(ConsistOf("example.com_a-v0.0.1"))
+	})
+
+	It("should check the references of a ctf directory", func() {
+		Expect(add("/ctf", true, "/b")).To(MatchError(ContainSubstring("unresolved component references")))
+	})
+
+	It("should read and remove component archives of a ctf directory", func() {
+		Expect(add("/ctf", true, "/a", "/b", "/c")).To(Succeed())
+
+		getOpts := cmd.GetOptions{CTFPath: "/ctf", Name: "example.com/c"}
+		ca, err := getOpts.Get(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ca.ComponentDescriptor.GetVersion()).To(Equal("v2.0.0"))
+
+		treeOpts := cmd.TreeOptions{CTFPath: "/ctf", Component: "example.com/b:v1.0.0"}
+		tree, err := treeOpts.Tree(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree.References).To(HaveLen(1))
+
+		removeOpts := cmd.RemoveOptions{CTFPath: "/ctf", Name: "example.com/c"}
+		Expect(removeOpts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(dirNames("/ctf")).To(ConsistOf("example.com_a-v0.0.1", "example.com_b-v1.0.0"))
+	})
+
+	It("should merge a ctf directory and a tar ctf into a ctf directory", func() {
+		Expect(add("/a.ctf", true, "/a")).To(Succeed())
+		Expect(add("/c.ctf", false, "/c")).To(Succeed())
+
+		opts := cmd.MergeOptions{
+			CTFPaths:      []string{"/a.ctf", "/c.ctf"},
+			OutputPath:    "/merged",
+			OnConflict:    string(cmd.ConflictFail),
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			Directory:     true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(dirNames("/merged")).To(ConsistOf("example.com_a-v0.0.1", "example.com_c-v2.0.0"))
+
+		data, err := vfs.ReadFile(fs, filepath.Join("/merged/example.com_c-v2.0.0", ctf.BlobPath("blob")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("blob"))
+	})
+
+	It("should not compress a ctf directory", func() {
+		opts := cmd.AddOptions{
+			CTFPath:           "/ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/a"},
+			Parallel:          1,
+			Directory:         true,
+			Compress:          true,
+		}
+		Expect(opts.Validate()).To(MatchError("a ctf in the directory layout cannot be compressed"))
+	})
+
+})
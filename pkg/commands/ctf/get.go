@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// GetOptions defines the options that are used to extract a component archive from a ctf.
+type GetOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+	// Name is the name of the component.
+	Name string
+	// Version is the optional version of the component.
+	// It is required if the ctf contains multiple versions of the component.
+	Version string
+	// OutputPath is the path where the component archive is written to.
+	// The component archive is written to stdout if no path is defined.
+	OutputPath string
+}
+
+// NewGetCommand creates a new command to extract a component archive from a ctf.
+func NewGetCommand(ctx context.Context) *cobra.Command {
+	opts := &GetOptions{}
+	cmd := &cobra.Command{
+		Use:   "get CTF_PATH --name component-name [--version version] [--output path]",
+		Args:  cobra.ExactArgs(1),
+		Short: "Extracts a component archive from a ctf",
+		Long: `
+Extracts the component archive of a component from a ctf and writes it as tar archive to the output path or stdout.
+The version can be omitted if the ctf contains only one version of the component.
+
+Only the component archive of the component is read from an indexed ctf (see "ctf index"), it is verified against the digest of the index.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *GetOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ca, err := o.Get(fs)
+	if err != nil {
+		return err
+	}
+
+	if len(o.OutputPath) == 0 {
+		return ca.WriteTar(os.Stdout)
+	}
+	file, err := fs.OpenFile(o.OutputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("unable to open file for %s: %w", o.OutputPath, err)
+	}
+	if err := ca.WriteTar(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("unable to write component archive to %q: %w", o.OutputPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("unable to close file %s: %w", o.OutputPath, err)
+	}
+	log.Info(fmt.Sprintf("Successfully written component archive of %s to %q",
+		componentKey(ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion()), o.OutputPath))
+	return nil
+}
+
+// Get returns the component archive of the configured component.
+func (o *GetOptions) Get(fs vfs.FileSystem) (*ctf.ComponentArchive, error) {
+	file, index, err := openIndexedCTF(fs, o.CTFPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ctf: %w", err)
+	}
+	if index != nil {
+		defer file.Close()
+		entries := map[string]ctfIndexEntry{}
+		for _, e := range index.Components {
+			if e.Name == o.Name {
+				entries[e.Version] = e
+			}
+		}
+		versions := make([]string, 0, len(entries))
+		for version := range entries {
+			versions = append(versions, version)
+		}
+		version, err := o.selectVersion(versions)
+		if err != nil {
+			return nil, err
+		}
+		return readIndexedComponentArchive(file, entries[version])
+	}
+
+	archives := map[string]*ctf.ComponentArchive{}
+	err = walkCTF(fs, o.CTFPath, func(ca *ctf.ComponentArchive) error {
+		if ca.ComponentDescriptor.GetName() == o.Name {
+			archives[ca.ComponentDescriptor.GetVersion()] = ca
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ctf: %w", err)
+	}
+
+	versions := make([]string, 0, len(archives))
+	for version := range archives {
+		versions = append(versions, version)
+	}
+	version, err := o.selectVersion(versions)
+	if err != nil {
+		return nil, err
+	}
+	return archives[version], nil
+}
+
+// selectVersion returns the configured version out of the available versions of the component.
+func (o *GetOptions) selectVersion(versions []string) (string, error) {
+	sort.Strings(versions)
+	if len(versions) == 0 {
+		return "", fmt.Errorf("component %q is not part of the ctf", o.Name)
+	}
+	if len(o.Version) != 0 {
+		for _, version := range versions {
+			if version == o.Version {
+				return version, nil
+			}
+		}
+		return "", fmt.Errorf("component %s is not part of the ctf. Available versions: %s",
+			componentKey(o.Name, o.Version), strings.Join(versions, ", "))
+	}
+	if len(versions) > 1 {
+		return "", fmt.Errorf("the ctf contains multiple versions of component %q, a version must be provided. Available versions: %s",
+			o.Name, strings.Join(versions, ", "))
+	}
+	return versions[0], nil
+}
+
+func (o *GetOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the get options
+func (o *GetOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if len(o.Name) == 0 {
+		return errors.New("a component name must be provided")
+	}
+	return nil
+}
+
+func (o *GetOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Name, "name", "", "name of the component")
+	fs.StringVar(&o.Version, "version", "", "[OPTIONAL] version of the component. Required if the ctf contains multiple versions of the component")
+	fs.StringVarP(&o.OutputPath, "output", "o", "", "[OPTIONAL] path where the component archive is written to. Defaults to stdout")
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"context"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+var _ = Describe("Get", func() {
+
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchive(fs, "/a-1", "example.com/a", "v0.0.1")).To(Succeed())
+		Expect(writeComponentArchive(fs, "/a-2", "example.com/a", "v0.0.2")).To(Succeed())
+		Expect(writeComponentArchive(fs, "/b", "example.com/b", "v1.0.0")).To(Succeed())
+		addOpts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/a-1", "/a-2", "/b"},
+		}
+		Expect(addOpts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	})
+
+	It("should write the component archive to the output path", func() {
+		opts := cmd.GetOptions{
+			CTFPath:    "/component.ctf",
+			Name:       "example.com/a",
+			Version:    "v0.0.2",
+			OutputPath: "/out.tar",
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		Expect(tarEntries(fs, "/out.tar")).To(ContainElement(ctf.ComponentDescriptorFileName))
+		ca, _, err := componentarchive.Parse(fs, "/out.tar")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ca.ComponentDescriptor.GetName()).To(Equal("example.com/a"))
+		Expect(ca.ComponentDescriptor.GetVersion()).To(Equal("v0.0.2"))
+	})
+
+	It("should get a component without version if the ctf contains only one version", func() {
+		opts := cmd.GetOptions{CTFPath: "/component.ctf", Name: "example.com/b"}
+		ca, err := opts.Get(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ca.ComponentDescriptor.GetVersion()).To(Equal("v1.0.0"))
+	})
+
+	It("should list the available versions if no version is given", func() {
+		opts := cmd.GetOptions{CTFPath: "/component.ctf", Name: "example.com/a"}
+		_, err := opts.Get(fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Available versions: v0.0.1, v0.0.2"))
+	})
+
+	It("should return an error if the component is not part of the ctf", func() {
+		opts := cmd.GetOptions{CTFPath: "/component.ctf", Name: "example.com/x", OutputPath: "/out.tar"}
+		err := opts.Run(context.TODO(), logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`component "example.com/x" is not part of the ctf`))
+		_, err = fs.Stat("/out.tar")
+		Expect(err).To(HaveOccurred())
+
+		opts = cmd.GetOptions{CTFPath: "/component.ctf", Name: "example.com/a", Version: "v9.9.9"}
+		_, err = opts.Get(fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("example.com/a:v9.9.9 is not part of the ctf"))
+	})
+
+})
@@ -0,0 +1,418 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// A plain ctf can have an index of its component archives that is stored in a file next to the ctf.
+// The index contains the offset, size and digest of every component archive,
+// so that single component archives can be read without scanning the whole ctf.
+// The ctf itself is not modified, so that indexed ctfs can still be read by all other tools.
+const (
+	// ctfIndexFileSuffix is the suffix of the file next to the ctf that contains its index.
+	ctfIndexFileSuffix = ".index.json"
+	// ctfIndexSchemaVersion is the schema version of the index.
+	ctfIndexSchemaVersion = "v1"
+
+	tarBlockSize = 512
+)
+
+// ctfIndex is the index of the component archives of a plain ctf.
+type ctfIndex struct {
+	SchemaVersion string `json:"schemaVersion"`
+	// Size is the size of the indexed ctf.
+	Size int64 `json:"size"`
+	// Components contains the component archives of the ctf in the order of their offset.
+	Components []ctfIndexEntry `json:"components"`
+}
+
+// ctfIndexEntry describes a component archive of an indexed ctf.
+type ctfIndexEntry struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Entry is the name of the tar entry of the component archive.
+	Entry string `json:"entry"`
+	// Offset is the offset of the tar header of the component archive in the ctf.
+	Offset int64 `json:"offset"`
+	// Size is the size of the component archive.
+	Size int64 `json:"size"`
+	// Digest is the sha256 digest of the component archive.
+	Digest string `json:"digest"`
+
+	// dataOffset is the offset of the component archive in the ctf, it is set when the index is validated.
+	dataOffset int64
+}
+
+// IndexOptions defines the options that are used to index a ctf.
+type IndexOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+}
+
+// NewIndexCommand creates a new command to write the index of a ctf.
+func NewIndexCommand(ctx context.Context) *cobra.Command {
+	opts := &IndexOptions{}
+	cmd := &cobra.Command{
+		Use:   "index CTF_PATH",
+		Args:  cobra.ExactArgs(1),
+		Short: "Writes the index of a ctf",
+		Long: `
+Writes an index of the component archives of a plain ctf to the file "<CTF_PATH>` + ctfIndexFileSuffix + `" next to the ctf.
+The ctf itself is not modified. An existing index is replaced, the digests of all component archives are calculated again.
+
+The index contains the component name, version, offset, size and digest of every component archive,
+so that "ctf list", "ctf get" and "ctf push" read the component archives directly at their offset
+instead of scanning the whole ctf. Component archives that are read by their offset are verified against their digest.
+
+Once a ctf has an index, it is updated by all commands that modify the ctf, e.g. "ctf add" and "ctf remove".
+An index that does not match the ctf, e.g. because the ctf was modified by another tool, is ignored.
+
+Compressed ctfs and ctfs in the directory layout cannot be indexed.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *IndexOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	if err := validateIndexableCTF(fs, o.CTFPath); err != nil {
+		return err
+	}
+	if err := writeCTFIndex(fs, o.CTFPath, nil, nil); err != nil {
+		return err
+	}
+	index, err := readCTFIndex(fs, o.CTFPath)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		return fmt.Errorf("unable to read the written index of the ctf at %q", o.CTFPath)
+	}
+	log.Info(fmt.Sprintf("Indexed %d component archive(s) of %q", len(index.Components), o.CTFPath))
+	return nil
+}
+
+func (o *IndexOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the index options
+func (o *IndexOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	return nil
+}
+
+func (o *IndexOptions) AddFlags(_ *pflag.FlagSet) {}
+
+// validateIndexableCTF validates that the ctf at the given path is a plain tar.
+func validateIndexableCTF(fs vfs.FileSystem, ctfPath string) error {
+	info, err := fs.Stat(ctfPath)
+	if err != nil {
+		return fmt.Errorf("unable to get info for %s: %w", ctfPath, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%q is a ctf in the directory layout that cannot be indexed", ctfPath)
+	}
+	c, err := detectCompression(fs, ctfPath)
+	if err != nil {
+		return err
+	}
+	if c != noCompression {
+		return fmt.Errorf("%q is a %s compressed ctf that cannot be indexed", ctfPath, c)
+	}
+	return nil
+}
+
+// readCTFIndex reads the index of the ctf at the given path.
+// Nil is returned if the ctf has no valid index.
+func readCTFIndex(fs vfs.FileSystem, ctfPath string) (*ctfIndex, error) {
+	file, index, err := openIndexedCTF(fs, ctfPath)
+	if err != nil || index == nil {
+		return nil, err
+	}
+	defer file.Close()
+	return index, nil
+}
+
+// ctfIndexPath returns the path of the index of the ctf at the given path.
+func ctfIndexPath(ctfPath string) string {
+	return ctfPath + ctfIndexFileSuffix
+}
+
+// openIndexedCTF opens the ctf at the given path and reads its index.
+// The file is only returned if the ctf has a valid index, the caller is responsible for closing it.
+func openIndexedCTF(fs vfs.FileSystem, ctfPath string) (vfs.File, *ctfIndex, error) {
+	info, err := fs.Stat(ctfPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get info for %s: %w", ctfPath, err)
+	}
+	if info.IsDir() {
+		return nil, nil, nil
+	}
+	data, err := vfs.ReadFile(fs, ctfIndexPath(ctfPath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("unable to read index of ctf at %q: %w", ctfPath, err)
+	}
+	file, err := fs.Open(ctfPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to open ctf at %q: %w", ctfPath, err)
+	}
+	index := decodeCTFIndex(data, file, info.Size())
+	if index == nil {
+		_ = file.Close()
+		return nil, nil, nil
+	}
+	return file, index, nil
+}
+
+// decodeCTFIndex decodes the index of the plain ctf with the given size.
+// Nil is returned if the index cannot be decoded or does not match the entries of the ctf.
+func decodeCTFIndex(data []byte, file io.ReaderAt, size int64) *ctfIndex {
+	index := &ctfIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil
+	}
+	if index.SchemaVersion != ctfIndexSchemaVersion || index.Size != size || !index.validate(file, size) {
+		return nil
+	}
+	return index
+}
+
+// validate validates that the component archives of the index are the only entries of the ctf.
+func (idx *ctfIndex) validate(file io.ReaderAt, size int64) bool {
+	var offset int64
+	for i := range idx.Components {
+		e := &idx.Components[i]
+		if e.Offset != offset {
+			return false
+		}
+		cr := &offsetReader{r: io.NewSectionReader(file, e.Offset, size-e.Offset)}
+		header, err := tar.NewReader(cr).Next()
+		if err != nil || header.Typeflag != tar.TypeReg || header.Name != e.Entry || header.Size != e.Size {
+			return false
+		}
+		e.dataOffset = e.Offset + cr.offset
+		offset = e.dataOffset + paddedTarSize(e.Size)
+	}
+	marker := make([]byte, tarEndOfArchiveSize)
+	if n, _ := file.ReadAt(marker, offset); n != len(marker) {
+		return false
+	}
+	return bytes.Equal(marker, make([]byte, tarEndOfArchiveSize))
+}
+
+// reader returns a reader for the component archive of the entry.
+func (e ctfIndexEntry) reader(file io.ReaderAt) io.Reader {
+	return io.NewSectionReader(file, e.dataOffset, e.Size)
+}
+
+// walk calls the function for every component archive of the index in the order of their entry names.
+func (idx *ctfIndex) walk(file io.ReaderAt, fn func(ca *ctf.ComponentArchive) error) error {
+	entries := append([]ctfIndexEntry{}, idx.Components...)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Entry < entries[j].Entry
+	})
+	for _, e := range entries {
+		ca, err := readIndexedComponentArchive(file, e)
+		if err != nil {
+			return err
+		}
+		if err := fn(ca); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readIndexedComponentArchive reads the component archive of the index entry and verifies its digest.
+func readIndexedComponentArchive(file io.ReaderAt, e ctfIndexEntry) (*ctf.ComponentArchive, error) {
+	h := sha256.New()
+	r := io.TeeReader(e.reader(file), h)
+	archive, err := newTarReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component archive %q: %w", e.Entry, err)
+	}
+	ca, err := ctf.NewComponentArchiveFromTarReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component archive %q: %w", e.Entry, err)
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("unable to read component archive %q: %w", e.Entry, err)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, fmt.Errorf("unable to read component archive %q: %w", e.Entry, err)
+	}
+	if digest := sha256Digest(h.Sum(nil)); digest != e.Digest {
+		return nil, fmt.Errorf("digest %s of component archive %q does not match the digest %s of the ctf index", digest, e.Entry, e.Digest)
+	}
+	return ca, nil
+}
+
+// writeCTFIndex writes the index of the plain ctf at the given path to the file next to the ctf.
+// An existing index is replaced.
+// The digests and components of the previous index are reused for all entries that are not changed,
+// all other component archives are read to calculate their digest.
+func writeCTFIndex(fs vfs.FileSystem, ctfPath string, previous *ctfIndex, changed sets.String) error {
+	file, err := fs.Open(ctfPath)
+	if err != nil {
+		return fmt.Errorf("unable to open ctf at %q: %w", ctfPath, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to get info for %s: %w", ctfPath, err)
+	}
+
+	entries, _, err := scanTar(file)
+	if err != nil {
+		return fmt.Errorf("unable to index ctf at %q: %w", ctfPath, err)
+	}
+	reuse := map[string]ctfIndexEntry{}
+	if previous != nil {
+		for _, e := range previous.Components {
+			if !changed.Has(e.Entry) {
+				reuse[e.Entry] = e
+			}
+		}
+	}
+
+	index := &ctfIndex{SchemaVersion: ctfIndexSchemaVersion, Size: info.Size(), Components: []ctfIndexEntry{}}
+	for _, entry := range entries {
+		if entry.typeflag != tar.TypeReg {
+			return fmt.Errorf("unable to index ctf at %q: unsupported entry %q", ctfPath, entry.name)
+		}
+		e := ctfIndexEntry{
+			Entry:  entry.name,
+			Offset: entry.offset,
+			Size:   entry.size,
+		}
+		if prev, ok := reuse[entry.name]; ok && prev.Size == entry.size {
+			e.Name, e.Version, e.Digest = prev.Name, prev.Version, prev.Digest
+		} else {
+			h := sha256.New()
+			r := io.TeeReader(io.NewSectionReader(file, entry.dataOffset, entry.size), h)
+			cd, err := readArchiveComponentDescriptor(r)
+			if err != nil {
+				return fmt.Errorf("unable to read component descriptor of %q: %w", entry.name, err)
+			}
+			if _, err := io.Copy(io.Discard, r); err != nil {
+				return fmt.Errorf("unable to read component archive %q: %w", entry.name, err)
+			}
+			e.Name, e.Version, e.Digest = cd.GetName(), cd.GetVersion(), sha256Digest(h.Sum(nil))
+		}
+		index.Components = append(index.Components, e)
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("unable to encode ctf index: %w", err)
+	}
+	return utils.WriteFileAtomic(fs, ctfIndexPath(ctfPath), data, 0644)
+}
+
+// tarEntryInfo describes an entry of a plain tar.
+type tarEntryInfo struct {
+	name     string
+	typeflag byte
+	// offset is the offset of the header of the entry.
+	offset int64
+	// dataOffset is the offset of the content of the entry.
+	dataOffset int64
+	size       int64
+}
+
+// scanTar returns the entries of the plain tar and the offset of its end-of-archive marker.
+// The content of the entries is skipped, so that only the headers are read.
+func scanTar(file vfs.File) ([]tarEntryInfo, int64, error) {
+	cr := &offsetReader{r: file}
+	tr := tar.NewReader(cr)
+	entries := []tarEntryInfo{}
+	var end int64
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, 0, err
+		}
+		entries = append(entries, tarEntryInfo{
+			name:       header.Name,
+			typeflag:   header.Typeflag,
+			offset:     end,
+			dataOffset: cr.offset,
+			size:       header.Size,
+		})
+		end = cr.offset + paddedTarSize(header.Size)
+	}
+
+	marker := make([]byte, tarEndOfArchiveSize)
+	if _, err := file.ReadAt(marker, end); err != nil || !bytes.Equal(marker, make([]byte, tarEndOfArchiveSize)) {
+		return nil, 0, errors.New("the tar has no end-of-archive marker")
+	}
+	return entries, end, nil
+}
+
+// paddedTarSize returns the size of tar content that is padded to the tar block size.
+func paddedTarSize(size int64) int64 {
+	return (size + tarBlockSize - 1) / tarBlockSize * tarBlockSize
+}
+
+// sha256Digest returns the digest string of a sha256 sum.
+func sha256Digest(sum []byte) string {
+	return "sha256:" + hex.EncodeToString(sum)
+}
+
+// componentDescriptorOfIndexEntry reads the component descriptor of the component archive of the index entry.
+func componentDescriptorOfIndexEntry(file io.ReaderAt, e ctfIndexEntry) (*cdv2.ComponentDescriptor, error) {
+	cd, err := readArchiveComponentDescriptor(e.reader(file))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component descriptor of %q: %w", e.Entry, err)
+	}
+	return cd, nil
+}
@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("Index", func() {
+
+	var fs vfs.FileSystem
+
+	addComponents := func(ctfPath string, index bool, archives ...string) error {
+		addOpts := cmd.AddOptions{
+			CTFPath:           ctfPath,
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: archives,
+			Parallel:          1,
+			Overwrite:         true,
+			Index:             index,
+		}
+		return addOpts.Run(context.TODO(), logr.Discard(), fs)
+	}
+
+	listComponents := func(ctfPath string) []string {
+		opts := cmd.ListOptions{CTFPath: ctfPath}
+		entries, err := opts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		components := []string{}
+		for _, entry := range entries {
+			components = append(components, entry.Name+"@"+entry.Version)
+		}
+		return components
+	}
+
+	getBlob := func(ctfPath, name, version string) string {
+		opts := cmd.GetOptions{CTFPath: ctfPath, Name: name, Version: version}
+		ca, err := opts.Get(fs)
+		Expect(err).ToNot(HaveOccurred())
+		acc, err := cdv2.NewUnstructured(cdv2.NewLocalFilesystemBlobAccess("blob.txt", "text/plain"))
+		Expect(err).ToNot(HaveOccurred())
+		var buf bytes.Buffer
+		_, err = ca.Resolve(context.TODO(), cdv2.Resource{Access: &acc}, &buf)
+		Expect(err).ToNot(HaveOccurred())
+		return buf.String()
+	}
+
+	// indexedEntries returns the entries of the index of the ctf.
+	indexedEntries := func(ctfPath string) []string {
+		data, err := vfs.ReadFile(fs, ctfPath+".index.json")
+		Expect(err).ToNot(HaveOccurred())
+		index := struct {
+			Components []struct {
+				Entry string `json:"entry"`
+			} `json:"components"`
+		}{}
+		Expect(json.Unmarshal(data, &index)).To(Succeed())
+		entries := []string{}
+		for _, c := range index.Components {
+			entries = append(entries, c.Entry)
+		}
+		return entries
+	}
+
+	writeBlobArchive := func(path, name, version, content string) {
+		Expect(writeComponentArchiveWithLocalBlob(fs, path, name, version, "blob.txt", false)).To(Succeed())
+		Expect(vfs.WriteFile(fs, filepath.Join(path, ctf.BlobPath("blob.txt")), []byte(content), os.ModePerm)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		writeBlobArchive("/a", "example.com/a", "v0.0.1", "content of a")
+		writeBlobArchive("/b", "example.com/b", "v0.0.1", "content of b")
+		writeBlobArchive("/c", "example.com/c", "v0.0.1", "content of c")
+	})
+
+	It("should write the index next to the ctf", func() {
+		Expect(addComponents("/component.ctf", true, "/a", "/b")).To(Succeed())
+
+		names := []string{
+			utils.CTFComponentArchiveFilename("example.com/a", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/b", "v0.0.1"),
+		}
+		Expect(tarEntries(fs, "/component.ctf")).To(Equal(names))
+		Expect(indexedEntries("/component.ctf")).To(Equal(names))
+		Expect(listComponents("/component.ctf")).To(Equal([]string{"example.com/a@v0.0.1", "example.com/b@v0.0.1"}))
+		Expect(getBlob("/component.ctf", "example.com/b", "")).To(Equal("content of b"))
+	})
+
+	It("should update the index when component archives are appended without --index", func() {
+		Expect(addComponents("/component.ctf", true, "/a", "/b")).To(Succeed())
+		Expect(addComponents("/component.ctf", false, "/c")).To(Succeed())
+
+		Expect(indexedEntries("/component.ctf")).To(Equal([]string{
+			utils.CTFComponentArchiveFilename("example.com/a", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/b", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/c", "v0.0.1"),
+		}))
+		Expect(listComponents("/component.ctf")).To(Equal([]string{"example.com/a@v0.0.1", "example.com/b@v0.0.1", "example.com/c@v0.0.1"}))
+		Expect(getBlob("/component.ctf", "example.com/c", "v0.0.1")).To(Equal("content of c"))
+	})
+
+	It("should update the index when a component archive is appended to an indexed ctf", func() {
+		Expect(addComponents("/component.ctf", true, "/a", "/b")).To(Succeed())
+		sink := &recordingLogSink{}
+		addOpts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/c"},
+			Parallel:          1,
+			Index:             true,
+		}
+		Expect(addOpts.Run(context.TODO(), logr.New(sink), fs)).To(Succeed())
+
+		Expect(sink.entriesWithMessage("Component archives cannot be appended to the ctf, rewriting the complete ctf")).To(BeEmpty())
+		Expect(indexedEntries("/component.ctf")).To(Equal([]string{
+			utils.CTFComponentArchiveFilename("example.com/a", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/b", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/c", "v0.0.1"),
+		}))
+		Expect(getBlob("/component.ctf", "example.com/c", "v0.0.1")).To(Equal("content of c"))
+	})
+
+	It("should update the index when a component archive is replaced", func() {
+		Expect(addComponents("/component.ctf", true, "/a", "/b")).To(Succeed())
+		writeBlobArchive("/a-new", "example.com/a", "v0.0.1", "new content of a")
+		Expect(addComponents("/component.ctf", false, "/a-new")).To(Succeed())
+
+		Expect(indexedEntries("/component.ctf")).To(ConsistOf(
+			utils.CTFComponentArchiveFilename("example.com/a", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/b", "v0.0.1"),
+		))
+		Expect(getBlob("/component.ctf", "example.com/a", "")).To(Equal("new content of a"))
+		Expect(getBlob("/component.ctf", "example.com/b", "")).To(Equal("content of b"))
+	})
+
+	It("should keep the index when a component archive is removed", func() {
+		Expect(addComponents("/component.ctf", true, "/a", "/b", "/c")).To(Succeed())
+
+		opts := cmd.RemoveOptions{CTFPath: "/component.ctf", Name: "example.com/b"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		Expect(indexedEntries("/component.ctf")).To(Equal([]string{
+			utils.CTFComponentArchiveFilename("example.com/a", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/c", "v0.0.1"),
+		}))
+		Expect(listComponents("/component.ctf")).To(Equal([]string{"example.com/a@v0.0.1", "example.com/c@v0.0.1"}))
+		Expect(getBlob("/component.ctf", "example.com/c", "")).To(Equal("content of c"))
+	})
+
+	It("should index an existing ctf", func() {
+		Expect(addComponents("/component.ctf", false, "/a", "/b")).To(Succeed())
+		_, err := fs.Stat("/component.ctf.index.json")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		before, err := vfs.ReadFile(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+
+		opts := cmd.IndexOptions{CTFPath: "/component.ctf"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		after, err := vfs.ReadFile(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(after).To(Equal(before))
+		Expect(indexedEntries("/component.ctf")).To(Equal([]string{
+			utils.CTFComponentArchiveFilename("example.com/a", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/b", "v0.0.1"),
+		}))
+		infos, err := vfs.ReadDir(fs, "/")
+		Expect(err).ToNot(HaveOccurred())
+		for _, info := range infos {
+			Expect(info.Name()).ToNot(HavePrefix("."))
+		}
+		Expect(getBlob("/component.ctf", "example.com/a", "")).To(Equal("content of a"))
+	})
+
+	It("should fail if a component archive does not match the digest of the index", func() {
+		Expect(addComponents("/component.ctf", true, "/a", "/b")).To(Succeed())
+		data, err := vfs.ReadFile(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bytes.Count(data, []byte("content of a"))).To(Equal(1))
+		data = bytes.Replace(data, []byte("content of a"), []byte("CONTENT OF A"), 1)
+		Expect(vfs.WriteFile(fs, "/component.ctf", data, os.ModePerm)).To(Succeed())
+
+		opts := cmd.GetOptions{CTFPath: "/component.ctf", Name: "example.com/a"}
+		_, err = opts.Get(fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("does not match the digest"))
+	})
+
+	It("should keep indexed ctfs readable by the component-spec bindings", func() {
+		Expect(addComponents("/component.ctf", true, "/a", "/b")).To(Succeed())
+
+		c, err := ctf.NewCTF(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		defer c.Close()
+		components := []string{}
+		Expect(c.Walk(func(ca *ctf.ComponentArchive) error {
+			components = append(components, ca.ComponentDescriptor.GetName())
+			return nil
+		})).To(Succeed())
+		Expect(components).To(ConsistOf("example.com/a", "example.com/b"))
+	})
+
+	It("should ignore an index that does not match the ctf", func() {
+		Expect(addComponents("/component.ctf", true, "/a", "/b")).To(Succeed())
+		// the ctf is replaced by another tool that does not update the index.
+		Expect(fs.Remove("/component.ctf")).To(Succeed())
+		Expect(addComponents("/component.ctf", false, "/b", "/a")).To(Succeed())
+		Expect(indexedEntries("/component.ctf")).To(Equal([]string{
+			utils.CTFComponentArchiveFilename("example.com/a", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/b", "v0.0.1"),
+		}))
+
+		Expect(listComponents("/component.ctf")).To(Equal([]string{"example.com/a@v0.0.1", "example.com/b@v0.0.1"}))
+		Expect(getBlob("/component.ctf", "example.com/a", "")).To(Equal("content of a"))
+
+		// the outdated index is replaced when the ctf is modified with --index.
+		Expect(addComponents("/component.ctf", true, "/c")).To(Succeed())
+		Expect(indexedEntries("/component.ctf")).To(Equal([]string{
+			utils.CTFComponentArchiveFilename("example.com/b", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/a", "v0.0.1"),
+			utils.CTFComponentArchiveFilename("example.com/c", "v0.0.1"),
+		}))
+		Expect(getBlob("/component.ctf", "example.com/c", "")).To(Equal("content of c"))
+	})
+
+	It("should fail to index a compressed ctf", func() {
+		Expect(addComponents("/component.ctf.tgz", false, "/a")).To(Succeed())
+		Expect(addComponents("/component.ctf.tgz", true, "/b")).To(HaveOccurred())
+
+		opts := cmd.IndexOptions{CTFPath: "/component.ctf.tgz"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// ListOptions defines the options that are used to list the components of a ctf.
+type ListOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+	// Output defines the output format of the list.
+	Output string
+}
+
+// ListEntry describes a component of a ctf.
+type ListEntry struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Resources is the number of resources of the component.
+	Resources int `json:"resources"`
+	// Size is the size of the component archive in bytes.
+	Size int64 `json:"size"`
+}
+
+// NewListCommand creates a new command to list the components of a ctf.
+func NewListCommand(ctx context.Context) *cobra.Command {
+	opts := &ListOptions{}
+	cmd := &cobra.Command{
+		Use:   "list CTF_PATH",
+		Args:  cobra.ExactArgs(1),
+		Short: "Lists the components of a ctf",
+		Long: `
+Lists the name, version, number of resources and archive size of all components that are contained in a ctf.
+The components are sorted by name and version.
+
+Only the component descriptors are read from the ctf, the component archives are not extracted.
+The ctf can be a plain, gzipped or zstd compressed tar or a directory.
+The component descriptors of an indexed ctf (see "ctf index") are read directly at the offsets of their component archives.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *ListOptions) Run(_ context.Context, _ logr.Logger, fs vfs.FileSystem) error {
+	entries, err := o.List(fs)
+	if err != nil {
+		return err
+	}
+	return WriteList(os.Stdout, entries, o.Output)
+}
+
+// List returns all components of the ctf.
+// The ctf is streamed and only the component descriptors of the component archives are read.
+func (o *ListOptions) List(fs vfs.FileSystem) ([]ListEntry, error) {
+	isDir, err := isDirectoryCTF(fs, o.CTFPath)
+	if err != nil {
+		return nil, err
+	}
+	var entries []ListEntry
+	if isDir {
+		entries, err = listDirectoryCTF(fs, o.CTFPath)
+	} else {
+		entries, err = listTarCTF(fs, o.CTFPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].Version < entries[j].Version
+	})
+	return entries, nil
+}
+
+// listTarCTF returns the components of a plain or compressed ctf.
+// The size of a component is the size of its component archive in the ctf.
+func listTarCTF(fs vfs.FileSystem, ctfPath string) ([]ListEntry, error) {
+	indexed, index, err := openIndexedCTF(fs, ctfPath)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil {
+		// only the component descriptors of an indexed ctf are read at the offsets of their component archives.
+		defer indexed.Close()
+		entries := make([]ListEntry, 0, len(index.Components))
+		for _, e := range index.Components {
+			cd, err := componentDescriptorOfIndexEntry(indexed, e)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, ListEntry{
+				Name:      cd.GetName(),
+				Version:   cd.GetVersion(),
+				Resources: len(cd.Resources),
+				Size:      e.Size,
+			})
+		}
+		return entries, nil
+	}
+
+	file, err := fs.Open(ctfPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open ctf at %q: %w", ctfPath, err)
+	}
+	defer file.Close()
+	r, err := newTarReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ctf at %q: %w", ctfPath, err)
+	}
+	defer r.Close()
+
+	entries := []ListEntry{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			return nil, fmt.Errorf("unable to read ctf at %q: %w", ctfPath, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		cd, err := readArchiveComponentDescriptor(tr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read component descriptor of %q: %w", header.Name, err)
+		}
+		entries = append(entries, ListEntry{
+			Name:      cd.GetName(),
+			Version:   cd.GetVersion(),
+			Resources: len(cd.Resources),
+			Size:      header.Size,
+		})
+	}
+}
+
+// listDirectoryCTF returns the components of a ctf in the directory layout.
+// The size of a component is the size of all files of its component archive.
+func listDirectoryCTF(fs vfs.FileSystem, ctfPath string) ([]ListEntry, error) {
+	cds, err := directoryCTFComponentDescriptors(fs, ctfPath)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]ListEntry, 0, len(cds))
+	for name, cd := range cds {
+		var size int64
+		err := vfs.Walk(fs, filepath.Join(ctfPath, name), func(_ string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() {
+				size += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to read component archive %q: %w", name, err)
+		}
+		entries = append(entries, ListEntry{
+			Name:      cd.GetName(),
+			Version:   cd.GetVersion(),
+			Resources: len(cd.Resources),
+			Size:      size,
+		})
+	}
+	return entries, nil
+}
+
+// WriteList writes the components in the given output format.
+func WriteList(w io.Writer, entries []ListEntry, output string) error {
+	switch output {
+	case TextOutput:
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		if _, err := fmt.Fprintln(tw, "NAME\tVERSION\tRESOURCES\tSIZE"); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if _, err := fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", entry.Name, entry.Version, entry.Resources, utils.BytesString(uint64(entry.Size), 2)); err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	case JSONOutput:
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal components: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+}
+
+func (o *ListOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the list options
+func (o *ListOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if o.Output != TextOutput && o.Output != JSONOutput {
+		return fmt.Errorf("unknown output format %q, expected one of %q, %q", o.Output, TextOutput, JSONOutput)
+	}
+	return nil
+}
+
+func (o *ListOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.Output, "output", "o", TextOutput, fmt.Sprintf("output format of the list. One of %q, %q", TextOutput, JSONOutput))
+}
@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("List", func() {
+
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchive(fs, "/b-ca", "example.com/b", "v0.0.1")).To(Succeed())
+		Expect(fs.MkdirAll("/a-ca", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, filepath.Join("/a-ca", ctf.ComponentDescriptorFileName), []byte(`meta:
+  schemaVersion: 'v2'
+component:
+  name: 'example.com/a'
+  version: 'v1.0.0'
+  repositoryContexts: []
+  provider: 'internal'
+  sources: []
+  componentReferences: []
+  resources:
+  - name: 'image'
+    version: 'v1.0.0'
+    type: 'ociImage'
+    relation: 'external'
+    access:
+      type: 'ociRegistry'
+      imageReference: 'example.com/image:v1.0.0'
+  - name: 'chart'
+    version: 'v1.0.0'
+    type: 'helm'
+    relation: 'external'
+    access:
+      type: 'ociRegistry'
+      imageReference: 'example.com/chart:v1.0.0'
+`), os.ModePerm)).To(Succeed())
+	})
+
+	It("should list all components of the ctf sorted by name", func() {
+		addOpts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/b-ca", "/a-ca"},
+		}
+		Expect(addOpts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		opts := cmd.ListOptions{CTFPath: "/component.ctf"}
+		entries, err := opts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		sizes := tarEntrySizes(fs, "/component.ctf")
+		Expect(entries).To(Equal([]cmd.ListEntry{
+			{Name: "example.com/a", Version: "v1.0.0", Resources: 2, Size: sizes[utils.CTFComponentArchiveFilename("example.com/a", "v1.0.0")]},
+			{Name: "example.com/b", Version: "v0.0.1", Resources: 0, Size: sizes[utils.CTFComponentArchiveFilename("example.com/b", "v0.0.1")]},
+		}))
+		Expect(entries[0].Size).To(BeNumerically(">", 0))
+
+		out := &bytes.Buffer{}
+		Expect(cmd.WriteList(out, []cmd.ListEntry{
+			{Name: "example.com/a", Version: "v1.0.0", Resources: 2, Size: 3072},
+			{Name: "example.com/b", Version: "v0.0.1", Resources: 0, Size: 1536},
+		}, cmd.TextOutput)).To(Succeed())
+		Expect(out.String()).To(Equal(`NAME           VERSION  RESOURCES  SIZE
+example.com/a  v1.0.0   2          3 KiB
+example.com/b  v0.0.1   0          1.50 KiB
+`))
+	})
+
+	It("should list the components of a gzipped ctf", func() {
+		addOpts := cmd.AddOptions{
+			CTFPath:           "/component.ctf.tgz",
+			ArchiveFormat:     ctf.ArchiveFormatTarGzip,
+			ComponentArchives: []string{"/b-ca", "/a-ca"},
+		}
+		Expect(addOpts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		opts := cmd.ListOptions{CTFPath: "/component.ctf.tgz"}
+		entries, err := opts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Name).To(Equal("example.com/a"))
+		Expect(entries[0].Resources).To(Equal(2))
+		Expect(entries[1].Name).To(Equal("example.com/b"))
+	})
+
+	It("should render the list as json", func() {
+		addOpts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/a-ca"},
+		}
+		Expect(addOpts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		opts := cmd.ListOptions{CTFPath: "/component.ctf"}
+		entries, err := opts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+
+		out := &bytes.Buffer{}
+		Expect(cmd.WriteList(out, entries, cmd.JSONOutput)).To(Succeed())
+		Expect(out.String()).To(MatchJSON(fmt.Sprintf(`[{"name": "example.com/a", "version": "v1.0.0", "resources": 2, "size": %d}]`, entries[0].Size)))
+		actual := []cmd.ListEntry{}
+		Expect(json.Unmarshal(out.Bytes(), &actual)).To(Succeed())
+		Expect(actual).To(Equal(entries))
+	})
+
+	It("should handle an empty ctf", func() {
+		Expect(vfs.WriteFile(fs, "/empty.ctf", []byte{}, os.ModePerm)).To(Succeed())
+
+		opts := cmd.ListOptions{CTFPath: "/empty.ctf"}
+		entries, err := opts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+
+		out := &bytes.Buffer{}
+		Expect(cmd.WriteList(out, entries, cmd.JSONOutput)).To(Succeed())
+		Expect(out.String()).To(MatchJSON(`[]`))
+	})
+
+})
+
+// tarEntrySizes returns the sizes of all entries of the tar at the given path by their name.
+func tarEntrySizes(fs vfs.FileSystem, path string) map[string]int64 {
+	file, err := fs.Open(path)
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+	sizes := map[string]int64{}
+	tr := tar.NewReader(file)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return sizes
+		}
+		Expect(err).ToNot(HaveOccurred())
+		sizes[header.Name] = header.Size
+	}
+}
@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// ConflictStrategy defines how components that are contained in multiple ctfs are merged.
+type ConflictStrategy string
+
+const (
+	// ConflictSkip keeps the component of the first ctf that contains it.
+	ConflictSkip ConflictStrategy = "skip"
+	// ConflictOverwrite uses the component of the last ctf that contains it.
+	ConflictOverwrite ConflictStrategy = "overwrite"
+	// ConflictFail fails the merge if a component is contained in multiple ctfs.
+	ConflictFail ConflictStrategy = "fail"
+)
+
+// MergeOptions defines the options that are used to merge multiple ctfs.
+type MergeOptions struct {
+	// CTFPaths are the paths to the ctfs that are merged.
+	CTFPaths []string
+	// OutputPath is the path to the resulting ctf.
+	OutputPath string
+	// OnConflict defines the strategy for components that are contained in multiple ctfs.
+	OnConflict string
+	// ArchiveFormat defines the component archive format of the resulting ctf.
+	ArchiveFormat ctf.ArchiveFormat
+	// Directory writes the resulting ctf in the directory layout.
+	Directory bool
+}
+
+// MergeConflict describes a component that is contained in multiple ctfs.
+type MergeConflict struct {
+	// Component is the component in the form name:version.
+	Component string
+	// CTFPath is the path of the ctf that contains the conflicting component.
+	CTFPath string
+	// Resolution describes how the conflict was resolved.
+	Resolution ConflictStrategy
+}
+
+// NewMergeCommand creates a new command to merge multiple ctfs.
+func NewMergeCommand(ctx context.Context) *cobra.Command {
+	opts := &MergeOptions{}
+	cmd := &cobra.Command{
+		Use:   "merge CTF_PATH CTF_PATH... --output OUTPUT_PATH",
+		Args:  cobra.MinimumNArgs(2),
+		Short: "Merges multiple ctfs into one ctf",
+		Long: `
+Merges the component archives of multiple ctfs into a new ctf.
+The ctfs can be plain, gzipped or zstd compressed tars or directories.
+The merged ctf is compressed according to its .tar.gz, .tgz, .tar.zst or .tzst extension.
+
+Components with the same name and version that are contained in multiple ctfs are conflicts that are resolved with the strategy defined by --on-conflict:
+- skip: the component of the first ctf is used.
+- overwrite: the component of the last ctf is used.
+- fail: the merge fails.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			conflicts, err := opts.Merge(ctx, logger.Log, osfs.New())
+			for _, conflict := range conflicts {
+				fmt.Printf("%s from %q: %s\n", conflict.Component, conflict.CTFPath, conflict.Resolution)
+			}
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			fmt.Printf("Successfully merged ctfs into %q\n", opts.OutputPath)
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *MergeOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	_, err := o.Merge(ctx, log, fs)
+	return err
+}
+
+// Merge merges the ctfs and returns all conflicts with their resolution.
+// On a failed merge all conflicts that lead to the failure are returned.
+func (o *MergeOptions) Merge(_ context.Context, log logr.Logger, fs vfs.FileSystem) ([]MergeConflict, error) {
+	var (
+		keys []string
+		// sources are the paths of the ctfs the components are taken from by their component name and version
+		sources   = map[string]string{}
+		conflicts []MergeConflict
+	)
+	for _, ctfPath := range o.CTFPaths {
+		err := walkCTF(fs, ctfPath, func(ca *ctf.ComponentArchive) error {
+			key := componentKey(ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion())
+			if _, ok := sources[key]; !ok {
+				keys = append(keys, key)
+				sources[key] = ctfPath
+				return nil
+			}
+
+			conflict := MergeConflict{
+				Component:  key,
+				CTFPath:    ctfPath,
+				Resolution: ConflictStrategy(o.OnConflict),
+			}
+			conflicts = append(conflicts, conflict)
+			log.V(3).Info(fmt.Sprintf("component %s of %q is already defined: %s", key, ctfPath, o.OnConflict))
+			if conflict.Resolution == ConflictOverwrite {
+				sources[key] = ctfPath
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ctf %q: %w", ctfPath, err)
+		}
+	}
+
+	if ConflictStrategy(o.OnConflict) == ConflictFail && len(conflicts) != 0 {
+		return conflicts, fmt.Errorf("%d component(s) are contained in multiple ctfs", len(conflicts))
+	}
+
+	// the component archives are read again from the ctfs they are taken from and written one after another.
+	w, err := newCTFWriter(fs, o.OutputPath, o.ArchiveFormat, o.Directory)
+	if err != nil {
+		return conflicts, err
+	}
+	defer w.Abort()
+	written := sets.NewString()
+	for _, ctfPath := range o.CTFPaths {
+		err := walkCTF(fs, ctfPath, func(ca *ctf.ComponentArchive) error {
+			key := componentKey(ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion())
+			if sources[key] != ctfPath || written.Has(key) {
+				return nil
+			}
+			written.Insert(key)
+			return w.Add(ca)
+		})
+		if err != nil {
+			return conflicts, fmt.Errorf("unable to read ctf %q: %w", ctfPath, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return conflicts, fmt.Errorf("unable to write merged ctf: %w", err)
+	}
+	log.Info(fmt.Sprintf("Merged %d component(s) into %q", len(keys), o.OutputPath))
+	return conflicts, nil
+}
+
+func (o *MergeOptions) Complete(args []string) error {
+	o.CTFPaths = args
+	return o.Validate()
+}
+
+// Validate validates the merge options
+func (o *MergeOptions) Validate() error {
+	if len(o.CTFPaths) < 2 {
+		return errors.New("at least two ctfs must be provided")
+	}
+	if len(o.OutputPath) == 0 {
+		return errors.New("an output path must be provided")
+	}
+	for _, ctfPath := range o.CTFPaths {
+		if ctfPath == o.OutputPath {
+			return fmt.Errorf("the output path %q must not be one of the merged ctfs", o.OutputPath)
+		}
+	}
+	switch ConflictStrategy(o.OnConflict) {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+	default:
+		return fmt.Errorf("unknown conflict strategy %q, expected one of %q, %q, %q", o.OnConflict, ConflictSkip, ConflictOverwrite, ConflictFail)
+	}
+	if o.ArchiveFormat != ctf.ArchiveFormatTar &&
+		o.ArchiveFormat != ctf.ArchiveFormatTarGzip {
+		return fmt.Errorf("unsupported archive format %q", o.ArchiveFormat)
+	}
+	return nil
+}
+
+func (o *MergeOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.OutputPath, "output", "o", "", "path to the merged ctf")
+	fs.StringVar(&o.OnConflict, "on-conflict", string(ConflictFail),
+		fmt.Sprintf("strategy for components that are contained in multiple ctfs. One of %q, %q, %q", ConflictSkip, ConflictOverwrite, ConflictFail))
+	componentarchive.OutputFormatVar(fs, &o.ArchiveFormat, "format", ctf.ArchiveFormatTar,
+		componentarchive.ArchiveOutputFormatUsage)
+	fs.BoolVar(&o.Directory, "directory", false,
+		"write the merged ctf in the directory layout. Existing directories are always written in the directory layout")
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+)
+
+var _ = Describe("Merge", func() {
+
+	var fs vfs.FileSystem
+
+	// addComponent adds a component of version v0.0.0 with the given provider to the ctf.
+	addComponent := func(ctfPath, name string, provider cdv2.ProviderType) {
+		cd := fmt.Sprintf(`meta:
+  schemaVersion: 'v2'
+component:
+  name: '%s'
+  version: 'v0.0.0'
+  repositoryContexts: []
+  provider: '%s'
+  sources: []
+  componentReferences: []
+  resources: []
+`, name, provider)
+		path := filepath.Join("/components", ctfPath, name)
+		Expect(fs.MkdirAll(path, os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, filepath.Join(path, ctf.ComponentDescriptorFileName), []byte(cd), os.ModePerm)).To(Succeed())
+		opts := cmd.AddOptions{
+			CTFPath:           ctfPath,
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{path},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	}
+
+	readProviders := func(ctfPath string) map[string]cdv2.ProviderType {
+		ctfArchive, err := ctf.NewCTF(fs, ctfPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		providers := map[string]cdv2.ProviderType{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			providers[ca.ComponentDescriptor.Name] = ca.ComponentDescriptor.Provider
+			return nil
+		})).To(Succeed())
+		return providers
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		addComponent("/a.ctf", "example.com/a", cdv2.InternalProvider)
+		addComponent("/a.ctf", "example.com/shared", cdv2.InternalProvider)
+		addComponent("/b.ctf", "example.com/b", cdv2.ExternalProvider)
+		addComponent("/b.ctf", "example.com/shared", cdv2.ExternalProvider)
+	})
+
+	It("should keep the component of the first ctf on conflicts with the skip strategy", func() {
+		opts := cmd.MergeOptions{
+			CTFPaths:      []string{"/a.ctf", "/b.ctf"},
+			OutputPath:    "/merged.ctf",
+			OnConflict:    string(cmd.ConflictSkip),
+			ArchiveFormat: ctf.ArchiveFormatTar,
+		}
+		conflicts, err := opts.Merge(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conflicts).To(ConsistOf(cmd.MergeConflict{
+			Component:  "example.com/shared:v0.0.0",
+			CTFPath:    "/b.ctf",
+			Resolution: cmd.ConflictSkip,
+		}))
+		Expect(readProviders(opts.OutputPath)).To(Equal(map[string]cdv2.ProviderType{
+			"example.com/a":      cdv2.InternalProvider,
+			"example.com/b":      cdv2.ExternalProvider,
+			"example.com/shared": cdv2.InternalProvider,
+		}))
+	})
+
+	It("should use the component of the last ctf on conflicts with the overwrite strategy", func() {
+		opts := cmd.MergeOptions{
+			CTFPaths:      []string{"/a.ctf", "/b.ctf"},
+			OutputPath:    "/merged.ctf",
+			OnConflict:    string(cmd.ConflictOverwrite),
+			ArchiveFormat: ctf.ArchiveFormatTar,
+		}
+		_, err := opts.Merge(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readProviders(opts.OutputPath)).To(HaveKeyWithValue("example.com/shared", cdv2.ExternalProvider))
+	})
+
+	It("should fail on conflicts with the fail strategy", func() {
+		opts := cmd.MergeOptions{
+			CTFPaths:      []string{"/a.ctf", "/b.ctf"},
+			OutputPath:    "/merged.ctf",
+			OnConflict:    string(cmd.ConflictFail),
+			ArchiveFormat: ctf.ArchiveFormatTar,
+		}
+		conflicts, err := opts.Merge(context.TODO(), logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		Expect(conflicts).To(HaveLen(1))
+		_, err = fs.Stat(opts.OutputPath)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should merge compressed ctfs into a ctf compressed according to its extension", func() {
+		addComponent("/c.tgz", "example.com/c", cdv2.InternalProvider)
+		addComponent("/d.tar.zst", "example.com/d", cdv2.InternalProvider)
+		opts := cmd.MergeOptions{
+			CTFPaths:      []string{"/c.tgz", "/d.tar.zst"},
+			OutputPath:    "/merged.tar.zst",
+			OnConflict:    string(cmd.ConflictFail),
+			ArchiveFormat: ctf.ArchiveFormatTar,
+		}
+		_, err := opts.Merge(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(zstdTarEntries(fs, opts.OutputPath)).To(ConsistOf("example.com_c-v0.0.0.tar", "example.com_d-v0.0.0.tar"))
+	})
+
+})
@@ -0,0 +1,321 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// PruneOptions defines the options that are used to prune the unreferenced blobs of a ctf.
+type PruneOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+	// ArchiveFormat defines the format of the pruned component archives.
+	ArchiveFormat ctf.ArchiveFormat
+	// DryRun only reports the unreferenced blobs without modifying the ctf.
+	DryRun bool
+}
+
+// PruneSummary describes the result of a prune.
+type PruneSummary struct {
+	// Components is the number of components of the ctf.
+	Components int
+	// PrunedComponents is the number of components with unreferenced blobs.
+	PrunedComponents int
+	// Blobs are the unreferenced blobs of all components.
+	Blobs []PrunedBlob
+	// Bytes is the size of all unreferenced blobs.
+	Bytes int64
+	// DryRun is set if the unreferenced blobs were only detected but not removed.
+	DryRun bool
+}
+
+// PrunedBlob describes an unreferenced blob of a component archive.
+type PrunedBlob struct {
+	Name    string
+	Version string
+	// Blob is the filename of the blob in the component archive.
+	Blob string
+	Size int64
+}
+
+// NewPruneCommand creates a new command to prune the unreferenced blobs of a ctf.
+func NewPruneCommand(ctx context.Context) *cobra.Command {
+	opts := &PruneOptions{}
+	cmd := &cobra.Command{
+		Use:   "prune CTF_PATH",
+		Args:  cobra.ExactArgs(1),
+		Short: "Removes the blobs of a ctf that are not referenced by any resource or source",
+		Long: `
+Removes the blobs of the component archives of a ctf that are not referenced by any resource or source
+with a local filesystem blob access, e.g. blobs that were left over after a resource has been removed.
+
+Only the component archives that contain unreferenced blobs are rewritten, their component descriptors are not modified.
+All other component archives are copied without modification. The ctf keeps its compression and its index.
+Use --dry-run to only report the unreferenced blobs without modifying the ctf.
+
+In contrast to "ctf repack", blobs that are referenced multiple times or that are identical to other blobs are kept.
+`,
+		Example: `
+component-cli ctf prune ./delivery.ctf --dry-run
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *PruneOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	summary, err := o.Prune(ctx, log, fs)
+	if err != nil {
+		return err
+	}
+	WritePruneSummary(os.Stdout, summary)
+	return nil
+}
+
+// Prune removes the unreferenced blobs of all component archives of the ctf.
+func (o *PruneOptions) Prune(_ context.Context, log logr.Logger, fs vfs.FileSystem) (*PruneSummary, error) {
+	isDir, err := isDirectoryCTF(fs, o.CTFPath)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &PruneSummary{DryRun: o.DryRun}
+	// pruned are the component archives without their unreferenced blobs by their component name and version
+	pruned := map[string]*ctf.ComponentArchive{}
+	err = walkCTF(fs, o.CTFPath, func(ca *ctf.ComponentArchive) error {
+		summary.Components++
+		cd := ca.ComponentDescriptor
+		prunedCa, blobs, err := pruneComponentArchive(ca)
+		if err != nil {
+			return fmt.Errorf("unable to prune component %s: %w", componentKey(cd.GetName(), cd.GetVersion()), err)
+		}
+		if len(blobs) == 0 {
+			return nil
+		}
+		for _, blob := range blobs {
+			log.V(3).Info(fmt.Sprintf("Blob %q of component %s is not referenced", blob.Blob, componentKey(cd.GetName(), cd.GetVersion())))
+			summary.Bytes += blob.Size
+		}
+		summary.PrunedComponents++
+		summary.Blobs = append(summary.Blobs, blobs...)
+		if !o.DryRun {
+			pruned[componentKey(cd.GetName(), cd.GetVersion())] = prunedCa
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pruned) == 0 {
+		return summary, nil
+	}
+
+	if isDir {
+		for _, ca := range pruned {
+			if err := writeDirectoryComponentArchive(fs, o.CTFPath, ca); err != nil {
+				return nil, fmt.Errorf("unable to write pruned component archive: %w", err)
+			}
+		}
+	} else if err := o.replaceTarEntries(fs, pruned); err != nil {
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Removed %d unreferenced blob(s) of %d component(s)", len(summary.Blobs), summary.PrunedComponents))
+	return summary, nil
+}
+
+// replaceTarEntries rewrites the tar ctf with the given component archives by their component name and version.
+// The component archives keep the names of their ctf entries, all other entries are copied.
+func (o *PruneOptions) replaceTarEntries(fs vfs.FileSystem, archives map[string]*ctf.ComponentArchive) error {
+	cds, err := ctfComponentDescriptors(fs, o.CTFPath)
+	if err != nil {
+		return err
+	}
+	entries := map[string]string{}
+	for name, cd := range cds {
+		entries[componentKey(cd.GetName(), cd.GetVersion())] = name
+	}
+	// replaced are the component archives by the names of their ctf entries
+	replaced := map[string]*ctf.ComponentArchive{}
+	for key, ca := range archives {
+		name, ok := entries[key]
+		if !ok {
+			return fmt.Errorf("component %s is not part of the ctf", key)
+		}
+		replaced[name] = ca
+	}
+	names := sets.StringKeySet(replaced)
+
+	c, err := detectCompression(fs, o.CTFPath)
+	if err != nil {
+		return err
+	}
+	var previous *ctfIndex
+	if c == noCompression {
+		if previous, err = readCTFIndex(fs, o.CTFPath); err != nil {
+			return err
+		}
+	}
+	err = rewriteTarCTF(fs, o.CTFPath, c, names, func(tw *tar.Writer) error {
+		for _, name := range names.List() {
+			if err := newComponentArchiveSource(replaced[name]).writeEntry(fs, tw, name, o.ArchiveFormat); err != nil {
+				return fmt.Errorf("unable to write pruned component archive %q: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		return nil
+	}
+	if err := writeCTFIndex(fs, o.CTFPath, previous, names); err != nil {
+		return fmt.Errorf("unable to write the index of the ctf: %w", err)
+	}
+	return nil
+}
+
+// pruneComponentArchive returns a copy of the component archive without the blobs
+// that are not referenced by any resource or source and the removed blobs.
+func pruneComponentArchive(ca *ctf.ComponentArchive) (*ctf.ComponentArchive, []PrunedBlob, error) {
+	caFs := memoryfs.New()
+	if err := ca.WriteToFilesystem(caFs, "/"); err != nil {
+		return nil, nil, fmt.Errorf("unable to read component archive: %w", err)
+	}
+	cd := ca.ComponentDescriptor
+
+	referenced := sets.NewString()
+	addReference := func(access *cdv2.UnstructuredTypedObject) error {
+		if access == nil || access.GetType() != cdv2.LocalFilesystemBlobType {
+			return nil
+		}
+		blobAccess := &cdv2.LocalFilesystemBlobAccess{}
+		if err := access.DecodeInto(blobAccess); err != nil {
+			return fmt.Errorf("unable to decode access: %w", err)
+		}
+		referenced.Insert(blobAccess.Filename)
+		return nil
+	}
+	for _, res := range cd.Resources {
+		if err := addReference(res.Access); err != nil {
+			return nil, nil, fmt.Errorf("resource %q: %w", res.GetName(), err)
+		}
+	}
+	for _, src := range cd.Sources {
+		if err := addReference(src.Access); err != nil {
+			return nil, nil, fmt.Errorf("source %q: %w", src.GetName(), err)
+		}
+	}
+
+	infos, err := vfs.ReadDir(caFs, ctf.BlobsDirectoryName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ca, nil, nil
+		}
+		return nil, nil, fmt.Errorf("unable to read blobs: %w", err)
+	}
+	blobs := []PrunedBlob{}
+	for _, info := range infos {
+		if referenced.Has(info.Name()) {
+			continue
+		}
+		size, err := pathSize(caFs, ctf.BlobPath(info.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := removeAll(caFs, ctf.BlobPath(info.Name())); err != nil {
+			return nil, nil, fmt.Errorf("unable to remove blob %q: %w", info.Name(), err)
+		}
+		blobs = append(blobs, PrunedBlob{
+			Name:    cd.GetName(),
+			Version: cd.GetVersion(),
+			Blob:    info.Name(),
+			Size:    size,
+		})
+	}
+	if len(blobs) == 0 {
+		return ca, nil, nil
+	}
+	return ctf.NewComponentArchive(cd, caFs), blobs, nil
+}
+
+// WritePruneSummary writes the summary of a prune.
+func WritePruneSummary(w io.Writer, summary *PruneSummary) {
+	blobs := append([]PrunedBlob{}, summary.Blobs...)
+	sort.Slice(blobs, func(i, j int) bool {
+		if blobs[i].Name != blobs[j].Name {
+			return blobs[i].Name < blobs[j].Name
+		}
+		if blobs[i].Version != blobs[j].Version {
+			return blobs[i].Version < blobs[j].Version
+		}
+		return blobs[i].Blob < blobs[j].Blob
+	})
+	for _, blob := range blobs {
+		fmt.Fprintf(w, "%s: %s (%s)\n", componentKey(blob.Name, blob.Version), blob.Blob, bytesString(blob.Size))
+	}
+	action := "Removed"
+	if summary.DryRun {
+		action = "Found"
+	}
+	fmt.Fprintf(w, "%s %d unreferenced blob(s) (%s) in %d of %d component(s)\n",
+		action, len(blobs), bytesString(summary.Bytes), summary.PrunedComponents, summary.Components)
+}
+
+func (o *PruneOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the prune options
+func (o *PruneOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if o.ArchiveFormat != ctf.ArchiveFormatTar &&
+		o.ArchiveFormat != ctf.ArchiveFormatTarGzip {
+		return fmt.Errorf("unsupported archive format %q", o.ArchiveFormat)
+	}
+	return nil
+}
+
+func (o *PruneOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.DryRun, "dry-run", false, "[OPTIONAL] only report the unreferenced blobs without modifying the ctf")
+	componentarchive.OutputFormatVar(fs, &o.ArchiveFormat, "format", ctf.ArchiveFormatTar,
+		componentarchive.ArchiveOutputFormatUsage)
+}
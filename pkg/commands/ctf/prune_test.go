@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("Prune", func() {
+
+	var fs vfs.FileSystem
+
+	addComponents := func(ctfPath string, opts cmd.AddOptions) {
+		opts.CTFPath = ctfPath
+		opts.ArchiveFormat = ctf.ArchiveFormatTar
+		opts.ComponentArchives = []string{"/a", "/b"}
+		opts.Parallel = 1
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	}
+
+	prune := func(ctfPath string, dryRun bool) *cmd.PruneSummary {
+		opts := cmd.PruneOptions{CTFPath: ctfPath, ArchiveFormat: ctf.ArchiveFormatTar, DryRun: dryRun}
+		summary, err := opts.Prune(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		return summary
+	}
+
+	// blobs returns the names of the blobs of the component archive of the given component.
+	blobs := func(ctfPath, name string) []string {
+		opts := cmd.GetOptions{CTFPath: ctfPath, Name: name}
+		ca, err := opts.Get(fs)
+		Expect(err).ToNot(HaveOccurred())
+		caFs := memoryfs.New()
+		Expect(ca.WriteToFilesystem(caFs, "/")).To(Succeed())
+		infos, err := vfs.ReadDir(caFs, ctf.BlobsDirectoryName)
+		Expect(err).ToNot(HaveOccurred())
+		names := []string{}
+		for _, info := range infos {
+			names = append(names, info.Name())
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a", "example.com/a", "v1.0.0", "blob.txt", true)).To(Succeed())
+		Expect(vfs.WriteFile(fs, filepath.Join("/a", ctf.BlobPath("orphan.txt")), []byte("unreferenced"), os.ModePerm)).To(Succeed())
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/b", "example.com/b", "v1.0.0", "blob.txt", true)).To(Succeed())
+	})
+
+	It("should remove the unreferenced blobs of a ctf", func() {
+		addComponents("/component.ctf", cmd.AddOptions{})
+		entryB := utils.CTFComponentArchiveFilename("example.com/b", "v1.0.0")
+		sizeB := tarEntrySizes(fs, "/component.ctf")[entryB]
+
+		summary := prune("/component.ctf", false)
+		Expect(summary.Components).To(Equal(2))
+		Expect(summary.PrunedComponents).To(Equal(1))
+		Expect(summary.Blobs).To(Equal([]cmd.PrunedBlob{{Name: "example.com/a", Version: "v1.0.0", Blob: "orphan.txt", Size: 12}}))
+		Expect(summary.Bytes).To(Equal(int64(12)))
+
+		Expect(blobs("/component.ctf", "example.com/a")).To(Equal([]string{"blob.txt"}))
+		Expect(blobs("/component.ctf", "example.com/b")).To(Equal([]string{"blob.txt"}))
+		Expect(tarEntrySizes(fs, "/component.ctf")[entryB]).To(Equal(sizeB))
+
+		summary = prune("/component.ctf", false)
+		Expect(summary.Blobs).To(BeEmpty())
+
+		out := &bytes.Buffer{}
+		cmd.WritePruneSummary(out, &cmd.PruneSummary{Components: 2})
+		Expect(out.String()).To(Equal("Removed 0 unreferenced blob(s) (0) in 0 of 2 component(s)\n"))
+	})
+
+	It("should only report the unreferenced blobs with --dry-run", func() {
+		addComponents("/component.ctf", cmd.AddOptions{})
+		before, err := vfs.ReadFile(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+
+		summary := prune("/component.ctf", true)
+		Expect(summary.Blobs).To(HaveLen(1))
+		Expect(summary.Blobs[0].Blob).To(Equal("orphan.txt"))
+
+		after, err := vfs.ReadFile(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(after).To(Equal(before))
+
+		out := &bytes.Buffer{}
+		cmd.WritePruneSummary(out, summary)
+		Expect(out.String()).To(ContainSubstring("example.com/a:v1.0.0: orphan.txt"))
+		Expect(out.String()).To(ContainSubstring("Found 1 unreferenced blob(s)"))
+	})
+
+	It("should remove the unreferenced blobs of a zstd compressed ctf", func() {
+		addComponents("/component.tar.zst", cmd.AddOptions{})
+
+		Expect(prune("/component.tar.zst", false).Blobs).To(HaveLen(1))
+		Expect(zstdTarEntries(fs, "/component.tar.zst")).To(HaveLen(2))
+		Expect(blobs("/component.tar.zst", "example.com/a")).To(Equal([]string{"blob.txt"}))
+	})
+
+	It("should keep the index of an indexed ctf", func() {
+		addComponents("/component.ctf", cmd.AddOptions{Index: true})
+
+		Expect(prune("/component.ctf", false).Blobs).To(HaveLen(1))
+		Expect(tarEntries(fs, "/component.ctf")).To(HaveLen(2))
+		index, err := vfs.ReadFile(fs, "/component.ctf.index.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(index)).To(ContainSubstring(utils.CTFComponentArchiveFilename("example.com/a", "v1.0.0")))
+		Expect(blobs("/component.ctf", "example.com/a")).To(Equal([]string{"blob.txt"}))
+	})
+
+	It("should remove the unreferenced blobs of a ctf in the directory layout", func() {
+		addComponents("/component", cmd.AddOptions{Directory: true})
+
+		Expect(prune("/component", false).Blobs).To(HaveLen(1))
+		_, err := fs.Stat(filepath.Join("/component", "example.com_a-v1.0.0", ctf.BlobPath("orphan.txt")))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		_, err = fs.Stat(filepath.Join("/component", "example.com_a-v1.0.0", ctf.BlobPath("blob.txt")))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,270 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+type PullOptions struct {
+	// CTFPath is the path to the ctf archive that is created.
+	CTFPath string
+	// BaseUrl is the oci registry where the components are stored.
+	BaseUrl string
+	// ComponentNameMapping is the component name mapping of the repository context.
+	ComponentNameMapping string
+	// Components are the components to pull in the format NAME:VERSION.
+	Components []string
+	// ComponentsFile is the path to a file that contains additional components to pull.
+	ComponentsFile string
+	// ArchiveFormat defines the component archive format of a component descriptor
+	ArchiveFormat ctf.ArchiveFormat
+	// Compress writes the ctf as gzipped tar.
+	Compress bool
+	// Compression is the compression of the ctf, either gzip or zstd. It takes precedence over Compress.
+	Compression string
+	// Overwrite replaces an already existing ctf.
+	Overwrite bool
+	// Directory writes the ctf in the directory layout.
+	Directory bool
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+	// ComponentResolver resolves the components and their blobs.
+	// Defaults to a resolver for the oci registry configured by the oci options.
+	ComponentResolver ctf.ComponentResolver
+}
+
+// NewPullCommand creates a new command to pull components from a registry into a ctf.
+func NewPullCommand(ctx context.Context) *cobra.Command {
+	opts := &PullOptions{}
+	cmd := &cobra.Command{
+		Use:   "pull CTF_PATH [NAME:VERSION...] --repo-ctx BASE_URL",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "Pulls components including their local blobs from a registry into a new ctf",
+		Long: `
+Pull fetches the given components with all local blobs of their resources from the oci registry
+and assembles them in a new CTF archive, e.g. for a transfer to an offline environment.
+
+The components are defined as arguments in the format NAME:VERSION or in a file with "--components-file".
+The file is either a yaml list or contains one component per line. Lines starting with "#" are ignored.
+
+Local blobs are added to the component archives and their access is converted to "localFilesystemBlob".
+Resources with other access types, e.g. oci images, are not fetched and keep their access.
+
+The ctf is only written if all components could be pulled.
+A ctf with a .tar.gz or .tgz extension is written as gzipped tar,
+a ctf with a .tar.zst or .tzst extension is written as zstd compressed tar.
+With "--directory" the ctf is written in the directory layout with one subdirectory per component archive.
+`,
+		Example: `
+component-cli ctf pull ./ctf.tar --repo-ctx eu.gcr.io/my-project/components github.com/gardener/example:v0.1.0
+component-cli ctf pull ./ctf.tgz --repo-ctx eu.gcr.io/my-project/components --components-file ./components.txt
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			fmt.Print("Successfully pulled components into ctf\n")
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *PullOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	directory := o.Directory
+	if info, err := fs.Stat(o.CTFPath); err == nil {
+		if !o.Overwrite {
+			return fmt.Errorf("%q already exists. Use --overwrite to replace it", o.CTFPath)
+		}
+		directory = directory || info.IsDir()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to get info for %s: %w", o.CTFPath, err)
+	}
+
+	components := append([]string{}, o.Components...)
+	if len(o.ComponentsFile) != 0 {
+		fileComponents, err := readArchivesFile(fs, o.ComponentsFile)
+		if err != nil {
+			return err
+		}
+		components = append(components, fileComponents...)
+	}
+	if len(components) == 0 {
+		return errors.New("no components to pull")
+	}
+
+	resolver := o.ComponentResolver
+	if resolver == nil {
+		ociClient, _, err := o.OciOptions.Build(log, fs)
+		if err != nil {
+			return fmt.Errorf("unable to build oci client: %s", err.Error())
+		}
+		resolver = cdoci.NewResolver(ociClient)
+	}
+	repoCtx := cdv2.NewOCIRegistryRepository(o.BaseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping))
+
+	var (
+		w   *ctfWriter
+		err error
+	)
+	if directory {
+		w, err = newCTFWriter(fs, o.CTFPath, o.ArchiveFormat, true)
+	} else {
+		var compress compression
+		if compress, err = parseCompression(o.Compress, o.Compression); err != nil {
+			return err
+		}
+		if compress == noCompression {
+			compress = compressionFromExtension(o.CTFPath)
+		}
+		w, err = newTarCTFWriter(fs, o.CTFPath, o.ArchiveFormat, compress)
+	}
+	if err != nil {
+		return err
+	}
+	// the ctf is only written when all components have been fetched so that no incomplete ctf is left behind.
+	defer w.Abort()
+	pulled := map[string]bool{}
+	for i, component := range components {
+		name, version, err := parseComponent(component)
+		if err != nil {
+			return err
+		}
+		filename := utils.CTFComponentArchiveFilename(name, version)
+		if pulled[filename] {
+			log.V(3).Info("Component is defined multiple times", "component", name, "version", version)
+			continue
+		}
+		pulled[filename] = true
+
+		ca, err := pullComponentArchive(ctx, resolver, repoCtx, name, version)
+		if err != nil {
+			return err
+		}
+		if err := w.Add(ca); err != nil {
+			return err
+		}
+		log.Info("Pulled component",
+			"component", name,
+			"version", version,
+			"progress", fmt.Sprintf("%d/%d", i+1, len(components)))
+	}
+	return w.Close()
+}
+
+// pullComponentArchive resolves a component and creates an in-memory component archive
+// that contains the local blobs of all its resources.
+func pullComponentArchive(ctx context.Context, resolver ctf.ComponentResolver, repoCtx cdv2.Repository, name, version string) (*ctf.ComponentArchive, error) {
+	cd, blobResolver, err := resolver.ResolveWithBlobResolver(ctx, repoCtx, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve component %q in version %q: %w", name, version, err)
+	}
+
+	ca := ctf.NewComponentArchive(cd, memoryfs.New())
+	for _, res := range cd.Resources {
+		if res.Access == nil {
+			continue
+		}
+		switch res.Access.GetType() {
+		case cdv2.LocalOCIBlobType, cdv2.LocalFilesystemBlobType:
+		default:
+			continue
+		}
+		res := res
+		if err := ca.AddResourceFromResolver(ctx, &res, blobResolver); err != nil {
+			return nil, fmt.Errorf("unable to pull local blob of resource %q of component %q in version %q: %w", res.GetName(), name, version, err)
+		}
+	}
+	return ca, nil
+}
+
+func (o *PullOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	o.Components = append(o.Components, args[1:]...)
+
+	var err error
+	o.OciOptions.CacheDir, err = utils.CacheDir()
+	if err != nil {
+		return fmt.Errorf("unable to get oci cache directory: %w", err)
+	}
+
+	return o.Validate()
+}
+
+// Validate validates the pull options.
+func (o *PullOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if len(o.BaseUrl) == 0 {
+		return errors.New("the base url must be provided with --repo-ctx")
+	}
+	if len(o.Components) == 0 && len(o.ComponentsFile) == 0 {
+		return errors.New("at least one component must be provided as argument or with --components-file")
+	}
+	for _, component := range o.Components {
+		if _, _, err := parseComponent(component); err != nil {
+			return err
+		}
+	}
+	if o.ArchiveFormat != ctf.ArchiveFormatTar &&
+		o.ArchiveFormat != ctf.ArchiveFormatTarGzip {
+		return fmt.Errorf("unsupported archive format %q", o.ArchiveFormat)
+	}
+	compress, err := parseCompression(o.Compress, o.Compression)
+	if err != nil {
+		return err
+	}
+	if o.Directory && compress != noCompression {
+		return errors.New("a ctf in the directory layout cannot be compressed")
+	}
+	return nil
+}
+
+func (o *PullOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.BaseUrl, "repo-ctx", "", "base url of the oci registry the components are pulled from")
+	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] repository context name mapping")
+	fs.StringVar(&o.ComponentsFile, "components-file", "",
+		"path to a file that contains a newline-delimited or yaml list of components in the format NAME:VERSION. Lines starting with '#' are ignored.")
+	componentarchive.OutputFormatVar(fs, &o.ArchiveFormat, "format", ctf.ArchiveFormatTar,
+		componentarchive.ArchiveOutputFormatUsage)
+	fs.BoolVar(&o.Compress, "compress", false,
+		"write the ctf as gzipped tar. Ctfs with a .tar.gz or .tgz extension are always compressed")
+	fs.StringVar(&o.Compression, "compression", "",
+		"compression of the ctf, either gzip or zstd. Defaults to the .tar.gz, .tgz, .tar.zst or .tzst extension of the ctf")
+	fs.BoolVar(&o.Overwrite, "overwrite", false, "replace the ctf if it already exists. Component archives of other components in a ctf directory are kept")
+	fs.BoolVar(&o.Directory, "directory", false,
+		"write the ctf in the directory layout with one subdirectory per component archive")
+	o.OciOptions.AddFlags(fs)
+}
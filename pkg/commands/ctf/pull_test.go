@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+)
+
+// fakeResolver resolves components from in-memory component archives.
+type fakeResolver map[string]*ctf.ComponentArchive
+
+func (r fakeResolver) Resolve(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	cd, _, err := r.ResolveWithBlobResolver(ctx, repoCtx, name, version)
+	return cd, err
+}
+
+func (r fakeResolver) ResolveWithBlobResolver(_ context.Context, _ cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	ca, ok := r[name+":"+version]
+	if !ok {
+		return nil, nil, ctf.NotFoundError
+	}
+	return ca.ComponentDescriptor.DeepCopy(), ca.BlobResolver, nil
+}
+
+var _ = Describe("Pull", func() {
+
+	var (
+		fs       vfs.FileSystem
+		resolver fakeResolver
+	)
+
+	newComponentArchive := func(name, version string, blobs ...string) *ctf.ComponentArchive {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Metadata.Version = cdv2.SchemaVersion
+		cd.Name = name
+		cd.Version = version
+		cd.Provider = cdv2.InternalProvider
+		cd.RepositoryContexts = []*cdv2.UnstructuredTypedObject{}
+		Expect(cdv2.InjectRepositoryContext(cd, cdv2.NewOCIRegistryRepository("example.com/components", ""))).To(Succeed())
+		ca := ctf.NewComponentArchive(cd, memoryfs.New())
+		for _, blob := range blobs {
+			data := []byte(fmt.Sprintf("%s %s %s", name, version, blob))
+			res := &cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: blob, Version: version, Type: "plain-text"},
+				Relation:           cdv2.LocalRelation,
+			}
+			Expect(ca.AddResource(res, ctf.BlobInfo{MediaType: "text/plain", Digest: blob, Size: int64(len(data))},
+				bytes.NewReader(data))).To(Succeed())
+		}
+		return ca
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		resolver = fakeResolver{
+			"example.com/a:v0.0.1": newComponentArchive("example.com/a", "v0.0.1", "blob-a"),
+			"example.com/b:v1.0.0": newComponentArchive("example.com/b", "v1.0.0"),
+		}
+	})
+
+	It("should pull the components with their local blobs into a new ctf", func() {
+		opts := cmd.PullOptions{
+			CTFPath:           "/component.ctf",
+			BaseUrl:           "example.com/components",
+			Components:        []string{"example.com/a:v0.0.1", "example.com/b:v1.0.0"},
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentResolver: resolver,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		ctfArchive, err := ctf.NewCTF(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		components := map[string]*ctf.ComponentArchive{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			components[ca.ComponentDescriptor.GetName()] = ca
+			return nil
+		})).To(Succeed())
+		Expect(components).To(HaveLen(2))
+
+		ca := components["example.com/a"]
+		Expect(ca.ComponentDescriptor.Resources).To(HaveLen(1))
+		var data bytes.Buffer
+		_, err = ca.BlobResolver.Resolve(context.TODO(), ca.ComponentDescriptor.Resources[0], &data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data.String()).To(Equal("example.com/a v0.0.1 blob-a"))
+	})
+
+	It("should read the components from a file", func() {
+		Expect(vfs.WriteFile(fs, "/components.txt", []byte("# components\nexample.com/b:v1.0.0\n"), 0644)).To(Succeed())
+		opts := cmd.PullOptions{
+			CTFPath:           "/component.ctf.tgz",
+			BaseUrl:           "example.com/components",
+			ComponentsFile:    "/components.txt",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentResolver: resolver,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		listOpts := cmd.ListOptions{CTFPath: "/component.ctf.tgz"}
+		entries, err := listOpts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	})
+
+	It("should not write a ctf if a component cannot be pulled", func() {
+		opts := cmd.PullOptions{
+			CTFPath:           "/component.ctf",
+			BaseUrl:           "example.com/components",
+			Components:        []string{"example.com/a:v0.0.1", "example.com/c:v1.0.0"},
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentResolver: resolver,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(MatchError(ContainSubstring(`unable to resolve component "example.com/c"`)))
+		_, err := fs.Stat("/component.ctf")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should not overwrite an existing ctf", func() {
+		Expect(vfs.WriteFile(fs, "/component.ctf", []byte{}, 0644)).To(Succeed())
+		opts := cmd.PullOptions{
+			CTFPath:           "/component.ctf",
+			BaseUrl:           "example.com/components",
+			Components:        []string{"example.com/b:v1.0.0"},
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentResolver: resolver,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(MatchError(ContainSubstring("already exists")))
+	})
+
+	It("should reject components without version", func() {
+		opts := cmd.PullOptions{
+			CTFPath:       "/component.ctf",
+			BaseUrl:       "example.com/components",
+			Components:    []string{"example.com/b"},
+			ArchiveFormat: ctf.ArchiveFormatTar,
+		}
+		Expect(opts.Validate()).To(MatchError(ContainSubstring("expected the form name:version")))
+	})
+
+})
@@ -6,9 +6,12 @@ package ctf
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"text/tabwriter"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/ctf"
@@ -19,9 +22,10 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
-	"github.com/gardener/component-cli/pkg/components"
-
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
 	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/components"
 	"github.com/gardener/component-cli/pkg/logger"
 	"github.com/gardener/component-cli/pkg/utils"
 )
@@ -33,11 +37,28 @@ type PushOptions struct {
 	BaseUrl string
 	// AdditionalTags defines additional tags that the oci artifact should be tagged with.
 	AdditionalTags []string
+	// FailFast stops the upload at the first component archive that cannot be uploaded.
+	FailFast bool
+	// Output defines the output format of the upload report.
+	Output string
+	// Writer is the writer the upload report is written to.
+	// Defaults to stdout.
+	Writer io.Writer
 
 	// OciOptions contains all exposed options to configure the oci client.
 	OciOptions ociopts.Options
 }
 
+// PushResult describes the upload of a component archive of a ctf.
+type PushResult struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Ref is the oci reference the component archive is uploaded to.
+	Ref string `json:"ref,omitempty"`
+	// Error is the reason why the component archive could not be uploaded.
+	Error string `json:"error,omitempty"`
+}
+
 // NewPushCommand creates a new definition command to push definitions
 func NewPushCommand(ctx context.Context) *cobra.Command {
 	opts := &PushOptions{}
@@ -49,6 +70,13 @@ func NewPushCommand(ctx context.Context) *cobra.Command {
 Push pushes all component archives and oci artifacts to the defined oci repository.
 
 The oci repository is automatically determined based on the component/artifact descriptor (repositoryContext, component name and version).
+The ctf can be a plain, gzipped or zstd compressed tar or a directory.
+
+All component archives are uploaded even if the upload of a single component archive fails, unless "--fail-fast" is set.
+A report with the result of every component archive is printed and the command fails if at least one upload failed.
+
+The component archives of an indexed ctf (see "ctf index") are read one after another at their offsets
+instead of extracting the whole ctf, every component archive is verified against the digest of the index.
 
 Note: Currently only component archives are supoprted. Generic OCI Artifacts will be supported in the future.
 `,
@@ -73,65 +101,112 @@ Note: Currently only component archives are supoprted. Generic OCI Artifacts wil
 }
 
 func (o *PushOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
-	info, err := fs.Stat(o.CTFPath)
-	if err != nil {
+	if _, err := fs.Stat(o.CTFPath); err != nil {
 		return fmt.Errorf("unable to get info for %s: %w", o.CTFPath, err)
 	}
-	if info.IsDir() {
-		return fmt.Errorf(`%q is a directory. 
-It is expected that the given path points to a CTF Archive`, o.CTFPath)
-	}
 
 	ociClient, cache, err := o.OciOptions.Build(log, fs)
 	if err != nil {
 		return fmt.Errorf("unable to build oci client: %s", err.Error())
 	}
 
-	ctfArchive, err := ctf.NewCTF(fs, o.CTFPath)
+	results := []PushResult{}
+	failed := 0
+	err = walkCTF(fs, o.CTFPath, func(ca *ctf.ComponentArchive) error {
+		result := PushResult{
+			Name:    ca.ComponentDescriptor.GetName(),
+			Version: ca.ComponentDescriptor.GetVersion(),
+		}
+		ref, err := o.push(ctx, log, ociClient, cache, ca)
+		result.Ref = ref
+		if err != nil {
+			log.Error(err, "unable to upload component archive", "name", result.Name, "version", result.Version)
+			result.Error = err.Error()
+			failed++
+		}
+		results = append(results, result)
+		if err != nil && o.FailFast {
+			return err
+		}
+		return nil
+	})
+	if err := o.writeReport(results); err != nil {
+		return err
+	}
 	if err != nil {
-		return fmt.Errorf("unable to open ctf at %q: %s", o.CTFPath, err.Error())
+		return fmt.Errorf("error while reading component archives in ctf: %w", err)
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d of %d component archives could not be uploaded", failed, len(results))
 	}
+	return nil
+}
 
-	err = ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
-		// update repository context
-		if len(o.BaseUrl) != 0 {
-			if err := cdv2.InjectRepositoryContext(ca.ComponentDescriptor, cdv2.NewOCIRegistryRepository(o.BaseUrl, "")); err != nil {
-				return fmt.Errorf("unable to add repository context: %w", err)
-			}
+// push uploads the component archive and returns the oci reference it is uploaded to.
+func (o *PushOptions) push(ctx context.Context, log logr.Logger, ociClient ociclient.Client, ociCache cache.Cache, ca *ctf.ComponentArchive) (string, error) {
+	// update repository context
+	if len(o.BaseUrl) != 0 {
+		if err := cdv2.InjectRepositoryContext(ca.ComponentDescriptor, cdv2.NewOCIRegistryRepository(o.BaseUrl, "")); err != nil {
+			return "", fmt.Errorf("unable to add repository context: %w", err)
 		}
+	}
 
-		manifest, err := cdoci.NewManifestBuilder(cache, ca).Build(ctx)
+	ref, err := components.OCIRef(ca.ComponentDescriptor.GetEffectiveRepositoryContext(), ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion())
+	if err != nil {
+		return "", fmt.Errorf("unable to calculate oci ref for %q: %s", ca.ComponentDescriptor.GetName(), err.Error())
+	}
+
+	manifest, err := cdoci.NewManifestBuilder(ociCache, ca).Build(ctx)
+	if err != nil {
+		return ref, fmt.Errorf("unable to build oci artifact for component acrchive: %w", err)
+	}
+
+	if err := ociClient.PushManifest(ctx, ref, manifest); err != nil {
+		return ref, fmt.Errorf("unable to upload component archive to %q: %s", ref, err.Error())
+	}
+	log.Info(fmt.Sprintf("Successfully uploaded component archive to %q", ref))
+
+	for _, tag := range o.AdditionalTags {
+		tagRef, err := components.OCIRef(ca.ComponentDescriptor.GetEffectiveRepositoryContext(), ca.ComponentDescriptor.GetName(), tag)
 		if err != nil {
-			return fmt.Errorf("unable to build oci artifact for component acrchive: %w", err)
+			return ref, fmt.Errorf("unable to calculate oci ref for %q: %s", ca.ComponentDescriptor.GetName(), err.Error())
 		}
+		if err := ociClient.PushManifest(ctx, tagRef, manifest); err != nil {
+			return ref, fmt.Errorf("unable to upload component archive to %q: %s", tagRef, err.Error())
+		}
+		log.Info(fmt.Sprintf("Successfully tagged component archive with %q", tagRef))
+	}
+	return ref, nil
+}
 
-		ref, err := components.OCIRef(ca.ComponentDescriptor.GetEffectiveRepositoryContext(), ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion())
+// writeReport writes the result of every uploaded component archive in the output format.
+func (o *PushOptions) writeReport(results []PushResult) error {
+	w := o.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	if o.Output == JSONOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
 		if err != nil {
-			return fmt.Errorf("unable to calculate oci ref for %q: %s", ca.ComponentDescriptor.GetName(), err.Error())
+			return fmt.Errorf("unable to marshal upload report: %w", err)
 		}
-		if err := ociClient.PushManifest(ctx, ref, manifest); err != nil {
-			return fmt.Errorf("unable to upload component archive to %q: %s", ref, err.Error())
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "NAME\tVERSION\tREF\tSTATUS"); err != nil {
+		return err
+	}
+	for _, result := range results {
+		status := "uploaded"
+		if len(result.Error) != 0 {
+			status = "failed: " + result.Error
 		}
-		log.Info(fmt.Sprintf("Successfully uploaded component archive to %q", ref))
-
-		for _, tag := range o.AdditionalTags {
-			ref, err := components.OCIRef(ca.ComponentDescriptor.GetEffectiveRepositoryContext(), ca.ComponentDescriptor.GetName(), tag)
-			if err != nil {
-				return fmt.Errorf("unable to calculate oci ref for %q: %s", ca.ComponentDescriptor.GetName(), err.Error())
-			}
-			if err := ociClient.PushManifest(ctx, ref, manifest); err != nil {
-				return fmt.Errorf("unable to upload component archive to %q: %s", ref, err.Error())
-			}
-			log.Info(fmt.Sprintf("Successfully tagged component archive with %q", ref))
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.Name, result.Version, result.Ref, status); err != nil {
+			return err
 		}
-
-		return nil
-	})
-	if err != nil {
-		return fmt.Errorf("error while reading component archives in ctf: %w", err)
 	}
-
-	return ctfArchive.Close()
+	return tw.Flush()
 }
 
 func (o *PushOptions) Complete(args []string) error {
@@ -155,12 +230,17 @@ func (o *PushOptions) Validate() error {
 	if len(o.CTFPath) == 0 {
 		return errors.New("a path to the component descriptor must be provided")
 	}
+	if o.Output != TextOutput && o.Output != JSONOutput {
+		return fmt.Errorf("unknown output format %q, expected one of %q, %q", o.Output, TextOutput, JSONOutput)
+	}
 	return nil
 }
 
 func (o *PushOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.BaseUrl, "repo-ctx", "", "repository context url for component to upload. The repository url will be automatically added to the repository contexts.")
 	fs.StringArrayVarP(&o.AdditionalTags, "tag", "t", []string{}, "set additional tags on the oci artifact")
+	fs.BoolVar(&o.FailFast, "fail-fast", false, "[OPTIONAL] stops at the first component archive that cannot be uploaded")
+	fs.StringVarP(&o.Output, "output", "o", TextOutput, fmt.Sprintf("[OPTIONAL] output format of the upload report. One of %q, %q", TextOutput, JSONOutput))
 
 	o.OciOptions.AddFlags(fs)
 }
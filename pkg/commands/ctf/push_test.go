@@ -5,7 +5,9 @@
 package ctf_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"os"
 
 	"github.com/gardener/component-spec/bindings-go/ctf"
@@ -116,6 +118,7 @@ var _ = Describe("Add", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(vfs.WriteFile(testdataFs, "/auth.json", cf, os.ModePerm))
 
+		var report bytes.Buffer
 		opts := cmd.PushOptions{
 			CTFPath: "/component.ctf",
 			BaseUrl: testenv.Addr + "/test",
@@ -123,8 +126,16 @@ var _ = Describe("Add", func() {
 				AllowPlainHttp:     false,
 				RegistryConfigPath: "/auth.json",
 			},
+			Output: cmd.JSONOutput,
+			Writer: &report,
 		}
-		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(HaveOccurred())
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(MatchError("1 of 1 component archives could not be uploaded"))
+
+		results := []cmd.PushResult{}
+		Expect(json.Unmarshal(report.Bytes(), &results)).To(Succeed())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Name).To(Equal("example.com/component"))
+		Expect(results[0].Error).ToNot(BeEmpty())
 	})
 
 })
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// validateReferences checks that all component references of the given component descriptors
+// resolve to one of the component descriptors.
+// All dangling references are returned as aggregated error.
+func validateReferences(cds []*cdv2.ComponentDescriptor) error {
+	available := sets.NewString()
+	for _, cd := range cds {
+		available.Insert(referenceKey(cd.GetName(), cd.GetVersion()))
+	}
+
+	errs := []error{}
+	for _, cd := range cds {
+		for _, ref := range cd.ComponentReferences {
+			if available.Has(referenceKey(ref.ComponentName, ref.Version)) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("component %s references %s (%s) which is not part of the ctf",
+				referenceKey(cd.GetName(), cd.GetVersion()), referenceKey(ref.ComponentName, ref.Version), ref.Name))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unresolved component references, use --skip-reference-check to ignore them: %w", utilerrors.NewAggregate(errs))
+}
+
+func referenceKey(name, version string) string {
+	return fmt.Sprintf("%s@%s", name, version)
+}
+
+// ctfComponentDescriptors returns the component descriptors of all component archives of the plain or compressed ctf
+// or the ctf in the directory layout at the given path by the name of the ctf entry.
+// Only the component descriptors are read, the ctf is not extracted.
+func ctfComponentDescriptors(fs vfs.FileSystem, ctfPath string) (map[string]*cdv2.ComponentDescriptor, error) {
+	isDir, err := isDirectoryCTF(fs, ctfPath)
+	if err != nil {
+		return nil, err
+	}
+	if isDir {
+		return directoryCTFComponentDescriptors(fs, ctfPath)
+	}
+
+	file, err := fs.Open(ctfPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open ctf at %q: %w", ctfPath, err)
+	}
+	defer file.Close()
+	r, err := newTarReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ctf at %q: %w", ctfPath, err)
+	}
+	defer r.Close()
+
+	cds := map[string]*cdv2.ComponentDescriptor{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return cds, nil
+			}
+			return nil, fmt.Errorf("unable to read ctf at %q: %w", ctfPath, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		cd, err := readArchiveComponentDescriptor(tr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read component descriptor of %q: %w", header.Name, err)
+		}
+		cds[header.Name] = cd
+	}
+}
+
+// directoryCTFComponentDescriptors returns the component descriptors of all component archives
+// of the ctf in the directory layout by the name of their directory.
+func directoryCTFComponentDescriptors(fs vfs.FileSystem, ctfPath string) (map[string]*cdv2.ComponentDescriptor, error) {
+	names, err := directoryCTFEntries(fs, ctfPath)
+	if err != nil {
+		return nil, err
+	}
+	cds := map[string]*cdv2.ComponentDescriptor{}
+	for _, name := range names {
+		data, err := vfs.ReadFile(fs, filepath.Join(ctfPath, name, ctf.ComponentDescriptorFileName))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read component descriptor of %q: %w", name, err)
+		}
+		cd := &cdv2.ComponentDescriptor{}
+		if err := codec.Decode(data, cd); err != nil {
+			return nil, fmt.Errorf("unable to decode component descriptor of %q: %w", name, err)
+		}
+		cds[name] = cd
+	}
+	return cds, nil
+}
+
+// readArchiveComponentDescriptor reads the component descriptor of a tar or gzipped tar component archive.
+func readArchiveComponentDescriptor(r io.Reader) (*cdv2.ComponentDescriptor, error) {
+	archive, err := newTarReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("no %s found", ctf.ComponentDescriptorFileName)
+			}
+			return nil, err
+		}
+		if path.Clean("/"+header.Name) != "/"+ctf.ComponentDescriptorFileName {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", ctf.ComponentDescriptorFileName, err)
+		}
+		cd := &cdv2.ComponentDescriptor{}
+		if err := codec.Decode(data, cd); err != nil {
+			return nil, fmt.Errorf("unable to decode %s: %w", ctf.ComponentDescriptorFileName, err)
+		}
+		return cd, nil
+	}
+}
+
+// componentDescriptorsAfterAdd returns the component descriptors that are part of the ctf after the component descriptors
+// with the given ctf entry names are added. Replaced entries and archives are omitted.
+func componentDescriptorsAfterAdd(existing map[string]*cdv2.ComponentDescriptor, added []*cdv2.ComponentDescriptor, filenames []string) []*cdv2.ComponentDescriptor {
+	entries := map[string]*cdv2.ComponentDescriptor{}
+	for name, cd := range existing {
+		entries[name] = cd
+	}
+	for i, cd := range added {
+		entries[filenames[i]] = cd
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cds := make([]*cdv2.ComponentDescriptor, 0, len(names))
+	for _, name := range names {
+		cds = append(cds, entries[name])
+	}
+	return cds
+}
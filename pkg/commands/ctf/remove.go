@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// RemoveOptions defines the options that are used to remove a component archive from a ctf.
+type RemoveOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+	// Name is the name of the component.
+	Name string
+	// Version is the optional version of the component.
+	// It is required if the ctf contains multiple versions of the component.
+	Version string
+	// SkipReferenceCheck skips the check that no remaining component references the removed component.
+	SkipReferenceCheck bool
+}
+
+// NewRemoveCommand creates a new command to remove a component archive from a ctf.
+func NewRemoveCommand(ctx context.Context) *cobra.Command {
+	opts := &RemoveOptions{}
+	cmd := &cobra.Command{
+		Use:   "remove CTF_PATH --name component-name [--version version]",
+		Args:  cobra.ExactArgs(1),
+		Short: "Removes a component archive from a ctf",
+		Long: `
+Removes the component archive of a component from a ctf.
+The version can be omitted if the ctf contains only one version of the component.
+
+The ctf is rewritten to a temporary file next to the ctf that replaces the ctf only if it has been written completely.
+Compressed ctfs keep their gzip or zstd compression. For ctfs in the directory layout only the directory of the component archive is removed.
+
+By default the component archive is not removed if another component of the ctf references it.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *RemoveOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	cds, err := ctfComponentDescriptors(fs, o.CTFPath)
+	if err != nil {
+		return err
+	}
+	entryName, err := o.entryName(cds)
+	if err != nil {
+		return err
+	}
+	removed := cds[entryName]
+
+	if !o.SkipReferenceCheck {
+		remaining := make([]*cdv2.ComponentDescriptor, 0, len(cds))
+		for name, cd := range cds {
+			if name != entryName {
+				remaining = append(remaining, cd)
+			}
+		}
+		if err := validateReferences(remaining); err != nil {
+			return fmt.Errorf("unable to remove component %s: %w", componentKey(removed.GetName(), removed.GetVersion()), err)
+		}
+	}
+
+	isDir, err := isDirectoryCTF(fs, o.CTFPath)
+	if err != nil {
+		return err
+	}
+	if isDir {
+		if err := fs.RemoveAll(filepath.Join(o.CTFPath, entryName)); err != nil {
+			return fmt.Errorf("unable to remove component archive %q: %w", entryName, err)
+		}
+	} else if err := removeCTFEntry(fs, o.CTFPath, entryName); err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("Successfully removed component %s from the ctf", componentKey(removed.GetName(), removed.GetVersion())))
+	return nil
+}
+
+// entryName returns the name of the ctf entry that contains the configured component.
+func (o *RemoveOptions) entryName(cds map[string]*cdv2.ComponentDescriptor) (string, error) {
+	entries := map[string]string{}
+	for name, cd := range cds {
+		if cd.GetName() == o.Name {
+			entries[cd.GetVersion()] = name
+		}
+	}
+
+	versions := make([]string, 0, len(entries))
+	for version := range entries {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	if len(versions) == 0 {
+		return "", fmt.Errorf("component %q is not part of the ctf", o.Name)
+	}
+	if len(o.Version) != 0 {
+		name, ok := entries[o.Version]
+		if !ok {
+			return "", fmt.Errorf("component %s is not part of the ctf. Available versions: %s",
+				componentKey(o.Name, o.Version), strings.Join(versions, ", "))
+		}
+		return name, nil
+	}
+	if len(versions) > 1 {
+		return "", fmt.Errorf("the ctf contains multiple versions of component %q, a version must be provided. Available versions: %s",
+			o.Name, strings.Join(versions, ", "))
+	}
+	return entries[versions[0]], nil
+}
+
+// removeCTFEntry rewrites the ctf without the entry with the given name.
+// The ctf keeps its compression and its index.
+func removeCTFEntry(fs vfs.FileSystem, ctfPath, entryName string) error {
+	c, err := detectCompression(fs, ctfPath)
+	if err != nil {
+		return err
+	}
+	var previous *ctfIndex
+	if c == noCompression {
+		previous, err = readCTFIndex(fs, ctfPath)
+		if err != nil {
+			return err
+		}
+	}
+	if err := rewriteTarCTF(fs, ctfPath, c, sets.NewString(entryName), nil); err != nil {
+		return err
+	}
+	if previous == nil {
+		return nil
+	}
+	if err := writeCTFIndex(fs, ctfPath, previous, nil); err != nil {
+		return fmt.Errorf("unable to write the index of the ctf: %w", err)
+	}
+	return nil
+}
+
+func (o *RemoveOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the remove options
+func (o *RemoveOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if len(o.Name) == 0 {
+		return errors.New("a component name must be provided")
+	}
+	return nil
+}
+
+func (o *RemoveOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Name, "name", "", "name of the component")
+	fs.StringVar(&o.Version, "version", "", "[OPTIONAL] version of the component. Required if the ctf contains multiple versions of the component")
+	fs.BoolVar(&o.SkipReferenceCheck, "skip-reference-check", false,
+		"remove the component even if it is referenced by another component of the ctf")
+}
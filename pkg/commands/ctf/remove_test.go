@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"context"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("Remove", func() {
+
+	var fs vfs.FileSystem
+
+	addComponents := func(ctfPath string) {
+		addOpts := cmd.AddOptions{
+			CTFPath:           ctfPath,
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/a-1", "/a-2", "/b"},
+		}
+		Expect(addOpts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	}
+
+	listComponents := func(ctfPath string) []string {
+		opts := cmd.ListOptions{CTFPath: ctfPath}
+		entries, err := opts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		components := []string{}
+		for _, entry := range entries {
+			components = append(components, entry.Name+"@"+entry.Version)
+		}
+		return components
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchive(fs, "/a-1", "example.com/a", "v0.0.1")).To(Succeed())
+		Expect(writeComponentArchive(fs, "/a-2", "example.com/a", "v0.0.2")).To(Succeed())
+		Expect(writeComponentArchiveWithReferences(fs, "/b", "example.com/b", "v1.0.0", "example.com/a@v0.0.2")).To(Succeed())
+	})
+
+	It("should remove a component archive from the ctf", func() {
+		addComponents("/component.ctf")
+
+		opts := cmd.RemoveOptions{CTFPath: "/component.ctf", Name: "example.com/a", Version: "v0.0.1"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		Expect(listComponents("/component.ctf")).To(Equal([]string{"example.com/a@v0.0.2", "example.com/b@v1.0.0"}))
+		Expect(tarEntries(fs, "/component.ctf")).ToNot(ContainElement(utils.CTFComponentArchiveFilename("example.com/a", "v0.0.1")))
+		files, err := vfs.ReadDir(fs, "/")
+		Expect(err).ToNot(HaveOccurred())
+		for _, file := range files {
+			Expect(file.Name()).ToNot(HavePrefix(".ctf-"), "the temporary file should be removed")
+		}
+	})
+
+	It("should remove a component archive from a gzipped ctf", func() {
+		addComponents("/component.ctf.tgz")
+
+		opts := cmd.RemoveOptions{CTFPath: "/component.ctf.tgz", Name: "example.com/b"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		Expect(listComponents("/component.ctf.tgz")).To(Equal([]string{"example.com/a@v0.0.1", "example.com/a@v0.0.2"}))
+		data, err := vfs.ReadFile(fs, "/component.ctf.tgz")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data[:2]).To(Equal([]byte{0x1f, 0x8b}))
+	})
+
+	It("should remove a component archive from a zstd compressed ctf", func() {
+		addComponents("/component.tar.zst")
+
+		opts := cmd.RemoveOptions{CTFPath: "/component.tar.zst", Name: "example.com/b"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		Expect(listComponents("/component.tar.zst")).To(Equal([]string{"example.com/a@v0.0.1", "example.com/a@v0.0.2"}))
+		Expect(zstdTarEntries(fs, "/component.tar.zst")).To(HaveLen(2))
+	})
+
+	It("should require a version if the ctf contains multiple versions of the component", func() {
+		addComponents("/component.ctf")
+
+		opts := cmd.RemoveOptions{CTFPath: "/component.ctf", Name: "example.com/a"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(MatchError(ContainSubstring("a version must be provided. Available versions: v0.0.1, v0.0.2")))
+		Expect(listComponents("/component.ctf")).To(HaveLen(3))
+	})
+
+	It("should not remove a component that is referenced by another component", func() {
+		addComponents("/component.ctf")
+
+		opts := cmd.RemoveOptions{CTFPath: "/component.ctf", Name: "example.com/a", Version: "v0.0.2"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(MatchError(ContainSubstring("unresolved component references")))
+		Expect(listComponents("/component.ctf")).To(HaveLen(3))
+
+		opts.SkipReferenceCheck = true
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(listComponents("/component.ctf")).To(Equal([]string{"example.com/a@v0.0.1", "example.com/b@v1.0.0"}))
+	})
+
+	It("should fail if the component is not part of the ctf", func() {
+		addComponents("/component.ctf")
+
+		opts := cmd.RemoveOptions{CTFPath: "/component.ctf", Name: "example.com/c"}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(MatchError(`component "example.com/c" is not part of the ctf`))
+	})
+
+})
@@ -0,0 +1,596 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/klauspost/compress/zstd"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+const (
+	// noCompressionName removes the compression of a repacked ctf.
+	noCompressionName = "none"
+	// minZstdWindowSize is the minimal size of an automatically sized zstd window.
+	minZstdWindowSize = 8 << 20
+)
+
+// RepackOptions defines the options that are used to repack a ctf.
+type RepackOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+	// OutputPath is the optional path of the repacked ctf, the ctf is repacked in place if empty.
+	OutputPath string
+	// ArchiveFormat defines the format of the repacked component archives.
+	ArchiveFormat ctf.ArchiveFormat
+	// Compression defines the compression of the repacked ctf.
+	// The compression of the ctf is kept if empty.
+	Compression string
+	// ZstdWindowSize is the window size in MiB of the zstd compression.
+	// The window is sized to cover the distances between identical blobs of different component archives if zero.
+	ZstdWindowSize int
+	// RecompressBlobs recompresses gzip compressed blobs with the best compression level.
+	RecompressBlobs bool
+}
+
+// RepackSummary describes the result of a repack.
+type RepackSummary struct {
+	// Components is the number of repacked components.
+	Components int
+	// DuplicateBlobs is the number of removed blobs that were identical to another blob of the same component archive.
+	DuplicateBlobs int
+	// DuplicateBytes is the size of the removed duplicate blobs.
+	DuplicateBytes int64
+	// UnreferencedBlobs is the number of removed blobs that were not referenced by any resource or source.
+	UnreferencedBlobs int
+	// UnreferencedBytes is the size of the removed unreferenced blobs.
+	UnreferencedBytes int64
+	// SharedBlobs is the number of blobs that are contained in multiple component archives.
+	SharedBlobs int
+	// SharedBytes is the size of all but one copy of the shared blobs.
+	SharedBytes int64
+	// DistantSharedBlobs is the number of shared blobs whose copies are too far apart
+	// to be stored once by an automatically sized zstd window.
+	DistantSharedBlobs int
+	// ZstdWindowSize is the window size of the zstd compression of the repacked ctf.
+	ZstdWindowSize int64
+	// RecompressedBlobs is the number of blobs that were recompressed.
+	RecompressedBlobs int
+	// RecompressedBytes is the size that was saved by the recompression.
+	RecompressedBytes int64
+	// OldSize is the size of the ctf before the repack.
+	OldSize int64
+	// NewSize is the size of the repacked ctf.
+	NewSize int64
+}
+
+// NewRepackCommand creates a new command to repack a ctf.
+func NewRepackCommand(ctx context.Context) *cobra.Command {
+	opts := &RepackOptions{}
+	cmd := &cobra.Command{
+		Use:   "repack CTF_PATH",
+		Args:  cobra.ExactArgs(1),
+		Short: "Repacks a ctf to remove duplicate blobs",
+		Long: `
+Repacks a ctf to reduce its size and reports the size savings.
+
+Resources and sources of a component archive with identical local blobs are changed to reference the same blob
+and the duplicates as well as blobs that are not referenced at all are removed from the component archive.
+The signatures of the component descriptors stay valid as the blob names are not part of the signed digest.
+
+Component archives cannot share blobs, so blobs that are contained in multiple component archives,
+e.g. common base image layers, are deduplicated by the zstd compression of the ctf.
+Identical blobs are stored once if they are within the zstd window of each other.
+By default the repacked ctf is staged uncompressed and the zstd window is sized to cover the distances
+between the copies of all shared blobs, up to the maximal window of 512 MiB.
+Decompressing a ctf with a window larger than 128 MiB with the zstd cli requires --long=29 or --memory=512MB.
+A fixed window is set with --zstd-window-size.
+This requires the component archives in the tar format, gzipped component archives cannot be deduplicated.
+
+With --recompress-blobs gzip compressed blobs are recompressed with the best compression level
+if this reduces their size. Blobs of resources with a digest are not recompressed as their digest would not match anymore.
+
+The ctf is repacked in place unless --output is given and keeps its compression unless --compression is given.
+Ctfs in the directory layout are only repacked component archive by component archive.
+`,
+		Example: `
+component-cli ctf repack ./delivery.ctf -o ./delivery.tar.zst
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			summary, err := opts.Repack(ctx, logger.Log, osfs.New())
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			WriteRepackSummary(os.Stdout, summary)
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *RepackOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	summary, err := o.Repack(ctx, log, fs)
+	if err != nil {
+		return err
+	}
+	WriteRepackSummary(os.Stdout, summary)
+	return nil
+}
+
+// Repack repacks all component archives of the ctf.
+func (o *RepackOptions) Repack(ctx context.Context, log logr.Logger, fs vfs.FileSystem) (*RepackSummary, error) {
+	outputPath := o.OutputPath
+	if len(outputPath) == 0 {
+		outputPath = o.CTFPath
+	}
+	isDir, err := isDirectoryCTF(fs, o.CTFPath)
+	if err != nil {
+		return nil, err
+	}
+	if isDir && len(o.Compression) != 0 {
+		return nil, errors.New("a ctf in the directory layout cannot be compressed")
+	}
+
+	c := noCompression
+	if !isDir {
+		switch {
+		case o.Compression == noCompressionName:
+		case len(o.Compression) != 0:
+			if c, err = parseCompression(false, o.Compression); err != nil {
+				return nil, err
+			}
+		case outputPath == o.CTFPath:
+			if c, err = detectCompression(fs, o.CTFPath); err != nil {
+				return nil, err
+			}
+		default:
+			c = compressionFromExtension(outputPath)
+		}
+	}
+
+	summary := &RepackSummary{}
+	if summary.OldSize, err = pathSize(fs, o.CTFPath); err != nil {
+		return nil, err
+	}
+
+	// the repacked ctf is staged as plain tar if the zstd window is sized automatically,
+	// as the distances between the copies of shared blobs are only known after all component archives are written.
+	stage := c == zstdCompression && o.ZstdWindowSize == 0
+	var (
+		w          *ctfWriter
+		stagedPath string
+	)
+	switch {
+	case isDir:
+		w, err = newCTFWriter(fs, outputPath, o.ArchiveFormat, true)
+	case stage:
+		if stagedPath, err = tempFilePath(fs, filepath.Dir(outputPath), ".ctf-"); err != nil {
+			return nil, err
+		}
+		defer fs.Remove(stagedPath)
+		w, err = newTarCTFWriter(fs, stagedPath, o.ArchiveFormat, noCompression)
+	case c == zstdCompression:
+		summary.ZstdWindowSize = int64(o.ZstdWindowSize) << 20
+		w, err = newTarCTFWriter(fs, outputPath, o.ArchiveFormat, c, zstd.WithWindowSize(o.ZstdWindowSize<<20))
+	default:
+		w, err = newTarCTFWriter(fs, outputPath, o.ArchiveFormat, c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer w.Abort()
+	// blobs of all component archives by their digest
+	blobs := map[string]*repackedBlob{}
+	err = walkCTF(fs, o.CTFPath, func(ca *ctf.ComponentArchive) error {
+		repacked, err := repackComponentArchive(ca, summary, blobs, o.RecompressBlobs)
+		if err != nil {
+			return fmt.Errorf("unable to repack component %s: %w",
+				componentKey(ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion()), err)
+		}
+		summary.Components++
+		return w.Add(repacked)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, blob := range blobs {
+		if len(blob.entries) > 1 {
+			summary.SharedBlobs++
+			summary.SharedBytes += blob.size * int64(len(blob.entries)-1)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("unable to write repacked ctf: %w", err)
+	}
+	if stage {
+		windowSize, distant, err := sharedBlobsWindowSize(fs, stagedPath, blobs)
+		if err != nil {
+			return nil, err
+		}
+		summary.ZstdWindowSize = int64(windowSize)
+		summary.DistantSharedBlobs = distant
+		if err := compressCTF(fs, stagedPath, outputPath, c, zstd.WithWindowSize(windowSize)); err != nil {
+			return nil, fmt.Errorf("unable to write repacked ctf: %w", err)
+		}
+	}
+
+	if summary.NewSize, err = pathSize(fs, outputPath); err != nil {
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Repacked %d component(s) into %q", summary.Components, outputPath))
+	return summary, nil
+}
+
+// repackedBlob describes a blob of the repacked ctf.
+type repackedBlob struct {
+	size int64
+	// entries are the ctf entries of the component archives that contain the blob in the order they are written.
+	entries []string
+}
+
+// repackComponentArchive returns a copy of the component archive that contains every referenced local blob only once.
+// The blobs of the copy are added to the given blobs.
+// Gzip compressed blobs of resources without digest are recompressed if recompress is set.
+func repackComponentArchive(ca *ctf.ComponentArchive, summary *RepackSummary, blobs map[string]*repackedBlob, recompress bool) (*ctf.ComponentArchive, error) {
+	caFs := memoryfs.New()
+	if err := ca.WriteToFilesystem(caFs, "/"); err != nil {
+		return nil, fmt.Errorf("unable to read component archive: %w", err)
+	}
+	cd := ca.ComponentDescriptor
+	entry := utils.CTFComponentArchiveFilename(cd.GetName(), cd.GetVersion())
+
+	// kept are the names of the blobs that are kept by their digest
+	kept := map[string]string{}
+	// filenames maps the name of every referenced blob to the name of the kept blob
+	filenames := map[string]string{}
+	repackAccess := func(access *cdv2.UnstructuredTypedObject) (*cdv2.UnstructuredTypedObject, error) {
+		if access == nil || access.GetType() != cdv2.LocalFilesystemBlobType {
+			return access, nil
+		}
+		blobAccess := &cdv2.LocalFilesystemBlobAccess{}
+		if err := access.DecodeInto(blobAccess); err != nil {
+			return nil, fmt.Errorf("unable to decode access: %w", err)
+		}
+		filename, ok := filenames[blobAccess.Filename]
+		if !ok {
+			digest, size, err := blobDigest(caFs, ctf.BlobPath(blobAccess.Filename))
+			if err != nil {
+				return nil, err
+			}
+			filename, ok = kept[digest]
+			if ok {
+				summary.DuplicateBlobs++
+				summary.DuplicateBytes += size
+			} else {
+				filename = blobAccess.Filename
+				kept[digest] = filename
+				if blob, ok := blobs[digest]; ok {
+					blob.entries = append(blob.entries, entry)
+				} else {
+					blobs[digest] = &repackedBlob{size: size, entries: []string{entry}}
+				}
+			}
+			filenames[blobAccess.Filename] = filename
+		}
+		if filename == blobAccess.Filename {
+			return access, nil
+		}
+		blobAccess.Filename = filename
+		repacked, err := cdv2.NewUnstructured(blobAccess)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode access: %w", err)
+		}
+		return &repacked, nil
+	}
+
+	for i, res := range cd.Resources {
+		access, err := repackAccess(res.Access)
+		if err != nil {
+			return nil, fmt.Errorf("resource %q: %w", res.GetName(), err)
+		}
+		cd.Resources[i].Access = access
+	}
+	for i, src := range cd.Sources {
+		access, err := repackAccess(src.Access)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", src.GetName(), err)
+		}
+		cd.Sources[i].Access = access
+	}
+
+	keptNames := map[string]bool{}
+	for _, filename := range kept {
+		keptNames[filename] = true
+	}
+	infos, err := vfs.ReadDir(caFs, ctf.BlobsDirectoryName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read blobs: %w", err)
+	}
+	for _, info := range infos {
+		if keptNames[info.Name()] {
+			continue
+		}
+		if _, ok := filenames[info.Name()]; !ok {
+			summary.UnreferencedBlobs++
+			summary.UnreferencedBytes += info.Size()
+		}
+		if err := removeAll(caFs, ctf.BlobPath(info.Name())); err != nil {
+			return nil, fmt.Errorf("unable to remove blob %q: %w", info.Name(), err)
+		}
+	}
+
+	if recompress {
+		// the blobs of resources with a digest are kept as their digest would not match the recompressed blob.
+		digested := sets.NewString()
+		for _, res := range cd.Resources {
+			if res.Digest == nil || res.Access == nil || res.Access.GetType() != cdv2.LocalFilesystemBlobType {
+				continue
+			}
+			blobAccess := &cdv2.LocalFilesystemBlobAccess{}
+			if err := res.Access.DecodeInto(blobAccess); err != nil {
+				return nil, fmt.Errorf("resource %q: unable to decode access: %w", res.GetName(), err)
+			}
+			digested.Insert(blobAccess.Filename)
+		}
+		for _, filename := range sets.StringKeySet(keptNames).List() {
+			if digested.Has(filename) {
+				continue
+			}
+			saved, err := recompressBlob(caFs, ctf.BlobPath(filename))
+			if err != nil {
+				return nil, fmt.Errorf("unable to recompress blob %q: %w", filename, err)
+			}
+			if saved > 0 {
+				summary.RecompressedBlobs++
+				summary.RecompressedBytes += saved
+			}
+		}
+	}
+	return ctf.NewComponentArchive(cd, caFs), nil
+}
+
+// recompressBlob recompresses the gzip compressed blob at the given path with the best compression level
+// and returns the saved size. The blob is kept if it is not gzip compressed or the recompression does not reduce its size.
+func recompressBlob(fs vfs.FileSystem, path string) (int64, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open blob: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get info for blob: %w", err)
+	}
+	br := bufio.NewReader(file)
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil || compressionFromMagic(magic) != gzipCompression {
+		return 0, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open gzip reader: %w", err)
+	}
+	defer zr.Close()
+
+	tmpFile, err := vfs.TempFile(fs, filepath.Dir(path), ".recompressed-")
+	if err != nil {
+		return 0, fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	defer fs.Remove(tmpFile.Name())
+	zw, err := gzip.NewWriterLevel(tmpFile, gzip.BestCompression)
+	if err != nil {
+		_ = tmpFile.Close()
+		return 0, err
+	}
+	zw.Header = zr.Header
+	if _, err := io.Copy(zw, zr); err != nil {
+		_ = tmpFile.Close()
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		_ = tmpFile.Close()
+		return 0, err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return 0, err
+	}
+	recompressed, err := fs.Stat(tmpFile.Name())
+	if err != nil {
+		return 0, err
+	}
+	if recompressed.Size() >= info.Size() {
+		return 0, nil
+	}
+	if err := utils.ReplaceFile(fs, tmpFile.Name(), path); err != nil {
+		return 0, err
+	}
+	return info.Size() - recompressed.Size(), nil
+}
+
+// sharedBlobsWindowSize returns the smallest zstd window that covers the distances between consecutive copies
+// of all shared blobs in the plain ctf at the given path. The window is at least minZstdWindowSize and at most the maximal zstd window.
+// The number of shared blobs whose copies are too far apart for the maximal window is returned as well.
+func sharedBlobsWindowSize(fs vfs.FileSystem, ctfPath string, blobs map[string]*repackedBlob) (int, int, error) {
+	file, err := fs.Open(ctfPath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to open ctf at %q: %w", ctfPath, err)
+	}
+	defer file.Close()
+	entries, _, err := scanTar(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to read ctf at %q: %w", ctfPath, err)
+	}
+	offsets := map[string]tarEntryInfo{}
+	for _, entry := range entries {
+		offsets[entry.name] = entry
+	}
+
+	var (
+		maxDistance int64
+		distant     int
+	)
+	for _, blob := range blobs {
+		tooFar := false
+		for i := 1; i < len(blob.entries); i++ {
+			prev, next := offsets[blob.entries[i-1]], offsets[blob.entries[i]]
+			// the blob is somewhere within both entries, so the distance of its copies is at most the distance of the entries.
+			distance := next.dataOffset + next.size - prev.dataOffset
+			if distance > zstd.MaxWindowSize {
+				tooFar = true
+				continue
+			}
+			if distance > maxDistance {
+				maxDistance = distance
+			}
+		}
+		if tooFar {
+			distant++
+		}
+	}
+
+	windowSize := minZstdWindowSize
+	for int64(windowSize) < maxDistance {
+		windowSize <<= 1
+	}
+	return windowSize, distant, nil
+}
+
+// blobDigest returns the sha256 digest and the size of the blob at the given path.
+func blobDigest(fs vfs.FileSystem, path string) (string, int64, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("unable to open blob: %w", err)
+	}
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("unable to read blob %q: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// pathSize returns the size of the file or of all files of the directory at the given path.
+func pathSize(fs vfs.FileSystem, path string) (int64, error) {
+	var size int64
+	err := vfs.Walk(fs, path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to get size of %q: %w", path, err)
+	}
+	return size, nil
+}
+
+// WriteRepackSummary writes the summary of a repack.
+func WriteRepackSummary(w io.Writer, summary *RepackSummary) {
+	fmt.Fprintf(w, "Repacked %d component(s)\n", summary.Components)
+	fmt.Fprintf(w, "Removed %d duplicate blob(s) (%s) and %d unreferenced blob(s) (%s)\n",
+		summary.DuplicateBlobs, bytesString(summary.DuplicateBytes),
+		summary.UnreferencedBlobs, bytesString(summary.UnreferencedBytes))
+	if summary.RecompressedBlobs != 0 {
+		fmt.Fprintf(w, "Recompressed %d blob(s) (saved %s)\n", summary.RecompressedBlobs, bytesString(summary.RecompressedBytes))
+	}
+	if summary.SharedBlobs != 0 {
+		fmt.Fprintf(w, "%d blob(s) (%s) are contained in multiple component archives\n",
+			summary.SharedBlobs, bytesString(summary.SharedBytes))
+		if summary.ZstdWindowSize == 0 {
+			fmt.Fprintln(w, "Use --compression zstd to store them once")
+		} else {
+			fmt.Fprintf(w, "They are stored once by the zstd compression with a window of %s\n", bytesString(summary.ZstdWindowSize))
+		}
+		if summary.DistantSharedBlobs != 0 {
+			fmt.Fprintf(w, "%d of them are too far apart to be stored once\n", summary.DistantSharedBlobs)
+		}
+	}
+	saved := summary.OldSize - summary.NewSize
+	percent := 0.0
+	if summary.OldSize != 0 {
+		percent = float64(saved) / float64(summary.OldSize) * 100
+	}
+	if saved < 0 {
+		fmt.Fprintf(w, "Size: %s -> %s (%s larger)\n",
+			bytesString(summary.OldSize), bytesString(summary.NewSize), bytesString(-saved))
+		return
+	}
+	fmt.Fprintf(w, "Size: %s -> %s (saved %s, %.1f%%)\n",
+		bytesString(summary.OldSize), bytesString(summary.NewSize), bytesString(saved), percent)
+}
+
+func bytesString(size int64) string {
+	return utils.BytesString(uint64(size), 2)
+}
+
+func (o *RepackOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the repack options
+func (o *RepackOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if len(o.Compression) != 0 && o.Compression != noCompressionName {
+		if _, err := parseCompression(false, o.Compression); err != nil {
+			return err
+		}
+	}
+	if o.ZstdWindowSize < 0 || o.ZstdWindowSize > zstd.MaxWindowSize>>20 || o.ZstdWindowSize&(o.ZstdWindowSize-1) != 0 {
+		return fmt.Errorf("the zstd window size must be a power of 2 between 1 and %d MiB", zstd.MaxWindowSize>>20)
+	}
+	if o.ArchiveFormat != ctf.ArchiveFormatTar &&
+		o.ArchiveFormat != ctf.ArchiveFormatTarGzip {
+		return fmt.Errorf("unsupported archive format %q", o.ArchiveFormat)
+	}
+	return nil
+}
+
+func (o *RepackOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.OutputPath, "output", "o", "", "[OPTIONAL] path of the repacked ctf. The ctf is repacked in place if empty")
+	fs.StringVar(&o.Compression, "compression", "",
+		fmt.Sprintf("[OPTIONAL] compression of the repacked ctf. One of %q, %q, %q", gzipCompression, zstdCompression, noCompressionName))
+	fs.IntVar(&o.ZstdWindowSize, "zstd-window-size", 0,
+		"[OPTIONAL] window size of the zstd compression in MiB, a power of 2. The window is sized to cover the distances between shared blobs if not set")
+	fs.BoolVar(&o.RecompressBlobs, "recompress-blobs", false, "[OPTIONAL] recompresses gzip compressed blobs with the best compression level")
+	componentarchive.OutputFormatVar(fs, &o.ArchiveFormat, "format", ctf.ArchiveFormatTar,
+		componentarchive.ArchiveOutputFormatUsage)
+}
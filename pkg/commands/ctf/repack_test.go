@@ -0,0 +1,329 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+)
+
+var _ = Describe("Repack", func() {
+
+	var fs vfs.FileSystem
+
+	// writeComponentArchiveWithBlobs writes a component archive with a resource for every given blob file
+	// and the blobs with the given content.
+	writeComponentArchiveWithBlobs := func(path, name string, resourceBlobs []string, blobs map[string][]byte) {
+		Expect(fs.MkdirAll(filepath.Join(path, ctf.BlobsDirectoryName), os.ModePerm)).To(Succeed())
+		for filename, data := range blobs {
+			Expect(vfs.WriteFile(fs, filepath.Join(path, ctf.BlobPath(filename)), data, os.ModePerm)).To(Succeed())
+		}
+		resources := ""
+		for i, filename := range resourceBlobs {
+			resources += fmt.Sprintf(`
+  - name: 'res-%d'
+    version: 'v1.0.0'
+    type: 'plain-text'
+    relation: 'local'
+    access:
+      type: 'localFilesystemBlob'
+      filename: '%s'
+      mediaType: 'text/plain'`, i, filename)
+		}
+		cd := fmt.Sprintf(`meta:
+  schemaVersion: 'v2'
+component:
+  name: '%s'
+  version: 'v1.0.0'
+  repositoryContexts: []
+  provider: 'internal'
+  sources: []
+  componentReferences: []
+  resources:%s
+`, name, resources)
+		Expect(vfs.WriteFile(fs, filepath.Join(path, ctf.ComponentDescriptorFileName), []byte(cd), os.ModePerm)).To(Succeed())
+	}
+
+	addComponents := func(ctfPath string, directory bool, archives ...string) {
+		opts := cmd.AddOptions{
+			CTFPath:           ctfPath,
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: archives,
+			Directory:         directory,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	}
+
+	readArchives := func(ctfPath string) map[string]*ctf.ComponentArchive {
+		archives := map[string]*ctf.ComponentArchive{}
+		ctfArchive, err := ctf.NewCTF(fs, ctfPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			archives[ca.ComponentDescriptor.GetName()] = ca
+			return nil
+		})).To(Succeed())
+		return archives
+	}
+
+	repackOptions := func(ctfPath string) cmd.RepackOptions {
+		return cmd.RepackOptions{
+			CTFPath:        ctfPath,
+			ArchiveFormat:  ctf.ArchiveFormatTar,
+			ZstdWindowSize: 8,
+		}
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+	})
+
+	It("should remove duplicate and unreferenced blobs of a component archive", func() {
+		writeComponentArchiveWithBlobs("/a", "example.com/a", []string{"blob-1", "blob-2", "other"}, map[string][]byte{
+			"blob-1": []byte("blob"),
+			"blob-2": []byte("blob"),
+			"other":  []byte("other"),
+			"unused": []byte("unused"),
+		})
+		addComponents("/component.ctf", false, "/a")
+
+		opts := repackOptions("/component.ctf")
+		summary, err := opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Components).To(Equal(1))
+		Expect(summary.DuplicateBlobs).To(Equal(1))
+		Expect(summary.DuplicateBytes).To(Equal(int64(4)))
+		Expect(summary.UnreferencedBlobs).To(Equal(1))
+		Expect(summary.UnreferencedBytes).To(Equal(int64(6)))
+		Expect(summary.NewSize).To(BeNumerically("<", summary.OldSize))
+
+		ca := readArchives("/component.ctf")["example.com/a"]
+		filenames := []string{}
+		for _, res := range ca.ComponentDescriptor.Resources {
+			blobAccess := &cdv2.LocalFilesystemBlobAccess{}
+			Expect(res.Access.DecodeInto(blobAccess)).To(Succeed())
+			Expect(blobAccess.MediaType).To(Equal("text/plain"))
+			filenames = append(filenames, blobAccess.Filename)
+			_, err := ca.Info(context.TODO(), res)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(filenames).To(Equal([]string{"blob-1", "blob-1", "other"}))
+		entries := tarEntries(fs, "/component.ctf")
+		Expect(entries).To(HaveLen(1))
+	})
+
+	It("should keep the signatures valid", func() {
+		writeComponentArchiveWithBlobs("/a", "example.com/a", []string{"blob-1", "blob-2"}, map[string][]byte{
+			"blob-1": []byte("blob"),
+			"blob-2": []byte("blob"),
+		})
+		addComponents("/component.ctf", false, "/a")
+		signOpts := cmd.SignOptions{
+			CTFPath:       "/component.ctf",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			SignatureName: "release",
+			HashAlgorithm: cdv2Sign.SHA256,
+		}
+		_, err := signOpts.SignWithSigner(context.TODO(), logr.Discard(), fs, staticSigner{value: "sig"})
+		Expect(err).ToNot(HaveOccurred())
+		signed := readArchives("/component.ctf")["example.com/a"].ComponentDescriptor
+
+		opts := repackOptions("/component.ctf")
+		_, err = opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		repacked := readArchives("/component.ctf")["example.com/a"].ComponentDescriptor
+
+		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		signedDigest, err := cdv2Sign.HashForComponentDescriptor(*signed, *hasher)
+		Expect(err).ToNot(HaveOccurred())
+		repackedDigest, err := cdv2Sign.HashForComponentDescriptor(*repacked, *hasher)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repackedDigest).To(Equal(signedDigest))
+		Expect(repacked.Signatures).To(Equal(signed.Signatures))
+	})
+
+	It("should store blobs of multiple component archives once with zstd", func() {
+		shared := make([]byte, 1<<20)
+		_, err := rand.Read(shared)
+		Expect(err).ToNot(HaveOccurred())
+		writeComponentArchiveWithBlobs("/a", "example.com/a", []string{"layer"}, map[string][]byte{"layer": shared})
+		writeComponentArchiveWithBlobs("/b", "example.com/b", []string{"layer"}, map[string][]byte{"layer": shared})
+		addComponents("/component.ctf", false, "/a", "/b")
+
+		opts := repackOptions("/component.ctf")
+		opts.OutputPath = "/component.tar.zst"
+		summary, err := opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.SharedBlobs).To(Equal(1))
+		Expect(summary.SharedBytes).To(Equal(int64(1 << 20)))
+		Expect(summary.NewSize).To(BeNumerically("<", summary.OldSize*3/5))
+		Expect(zstdTarEntries(fs, "/component.tar.zst")).To(HaveLen(2))
+
+		listOpts := cmd.ListOptions{CTFPath: "/component.tar.zst"}
+		components, err := listOpts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(components).To(HaveLen(2))
+	})
+
+	It("should size the zstd window to store distant blobs of multiple component archives once", func() {
+		shared := make([]byte, 1<<20)
+		_, err := rand.Read(shared)
+		Expect(err).ToNot(HaveOccurred())
+		unique := make([]byte, 9<<20)
+		_, err = rand.Read(unique)
+		Expect(err).ToNot(HaveOccurred())
+		writeComponentArchiveWithBlobs("/a", "example.com/a", []string{"layer"}, map[string][]byte{"layer": shared})
+		writeComponentArchiveWithBlobs("/b", "example.com/b", []string{"layer"}, map[string][]byte{"layer": unique})
+		writeComponentArchiveWithBlobs("/c", "example.com/c", []string{"layer"}, map[string][]byte{"layer": shared})
+		addComponents("/component.ctf", false, "/a", "/b", "/c")
+
+		// the copies of the shared blob are more than 8 MiB apart.
+		opts := repackOptions("/component.ctf")
+		opts.OutputPath = "/fixed.tar.zst"
+		fixed, err := opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fixed.ZstdWindowSize).To(Equal(int64(8 << 20)))
+
+		opts.OutputPath = "/auto.tar.zst"
+		opts.ZstdWindowSize = 0
+		auto, err := opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(auto.SharedBlobs).To(Equal(1))
+		Expect(auto.DistantSharedBlobs).To(Equal(0))
+		Expect(auto.ZstdWindowSize).To(Equal(int64(16 << 20)))
+		Expect(auto.NewSize).To(BeNumerically("<", fixed.NewSize-(1<<19)))
+		Expect(auto.NewSize).To(BeNumerically("<", (10<<20)+(1<<19)))
+		Expect(zstdTarEntries(fs, "/auto.tar.zst")).To(HaveLen(3))
+
+		listOpts := cmd.ListOptions{CTFPath: "/auto.tar.zst"}
+		components, err := listOpts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(components).To(HaveLen(3))
+		files, err := vfs.ReadDir(fs, "/")
+		Expect(err).ToNot(HaveOccurred())
+		for _, file := range files {
+			Expect(file.Name()).ToNot(HavePrefix(".ctf-"))
+		}
+	})
+
+	It("should recompress gzip compressed blobs of resources without digest", func() {
+		var content bytes.Buffer
+		for i := 0; i < 20000; i++ {
+			fmt.Fprintf(&content, "line %d of the blob\n", i%997)
+		}
+		var compressed bytes.Buffer
+		zw, err := gzip.NewWriterLevel(&compressed, gzip.BestSpeed)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = zw.Write(content.Bytes())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(zw.Close()).To(Succeed())
+		writeComponentArchiveWithBlobs("/a", "example.com/a", []string{"blob.gz", "plain"}, map[string][]byte{
+			"blob.gz": compressed.Bytes(),
+			"plain":   content.Bytes(),
+		})
+		addComponents("/component.ctf", false, "/a")
+
+		opts := repackOptions("/component.ctf")
+		opts.RecompressBlobs = true
+		opts.OutputPath = "/recompressed.ctf"
+		summary, err := opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.RecompressedBlobs).To(Equal(1))
+		Expect(summary.RecompressedBytes).To(BeNumerically(">", 0))
+
+		ca := readArchives("/recompressed.ctf")["example.com/a"]
+		var blob bytes.Buffer
+		_, err = ca.Resolve(context.TODO(), ca.ComponentDescriptor.Resources[0], &blob)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(int64(blob.Len())).To(Equal(int64(compressed.Len()) - summary.RecompressedBytes))
+		zr, err := gzip.NewReader(&blob)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(zr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(content.Bytes()))
+
+		// the blobs of a signed component descriptor have digests and are kept.
+		signOpts := cmd.SignOptions{
+			CTFPath:       "/component.ctf",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			SignatureName: "release",
+			HashAlgorithm: cdv2Sign.SHA256,
+		}
+		_, err = signOpts.SignWithSigner(context.TODO(), logr.Discard(), fs, staticSigner{value: "sig"})
+		Expect(err).ToNot(HaveOccurred())
+		summary, err = opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.RecompressedBlobs).To(Equal(0))
+	})
+
+	It("should repack the component archives of a ctf in the directory layout", func() {
+		writeComponentArchiveWithBlobs("/a", "example.com/a", []string{"blob-1", "blob-2"}, map[string][]byte{
+			"blob-1": []byte("blob"),
+			"blob-2": []byte("blob"),
+		})
+		addComponents("/component", true, "/a")
+
+		opts := repackOptions("/component")
+		summary, err := opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.DuplicateBlobs).To(Equal(1))
+		blobs, err := vfs.ReadDir(fs, "/component/example.com_a-v1.0.0/blobs")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobs).To(HaveLen(1))
+
+		opts.Compression = "zstd"
+		_, err = opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should keep the ctf if a component archive cannot be repacked", func() {
+		writeComponentArchiveWithBlobs("/a", "example.com/a", []string{"blob-1"}, map[string][]byte{"blob-1": []byte("a")})
+		writeComponentArchiveWithBlobs("/b", "example.com/b", []string{"blob-1"}, map[string][]byte{"blob-1": []byte("b")})
+		addComponents("/component", true, "/a", "/b")
+		Expect(fs.Remove("/component/example.com_b-v1.0.0/blobs/blob-1")).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/component.ctf", []byte("previous"), os.ModePerm)).To(Succeed())
+
+		opts := repackOptions("/component")
+		opts.OutputPath = "/component.ctf"
+		_, err := opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		data, err := vfs.ReadFile(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("previous"))
+		files, err := vfs.ReadDir(fs, "/")
+		Expect(err).ToNot(HaveOccurred())
+		for _, file := range files {
+			Expect(file.Name()).ToNot(HavePrefix(".ctf-"))
+		}
+
+		opts.OutputPath = ""
+		_, err = opts.Repack(context.TODO(), logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		entries, err := vfs.ReadDir(fs, "/component")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Name()).To(Equal("example.com_a-v1.0.0"))
+		Expect(entries[1].Name()).To(Equal("example.com_b-v1.0.0"))
+	})
+
+})
@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// ResignOptions defines the options that are used to re-sign all component descriptors of a ctf.
+type ResignOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+	// ArchiveFormat defines the format of the component archives that are written back to the ctf.
+	ArchiveFormat ctf.ArchiveFormat
+
+	// SignatureName defines the name for the generated signature.
+	SignatureName string
+	// OldSignatureName is the optional name of the signature that should be removed.
+	OldSignatureName string
+	// HashAlgorithm is the algorithm that is used to hash the normalised component descriptor.
+	HashAlgorithm string
+
+	SignerOptions
+}
+
+// NewResignCommand creates a new command to re-sign all component descriptors of a ctf.
+func NewResignCommand(ctx context.Context) *cobra.Command {
+	opts := &ResignOptions{}
+	cmd := &cobra.Command{
+		Use:   "resign CTF_PATH",
+		Args:  cobra.ExactArgs(1),
+		Short: "Re-signs all component descriptors of a ctf",
+		Long: `
+Re-signs all component descriptors of a ctf, e.g. after a signing key has been rotated.
+Signatures of the signer defined by --old-signature-name and existing signatures with the new signature name are removed
+before the component descriptors are signed again. The modified component archives are written back to the ctf.
+Compressed ctfs keep their gzip or zstd compression.
+
+The component descriptors are expected to already contain digests for all resources and component references.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *ResignOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	signer, err := o.SignerOptions.Build()
+	if err != nil {
+		return err
+	}
+
+	count, err := o.ResignWithSigner(log, fs, signer)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Successfully re-signed %d component(s)\n", count)
+	return nil
+}
+
+// ResignWithSigner re-signs all component descriptors of the ctf with the given signer.
+// It returns the number of re-signed components.
+func (o *ResignOptions) ResignWithSigner(log logr.Logger, fs vfs.FileSystem, signer cdv2Sign.Signer) (int, error) {
+	// all component descriptors are re-signed before the ctf is written, so that a failed signature leaves the ctf unchanged.
+	// Only the component descriptors are kept, the component archives are read again when the ctf is written.
+	descriptors := map[string]*cdv2.ComponentDescriptor{}
+	err := walkCTF(fs, o.CTFPath, func(ca *ctf.ComponentArchive) error {
+		cd := ca.ComponentDescriptor
+		cd.Signatures = removeSignatures(cd.Signatures, o.OldSignatureName, o.SignatureName)
+
+		hasher, err := cdv2Sign.HasherForName(o.HashAlgorithm)
+		if err != nil {
+			return fmt.Errorf("unable to create hasher: %w", err)
+		}
+		if err := cdv2Sign.SignComponentDescriptor(cd, signer, *hasher, o.SignatureName); err != nil {
+			return fmt.Errorf("unable to sign component descriptor %s:%s: %w", cd.GetName(), cd.GetVersion(), err)
+		}
+		log.V(3).Info(fmt.Sprintf("Signed component descriptor %s %s", cd.GetName(), cd.GetVersion()))
+		descriptors[componentKey(cd.GetName(), cd.GetVersion())] = cd
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// the component archives are written to a new ctf so that the archive names are consistent with "ctf add".
+	// Ctfs in the directory layout are updated in place.
+	if err := replaceComponentDescriptors(fs, o.CTFPath, o.ArchiveFormat, descriptors); err != nil {
+		return 0, fmt.Errorf("unable to write modified ctf: %w", err)
+	}
+	log.Info(fmt.Sprintf("Re-signed %d component(s)", len(descriptors)))
+	return len(descriptors), nil
+}
+
+func (o *ResignOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the resign options
+func (o *ResignOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if len(o.SignatureName) == 0 {
+		return errors.New("a signature name must be provided")
+	}
+	if _, ok := cdv2Sign.HashFunctions[o.HashAlgorithm]; !ok {
+		return fmt.Errorf("unsupported hash algorithm %q", o.HashAlgorithm)
+	}
+	if err := o.SignerOptions.Validate(); err != nil {
+		return err
+	}
+	if o.ArchiveFormat != ctf.ArchiveFormatTar &&
+		o.ArchiveFormat != ctf.ArchiveFormatTarGzip {
+		return fmt.Errorf("unsupported archive format %q", o.ArchiveFormat)
+	}
+	return nil
+}
+
+func (o *ResignOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.SignatureName, "signature-name", "", "name of the new signature")
+	fs.StringVar(&o.OldSignatureName, "old-signature-name", "", "[OPTIONAL] name of the signature that is removed from the component descriptors")
+	fs.StringVar(&o.HashAlgorithm, "hash-algorithm", cdv2Sign.SHA256, "algorithm that is used to hash the normalised component descriptor")
+	o.SignerOptions.AddFlags(fs)
+	componentarchive.OutputFormatVar(fs, &o.ArchiveFormat, "format", ctf.ArchiveFormatTar,
+		componentarchive.ArchiveOutputFormatUsage)
+}
+
+// removeSignatures removes all signatures with one of the given names.
+func removeSignatures(signatures []cdv2.Signature, names ...string) []cdv2.Signature {
+	filtered := make([]cdv2.Signature, 0, len(signatures))
+	for _, sig := range signatures {
+		remove := false
+		for _, name := range names {
+			if len(name) != 0 && sig.Name == name {
+				remove = true
+				break
+			}
+		}
+		if !remove {
+			filtered = append(filtered, sig)
+		}
+	}
+	return filtered
+}
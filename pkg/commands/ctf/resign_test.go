@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"context"
+	"errors"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+)
+
+// staticSigner is a signer that returns a static signature value.
+type staticSigner struct {
+	value string
+}
+
+func (s staticSigner) Sign(_ cdv2.ComponentDescriptor, _ cdv2.DigestSpec) (*cdv2.SignatureSpec, error) {
+	return &cdv2.SignatureSpec{
+		Algorithm: "static",
+		Value:     s.value,
+		MediaType: cdv2.MediaTypePEM,
+	}, nil
+}
+
+// failingSigner is a signer that fails for the component with the given name.
+type failingSigner struct {
+	staticSigner
+	name string
+}
+
+func (s failingSigner) Sign(cd cdv2.ComponentDescriptor, digest cdv2.DigestSpec) (*cdv2.SignatureSpec, error) {
+	if cd.GetName() == s.name {
+		return nil, errors.New("signer failed")
+	}
+	return s.staticSigner.Sign(cd, digest)
+}
+
+var _ = Describe("Resign", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testdataFileSystem()
+
+		addOpts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"./00-ca"},
+		}
+		Expect(addOpts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+	})
+
+	readSignatures := func() []cdv2.Signature {
+		ctfArchive, err := ctf.NewCTF(testdataFs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		signatures := []cdv2.Signature{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			signatures = append(signatures, ca.ComponentDescriptor.Signatures...)
+			return nil
+		})).To(Succeed())
+		return signatures
+	}
+
+	It("should replace the signatures of the old signer with a new signature", func() {
+		opts := cmd.ResignOptions{
+			CTFPath:       "/component.ctf",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			SignatureName: "old",
+			HashAlgorithm: cdv2Sign.SHA256,
+		}
+		count, err := opts.ResignWithSigner(logr.Discard(), testdataFs, staticSigner{value: "old-sig"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(1))
+
+		opts.SignatureName = "new"
+		opts.OldSignatureName = "old"
+		count, err = opts.ResignWithSigner(logr.Discard(), testdataFs, staticSigner{value: "new-sig"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(1))
+
+		signatures := readSignatures()
+		Expect(signatures).To(HaveLen(1))
+		Expect(signatures[0].Name).To(Equal("new"))
+		Expect(signatures[0].Signature.Value).To(Equal("new-sig"))
+	})
+
+	It("should keep signatures of other signers", func() {
+		opts := cmd.ResignOptions{
+			CTFPath:       "/component.ctf",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			SignatureName: "other",
+			HashAlgorithm: cdv2Sign.SHA256,
+		}
+		_, err := opts.ResignWithSigner(logr.Discard(), testdataFs, staticSigner{value: "other-sig"})
+		Expect(err).ToNot(HaveOccurred())
+
+		opts.SignatureName = "new"
+		_, err = opts.ResignWithSigner(logr.Discard(), testdataFs, staticSigner{value: "new-sig"})
+		Expect(err).ToNot(HaveOccurred())
+
+		signatures := readSignatures()
+		Expect(signatures).To(HaveLen(2))
+	})
+
+	It("should keep the ctf unchanged if a component descriptor cannot be signed", func() {
+		Expect(writeComponentArchive(testdataFs, "/d", "example.com/d", "v0.0.1")).To(Succeed())
+		addOpts := cmd.AddOptions{
+			CTFPath:           "/component.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/d"},
+		}
+		Expect(addOpts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		// the failing component is signed after example.com/component.
+		Expect(tarEntries(testdataFs, "/component.ctf")).To(Equal([]string{"example.com_component-v0.0.0.tar", "example.com_d-v0.0.1.tar"}))
+		before, err := vfs.ReadFile(testdataFs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+
+		opts := cmd.ResignOptions{
+			CTFPath:       "/component.ctf",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			SignatureName: "new",
+			HashAlgorithm: cdv2Sign.SHA256,
+		}
+		_, err = opts.ResignWithSigner(logr.Discard(), testdataFs, failingSigner{staticSigner: staticSigner{value: "sig"}, name: "example.com/d"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("signer failed"))
+
+		after, err := vfs.ReadFile(testdataFs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(after).To(Equal(before))
+		Expect(readSignatures()).To(BeEmpty())
+	})
+
+})
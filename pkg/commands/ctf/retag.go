@@ -0,0 +1,336 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// RetagOptions defines the options that are used to rewrite the component versions of a ctf.
+type RetagOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+	// MappingPath is the path to the version mapping file.
+	MappingPath string
+	// ArchiveFormat defines the format of the component archives that are written back to the ctf.
+	ArchiveFormat ctf.ArchiveFormat
+}
+
+// VersionMapping defines the new versions of components.
+type VersionMapping struct {
+	Versions []VersionMappingEntry `json:"versions"`
+}
+
+// VersionMappingEntry maps a version of a component to a new version.
+type VersionMappingEntry struct {
+	// Component is the optional name of the component, an empty name matches all components.
+	Component string `json:"component,omitempty"`
+	// From is the current version of the component.
+	From string `json:"from"`
+	// To is the new version of the component.
+	To string `json:"to"`
+}
+
+// RetagSummary describes the result of a retag.
+type RetagSummary struct {
+	// Components is the number of components whose version was rewritten.
+	Components int
+	// References is the number of rewritten component references.
+	References int
+	// Unsigned is the number of components whose signatures were removed.
+	Unsigned int
+}
+
+// NewRetagCommand creates a new command to rewrite the component versions of a ctf.
+func NewRetagCommand(ctx context.Context) *cobra.Command {
+	opts := &RetagOptions{}
+	cmd := &cobra.Command{
+		Use:   "retag CTF_PATH --mapping MAPPING_PATH",
+		Args:  cobra.ExactArgs(1),
+		Short: "Rewrites the component versions of a ctf",
+		Long: `
+Rewrites the versions of the components of a ctf and the component references to these components
+according to a version mapping, e.g. to promote snapshot builds to release versions.
+The modified component archives are written back to the ctf. Compressed ctfs keep their gzip or zstd compression.
+
+The mapping is a yaml or json file. Entries without a component match all components with the given version,
+entries with a component take precedence:
+
+<pre>
+
+versions:
+- component: github.com/gardener/component-cli
+  from: v0.1.0-dev-abcdef
+  to: v0.1.0
+- from: v0.2.0-dev
+  to: v0.2.0
+
+</pre>
+
+Every entry must match at least one component and no two components may be rewritten to the same version.
+The versions of local resources are rewritten with the component version,
+references to components that are not part of the ctf are not modified.
+
+Rewriting a component invalidates its signatures and the digests of the references to it.
+These signatures and digests are removed, use "ctf sign" to sign the retagged ctf.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			summary, err := opts.Retag(logger.Log, osfs.New())
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully retagged %d component(s) and %d component reference(s)\n", summary.Components, summary.References)
+			if summary.Unsigned != 0 {
+				fmt.Printf("Removed the signatures of %d component(s)\n", summary.Unsigned)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *RetagOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	_, err := o.Retag(log, fs)
+	return err
+}
+
+// Retag rewrites the versions of all components of the ctf according to the version mapping.
+func (o *RetagOptions) Retag(log logr.Logger, fs vfs.FileSystem) (*RetagSummary, error) {
+	mapping, err := ParseVersionMapping(fs, o.MappingPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the component descriptors are kept, the component archives are read again when the retagged ctf is written.
+	cds := []*cdv2.ComponentDescriptor{}
+	// descriptors are the component descriptors by their component name and version in the ctf
+	descriptors := map[string]*cdv2.ComponentDescriptor{}
+	err = walkCTF(fs, o.CTFPath, func(ca *ctf.ComponentArchive) error {
+		cd := ca.ComponentDescriptor
+		cds = append(cds, cd)
+		descriptors[componentKey(cd.GetName(), cd.GetVersion())] = cd
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary, oldKeys, err := retagComponents(log, cds, mapping)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Components == 0 {
+		log.Info("no component versions changed")
+		return summary, nil
+	}
+
+	if err := replaceComponentDescriptors(fs, o.CTFPath, o.ArchiveFormat, descriptors); err != nil {
+		return nil, fmt.Errorf("unable to write retagged ctf: %w", err)
+	}
+	isDir, err := isDirectoryCTF(fs, o.CTFPath)
+	if err != nil {
+		return nil, err
+	}
+	if isDir {
+		// ctfs in the directory layout keep the entries of other components, so the entries of the old versions are removed.
+		for _, key := range oldKeys {
+			entryPath := filepath.Join(o.CTFPath, ctfDirectoryEntryName(key.Name, key.Version))
+			if err := removeAll(fs, entryPath); err != nil {
+				return nil, fmt.Errorf("unable to remove component archive %q: %w", entryPath, err)
+			}
+		}
+	}
+	log.Info(fmt.Sprintf("Retagged %d component(s)", summary.Components))
+	return summary, nil
+}
+
+// retagComponents rewrites the versions of the component descriptors and of the references between them.
+// It returns the old name and version of all rewritten components.
+func retagComponents(log logr.Logger, cds []*cdv2.ComponentDescriptor, mapping *VersionMapping) (*RetagSummary, []ComponentVersion, error) {
+	summary := &RetagSummary{}
+	used := make([]bool, len(mapping.Versions))
+
+	// new versions by the old component key
+	newVersions := map[string]string{}
+	newKeys := map[string]string{}
+	for _, cd := range cds {
+		key := componentKey(cd.GetName(), cd.GetVersion())
+		version := cd.GetVersion()
+		if i, ok := mapping.match(cd.GetName(), cd.GetVersion()); ok {
+			used[i] = true
+			version = mapping.Versions[i].To
+			if version != cd.GetVersion() {
+				newVersions[key] = version
+			}
+		}
+		newKey := componentKey(cd.GetName(), version)
+		if other, ok := newKeys[newKey]; ok {
+			return nil, nil, fmt.Errorf("components %s and %s would both be retagged to %s", other, key, newKey)
+		}
+		newKeys[newKey] = key
+	}
+	for i, entry := range mapping.Versions {
+		if !used[i] {
+			return nil, nil, fmt.Errorf("version mapping %s matches no component of the ctf", entry)
+		}
+	}
+
+	// modified contains the new keys of all component descriptors that were modified
+	modified := map[string]bool{}
+	oldKeys := []ComponentVersion{}
+	for _, cd := range cds {
+		key := componentKey(cd.GetName(), cd.GetVersion())
+		for i, ref := range cd.ComponentReferences {
+			version, ok := newVersions[componentKey(ref.ComponentName, ref.Version)]
+			if !ok {
+				continue
+			}
+			cd.ComponentReferences[i].Version = version
+			cd.ComponentReferences[i].Digest = nil
+			summary.References++
+			modified[key] = true
+		}
+		if version, ok := newVersions[key]; ok {
+			oldKeys = append(oldKeys, ComponentVersion{Name: cd.GetName(), Version: cd.GetVersion()})
+			delete(modified, key)
+			cd.Version = version
+			// the version of local resources must match the component version
+			for j := range cd.Resources {
+				if cd.Resources[j].Relation == cdv2.LocalRelation {
+					cd.Resources[j].Version = version
+				}
+			}
+			modified[componentKey(cd.GetName(), cd.GetVersion())] = true
+			summary.Components++
+			log.V(3).Info(fmt.Sprintf("Retagged component %s to %s", key, version))
+		}
+	}
+
+	// modified component descriptors invalidate the digests of the references to them
+	// which modifies the referencing component descriptors.
+	for changed := true; changed; {
+		changed = false
+		for _, cd := range cds {
+			for i, ref := range cd.ComponentReferences {
+				if ref.Digest == nil || !modified[componentKey(ref.ComponentName, ref.Version)] {
+					continue
+				}
+				cd.ComponentReferences[i].Digest = nil
+				modified[componentKey(cd.GetName(), cd.GetVersion())] = true
+				changed = true
+			}
+		}
+	}
+
+	for _, cd := range cds {
+		if modified[componentKey(cd.GetName(), cd.GetVersion())] && len(cd.Signatures) != 0 {
+			cd.Signatures = nil
+			summary.Unsigned++
+			log.V(3).Info(fmt.Sprintf("Removed the signatures of component %s %s", cd.GetName(), cd.GetVersion()))
+		}
+	}
+	return summary, oldKeys, nil
+}
+
+// ParseVersionMapping reads the version mapping from a yaml or json file.
+func ParseVersionMapping(fs vfs.FileSystem, path string) (*VersionMapping, error) {
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read version mapping %q: %w", path, err)
+	}
+	mapping := &VersionMapping{}
+	if err := yaml.Unmarshal(data, mapping); err != nil {
+		return nil, fmt.Errorf("unable to parse version mapping %q: %w", path, err)
+	}
+
+	entries := map[VersionMappingEntry]bool{}
+	for _, entry := range mapping.Versions {
+		if len(entry.From) == 0 || len(entry.To) == 0 {
+			return nil, fmt.Errorf("version mapping %s must define the from and to versions", entry)
+		}
+		key := VersionMappingEntry{Component: entry.Component, From: entry.From}
+		if entries[key] {
+			return nil, fmt.Errorf("version %s is mapped multiple times", entry)
+		}
+		entries[key] = true
+	}
+	return mapping, nil
+}
+
+// match returns the index of the entry that maps the version of the component.
+// Entries for the component take precedence over entries for all components.
+func (m *VersionMapping) match(name, version string) (int, bool) {
+	index := -1
+	for i, entry := range m.Versions {
+		if entry.From != version {
+			continue
+		}
+		if entry.Component == name {
+			return i, true
+		}
+		if len(entry.Component) == 0 {
+			index = i
+		}
+	}
+	return index, index != -1
+}
+
+func (e VersionMappingEntry) String() string {
+	component := e.Component
+	if len(component) == 0 {
+		component = "*"
+	}
+	return fmt.Sprintf("%s:%s -> %s", component, e.From, e.To)
+}
+
+func (o *RetagOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the retag options
+func (o *RetagOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if len(o.MappingPath) == 0 {
+		return errors.New("a version mapping must be provided")
+	}
+	if o.ArchiveFormat != ctf.ArchiveFormatTar &&
+		o.ArchiveFormat != ctf.ArchiveFormatTarGzip {
+		return fmt.Errorf("unsupported archive format %q", o.ArchiveFormat)
+	}
+	return nil
+}
+
+func (o *RetagOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.MappingPath, "mapping", "", "path to the version mapping file")
+	componentarchive.OutputFormatVar(fs, &o.ArchiveFormat, "format", ctf.ArchiveFormatTar,
+		componentarchive.ArchiveOutputFormatUsage)
+}
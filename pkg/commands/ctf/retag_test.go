@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"context"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+)
+
+var _ = Describe("Retag", func() {
+
+	var fs vfs.FileSystem
+
+	addComponents := func(ctfPath string, directory bool, archives ...string) {
+		opts := cmd.AddOptions{
+			CTFPath:            ctfPath,
+			ArchiveFormat:      ctf.ArchiveFormatTar,
+			ComponentArchives:  archives,
+			Directory:          directory,
+			SkipReferenceCheck: true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	}
+
+	writeMapping := func(mapping string) {
+		Expect(vfs.WriteFile(fs, "/mapping.yaml", []byte(mapping), os.ModePerm)).To(Succeed())
+	}
+
+	componentDescriptors := func(ctfPath string) map[string]*cdv2.ComponentDescriptor {
+		cds := map[string]*cdv2.ComponentDescriptor{}
+		ctfArchive, err := ctf.NewCTF(fs, ctfPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			cds[ca.ComponentDescriptor.GetName()] = ca.ComponentDescriptor
+			return nil
+		})).To(Succeed())
+		return cds
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a", "example.com/a", "v1.0.0-dev", "blob", true)).To(Succeed())
+		Expect(writeComponentArchiveWithReferences(fs, "/b", "example.com/b", "v1.0.0-dev", "example.com/a@v1.0.0-dev", "example.com/x@v1.0.0-dev")).To(Succeed())
+		Expect(writeComponentArchiveWithReferences(fs, "/c", "example.com/c", "v2.0.0", "example.com/b@v1.0.0-dev")).To(Succeed())
+	})
+
+	It("should rewrite the component versions and the references to them", func() {
+		addComponents("/component.ctf", false, "/a", "/b", "/c")
+		writeMapping(`
+versions:
+- component: example.com/a
+  from: v1.0.0-dev
+  to: v1.0.1
+- from: v1.0.0-dev
+  to: v1.0.0
+`)
+		opts := cmd.RetagOptions{CTFPath: "/component.ctf", MappingPath: "/mapping.yaml", ArchiveFormat: ctf.ArchiveFormatTar}
+		summary, err := opts.Retag(logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Components).To(Equal(2))
+		Expect(summary.References).To(Equal(2))
+
+		cds := componentDescriptors("/component.ctf")
+		Expect(cds).To(HaveLen(3))
+		Expect(cds["example.com/a"].GetVersion()).To(Equal("v1.0.1"))
+		Expect(cds["example.com/a"].Resources[0].Version).To(Equal("v1.0.1"))
+		Expect(cds["example.com/b"].GetVersion()).To(Equal("v1.0.0"))
+		Expect(cds["example.com/b"].ComponentReferences[0].Version).To(Equal("v1.0.1"))
+		// references to components outside of the ctf are kept
+		Expect(cds["example.com/b"].ComponentReferences[1].Version).To(Equal("v1.0.0-dev"))
+		Expect(cds["example.com/c"].GetVersion()).To(Equal("v2.0.0"))
+		Expect(cds["example.com/c"].ComponentReferences[0].Version).To(Equal("v1.0.0"))
+	})
+
+	It("should remove invalidated signatures and reference digests", func() {
+		Expect(writeComponentArchiveWithReferences(fs, "/b", "example.com/b", "v1.0.0-dev", "example.com/a@v1.0.0-dev")).To(Succeed())
+		Expect(writeComponentArchiveWithReferences(fs, "/d", "example.com/d", "v3.0.0")).To(Succeed())
+		addComponents("/component.ctf", false, "/a", "/b", "/c", "/d")
+		signOpts := cmd.SignOptions{
+			CTFPath:       "/component.ctf",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			SignatureName: "release",
+			HashAlgorithm: cdv2Sign.SHA256,
+		}
+		_, err := signOpts.SignWithSigner(context.TODO(), logr.Discard(), fs, staticSigner{value: "sig"})
+		Expect(err).ToNot(HaveOccurred())
+
+		writeMapping(`
+versions:
+- component: example.com/a
+  from: v1.0.0-dev
+  to: v1.0.0
+`)
+		opts := cmd.RetagOptions{CTFPath: "/component.ctf", MappingPath: "/mapping.yaml", ArchiveFormat: ctf.ArchiveFormatTar}
+		summary, err := opts.Retag(logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Unsigned).To(Equal(3))
+
+		cds := componentDescriptors("/component.ctf")
+		Expect(cds["example.com/a"].Signatures).To(BeEmpty())
+		Expect(cds["example.com/b"].Signatures).To(BeEmpty())
+		Expect(cds["example.com/b"].ComponentReferences[0].Digest).To(BeNil())
+		// c references the modified component b with an unchanged version
+		Expect(cds["example.com/c"].Signatures).To(BeEmpty())
+		Expect(cds["example.com/c"].ComponentReferences[0].Digest).To(BeNil())
+		Expect(cds["example.com/d"].Signatures).To(HaveLen(1))
+	})
+
+	It("should replace the entries of retagged components in a ctf in the directory layout", func() {
+		addComponents("/component", true, "/a", "/c")
+		writeMapping(`
+versions:
+- from: v1.0.0-dev
+  to: v1.0.0
+`)
+		opts := cmd.RetagOptions{CTFPath: "/component", MappingPath: "/mapping.yaml", ArchiveFormat: ctf.ArchiveFormatTar}
+		_, err := opts.Retag(logr.Discard(), fs)
+		Expect(err).ToNot(HaveOccurred())
+
+		listOpts := cmd.ListOptions{CTFPath: "/component"}
+		components, err := listOpts.List(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(components).To(HaveLen(2))
+		versions := map[string]string{}
+		for _, c := range components {
+			versions[c.Name] = c.Version
+		}
+		Expect(versions).To(Equal(map[string]string{"example.com/a": "v1.0.0", "example.com/c": "v2.0.0"}))
+	})
+
+	It("should fail if a mapping matches no component", func() {
+		addComponents("/component.ctf", false, "/a")
+		writeMapping(`
+versions:
+- component: example.com/b
+  from: v1.0.0-dev
+  to: v1.0.0
+`)
+		opts := cmd.RetagOptions{CTFPath: "/component.ctf", MappingPath: "/mapping.yaml", ArchiveFormat: ctf.ArchiveFormatTar}
+		_, err := opts.Retag(logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("matches no component"))
+	})
+
+	It("should fail if two components would be retagged to the same version", func() {
+		Expect(writeComponentArchive(fs, "/a-2", "example.com/a", "v1.0.0")).To(Succeed())
+		addComponents("/component.ctf", false, "/a", "/a-2")
+		writeMapping(`
+versions:
+- from: v1.0.0-dev
+  to: v1.0.0
+`)
+		opts := cmd.RetagOptions{CTFPath: "/component.ctf", MappingPath: "/mapping.yaml", ArchiveFormat: ctf.ArchiveFormatTar}
+		_, err := opts.Retag(logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("would both be retagged to example.com/a:v1.0.0"))
+	})
+
+	It("should reject mappings that map a version multiple times", func() {
+		writeMapping(`
+versions:
+- from: v1.0.0-dev
+  to: v1.0.0
+- from: v1.0.0-dev
+  to: v1.0.1
+`)
+		_, err := cmd.ParseVersionMapping(fs, "/mapping.yaml")
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,375 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/signatures"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// SignOptions defines the options that are used to sign all component descriptors of a ctf.
+type SignOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+	// ArchiveFormat defines the format of the component archives that are written back to the ctf.
+	ArchiveFormat ctf.ArchiveFormat
+
+	// SignatureName defines the name for the generated signature.
+	SignatureName string
+	// HashAlgorithm is the algorithm that is used to hash the resources and the normalised component descriptors.
+	HashAlgorithm string
+	// SkipAccessTypes defines the access types of resources that are excluded from the signature.
+	SkipAccessTypes []string
+	// Force replaces existing signatures with the same name.
+	Force bool
+
+	SignerOptions
+
+	// OciOptions contains all exposed options to configure the oci client
+	// that is used to digest resources that are not contained in the ctf.
+	OciOptions ociopts.Options
+}
+
+// NewSignCommand creates a new command to sign all component descriptors of a ctf.
+func NewSignCommand(ctx context.Context) *cobra.Command {
+	opts := &SignOptions{}
+	cmd := &cobra.Command{
+		Use:   "sign CTF_PATH",
+		Args:  cobra.ExactArgs(1),
+		Short: "Signs all component descriptors of a ctf",
+		Long: `
+Adds digests to all resources and component references and signs all component descriptors of a ctf,
+e.g. to sign a whole delivery before it is shipped. The signed component archives are written back to the ctf.
+Compressed ctfs keep their gzip or zstd compression.
+
+Local blobs are digested from the ctf. Component references to components of the ctf are digested
+with the digest of the referenced component descriptor, references to other components must already contain a digest.
+Resources with other access types keep their existing digest or are digested with the oci client,
+resources with an access type of --skip-access-types are excluded from the signature.
+
+Signing fails if a component descriptor already contains a signature with the same name,
+use --force to replace it or "ctf resign" to re-sign component descriptors that already contain all digests.
+`,
+		Example: `
+component-cli ctf sign ./delivery.ctf --signature-name release --private-key ./key.pem
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *SignOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	signer, err := o.SignerOptions.Build()
+	if err != nil {
+		return err
+	}
+
+	count, err := o.SignWithSigner(ctx, log, fs, signer)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Successfully signed %d component(s)\n", count)
+	return nil
+}
+
+// SignWithSigner adds the digests to all component descriptors of the ctf and signs them with the given signer.
+// It returns the number of signed components.
+func (o *SignOptions) SignWithSigner(ctx context.Context, log logr.Logger, fs vfs.FileSystem, signer cdv2Sign.Signer) (int, error) {
+	digester := &ctfDigester{
+		log:             log,
+		fs:              fs,
+		hashAlgorithm:   o.HashAlgorithm,
+		skipAccessTypes: sets.NewString(o.SkipAccessTypes...),
+		ociOptions:      &o.OciOptions,
+		descriptors:     map[string]*cdv2.ComponentDescriptor{},
+		blobDigests:     map[string]map[string]*cdv2.DigestSpec{},
+		state:           map[string]digestState{},
+	}
+	// only the component descriptors and the digests of the local blobs are kept,
+	// the component archives are read again when the signed ctf is written.
+	keys := []string{}
+	err := walkCTF(fs, o.CTFPath, func(ca *ctf.ComponentArchive) error {
+		cd := ca.ComponentDescriptor
+		for _, sig := range cd.Signatures {
+			if sig.Name == o.SignatureName && !o.Force {
+				return fmt.Errorf("component descriptor %s:%s is already signed with signature %q, use --force or \"ctf resign\" to replace it",
+					cd.GetName(), cd.GetVersion(), o.SignatureName)
+			}
+		}
+		cd.Signatures = removeSignatures(cd.Signatures, o.SignatureName)
+		if err := digester.digestLocalBlobs(ctx, ca); err != nil {
+			return err
+		}
+		key := componentKey(cd.GetName(), cd.GetVersion())
+		digester.descriptors[key] = cd
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		cd := digester.descriptors[key]
+		if err := digester.addDigests(ctx, cd); err != nil {
+			return 0, err
+		}
+		hasher, err := cdv2Sign.HasherForName(o.HashAlgorithm)
+		if err != nil {
+			return 0, fmt.Errorf("unable to create hasher: %w", err)
+		}
+		if err := cdv2Sign.SignComponentDescriptor(cd, signer, *hasher, o.SignatureName); err != nil {
+			return 0, fmt.Errorf("unable to sign component descriptor %s:%s: %w", cd.GetName(), cd.GetVersion(), err)
+		}
+		log.V(3).Info(fmt.Sprintf("Signed component descriptor %s %s", cd.GetName(), cd.GetVersion()))
+	}
+
+	// the component archives are written to a new ctf so that the archive names are consistent with "ctf add".
+	// Ctfs in the directory layout are updated in place.
+	if err := replaceComponentDescriptors(fs, o.CTFPath, o.ArchiveFormat, digester.descriptors); err != nil {
+		return 0, fmt.Errorf("unable to write signed ctf: %w", err)
+	}
+	log.Info(fmt.Sprintf("Signed %d component(s)", len(keys)))
+	return len(keys), nil
+}
+
+// replaceComponentDescriptors rewrites the ctf with the given component descriptors by their component name and version.
+// The component archives are read and written one after another, the ctf is only replaced if all of them have been written.
+func replaceComponentDescriptors(fs vfs.FileSystem, ctfPath string, format ctf.ArchiveFormat, descriptors map[string]*cdv2.ComponentDescriptor) error {
+	w, err := newCTFWriter(fs, ctfPath, format, false)
+	if err != nil {
+		return err
+	}
+	defer w.Abort()
+	err = walkCTF(fs, ctfPath, func(ca *ctf.ComponentArchive) error {
+		if cd, ok := descriptors[componentKey(ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion())]; ok {
+			ca.ComponentDescriptor = cd
+		}
+		return w.Add(ca)
+	})
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+type digestState int
+
+const (
+	digestInProgress digestState = iota + 1
+	digestDone
+)
+
+// ctfDigester adds the digests of the resources and component references to the component descriptors of a ctf.
+type ctfDigester struct {
+	log             logr.Logger
+	fs              vfs.FileSystem
+	hashAlgorithm   string
+	skipAccessTypes sets.String
+	ociOptions      *ociopts.Options
+	// descriptors are the component descriptors of the ctf by their component name and version.
+	descriptors map[string]*cdv2.ComponentDescriptor
+	// blobDigests are the digests of the local blobs by their filename by the component name and version.
+	blobDigests map[string]map[string]*cdv2.DigestSpec
+	state       map[string]digestState
+	// remote is the lazily created digester for resources that are not contained in the ctf.
+	remote *signatures.Digester
+}
+
+// digestLocalBlobs digests the local blobs of all resources of the component archive that are not excluded from the signature.
+func (d *ctfDigester) digestLocalBlobs(ctx context.Context, ca *ctf.ComponentArchive) error {
+	cd := ca.ComponentDescriptor
+	key := componentKey(cd.GetName(), cd.GetVersion())
+	digests := map[string]*cdv2.DigestSpec{}
+	for i, res := range cd.Resources {
+		if res.Access == nil {
+			continue
+		}
+		if d.skipAccessTypes.Has(res.Access.GetType()) {
+			cd.Resources[i].Digest = cdv2.NewExcludeFromSignatureDigest()
+			continue
+		}
+		if res.Access.GetType() != cdv2.LocalFilesystemBlobType || reflect.DeepEqual(res.Digest, cdv2.NewExcludeFromSignatureDigest()) {
+			continue
+		}
+		blobAccess := &cdv2.LocalFilesystemBlobAccess{}
+		if err := res.Access.DecodeInto(blobAccess); err != nil {
+			return fmt.Errorf("unable to decode access of resource %q of component %s: %w", res.GetName(), key, err)
+		}
+		if _, ok := digests[blobAccess.Filename]; ok {
+			continue
+		}
+		hasher, err := cdv2Sign.HasherForName(d.hashAlgorithm)
+		if err != nil {
+			return fmt.Errorf("unable to create hasher: %w", err)
+		}
+		if _, err := ca.Resolve(ctx, res, hasher.HashFunction); err != nil {
+			return fmt.Errorf("unable to read local blob of resource %q of component %s: %w", res.GetName(), key, err)
+		}
+		digests[blobAccess.Filename] = &cdv2.DigestSpec{
+			HashAlgorithm:          hasher.AlgorithmName,
+			NormalisationAlgorithm: string(cdv2.GenericBlobDigestV1),
+			Value:                  hex.EncodeToString(hasher.HashFunction.Sum(nil)),
+		}
+	}
+	d.blobDigests[key] = digests
+	return nil
+}
+
+// addDigests adds the digests to the component descriptor.
+// Referenced component descriptors of the ctf are digested first.
+func (d *ctfDigester) addDigests(ctx context.Context, cd *cdv2.ComponentDescriptor) error {
+	key := componentKey(cd.GetName(), cd.GetVersion())
+	switch d.state[key] {
+	case digestDone:
+		return nil
+	case digestInProgress:
+		return fmt.Errorf("component %s references itself through its component references", key)
+	}
+	d.state[key] = digestInProgress
+
+	resourceDigest := func(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*cdv2.DigestSpec, error) {
+		return d.resourceDigest(ctx, key, cd, res)
+	}
+	if err := cdv2Sign.AddDigestsToComponentDescriptor(ctx, cd, d.referenceDigest, resourceDigest); err != nil {
+		return fmt.Errorf("unable to add digests to component descriptor %s: %w", key, err)
+	}
+
+	d.state[key] = digestDone
+	return nil
+}
+
+// referenceDigest returns the digest of the referenced component descriptor if it is part of the ctf.
+func (d *ctfDigester) referenceDigest(ctx context.Context, _ cdv2.ComponentDescriptor, ref cdv2.ComponentReference) (*cdv2.DigestSpec, error) {
+	refCd, ok := d.descriptors[componentKey(ref.ComponentName, ref.Version)]
+	if !ok {
+		if ref.Digest == nil {
+			return nil, fmt.Errorf("component %s:%s is not part of the ctf and the reference has no digest", ref.ComponentName, ref.Version)
+		}
+		return ref.Digest, nil
+	}
+	if err := d.addDigests(ctx, refCd); err != nil {
+		return nil, err
+	}
+	hasher, err := cdv2Sign.HasherForName(d.hashAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create hasher: %w", err)
+	}
+	return cdv2Sign.HashForComponentDescriptor(*refCd, *hasher)
+}
+
+// resourceDigest returns the digest of a local blob of the component with the given key
+// and digests all other resources with the oci client.
+func (d *ctfDigester) resourceDigest(ctx context.Context, key string, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*cdv2.DigestSpec, error) {
+	if res.Access == nil || res.Access.GetType() == "None" {
+		return nil, nil
+	}
+
+	if res.Access.GetType() == cdv2.LocalFilesystemBlobType {
+		blobAccess := &cdv2.LocalFilesystemBlobAccess{}
+		if err := res.Access.DecodeInto(blobAccess); err != nil {
+			return nil, fmt.Errorf("unable to decode access: %w", err)
+		}
+		digest, ok := d.blobDigests[key][blobAccess.Filename]
+		if !ok {
+			return nil, fmt.Errorf("local blob %q has not been digested", blobAccess.Filename)
+		}
+		return digest, nil
+	}
+
+	// resources that are not contained in the ctf keep their digest so that ctfs can be signed offline.
+	if res.Digest != nil {
+		return res.Digest, nil
+	}
+	hasher, err := cdv2Sign.HasherForName(d.hashAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create hasher: %w", err)
+	}
+	if d.remote == nil {
+		ociClient, _, err := d.ociOptions.Build(d.log, d.fs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to build oci client to digest resource with access type %s: %w", res.Access.GetType(), err)
+		}
+		d.remote = signatures.NewDigester(ociClient, *hasher)
+	}
+	return d.remote.DigestForResource(ctx, cd, res)
+}
+
+func (o *SignOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+
+	var err error
+	o.OciOptions.CacheDir, err = utils.CacheDir()
+	if err != nil {
+		return fmt.Errorf("unable to get oci cache directory: %w", err)
+	}
+
+	return o.Validate()
+}
+
+// Validate validates the sign options
+func (o *SignOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if len(o.SignatureName) == 0 {
+		return errors.New("a signature name must be provided")
+	}
+	if _, ok := cdv2Sign.HashFunctions[o.HashAlgorithm]; !ok {
+		return fmt.Errorf("unsupported hash algorithm %q", o.HashAlgorithm)
+	}
+	if err := o.SignerOptions.Validate(); err != nil {
+		return err
+	}
+	if o.ArchiveFormat != ctf.ArchiveFormatTar &&
+		o.ArchiveFormat != ctf.ArchiveFormatTarGzip {
+		return fmt.Errorf("unsupported archive format %q", o.ArchiveFormat)
+	}
+	return nil
+}
+
+func (o *SignOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.SignatureName, "signature-name", "", "name of the signature")
+	fs.StringVar(&o.HashAlgorithm, "hash-algorithm", cdv2Sign.SHA256, "algorithm that is used to hash the resources and the normalised component descriptors")
+	fs.StringSliceVar(&o.SkipAccessTypes, "skip-access-types", []string{}, "[OPTIONAL] comma separated list of access types that will not be digested and signed")
+	fs.BoolVar(&o.Force, "force", false, "replace existing signatures with the same name")
+	o.SignerOptions.AddFlags(fs)
+	componentarchive.OutputFormatVar(fs, &o.ArchiveFormat, "format", ctf.ArchiveFormatTar,
+		componentarchive.ArchiveOutputFormatUsage)
+	o.OciOptions.AddFlags(fs)
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+)
+
+var _ = Describe("Sign", func() {
+
+	var fs vfs.FileSystem
+
+	addComponents := func(archives ...string) {
+		opts := cmd.AddOptions{
+			CTFPath:            "/component.ctf",
+			ArchiveFormat:      ctf.ArchiveFormatTar,
+			ComponentArchives:  archives,
+			SkipReferenceCheck: true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	}
+
+	signOptions := func() cmd.SignOptions {
+		return cmd.SignOptions{
+			CTFPath:       "/component.ctf",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			SignatureName: "release",
+			HashAlgorithm: cdv2Sign.SHA256,
+		}
+	}
+
+	componentDescriptors := func() map[string]*cdv2.ComponentDescriptor {
+		ctfArchive, err := ctf.NewCTF(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		cds := map[string]*cdv2.ComponentDescriptor{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			cds[ca.ComponentDescriptor.GetName()] = ca.ComponentDescriptor
+			return nil
+		})).To(Succeed())
+		return cds
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a", "example.com/a", "v1.0.0", "blob", true)).To(Succeed())
+		Expect(writeComponentArchiveWithReferences(fs, "/b", "example.com/b", "v1.0.0", "example.com/a@v1.0.0")).To(Succeed())
+	})
+
+	It("should digest local blobs and component references and sign all component descriptors", func() {
+		addComponents("/a", "/b")
+		opts := signOptions()
+		count, err := opts.SignWithSigner(context.TODO(), logr.Discard(), fs, staticSigner{value: "sig"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(2))
+
+		cds := componentDescriptors()
+		blobDigest := sha256.Sum256([]byte("blob"))
+		Expect(cds["example.com/a"].Resources[0].Digest).To(Equal(&cdv2.DigestSpec{
+			HashAlgorithm:          cdv2Sign.SHA256,
+			NormalisationAlgorithm: string(cdv2.GenericBlobDigestV1),
+			Value:                  hex.EncodeToString(blobDigest[:]),
+		}))
+
+		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		refDigest, err := cdv2Sign.HashForComponentDescriptor(*cds["example.com/a"], *hasher)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cds["example.com/b"].ComponentReferences[0].Digest).To(Equal(refDigest))
+
+		for _, cd := range cds {
+			Expect(cd.Signatures).To(HaveLen(1))
+			Expect(cd.Signatures[0].Name).To(Equal("release"))
+			Expect(cd.Signatures[0].Signature.Value).To(Equal("sig"))
+		}
+	})
+
+	It("should fail if a referenced component is not part of the ctf and the reference has no digest", func() {
+		addComponents("/b")
+		opts := signOptions()
+		_, err := opts.SignWithSigner(context.TODO(), logr.Discard(), fs, staticSigner{value: "sig"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("example.com/a:v1.0.0 is not part of the ctf"))
+	})
+
+	It("should exclude resources with a skipped access type from the signature", func() {
+		Expect(fs.MkdirAll("/c", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, filepath.Join("/c", ctf.ComponentDescriptorFileName), []byte(`meta:
+  schemaVersion: 'v2'
+component:
+  name: 'example.com/c'
+  version: 'v1.0.0'
+  repositoryContexts: []
+  provider: 'internal'
+  sources: []
+  componentReferences: []
+  resources:
+  - name: 'image'
+    version: 'v1.0.0'
+    type: 'ociImage'
+    relation: 'external'
+    access:
+      type: 'ociRegistry'
+      imageReference: 'example.com/image:v1.0.0'
+`), os.ModePerm)).To(Succeed())
+		addComponents("/c")
+
+		opts := signOptions()
+		opts.SkipAccessTypes = []string{cdv2.OCIRegistryType}
+		_, err := opts.SignWithSigner(context.TODO(), logr.Discard(), fs, staticSigner{value: "sig"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(componentDescriptors()["example.com/c"].Resources[0].Digest).To(Equal(cdv2.NewExcludeFromSignatureDigest()))
+	})
+
+	It("should fail if a component descriptor is already signed with the signature name", func() {
+		addComponents("/a")
+		opts := signOptions()
+		_, err := opts.SignWithSigner(context.TODO(), logr.Discard(), fs, staticSigner{value: "sig"})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = opts.SignWithSigner(context.TODO(), logr.Discard(), fs, staticSigner{value: "other-sig"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("already signed"))
+
+		opts.Force = true
+		_, err = opts.SignWithSigner(context.TODO(), logr.Discard(), fs, staticSigner{value: "other-sig"})
+		Expect(err).ToNot(HaveOccurred())
+		cd := componentDescriptors()["example.com/a"]
+		Expect(cd.Signatures).To(HaveLen(1))
+		Expect(cd.Signatures[0].Signature.Value).To(Equal("other-sig"))
+	})
+
+})
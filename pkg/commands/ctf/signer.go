@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"errors"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/signatures"
+)
+
+const (
+	// RSASignerType signs the component descriptors with a local rsa private key.
+	RSASignerType = "rsa"
+	// SigningServerSignerType signs the component descriptors with a signature provided from a signing server.
+	SigningServerSignerType = "signing-server"
+)
+
+// SignerOptions defines the options that are used to create the signer of the component descriptors.
+type SignerOptions struct {
+	// Signer defines the type of signer that is used to sign the component descriptors.
+	Signer string
+
+	// PathToPrivateKey is the path to the rsa private key.
+	PathToPrivateKey string
+
+	// ServerURL is the url of the signing server.
+	ServerURL string
+	// ClientCertPath is the path to the client certificate used for authenticating to the signing server.
+	ClientCertPath string
+	// ClientKeyPath is the path to the private key of the client certificate.
+	ClientKeyPath string
+	// RootCACertsPath is the path to additional root ca certificates.
+	RootCACertsPath string
+}
+
+// Build creates the configured signer.
+func (o *SignerOptions) Build() (cdv2Sign.Signer, error) {
+	switch o.Signer {
+	case RSASignerType:
+		signer, err := cdv2Sign.CreateRSASignerFromKeyFile(o.PathToPrivateKey, cdv2.MediaTypePEM)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create rsa signer: %w", err)
+		}
+		return signer, nil
+	case SigningServerSignerType:
+		signer, err := signatures.NewSigningServerSigner(o.ServerURL, o.ClientCertPath, o.ClientKeyPath, o.RootCACertsPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create signing server signer: %w", err)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unknown signer %q", o.Signer)
+	}
+}
+
+// Validate validates the signer options
+func (o *SignerOptions) Validate() error {
+	switch o.Signer {
+	case RSASignerType:
+		if len(o.PathToPrivateKey) == 0 {
+			return errors.New("a path to a private key file must be provided")
+		}
+	case SigningServerSignerType:
+		if len(o.ServerURL) == 0 {
+			return errors.New("a server url must be provided")
+		}
+	default:
+		return fmt.Errorf("unknown signer %q, expected one of %q, %q", o.Signer, RSASignerType, SigningServerSignerType)
+	}
+	return nil
+}
+
+func (o *SignerOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Signer, "signer", RSASignerType, fmt.Sprintf("type of the signer. One of %q, %q", RSASignerType, SigningServerSignerType))
+	fs.StringVar(&o.PathToPrivateKey, "private-key", "", "path to the rsa private key file used for signing with the rsa signer")
+	fs.StringVar(&o.ServerURL, "server-url", "", "url where the signing server is running, e.g. https://localhost:8080")
+	fs.StringVar(&o.ClientCertPath, "client-cert", "", "[OPTIONAL] path to a file containing the client certificate in PEM format for authenticating to the signing server")
+	fs.StringVar(&o.ClientKeyPath, "client-key", "", "[OPTIONAL] path to a file containing the private key for the provided client certificate in PEM format")
+	fs.StringVar(&o.RootCACertsPath, "root-ca-certs", "", "[OPTIONAL] path to a file containing additional root ca certificates in PEM format. if empty, the system root ca certificate pool is used")
+}
@@ -0,0 +1,600 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/klauspost/compress/zstd"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// componentArchiveSource is a component archive that is written to a ctf.
+// Tar and gzipped tar archives are only scanned for their component descriptor and the names of their blobs,
+// so that their content is streamed into the ctf and never held in memory.
+type componentArchiveSource struct {
+	// path is the path of the component archive, it is empty for in-memory archives.
+	path string
+	// format is the format of the component archive at the path.
+	format ctf.ArchiveFormat
+	// cd is the component descriptor of the component archive.
+	cd *cdv2.ComponentDescriptor
+	// blobs contains the filenames of the local blobs of a tar or gzipped tar archive.
+	blobs sets.String
+	// ca is the component archive of archives in the filesystem format and of in-memory archives.
+	ca *ctf.ComponentArchive
+}
+
+// newComponentArchiveSource returns the source for an in-memory component archive.
+func newComponentArchiveSource(ca *ctf.ComponentArchive) *componentArchiveSource {
+	return &componentArchiveSource{
+		format: ctf.ArchiveFormatFilesystem,
+		cd:     ca.ComponentDescriptor,
+		ca:     ca,
+	}
+}
+
+// openComponentArchiveSource opens the component archive at the given path.
+// Directories are read as component archives in the filesystem format, files as tar or gzipped tar archives.
+func openComponentArchiveSource(fs vfs.FileSystem, caPath string) (*componentArchiveSource, error) {
+	info, err := fs.Stat(caPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("component archive at %q does not exist", caPath)
+		}
+		return nil, fmt.Errorf("unable to read %q: %w", caPath, err)
+	}
+	if info.IsDir() {
+		ca, _, err := componentarchive.Parse(fs, caPath)
+		if err != nil {
+			return nil, err
+		}
+		src := newComponentArchiveSource(ca)
+		src.path = caPath
+		return src, nil
+	}
+
+	c, err := detectCompression(fs, caPath)
+	if err != nil {
+		return nil, err
+	}
+	src := &componentArchiveSource{
+		path:   caPath,
+		format: ctf.ArchiveFormatTar,
+		blobs:  sets.NewString(),
+	}
+	switch c {
+	case noCompression:
+	case gzipCompression:
+		src.format = ctf.ArchiveFormatTarGzip
+	default:
+		return nil, fmt.Errorf("unsupported %s compression of component archive %q. Expected a tar or a tar.gz", c, caPath)
+	}
+
+	file, err := fs.Open(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component archive from %q: %w", caPath, err)
+	}
+	defer file.Close()
+	r, err := newTarReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component archive %q: %w", caPath, err)
+	}
+	defer r.Close()
+
+	blobsPrefix := "/" + ctf.BlobsDirectoryName + "/"
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("unable to read component archive %q: %w", caPath, err)
+		}
+		name := path.Clean("/" + header.Name)
+		switch {
+		case name == "/"+ctf.ComponentDescriptorFileName:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read %s of %q: %w", ctf.ComponentDescriptorFileName, caPath, err)
+			}
+			cd := &cdv2.ComponentDescriptor{}
+			if err := codec.Decode(data, cd); err != nil {
+				return nil, fmt.Errorf("unable to parse component descriptor of %q: %w", caPath, err)
+			}
+			src.cd = cd
+		case strings.HasPrefix(name, blobsPrefix) && header.Typeflag == tar.TypeReg:
+			src.blobs.Insert(strings.TrimPrefix(name, blobsPrefix))
+		}
+	}
+	if src.cd == nil {
+		return nil, fmt.Errorf("component archive %q contains no %s", caPath, ctf.ComponentDescriptorFileName)
+	}
+	return src, nil
+}
+
+// hasBlob returns whether the component archive contains the local blob of the resource with the given filename.
+func (s *componentArchiveSource) hasBlob(ctx context.Context, res cdv2.Resource, filename string) (bool, error) {
+	if s.ca == nil {
+		return s.blobs.Has(strings.TrimPrefix(path.Clean("/"+filename), "/")), nil
+	}
+	if _, err := s.ca.Info(ctx, res); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// writeEntry writes the component archive as entry with the given name and format to the tar.
+// Tar and gzipped tar archives that already have the requested format are copied without modification.
+// All other archives are written to a temporary file first as the size of a tar entry has to be known upfront.
+func (s *componentArchiveSource) writeEntry(fs vfs.FileSystem, tw *tar.Writer, name string, format ctf.ArchiveFormat) error {
+	if s.ca == nil && s.format == format {
+		file, err := fs.Open(s.path)
+		if err != nil {
+			return fmt.Errorf("unable to read component archive %q: %w", s.path, err)
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return fmt.Errorf("unable to get size of component archive %q: %w", s.path, err)
+		}
+		return writeTarEntry(tw, name, info.Size(), file)
+	}
+
+	tmpFile, err := vfs.TempFile(fs, "", "component-archive-")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	defer func() {
+		_ = tmpFile.Close()
+		_ = fs.Remove(tmpFile.Name())
+	}()
+	if err := s.write(fs, tmpFile, format); err != nil {
+		return fmt.Errorf("unable to write component archive to %q: %w", name, err)
+	}
+	info, err := tmpFile.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to get size of component archive %q: %w", name, err)
+	}
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to read component archive %q: %w", name, err)
+	}
+	return writeTarEntry(tw, name, info.Size(), tmpFile)
+}
+
+// write writes the component archive in the given format to the writer.
+// Tar and gzipped tar archives are converted as stream.
+func (s *componentArchiveSource) write(fs vfs.FileSystem, w io.Writer, format ctf.ArchiveFormat) error {
+	if s.ca != nil {
+		switch format {
+		case ctf.ArchiveFormatTar:
+			return s.ca.WriteTar(w)
+		case ctf.ArchiveFormatTarGzip:
+			return s.ca.WriteTarGzip(w)
+		default:
+			return fmt.Errorf("unsupported archive format %q", format)
+		}
+	}
+
+	file, err := fs.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	switch {
+	case s.format == ctf.ArchiveFormatTar && format == ctf.ArchiveFormatTarGzip:
+		zw := gzip.NewWriter(w)
+		if _, err := io.Copy(zw, file); err != nil {
+			return err
+		}
+		return zw.Close()
+	case s.format == ctf.ArchiveFormatTarGzip && format == ctf.ArchiveFormatTar:
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("unable to open gzip reader: %w", err)
+		}
+		defer zr.Close()
+		_, err = io.Copy(w, zr)
+		return err
+	case s.format == format:
+		_, err := io.Copy(w, file)
+		return err
+	default:
+		return fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+// writeDirectoryEntry writes the component archive to its subdirectory of the ctf in the directory layout.
+// Tar and gzipped tar archives are extracted as stream.
+func (s *componentArchiveSource) writeDirectoryEntry(fs vfs.FileSystem, ctfPath string) error {
+	if s.ca != nil {
+		return writeDirectoryComponentArchive(fs, ctfPath, s.ca)
+	}
+	return writeDirectoryEntry(fs, ctfPath, s.cd, func(dir string) error {
+		file, err := fs.Open(s.path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r, err := newTarReader(file)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		return extractTar(fs, tar.NewReader(r), dir)
+	})
+}
+
+// extractTar extracts the regular files of the tar to the given directory.
+// The names of the entries are cleaned so that no file is written outside of the directory.
+func extractTar(fs vfs.FileSystem, tr *tar.Reader, dir string) error {
+	if err := fs.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		filePath := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+header.Name)))
+		if err := fs.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			return err
+		}
+		file, err := fs.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, tr); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("unable to extract %q: %w", header.Name, err)
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+}
+
+// writeTarEntry writes a regular file entry with the given name and content to the tar.
+func writeTarEntry(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     int64(os.ModePerm),
+		ModTime:  time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("unable to write header for %q: %w", name, err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("unable to write component archive %q: %w", name, err)
+	}
+	return nil
+}
+
+// ctfWriter writes the component archives of a new ctf one after another,
+// so that the callers never have to hold more than one component archive in memory.
+// The ctf at the path is only replaced when the writer is closed, so that a failed write leaves it unchanged:
+// a tar is written to a temporary file in the same directory that is renamed to the ctf,
+// the component archives of a ctf in the directory layout are written to hidden directories that replace their entries.
+type ctfWriter struct {
+	fs      vfs.FileSystem
+	ctfPath string
+	format  ctf.ArchiveFormat
+	done    bool
+
+	// directory is set for a ctf in the directory layout.
+	directory bool
+	// created is set if the directory of the ctf was created by the writer.
+	created bool
+	// staged are the names of the written entries of a ctf in the directory layout.
+	staged []string
+
+	// file is the temporary file of a tar.
+	file vfs.File
+	c    compression
+	zw   io.WriteCloser
+	tw   *tar.Writer
+	// mode are the permissions of the written tar.
+	mode os.FileMode
+	// indexed is set if the tar replaces an indexed ctf.
+	indexed bool
+}
+
+// newCTFWriter returns a writer for a new ctf at the given path.
+// A ctf in the directory layout is written if directory is set or the path is an existing directory.
+// Component archives of a directory that are not written are kept.
+// A tar keeps the compression of an existing file at the path, new files are compressed according to their extension.
+func newCTFWriter(fs vfs.FileSystem, ctfPath string, format ctf.ArchiveFormat, directory bool) (*ctfWriter, error) {
+	compress := compressionFromExtension(ctfPath)
+	if info, err := fs.Stat(ctfPath); err == nil {
+		directory = directory || info.IsDir()
+		if !info.IsDir() {
+			if compress, err = detectCompression(fs, ctfPath); err != nil {
+				return nil, err
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unable to get info for %s: %w", ctfPath, err)
+	}
+	if !directory {
+		return newTarCTFWriter(fs, ctfPath, format, compress)
+	}
+
+	w := &ctfWriter{
+		fs:        fs,
+		ctfPath:   ctfPath,
+		format:    format,
+		directory: true,
+	}
+	if _, err := fs.Stat(ctfPath); errors.Is(err, os.ErrNotExist) {
+		w.created = true
+	}
+	if err := fs.MkdirAll(ctfPath, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("unable to create ctf directory %q: %w", ctfPath, err)
+	}
+	return w, nil
+}
+
+// newTarCTFWriter returns a writer for a new tar ctf at the given path with the given compression.
+// A plain ctf that replaces an indexed ctf is indexed again.
+func newTarCTFWriter(fs vfs.FileSystem, ctfPath string, format ctf.ArchiveFormat, c compression, zstdOpts ...zstd.EOption) (*ctfWriter, error) {
+	w := &ctfWriter{
+		fs:      fs,
+		ctfPath: ctfPath,
+		format:  format,
+		c:       c,
+		mode:    0644,
+	}
+	if info, err := fs.Stat(ctfPath); err == nil {
+		w.mode = info.Mode().Perm()
+		if c == noCompression {
+			index, err := readCTFIndex(fs, ctfPath)
+			if err != nil {
+				return nil, err
+			}
+			w.indexed = index != nil
+		}
+	}
+
+	file, err := vfs.TempFile(fs, filepath.Dir(ctfPath), ".ctf-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	zw, err := newCompressedWriter(file, c, zstdOpts...)
+	if err != nil {
+		_ = file.Close()
+		_ = fs.Remove(file.Name())
+		return nil, err
+	}
+	w.file = file
+	w.zw = zw
+	w.tw = tar.NewWriter(zw)
+	return w, nil
+}
+
+// Add writes the component archive to the ctf.
+func (w *ctfWriter) Add(ca *ctf.ComponentArchive) error {
+	cd := ca.ComponentDescriptor
+	if w.directory {
+		name := ctfDirectoryEntryName(cd.GetName(), cd.GetVersion())
+		tmpPath := filepath.Join(w.ctfPath, "."+name+".tmp")
+		if err := removeAll(w.fs, tmpPath); err != nil {
+			return fmt.Errorf("unable to remove %q: %w", tmpPath, err)
+		}
+		w.staged = append(w.staged, name)
+		if err := ca.WriteToFilesystem(w.fs, tmpPath); err != nil {
+			return fmt.Errorf("unable to write component archive %q: %w", name, err)
+		}
+		return nil
+	}
+
+	name := utils.CTFComponentArchiveFilename(cd.GetName(), cd.GetVersion())
+	if err := newComponentArchiveSource(ca).writeEntry(w.fs, w.tw, name, w.format); err != nil {
+		return fmt.Errorf("unable to add component archive %q to ctf: %w", cd.GetName(), err)
+	}
+	return nil
+}
+
+// Close replaces the ctf with the written component archives.
+func (w *ctfWriter) Close() error {
+	if w.done {
+		return nil
+	}
+	if err := w.commit(); err != nil {
+		w.Abort()
+		return fmt.Errorf("unable to write ctf at %q: %w", w.ctfPath, err)
+	}
+	w.done = true
+	if !w.indexed {
+		return nil
+	}
+	if err := writeCTFIndex(w.fs, w.ctfPath, nil, nil); err != nil {
+		return fmt.Errorf("unable to write the index of the ctf: %w", err)
+	}
+	return nil
+}
+
+func (w *ctfWriter) commit() error {
+	if w.directory {
+		for len(w.staged) != 0 {
+			name := w.staged[0]
+			entryPath := filepath.Join(w.ctfPath, name)
+			if err := removeAll(w.fs, entryPath); err != nil {
+				return fmt.Errorf("unable to remove component archive %q: %w", name, err)
+			}
+			if err := w.fs.Rename(filepath.Join(w.ctfPath, "."+name+".tmp"), entryPath); err != nil {
+				return fmt.Errorf("unable to write component archive %q: %w", name, err)
+			}
+			w.staged = w.staged[1:]
+		}
+		return nil
+	}
+
+	if err := w.tw.Close(); err != nil {
+		return fmt.Errorf("unable to close tar writer: %w", err)
+	}
+	if err := w.zw.Close(); err != nil {
+		return fmt.Errorf("unable to close %s writer: %w", w.c, err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("unable to sync temporary file: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("unable to close temporary file: %w", err)
+	}
+	if err := w.fs.Chmod(w.file.Name(), w.mode); err != nil {
+		return fmt.Errorf("unable to set permissions of temporary file: %w", err)
+	}
+	return utils.ReplaceFile(w.fs, w.file.Name(), w.ctfPath)
+}
+
+// Abort removes all written component archives and leaves the ctf unchanged.
+// It does nothing if the writer has been closed successfully, so that it can be deferred.
+func (w *ctfWriter) Abort() {
+	if w.done {
+		return
+	}
+	w.done = true
+	if w.directory {
+		for _, name := range w.staged {
+			_ = removeAll(w.fs, filepath.Join(w.ctfPath, "."+name+".tmp"))
+		}
+		if w.created {
+			_ = removeAll(w.fs, w.ctfPath)
+		}
+		return
+	}
+	_ = w.zw.Close()
+	_ = w.file.Close()
+	_ = w.fs.Remove(w.file.Name())
+}
+
+// rewriteTarCTF rewrites the tar ctf at the given path with the given compression.
+// All entries of the existing ctf except the skipped ones are copied, then write is called to add further entries.
+// The ctf is written to a temporary file in the same directory that is renamed to the ctf afterwards,
+// so that the ctf is not corrupted if the rewrite fails.
+func rewriteTarCTF(fs vfs.FileSystem, ctfPath string, c compression, skip sets.String, write func(tw *tar.Writer) error) error {
+	info, err := fs.Stat(ctfPath)
+	if err != nil {
+		return fmt.Errorf("unable to get info for %s: %w", ctfPath, err)
+	}
+	in, err := fs.Open(ctfPath)
+	if err != nil {
+		return fmt.Errorf("unable to open ctf at %q: %w", ctfPath, err)
+	}
+	defer in.Close()
+	r, err := newTarReader(in)
+	if err != nil {
+		return fmt.Errorf("unable to read ctf at %q: %w", ctfPath, err)
+	}
+	defer r.Close()
+
+	tmpFile, err := vfs.TempFile(fs, filepath.Dir(ctfPath), ".ctf-")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	err = writeTarEntries(tmpFile, c, func(tw *tar.Writer) error {
+		if err := copyTarEntries(tar.NewReader(r), tw, skip); err != nil {
+			return err
+		}
+		if write == nil {
+			return nil
+		}
+		return write(tw)
+	})
+	if err != nil {
+		_ = tmpFile.Close()
+		_ = fs.Remove(tmpPath)
+		return fmt.Errorf("unable to rewrite ctf at %q: %w", ctfPath, err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		_ = tmpFile.Close()
+		_ = fs.Remove(tmpPath)
+		return fmt.Errorf("unable to sync temporary file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = fs.Remove(tmpPath)
+		return fmt.Errorf("unable to close temporary file: %w", err)
+	}
+	defer fs.Remove(tmpPath)
+	if err := fs.Chmod(tmpPath, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("unable to set permissions of temporary file: %w", err)
+	}
+	if err := utils.ReplaceFile(fs, tmpPath, ctfPath); err != nil {
+		return fmt.Errorf("unable to replace ctf at %q: %w", ctfPath, err)
+	}
+	return nil
+}
+
+// writeTarEntries writes a tar with the given compression to the writer.
+// The entries of the tar are written by the given func.
+func writeTarEntries(w io.Writer, c compression, write func(tw *tar.Writer) error, zstdOpts ...zstd.EOption) error {
+	zw, err := newCompressedWriter(w, c, zstdOpts...)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+	if err := write(tw); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("unable to close tar writer: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("unable to close %s writer: %w", c, err)
+	}
+	return nil
+}
+
+// copyTarEntries copies all entries of the tar except the skipped ones to the tar writer.
+func copyTarEntries(tr *tar.Reader, tw *tar.Writer, skip sets.String) error {
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if skip.Has(header.Name) {
+			continue
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("unable to write header of %q: %w", header.Name, err)
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return fmt.Errorf("unable to write %q: %w", header.Name, err)
+		}
+	}
+}
@@ -0,0 +1,491 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/transport/config"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	processutils "github.com/gardener/component-cli/pkg/transport/process/utils"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// TransformOptions defines the options that are used to transform a ctf with a transport config.
+type TransformOptions struct {
+	// CTFPath is the path to the source ctf.
+	CTFPath string
+	// OutputPath is the path to the transformed ctf.
+	OutputPath string
+	// TransportConfigPath is the path to the transport config.
+	TransportConfigPath string
+	// ArchiveFormat defines the component archive format of the transformed ctf.
+	ArchiveFormat ctf.ArchiveFormat
+	// Directory writes the transformed ctf in the directory layout.
+	Directory bool
+
+	// OciOptions contains all exposed options to configure the oci client
+	// that is used by processors that access an oci registry.
+	OciOptions ociopts.Options
+}
+
+// TransformSummary describes the result of a ctf transformation.
+type TransformSummary struct {
+	// Components is the number of transformed components.
+	Components int
+	// Resources is the number of resources of all components.
+	Resources int
+	// Processed is the number of resources that matched at least one processing rule.
+	Processed int
+	// Modified is the number of processed resources that were changed by the processors.
+	Modified int
+	// FilteredOut is the number of resources that matched no processing rule and were copied unchanged.
+	FilteredOut int
+	// Sources is the number of sources of all components.
+	Sources int
+	// RemovedSources is the number of sources that did not match the source filters and were removed.
+	RemovedSources int
+}
+
+// NewTransformCommand creates a new command to transform a ctf with a transport config.
+func NewTransformCommand(ctx context.Context) *cobra.Command {
+	opts := &TransformOptions{}
+	cmd := &cobra.Command{
+		Use:   "transform CTF_PATH --transport-config CONFIG_PATH --output OUTPUT_PATH",
+		Args:  cobra.ExactArgs(1),
+		Short: "Transforms all components of a ctf with the processors of a transport config",
+		Long: `
+Transforms all components of a ctf with the processing rules of a transport config and writes the result to a new ctf.
+The new ctf is compressed according to its .tar.gz, .tgz, .tar.zst or .tzst extension.
+
+Every resource is passed through the processors of all processing rules whose filters match the resource.
+Resources that match no processing rule are copied unchanged.
+The local blobs of the resources are passed to the processors and the processed blobs are written back to the component archives.
+Blobs of resources that no longer have a local access, e.g. after the BlobDropper, are removed.
+Downloaders and uploaders of the transport config are ignored.
+
+The supported processor types are "ResourceLabeler", "LabelRenamer", "ReferenceCanonicalizer", "BaseImageMapper",
+"EffectiveURLLabeler", "VersionAligner", "ResourceSchemaValidator", "CosignVerifier", "Digester", "Relocator",
+"LabelRemover", "BlobDropper" and "Executable".
+
+The CosignVerifier verifies the cosign signatures of oci images with the PEM encoded public key at the path of "key".
+Keyless verification is not supported.
+
+The BlobDropper drops the local blobs of resources and references them with the external access that is defined
+for the resource name and the optional component name. The processing fails for local blobs without an external access.
+
+<pre>
+
+processors:
+- name: drop-blobs
+  type: BlobDropper
+  spec:
+    externalAccesses:
+    - componentName: github.com/gardener/my-component  # optional
+      resourceName: my-chart
+      access:
+        type: ociRegistry
+        imageReference: eu.gcr.io/my-project/charts/my-chart:v1.0.0
+
+</pre>
+
+With "expandEnv: true" the ResourceLabeler expands ${VAR} placeholders in label values from the environment.
+Variables that are not set expand to an empty string unless "strictEnv: true" is set, then the processing fails.
+
+Failed processors are retried as defined by the optional retry section of the transport config:
+
+<pre>
+
+retry:
+  retries: 3      # number of retries after a failed attempt
+  backoff: 1s     # wait duration before the first retry, doubled for every further retry
+  timeout: 30s    # timeout of a single attempt
+
+</pre>
+
+By default every processor runs once with a timeout of 30s.
+
+The sources of the components are filtered by the optional sourceFilters section of the transport config.
+Sources that do not match all source filters are removed, all sources are kept if no source filters are defined.
+The filters "SourceFilter", "ComponentNameFilter", "VersionFilter", "AndFilter", "OrFilter" and "NotFilter" can be used as source filters,
+the composite filters may only contain filters of these types.
+Blobs of removed sources remain in the component archives and can be removed with "ctf prune".
+
+<pre>
+
+sourceFilters:
+- type: SourceFilter
+  spec:
+    includeSourceTypes:
+    - git
+
+</pre>
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *TransformOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+
+	summary, err := o.Transform(ctx, log, fs, processors.NewProcessorFactory(log, ociClient))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Transformed %d component(s) into %q\n", summary.Components, o.OutputPath)
+	fmt.Printf("Resources: %d, processed: %d, modified: %d, filtered out: %d\n",
+		summary.Resources, summary.Processed, summary.Modified, summary.FilteredOut)
+	fmt.Printf("Sources: %d, removed: %d\n", summary.Sources, summary.RemovedSources)
+	return nil
+}
+
+// Transform transforms the ctf with the processors that are created by the given factory.
+func (o *TransformOptions) Transform(ctx context.Context, log logr.Logger, fs vfs.FileSystem, factory *processors.ProcessorFactory) (*TransformSummary, error) {
+	transportCfg, err := config.ParseTransportConfig(o.TransportConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse transport config: %w", err)
+	}
+
+	w, err := newCTFWriter(fs, o.OutputPath, o.ArchiveFormat, o.Directory)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Abort()
+	summary := &TransformSummary{}
+	err = walkCTF(fs, o.CTFPath, func(ca *ctf.ComponentArchive) error {
+		transformed, err := transformComponent(ctx, log, transportCfg, factory, ca, summary)
+		if err != nil {
+			return fmt.Errorf("unable to transform component %s: %w",
+				componentKey(ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion()), err)
+		}
+		summary.Components++
+		return w.Add(transformed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("unable to write transformed ctf: %w", err)
+	}
+	log.Info(fmt.Sprintf("Transformed %d component(s) into %q", summary.Components, o.OutputPath))
+	return summary, nil
+}
+
+// transformComponent runs the processors of all matching processing rules for every resource of the component archive
+// and returns the component archive with the processed resources.
+// The local blobs of the resources are passed to the processors and the processed blobs are written back,
+// blobs that are no longer referenced are removed.
+// Sources that do not match the source filters are removed.
+func transformComponent(ctx context.Context, log logr.Logger, transportCfg *config.ParsedTransportConfig, factory *processors.ProcessorFactory, ca *ctf.ComponentArchive, summary *TransformSummary) (*ctf.ComponentArchive, error) {
+	cd := ca.ComponentDescriptor
+
+	// the local blobs are processed in a writable copy of the component archive.
+	var caFs vfs.FileSystem
+	if hasLocalBlobs(cd) {
+		caFs = memoryfs.New()
+		if err := ca.WriteToFilesystem(caFs, "/"); err != nil {
+			return nil, fmt.Errorf("unable to copy component archive: %w", err)
+		}
+		ca = ctf.NewComponentArchive(cd, caFs)
+	}
+
+	replacedAccesses := []*cdv2.UnstructuredTypedObject{}
+	for i, res := range cd.Resources {
+		summary.Resources++
+		rules := transportCfg.MatchProcessingRules(*cd, res)
+		if len(rules) == 0 {
+			summary.FilteredOut++
+			log.V(5).Info("no processing rule matches resource", "resource", res.Name)
+			continue
+		}
+
+		procs := []process.ResourceStreamProcessor{}
+		if caFs != nil {
+			procs = append(procs, &localBlobReader{fs: caFs})
+		}
+		for _, rule := range rules {
+			for _, procDef := range rule.Processors {
+				proc, err := factory.Create(procDef.Type, procDef.Spec)
+				if err != nil {
+					return nil, fmt.Errorf("unable to create processor %s of processing rule %s: %w", procDef.Name, rule.Name, err)
+				}
+				procs = append(procs, proc)
+			}
+		}
+		if caFs != nil {
+			procs = append(procs, &localBlobWriter{fs: caFs})
+		}
+
+		pipeline := process.NewResourceProcessingPipelineWithOptions(process.PipelineOptions{Retry: transportCfg.Retry}, procs...)
+		_, processedRes, err := pipeline.Process(ctx, *cd, res)
+		if err != nil {
+			return nil, fmt.Errorf("unable to process resource %q: %w", res.Name, err)
+		}
+		summary.Processed++
+
+		modified, err := resourceModified(res, processedRes)
+		if err != nil {
+			return nil, err
+		}
+		if modified {
+			summary.Modified++
+			log.V(3).Info("modified resource", "resource", res.Name)
+			replacedAccesses = append(replacedAccesses, res.Access)
+		}
+		cd.Resources[i] = processedRes
+	}
+
+	sources := make([]cdv2.Source, 0, len(cd.Sources))
+	for _, src := range cd.Sources {
+		summary.Sources++
+		if !transportCfg.MatchSource(*cd, src) {
+			summary.RemovedSources++
+			log.V(3).Info("removed source that does not match the source filters", "source", src.Name)
+			continue
+		}
+		sources = append(sources, src)
+	}
+	cd.Sources = sources
+
+	if err := cdvalidation.Validate(cd); err != nil {
+		return nil, fmt.Errorf("invalid component descriptor: %w", err)
+	}
+
+	if caFs != nil {
+		for _, access := range replacedAccesses {
+			if err := componentarchive.RemoveUnreferencedBlob(log, caFs, cd, access); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ca, nil
+}
+
+// hasLocalBlobs returns whether a resource of the component descriptor has a local filesystem blob access.
+func hasLocalBlobs(cd *cdv2.ComponentDescriptor) bool {
+	for _, res := range cd.Resources {
+		if _, ok, _ := componentarchive.LocalBlobFilename(res.Access); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// localBlobReader is the first processor of the pipeline of a resource.
+// It passes the local blob of the resource from the component archive to the following processors.
+type localBlobReader struct {
+	fs vfs.FileSystem
+}
+
+func (p *localBlobReader) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := processutils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	var blobReader io.Reader
+	filename, ok, err := componentarchive.LocalBlobFilename(res.Access)
+	if err != nil {
+		return err
+	}
+	if ok {
+		file, err := p.fs.Open(ctf.BlobPath(filename))
+		if err != nil {
+			return fmt.Errorf("unable to open local blob %q of resource %q: %w", filename, res.Name, err)
+		}
+		defer file.Close()
+		// files of the in-memory component archive cannot seek to their end,
+		// so the blob is passed as plain reader that is spooled by the processor message.
+		blobReader = struct{ io.Reader }{file}
+	}
+
+	if err := processutils.WriteProcessorMessage(*cd, res, blobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+	return nil
+}
+
+// localBlobWriter is the last processor of the pipeline of a resource.
+// It writes the processed blob of a resource with a local filesystem blob access back to the component archive.
+// Blobs of resources whose access was changed to an external access are dropped.
+type localBlobWriter struct {
+	fs vfs.FileSystem
+}
+
+func (p *localBlobWriter) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := processutils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+		access, err := p.writeBlob(res, resBlobReader)
+		if err != nil {
+			return fmt.Errorf("unable to write blob of resource %q: %w", res.Name, err)
+		}
+		res.Access = access
+	}
+
+	if err := processutils.WriteProcessorMessage(*cd, res, nil, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+	return nil
+}
+
+// writeBlob writes the blob of a resource with a local filesystem blob access to the component archive
+// and returns the access of the written blob.
+// Unmodified blobs keep their access, modified blobs are stored by their digest.
+func (p *localBlobWriter) writeBlob(res cdv2.Resource, blob io.Reader) (*cdv2.UnstructuredTypedObject, error) {
+	if res.Access == nil || res.Access.GetType() != cdv2.LocalFilesystemBlobType {
+		return res.Access, nil
+	}
+	localAccess := &cdv2.LocalFilesystemBlobAccess{}
+	if err := res.Access.DecodeInto(localAccess); err != nil {
+		return nil, fmt.Errorf("unable to decode local filesystem blob access: %w", err)
+	}
+
+	const tmpPath = "/.processed-blob.tmp"
+	file, err := p.fs.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temporary blob: %w", err)
+	}
+	defer func() {
+		_ = p.fs.Remove(tmpPath)
+	}()
+	digester := digest.Canonical.Digester()
+	if _, err := io.Copy(io.MultiWriter(file, digester.Hash()), blob); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("unable to write temporary blob: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("unable to close temporary blob: %w", err)
+	}
+	dig := digester.Digest()
+
+	if existing, err := p.fs.Open(ctf.BlobPath(localAccess.Filename)); err == nil {
+		existingDig, err := digest.Canonical.FromReader(existing)
+		existing.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to calculate digest of local blob %q: %w", localAccess.Filename, err)
+		}
+		if existingDig == dig {
+			return res.Access, nil
+		}
+	}
+
+	if _, err := p.fs.Stat(ctf.BlobPath(dig.String())); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("unable to get file info for blob %q: %w", dig.String(), err)
+		}
+		if err := p.fs.MkdirAll(ctf.BlobsDirectoryName, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("unable to create blob directory: %w", err)
+		}
+		if err := p.fs.Rename(tmpPath, ctf.BlobPath(dig.String())); err != nil {
+			return nil, fmt.Errorf("unable to write blob %q: %w", dig.String(), err)
+		}
+	}
+
+	access, err := cdv2.NewUnstructured(cdv2.NewLocalFilesystemBlobAccess(dig.String(), localAccess.MediaType))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create local filesystem blob access: %w", err)
+	}
+	return &access, nil
+}
+
+func resourceModified(res, processedRes cdv2.Resource) (bool, error) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return false, fmt.Errorf("unable to marshal resource: %w", err)
+	}
+	processedData, err := json.Marshal(processedRes)
+	if err != nil {
+		return false, fmt.Errorf("unable to marshal processed resource: %w", err)
+	}
+	return !bytes.Equal(data, processedData), nil
+}
+
+func (o *TransformOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+
+	var err error
+	o.OciOptions.CacheDir, err = utils.CacheDir()
+	if err != nil {
+		return fmt.Errorf("unable to get oci cache directory: %w", err)
+	}
+
+	return o.Validate()
+}
+
+// Validate validates the transform options
+func (o *TransformOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if len(o.OutputPath) == 0 {
+		return errors.New("an output path must be provided")
+	}
+	if o.OutputPath == o.CTFPath {
+		return fmt.Errorf("the output path %q must not be the source ctf", o.OutputPath)
+	}
+	if len(o.TransportConfigPath) == 0 {
+		return errors.New("a transport config must be provided")
+	}
+	if o.ArchiveFormat != ctf.ArchiveFormatTar &&
+		o.ArchiveFormat != ctf.ArchiveFormatTarGzip {
+		return fmt.Errorf("unsupported archive format %q", o.ArchiveFormat)
+	}
+	return nil
+}
+
+func (o *TransformOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.OutputPath, "output", "o", "", "path to the transformed ctf")
+	fs.StringVar(&o.TransportConfigPath, "transport-config", "", "path to the transport config file")
+	componentarchive.OutputFormatVar(fs, &o.ArchiveFormat, "format", ctf.ArchiveFormatTar,
+		componentarchive.ArchiveOutputFormatUsage)
+	fs.BoolVar(&o.Directory, "directory", false,
+		"write the transformed ctf in the directory layout. Existing directories are always written in the directory layout")
+	o.OciOptions.AddFlags(fs)
+}
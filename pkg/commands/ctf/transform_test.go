@@ -0,0 +1,300 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+)
+
+var _ = Describe("Transform", func() {
+
+	const transportConfig = `
+meta:
+  version: v1
+processors:
+- name: labeler
+  type: ResourceLabeler
+  spec:
+    labels:
+    - name: transformed
+      value: true
+processingRules:
+- name: label-oci-images
+  processors:
+  - name: labeler
+    type: processor
+  filters:
+  - type: ResourceTypeFilter
+    spec:
+      includeResourceTypes:
+      - ociImage
+`
+
+	const componentDescriptor = `meta:
+  schemaVersion: 'v2'
+component:
+  name: 'example.com/component'
+  version: 'v0.0.0'
+  repositoryContexts: []
+  provider: 'internal'
+  sources:
+  - name: repository
+    version: 'v0.0.0'
+    type: git
+    access:
+      type: github
+      repoUrl: github.com/example/repository
+      commit: 0000000000000000000000000000000000000000
+  - name: docs
+    version: 'v0.0.0'
+    type: git
+    access:
+      type: github
+      repoUrl: github.com/example/docs
+      commit: 0000000000000000000000000000000000000000
+  componentReferences: []
+  resources:
+  - name: image
+    version: 'v0.0.0'
+    type: ociImage
+    relation: external
+    access:
+      type: ociRegistry
+      imageReference: example.com/image:v0.0.0
+  - name: chart
+    version: 'v0.0.0'
+    type: helm
+    relation: external
+    access:
+      type: ociRegistry
+      imageReference: example.com/chart:v0.0.0
+`
+
+	var (
+		fs        vfs.FileSystem
+		configDir string
+	)
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		var err error
+		configDir, err = os.MkdirTemp("", "transport-config-")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(transportConfig), 0600)).To(Succeed())
+
+		Expect(fs.MkdirAll("/component", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, filepath.Join("/component", ctf.ComponentDescriptorFileName), []byte(componentDescriptor), os.ModePerm)).To(Succeed())
+		addOpts := cmd.AddOptions{
+			CTFPath:           "/source.ctf",
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: []string{"/component"},
+		}
+		Expect(addOpts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(configDir)).To(Succeed())
+	})
+
+	It("should apply the processors of the matching processing rules", func() {
+		opts := &cmd.TransformOptions{
+			CTFPath:             "/source.ctf",
+			OutputPath:          "/output.ctf",
+			TransportConfigPath: filepath.Join(configDir, "config.yaml"),
+			ArchiveFormat:       ctf.ArchiveFormatTar,
+		}
+		Expect(opts.Validate()).To(Succeed())
+		summary, err := opts.Transform(context.TODO(), logr.Discard(), fs, processors.NewProcessorFactory(logr.Discard(), nil))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*summary).To(Equal(cmd.TransformSummary{
+			Components:  1,
+			Resources:   2,
+			Processed:   1,
+			Modified:    1,
+			FilteredOut: 1,
+			Sources:     2,
+		}))
+
+		ctfArchive, err := ctf.NewCTF(fs, "/output.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		resources := map[string]cdv2.Resource{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			for _, res := range ca.ComponentDescriptor.Resources {
+				resources[res.Name] = res
+			}
+			return nil
+		})).To(Succeed())
+		Expect(resources).To(HaveLen(2))
+		Expect(resources["image"].Labels).To(HaveLen(1))
+		Expect(resources["image"].Labels[0].Name).To(Equal("transformed"))
+		Expect(resources["chart"].Labels).To(BeEmpty())
+	})
+
+	It("should remove the sources that do not match the source filters", func() {
+		Expect(os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(transportConfig+`
+sourceFilters:
+- type: SourceFilter
+  spec:
+    includeSourceNames:
+    - repository
+`), 0600)).To(Succeed())
+		opts := &cmd.TransformOptions{
+			CTFPath:             "/source.ctf",
+			OutputPath:          "/output.ctf",
+			TransportConfigPath: filepath.Join(configDir, "config.yaml"),
+			ArchiveFormat:       ctf.ArchiveFormatTar,
+		}
+		summary, err := opts.Transform(context.TODO(), logr.Discard(), fs, processors.NewProcessorFactory(logr.Discard(), nil))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Sources).To(Equal(2))
+		Expect(summary.RemovedSources).To(Equal(1))
+
+		ctfArchive, err := ctf.NewCTF(fs, "/output.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		defer ctfArchive.Close()
+		sources := []string{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			Expect(ca.ComponentDescriptor.Resources).To(HaveLen(2))
+			for _, src := range ca.ComponentDescriptor.Sources {
+				sources = append(sources, src.Name)
+			}
+			return nil
+		})).To(Succeed())
+		Expect(sources).To(Equal([]string{"repository"}))
+	})
+
+	Context("local blobs", func() {
+
+		transform := func(config string) *cmd.TransformSummary {
+			Expect(writeComponentArchiveWithLocalBlob(fs, "/blob-component", "example.com/blob-component", "v0.0.0", "blob.txt", true)).To(Succeed())
+			addOpts := cmd.AddOptions{
+				CTFPath:           "/blob.ctf",
+				ArchiveFormat:     ctf.ArchiveFormatTar,
+				ComponentArchives: []string{"/blob-component"},
+			}
+			Expect(addOpts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(config), 0600)).To(Succeed())
+
+			opts := &cmd.TransformOptions{
+				CTFPath:             "/blob.ctf",
+				OutputPath:          "/output.ctf",
+				TransportConfigPath: filepath.Join(configDir, "config.yaml"),
+				ArchiveFormat:       ctf.ArchiveFormatTar,
+			}
+			summary, err := opts.Transform(context.TODO(), logr.Discard(), fs, processors.NewProcessorFactory(logr.Discard(), nil))
+			Expect(err).ToNot(HaveOccurred())
+			return summary
+		}
+
+		// readOutput returns the resource and the blob names of the component archive of the transformed ctf.
+		readOutput := func() (cdv2.Resource, []string) {
+			opts := cmd.GetOptions{CTFPath: "/output.ctf", Name: "example.com/blob-component"}
+			ca, err := opts.Get(fs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ca.ComponentDescriptor.Resources).To(HaveLen(1))
+			caFs := memoryfs.New()
+			Expect(ca.WriteToFilesystem(caFs, "/")).To(Succeed())
+			infos, err := vfs.ReadDir(caFs, ctf.BlobsDirectoryName)
+			Expect(err).ToNot(HaveOccurred())
+			names := []string{}
+			for _, info := range infos {
+				names = append(names, info.Name())
+			}
+			return ca.ComponentDescriptor.Resources[0], names
+		}
+
+		It("should pass the local blob of a resource to the processors", func() {
+			summary := transform(`
+processors:
+- name: digester
+  type: Digester
+processingRules:
+- name: digest
+  processors:
+  - name: digester
+    type: processor
+`)
+			Expect(summary.Modified).To(Equal(1))
+
+			res, blobs := readOutput()
+			Expect(res.Digest).ToNot(BeNil())
+			// sha256 of "blob"
+			Expect(res.Digest.Value).To(Equal("fa2c8cc4f28176bbeed4b736df569a34c79cd3723e9ec42f9674b4d46ac6b8b8"))
+			Expect(res.Access.GetType()).To(Equal(cdv2.LocalFilesystemBlobType))
+			Expect(blobs).To(Equal([]string{"blob.txt"}))
+		})
+
+		It("should remove the local blob of a resource that is dropped", func() {
+			transform(`
+processors:
+- name: dropper
+  type: BlobDropper
+  spec:
+    externalAccesses:
+    - resourceName: blob
+      access:
+        type: ociRegistry
+        imageReference: example.com/blob:v0.0.0
+processingRules:
+- name: drop
+  processors:
+  - name: dropper
+    type: processor
+`)
+
+			res, blobs := readOutput()
+			Expect(res.Access.GetType()).To(Equal(cdv2.OCIRegistryType))
+			Expect(blobs).To(BeEmpty())
+		})
+
+	})
+
+	It("should fail for an unknown processor type", func() {
+		Expect(os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(`
+processors:
+- name: unknown
+  type: Unknown
+processingRules:
+- name: all
+  processors:
+  - name: unknown
+    type: processor
+`), 0600)).To(Succeed())
+		opts := &cmd.TransformOptions{
+			CTFPath:             "/source.ctf",
+			OutputPath:          "/output.ctf",
+			TransportConfigPath: filepath.Join(configDir, "config.yaml"),
+			ArchiveFormat:       ctf.ArchiveFormatTar,
+		}
+		_, err := opts.Transform(context.TODO(), logr.Discard(), fs, processors.NewProcessorFactory(logr.Discard(), nil))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unknown processor type"))
+	})
+
+	It("should not allow the source ctf as output", func() {
+		opts := &cmd.TransformOptions{
+			CTFPath:             "/source.ctf",
+			OutputPath:          "/source.ctf",
+			TransportConfigPath: filepath.Join(configDir, "config.yaml"),
+			ArchiveFormat:       ctf.ArchiveFormatTar,
+		}
+		Expect(opts.Validate()).ToNot(Succeed())
+	})
+
+})
@@ -0,0 +1,256 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+const (
+	// TextOutput renders the tree as indented text.
+	TextOutput = "text"
+	// JSONOutput renders the tree as json.
+	JSONOutput = "json"
+)
+
+// TreeOptions defines the options that are used to show the dependency tree of a component in a ctf.
+type TreeOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+	// Component is the root component of the tree in the form "name:version".
+	Component string
+	// Output defines the output format of the tree.
+	Output string
+}
+
+// TreeNode is a component of the dependency tree.
+type TreeNode struct {
+	// ReferenceName is the name of the component reference that points to the component.
+	ReferenceName string `json:"referenceName,omitempty"`
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	// Unresolved is true if the component is not part of the ctf.
+	Unresolved bool `json:"unresolved,omitempty"`
+	// Cycle is true if the component is already one of its own ancestors.
+	// The references of the component are not traversed again.
+	Cycle      bool        `json:"cycle,omitempty"`
+	References []*TreeNode `json:"references,omitempty"`
+}
+
+// NewTreeCommand creates a new command to show the dependency tree of a component in a ctf.
+func NewTreeCommand(ctx context.Context) *cobra.Command {
+	opts := &TreeOptions{}
+	cmd := &cobra.Command{
+		Use:   "tree CTF_PATH --component name:version",
+		Args:  cobra.ExactArgs(1),
+		Short: "Shows the dependency tree of a component in a ctf",
+		Long: `
+Shows the tree of a component and its transitively referenced components of a ctf.
+Referenced components that are not part of the ctf are marked as unresolved.
+Components that reference one of their ancestors are marked as cycle and not traversed again.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *TreeOptions) Run(_ context.Context, _ logr.Logger, fs vfs.FileSystem) error {
+	tree, err := o.Tree(fs)
+	if err != nil {
+		return err
+	}
+	return WriteTree(os.Stdout, tree, o.Output)
+}
+
+// Tree builds the dependency tree of the configured component.
+func (o *TreeOptions) Tree(fs vfs.FileSystem) (*TreeNode, error) {
+	name, version, err := parseComponent(o.Component)
+	if err != nil {
+		return nil, err
+	}
+
+	components := map[string]*cdv2.ComponentDescriptor{}
+	err = walkCTF(fs, o.CTFPath, func(ca *ctf.ComponentArchive) error {
+		cd := ca.ComponentDescriptor
+		components[componentKey(cd.GetName(), cd.GetVersion())] = cd
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ctf: %w", err)
+	}
+
+	if _, ok := components[componentKey(name, version)]; !ok {
+		return nil, fmt.Errorf("component %s:%s is not part of the ctf", name, version)
+	}
+	b := &treeBuilder{
+		components: components,
+		ancestors:  map[string]bool{},
+		resolved:   map[string]*TreeNode{},
+	}
+	tree, _ := b.build("", name, version)
+	return tree, nil
+}
+
+// treeBuilder builds the dependency tree of a component.
+// The subtrees of components that are referenced multiple times are only built once.
+type treeBuilder struct {
+	components map[string]*cdv2.ComponentDescriptor
+	// ancestors are the component keys of the ancestors of the current node.
+	ancestors map[string]bool
+	// resolved are the nodes without cycles in their subtrees by their component key.
+	// Subtrees with cycles depend on the ancestors of the node and are built again for every reference.
+	resolved map[string]*TreeNode
+}
+
+// build returns the node of the given component and whether its subtree contains a cycle.
+func (b *treeBuilder) build(refName, name, version string) (*TreeNode, bool) {
+	key := componentKey(name, version)
+	if b.ancestors[key] {
+		return &TreeNode{ReferenceName: refName, Name: name, Version: version, Cycle: true}, true
+	}
+	if resolved, ok := b.resolved[key]; ok {
+		// the references are shared with all other nodes of the component
+		node := *resolved
+		node.ReferenceName = refName
+		return &node, false
+	}
+	node := &TreeNode{
+		ReferenceName: refName,
+		Name:          name,
+		Version:       version,
+	}
+	cd, ok := b.components[key]
+	if !ok {
+		node.Unresolved = true
+		return node, false
+	}
+
+	b.ancestors[key] = true
+	cycle := false
+	for _, ref := range cd.ComponentReferences {
+		child, childCycle := b.build(ref.Name, ref.ComponentName, ref.Version)
+		node.References = append(node.References, child)
+		cycle = cycle || childCycle
+	}
+	delete(b.ancestors, key)
+	if !cycle {
+		b.resolved[key] = node
+	}
+	return node, cycle
+}
+
+// WriteTree writes the tree in the given output format.
+func WriteTree(w io.Writer, tree *TreeNode, output string) error {
+	switch output {
+	case TextOutput:
+		if _, err := fmt.Fprintln(w, tree.String()); err != nil {
+			return err
+		}
+		return writeTreeReferences(w, tree.References, "")
+	case JSONOutput:
+		data, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal tree: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+}
+
+func writeTreeReferences(w io.Writer, nodes []*TreeNode, prefix string) error {
+	for i, node := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+		if _, err := fmt.Fprintln(w, prefix+branch+node.String()); err != nil {
+			return err
+		}
+		if err := writeTreeReferences(w, node.References, prefix+indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the text representation of a single node.
+func (n *TreeNode) String() string {
+	s := componentKey(n.Name, n.Version)
+	if n.Unresolved {
+		s += " (unresolved)"
+	}
+	if n.Cycle {
+		s += " (cycle)"
+	}
+	return s
+}
+
+func (o *TreeOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the tree options
+func (o *TreeOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if _, _, err := parseComponent(o.Component); err != nil {
+		return err
+	}
+	if o.Output != TextOutput && o.Output != JSONOutput {
+		return fmt.Errorf("unknown output format %q, expected one of %q, %q", o.Output, TextOutput, JSONOutput)
+	}
+	return nil
+}
+
+func (o *TreeOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Component, "component", "", "root component of the tree in the form name:version")
+	fs.StringVarP(&o.Output, "output", "o", TextOutput, fmt.Sprintf("output format of the tree. One of %q, %q", TextOutput, JSONOutput))
+}
+
+// parseComponent parses a component of the form "name:version".
+func parseComponent(component string) (string, string, error) {
+	i := strings.LastIndex(component, ":")
+	if i <= 0 || i == len(component)-1 {
+		return "", "", fmt.Errorf("invalid component %q: expected the form name:version", component)
+	}
+	return component[:i], component[i+1:], nil
+}
+
+func componentKey(name, version string) string {
+	return fmt.Sprintf("%s:%s", name, version)
+}
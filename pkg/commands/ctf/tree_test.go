@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+)
+
+var _ = Describe("Tree", func() {
+
+	var fs vfs.FileSystem
+
+	// addComponent adds a component with references to other components of version v0.0.0 to the ctf.
+	addComponent := func(name string, refs ...string) {
+		refsYaml := ""
+		for i, ref := range refs {
+			refsYaml += fmt.Sprintf("\n  - name: 'ref-%d'\n    componentName: '%s'\n    version: 'v0.0.0'", i, ref)
+		}
+		if len(refsYaml) == 0 {
+			refsYaml = " []"
+		}
+		cd := fmt.Sprintf(`meta:
+  schemaVersion: 'v2'
+component:
+  name: '%s'
+  version: 'v0.0.0'
+  repositoryContexts: []
+  provider: 'internal'
+  sources: []
+  componentReferences:%s
+  resources: []
+`, name, refsYaml)
+		path := filepath.Join("/components", name)
+		Expect(fs.MkdirAll(path, os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, filepath.Join(path, ctf.ComponentDescriptorFileName), []byte(cd), os.ModePerm)).To(Succeed())
+		// components are added one by one and the tree may contain unresolved references.
+		opts := cmd.AddOptions{
+			CTFPath:            "/component.ctf",
+			ArchiveFormat:      ctf.ArchiveFormatTar,
+			ComponentArchives:  []string{path},
+			SkipReferenceCheck: true,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+	})
+
+	It("should render the dependency tree and mark unresolved references", func() {
+		addComponent("example.com/a", "example.com/b", "example.com/c")
+		addComponent("example.com/b", "example.com/missing")
+		addComponent("example.com/c")
+
+		opts := cmd.TreeOptions{CTFPath: "/component.ctf", Component: "example.com/a:v0.0.0"}
+		tree, err := opts.Tree(fs)
+		Expect(err).ToNot(HaveOccurred())
+
+		out := &bytes.Buffer{}
+		Expect(cmd.WriteTree(out, tree, cmd.TextOutput)).To(Succeed())
+		Expect(out.String()).To(Equal(`example.com/a:v0.0.0
+├── example.com/b:v0.0.0
+│   └── example.com/missing:v0.0.0 (unresolved)
+└── example.com/c:v0.0.0
+`))
+	})
+
+	It("should detect cycles", func() {
+		addComponent("example.com/a", "example.com/b")
+		addComponent("example.com/b", "example.com/a")
+
+		opts := cmd.TreeOptions{CTFPath: "/component.ctf", Component: "example.com/a:v0.0.0"}
+		tree, err := opts.Tree(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree.References).To(HaveLen(1))
+		Expect(tree.References[0].References).To(HaveLen(1))
+		Expect(tree.References[0].References[0].Cycle).To(BeTrue())
+		Expect(tree.References[0].References[0].References).To(BeEmpty())
+	})
+
+	It("should detect cycles that depend on the path to a component", func() {
+		addComponent("example.com/a", "example.com/b", "example.com/c")
+		addComponent("example.com/b", "example.com/c")
+		addComponent("example.com/c", "example.com/b")
+
+		opts := cmd.TreeOptions{CTFPath: "/component.ctf", Component: "example.com/a:v0.0.0"}
+		tree, err := opts.Tree(fs)
+		Expect(err).ToNot(HaveOccurred())
+
+		out := &bytes.Buffer{}
+		Expect(cmd.WriteTree(out, tree, cmd.TextOutput)).To(Succeed())
+		Expect(out.String()).To(Equal(`example.com/a:v0.0.0
+├── example.com/b:v0.0.0
+│   └── example.com/c:v0.0.0
+│       └── example.com/b:v0.0.0 (cycle)
+└── example.com/c:v0.0.0
+    └── example.com/b:v0.0.0
+        └── example.com/c:v0.0.0 (cycle)
+`))
+	})
+
+	It("should build the subtree of a component that is referenced multiple times only once", func() {
+		// every level references both components of the next level, so the tree has 2^levels paths.
+		levels := 40
+		for i := 0; i < levels; i++ {
+			next := []string{fmt.Sprintf("example.com/%d-a", i+1), fmt.Sprintf("example.com/%d-b", i+1)}
+			if i == levels-1 {
+				next = nil
+			}
+			addComponent(fmt.Sprintf("example.com/%d-a", i), next...)
+			addComponent(fmt.Sprintf("example.com/%d-b", i), next...)
+		}
+
+		opts := cmd.TreeOptions{CTFPath: "/component.ctf", Component: "example.com/0-a:v0.0.0"}
+		tree, err := opts.Tree(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree.References).To(HaveLen(2))
+		Expect(tree.References[0].References).To(HaveLen(2))
+		Expect(tree.References[0].References[0]).To(Equal(tree.References[1].References[0]))
+		Expect(tree.References[0].References[0].References[0]).To(BeIdenticalTo(tree.References[1].References[0].References[0]))
+	})
+
+	It("should render the tree as json", func() {
+		addComponent("example.com/a", "example.com/b")
+
+		opts := cmd.TreeOptions{CTFPath: "/component.ctf", Component: "example.com/a:v0.0.0"}
+		tree, err := opts.Tree(fs)
+		Expect(err).ToNot(HaveOccurred())
+
+		out := &bytes.Buffer{}
+		Expect(cmd.WriteTree(out, tree, cmd.JSONOutput)).To(Succeed())
+		actual := &cmd.TreeNode{}
+		Expect(json.Unmarshal(out.Bytes(), actual)).To(Succeed())
+		Expect(actual).To(Equal(tree))
+		Expect(actual.References[0].Unresolved).To(BeTrue())
+	})
+
+	It("should return an error if the root component is not part of the ctf", func() {
+		addComponent("example.com/a")
+
+		opts := cmd.TreeOptions{CTFPath: "/component.ctf", Component: "example.com/x:v0.0.0"}
+		_, err := opts.Tree(fs)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,309 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+const (
+	// DigestVerified is the status of a local blob that matches its digest.
+	DigestVerified = "verified"
+	// DigestMismatch is the status of a local blob that does not match its digest.
+	DigestMismatch = "mismatch"
+	// DigestBlobMissing is the status of a local blob that is not part of its component archive.
+	DigestBlobMissing = "missing"
+	// DigestNotDeclared is the status of a local blob whose resource has no digest.
+	DigestNotDeclared = "no-digest"
+	// DigestExcluded is the status of a local blob whose resource is excluded from the signature.
+	DigestExcluded = "excluded"
+	// DigestUnsupported is the status of a local blob whose digest uses an unsupported hash or normalisation algorithm.
+	DigestUnsupported = "unsupported"
+	// DigestError is the status of a local blob that could not be verified.
+	DigestError = "error"
+)
+
+// VerifyDigestsOptions defines the options that are used to verify the digests of the local blobs of a ctf.
+type VerifyDigestsOptions struct {
+	// CTFPath is the path to the ctf archive.
+	CTFPath string
+	// Strict fails for local blobs without a digest or with a digest that cannot be verified.
+	Strict bool
+	// Output defines the output format of the verification report.
+	Output string
+	// Writer is the writer the verification report is written to.
+	// Defaults to stdout.
+	Writer io.Writer
+}
+
+// DigestResult describes the verification of a local blob of a ctf.
+type DigestResult struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Resource is the name of the resource of the local blob.
+	Resource string `json:"resource"`
+	// Blob is the filename of the local blob in the component archive.
+	Blob string `json:"blob"`
+	// Status is the result of the verification, e.g. verified or mismatch.
+	Status string `json:"status"`
+	// Expected is the digest that is declared by the component descriptor.
+	Expected string `json:"expected,omitempty"`
+	// Actual is the digest of the local blob.
+	Actual string `json:"actual,omitempty"`
+	// Error is the reason why the local blob could not be verified.
+	Error string `json:"error,omitempty"`
+}
+
+// Failed returns whether the local blob failed the verification.
+// Local blobs without a digest or with an unsupported digest only fail in strict mode.
+func (r DigestResult) Failed(strict bool) bool {
+	switch r.Status {
+	case DigestVerified, DigestExcluded:
+		return false
+	case DigestNotDeclared, DigestUnsupported:
+		return strict
+	default:
+		return true
+	}
+}
+
+// NewVerifyDigestsCommand creates a new command to verify the digests of the local blobs of a ctf.
+func NewVerifyDigestsCommand(ctx context.Context) *cobra.Command {
+	opts := &VerifyDigestsOptions{}
+	cmd := &cobra.Command{
+		Use:   "verify-digests CTF_PATH",
+		Args:  cobra.ExactArgs(1),
+		Short: "Verifies the local blobs of a ctf against the digests of their resources",
+		Long: `
+Verifies the blobs of all resources with a local filesystem blob access of all component archives of a ctf
+against the digests that are declared for the resources in the component descriptors, e.g. by "ctf sign".
+The ctf can be a plain, gzipped or zstd compressed tar or a directory, the component archives are read one after another.
+
+A report with the result of every local blob is printed:
+- verified: the blob matches the digest of its resource.
+- mismatch: the blob does not match the digest of its resource.
+- missing: the blob is not part of the component archive.
+- no-digest: the resource has no digest.
+- excluded: the resource is excluded from the signature.
+- unsupported: the digest uses an unsupported hash or normalisation algorithm.
+- error: the blob could not be read.
+
+The command exits with status 1 if at least one blob is mismatched, missing or cannot be read.
+Blobs without a digest or with an unsupported digest only fail the verification with --strict.
+The command exits with status 2 if the ctf cannot be read, so that both cases can be distinguished in CI.
+`,
+		Example: `
+component-cli ctf verify-digests ./delivery.ctf --strict -o json
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(2)
+			}
+
+			results, err := opts.Verify(ctx, osfs.New())
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(2)
+			}
+			if err := opts.writeReport(results); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(2)
+			}
+			if err := opts.checkResults(results); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *VerifyDigestsOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	results, err := o.Verify(ctx, fs)
+	if err != nil {
+		return err
+	}
+	if err := o.writeReport(results); err != nil {
+		return err
+	}
+	if err := o.checkResults(results); err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("Successfully verified %d local blob(s)", len(results)))
+	return nil
+}
+
+// Verify verifies the local blobs of all component archives of the ctf and returns the result of every local blob.
+// An error is only returned if the ctf cannot be read.
+func (o *VerifyDigestsOptions) Verify(_ context.Context, fs vfs.FileSystem) ([]DigestResult, error) {
+	if _, err := fs.Stat(o.CTFPath); err != nil {
+		return nil, fmt.Errorf("unable to get info for %s: %w", o.CTFPath, err)
+	}
+	results := []DigestResult{}
+	err := walkCTF(fs, o.CTFPath, func(ca *ctf.ComponentArchive) error {
+		var caFs vfs.FileSystem
+		cd := ca.ComponentDescriptor
+		for _, res := range cd.Resources {
+			if res.Access == nil || res.Access.GetType() != cdv2.LocalFilesystemBlobType {
+				continue
+			}
+			if caFs == nil {
+				caFs = memoryfs.New()
+				if err := ca.WriteToFilesystem(caFs, "/"); err != nil {
+					return fmt.Errorf("unable to read component archive %s: %w", componentKey(cd.GetName(), cd.GetVersion()), err)
+				}
+			}
+			results = append(results, verifyLocalBlob(caFs, cd, res))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ctf: %w", err)
+	}
+	return results, nil
+}
+
+// verifyLocalBlob verifies the local blob of the resource in the component archive filesystem against the digest of the resource.
+func verifyLocalBlob(caFs vfs.FileSystem, cd *cdv2.ComponentDescriptor, res cdv2.Resource) DigestResult {
+	result := DigestResult{
+		Name:     cd.GetName(),
+		Version:  cd.GetVersion(),
+		Resource: res.GetName(),
+	}
+	filename, _, err := componentarchive.LocalBlobFilename(res.Access)
+	if err != nil {
+		result.Status = DigestError
+		result.Error = err.Error()
+		return result
+	}
+	result.Blob = filename
+
+	digest := res.Digest
+	switch {
+	case digest == nil:
+		result.Status = DigestNotDeclared
+		return result
+	case digest.HashAlgorithm == cdv2.NoDigest && digest.NormalisationAlgorithm == cdv2.ExcludeFromSignature:
+		result.Status = DigestExcluded
+		return result
+	}
+	result.Expected = fmt.Sprintf("%s:%s", digest.HashAlgorithm, digest.Value)
+	if digest.NormalisationAlgorithm != string(cdv2.GenericBlobDigestV1) {
+		result.Status = DigestUnsupported
+		result.Error = fmt.Sprintf("unsupported normalisation algorithm %q", digest.NormalisationAlgorithm)
+		return result
+	}
+	if err := componentarchive.ValidateDigestAlgorithm(digest.HashAlgorithm); err != nil {
+		result.Status = DigestUnsupported
+		result.Error = err.Error()
+		return result
+	}
+
+	actual, err := componentarchive.LocalBlobDigest(caFs, filename, digest.HashAlgorithm)
+	if err != nil {
+		result.Status = DigestError
+		if errors.Is(err, os.ErrNotExist) {
+			result.Status = DigestBlobMissing
+		}
+		result.Error = err.Error()
+		return result
+	}
+	result.Actual = fmt.Sprintf("%s:%s", actual.HashAlgorithm, actual.Value)
+	result.Status = DigestVerified
+	if !strings.EqualFold(actual.Value, digest.Value) {
+		result.Status = DigestMismatch
+	}
+	return result
+}
+
+// checkResults returns an error if at least one local blob failed the verification.
+func (o *VerifyDigestsOptions) checkResults(results []DigestResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.Failed(o.Strict) {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d of %d local blobs failed the digest verification", failed, len(results))
+	}
+	return nil
+}
+
+// writeReport writes the result of every verified local blob in the output format.
+func (o *VerifyDigestsOptions) writeReport(results []DigestResult) error {
+	w := o.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	if o.Output == JSONOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal verification report: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "NAME\tVERSION\tRESOURCE\tBLOB\tSTATUS"); err != nil {
+		return err
+	}
+	for _, result := range results {
+		status := result.Status
+		switch {
+		case result.Status == DigestMismatch:
+			status = fmt.Sprintf("%s: expected %s, got %s", status, result.Expected, result.Actual)
+		case len(result.Error) != 0:
+			status = fmt.Sprintf("%s: %s", status, result.Error)
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", result.Name, result.Version, result.Resource, result.Blob, status); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func (o *VerifyDigestsOptions) Complete(args []string) error {
+	o.CTFPath = args[0]
+	return o.Validate()
+}
+
+// Validate validates the verify digests options
+func (o *VerifyDigestsOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf must be provided")
+	}
+	if o.Output != TextOutput && o.Output != JSONOutput {
+		return fmt.Errorf("unknown output format %q, expected one of %q, %q", o.Output, TextOutput, JSONOutput)
+	}
+	return nil
+}
+
+func (o *VerifyDigestsOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Strict, "strict", false, "[OPTIONAL] fail for local blobs without a digest or with a digest that cannot be verified")
+	fs.StringVarP(&o.Output, "output", "o", TextOutput, fmt.Sprintf("[OPTIONAL] output format of the verification report. One of %q, %q", TextOutput, JSONOutput))
+}
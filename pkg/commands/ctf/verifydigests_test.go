@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+)
+
+var _ = Describe("VerifyDigests", func() {
+
+	var fs vfs.FileSystem
+
+	addComponents := func(ctfPath string, directory bool, archives ...string) {
+		opts := cmd.AddOptions{
+			CTFPath:           ctfPath,
+			ArchiveFormat:     ctf.ArchiveFormatTar,
+			ComponentArchives: archives,
+			Parallel:          1,
+			Directory:         directory,
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	}
+
+	sign := func(ctfPath string) {
+		opts := cmd.SignOptions{
+			CTFPath:       ctfPath,
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			SignatureName: "release",
+			HashAlgorithm: cdv2Sign.SHA256,
+		}
+		_, err := opts.SignWithSigner(context.TODO(), logr.Discard(), fs, staticSigner{value: "sig"})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	verify := func(opts cmd.VerifyDigestsOptions) []cmd.DigestResult {
+		results, err := opts.Verify(context.TODO(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		return results
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(writeComponentArchiveWithLocalBlob(fs, "/a", "example.com/a", "v1.0.0", "blob.txt", false)).To(Succeed())
+		Expect(vfs.WriteFile(fs, filepath.Join("/a", ctf.BlobPath("blob.txt")), []byte("content of a"), os.ModePerm)).To(Succeed())
+		Expect(writeComponentArchive(fs, "/b", "example.com/b", "v1.0.0")).To(Succeed())
+	})
+
+	It("should verify the local blobs of a signed ctf", func() {
+		addComponents("/component.ctf", false, "/a", "/b")
+		sign("/component.ctf")
+
+		results := verify(cmd.VerifyDigestsOptions{CTFPath: "/component.ctf"})
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Name).To(Equal("example.com/a"))
+		Expect(results[0].Resource).To(Equal("blob"))
+		Expect(results[0].Blob).To(Equal("blob.txt"))
+		Expect(results[0].Status).To(Equal(cmd.DigestVerified))
+		Expect(results[0].Actual).To(Equal(results[0].Expected))
+
+		out := &bytes.Buffer{}
+		opts := cmd.VerifyDigestsOptions{CTFPath: "/component.ctf", Output: cmd.TextOutput, Writer: out}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("NAME"))
+		Expect(out.String()).To(ContainSubstring("verified"))
+	})
+
+	It("should report a local blob that does not match its digest", func() {
+		addComponents("/component.ctf", false, "/a", "/b")
+		sign("/component.ctf")
+		data, err := vfs.ReadFile(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bytes.Count(data, []byte("content of a"))).To(Equal(1))
+		data = bytes.Replace(data, []byte("content of a"), []byte("CONTENT OF A"), 1)
+		Expect(vfs.WriteFile(fs, "/component.ctf", data, os.ModePerm)).To(Succeed())
+
+		results := verify(cmd.VerifyDigestsOptions{CTFPath: "/component.ctf"})
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Status).To(Equal(cmd.DigestMismatch))
+		Expect(results[0].Actual).ToNot(Equal(results[0].Expected))
+
+		out := &bytes.Buffer{}
+		opts := cmd.VerifyDigestsOptions{CTFPath: "/component.ctf", Output: cmd.TextOutput, Writer: out}
+		err = opts.Run(context.TODO(), logr.Discard(), fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("1 of 1 local blobs failed"))
+		Expect(out.String()).To(ContainSubstring("mismatch: expected " + results[0].Expected))
+	})
+
+	It("should only fail for local blobs without a digest in strict mode", func() {
+		addComponents("/component.ctf", false, "/a", "/b")
+
+		results := verify(cmd.VerifyDigestsOptions{CTFPath: "/component.ctf"})
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Status).To(Equal(cmd.DigestNotDeclared))
+
+		opts := cmd.VerifyDigestsOptions{CTFPath: "/component.ctf", Output: cmd.TextOutput, Writer: &bytes.Buffer{}}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+		opts.Strict = true
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(HaveOccurred())
+	})
+
+	It("should report a missing local blob of a ctf in the directory layout as json", func() {
+		addComponents("/component", true, "/a", "/b")
+		sign("/component")
+		Expect(fs.Remove(filepath.Join("/component", "example.com_a-v1.0.0", ctf.BlobPath("blob.txt")))).To(Succeed())
+
+		out := &bytes.Buffer{}
+		opts := cmd.VerifyDigestsOptions{CTFPath: "/component", Output: cmd.JSONOutput, Writer: out}
+		Expect(opts.Run(context.TODO(), logr.Discard(), fs)).To(HaveOccurred())
+
+		results := []cmd.DigestResult{}
+		Expect(json.Unmarshal(out.Bytes(), &results)).To(Succeed())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Status).To(Equal(cmd.DigestBlobMissing))
+	})
+
+	It("should fail if the ctf cannot be read", func() {
+		opts := cmd.VerifyDigestsOptions{CTFPath: "/missing.ctf"}
+		_, err := opts.Verify(context.TODO(), fs)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -212,7 +212,7 @@ func (o *GenerateOverwriteOptions) Run(ctx context.Context, log logr.Logger, fs
 		if err := fs.MkdirAll(filepath.Dir(o.ImageVectorPath), os.ModePerm); err != nil {
 			return fmt.Errorf("unable to create directories for %q: %s", o.ImageVectorPath, err.Error())
 		}
-		if err := vfs.WriteFile(fs, o.ImageVectorPath, data, 06444); err != nil {
+		if err := vfs.WriteFile(fs, o.ImageVectorPath, data, 0664); err != nil {
 			return fmt.Errorf("unable to write image vector: %w", err)
 		}
 		fmt.Printf("Successfully generated image vector from component descriptor")
@@ -27,6 +27,8 @@ type CopyOptions struct {
 	SourceRef string
 	// TargetRef is the target oci artifact reference where the artifact is copied to.
 	TargetRef string
+	// Platforms restricts the manifests of a multi-arch image that are copied.
+	Platforms []string
 
 	// OCIOptions contains all oci client related options.
 	OCIOptions ociopts.Options
@@ -41,6 +43,10 @@ func NewCopyCommand(ctx context.Context) *cobra.Command {
 		Long: `
 Copy copies a artifact from a source to a target registry.
 The artifact is copied without modification.
+
+Multi-arch images are copied with their image index and all platform specific manifests.
+With "--platform os/architecture[/variant]" only the manifests of the given platforms are copied
+and the image index is rewritten to only reference them, which changes its digest.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
@@ -59,6 +65,7 @@ The artifact is copied without modification.
 }
 
 func (o *CopyOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&o.Platforms, "platform", []string{}, "[OPTIONAL] platform in the form os/architecture[/variant] whose manifest of a multi-arch image is copied. Can be defined multiple times")
 	o.OCIOptions.AddFlags(fs)
 }
 
@@ -68,6 +75,9 @@ func (o *CopyOptions) Complete(args []string) error {
 	}
 	o.SourceRef = args[0]
 	o.TargetRef = args[1]
+	if _, err := ociclient.ParsePlatforms(o.Platforms); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -76,7 +86,11 @@ func (o *CopyOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyste
 	if err != nil {
 		return fmt.Errorf("unable to build oci client: %s", err.Error())
 	}
-	if err := ociclient.Copy(ctx, ociClient, o.SourceRef, o.TargetRef); err != nil {
+	platforms, err := ociclient.ParsePlatforms(o.Platforms)
+	if err != nil {
+		return err
+	}
+	if err := ociclient.CopyPlatforms(ctx, ociClient, o.SourceRef, o.TargetRef, platforms); err != nil {
 		return err
 	}
 	fmt.Printf("Successfully copied %q to %q", o.SourceRef, o.TargetRef)
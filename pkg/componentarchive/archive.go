@@ -32,6 +32,9 @@ type BuilderOptions struct {
 	Version              string
 	BaseUrl              string
 	ComponentNameMapping string
+	// Provider is the provider type of a newly created component descriptor.
+	// Defaults to "internal".
+	Provider string
 
 	Overwrite bool
 }
@@ -69,6 +72,12 @@ func (o *BuilderOptions) Validate() error {
 			return fmt.Errorf("unknown component name mapping method %q", o.ComponentNameMapping)
 		}
 	}
+	if len(o.Provider) != 0 {
+		if o.Provider != string(cdv2.InternalProvider) &&
+			o.Provider != string(cdv2.ExternalProvider) {
+			return fmt.Errorf("unknown provider type %q", o.Provider)
+		}
+	}
 	return nil
 }
 
@@ -123,7 +132,7 @@ func (o *BuilderOptions) Build(fs vfs.FileSystem) (*ctf.ComponentArchive, error)
 
 	// build minimal archive
 
-	if err := fs.MkdirAll(o.ComponentArchivePath, os.ModePerm); err != nil {
+	if err := fs.MkdirAll(filepath.Join(o.ComponentArchivePath, ctf.BlobsDirectoryName), os.ModePerm); err != nil {
 		return nil, fmt.Errorf("unable to create component-archive path %q: %w", o.ComponentArchivePath, err)
 	}
 	archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
@@ -136,6 +145,9 @@ func (o *BuilderOptions) Build(fs vfs.FileSystem) (*ctf.ComponentArchive, error)
 	cd.ComponentSpec.Name = o.Name
 	cd.ComponentSpec.Version = o.Version
 	cd.Provider = cdv2.InternalProvider
+	if len(o.Provider) != 0 {
+		cd.Provider = cdv2.ProviderType(o.Provider)
+	}
 	cd.RepositoryContexts = make([]*cdv2.UnstructuredTypedObject, 0)
 	if len(o.BaseUrl) != 0 {
 		repoCtx, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryRepository(o.BaseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping)))
@@ -158,7 +170,7 @@ func (o *BuilderOptions) Build(fs vfs.FileSystem) (*ctf.ComponentArchive, error)
 		utils.PrintPrettyYaml(cd, true)
 		return nil, fmt.Errorf("unable to marshal component descriptor: %w", err)
 	}
-	if err := vfs.WriteFile(fs, compDescFilePath, data, os.ModePerm); err != nil {
+	if err := WriteComponentDescriptor(fs, o.ComponentArchivePath, data, false); err != nil {
 		utils.PrintPrettyYaml(cd, true)
 		return nil, fmt.Errorf("unable to write component descriptor to %s: %w", compDescFilePath, err)
 	}
@@ -214,7 +226,7 @@ func Parse(fs vfs.FileSystem, path string) (*ctf.ComponentArchive, ctf.ArchiveFo
 		if err := file.Close(); err != nil {
 			return nil, "", fmt.Errorf("unable to close file reader: %w", err)
 		}
-		return ca, ctf.ArchiveFormatTar, nil
+		return ca, ctf.ArchiveFormatTarGzip, nil
 	case "application/octet-stream": // expect that is has to be a tar
 		ca, err := ctf.NewComponentArchiveFromTarReader(file)
 		if err != nil {
@@ -223,8 +235,31 @@ func Parse(fs vfs.FileSystem, path string) (*ctf.ComponentArchive, ctf.ArchiveFo
 		if err := file.Close(); err != nil {
 			return nil, "", fmt.Errorf("unable to close file reader: %w", err)
 		}
-		return ca, ctf.ArchiveFormatTarGzip, nil
+		return ca, ctf.ArchiveFormatTar, nil
 	default:
 		return nil, "", fmt.Errorf("unsupported file type %q. Expected a tar or a tar.gz", mimetype)
 	}
 }
+
+// ReadComponentDescriptor reads a component descriptor from a component archive or a component descriptor file.
+// Component descriptor files are detected by their .yaml, .yml or .json extension.
+func ReadComponentDescriptor(fs vfs.FileSystem, path string) (*cdv2.ComponentDescriptor, error) {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml", ".json":
+		data, err := vfs.ReadFile(fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read component descriptor from %q: %w", path, err)
+		}
+		cd := &cdv2.ComponentDescriptor{}
+		if err := codec.Decode(data, cd); err != nil {
+			return nil, fmt.Errorf("unable to decode component descriptor from %q: %w", path, err)
+		}
+		return cd, nil
+	default:
+		ca, _, err := Parse(fs, path)
+		if err != nil {
+			return nil, err
+		}
+		return ca.ComponentDescriptor, nil
+	}
+}
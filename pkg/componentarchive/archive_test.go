@@ -5,15 +5,17 @@
 package componentarchive
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
-	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/mandelsoft/vfs/pkg/memoryfs"
-	"github.com/mandelsoft/vfs/pkg/osfs"
-	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/testutils"
 )
 
 func TestConfig(t *testing.T) {
@@ -26,9 +28,7 @@ var _ = Describe("Archive", func() {
 	var testdataFs vfs.FileSystem
 
 	BeforeEach(func() {
-		fs, err := projectionfs.New(osfs.New(), "./testdata")
-		Expect(err).ToNot(HaveOccurred())
-		testdataFs = layerfs.New(memoryfs.New(), fs)
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
 	})
 
 	It("should return error for empty component descriptor if name and version not set in options", func() {
@@ -57,6 +57,21 @@ var _ = Describe("Archive", func() {
 		Expect(archive.ComponentDescriptor.Version).To(Equal(componentVersion))
 	})
 
+	It("should write the component descriptor of a new component archive with a non-executable file mode", func() {
+		fs := memoryfs.New()
+		opts := BuilderOptions{
+			ComponentArchivePath: "/component",
+			Name:                 "example.com/component",
+			Version:              "v0.0.0",
+		}
+
+		_, err := opts.Build(fs)
+		Expect(err).ToNot(HaveOccurred())
+		info, err := fs.Stat(filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0664)))
+	})
+
 	It("should return error when trying to overwrite existing component name", func() {
 		const (
 			componentName    = "example.com/new-component"
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// RemoveUnreferencedBlob removes the local blob of the given access from the component archive
+// if no resource or source of the component descriptor references it.
+// Blobs are stored by their digest, so resources and sources with identical content share one blob.
+func RemoveUnreferencedBlob(log logr.Logger, archiveFs vfs.FileSystem, cd *cdv2.ComponentDescriptor, access *cdv2.UnstructuredTypedObject) error {
+	filename, ok, err := LocalBlobFilename(access)
+	if err != nil || !ok {
+		return err
+	}
+	for _, res := range cd.Resources {
+		if other, _, _ := LocalBlobFilename(res.Access); other == filename {
+			log.V(1).Info(fmt.Sprintf("Local blob %q is still referenced by resource %q", filename, res.GetName()))
+			return nil
+		}
+	}
+	for _, src := range cd.Sources {
+		if other, _, _ := LocalBlobFilename(src.Access); other == filename {
+			log.V(1).Info(fmt.Sprintf("Local blob %q is still referenced by source %q", filename, src.GetName()))
+			return nil
+		}
+	}
+	if err := archiveFs.Remove(ctf.BlobPath(filename)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.V(1).Info(fmt.Sprintf("Local blob %q is not part of the component archive", filename))
+			return nil
+		}
+		return fmt.Errorf("unable to remove local blob %q: %w", filename, err)
+	}
+	log.V(1).Info(fmt.Sprintf("Successfully removed local blob %q", filename))
+	return nil
+}
+
+// LocalBlobFilename returns the blob filename of a local filesystem blob access.
+// False is returned if the access is not a local filesystem blob access.
+func LocalBlobFilename(access *cdv2.UnstructuredTypedObject) (string, bool, error) {
+	if access == nil || access.GetType() != cdv2.LocalFilesystemBlobType {
+		return "", false, nil
+	}
+	blobAccess := &cdv2.LocalFilesystemBlobAccess{}
+	if err := access.DecodeInto(blobAccess); err != nil {
+		return "", false, fmt.Errorf("unable to decode local filesystem blob access: %w", err)
+	}
+	return blobAccess.Filename, true, nil
+}
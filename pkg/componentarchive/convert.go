@@ -0,0 +1,286 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"errors"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/ghodss/yaml"
+
+	"github.com/gardener/component-cli/pkg/componentarchive/v3alpha1"
+)
+
+const (
+	// SchemaVersionV2 is the schema version of the component descriptor v2.
+	SchemaVersionV2 = cdv2.SchemaVersion
+	// SchemaVersionV3Alpha1 is the schema version of the OCM component descriptor ocm.software/v3alpha1.
+	SchemaVersionV3Alpha1 = "v3alpha1"
+)
+
+// typeMapping describes how a typed object, e.g. an access, is translated between the schema versions.
+type typeMapping struct {
+	// Type is the type in the target schema version.
+	Type string
+	// Fields maps attributes that are renamed to their name in the target schema version.
+	Fields map[string]string
+}
+
+// v3alpha1AccessTypes defines the translation of v2 access types to OCM access types.
+// Access types that are not defined are kept as they are.
+var v3alpha1AccessTypes = map[string]typeMapping{
+	cdv2.OCIRegistryType:         {Type: v3alpha1.OCIArtifactType},
+	cdv2.LocalFilesystemBlobType: {Type: v3alpha1.LocalBlobType, Fields: map[string]string{"filename": "localReference"}},
+	cdv2.LocalOCIBlobType:        {Type: v3alpha1.LocalBlobType, Fields: map[string]string{"digest": "localReference"}},
+	cdv2.GitHubAccessType:        {Type: v3alpha1.GitHubAccessType},
+}
+
+// v2AccessTypes defines the translation of OCM access types to v2 access types.
+// Local blobs are translated to local filesystem blobs as they are stored as file in a component archive.
+var v2AccessTypes = map[string]typeMapping{
+	v3alpha1.OCIArtifactType:  {Type: cdv2.OCIRegistryType},
+	v3alpha1.LocalBlobType:    {Type: cdv2.LocalFilesystemBlobType, Fields: map[string]string{"localReference": "filename"}},
+	v3alpha1.GitHubAccessType: {Type: cdv2.GitHubAccessType},
+}
+
+// v3alpha1RepositoryTypes defines the translation of v2 repository types to OCM repository types.
+var v3alpha1RepositoryTypes = map[string]typeMapping{
+	cdv2.OCIRegistryType: {Type: v3alpha1.OCIRegistryType},
+}
+
+// v2RepositoryTypes defines the translation of OCM repository types to v2 repository types.
+var v2RepositoryTypes = map[string]typeMapping{
+	v3alpha1.OCIRegistryType: {Type: cdv2.OCIRegistryType},
+}
+
+// ValidateSchemaVersion checks whether component descriptors can be converted to the schema version.
+func ValidateSchemaVersion(schemaVersion string) error {
+	if schemaVersion != SchemaVersionV2 && schemaVersion != SchemaVersionV3Alpha1 {
+		return fmt.Errorf("unsupported schema version %q, expected one of %q, %q", schemaVersion, SchemaVersionV2, SchemaVersionV3Alpha1)
+	}
+	return nil
+}
+
+// DetectSchemaVersion returns the schema version of the encoded component descriptor.
+func DetectSchemaVersion(data []byte) (string, error) {
+	header := struct {
+		APIVersion string        `json:"apiVersion"`
+		Meta       cdv2.Metadata `json:"meta"`
+	}{}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return "", fmt.Errorf("unable to decode component descriptor: %w", err)
+	}
+	switch {
+	case header.APIVersion == v3alpha1.SchemaVersion:
+		return SchemaVersionV3Alpha1, nil
+	case header.Meta.Version == cdv2.SchemaVersion:
+		return SchemaVersionV2, nil
+	case len(header.APIVersion) != 0:
+		return "", fmt.Errorf("unsupported component descriptor api version %q", header.APIVersion)
+	case len(header.Meta.Version) != 0:
+		return "", fmt.Errorf("unsupported component descriptor schema version %q", header.Meta.Version)
+	default:
+		return "", errors.New("unable to detect the schema version of the component descriptor")
+	}
+}
+
+// ConvertComponentDescriptor converts the encoded component descriptor to the given schema version
+// and returns it encoded as yaml.
+// The schema version of the given component descriptor is detected automatically.
+func ConvertComponentDescriptor(data []byte, schemaVersion string) ([]byte, error) {
+	if err := ValidateSchemaVersion(schemaVersion); err != nil {
+		return nil, err
+	}
+	sourceVersion, err := DetectSchemaVersion(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var cd *cdv2.ComponentDescriptor
+	if sourceVersion == SchemaVersionV2 {
+		cd = &cdv2.ComponentDescriptor{}
+		if err := codec.Decode(data, cd); err != nil {
+			return nil, fmt.Errorf("unable to decode component descriptor: %w", err)
+		}
+	} else {
+		ocmCd := &v3alpha1.ComponentDescriptor{}
+		if err := yaml.Unmarshal(data, ocmCd); err != nil {
+			return nil, fmt.Errorf("unable to decode component descriptor: %w", err)
+		}
+		cd, err = ConvertFromV3Alpha1(ocmCd)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var converted interface{} = cd
+	if schemaVersion == SchemaVersionV3Alpha1 {
+		converted = ConvertToV3Alpha1(cd)
+	}
+	data, err = yaml.Marshal(converted)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	return data, nil
+}
+
+// ConvertToV3Alpha1 converts a component descriptor v2 to the OCM schema version ocm.software/v3alpha1.
+// The access and repository types are translated to their OCM counterparts.
+func ConvertToV3Alpha1(cd *cdv2.ComponentDescriptor) *v3alpha1.ComponentDescriptor {
+	out := &v3alpha1.ComponentDescriptor{
+		APIVersion: v3alpha1.SchemaVersion,
+		Kind:       v3alpha1.Kind,
+		Metadata: v3alpha1.ObjectMeta{
+			Name:     cd.GetName(),
+			Version:  cd.GetVersion(),
+			Labels:   cd.Labels,
+			Provider: v3alpha1.Provider{Name: string(cd.Provider)},
+		},
+		Signatures: cd.Signatures,
+	}
+
+	for _, repoCtx := range cd.RepositoryContexts {
+		out.RepositoryContexts = append(out.RepositoryContexts, convertTypedObject(repoCtx, v3alpha1RepositoryTypes))
+	}
+	for _, src := range cd.Sources {
+		out.Spec.Sources = append(out.Spec.Sources, v3alpha1.Source{
+			ElementMeta: v3alpha1.ElementMeta{
+				Name:          src.GetName(),
+				Version:       src.GetVersion(),
+				ExtraIdentity: src.ExtraIdentity,
+				Labels:        src.Labels,
+			},
+			Type:   src.GetType(),
+			Access: convertTypedObject(src.Access, v3alpha1AccessTypes),
+		})
+	}
+	for _, ref := range cd.ComponentReferences {
+		out.Spec.References = append(out.Spec.References, v3alpha1.Reference{
+			ElementMeta: v3alpha1.ElementMeta{
+				Name:          ref.GetName(),
+				Version:       ref.GetVersion(),
+				ExtraIdentity: ref.ExtraIdentity,
+				Labels:        ref.Labels,
+			},
+			ComponentName: ref.ComponentName,
+			Digest:        ref.Digest,
+		})
+	}
+	for _, res := range cd.Resources {
+		srcRefs := make([]v3alpha1.SourceRef, 0, len(res.SourceRef))
+		for _, srcRef := range res.SourceRef {
+			srcRefs = append(srcRefs, v3alpha1.SourceRef{IdentitySelector: srcRef.IdentitySelector, Labels: srcRef.Labels})
+		}
+		out.Spec.Resources = append(out.Spec.Resources, v3alpha1.Resource{
+			ElementMeta: v3alpha1.ElementMeta{
+				Name:          res.GetName(),
+				Version:       res.GetVersion(),
+				ExtraIdentity: res.ExtraIdentity,
+				Labels:        res.Labels,
+			},
+			Type:       res.GetType(),
+			Relation:   res.Relation,
+			SourceRefs: srcRefs,
+			Access:     convertTypedObject(res.Access, v3alpha1AccessTypes),
+			Digest:     res.Digest,
+		})
+	}
+	return out
+}
+
+// ConvertFromV3Alpha1 converts a component descriptor of the OCM schema version ocm.software/v3alpha1 to a component descriptor v2.
+// The access and repository types are translated to their v2 counterparts.
+// An error is returned if the component descriptor contains information that cannot be represented in v2.
+func ConvertFromV3Alpha1(cd *v3alpha1.ComponentDescriptor) (*cdv2.ComponentDescriptor, error) {
+	if cd.APIVersion != v3alpha1.SchemaVersion {
+		return nil, fmt.Errorf("unsupported component descriptor api version %q, expected %q", cd.APIVersion, v3alpha1.SchemaVersion)
+	}
+	if len(cd.Metadata.Provider.Labels) != 0 {
+		return nil, errors.New("labels of the provider cannot be converted to schema version v2")
+	}
+
+	out := &cdv2.ComponentDescriptor{}
+	out.Metadata.Version = cdv2.SchemaVersion
+	out.Name = cd.Metadata.Name
+	out.Version = cd.Metadata.Version
+	out.Labels = cd.Metadata.Labels
+	out.Provider = cdv2.ProviderType(cd.Metadata.Provider.Name)
+	out.Signatures = cd.Signatures
+
+	for _, repoCtx := range cd.RepositoryContexts {
+		out.RepositoryContexts = append(out.RepositoryContexts, convertTypedObject(repoCtx, v2RepositoryTypes))
+	}
+	for _, src := range cd.Spec.Sources {
+		out.Sources = append(out.Sources, cdv2.Source{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:          src.Name,
+				Version:       src.Version,
+				Type:          src.Type,
+				ExtraIdentity: src.ExtraIdentity,
+				Labels:        src.Labels,
+			},
+			Access: convertTypedObject(src.Access, v2AccessTypes),
+		})
+	}
+	for _, ref := range cd.Spec.References {
+		out.ComponentReferences = append(out.ComponentReferences, cdv2.ComponentReference{
+			Name:          ref.Name,
+			ComponentName: ref.ComponentName,
+			Version:       ref.Version,
+			ExtraIdentity: ref.ExtraIdentity,
+			Digest:        ref.Digest,
+			Labels:        ref.Labels,
+		})
+	}
+	for _, res := range cd.Spec.Resources {
+		var srcRefs []cdv2.SourceRef
+		for _, srcRef := range res.SourceRefs {
+			srcRefs = append(srcRefs, cdv2.SourceRef{IdentitySelector: srcRef.IdentitySelector, Labels: srcRef.Labels})
+		}
+		out.Resources = append(out.Resources, cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:          res.Name,
+				Version:       res.Version,
+				Type:          res.Type,
+				ExtraIdentity: res.ExtraIdentity,
+				Labels:        res.Labels,
+			},
+			Digest:    res.Digest,
+			Relation:  res.Relation,
+			SourceRef: srcRefs,
+			Access:    convertTypedObject(res.Access, v2AccessTypes),
+		})
+	}
+
+	if err := cdv2.DefaultComponent(out); err != nil {
+		return nil, fmt.Errorf("unable to default component descriptor: %w", err)
+	}
+	if err := cdvalidation.Validate(out); err != nil {
+		return nil, fmt.Errorf("unable to validate converted component descriptor: %w", err)
+	}
+	return out, nil
+}
+
+// convertTypedObject translates the type and the renamed attributes of a typed object.
+func convertTypedObject(obj *cdv2.UnstructuredTypedObject, mappings map[string]typeMapping) *cdv2.UnstructuredTypedObject {
+	if obj == nil {
+		return nil
+	}
+	mapping, ok := mappings[obj.GetType()]
+	if !ok {
+		mapping = typeMapping{Type: obj.GetType()}
+	}
+	data := make(map[string]interface{}, len(obj.Object))
+	for key, value := range obj.Object {
+		if newKey, ok := mapping.Fields[key]; ok {
+			key = newKey
+		}
+		data[key] = value
+	}
+	return cdv2.NewUnstructuredType(mapping.Type, data)
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/componentarchive/v3alpha1"
+)
+
+var _ = Describe("Convert", func() {
+
+	It("should detect the schema version of a component descriptor", func() {
+		version, err := DetectSchemaVersion([]byte("meta:\n  schemaVersion: v2\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(SchemaVersionV2))
+
+		version, err = DetectSchemaVersion([]byte("apiVersion: ocm.software/v3alpha1\nkind: ComponentVersion\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(SchemaVersionV3Alpha1))
+
+		_, err = DetectSchemaVersion([]byte("apiVersion: ocm.software/v3\n"))
+		Expect(err).To(MatchError(`unsupported component descriptor api version "ocm.software/v3"`))
+		_, err = DetectSchemaVersion([]byte("name: example\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should translate access types in both directions", func() {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Metadata.Version = cdv2.SchemaVersion
+		cd.Name = "example.com/a"
+		cd.Version = "v1.0.0"
+		cd.Provider = cdv2.InternalProvider
+		cd.Resources = []cdv2.Resource{
+			{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "blob", Version: "v1.0.0", Type: "plain-text"},
+				Relation:           cdv2.LocalRelation,
+				Access: cdv2.NewUnstructuredType(cdv2.LocalFilesystemBlobType, map[string]interface{}{
+					"filename":  "sha256-abc",
+					"mediaType": "text/plain",
+				}),
+			},
+		}
+
+		ocmCd := ConvertToV3Alpha1(cd)
+		access := ocmCd.Spec.Resources[0].Access
+		Expect(access.GetType()).To(Equal(v3alpha1.LocalBlobType))
+		Expect(access.Object).To(Equal(map[string]interface{}{
+			"type":           v3alpha1.LocalBlobType,
+			"localReference": "sha256-abc",
+			"mediaType":      "text/plain",
+		}))
+		// the original access is not modified
+		Expect(cd.Resources[0].Access.Object).To(HaveKey("filename"))
+
+		converted, err := ConvertFromV3Alpha1(ocmCd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(converted.Resources[0].Access.GetType()).To(Equal(cdv2.LocalFilesystemBlobType))
+		Expect(converted.Resources[0].Access.Object).To(Equal(cd.Resources[0].Access.Object))
+	})
+
+})
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// ComponentDescriptorBackupFileName is the name of the backup of the previous component descriptor.
+const ComponentDescriptorBackupFileName = ctf.ComponentDescriptorFileName + ".bak"
+
+// WriteComponentDescriptor writes the encoded component descriptor to the component archive at the given path.
+// The data is written to a temporary file that replaces the component descriptor by a rename,
+// so that the component descriptor is never written partially.
+// If backup is set, the previous component descriptor is kept as component-descriptor.yaml.bak.
+func WriteComponentDescriptor(fs vfs.FileSystem, archivePath string, data []byte, backup bool) error {
+	compDescFilePath := filepath.Join(archivePath, ctf.ComponentDescriptorFileName)
+	if backup {
+		oldData, err := vfs.ReadFile(fs, compDescFilePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("unable to read component descriptor: %w", err)
+		}
+		if err == nil {
+			if err := writeFileSynced(fs, filepath.Join(archivePath, ComponentDescriptorBackupFileName), oldData); err != nil {
+				return fmt.Errorf("unable to write backup of component descriptor: %w", err)
+			}
+		}
+	}
+
+	tmpFilePath := filepath.Join(archivePath, "."+ctf.ComponentDescriptorFileName+".tmp")
+	if err := writeFileSynced(fs, tmpFilePath, data); err != nil {
+		_ = fs.Remove(tmpFilePath)
+		return fmt.Errorf("unable to write component descriptor: %w", err)
+	}
+	if err := utils.ReplaceFile(fs, tmpFilePath, compDescFilePath); err != nil {
+		_ = fs.Remove(tmpFilePath)
+		return fmt.Errorf("unable to replace component descriptor: %w", err)
+	}
+	return nil
+}
+
+// writeFileSynced writes the data to the file and flushes it to the storage.
+func writeFileSynced(fs vfs.FileSystem, path string, data []byte) error {
+	file, err := fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0664)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("WriteComponentDescriptor", func() {
+
+	for name, newFs := range map[string]func() (vfs.FileSystem, string){
+		"memory": func() (vfs.FileSystem, string) {
+			fs := memoryfs.New()
+			Expect(fs.MkdirAll("/ca", os.ModePerm)).To(Succeed())
+			return fs, "/ca"
+		},
+		"overlay": func() (vfs.FileSystem, string) {
+			fs, err := utils.NewOverlayFS(memoryfs.New())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.MkdirAll("/ca", os.ModePerm)).To(Succeed())
+			return fs, "/ca"
+		},
+		"os": func() (vfs.FileSystem, string) {
+			dir, err := os.MkdirTemp("", "ca-")
+			Expect(err).ToNot(HaveOccurred())
+			return osfs.New(), dir
+		},
+	} {
+		newFs := newFs
+		Context(name+" filesystem", func() {
+			var (
+				fs  vfs.FileSystem
+				dir string
+			)
+
+			BeforeEach(func() {
+				fs, dir = newFs()
+			})
+
+			AfterEach(func() {
+				Expect(fs.RemoveAll(dir)).To(Succeed())
+			})
+
+			It("should replace the component descriptor without leaving a temporary file", func() {
+				compDescFilePath := filepath.Join(dir, ctf.ComponentDescriptorFileName)
+				Expect(vfs.WriteFile(fs, compDescFilePath, []byte("old"), 0664)).To(Succeed())
+
+				Expect(WriteComponentDescriptor(fs, dir, []byte("new"), false)).To(Succeed())
+
+				data, err := vfs.ReadFile(fs, compDescFilePath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(Equal("new"))
+				entries, err := vfs.ReadDir(fs, dir)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+			})
+
+			It("should keep the previous component descriptor as backup", func() {
+				Expect(vfs.WriteFile(fs, filepath.Join(dir, ctf.ComponentDescriptorFileName), []byte("old"), 0664)).To(Succeed())
+
+				Expect(WriteComponentDescriptor(fs, dir, []byte("new"), true)).To(Succeed())
+
+				data, err := vfs.ReadFile(fs, filepath.Join(dir, ComponentDescriptorBackupFileName))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(Equal("old"))
+				data, err = vfs.ReadFile(fs, filepath.Join(dir, ctf.ComponentDescriptorFileName))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(Equal("new"))
+			})
+
+			It("should write a new component descriptor without a backup", func() {
+				Expect(WriteComponentDescriptor(fs, dir, []byte("new"), true)).To(Succeed())
+
+				_, err := fs.Stat(filepath.Join(dir, ComponentDescriptorBackupFileName))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+				data, err := vfs.ReadFile(fs, filepath.Join(dir, ctf.ComponentDescriptorFileName))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(Equal("new"))
+			})
+		})
+	}
+
+	It("should fail without modifying the component descriptor if it cannot be replaced", func() {
+		fs := renameFailingFS{FileSystem: memoryfs.New()}
+		Expect(fs.MkdirAll("/ca", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/ca/"+ctf.ComponentDescriptorFileName, []byte("old"), 0664)).To(Succeed())
+
+		Expect(WriteComponentDescriptor(fs, "/ca", []byte("new"), false)).To(HaveOccurred())
+
+		data, err := vfs.ReadFile(fs, "/ca/"+ctf.ComponentDescriptorFileName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("old"))
+		_, err = fs.Stat("/ca/." + ctf.ComponentDescriptorFileName + ".tmp")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
+
+// renameFailingFS is a filesystem that fails to rename files.
+type renameFailingFS struct {
+	vfs.FileSystem
+}
+
+func (renameFailingFS) Rename(_, _ string) error {
+	return errors.New("rename failed")
+}
@@ -0,0 +1,264 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ComponentDiff describes the differences between two component descriptors.
+type ComponentDiff struct {
+	Resources           ElementDiff `json:"resources"`
+	Sources             ElementDiff `json:"sources"`
+	ComponentReferences ElementDiff `json:"componentReferences"`
+	Labels              LabelDiff   `json:"labels"`
+}
+
+// ElementDiff describes the resources, sources or component references that were added, removed or changed.
+// The elements are matched by their identity.
+type ElementDiff struct {
+	Added   []cdv2.Identity `json:"added"`
+	Removed []cdv2.Identity `json:"removed"`
+	Changed []ElementChange `json:"changed"`
+}
+
+// ElementChange describes an element that exists in both component descriptors but has different attributes.
+type ElementChange struct {
+	Identity cdv2.Identity `json:"identity"`
+	// Fields are the names of the changed attributes.
+	Fields []string `json:"fields"`
+}
+
+// LabelDiff describes the names of the component labels that were added, removed or changed.
+type LabelDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// DiffComponentDescriptors compares two component descriptors.
+func DiffComponentDescriptors(oldCd, newCd *cdv2.ComponentDescriptor) ComponentDiff {
+	oldResources, newResources := map[string]diffElement{}, map[string]diffElement{}
+	for _, res := range oldCd.Resources {
+		addDiffElement(oldResources, res.GetIdentity(), resourceFields(res))
+	}
+	for _, res := range newCd.Resources {
+		addDiffElement(newResources, res.GetIdentity(), resourceFields(res))
+	}
+	oldSources, newSources := map[string]diffElement{}, map[string]diffElement{}
+	for _, src := range oldCd.Sources {
+		addDiffElement(oldSources, src.GetIdentity(), sourceFields(src))
+	}
+	for _, src := range newCd.Sources {
+		addDiffElement(newSources, src.GetIdentity(), sourceFields(src))
+	}
+	oldRefs, newRefs := map[string]diffElement{}, map[string]diffElement{}
+	for _, ref := range oldCd.ComponentReferences {
+		addDiffElement(oldRefs, ref.GetIdentity(), referenceFields(ref))
+	}
+	for _, ref := range newCd.ComponentReferences {
+		addDiffElement(newRefs, ref.GetIdentity(), referenceFields(ref))
+	}
+
+	return ComponentDiff{
+		Resources:           diffElements(oldResources, newResources),
+		Sources:             diffElements(oldSources, newSources),
+		ComponentReferences: diffElements(oldRefs, newRefs),
+		Labels:              diffLabels(oldCd.Labels, newCd.Labels),
+	}
+}
+
+// diffElement is a resource, source or component reference with the attributes that are compared.
+type diffElement struct {
+	identity cdv2.Identity
+	fields   map[string]interface{}
+}
+
+func addDiffElement(elements map[string]diffElement, identity cdv2.Identity, fields map[string]interface{}) {
+	elements[DiffIdentityKey(identity)] = diffElement{identity: identity, fields: fields}
+}
+
+func resourceFields(res cdv2.Resource) map[string]interface{} {
+	return map[string]interface{}{
+		"version":  res.GetVersion(),
+		"type":     res.GetType(),
+		"relation": res.Relation,
+		"labels":   res.Labels,
+		"access":   res.Access,
+		"digest":   res.Digest,
+		"srcRef":   res.SourceRef,
+	}
+}
+
+func sourceFields(src cdv2.Source) map[string]interface{} {
+	return map[string]interface{}{
+		"version": src.GetVersion(),
+		"type":    src.GetType(),
+		"labels":  src.Labels,
+		"access":  src.Access,
+	}
+}
+
+func referenceFields(ref cdv2.ComponentReference) map[string]interface{} {
+	return map[string]interface{}{
+		"componentName": ref.ComponentName,
+		"version":       ref.GetVersion(),
+		"labels":        ref.Labels,
+		"digest":        ref.Digest,
+	}
+}
+
+// diffElements compares the elements by their identity.
+// Added and changed elements are returned in the order of the new elements, removed elements in the order of their identity.
+func diffElements(oldElements, newElements map[string]diffElement) ElementDiff {
+	diff := ElementDiff{
+		Added:   []cdv2.Identity{},
+		Removed: []cdv2.Identity{},
+		Changed: []ElementChange{},
+	}
+	for _, key := range sets.StringKeySet(newElements).List() {
+		newElement := newElements[key]
+		oldElement, ok := oldElements[key]
+		if !ok {
+			diff.Added = append(diff.Added, newElement.identity)
+			continue
+		}
+		changed := []string{}
+		for _, field := range sets.StringKeySet(newElement.fields).List() {
+			if !semanticEqual(oldElement.fields[field], newElement.fields[field]) {
+				changed = append(changed, field)
+			}
+		}
+		if len(changed) != 0 {
+			diff.Changed = append(diff.Changed, ElementChange{Identity: newElement.identity, Fields: changed})
+		}
+	}
+	for _, key := range sets.StringKeySet(oldElements).List() {
+		if _, ok := newElements[key]; !ok {
+			diff.Removed = append(diff.Removed, oldElements[key].identity)
+		}
+	}
+	return diff
+}
+
+func diffLabels(oldLabels, newLabels cdv2.Labels) LabelDiff {
+	diff := LabelDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+	oldValues := map[string]json.RawMessage{}
+	for _, label := range oldLabels {
+		oldValues[label.Name] = label.Value
+	}
+	newValues := map[string]json.RawMessage{}
+	for _, label := range newLabels {
+		newValues[label.Name] = label.Value
+	}
+	for _, name := range sets.StringKeySet(newValues).List() {
+		oldValue, ok := oldValues[name]
+		if !ok {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+		if !semanticEqual(oldValue, newValues[name]) {
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	for _, name := range sets.StringKeySet(oldValues).List() {
+		if _, ok := newValues[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	return diff
+}
+
+// semanticEqual compares the json representation of both values,
+// so that formatting and the order of object keys are ignored.
+func semanticEqual(a, b interface{}) bool {
+	normalize := func(v interface{}) interface{} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return v
+		}
+		var out interface{}
+		if err := json.Unmarshal(data, &out); err != nil {
+			return v
+		}
+		return out
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// DiffIdentityKey returns a stable string representation of an identity.
+func DiffIdentityKey(identity cdv2.Identity) string {
+	parts := make([]string, 0, len(identity))
+	for _, k := range sets.StringKeySet(identity).List() {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, identity[k]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Empty returns whether the component descriptors do not differ.
+func (d ComponentDiff) Empty() bool {
+	for _, elements := range []ElementDiff{d.Resources, d.Sources, d.ComponentReferences} {
+		if len(elements.Added) != 0 || len(elements.Removed) != 0 || len(elements.Changed) != 0 {
+			return false
+		}
+	}
+	return len(d.Labels.Added) == 0 && len(d.Labels.Removed) == 0 && len(d.Labels.Changed) == 0
+}
+
+// ComponentDiffLines returns the human-readable lines of the diff.
+// Every section is followed by its added (+), removed (-) and changed (~) elements.
+func ComponentDiffLines(diff ComponentDiff) []string {
+	sections := []struct {
+		name string
+		diff ElementDiff
+	}{
+		{name: "resources", diff: diff.Resources},
+		{name: "sources", diff: diff.Sources},
+		{name: "componentReferences", diff: diff.ComponentReferences},
+	}
+	lines := []string{}
+	for _, section := range sections {
+		sectionLines := []string{}
+		for _, id := range section.diff.Added {
+			sectionLines = append(sectionLines, fmt.Sprintf("  + %s", DiffIdentityKey(id)))
+		}
+		for _, id := range section.diff.Removed {
+			sectionLines = append(sectionLines, fmt.Sprintf("  - %s", DiffIdentityKey(id)))
+		}
+		for _, change := range section.diff.Changed {
+			sectionLines = append(sectionLines, fmt.Sprintf("  ~ %s (%s)", DiffIdentityKey(change.Identity), strings.Join(change.Fields, ", ")))
+		}
+		if len(sectionLines) != 0 {
+			lines = append(lines, section.name+":")
+			lines = append(lines, sectionLines...)
+		}
+	}
+
+	labelLines := []string{}
+	for _, name := range diff.Labels.Added {
+		labelLines = append(labelLines, "  + "+name)
+	}
+	for _, name := range diff.Labels.Removed {
+		labelLines = append(labelLines, "  - "+name)
+	}
+	for _, name := range diff.Labels.Changed {
+		labelLines = append(labelLines, "  ~ "+name)
+	}
+	if len(labelLines) != 0 {
+		lines = append(lines, "labels:")
+		lines = append(lines, labelLines...)
+	}
+	return lines
+}
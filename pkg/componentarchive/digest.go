@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// SHA256 is the name of the sha256 hash algorithm.
+	SHA256 = "sha256"
+	// SHA512 is the name of the sha512 hash algorithm.
+	SHA512 = "sha512"
+)
+
+// blobHashFunctions contains the hash algorithms that can be used to calculate the digest of a local blob.
+var blobHashFunctions = map[string]func() hash.Hash{
+	SHA256: sha256.New,
+	SHA512: sha512.New,
+}
+
+// ValidateDigestAlgorithm checks whether the hash algorithm can be used to calculate the digest of a local blob.
+func ValidateDigestAlgorithm(algorithm string) error {
+	if _, ok := blobHashFunctions[algorithm]; !ok {
+		return fmt.Errorf("unsupported digest algorithm %q, expected one of %q, %q", algorithm, SHA256, SHA512)
+	}
+	return nil
+}
+
+// LocalBlobDigest calculates the generic blob digest of the local blob with the given filename.
+func LocalBlobDigest(archiveFs vfs.FileSystem, filename, algorithm string) (*cdv2.DigestSpec, error) {
+	newHash, ok := blobHashFunctions[algorithm]
+	if !ok {
+		return nil, ValidateDigestAlgorithm(algorithm)
+	}
+	file, err := archiveFs.Open(ctf.BlobPath(filename))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open local blob %q: %w", filename, err)
+	}
+	defer file.Close()
+
+	h := newHash()
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, fmt.Errorf("unable to read local blob %q: %w", filename, err)
+	}
+	return &cdv2.DigestSpec{
+		HashAlgorithm:          algorithm,
+		NormalisationAlgorithm: string(cdv2.GenericBlobDigestV1),
+		Value:                  hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// VerifyLocalBlobDigests verifies the local blobs of all resources against their recorded generic blob digests.
+// Resources without a generic blob digest are skipped.
+func VerifyLocalBlobDigests(archiveFs vfs.FileSystem, cd *cdv2.ComponentDescriptor) error {
+	var errs []error
+	for _, res := range cd.Resources {
+		if res.Digest == nil || res.Digest.NormalisationAlgorithm != string(cdv2.GenericBlobDigestV1) {
+			continue
+		}
+		filename, ok, err := LocalBlobFilename(res.Access)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resource %q: %w", res.GetName(), err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		actual, err := LocalBlobDigest(archiveFs, filename, res.Digest.HashAlgorithm)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resource %q: %w", res.GetName(), err))
+			continue
+		}
+		if actual.Value != res.Digest.Value {
+			errs = append(errs, fmt.Errorf("digest of local blob %q of resource %q is %s:%s but expected %s:%s",
+				filename, res.GetName(), actual.HashAlgorithm, actual.Value, res.Digest.HashAlgorithm, res.Digest.Value))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// ReadComponentDescriptorData reads the component descriptor of a component archive
+// and encodes it the same way as modified component descriptors are written, so that both can be compared.
+// Nil is returned if the component archive has no component descriptor.
+func ReadComponentDescriptorData(fs vfs.FileSystem, archivePath string) ([]byte, error) {
+	data, err := vfs.ReadFile(fs, filepath.Join(archivePath, ctf.ComponentDescriptorFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to read component descriptor: %w", err)
+	}
+	cd := &cdv2.ComponentDescriptor{}
+	if err := codec.Decode(data, cd, codec.DisableValidation(true)); err != nil {
+		return nil, fmt.Errorf("unable to decode component descriptor: %w", err)
+	}
+	data, err = yaml.Marshal(cd)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	return data, nil
+}
+
+// WriteComponentDescriptorDiff writes the differences between the old and the new encoded component descriptor
+// of a component archive as unified diff.
+func WriteComponentDescriptorDiff(w io.Writer, archivePath string, oldData, newData []byte) error {
+	compDescFilePath := filepath.ToSlash(filepath.Join(archivePath, ctf.ComponentDescriptorFileName))
+	return utils.WriteUnifiedDiff(w, compDescFilePath, compDescFilePath, oldData, newData)
+}
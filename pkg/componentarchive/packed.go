@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// Unpack prepares a component archive for modification.
+// If the path points to a tar or tgz file, the component archive is unpacked into a directory at the same path
+// of an in-memory layer on top of the given filesystem, so that it can be modified like a component archive in filesystem format.
+// All other paths are not touched.
+//
+// The returned filesystem has to be used for all modifications.
+// The returned repack function writes the modified component archive back to the file in its original format.
+func Unpack(fs vfs.FileSystem, path string) (vfs.FileSystem, func() error, error) {
+	info, err := fs.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fs, func() error { return nil }, nil
+		}
+		return nil, nil, fmt.Errorf("unable to read %q: %w", path, err)
+	}
+	if info.IsDir() {
+		return fs, func() error { return nil }, nil
+	}
+
+	ca, format, err := Parse(fs, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the file is hidden so that it can be replaced by the unpacked directory
+	unpackedFs, err := utils.NewOverlayFS(fs, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create in-memory filesystem: %w", err)
+	}
+	if err := ca.WriteToFilesystem(unpackedFs, path); err != nil {
+		return nil, nil, fmt.Errorf("unable to unpack component archive %q: %w", path, err)
+	}
+
+	repack := func() error {
+		archiveFs, err := projectionfs.New(unpackedFs, path)
+		if err != nil {
+			return fmt.Errorf("unable to create projectionfilesystem: %w", err)
+		}
+		ca, err := ctf.NewComponentArchiveFromFilesystem(archiveFs, codec.DisableValidation(true))
+		if err != nil {
+			return fmt.Errorf("unable to parse modified component archive %q: %w", path, err)
+		}
+		// the archive is packed in memory first so that the file mode of the original file is kept
+		packedFs := memoryfs.New()
+		if err := Write(packedFs, "/archive", ca, format); err != nil {
+			return err
+		}
+		data, err := vfs.ReadFile(packedFs, "/archive")
+		if err != nil {
+			return fmt.Errorf("unable to read packed component archive: %w", err)
+		}
+		if err := utils.WriteFileAtomic(fs, path, data, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("unable to write component archive %q: %w", path, err)
+		}
+		return nil
+	}
+	return unpackedFs, repack, nil
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"path/filepath"
+
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/testutils"
+)
+
+var _ = Describe("Unpack", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		testdataFs = testutils.NewTestdataFileSystem("./testdata")
+	})
+
+	pack := func(path string, format ctf.ArchiveFormat) {
+		ca, _, err := Parse(testdataFs, "./01-component")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(Write(testdataFs, path, ca, format)).To(Succeed())
+	}
+
+	for _, format := range []ctf.ArchiveFormat{ctf.ArchiveFormatTar, ctf.ArchiveFormatTarGzip} {
+		format := format
+		It("should modify a "+string(format)+" component archive and repack it in the same format", func() {
+			pack("ca.archive", format)
+
+			fs, repack, err := Unpack(testdataFs, "ca.archive")
+			Expect(err).ToNot(HaveOccurred())
+
+			compDescFilePath := filepath.Join("ca.archive", ctf.ComponentDescriptorFileName)
+			data, err := vfs.ReadFile(fs, compDescFilePath)
+			Expect(err).ToNot(HaveOccurred())
+			ca, err := ctf.NewComponentArchiveFromFilesystem(mustProject(fs, "ca.archive"), codec.DisableValidation(true))
+			Expect(err).ToNot(HaveOccurred())
+			ca.ComponentDescriptor.Version = "v0.0.1"
+			data, err = yaml.Marshal(ca.ComponentDescriptor)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vfs.WriteFile(fs, compDescFilePath, data, 0664)).To(Succeed())
+
+			// the archive file is only modified after it is repacked
+			parsed, _, err := Parse(testdataFs, "ca.archive")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed.ComponentDescriptor.Version).To(Equal("v0.0.0"))
+
+			Expect(repack()).To(Succeed())
+			parsed, parsedFormat, err := Parse(testdataFs, "ca.archive")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsedFormat).To(Equal(format))
+			Expect(parsed.ComponentDescriptor.Version).To(Equal("v0.0.1"))
+		})
+	}
+
+	It("should not touch component archive directories", func() {
+		fs, repack, err := Unpack(testdataFs, "./01-component")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs).To(BeIdenticalTo(testdataFs))
+		Expect(repack()).To(Succeed())
+	})
+
+	It("should not touch non-existing component archives", func() {
+		fs, repack, err := Unpack(testdataFs, "./new-component")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs).To(BeIdenticalTo(testdataFs))
+		Expect(repack()).To(Succeed())
+	})
+
+})
+
+func mustProject(fs vfs.FileSystem, path string) vfs.FileSystem {
+	archiveFs, err := projectionfs.New(fs, path)
+	Expect(err).ToNot(HaveOccurred())
+	return archiveFs
+}
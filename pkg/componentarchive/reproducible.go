@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// SourceDateEpochEnvName is the name of the environment variable that defines the timestamp of reproducible archives.
+// See https://reproducible-builds.org/specs/source-date-epoch/.
+const SourceDateEpochEnvName = "SOURCE_DATE_EPOCH"
+
+// ReproducibleModTime returns the modification time of the entries of reproducible archives.
+// It is read from SOURCE_DATE_EPOCH and defaults to the unix epoch.
+func ReproducibleModTime() (time.Time, error) {
+	epoch := os.Getenv(SourceDateEpochEnvName)
+	if len(epoch) == 0 {
+		return time.Unix(0, 0), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: %w", SourceDateEpochEnvName, epoch, err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// WriteReproducible writes the component archive as tar or gzipped tar with normalized tar headers,
+// so that the same component archive always results in the same file.
+// All entries get the given modification time and no user or group ownership.
+func WriteReproducible(fs vfs.FileSystem, path string, ca *ctf.ComponentArchive, format ctf.ArchiveFormat, modTime time.Time) error {
+	if format != ctf.ArchiveFormatTar && format != ctf.ArchiveFormatTarGzip {
+		return fmt.Errorf("reproducible archives can only be written as %q or %q", ctf.ArchiveFormatTar, ctf.ArchiveFormatTarGzip)
+	}
+	out, err := fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0664)
+	if err != nil {
+		return fmt.Errorf("unable to open exported file %s: %s", path, err.Error())
+	}
+
+	var (
+		w  io.Writer = out
+		gw *gzip.Writer
+	)
+	if format == ctf.ArchiveFormatTarGzip {
+		// the gzip header contains no name and modification time by default
+		gw = gzip.NewWriter(out)
+		w = gw
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(ca.WriteTar(pw))
+	}()
+	if err := normalizeTar(pr, w, modTime); err != nil {
+		_ = pr.CloseWithError(err)
+		_ = out.Close()
+		return fmt.Errorf("unable to export file to %s: %w", path, err)
+	}
+	if gw != nil {
+		if err := gw.Close(); err != nil {
+			_ = out.Close()
+			return fmt.Errorf("unable to close gzip writer: %w", err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("unable to close file: %w", err)
+	}
+	return nil
+}
+
+// normalizeTar copies all entries of the tar to the writer and normalizes their headers.
+func normalizeTar(r io.Reader, w io.Writer, modTime time.Time) error {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("unable to read tar header: %w", err)
+		}
+		normalized := &tar.Header{
+			Typeflag: header.Typeflag,
+			Name:     header.Name,
+			Linkname: header.Linkname,
+			Size:     header.Size,
+			Mode:     header.Mode,
+			ModTime:  modTime,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(normalized); err != nil {
+			return fmt.Errorf("unable to write tar header for %s: %w", header.Name, err)
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return fmt.Errorf("unable to write content of %s: %w", header.Name, err)
+		}
+	}
+	return tw.Close()
+}
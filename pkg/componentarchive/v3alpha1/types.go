@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package v3alpha1 contains the component descriptor of the OCM schema version ocm.software/v3alpha1.
+// Only the parts that can be translated from and to the component descriptor v2 are defined.
+package v3alpha1
+
+import (
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+)
+
+const (
+	// SchemaVersion is the api version of the component descriptor.
+	SchemaVersion = "ocm.software/v3alpha1"
+	// Kind is the kind of the component descriptor.
+	Kind = "ComponentVersion"
+)
+
+// Access types of OCM that differ from the access types of the component descriptor v2.
+const (
+	// OCIArtifactType is the access type of an oci artifact.
+	OCIArtifactType = "ociArtifact"
+	// LocalBlobType is the access type of a blob that is stored along with the component descriptor.
+	LocalBlobType = "localBlob"
+	// GitHubAccessType is the access type of a commit in a GitHub repository.
+	GitHubAccessType = "gitHub"
+)
+
+// OCIRegistryType is the repository type of an oci registry.
+const OCIRegistryType = "OCIRegistry"
+
+// ComponentDescriptor defines a versioned component with its sources, resources and references.
+type ComponentDescriptor struct {
+	// APIVersion is the schema version of the component descriptor.
+	APIVersion string `json:"apiVersion"`
+	// Kind is the kind of the component descriptor.
+	Kind string `json:"kind"`
+	// Metadata contains the name, version and provider of the component.
+	Metadata ObjectMeta `json:"metadata"`
+	// RepositoryContexts defines the previous repositories of the component.
+	RepositoryContexts []*cdv2.UnstructuredTypedObject `json:"repositoryContexts,omitempty"`
+	// Spec contains the specification of the component.
+	Spec ComponentVersionSpec `json:"spec"`
+	// Signatures contains a list of signatures for the component descriptor.
+	Signatures []cdv2.Signature `json:"signatures,omitempty"`
+}
+
+// ObjectMeta defines the metadata of a component.
+type ObjectMeta struct {
+	// Name is the name of the component.
+	Name string `json:"name"`
+	// Version is the version of the component.
+	Version string `json:"version"`
+	// Labels defines an optional set of additional labels describing the component.
+	Labels cdv2.Labels `json:"labels,omitempty"`
+	// Provider describes the provider of the component.
+	Provider Provider `json:"provider"`
+}
+
+// Provider describes the provider of a component.
+type Provider struct {
+	// Name is the name of the provider.
+	Name string `json:"name"`
+	// Labels defines an optional set of additional labels describing the provider.
+	Labels cdv2.Labels `json:"labels,omitempty"`
+}
+
+// ComponentVersionSpec defines the sources, resources and references of a component.
+type ComponentVersionSpec struct {
+	Sources    []Source    `json:"sources,omitempty"`
+	References []Reference `json:"references,omitempty"`
+	Resources  []Resource  `json:"resources,omitempty"`
+}
+
+// ElementMeta defines the identity and labels of a source, resource or reference.
+type ElementMeta struct {
+	Name          string        `json:"name"`
+	Version       string        `json:"version"`
+	ExtraIdentity cdv2.Identity `json:"extraIdentity,omitempty"`
+	Labels        cdv2.Labels   `json:"labels,omitempty"`
+}
+
+// Source describes a source of a component.
+type Source struct {
+	ElementMeta `json:",inline"`
+	Type        string                        `json:"type"`
+	Access      *cdv2.UnstructuredTypedObject `json:"access"`
+}
+
+// SourceRef references a source of the component by its identity.
+type SourceRef struct {
+	IdentitySelector map[string]string `json:"identitySelector,omitempty"`
+	Labels           cdv2.Labels       `json:"labels,omitempty"`
+}
+
+// Resource describes a resource of a component.
+type Resource struct {
+	ElementMeta `json:",inline"`
+	Type        string                        `json:"type"`
+	Relation    cdv2.ResourceRelation         `json:"relation,omitempty"`
+	SourceRefs  []SourceRef                   `json:"srcRefs,omitempty"`
+	Access      *cdv2.UnstructuredTypedObject `json:"access"`
+	Digest      *cdv2.DigestSpec              `json:"digest,omitempty"`
+}
+
+// Reference describes a reference to another component.
+type Reference struct {
+	ElementMeta   `json:",inline"`
+	ComponentName string           `json:"componentName"`
+	Digest        *cdv2.DigestSpec `json:"digest,omitempty"`
+}
@@ -91,7 +91,7 @@ func AddToLocalCache(fs vfs.FileSystem, cd *cdv2.ComponentDescriptor) error {
 	if err := fs.MkdirAll(filepath.Dir(componentPath), os.ModePerm); err != nil {
 		return fmt.Errorf("unable to create components path %q: %w", filepath.Dir(componentPath), err)
 	}
-	if err := vfs.WriteFile(fs, componentPath, data, os.ModePerm); err != nil {
+	if err := vfs.WriteFile(fs, componentPath, data, 0664); err != nil {
 		return fmt.Errorf("unable to write component to cache at %q: %w", componentPath, err)
 	}
 	return nil
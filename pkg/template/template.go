@@ -5,14 +5,34 @@
 package template
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/drone/envsubst"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
 )
 
 // Options defines the options for component-cli templating
 type Options struct {
 	Vars map[string]string
+	// FlagVars are the variables that are defined with --var.
+	// They take precedence over all other variables.
+	FlagVars map[string]string
+	// ValuesFiles are the paths to yaml files that define variables as key value pairs.
+	// Variables of later files overwrite the ones of earlier files.
+	ValuesFiles []string
+
+	// values are the variables that are read from the values files.
+	values map[string]string
+}
+
+// AddFlags adds the flags to define template variables to the flagset.
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringToStringVar(&o.FlagVars, "var", nil, "[OPTIONAL] template variable in the form <name>=<value>. Can be defined multiple times")
+	fs.StringArrayVar(&o.ValuesFiles, "values", nil, "[OPTIONAL] path to a yaml file with template variables as key value pairs. Can be defined multiple times")
 }
 
 // Usage prints out the usage for templating
@@ -56,6 +76,36 @@ func (o *Options) Parse(args []string) []string {
 	return addArgs
 }
 
+// LoadValuesFiles reads the variables of the values files.
+func (o *Options) LoadValuesFiles(fs vfs.FileSystem) error {
+	o.values = map[string]string{}
+	for _, path := range o.ValuesFiles {
+		data, err := vfs.ReadFile(fs, path)
+		if err != nil {
+			return fmt.Errorf("unable to read values file %q: %w", path, err)
+		}
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("unable to decode values file %q: %w", path, err)
+		}
+		for name, value := range values {
+			switch v := value.(type) {
+			case string:
+				o.values[name] = v
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("value of %q in values file %q must be a scalar", name, path)
+			default:
+				encoded, err := json.Marshal(v)
+				if err != nil {
+					return fmt.Errorf("unable to encode value of %q in values file %q: %w", name, path, err)
+				}
+				o.values[name] = string(encoded)
+			}
+		}
+	}
+	return nil
+}
+
 // Template templates a string with the parsed vars.
 func (o *Options) Template(data string) (string, error) {
 	return envsubst.Eval(data, o.mapping)
@@ -64,9 +114,12 @@ func (o *Options) Template(data string) (string, error) {
 // mapping is a helper function for the envsubst to provide the value for a variable name.
 // It returns an emtpy string if the variable is not defined.
 func (o *Options) mapping(variable string) string {
-	if o.Vars == nil {
-		return ""
+	if value, ok := o.FlagVars[variable]; ok {
+		return value
+	}
+	if value, ok := o.Vars[variable]; ok {
+		return value
 	}
 	// todo: maybe use os.getenv as backup.
-	return o.Vars[variable]
+	return o.values[variable]
 }
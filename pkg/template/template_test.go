@@ -7,6 +7,8 @@ package template_test
 import (
 	"testing"
 
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -82,4 +84,58 @@ var _ = Describe("Template", func() {
 
 	})
 
+	Context("Variables", func() {
+
+		It("should read variables from values files and convert scalars", func() {
+			fs := memoryfs.New()
+			Expect(vfs.WriteFile(fs, "/values.yaml", []byte("name: nginx\nreplicas: 3\nenabled: true\n"), 0644)).To(Succeed())
+			opts := template.Options{ValuesFiles: []string{"/values.yaml"}}
+			Expect(opts.LoadValuesFiles(fs)).To(Succeed())
+
+			res, err := opts.Template("${name} ${replicas} ${enabled}")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal("nginx 3 true"))
+		})
+
+		It("should overwrite values of earlier values files", func() {
+			fs := memoryfs.New()
+			Expect(vfs.WriteFile(fs, "/a.yaml", []byte("name: a\nversion: v1\n"), 0644)).To(Succeed())
+			Expect(vfs.WriteFile(fs, "/b.yaml", []byte("name: b\n"), 0644)).To(Succeed())
+			opts := template.Options{ValuesFiles: []string{"/a.yaml", "/b.yaml"}}
+			Expect(opts.LoadValuesFiles(fs)).To(Succeed())
+
+			res, err := opts.Template("${name}:${version}")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal("b:v1"))
+		})
+
+		It("should prefer --var over arguments over values files", func() {
+			fs := memoryfs.New()
+			Expect(vfs.WriteFile(fs, "/values.yaml", []byte("a: file\nb: file\nc: file\n"), 0644)).To(Succeed())
+			opts := template.Options{
+				ValuesFiles: []string{"/values.yaml"},
+				FlagVars:    map[string]string{"a": "flag"},
+			}
+			opts.Parse([]string{"a=arg", "b=arg"})
+			Expect(opts.LoadValuesFiles(fs)).To(Succeed())
+
+			res, err := opts.Template("${a} ${b} ${c}")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal("flag arg file"))
+		})
+
+		It("should fail if a values file contains nested values", func() {
+			fs := memoryfs.New()
+			Expect(vfs.WriteFile(fs, "/values.yaml", []byte("image:\n  tag: v1\n"), 0644)).To(Succeed())
+			opts := template.Options{ValuesFiles: []string{"/values.yaml"}}
+			Expect(opts.LoadValuesFiles(fs)).To(HaveOccurred())
+		})
+
+		It("should fail if a values file does not exist", func() {
+			opts := template.Options{ValuesFiles: []string{"/values.yaml"}}
+			Expect(opts.LoadValuesFiles(memoryfs.New())).To(HaveOccurred())
+		})
+
+	})
+
 })
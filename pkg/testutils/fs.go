@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testutils
+
+import (
+	"os"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/gomega"
+)
+
+// NewTestdataFileSystem returns an in-memory filesystem with a copy of the directory at the given path including its symlinks.
+// Other than a layered filesystem, it supports the renames that atomically replace files.
+func NewTestdataFileSystem(path string) vfs.FileSystem {
+	baseFs, err := projectionfs.New(osfs.New(), path)
+	Expect(err).ToNot(HaveOccurred())
+	fs := memoryfs.New()
+	err = vfs.Walk(baseFs, "/", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.IsDir():
+			return fs.MkdirAll(path, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := baseFs.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return fs.Symlink(target, path)
+		default:
+			return vfs.CopyFile(baseFs, path, fs, path)
+		}
+	})
+	Expect(err).ToNot(HaveOccurred())
+	return fs
+}
@@ -17,6 +17,18 @@ type transportConfig struct {
 	Processors      []processorDefinition      `json:"processors"`
 	Downloaders     []downloaderDefinition     `json:"downloaders"`
 	ProcessingRules []processingRuleDefinition `json:"processingRules"`
+	// SourceFilters define the sources that are transported.
+	// All sources are transported if no source filters are defined.
+	SourceFilters []filterDefinition `json:"sourceFilters"`
+	Retry         *retryDefinition   `json:"retry"`
+}
+
+// retryDefinition defines how failed processors and registry operations of downloaders and uploaders are retried.
+// Durations are defined as go duration strings, e.g. "500ms" or "1m".
+type retryDefinition struct {
+	Retries int    `json:"retries"`
+	Backoff string `json:"backoff"`
+	Timeout string `json:"timeout"`
 }
 
 type baseProcessorDefinition struct {
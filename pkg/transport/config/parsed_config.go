@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"sigs.k8s.io/yaml"
 
 	"github.com/gardener/component-cli/pkg/transport/filters"
+	"github.com/gardener/component-cli/pkg/transport/process"
 )
 
 type ParsedTransportConfig struct {
@@ -16,6 +18,12 @@ type ParsedTransportConfig struct {
 	Processors      []ParsedProcessorDefinition
 	Uploaders       []ParsedUploaderDefinition
 	ProcessingRules []ParsedProcessingRuleDefinition
+	// SourceFilters define the sources that are transported.
+	SourceFilters []filters.Filter
+	// Retry defines how failed processors and the registry operations of downloaders and uploaders are retried,
+	// e.g. the fetches and uploads of resource blobs.
+	// Nothing is retried if the config does not define a retry section.
+	Retry process.RetryOptions
 }
 
 type ParsedDownloaderDefinition struct {
@@ -121,9 +129,54 @@ func ParseTransportConfig(configFilePath string) (*ParsedTransportConfig, error)
 		parsedConfig.ProcessingRules = append(parsedConfig.ProcessingRules, parsedProcessingRule)
 	}
 
+	// source filters
+	sourceFilters, err := createFilterList(config.SourceFilters, ff)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create source filters: %w", err)
+	}
+	for i, filter := range sourceFilters {
+		if !filters.CanMatchSources(filter) {
+			return nil, fmt.Errorf("filter type %s cannot be used as source filter", config.SourceFilters[i].Type)
+		}
+	}
+	parsedConfig.SourceFilters = sourceFilters
+
+	// retry
+	if config.Retry != nil {
+		retry, err := parseRetryDefinition(*config.Retry)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse retry: %w", err)
+		}
+		parsedConfig.Retry = retry
+	}
+
 	return &parsedConfig, nil
 }
 
+func parseRetryDefinition(def retryDefinition) (process.RetryOptions, error) {
+	opts := process.RetryOptions{
+		Retries: def.Retries,
+	}
+	if opts.Retries < 0 {
+		return process.RetryOptions{}, fmt.Errorf("retries must not be negative")
+	}
+	var err error
+	if len(def.Backoff) != 0 {
+		if opts.Backoff, err = time.ParseDuration(def.Backoff); err != nil {
+			return process.RetryOptions{}, fmt.Errorf("invalid backoff: %w", err)
+		}
+	}
+	if len(def.Timeout) != 0 {
+		if opts.Timeout, err = time.ParseDuration(def.Timeout); err != nil {
+			return process.RetryOptions{}, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	if opts.Backoff < 0 || opts.Timeout < 0 {
+		return process.RetryOptions{}, fmt.Errorf("backoff and timeout must not be negative")
+	}
+	return opts, nil
+}
+
 // MatchDownloaders finds all matching downloaders
 func (c *ParsedTransportConfig) MatchDownloaders(cd cdv2.ComponentDescriptor, res cdv2.Resource) []ParsedDownloaderDefinition {
 	dls := []ParsedDownloaderDefinition{}
@@ -157,6 +210,17 @@ func (c *ParsedTransportConfig) MatchProcessingRules(cd cdv2.ComponentDescriptor
 	return prs
 }
 
+// MatchSource checks whether the source matches all source filters.
+// All sources match if no source filters are defined.
+func (c *ParsedTransportConfig) MatchSource(cd cdv2.ComponentDescriptor, src cdv2.Source) bool {
+	for _, filter := range c.SourceFilters {
+		if !filters.MatchesSource(filter, cd, src) {
+			return false
+		}
+	}
+	return true
+}
+
 func areAllFiltersMatching(filters []filters.Filter, cd cdv2.ComponentDescriptor, res cdv2.Resource) bool {
 	for _, filter := range filters {
 		if !filter.Matches(cd, res) {
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/config"
+	"github.com/gardener/component-cli/pkg/transport/process"
+)
+
+var _ = Describe("ParseTransportConfig", func() {
+
+	var configDir string
+
+	BeforeEach(func() {
+		var err error
+		configDir, err = os.MkdirTemp("", "transport-config-")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(configDir)).To(Succeed())
+	})
+
+	writeConfig := func(data string) string {
+		path := filepath.Join(configDir, "config.yaml")
+		Expect(os.WriteFile(path, []byte(data), 0600)).To(Succeed())
+		return path
+	}
+
+	It("should parse the retry section alongside the filters", func() {
+		path := writeConfig(`
+meta:
+  version: v1
+processors:
+- name: labeler
+  type: ResourceLabeler
+processingRules:
+- name: label-oci-images
+  processors:
+  - name: labeler
+    type: processor
+  filters:
+  - type: ResourceTypeFilter
+    spec:
+      includeResourceTypes:
+      - ociImage
+retry:
+  retries: 3
+  backoff: 500ms
+  timeout: 1m
+`)
+		cfg, err := config.ParseTransportConfig(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.ProcessingRules).To(HaveLen(1))
+		Expect(cfg.ProcessingRules[0].Filters).To(HaveLen(1))
+		Expect(cfg.Retry).To(Equal(process.RetryOptions{
+			Retries: 3,
+			Backoff: 500 * time.Millisecond,
+			Timeout: time.Minute,
+		}))
+	})
+
+	It("should default to a single attempt if no retry section is defined", func() {
+		path := writeConfig(`
+meta:
+  version: v1
+`)
+		cfg, err := config.ParseTransportConfig(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Retry).To(Equal(process.RetryOptions{}))
+	})
+
+	It("should parse the source filters", func() {
+		path := writeConfig(`
+meta:
+  version: v1
+sourceFilters:
+- type: SourceFilter
+  spec:
+    includeSourceTypes:
+    - git
+- type: ComponentNameFilter
+  spec:
+    includeComponentNames:
+    - example.com/.*
+`)
+		cfg, err := config.ParseTransportConfig(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.SourceFilters).To(HaveLen(2))
+
+		cd := cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/component"
+		Expect(cfg.MatchSource(cd, cdv2.Source{IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "repo", Type: "git"}})).To(BeTrue())
+		Expect(cfg.MatchSource(cd, cdv2.Source{IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "docs", Type: "http"}})).To(BeFalse())
+		cd.Name = "other.com/component"
+		Expect(cfg.MatchSource(cd, cdv2.Source{IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "repo", Type: "git"}})).To(BeFalse())
+	})
+
+	It("should match all sources if no source filters are defined", func() {
+		path := writeConfig(`
+meta:
+  version: v1
+`)
+		cfg, err := config.ParseTransportConfig(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.MatchSource(cdv2.ComponentDescriptor{}, cdv2.Source{IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "repo", Type: "git"}})).To(BeTrue())
+	})
+
+	It("should return an error for a source filter that cannot match sources", func() {
+		path := writeConfig(`
+sourceFilters:
+- type: ResourceTypeFilter
+  spec:
+    includeResourceTypes:
+    - ociImage
+`)
+		_, err := config.ParseTransportConfig(path)
+		Expect(err).To(MatchError("filter type ResourceTypeFilter cannot be used as source filter"))
+
+		path = writeConfig(`
+sourceFilters:
+- type: AndFilter
+  spec:
+    filters:
+    - type: ResourceTypeFilter
+      spec:
+        includeResourceTypes:
+        - ociImage
+`)
+		_, err = config.ParseTransportConfig(path)
+		Expect(err).To(MatchError("filter type AndFilter cannot be used as source filter"))
+	})
+
+	It("should return an error for an invalid retry section", func() {
+		path := writeConfig(`
+retry:
+  backoff: soon
+`)
+		_, err := config.ParseTransportConfig(path)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid backoff"))
+
+		path = writeConfig(`
+retry:
+  retries: -1
+`)
+		_, err = config.ParseTransportConfig(path)
+		Expect(err).To(MatchError("unable to parse retry: retries must not be negative"))
+	})
+
+})
@@ -11,7 +11,8 @@ import (
 )
 
 type ComponentNameFilterSpec struct {
-	IncludeComponentNames []string
+	// IncludeComponentNames are regular expressions that have to match the complete component name.
+	IncludeComponentNames []string `json:"includeComponentNames"`
 }
 
 type componentNameFilter struct {
@@ -27,7 +28,12 @@ func (f componentNameFilter) Matches(cd cdv2.ComponentDescriptor, r cdv2.Resourc
 	return false
 }
 
-// NewComponentNameFilter creates a new componentNameFilter
+func (f componentNameFilter) MatchesSource(cd cdv2.ComponentDescriptor, s cdv2.Source) bool {
+	return f.Matches(cd, cdv2.Resource{})
+}
+
+// NewComponentNameFilter creates a new componentNameFilter.
+// The include list entries are regular expressions that are anchored to the complete component name.
 func NewComponentNameFilter(spec ComponentNameFilterSpec) (Filter, error) {
 	if len(spec.IncludeComponentNames) == 0 {
 		return nil, fmt.Errorf("includeComponentNames must not be empty")
@@ -35,7 +41,7 @@ func NewComponentNameFilter(spec ComponentNameFilterSpec) (Filter, error) {
 
 	icnRegexps := []*regexp.Regexp{}
 	for _, icn := range spec.IncludeComponentNames {
-		icnRegexp, err := regexp.Compile(icn)
+		icnRegexp, err := regexp.Compile("^(?:" + icn + ")$")
 		if err != nil {
 			return nil, fmt.Errorf("unable to parse regexp %s: %w", icn, err)
 		}
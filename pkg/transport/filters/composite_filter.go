@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package filters
+
+import (
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+)
+
+// FilterDefinition defines a nested filter of a composite filter.
+type FilterDefinition struct {
+	Type string           `json:"type"`
+	Spec *json.RawMessage `json:"spec"`
+}
+
+type AndFilterSpec struct {
+	Filters []FilterDefinition `json:"filters"`
+}
+
+type OrFilterSpec struct {
+	Filters []FilterDefinition `json:"filters"`
+}
+
+type NotFilterSpec struct {
+	Filter FilterDefinition `json:"filter"`
+}
+
+type andFilter struct {
+	filters []Filter
+}
+
+func (f andFilter) Matches(cd cdv2.ComponentDescriptor, r cdv2.Resource) bool {
+	for _, filter := range f.filters {
+		if !filter.Matches(cd, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesSource requires all nested filters to be able to match sources, see CanMatchSources.
+func (f andFilter) MatchesSource(cd cdv2.ComponentDescriptor, s cdv2.Source) bool {
+	for _, filter := range f.filters {
+		if !filter.(SourceMatcher).MatchesSource(cd, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewAndFilter creates a new filter that matches if all of the given filters match
+func NewAndFilter(filters ...Filter) Filter {
+	return &andFilter{
+		filters: filters,
+	}
+}
+
+type orFilter struct {
+	filters []Filter
+}
+
+func (f orFilter) Matches(cd cdv2.ComponentDescriptor, r cdv2.Resource) bool {
+	for _, filter := range f.filters {
+		if filter.Matches(cd, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesSource requires all nested filters to be able to match sources, see CanMatchSources.
+func (f orFilter) MatchesSource(cd cdv2.ComponentDescriptor, s cdv2.Source) bool {
+	for _, filter := range f.filters {
+		if filter.(SourceMatcher).MatchesSource(cd, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewOrFilter creates a new filter that matches if at least one of the given filters matches
+func NewOrFilter(filters ...Filter) Filter {
+	return &orFilter{
+		filters: filters,
+	}
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+func (f notFilter) Matches(cd cdv2.ComponentDescriptor, r cdv2.Resource) bool {
+	return !f.filter.Matches(cd, r)
+}
+
+// MatchesSource requires the nested filter to be able to match sources, see CanMatchSources.
+func (f notFilter) MatchesSource(cd cdv2.ComponentDescriptor, s cdv2.Source) bool {
+	return !f.filter.(SourceMatcher).MatchesSource(cd, s)
+}
+
+// NewNotFilter creates a new filter that inverts the result of the given filter
+func NewNotFilter(filter Filter) Filter {
+	return &notFilter{
+		filter: filter,
+	}
+}
+
+// CanMatchSources returns whether the filter and all of its nested filters are able to match sources.
+// Composite filters always implement SourceMatcher but can only match sources if their nested filters can.
+func CanMatchSources(f Filter) bool {
+	switch filter := f.(type) {
+	case *andFilter:
+		return canAllMatchSources(filter.filters)
+	case *orFilter:
+		return canAllMatchSources(filter.filters)
+	case *notFilter:
+		return CanMatchSources(filter.filter)
+	}
+	_, ok := f.(SourceMatcher)
+	return ok
+}
+
+func canAllMatchSources(filters []Filter) bool {
+	for _, f := range filters {
+		if !CanMatchSources(f) {
+			return false
+		}
+	}
+	return true
+}
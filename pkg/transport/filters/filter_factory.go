@@ -17,8 +17,26 @@ const (
 	// ResourceTypeFilterType defines the type of a resource type filter
 	ResourceTypeFilterType = "ResourceTypeFilter"
 
+	// ResourceLabelFilterType defines the type of a resource label filter
+	ResourceLabelFilterType = "ResourceLabelFilter"
+
 	// AccessTypeFilterType defines the type of a access type filter
 	AccessTypeFilterType = "AccessTypeFilter"
+
+	// SourceFilterType defines the type of a source filter
+	SourceFilterType = "SourceFilter"
+
+	// VersionFilterType defines the type of a component version filter
+	VersionFilterType = "VersionFilter"
+
+	// AndFilterType defines the type of a filter that matches if all nested filters match
+	AndFilterType = "AndFilter"
+
+	// OrFilterType defines the type of a filter that matches if one of the nested filters matches
+	OrFilterType = "OrFilter"
+
+	// NotFilterType defines the type of a filter that inverts a nested filter
+	NotFilterType = "NotFilter"
 )
 
 // NewFilterFactory creates a new filter factory
@@ -40,8 +58,20 @@ func (f *FilterFactory) Create(filterType string, spec *json.RawMessage) (Filter
 		return f.createComponentNameFilter(spec)
 	case ResourceTypeFilterType:
 		return f.createResourceTypeFilter(spec)
+	case ResourceLabelFilterType:
+		return f.createResourceLabelFilter(spec)
 	case AccessTypeFilterType:
 		return f.createAccessTypeFilter(spec)
+	case SourceFilterType:
+		return f.createSourceFilter(spec)
+	case VersionFilterType:
+		return f.createVersionFilter(spec)
+	case AndFilterType:
+		return f.createAndFilter(spec)
+	case OrFilterType:
+		return f.createOrFilter(spec)
+	case NotFilterType:
+		return f.createNotFilter(spec)
 	default:
 		return nil, fmt.Errorf("unknown filter type %s", filterType)
 	}
@@ -65,6 +95,15 @@ func (f *FilterFactory) createResourceTypeFilter(rawSpec *json.RawMessage) (Filt
 	return NewResourceTypeFilter(spec)
 }
 
+func (f *FilterFactory) createResourceLabelFilter(rawSpec *json.RawMessage) (Filter, error) {
+	var spec ResourceLabelFilterSpec
+	if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("unable to parse spec: %w", err)
+	}
+
+	return NewResourceLabelFilter(spec)
+}
+
 func (f *FilterFactory) createAccessTypeFilter(rawSpec *json.RawMessage) (Filter, error) {
 	var spec AccessTypeFilterSpec
 	if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
@@ -73,3 +112,80 @@ func (f *FilterFactory) createAccessTypeFilter(rawSpec *json.RawMessage) (Filter
 
 	return NewAccessTypeFilter(spec)
 }
+
+func (f *FilterFactory) createSourceFilter(rawSpec *json.RawMessage) (Filter, error) {
+	var spec SourceFilterSpec
+	if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("unable to parse spec: %w", err)
+	}
+
+	return NewSourceFilter(spec)
+}
+
+func (f *FilterFactory) createVersionFilter(rawSpec *json.RawMessage) (Filter, error) {
+	var spec VersionFilterSpec
+	if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("unable to parse spec: %w", err)
+	}
+
+	return NewVersionFilter(spec)
+}
+
+func (f *FilterFactory) createAndFilter(rawSpec *json.RawMessage) (Filter, error) {
+	var spec AndFilterSpec
+	if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("unable to parse spec: %w", err)
+	}
+
+	filters, err := f.createNestedFilters(spec.Filters)
+	if err != nil {
+		return nil, err
+	}
+	return NewAndFilter(filters...), nil
+}
+
+func (f *FilterFactory) createOrFilter(rawSpec *json.RawMessage) (Filter, error) {
+	var spec OrFilterSpec
+	if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("unable to parse spec: %w", err)
+	}
+
+	filters, err := f.createNestedFilters(spec.Filters)
+	if err != nil {
+		return nil, err
+	}
+	return NewOrFilter(filters...), nil
+}
+
+func (f *FilterFactory) createNotFilter(rawSpec *json.RawMessage) (Filter, error) {
+	var spec NotFilterSpec
+	if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("unable to parse spec: %w", err)
+	}
+
+	if len(spec.Filter.Type) == 0 {
+		return nil, fmt.Errorf("filter must not be empty")
+	}
+	filter, err := f.Create(spec.Filter.Type, spec.Filter.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create nested filter %s: %w", spec.Filter.Type, err)
+	}
+	return NewNotFilter(filter), nil
+}
+
+// createNestedFilters creates the nested filters of a composite filter.
+func (f *FilterFactory) createNestedFilters(definitions []FilterDefinition) ([]Filter, error) {
+	if len(definitions) == 0 {
+		return nil, fmt.Errorf("filters must not be empty")
+	}
+
+	filters := []Filter{}
+	for i, def := range definitions {
+		filter, err := f.Create(def.Type, def.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create nested filter %d (%s): %w", i, def.Type, err)
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
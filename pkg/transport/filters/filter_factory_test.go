@@ -0,0 +1,321 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package filters_test
+
+import (
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	filter "github.com/gardener/component-cli/pkg/transport/filters"
+)
+
+var _ = Describe("filterFactory", func() {
+
+	var factory *filter.FilterFactory
+
+	BeforeEach(func() {
+		factory = filter.NewFilterFactory()
+	})
+
+	// rawSpec marshals the given filter spec as it is defined in a transport config.
+	rawSpec := func(spec interface{}) *json.RawMessage {
+		data, err := json.Marshal(spec)
+		Expect(err).ToNot(HaveOccurred())
+		raw := json.RawMessage(data)
+		return &raw
+	}
+
+	newResource := func(resourceType string) cdv2.Resource {
+		return cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    resourceType,
+			},
+		}
+	}
+
+	Context("ResourceTypeFilter", func() {
+
+		It("should create a resource type filter from a spec", func() {
+			spec := rawSpec(filter.ResourceTypeFilterSpec{
+				IncludeResourceTypes: []string{cdv2.OCIImageType},
+			})
+			Expect(string(*spec)).To(MatchJSON(`{"includeResourceTypes": ["ociImage"]}`))
+
+			f, err := factory.Create(filter.ResourceTypeFilterType, spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newResource(cdv2.OCIImageType))).To(BeTrue())
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newResource("helm"))).To(BeFalse())
+		})
+
+		It("should return an error if the include list is empty", func() {
+			_, err := factory.Create(filter.ResourceTypeFilterType, rawSpec(map[string]interface{}{}))
+			Expect(err).To(MatchError("includeResourceTypes must not be empty"))
+		})
+
+	})
+
+	Context("ResourceLabelFilter", func() {
+
+		newLabelledResource := func(labels ...cdv2.Label) cdv2.Resource {
+			res := newResource(cdv2.OCIImageType)
+			res.Labels = labels
+			return res
+		}
+		transportLabel := cdv2.Label{Name: "transport", Value: json.RawMessage(`true`)}
+
+		It("should match resources with a label of the given name", func() {
+			spec := json.RawMessage(`{"name": "transport"}`)
+			f, err := factory.Create(filter.ResourceLabelFilterType, &spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newLabelledResource(transportLabel))).To(BeTrue())
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newLabelledResource(
+				cdv2.Label{Name: "transport", Value: json.RawMessage(`"false"`)}))).To(BeTrue())
+		})
+
+		It("should match resources with a label of the given name and value", func() {
+			spec := json.RawMessage(`{"name": "transport", "value": true}`)
+			f, err := factory.Create(filter.ResourceLabelFilterType, &spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newLabelledResource(transportLabel))).To(BeTrue())
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newLabelledResource(
+				cdv2.Label{Name: "transport", Value: json.RawMessage(`false`)}))).To(BeFalse())
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newLabelledResource(
+				cdv2.Label{Name: "transport", Value: json.RawMessage(`"true"`)}))).To(BeFalse())
+		})
+
+		It("should compare object values independent of their formatting", func() {
+			spec := json.RawMessage(`{"name": "owner", "value": {"team": "infra", "id": 1}}`)
+			f, err := factory.Create(filter.ResourceLabelFilterType, &spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newLabelledResource(
+				cdv2.Label{Name: "owner", Value: json.RawMessage(`{"id":1,"team":"infra"}`)}))).To(BeTrue())
+		})
+
+		It("should not match resources without the label", func() {
+			spec := json.RawMessage(`{"name": "transport"}`)
+			f, err := factory.Create(filter.ResourceLabelFilterType, &spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newLabelledResource())).To(BeFalse())
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newLabelledResource(
+				cdv2.Label{Name: "other", Value: json.RawMessage(`true`)}))).To(BeFalse())
+		})
+
+		It("should return an error if the name is empty", func() {
+			spec := json.RawMessage(`{"value": true}`)
+			_, err := factory.Create(filter.ResourceLabelFilterType, &spec)
+			Expect(err).To(MatchError("name must not be empty"))
+		})
+
+	})
+
+	Context("ComponentNameFilter", func() {
+
+		It("should return an error for an invalid regular expression", func() {
+			_, err := factory.Create(filter.ComponentNameFilterType, rawSpec(filter.ComponentNameFilterSpec{
+				IncludeComponentNames: []string{"github.com/gardener/("},
+			}))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("error parsing regexp"))
+		})
+
+		It("should create a component name filter from a spec", func() {
+			spec := rawSpec(filter.ComponentNameFilterSpec{
+				IncludeComponentNames: []string{"github.com/gardener/.*"},
+			})
+			Expect(string(*spec)).To(MatchJSON(`{"includeComponentNames": ["github.com/gardener/.*"]}`))
+
+			f, err := factory.Create(filter.ComponentNameFilterType, spec)
+			Expect(err).ToNot(HaveOccurred())
+			cd := cdv2.ComponentDescriptor{}
+			cd.Name = "github.com/gardener/gardener"
+			Expect(f.Matches(cd, newResource(cdv2.OCIImageType))).To(BeTrue())
+			cd.Name = "github.com/other/gardener"
+			Expect(f.Matches(cd, newResource(cdv2.OCIImageType))).To(BeFalse())
+		})
+
+	})
+
+	Context("SourceFilter", func() {
+
+		newSource := func(name, sourceType string) cdv2.Source {
+			return cdv2.Source{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    name,
+					Version: "v0.1.0",
+					Type:    sourceType,
+				},
+			}
+		}
+
+		It("should create a source filter from a spec", func() {
+			spec := rawSpec(filter.SourceFilterSpec{
+				IncludeSourceTypes: []string{"git"},
+			})
+			Expect(string(*spec)).To(MatchJSON(`{"includeSourceTypes": ["git"]}`))
+
+			f, err := factory.Create(filter.SourceFilterType, spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filter.MatchesSource(f, cdv2.ComponentDescriptor{}, newSource("repo", "git"))).To(BeTrue())
+			Expect(filter.MatchesSource(f, cdv2.ComponentDescriptor{}, newSource("repo", "svn"))).To(BeFalse())
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newResource("git"))).To(BeFalse())
+		})
+
+		It("should match sources by name and type", func() {
+			spec := json.RawMessage(`{"includeSourceNames": ["repo"], "includeSourceTypes": ["git"]}`)
+			f, err := factory.Create(filter.SourceFilterType, &spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filter.MatchesSource(f, cdv2.ComponentDescriptor{}, newSource("repo", "git"))).To(BeTrue())
+			Expect(filter.MatchesSource(f, cdv2.ComponentDescriptor{}, newSource("other", "git"))).To(BeFalse())
+			Expect(filter.MatchesSource(f, cdv2.ComponentDescriptor{}, newSource("repo", "svn"))).To(BeFalse())
+		})
+
+		It("should return an error if both include lists are empty", func() {
+			_, err := factory.Create(filter.SourceFilterType, rawSpec(filter.SourceFilterSpec{}))
+			Expect(err).To(MatchError("includeSourceNames or includeSourceTypes must not be empty"))
+		})
+
+		It("should match sources with composite and component level filters", func() {
+			spec := rawSpec(filter.AndFilterSpec{
+				Filters: []filter.FilterDefinition{
+					{Type: filter.ComponentNameFilterType, Spec: rawSpec(filter.ComponentNameFilterSpec{IncludeComponentNames: []string{"github.com/gardener/.*"}})},
+					{Type: filter.NotFilterType, Spec: rawSpec(filter.NotFilterSpec{
+						Filter: filter.FilterDefinition{Type: filter.SourceFilterType, Spec: rawSpec(filter.SourceFilterSpec{IncludeSourceTypes: []string{"svn"}})},
+					})},
+				},
+			})
+			f, err := factory.Create(filter.AndFilterType, spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			cd := cdv2.ComponentDescriptor{}
+			cd.Name = "github.com/gardener/gardener"
+			Expect(filter.MatchesSource(f, cd, newSource("repo", "git"))).To(BeTrue())
+			Expect(filter.MatchesSource(f, cd, newSource("repo", "svn"))).To(BeFalse())
+			cd.Name = "github.com/other/component"
+			Expect(filter.MatchesSource(f, cd, newSource("repo", "git"))).To(BeFalse())
+		})
+
+		It("should not be able to match sources with nested resource level filters", func() {
+			resourceFilter := filter.FilterDefinition{Type: filter.ResourceTypeFilterType, Spec: rawSpec(filter.ResourceTypeFilterSpec{IncludeResourceTypes: []string{"helm"}})}
+			sourceFilter := filter.FilterDefinition{Type: filter.SourceFilterType, Spec: rawSpec(filter.SourceFilterSpec{IncludeSourceTypes: []string{"git"}})}
+
+			f, err := factory.Create(filter.NotFilterType, rawSpec(filter.NotFilterSpec{Filter: resourceFilter}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filter.CanMatchSources(f)).To(BeFalse())
+
+			f, err = factory.Create(filter.OrFilterType, rawSpec(filter.OrFilterSpec{
+				Filters: []filter.FilterDefinition{sourceFilter, {Type: filter.AndFilterType, Spec: rawSpec(filter.AndFilterSpec{Filters: []filter.FilterDefinition{resourceFilter}})}},
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filter.CanMatchSources(f)).To(BeFalse())
+
+			f, err = factory.Create(filter.NotFilterType, rawSpec(filter.NotFilterSpec{Filter: sourceFilter}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filter.CanMatchSources(f)).To(BeTrue())
+		})
+
+	})
+
+	Context("VersionFilter", func() {
+
+		It("should create a version filter from a spec", func() {
+			f, err := factory.Create(filter.VersionFilterType, rawSpec(filter.VersionFilterSpec{Constraint: "~1.2"}))
+			Expect(err).ToNot(HaveOccurred())
+			cd := cdv2.ComponentDescriptor{}
+			cd.Version = "1.2.5"
+			Expect(f.Matches(cd, newResource(cdv2.OCIImageType))).To(BeTrue())
+			cd.Version = "1.3.0"
+			Expect(f.Matches(cd, newResource(cdv2.OCIImageType))).To(BeFalse())
+		})
+
+		It("should return an error for an invalid constraint", func() {
+			_, err := factory.Create(filter.VersionFilterType, rawSpec(filter.VersionFilterSpec{Constraint: "not a constraint"}))
+			Expect(err).To(HaveOccurred())
+		})
+
+	})
+
+	Context("composite filters", func() {
+
+		newComponentDescriptor := func(name string) cdv2.ComponentDescriptor {
+			cd := cdv2.ComponentDescriptor{}
+			cd.Name = name
+			return cd
+		}
+
+		It("should combine nested filters", func() {
+			spec := json.RawMessage(`{
+  "filters": [
+    {
+      "type": "ComponentNameFilter",
+      "spec": {"includeComponentNames": ["github.com/gardener/.*"]}
+    },
+    {
+      "type": "NotFilter",
+      "spec": {
+        "filter": {
+          "type": "ResourceTypeFilter",
+          "spec": {"includeResourceTypes": ["helm"]}
+        }
+      }
+    }
+  ]
+}`)
+			f, err := factory.Create(filter.AndFilterType, &spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			gardener := newComponentDescriptor("github.com/gardener/gardener")
+			other := newComponentDescriptor("github.com/other/component")
+			Expect(f.Matches(gardener, newResource(cdv2.OCIImageType))).To(BeTrue())
+			Expect(f.Matches(gardener, newResource("helm"))).To(BeFalse())
+			Expect(f.Matches(other, newResource(cdv2.OCIImageType))).To(BeFalse())
+			Expect(f.Matches(other, newResource("helm"))).To(BeFalse())
+		})
+
+		It("should match if one of the nested filters of an or filter matches", func() {
+			spec := rawSpec(filter.OrFilterSpec{
+				Filters: []filter.FilterDefinition{
+					{Type: filter.ResourceTypeFilterType, Spec: rawSpec(filter.ResourceTypeFilterSpec{IncludeResourceTypes: []string{"helm"}})},
+					{Type: filter.ResourceTypeFilterType, Spec: rawSpec(filter.ResourceTypeFilterSpec{IncludeResourceTypes: []string{cdv2.OCIImageType}})},
+				},
+			})
+			f, err := factory.Create(filter.OrFilterType, spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newResource("helm"))).To(BeTrue())
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newResource(cdv2.OCIImageType))).To(BeTrue())
+			Expect(f.Matches(cdv2.ComponentDescriptor{}, newResource("blob"))).To(BeFalse())
+		})
+
+		It("should return an error if a nested filter is invalid", func() {
+			spec := rawSpec(filter.AndFilterSpec{
+				Filters: []filter.FilterDefinition{
+					{Type: filter.ResourceTypeFilterType, Spec: rawSpec(filter.ResourceTypeFilterSpec{})},
+				},
+			})
+			_, err := factory.Create(filter.AndFilterType, spec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("includeResourceTypes must not be empty"))
+		})
+
+		It("should return an error if an and filter has no nested filters", func() {
+			_, err := factory.Create(filter.AndFilterType, rawSpec(filter.AndFilterSpec{}))
+			Expect(err).To(MatchError("filters must not be empty"))
+		})
+
+	})
+
+	It("should return an error for an unknown filter type", func() {
+		_, err := factory.Create("UnknownFilter", rawSpec(map[string]interface{}{}))
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -126,6 +126,49 @@ var _ = Describe("filters", func() {
 
 	})
 
+	Context("versionFilter", func() {
+
+		newComponentDescriptor := func(version string) cdv2.ComponentDescriptor {
+			cd := cdv2.ComponentDescriptor{}
+			cd.Name = "github.com/test/my-component"
+			cd.Version = version
+			return cd
+		}
+
+		It("should match if the version is in range", func() {
+			f, err := filter.NewVersionFilter(filter.VersionFilterSpec{Constraint: ">=1.2.0 <2.0.0"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.Matches(newComponentDescriptor("1.2.0"), cdv2.Resource{})).To(Equal(true))
+			Expect(f.Matches(newComponentDescriptor("v1.10.3"), cdv2.Resource{})).To(Equal(true))
+		})
+
+		It("should not match if the version is out of range", func() {
+			f, err := filter.NewVersionFilter(filter.VersionFilterSpec{Constraint: ">=1.2.0 <2.0.0"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.Matches(newComponentDescriptor("1.1.9"), cdv2.Resource{})).To(Equal(false))
+			Expect(f.Matches(newComponentDescriptor("v2.0.0"), cdv2.Resource{})).To(Equal(false))
+		})
+
+		It("should not match if the version is not a semver version", func() {
+			f, err := filter.NewVersionFilter(filter.VersionFilterSpec{Constraint: ">=1.2.0 <2.0.0"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(f.Matches(newComponentDescriptor("latest"), cdv2.Resource{})).To(Equal(false))
+			Expect(f.Matches(newComponentDescriptor(""), cdv2.Resource{})).To(Equal(false))
+		})
+
+		It("should return error upon creation if the constraint is invalid", func() {
+			_, err := filter.NewVersionFilter(filter.VersionFilterSpec{Constraint: ">=foo"})
+			Expect(err).To(HaveOccurred())
+
+			_, err = filter.NewVersionFilter(filter.VersionFilterSpec{})
+			Expect(err).To(MatchError("constraint must not be empty"))
+		})
+
+	})
+
 	Context("componentNameFilter", func() {
 
 		It("should match if component name is in include list", func() {
@@ -151,7 +194,7 @@ var _ = Describe("filters", func() {
 
 			spec = filter.ComponentNameFilterSpec{
 				IncludeComponentNames: []string{
-					"github.com/test/*",
+					"github.com/test/.*",
 				},
 			}
 			f2, err := filter.NewComponentNameFilter(spec)
@@ -194,6 +237,34 @@ var _ = Describe("filters", func() {
 			Expect(match2).To(Equal(false))
 		})
 
+		It("should match the complete component name against regular expressions", func() {
+			spec := filter.ComponentNameFilterSpec{
+				IncludeComponentNames: []string{
+					"github.com/gardener/.*",
+					"example.com/component",
+				},
+			}
+			f, err := filter.NewComponentNameFilter(spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			matches := func(name string) bool {
+				cd := cdv2.ComponentDescriptor{
+					ComponentSpec: cdv2.ComponentSpec{
+						ObjectMeta: cdv2.ObjectMeta{
+							Name: name,
+						},
+					},
+				}
+				return f.Matches(cd, cdv2.Resource{})
+			}
+			Expect(matches("github.com/gardener/gardener")).To(BeTrue())
+			Expect(matches("github.com/gardener/etcd-druid")).To(BeTrue())
+			Expect(matches("example.com/component")).To(BeTrue())
+			Expect(matches("github.com/other/gardener")).To(BeFalse())
+			Expect(matches("mirror.example.com/github.com/gardener/gardener")).To(BeFalse())
+			Expect(matches("example.com/component-2")).To(BeFalse())
+		})
+
 		It("should return error upon creation if include list is empty", func() {
 			spec := filter.ComponentNameFilterSpec{
 				IncludeComponentNames: []string{},
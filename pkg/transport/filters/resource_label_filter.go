@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package filters
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+)
+
+type ResourceLabelFilterSpec struct {
+	// Name is the name of the label a resource must have.
+	Name string `json:"name"`
+	// Value is the optional value the label must have.
+	// Resources only need a label with the given name if no value is defined.
+	Value *json.RawMessage `json:"value,omitempty"`
+}
+
+type resourceLabelFilter struct {
+	name  string
+	value interface{}
+	// matchValue defines whether the value of the label must match
+	matchValue bool
+}
+
+func (f resourceLabelFilter) Matches(cd cdv2.ComponentDescriptor, r cdv2.Resource) bool {
+	for _, label := range r.Labels {
+		if label.Name != f.name {
+			continue
+		}
+		if !f.matchValue {
+			return true
+		}
+		var value interface{}
+		if err := json.Unmarshal(label.Value, &value); err != nil {
+			continue
+		}
+		if reflect.DeepEqual(value, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewResourceLabelFilter creates a new resourceLabelFilter
+func NewResourceLabelFilter(spec ResourceLabelFilterSpec) (Filter, error) {
+	if len(spec.Name) == 0 {
+		return nil, fmt.Errorf("name must not be empty")
+	}
+
+	filter := resourceLabelFilter{
+		name: spec.Name,
+	}
+	if spec.Value != nil {
+		if err := json.Unmarshal(*spec.Value, &filter.value); err != nil {
+			return nil, fmt.Errorf("unable to parse value: %w", err)
+		}
+		filter.matchValue = true
+	}
+
+	return &filter, nil
+}
@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package filters
+
+import (
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+)
+
+type SourceFilterSpec struct {
+	IncludeSourceNames []string `json:"includeSourceNames,omitempty"`
+	IncludeSourceTypes []string `json:"includeSourceTypes,omitempty"`
+}
+
+type sourceFilter struct {
+	includeSourceNames map[string]bool
+	includeSourceTypes map[string]bool
+}
+
+// Matches never matches resources as the filter only applies to sources.
+func (f sourceFilter) Matches(cd cdv2.ComponentDescriptor, r cdv2.Resource) bool {
+	return false
+}
+
+func (f sourceFilter) MatchesSource(cd cdv2.ComponentDescriptor, s cdv2.Source) bool {
+	if len(f.includeSourceNames) != 0 && !f.includeSourceNames[s.Name] {
+		return false
+	}
+	if len(f.includeSourceTypes) != 0 && !f.includeSourceTypes[s.Type] {
+		return false
+	}
+	return true
+}
+
+// NewSourceFilter creates a new sourceFilter that matches sources by name and type.
+// If both include lists are defined, a source has to match both.
+func NewSourceFilter(spec SourceFilterSpec) (Filter, error) {
+	if len(spec.IncludeSourceNames) == 0 && len(spec.IncludeSourceTypes) == 0 {
+		return nil, fmt.Errorf("includeSourceNames or includeSourceTypes must not be empty")
+	}
+
+	filter := sourceFilter{
+		includeSourceNames: map[string]bool{},
+		includeSourceTypes: map[string]bool{},
+	}
+
+	for _, sourceName := range spec.IncludeSourceNames {
+		filter.includeSourceNames[sourceName] = true
+	}
+	for _, sourceType := range spec.IncludeSourceTypes {
+		filter.includeSourceTypes[sourceType] = true
+	}
+
+	return &filter, nil
+}
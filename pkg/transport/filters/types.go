@@ -12,3 +12,19 @@ type Filter interface {
 	// Matches matches a component descriptor and a resource against the filter
 	Matches(cdv2.ComponentDescriptor, cdv2.Resource) bool
 }
+
+// SourceMatcher is implemented by filters that are able to match component sources.
+type SourceMatcher interface {
+	// MatchesSource matches a component descriptor and a source against the filter
+	MatchesSource(cdv2.ComponentDescriptor, cdv2.Source) bool
+}
+
+// MatchesSource matches a component descriptor and a source against the filter.
+// Filters that do not implement SourceMatcher never match sources.
+func MatchesSource(f Filter, cd cdv2.ComponentDescriptor, s cdv2.Source) bool {
+	sm, ok := f.(SourceMatcher)
+	if !ok {
+		return false
+	}
+	return sm.MatchesSource(cd, s)
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package filters
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+)
+
+type VersionFilterSpec struct {
+	// Constraint is a semver constraint, e.g. ">=1.2.0 <2.0.0".
+	Constraint string `json:"constraint"`
+}
+
+type versionFilter struct {
+	constraint *semver.Constraints
+}
+
+func (f versionFilter) Matches(cd cdv2.ComponentDescriptor, r cdv2.Resource) bool {
+	version, err := semver.NewVersion(cd.Version)
+	if err != nil {
+		return false
+	}
+	return f.constraint.Check(version)
+}
+
+func (f versionFilter) MatchesSource(cd cdv2.ComponentDescriptor, s cdv2.Source) bool {
+	return f.Matches(cd, cdv2.Resource{})
+}
+
+// NewVersionFilter creates a new versionFilter that matches components whose version satisfies the semver constraint.
+// Components with a version that is not a valid semver version never match.
+func NewVersionFilter(spec VersionFilterSpec) (Filter, error) {
+	if len(spec.Constraint) == 0 {
+		return nil, fmt.Errorf("constraint must not be empty")
+	}
+
+	constraint, err := semver.NewConstraint(spec.Constraint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse constraint %s: %w", spec.Constraint, err)
+	}
+
+	filter := versionFilter{
+		constraint: constraint,
+	}
+
+	return &filter, nil
+}
@@ -26,9 +26,11 @@ const (
 // - Add Go file to downloader package which contains the source code of the new downloader
 // - Add string constant for new downloader type -> will be used in DownloaderFactory.Create()
 // - Add source code for creating new downloader to DownloaderFactory.Create() method
-func NewDownloaderFactory(client ociclient.Client, ocicache cache.Cache) *DownloaderFactory {
+//
+// The registry operations of the created downloaders are retried with the given retry options.
+func NewDownloaderFactory(client ociclient.Client, ocicache cache.Cache, retry process.RetryOptions) *DownloaderFactory {
 	return &DownloaderFactory{
-		client: client,
+		client: process.NewRetryingClient(client, retry),
 		cache:  ocicache,
 	}
 }
@@ -110,6 +110,73 @@ var _ = Describe("transport extensions", func() {
 		})
 	})
 
+	Context("executable processor", func() {
+		It("should pass the processor message through the executable", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+			resourceData := strings.Repeat("12345", 100000)
+
+			inputBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, strings.NewReader(resourceData), inputBuf)).To(Succeed())
+
+			outputBuf := bytes.NewBuffer([]byte{})
+			processor := extensions.NewExecutableProcessor("cat")
+			Expect(processor.Process(context.TODO(), inputBuf, outputBuf)).To(Succeed())
+
+			processedCD, processedRes, processedBlobReader, err := utils.ReadProcessorMessage(outputBuf)
+			Expect(err).ToNot(HaveOccurred())
+			defer processedBlobReader.Close()
+			Expect(*processedCD).To(Equal(cd))
+			Expect(processedRes).To(Equal(res))
+			processedResourceData, err := io.ReadAll(processedBlobReader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(processedResourceData)).To(Equal(resourceData))
+		})
+
+		It("should return an error if the executable exits with a non-zero exit code", func() {
+			processor := extensions.NewExecutableProcessor("sh", "-c", "cat > /dev/null; exit 3")
+			err := processor.Process(context.TODO(), strings.NewReader("message"), bytes.NewBuffer([]byte{}))
+			Expect(err).To(MatchError("unable to wait for processor: exit status 3"))
+		})
+
+		It("should succeed if the executable exits successfully without reading its input", func() {
+			// the input is larger than the pipe buffer, so that writing it fails after the executable exited.
+			input := strings.NewReader(strings.Repeat("12345", 1000000))
+			outputBuf := bytes.NewBuffer([]byte{})
+			processor := extensions.NewExecutableProcessor("sh", "-c", "printf output")
+			Expect(processor.Process(context.TODO(), input, outputBuf)).To(Succeed())
+			Expect(outputBuf.String()).To(Equal("output"))
+		})
+
+		It("should return an error if the executable exits with a non-zero exit code without reading its input", func() {
+			input := strings.NewReader(strings.Repeat("12345", 1000000))
+			processor := extensions.NewExecutableProcessor("sh", "-c", "exit 3")
+			err := processor.Process(context.TODO(), input, bytes.NewBuffer([]byte{}))
+			Expect(err).To(MatchError("unable to wait for processor: exit status 3"))
+		})
+
+		It("should stop the executable when the context is cancelled", func() {
+			ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+			defer cancel()
+
+			processor := extensions.NewExecutableProcessor("sleep", "5")
+			err := processor.Process(ctx, bytes.NewBuffer([]byte{}), bytes.NewBuffer([]byte{}))
+			Expect(err).To(MatchError("unable to wait for processor: signal: killed"))
+		})
+	})
+
 })
 
 func runTimeoutTest(processor process.ResourceStreamProcessor) {
@@ -5,10 +5,12 @@ package extensions
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"syscall"
 
 	"github.com/gardener/component-cli/pkg/transport/process"
 )
@@ -36,6 +38,16 @@ func NewStdIOExecutable(bin string, args []string, env map[string]string) (proce
 	return &e, nil
 }
 
+// NewExecutableProcessor returns a resource processor which runs an executable with the environment of the
+// current process when calling Process(). The processor message is piped into stdin of the executable and the
+// processed message is read from its stdout. A non-zero exit code of the executable is returned as error.
+func NewExecutableProcessor(bin string, args ...string) process.ResourceStreamProcessor {
+	return &stdIOExecutable{
+		bin:  bin,
+		args: args,
+	}
+}
+
 func (e *stdIOExecutable) Process(ctx context.Context, r io.Reader, w io.Writer) error {
 	cmd := exec.CommandContext(ctx, e.bin, e.args...)
 	cmd.Env = e.env
@@ -53,15 +65,24 @@ func (e *stdIOExecutable) Process(ctx context.Context, r io.Reader, w io.Writer)
 		return fmt.Errorf("unable to start processor: %w", err)
 	}
 
-	if _, err := io.Copy(stdin, r); err != nil {
-		return fmt.Errorf("unable to write input: %w", err)
-	}
-
-	if err := stdin.Close(); err != nil {
-		return fmt.Errorf("unable to close input writer: %w", err)
-	}
+	// the input is written concurrently as the executable may already write output
+	// before it consumed the whole input, which would block on full pipes otherwise.
+	inputErr := make(chan error, 1)
+	go func() {
+		if _, err := io.Copy(stdin, r); err != nil {
+			inputErr <- fmt.Errorf("unable to write input: %w", err)
+			return
+		}
+		if err := stdin.Close(); err != nil {
+			inputErr <- fmt.Errorf("unable to close input writer: %w", err)
+			return
+		}
+		inputErr <- nil
+	}()
 
 	if _, err := io.Copy(w, stdout); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
 		return fmt.Errorf("unable to read output: %w", err)
 	}
 
@@ -69,5 +90,10 @@ func (e *stdIOExecutable) Process(ctx context.Context, r io.Reader, w io.Writer)
 		return fmt.Errorf("unable to wait for processor: %w", err)
 	}
 
+	// an executable that exited successfully may not read its complete input,
+	// so that the input cannot be written to the closed pipe anymore.
+	if err := <-inputErr; err != nil && !errors.Is(err, syscall.EPIPE) && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
 	return nil
 }
@@ -4,6 +4,7 @@
 package process
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"os"
@@ -13,14 +14,28 @@ import (
 	"io/ioutil"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
 
 	"github.com/gardener/component-cli/pkg/transport/process/utils"
 )
 
 const processorTimeout = 30 * time.Second
 
+// PipelineOptions defines the options of a resource processing pipeline.
+type PipelineOptions struct {
+	// ValidateAfterEachProcessor validates the component descriptor after every processor
+	// and reports the first processor that produces an invalid component descriptor.
+	// The resource of the processor output is merged into the component descriptor before validation.
+	// This is meant for debugging pipelines as it requires to read the output of every processor.
+	ValidateAfterEachProcessor bool
+	// Retry defines how failed processors are retried.
+	// By default every processor runs once with a timeout of 30 seconds.
+	Retry RetryOptions
+}
+
 type resourceProcessingPipelineImpl struct {
 	processors []ResourceStreamProcessor
+	opts       PipelineOptions
 }
 
 func (p *resourceProcessingPipelineImpl) Process(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*cdv2.ComponentDescriptor, cdv2.Resource, error) {
@@ -33,13 +48,26 @@ func (p *resourceProcessingPipelineImpl) Process(ctx context.Context, cd cdv2.Co
 		return nil, cdv2.Resource{}, fmt.Errorf("unable to write: %w", err)
 	}
 
-	for _, proc := range p.processors {
+	if p.opts.ValidateAfterEachProcessor {
+		if err := validateMergedComponentDescriptor(cd, res, res); err != nil {
+			return nil, cdv2.Resource{}, fmt.Errorf("input component descriptor is invalid: %w", err)
+		}
+	}
+
+	for i, proc := range p.processors {
 		outfile, err := p.runProcessor(ctx, infile, proc)
 		if err != nil {
 			return nil, cdv2.Resource{}, err
 		}
 
 		infile = outfile
+
+		if p.opts.ValidateAfterEachProcessor {
+			if err := validateProcessorOutput(infile, res); err != nil {
+				infile.Close()
+				return nil, cdv2.Resource{}, fmt.Errorf("processor %d (%T) produced an invalid component descriptor: %w", i, proc, err)
+			}
+		}
 	}
 	defer infile.Close()
 
@@ -61,32 +89,81 @@ func (p *resourceProcessingPipelineImpl) Process(ctx context.Context, cd cdv2.Co
 func (p *resourceProcessingPipelineImpl) runProcessor(ctx context.Context, infile *os.File, proc ResourceStreamProcessor) (*os.File, error) {
 	defer infile.Close()
 
-	if _, err := infile.Seek(0, io.SeekStart); err != nil {
-		return nil, fmt.Errorf("unable to seek to beginning of input file: %w", err)
-	}
-
 	outfile, err := ioutil.TempFile("", "")
 	if err != nil {
 		return nil, fmt.Errorf("unable to create temporary outfile: %w", err)
 	}
 
-	inreader := infile
-	outwriter := outfile
-
-	ctx, cancelfunc := context.WithTimeout(ctx, processorTimeout)
-	defer cancelfunc()
+	retryOpts := p.opts.Retry
+	if retryOpts.Timeout == 0 {
+		retryOpts.Timeout = processorTimeout
+	}
 
-	if err := proc.Process(ctx, inreader, outwriter); err != nil {
+	err = Retry(ctx, retryOpts, func(ctx context.Context) error {
+		// every attempt reads the complete input and writes a new output.
+		if _, err := infile.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("unable to seek to beginning of input file: %w", err)
+		}
+		if _, err := outfile.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("unable to seek to beginning of output file: %w", err)
+		}
+		if err := outfile.Truncate(0); err != nil {
+			return fmt.Errorf("unable to truncate output file: %w", err)
+		}
+		return proc.Process(ctx, infile, outfile)
+	})
+	if err != nil {
+		outfile.Close()
 		return nil, fmt.Errorf("unable to process resource: %w", err)
 	}
 
 	return outfile, nil
 }
 
+// validateProcessorOutput validates the component descriptor and resource that are written by a processor.
+// origRes is the resource that was passed to the pipeline and is used to find the resource in the component descriptor.
+func validateProcessorOutput(outfile *os.File, origRes cdv2.Resource) error {
+	if _, err := outfile.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to beginning of output file: %w", err)
+	}
+	cd, res, blobreader, err := utils.ReadProcessorMessage(outfile)
+	if err != nil {
+		return fmt.Errorf("unable to read output data: %w", err)
+	}
+	if blobreader != nil {
+		defer blobreader.Close()
+	}
+	return validateMergedComponentDescriptor(*cd, origRes, res)
+}
+
+// validateMergedComponentDescriptor replaces origRes in the component descriptor with res and validates the result.
+func validateMergedComponentDescriptor(cd cdv2.ComponentDescriptor, origRes, res cdv2.Resource) error {
+	resources := make([]cdv2.Resource, 0, len(cd.Resources)+1)
+	found := false
+	for _, r := range cd.Resources {
+		if bytes.Equal(r.GetIdentityDigest(), origRes.GetIdentityDigest()) {
+			r = res
+			found = true
+		}
+		resources = append(resources, r)
+	}
+	if !found {
+		resources = append(resources, res)
+	}
+	cd.Resources = resources
+	return cdvalidation.Validate(&cd)
+}
+
 // NewResourceProcessingPipeline returns a new ResourceProcessingPipeline
 func NewResourceProcessingPipeline(processors ...ResourceStreamProcessor) ResourceProcessingPipeline {
+	return NewResourceProcessingPipelineWithOptions(PipelineOptions{}, processors...)
+}
+
+// NewResourceProcessingPipelineWithOptions returns a new ResourceProcessingPipeline that is configured with the given options
+func NewResourceProcessingPipelineWithOptions(opts PipelineOptions, processors ...ResourceStreamProcessor) ResourceProcessingPipeline {
 	p := resourceProcessingPipelineImpl{
 		processors: processors,
+		opts:       opts,
 	}
 	return &p
 }
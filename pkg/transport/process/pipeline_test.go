@@ -6,6 +6,9 @@ package process_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"time"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	. "github.com/onsi/ginkgo"
@@ -59,5 +62,135 @@ var _ = Describe("pipeline", func() {
 			Expect(actualRes).To(Equal(expectedRes))
 		})
 
+		It("should retry a flaky processor with a clean input and output", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{res},
+				},
+			}
+			l1 := cdv2.Label{
+				Name:  "processor-0",
+				Value: json.RawMessage(`"true"`),
+			}
+			flaky := &flakyProcessor{failures: 2, processor: processors.NewResourceLabeler(l1)}
+
+			pipeline := process.NewResourceProcessingPipeline(flaky)
+			_, _, err := pipeline.Process(context.TODO(), cd, res)
+			Expect(err).To(HaveOccurred())
+			Expect(flaky.calls).To(Equal(1))
+
+			flaky.calls = 0
+			pipeline = process.NewResourceProcessingPipelineWithOptions(
+				process.PipelineOptions{Retry: process.RetryOptions{Retries: 2, Backoff: time.Millisecond}},
+				flaky,
+			)
+			_, actualRes, err := pipeline.Process(context.TODO(), cd, res)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(flaky.calls).To(Equal(3))
+			Expect(actualRes.Labels).To(ConsistOf(l1))
+		})
+
+		Context("with validation after each processor", func() {
+
+			var (
+				res cdv2.Resource
+				cd  cdv2.ComponentDescriptor
+			)
+
+			BeforeEach(func() {
+				acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("example.com/my-image:1.0.0"))
+				Expect(err).ToNot(HaveOccurred())
+				res = cdv2.Resource{
+					IdentityObjectMeta: cdv2.IdentityObjectMeta{
+						Name:    "my-res",
+						Version: "v0.1.0",
+						Type:    "ociImage",
+					},
+					Relation: cdv2.ExternalRelation,
+					Access:   &acc,
+				}
+				cd = cdv2.ComponentDescriptor{
+					Metadata: cdv2.Metadata{
+						Version: cdv2.SchemaVersion,
+					},
+					ComponentSpec: cdv2.ComponentSpec{
+						ObjectMeta: cdv2.ObjectMeta{
+							Name:    "example.com/my-component",
+							Version: "v0.1.0",
+						},
+						Provider:            cdv2.InternalProvider,
+						RepositoryContexts:  []*cdv2.UnstructuredTypedObject{},
+						Sources:             []cdv2.Source{},
+						ComponentReferences: []cdv2.ComponentReference{},
+						Resources: []cdv2.Resource{
+							res,
+						},
+					},
+				}
+			})
+
+			It("should process a resource if all processors produce valid component descriptors", func() {
+				l1 := cdv2.Label{
+					Name:  "processor-0",
+					Value: json.RawMessage(`"true"`),
+				}
+				pipeline := process.NewResourceProcessingPipelineWithOptions(
+					process.PipelineOptions{ValidateAfterEachProcessor: true},
+					processors.NewResourceLabeler(l1),
+				)
+
+				_, actualRes, err := pipeline.Process(context.TODO(), cd, res)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualRes.Labels).To(ConsistOf(l1))
+			})
+
+			It("should report the first processor that produces an invalid component descriptor", func() {
+				l1 := cdv2.Label{
+					Name:  "processor-0",
+					Value: json.RawMessage(`"true"`),
+				}
+				pipeline := process.NewResourceProcessingPipelineWithOptions(
+					process.PipelineOptions{ValidateAfterEachProcessor: true},
+					processors.NewResourceLabeler(l1),
+					processors.NewResourceLabeler(l1),
+					processors.NewResourceLabeler(l1),
+				)
+
+				_, _, err := pipeline.Process(context.TODO(), cd, res)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("processor 1"))
+				Expect(err.Error()).To(ContainSubstring("labels"))
+			})
+
+		})
+
 	})
 })
+
+// flakyProcessor consumes its input and writes garbage for the first failures calls before it fails.
+type flakyProcessor struct {
+	failures  int
+	calls     int
+	processor process.ResourceStreamProcessor
+}
+
+func (p *flakyProcessor) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	p.calls++
+	if p.calls <= p.failures {
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte("partial output")); err != nil {
+			return err
+		}
+		return errors.New("temporary failure")
+	}
+	return p.processor.Process(ctx, r, w)
+}
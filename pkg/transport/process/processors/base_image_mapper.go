@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type baseImageMapper struct {
+	mapping map[string]string
+}
+
+// NewBaseImageMapProcessor returns a processor that rewrites the repository of image references
+// of resources with an ociRegistry access according to the given mapping of source to target repository.
+// Tags and digests of the references are preserved. References of repositories that are not
+// part of the mapping are unchanged. The resource blob is forwarded unchanged.
+func NewBaseImageMapProcessor(mapping map[string]string) process.ResourceStreamProcessor {
+	obj := baseImageMapper{
+		mapping: mapping,
+	}
+	return &obj
+}
+
+func (p *baseImageMapper) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if res.Access != nil && res.Access.GetType() == cdv2.OCIRegistryType {
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		if err := res.Access.DecodeInto(ociAccess); err != nil {
+			return fmt.Errorf("unable to decode resource access: %w", err)
+		}
+
+		repository, suffix := splitRepository(ociAccess.ImageReference)
+		if target, ok := p.mapping[repository]; ok {
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(target + suffix))
+			if err != nil {
+				return fmt.Errorf("unable to create resource access object: %w", err)
+			}
+			res.Access = &acc
+		}
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// splitRepository splits an image reference into its repository and the tag and/or digest suffix
+// including the separator, e.g. "example.com/img:1.0.0" into "example.com/img" and ":1.0.0".
+func splitRepository(ref string) (string, string) {
+	repository, suffix := ref, ""
+	if i := strings.Index(repository, "@"); i != -1 {
+		repository, suffix = repository[:i], repository[i:]
+	}
+	// a colon after the last slash separates the tag, other colons belong to the registry port.
+	if i := strings.LastIndex(repository, ":"); i != -1 && i > strings.LastIndex(repository, "/") {
+		repository, suffix = repository[:i], repository[i:]+suffix
+	}
+	return repository, suffix
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("baseImageMapper", func() {
+
+	mapping := map[string]string{
+		"docker.io/library/alpine":   "registry.example.com/mirror/alpine",
+		"localhost:5000/base/distro": "registry.example.com/base/distro",
+	}
+
+	DescribeTable("should rewrite the repository of oci references",
+		func(ref, expectedRef string) {
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(ref))
+			Expect(err).ToNot(HaveOccurred())
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    cdv2.OCIImageType,
+				},
+				Access: &acc,
+			}
+			resBytes := []byte("resource-blob")
+
+			inBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+			outBuf := bytes.NewBuffer([]byte{})
+			p := processors.NewBaseImageMapProcessor(mapping)
+			Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+			_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+			Expect(err).ToNot(HaveOccurred())
+			defer actualResBlobReader.Close()
+			actualResBlobBuf := bytes.NewBuffer([]byte{})
+			_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
+
+			ociAccess := &cdv2.OCIRegistryAccess{}
+			Expect(actualRes.Access.DecodeInto(ociAccess)).To(Succeed())
+			Expect(ociAccess.ImageReference).To(Equal(expectedRef))
+		},
+		Entry("tag", "docker.io/library/alpine:3.15", "registry.example.com/mirror/alpine:3.15"),
+		Entry("digest",
+			"docker.io/library/alpine@sha256:7cc4b5aefd1d0cadf8d97d4350462ba51c694ebca145b08d7d41b41acc8db5aa",
+			"registry.example.com/mirror/alpine@sha256:7cc4b5aefd1d0cadf8d97d4350462ba51c694ebca145b08d7d41b41acc8db5aa"),
+		Entry("tag and digest",
+			"docker.io/library/alpine:3.15@sha256:7cc4b5aefd1d0cadf8d97d4350462ba51c694ebca145b08d7d41b41acc8db5aa",
+			"registry.example.com/mirror/alpine:3.15@sha256:7cc4b5aefd1d0cadf8d97d4350462ba51c694ebca145b08d7d41b41acc8db5aa"),
+		Entry("registry with port", "localhost:5000/base/distro:1.0.0", "registry.example.com/base/distro:1.0.0"),
+		Entry("unmapped repository", "docker.io/library/nginx:1.21", "docker.io/library/nginx:1.21"),
+		Entry("repository prefix is not mapped", "docker.io/library/alpine-extra:1.0.0", "docker.io/library/alpine-extra:1.0.0"),
+	)
+
+})
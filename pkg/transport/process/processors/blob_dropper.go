@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+// ExternalAccessResolver returns the external access of a resource whose blob is stored locally.
+// It returns nil if no external location is known for the resource.
+type ExternalAccessResolver func(cd cdv2.ComponentDescriptor, res cdv2.Resource) (cdv2.TypedObjectAccessor, error)
+
+type blobDropper struct {
+	resolver ExternalAccessResolver
+}
+
+// NewBlobDropProcessor returns a processor that drops the blob of resources with a local blob access
+// (localOciBlob or localFilesystemBlob) and rewrites their access to the external access returned by the resolver.
+// The processor fails if the resolver does not know an external location for a resource.
+// Resources with other access types are forwarded unchanged.
+func NewBlobDropProcessor(resolver ExternalAccessResolver) (process.ResourceStreamProcessor, error) {
+	if resolver == nil {
+		return nil, errors.New("resolver must not be nil")
+	}
+	obj := blobDropper{
+		resolver: resolver,
+	}
+	return &obj, nil
+}
+
+func (p *blobDropper) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	var blobReader io.Reader = resBlobReader
+	if res.Access != nil && isLocalBlobAccess(res.Access.GetType()) {
+		access, err := p.resolver(*cd, res)
+		if err != nil {
+			return fmt.Errorf("unable to resolve external access of resource %q: %w", res.Name, err)
+		}
+		if access == nil {
+			return fmt.Errorf("no external location known for resource %q", res.Name)
+		}
+		unstructuredAccess, err := cdv2.NewUnstructured(access)
+		if err != nil {
+			return fmt.Errorf("unable to create unstructured access: %w", err)
+		}
+		res.Access = &unstructuredAccess
+		blobReader = nil
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, blobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+func isLocalBlobAccess(accessType string) bool {
+	return accessType == cdv2.LocalOCIBlobType || accessType == cdv2.LocalFilesystemBlobType
+}
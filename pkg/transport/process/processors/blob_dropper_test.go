@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("blobDropper", func() {
+
+	newResource := func(access cdv2.TypedObjectAccessor) cdv2.Resource {
+		acc, err := cdv2.NewUnstructured(access)
+		Expect(err).ToNot(HaveOccurred())
+		return cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    cdv2.OCIImageType,
+			},
+			Access: &acc,
+		}
+	}
+
+	process := func(resolver processors.ExternalAccessResolver, res cdv2.Resource) (cdv2.Resource, io.ReadCloser, error) {
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, bytes.NewReader([]byte("resource-blob")), inBuf)).To(Succeed())
+
+		p, err := processors.NewBlobDropProcessor(resolver)
+		Expect(err).ToNot(HaveOccurred())
+		outBuf := bytes.NewBuffer([]byte{})
+		if err := p.Process(context.TODO(), inBuf, outBuf); err != nil {
+			return cdv2.Resource{}, nil, err
+		}
+
+		_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		if actualResBlobReader == nil {
+			return actualRes, nil, nil
+		}
+		return actualRes, actualResBlobReader, nil
+	}
+
+	It("should drop the blob of a local resource and rewrite its access", func() {
+		externalAccess := cdv2.NewOCIRegistryAccess("example.com/my-image:1.0.0")
+		resolver := func(_ cdv2.ComponentDescriptor, res cdv2.Resource) (cdv2.TypedObjectAccessor, error) {
+			Expect(res.Name).To(Equal("my-res"))
+			return externalAccess, nil
+		}
+
+		actualRes, blobReader, err := process(resolver, newResource(cdv2.NewLocalOCIBlobAccess("sha256:abc")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobReader).To(BeNil())
+
+		expectedAccess, err := cdv2.NewUnstructured(externalAccess)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualRes.Access.Raw).To(MatchJSON(expectedAccess.Raw))
+	})
+
+	It("should fail if no external location is known", func() {
+		resolver := func(_ cdv2.ComponentDescriptor, _ cdv2.Resource) (cdv2.TypedObjectAccessor, error) {
+			return nil, nil
+		}
+		_, _, err := process(resolver, newResource(cdv2.NewLocalFilesystemBlobAccess("blob", "text/plain")))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("no external location"))
+	})
+
+	It("should fail if the resolver fails", func() {
+		resolver := func(_ cdv2.ComponentDescriptor, _ cdv2.Resource) (cdv2.TypedObjectAccessor, error) {
+			return nil, errors.New("resolver error")
+		}
+		_, _, err := process(resolver, newResource(cdv2.NewLocalOCIBlobAccess("sha256:abc")))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should forward resources without a local blob access unchanged", func() {
+		resolver := func(_ cdv2.ComponentDescriptor, _ cdv2.Resource) (cdv2.TypedObjectAccessor, error) {
+			Fail("resolver must not be called")
+			return nil, nil
+		}
+		res := newResource(cdv2.NewOCIRegistryAccess("example.com/my-image:1.0.0"))
+		actualRes, blobReader, err := process(resolver, res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualRes.Access.Raw).To(MatchJSON(res.Access.Raw))
+		Expect(blobReader).ToNot(BeNil())
+		defer blobReader.Close()
+		blob, err := io.ReadAll(blobReader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blob).To(Equal([]byte("resource-blob")))
+	})
+
+	It("should fail to create the processor without a resolver", func() {
+		_, err := processors.NewBlobDropProcessor(nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/oci"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+// CosignSignatureAnnotation is the layer annotation that contains the base64 encoded cosign signature.
+const CosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// cosignPayload is the "simple signing" payload that is signed by cosign.
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+type cosignVerifier struct {
+	log       logr.Logger
+	client    ociclient.Client
+	publicKey crypto.PublicKey
+	warnOnly  bool
+}
+
+// NewCosignVerifyProcessor returns a processor that verifies the cosign signature of oci images
+// that are referenced by resources with an ociRegistry access.
+// publicKeyPath is the path to the PEM encoded public key that the images are signed with.
+// Keyless verification with Fulcio certificates and Rekor transparency log entries is not supported.
+// If warnOnly is set, a missing or invalid signature is only logged instead of failing the processor.
+// The resource blob is forwarded unchanged.
+func NewCosignVerifyProcessor(log logr.Logger, client ociclient.Client, publicKeyPath string, warnOnly bool) (process.ResourceStreamProcessor, error) {
+	if client == nil {
+		return nil, errors.New("client must not be nil")
+	}
+	if len(publicKeyPath) == 0 {
+		return nil, errors.New("a public key must be provided")
+	}
+
+	data, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read public key: %w", err)
+	}
+	publicKey, err := parseCosignPublicKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := cosignVerifier{
+		log:       log,
+		client:    client,
+		publicKey: publicKey,
+		warnOnly:  warnOnly,
+	}
+	return &obj, nil
+}
+
+func (p *cosignVerifier) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if res.Access != nil && res.Access.GetType() == cdv2.OCIRegistryType {
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		if err := res.Access.DecodeInto(ociAccess); err != nil {
+			return fmt.Errorf("unable to decode resource access: %w", err)
+		}
+
+		if err := p.verify(ctx, ociAccess.ImageReference); err != nil {
+			if !p.warnOnly {
+				return fmt.Errorf("unable to verify cosign signature of %q: %w", ociAccess.ImageReference, err)
+			}
+			p.log.Info("unable to verify cosign signature", "ref", ociAccess.ImageReference, "error", err.Error())
+		}
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// verify checks that at least one cosign signature of the image is valid for the configured key.
+func (p *cosignVerifier) verify(ctx context.Context, ref string) error {
+	_, desc, err := p.client.Resolve(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("unable to resolve image: %w", err)
+	}
+
+	refSpec, err := oci.ParseRef(ref)
+	if err != nil {
+		return fmt.Errorf("unable to parse image reference: %w", err)
+	}
+	sigRef := fmt.Sprintf("%s:%s.sig", refSpec.Name(), strings.Replace(desc.Digest.String(), ":", "-", 1))
+
+	manifest, err := p.client.GetManifest(ctx, sigRef)
+	if err != nil {
+		return fmt.Errorf("unable to get signature manifest %q: %w", sigRef, err)
+	}
+
+	for _, layer := range manifest.Layers {
+		encodedSig, ok := layer.Annotations[CosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(encodedSig)
+		if err != nil {
+			continue
+		}
+
+		var payload bytes.Buffer
+		if err := p.client.Fetch(ctx, sigRef, layer, &payload); err != nil {
+			return fmt.Errorf("unable to fetch signature payload: %w", err)
+		}
+		if err := verifyCosignSignature(p.publicKey, payload.Bytes(), sig); err != nil {
+			continue
+		}
+
+		var parsedPayload cosignPayload
+		if err := json.Unmarshal(payload.Bytes(), &parsedPayload); err != nil {
+			continue
+		}
+		if parsedPayload.Critical.Image.DockerManifestDigest == desc.Digest.String() {
+			return nil
+		}
+	}
+
+	return errors.New("no valid signature found")
+}
+
+func parseCosignPublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("unable to decode public key: no PEM block found")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse public key: %w", err)
+	}
+	switch publicKey.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return publicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+func verifyCosignSignature(publicKey crypto.PublicKey, payload, sig []byte) error {
+	digest := sha256.Sum256(payload)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return errors.New("invalid ecdsa signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, sig) {
+			return errors.New("invalid ed25519 signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	mock_ociclient "github.com/gardener/component-cli/ociclient/mock"
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("cosignVerifier", func() {
+
+	const (
+		imageRef = "example.com/my-image:1.0.0"
+		sigRef   = "example.com/my-image:sha256-%s.sig"
+	)
+
+	var (
+		mockCtrl      *gomock.Controller
+		mockOCIClient *mock_ociclient.MockClient
+		key           *ecdsa.PrivateKey
+		keyDir        string
+		keyPath       string
+		imageDigest   digest.Digest
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockOCIClient = mock_ociclient.NewMockClient(mockCtrl)
+
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		pubKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		keyDir, err = os.MkdirTemp("", "cosign-")
+		Expect(err).ToNot(HaveOccurred())
+		keyPath = filepath.Join(keyDir, "cosign.pub")
+		Expect(os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubKey}), 0600)).To(Succeed())
+
+		imageDigest = digest.FromString("my-image-manifest")
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		Expect(os.RemoveAll(keyDir)).To(Succeed())
+	})
+
+	newOCIResource := func() cdv2.Resource {
+		acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(imageRef))
+		Expect(err).ToNot(HaveOccurred())
+		return cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    cdv2.OCIImageType,
+			},
+			Access: &acc,
+		}
+	}
+
+	expectSignature := func(signingKey *ecdsa.PrivateKey) {
+		payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"example.com/my-image"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"}}`, imageDigest))
+		hash := sha256.Sum256(payload)
+		sig, err := ecdsa.SignASN1(rand.Reader, signingKey, hash[:])
+		Expect(err).ToNot(HaveOccurred())
+
+		layer := ocispecv1.Descriptor{
+			MediaType: "application/vnd.dev.cosign.simplesigning.v1+json",
+			Digest:    digest.FromBytes(payload),
+			Size:      int64(len(payload)),
+			Annotations: map[string]string{
+				processors.CosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig),
+			},
+		}
+		ref := fmt.Sprintf(sigRef, imageDigest.Encoded())
+		mockOCIClient.EXPECT().Resolve(gomock.Any(), imageRef).Return(imageRef, ocispecv1.Descriptor{Digest: imageDigest}, nil)
+		mockOCIClient.EXPECT().GetManifest(gomock.Any(), ref).Return(&ocispecv1.Manifest{Layers: []ocispecv1.Descriptor{layer}}, nil)
+		mockOCIClient.EXPECT().Fetch(gomock.Any(), ref, layer, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ ocispecv1.Descriptor, w io.Writer) error {
+				_, err := w.Write(payload)
+				return err
+			})
+	}
+
+	process := func(p interface {
+		Process(context.Context, io.Reader, io.Writer) error
+	}, res cdv2.Resource) (cdv2.Resource, []byte, error) {
+		resBytes := []byte("resource-blob")
+		cd := cdv2.ComponentDescriptor{}
+
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+		outBuf := bytes.NewBuffer([]byte{})
+		if err := p.Process(context.TODO(), inBuf, outBuf); err != nil {
+			return cdv2.Resource{}, nil, err
+		}
+
+		_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		defer actualResBlobReader.Close()
+		actualResBlobBuf := bytes.NewBuffer([]byte{})
+		_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+		Expect(err).ToNot(HaveOccurred())
+		return actualRes, actualResBlobBuf.Bytes(), nil
+	}
+
+	It("should forward a resource with a valid signature", func() {
+		expectSignature(key)
+		p, err := processors.NewCosignVerifyProcessor(logr.Discard(), mockOCIClient, keyPath, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		res := newOCIResource()
+		actualRes, actualBlob, err := process(p, res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualRes.Name).To(Equal(res.Name))
+		Expect(actualRes.Access.Raw).To(MatchJSON(res.Access.Raw))
+		Expect(actualBlob).To(Equal([]byte("resource-blob")))
+	})
+
+	It("should fail if the signature was created with another key", func() {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		expectSignature(otherKey)
+		p, err := processors.NewCosignVerifyProcessor(logr.Discard(), mockOCIClient, keyPath, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, _, err = process(p, newOCIResource())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("no valid signature found"))
+	})
+
+	It("should only warn about an invalid signature in warn-only mode", func() {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		expectSignature(otherKey)
+		p, err := processors.NewCosignVerifyProcessor(logr.Discard(), mockOCIClient, keyPath, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		res := newOCIResource()
+		actualRes, _, err := process(p, res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualRes.Access.Raw).To(MatchJSON(res.Access.Raw))
+	})
+
+	It("should skip resources without an oci registry access", func() {
+		p, err := processors.NewCosignVerifyProcessor(logr.Discard(), mockOCIClient, keyPath, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		acc, err := cdv2.NewUnstructured(cdv2.NewLocalOCIBlobAccess("sha256:abc"))
+		Expect(err).ToNot(HaveOccurred())
+		res := newOCIResource()
+		res.Access = &acc
+
+		actualRes, _, err := process(p, res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualRes.Access.Raw).To(MatchJSON(res.Access.Raw))
+	})
+
+	It("should return an error if no public key is provided", func() {
+		_, err := processors.NewCosignVerifyProcessor(logr.Discard(), mockOCIClient, "", false)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
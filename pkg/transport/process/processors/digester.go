@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type digestProcessor struct{}
+
+// NewDigestProcessor returns a processor that sets the sha256 digest of the resource blob
+// as digest of the resource. An existing digest is replaced.
+// Resources without a blob are forwarded unchanged. The resource blob is forwarded unchanged.
+func NewDigestProcessor() process.ResourceStreamProcessor {
+	return &digestProcessor{}
+}
+
+func (p *digestProcessor) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+
+		hasher := sha256.New()
+		if _, err := io.Copy(hasher, resBlobReader); err != nil {
+			return fmt.Errorf("unable to calculate digest of resource blob: %w", err)
+		}
+		if _, err := resBlobReader.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("unable to seek to beginning of resource blob: %w", err)
+		}
+		res.Digest = &cdv2.DigestSpec{
+			HashAlgorithm:          cdv2Sign.SHA256,
+			NormalisationAlgorithm: string(cdv2.GenericBlobDigestV1),
+			Value:                  hex.EncodeToString(hasher.Sum(nil)),
+		}
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
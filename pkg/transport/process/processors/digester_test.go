@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("digestProcessor", func() {
+
+	Context("Process", func() {
+
+		var res cdv2.Resource
+
+		BeforeEach(func() {
+			res = cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "plain-text",
+				},
+			}
+		})
+
+		It("should set the sha256 digest of the resource blob", func() {
+			resBytes := []byte("resource-blob")
+			cd := cdv2.ComponentDescriptor{}
+
+			inBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+			outBuf := bytes.NewBuffer([]byte{})
+			p := processors.NewDigestProcessor()
+			Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+			_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+			Expect(err).ToNot(HaveOccurred())
+			defer actualResBlobReader.Close()
+
+			Expect(actualRes.Digest).To(Equal(&cdv2.DigestSpec{
+				HashAlgorithm:          "sha256",
+				NormalisationAlgorithm: string(cdv2.GenericBlobDigestV1),
+				Value:                  "344318db7c6622a99783ee712ab5eb28fca782b81e74fb66a4d19ce7bafb4198",
+			}))
+
+			actualResBlobBuf := bytes.NewBuffer([]byte{})
+			_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
+		})
+
+		It("should forward a resource without blob unchanged", func() {
+			cd := cdv2.ComponentDescriptor{}
+
+			inBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, nil, inBuf)).To(Succeed())
+
+			outBuf := bytes.NewBuffer([]byte{})
+			p := processors.NewDigestProcessor()
+			Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+			_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualResBlobReader).To(BeNil())
+			Expect(actualRes).To(Equal(res))
+		})
+
+	})
+})
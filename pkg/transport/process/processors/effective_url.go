@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+// EffectiveURLLabelName is the name of the label that contains the effective url of a resource.
+const EffectiveURLLabelName = "access-url"
+
+type effectiveURLProcessor struct{}
+
+// NewEffectiveURLProcessor returns a processor that adds the url of the resource access as "access-url" label.
+// An existing label with the same name is replaced. The url has the following format per access type:
+//   - ociRegistry: oci://<imageReference>
+//   - ociBlob: oci://<ref>@<digest>
+//   - localOciBlob: oci://<baseUrl>/component-descriptors/<component name>:<component version>@<digest>
+//   - web: <url>
+//   - s3: s3://<bucketName>/<objectKey>
+//
+// Resources with other access types are forwarded without a label. The resource blob is forwarded unchanged.
+func NewEffectiveURLProcessor() process.ResourceStreamProcessor {
+	return &effectiveURLProcessor{}
+}
+
+func (p *effectiveURLProcessor) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	url, err := effectiveURL(*cd, res)
+	if err != nil {
+		return fmt.Errorf("unable to get effective url of resource %q: %w", res.Name, err)
+	}
+	if len(url) != 0 {
+		value, err := json.Marshal(url)
+		if err != nil {
+			return fmt.Errorf("unable to encode label value: %w", err)
+		}
+		res.Labels = setLabel(res.Labels, cdv2.Label{
+			Name:  EffectiveURLLabelName,
+			Value: value,
+		})
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// effectiveURL returns the url of the resource access or an empty string for unknown access types.
+func effectiveURL(cd cdv2.ComponentDescriptor, res cdv2.Resource) (string, error) {
+	if res.Access == nil {
+		return "", nil
+	}
+	switch res.Access.GetType() {
+	case cdv2.OCIRegistryType:
+		acc := &cdv2.OCIRegistryAccess{}
+		if err := res.Access.DecodeInto(acc); err != nil {
+			return "", fmt.Errorf("unable to decode resource access: %w", err)
+		}
+		return "oci://" + acc.ImageReference, nil
+	case cdv2.OCIBlobType:
+		acc := &cdv2.OCIBlobAccess{}
+		if err := res.Access.DecodeInto(acc); err != nil {
+			return "", fmt.Errorf("unable to decode resource access: %w", err)
+		}
+		return fmt.Sprintf("oci://%s@%s", acc.Reference, acc.Digest), nil
+	case cdv2.LocalOCIBlobType:
+		acc := &cdv2.LocalOCIBlobAccess{}
+		if err := res.Access.DecodeInto(acc); err != nil {
+			return "", fmt.Errorf("unable to decode resource access: %w", err)
+		}
+		effectiveRepoCtx := cd.GetEffectiveRepositoryContext()
+		if effectiveRepoCtx == nil {
+			return "", fmt.Errorf("component descriptor has no repository context")
+		}
+		repoCtx := cdv2.OCIRegistryRepository{}
+		if err := effectiveRepoCtx.DecodeInto(&repoCtx); err != nil {
+			return "", fmt.Errorf("unable to decode repository context: %w", err)
+		}
+		ref, err := cdoci.OCIRef(repoCtx, cd.Name, cd.Version)
+		if err != nil {
+			return "", fmt.Errorf("unable to get component descriptor reference: %w", err)
+		}
+		return fmt.Sprintf("oci://%s@%s", ref, acc.Digest), nil
+	case cdv2.WebType:
+		acc := &cdv2.Web{}
+		if err := res.Access.DecodeInto(acc); err != nil {
+			return "", fmt.Errorf("unable to decode resource access: %w", err)
+		}
+		return acc.URL, nil
+	case cdv2.S3AccessType:
+		acc := &cdv2.S3Access{}
+		if err := res.Access.DecodeInto(acc); err != nil {
+			return "", fmt.Errorf("unable to decode resource access: %w", err)
+		}
+		return fmt.Sprintf("s3://%s/%s", acc.BucketName, acc.ObjectKey), nil
+	default:
+		return "", nil
+	}
+}
+
+// setLabel replaces the label with the same name or appends it if no such label exists.
+func setLabel(labels cdv2.Labels, label cdv2.Label) cdv2.Labels {
+	for i, l := range labels {
+		if l.Name == label.Name {
+			labels[i] = label
+			return labels
+		}
+	}
+	return append(labels, label)
+}
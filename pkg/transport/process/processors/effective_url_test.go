@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("effectiveURLProcessor", func() {
+
+	process := func(access cdv2.TypedObjectAccessor, labels ...cdv2.Label) cdv2.Resource {
+		acc, err := cdv2.NewUnstructured(access)
+		Expect(err).ToNot(HaveOccurred())
+		res := cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    cdv2.OCIImageType,
+				Labels:  labels,
+			},
+			Access: &acc,
+		}
+		cd := cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/my-component"
+		cd.Version = "v0.1.0"
+		Expect(cdv2.InjectRepositoryContext(&cd, cdv2.NewOCIRegistryRepository("example.com/components", ""))).To(Succeed())
+		resBytes := []byte("resource-blob")
+
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+		outBuf := bytes.NewBuffer([]byte{})
+		p := processors.NewEffectiveURLProcessor()
+		Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+		_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		defer actualResBlobReader.Close()
+		actualResBlobBuf := bytes.NewBuffer([]byte{})
+		_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
+		return actualRes
+	}
+
+	DescribeTable("should add the effective url label",
+		func(access cdv2.TypedObjectAccessor, expectedURL string) {
+			res := process(access)
+			Expect(res.Labels).To(HaveLen(1))
+			Expect(res.Labels[0].Name).To(Equal(processors.EffectiveURLLabelName))
+			var url string
+			Expect(json.Unmarshal(res.Labels[0].Value, &url)).To(Succeed())
+			Expect(url).To(Equal(expectedURL))
+		},
+		Entry("ociRegistry", cdv2.NewOCIRegistryAccess("example.com/my-image:1.0.0"), "oci://example.com/my-image:1.0.0"),
+		Entry("localOciBlob", cdv2.NewLocalOCIBlobAccess("sha256:abc"),
+			"oci://example.com/components/component-descriptors/example.com/my-component:v0.1.0@sha256:abc"),
+		Entry("web", cdv2.NewWebAccess("https://example.com/file.tgz"), "https://example.com/file.tgz"),
+		Entry("s3", cdv2.NewS3Access("my-bucket", "path/to/object"), "s3://my-bucket/path/to/object"),
+	)
+
+	It("should replace an existing label", func() {
+		res := process(cdv2.NewOCIRegistryAccess("example.com/my-image:1.0.0"), cdv2.Label{
+			Name:  processors.EffectiveURLLabelName,
+			Value: json.RawMessage(`"old"`),
+		})
+		Expect(res.Labels).To(HaveLen(1))
+		Expect(string(res.Labels[0].Value)).To(Equal(`"oci://example.com/my-image:1.0.0"`))
+	})
+
+	It("should skip unknown access types", func() {
+		res := process(cdv2.NewGitHubAccess("github.com/gardener/component-cli", "main", ""))
+		Expect(res.Labels).To(BeEmpty())
+	})
+
+})
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type labelRemover struct {
+	names sets.String
+}
+
+// NewLabelRemovalProcessor returns a processor that removes all labels with one of the given names from a resource.
+// Names that are not part of the resource labels are ignored. The resource blob is forwarded unchanged.
+func NewLabelRemovalProcessor(names ...string) process.ResourceStreamProcessor {
+	obj := labelRemover{
+		names: sets.NewString(names...),
+	}
+	return &obj
+}
+
+func (p *labelRemover) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if len(res.Labels) != 0 {
+		labels := res.Labels[:0]
+		for _, label := range res.Labels {
+			if !p.names.Has(label.Name) {
+				labels = append(labels, label)
+			}
+		}
+		res.Labels = labels
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
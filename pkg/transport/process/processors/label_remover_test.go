@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("labelRemover", func() {
+
+	var res cdv2.Resource
+
+	BeforeEach(func() {
+		res = cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    "ociImage",
+				Labels: cdv2.Labels{
+					{Name: "internal/owner", Value: json.RawMessage(`"team-a"`)},
+					{Name: "public", Value: json.RawMessage(`"true"`)},
+					{Name: "internal/cost-center", Value: json.RawMessage(`"1234"`)},
+				},
+			},
+		}
+	})
+
+	process := func(names ...string) cdv2.Resource {
+		resBytes := []byte("resource-blob")
+
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+		outBuf := bytes.NewBuffer([]byte{})
+		p := processors.NewLabelRemovalProcessor(names...)
+		Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+		_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		defer actualResBlobReader.Close()
+		actualResBlobBuf := bytes.NewBuffer([]byte{})
+		_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
+		return actualRes
+	}
+
+	It("should remove the labels with the given names", func() {
+		actualRes := process("internal/owner", "internal/cost-center")
+		Expect(actualRes.Labels).To(HaveLen(1))
+		Expect(actualRes.Labels[0].Name).To(Equal("public"))
+	})
+
+	It("should not modify the labels if no name matches", func() {
+		expected := res.Labels
+		actualRes := process("unknown")
+		Expect(actualRes.Labels).To(Equal(expected))
+	})
+
+})
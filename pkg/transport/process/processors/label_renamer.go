@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type labelRenamer struct {
+	mapping map[string]string
+}
+
+// NewLabelRenameProcessor returns a processor that renames the labels of a resource according to the given mapping
+// of old to new label names. Mapping keys that end with "/" are treated as label namespaces,
+// e.g. "old.example.com/" -> "new.example.com/" renames "old.example.com/x" to "new.example.com/x".
+// Exact label names take precedence over namespaces and longer namespaces take precedence over shorter ones. The label values and the resource blob are forwarded unchanged.
+// The processor fails if multiple labels of a resource would end up with the same name.
+func NewLabelRenameProcessor(mapping map[string]string) process.ResourceStreamProcessor {
+	obj := labelRenamer{
+		mapping: mapping,
+	}
+	return &obj
+}
+
+func (p *labelRenamer) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	labels, err := p.rename(res.Labels)
+	if err != nil {
+		return fmt.Errorf("unable to rename labels of resource %q: %w", res.Name, err)
+	}
+	res.Labels = labels
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+func (p *labelRenamer) rename(labels cdv2.Labels) (cdv2.Labels, error) {
+	if len(labels) == 0 {
+		return labels, nil
+	}
+	renamed := make(cdv2.Labels, 0, len(labels))
+	origins := map[string]string{}
+	for _, label := range labels {
+		oldName := label.Name
+		label.Name = p.newName(label.Name)
+		if origin, ok := origins[label.Name]; ok {
+			return nil, fmt.Errorf("labels %q and %q both resolve to %q", origin, oldName, label.Name)
+		}
+		origins[label.Name] = oldName
+		renamed = append(renamed, label)
+	}
+	return renamed, nil
+}
+
+func (p *labelRenamer) newName(name string) string {
+	if newName, ok := p.mapping[name]; ok {
+		return newName
+	}
+	// use the most specific namespace if multiple namespaces match
+	matchedNamespace := ""
+	for oldNamespace := range p.mapping {
+		if strings.HasSuffix(oldNamespace, "/") && strings.HasPrefix(name, oldNamespace) && len(oldNamespace) > len(matchedNamespace) {
+			matchedNamespace = oldNamespace
+		}
+	}
+	if len(matchedNamespace) == 0 {
+		return name
+	}
+	return p.mapping[matchedNamespace] + strings.TrimPrefix(name, matchedNamespace)
+}
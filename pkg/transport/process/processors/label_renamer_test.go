@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("labelRenamer", func() {
+
+	process := func(mapping map[string]string, labels cdv2.Labels) (cdv2.Labels, error) {
+		res := cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    "plain-text",
+				Labels:  labels,
+			},
+		}
+		resBytes := []byte("resource-blob")
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+		outBuf := bytes.NewBuffer([]byte{})
+		p := processors.NewLabelRenameProcessor(mapping)
+		if err := p.Process(context.TODO(), inBuf, outBuf); err != nil {
+			return nil, err
+		}
+
+		_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		defer actualResBlobReader.Close()
+		actualResBlobBuf := bytes.NewBuffer([]byte{})
+		_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
+		return actualRes.Labels, nil
+	}
+
+	It("should rename labels by name and namespace", func() {
+		labels, err := process(map[string]string{
+			"old.example.com/":    "new.example.com/",
+			"old.example.com/abc": "other.example.com/abc",
+		}, cdv2.Labels{
+			{Name: "old.example.com/x", Value: json.RawMessage(`"x"`)},
+			{Name: "old.example.com/abc", Value: json.RawMessage(`{"a":1}`)},
+			{Name: "unrelated", Value: json.RawMessage(`true`)},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(labels).To(Equal(cdv2.Labels{
+			{Name: "new.example.com/x", Value: json.RawMessage(`"x"`)},
+			{Name: "other.example.com/abc", Value: json.RawMessage(`{"a":1}`)},
+			{Name: "unrelated", Value: json.RawMessage(`true`)},
+		}))
+	})
+
+	It("should fail if a renamed label collides with an existing label", func() {
+		_, err := process(map[string]string{
+			"old.example.com/x": "new.example.com/x",
+		}, cdv2.Labels{
+			{Name: "new.example.com/x", Value: json.RawMessage(`"a"`)},
+			{Name: "old.example.com/x", Value: json.RawMessage(`"b"`)},
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("both resolve to"))
+	})
+
+	It("should fail if two labels are renamed to the same name", func() {
+		_, err := process(map[string]string{
+			"a": "c",
+			"b": "c",
+		}, cdv2.Labels{
+			{Name: "a", Value: json.RawMessage(`"a"`)},
+			{Name: "b", Value: json.RawMessage(`"b"`)},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,294 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/extensions"
+)
+
+const (
+	// ResourceLabelerProcessorType defines the type of a resource labeler
+	ResourceLabelerProcessorType = "ResourceLabeler"
+
+	// LabelRenameProcessorType defines the type of a label renamer
+	LabelRenameProcessorType = "LabelRenamer"
+
+	// ReferenceCanonicalizeProcessorType defines the type of a reference canonicalizer
+	ReferenceCanonicalizeProcessorType = "ReferenceCanonicalizer"
+
+	// BaseImageMapProcessorType defines the type of a base image mapper
+	BaseImageMapProcessorType = "BaseImageMapper"
+
+	// EffectiveURLProcessorType defines the type of an effective url labeler
+	EffectiveURLProcessorType = "EffectiveURLLabeler"
+
+	// VersionAlignmentProcessorType defines the type of a version aligner
+	VersionAlignmentProcessorType = "VersionAligner"
+
+	// ResourceSchemaProcessorType defines the type of a resource schema validator
+	ResourceSchemaProcessorType = "ResourceSchemaValidator"
+
+	// CosignVerifyProcessorType defines the type of a cosign signature verifier
+	CosignVerifyProcessorType = "CosignVerifier"
+
+	// DigestProcessorType defines the type of a resource blob digester
+	DigestProcessorType = "Digester"
+
+	// RelocationProcessorType defines the type of an image reference relocator
+	RelocationProcessorType = "Relocator"
+
+	// LabelRemovalProcessorType defines the type of a label remover
+	LabelRemovalProcessorType = "LabelRemover"
+
+	// BlobDropProcessorType defines the type of a blob dropper
+	BlobDropProcessorType = "BlobDropper"
+)
+
+// NewProcessorFactory creates a new processor factory.
+// The client is only required for processors that access an oci registry.
+// How to add a new processor (without using extension mechanism):
+// - Add Go file to processors package which contains the source code of the new processor
+// - Add string constant for new processor type -> will be used in ProcessorFactory.Create()
+// - Add source code for creating new processor to ProcessorFactory.Create() method
+func NewProcessorFactory(log logr.Logger, client ociclient.Client) *ProcessorFactory {
+	return &ProcessorFactory{
+		log:    log,
+		client: client,
+	}
+}
+
+// ProcessorFactory defines a helper struct for creating processors
+type ProcessorFactory struct {
+	log    logr.Logger
+	client ociclient.Client
+}
+
+// Create creates a new processor defined by a type and a spec
+func (f *ProcessorFactory) Create(processorType string, spec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	switch processorType {
+	case ResourceLabelerProcessorType:
+		return f.createResourceLabeler(spec)
+	case LabelRenameProcessorType:
+		return f.createLabelRenamer(spec)
+	case ReferenceCanonicalizeProcessorType:
+		return f.createReferenceCanonicalizer(spec)
+	case BaseImageMapProcessorType:
+		return f.createBaseImageMapper(spec)
+	case EffectiveURLProcessorType:
+		return NewEffectiveURLProcessor(), nil
+	case VersionAlignmentProcessorType:
+		return f.createVersionAligner(spec)
+	case ResourceSchemaProcessorType:
+		return f.createResourceSchemaValidator(spec)
+	case CosignVerifyProcessorType:
+		return f.createCosignVerifier(spec)
+	case DigestProcessorType:
+		return NewDigestProcessor(), nil
+	case RelocationProcessorType:
+		return f.createRelocator(spec)
+	case LabelRemovalProcessorType:
+		return f.createLabelRemover(spec)
+	case BlobDropProcessorType:
+		return f.createBlobDropper(spec)
+	case extensions.ExecutableType:
+		return extensions.CreateExecutable(spec)
+	default:
+		return nil, fmt.Errorf("unknown processor type %s", processorType)
+	}
+}
+
+func (f *ProcessorFactory) createResourceLabeler(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type resourceLabelerSpec struct {
+		Labels    cdv2.Labels `json:"labels"`
+		Mode      LabelMode   `json:"mode"`
+		ExpandEnv bool        `json:"expandEnv"`
+		StrictEnv bool        `json:"strictEnv"`
+	}
+
+	var spec resourceLabelerSpec
+	if err := unmarshalSpec(rawSpec, &spec); err != nil {
+		return nil, err
+	}
+
+	switch spec.Mode {
+	case "":
+		spec.Mode = LabelModeAppend
+	case LabelModeAppend, LabelModeOverwrite:
+	default:
+		return nil, fmt.Errorf("unknown label mode %q, expected one of %q, %q", spec.Mode, LabelModeAppend, LabelModeOverwrite)
+	}
+	if spec.ExpandEnv {
+		return NewResourceLabelerWithEnvExpansion(spec.Mode, EnvExpansion{Strict: spec.StrictEnv}, spec.Labels...), nil
+	}
+	return NewResourceLabelerWithMode(spec.Mode, spec.Labels...), nil
+}
+
+func (f *ProcessorFactory) createLabelRenamer(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type labelRenamerSpec struct {
+		Mapping map[string]string `json:"mapping"`
+	}
+
+	var spec labelRenamerSpec
+	if err := unmarshalSpec(rawSpec, &spec); err != nil {
+		return nil, err
+	}
+
+	return NewLabelRenameProcessor(spec.Mapping), nil
+}
+
+func (f *ProcessorFactory) createReferenceCanonicalizer(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type referenceCanonicalizerSpec struct {
+		DefaultRegistry string `json:"defaultRegistry"`
+	}
+
+	var spec referenceCanonicalizerSpec
+	if err := unmarshalSpec(rawSpec, &spec); err != nil {
+		return nil, err
+	}
+
+	return NewReferenceCanonicalizeProcessor(spec.DefaultRegistry), nil
+}
+
+func (f *ProcessorFactory) createBaseImageMapper(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type baseImageMapperSpec struct {
+		Mapping map[string]string `json:"mapping"`
+	}
+
+	var spec baseImageMapperSpec
+	if err := unmarshalSpec(rawSpec, &spec); err != nil {
+		return nil, err
+	}
+
+	return NewBaseImageMapProcessor(spec.Mapping), nil
+}
+
+func (f *ProcessorFactory) createVersionAligner(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type versionAlignerSpec struct {
+		AlignVersions bool `json:"alignVersions"`
+	}
+
+	var spec versionAlignerSpec
+	if err := unmarshalSpec(rawSpec, &spec); err != nil {
+		return nil, err
+	}
+
+	return NewVersionAlignmentProcessor(spec.AlignVersions), nil
+}
+
+func (f *ProcessorFactory) createResourceSchemaValidator(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type resourceSchemaValidatorSpec struct {
+		Schemas map[string]string `json:"schemas"`
+	}
+
+	var spec resourceSchemaValidatorSpec
+	if err := unmarshalSpec(rawSpec, &spec); err != nil {
+		return nil, err
+	}
+
+	return NewResourceSchemaProcessor(spec.Schemas)
+}
+
+func (f *ProcessorFactory) createCosignVerifier(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type cosignVerifierSpec struct {
+		Key      string `json:"key"`
+		WarnOnly bool   `json:"warnOnly"`
+		// the keyless fields are only parsed to reject keyless verification explicitly.
+		Keyless               bool   `json:"keyless"`
+		CertificateIdentity   string `json:"certificateIdentity"`
+		CertificateOIDCIssuer string `json:"certificateOidcIssuer"`
+	}
+
+	var spec cosignVerifierSpec
+	if err := unmarshalSpec(rawSpec, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Keyless || len(spec.CertificateIdentity) != 0 || len(spec.CertificateOIDCIssuer) != 0 {
+		return nil, errors.New("keyless cosign verification is not supported, a public key has to be defined")
+	}
+
+	return NewCosignVerifyProcessor(f.log, f.client, spec.Key, spec.WarnOnly)
+}
+
+func (f *ProcessorFactory) createRelocator(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type relocatorSpec struct {
+		SourcePrefix string `json:"sourcePrefix"`
+		TargetPrefix string `json:"targetPrefix"`
+	}
+
+	var spec relocatorSpec
+	if err := unmarshalSpec(rawSpec, &spec); err != nil {
+		return nil, err
+	}
+
+	return NewRelocationProcessor(spec.SourcePrefix, spec.TargetPrefix), nil
+}
+
+func (f *ProcessorFactory) createLabelRemover(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type labelRemoverSpec struct {
+		Names []string `json:"names"`
+	}
+
+	var spec labelRemoverSpec
+	if err := unmarshalSpec(rawSpec, &spec); err != nil {
+		return nil, err
+	}
+
+	return NewLabelRemovalProcessor(spec.Names...), nil
+}
+
+func (f *ProcessorFactory) createBlobDropper(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type externalAccess struct {
+		// ComponentName optionally restricts the access to the resources of a component.
+		ComponentName string                        `json:"componentName"`
+		ResourceName  string                        `json:"resourceName"`
+		Access        *cdv2.UnstructuredTypedObject `json:"access"`
+	}
+	type blobDropperSpec struct {
+		ExternalAccesses []externalAccess `json:"externalAccesses"`
+	}
+
+	var spec blobDropperSpec
+	if err := unmarshalSpec(rawSpec, &spec); err != nil {
+		return nil, err
+	}
+	for i, ext := range spec.ExternalAccesses {
+		if len(ext.ResourceName) == 0 {
+			return nil, fmt.Errorf("external access %d: resource name must not be empty", i)
+		}
+		if ext.Access == nil {
+			return nil, fmt.Errorf("external access %d: access must not be empty", i)
+		}
+	}
+
+	resolver := func(cd cdv2.ComponentDescriptor, res cdv2.Resource) (cdv2.TypedObjectAccessor, error) {
+		for _, ext := range spec.ExternalAccesses {
+			if ext.ResourceName == res.Name && (len(ext.ComponentName) == 0 || ext.ComponentName == cd.Name) {
+				return ext.Access.DeepCopy(), nil
+			}
+		}
+		return nil, nil
+	}
+	return NewBlobDropProcessor(resolver)
+}
+
+// unmarshalSpec parses an optional processor spec.
+func unmarshalSpec(rawSpec *json.RawMessage, spec interface{}) error {
+	if rawSpec == nil {
+		return nil
+	}
+	if err := yaml.Unmarshal(*rawSpec, spec); err != nil {
+		return fmt.Errorf("unable to parse spec: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("ProcessorFactory", func() {
+
+	var factory *processors.ProcessorFactory
+
+	BeforeEach(func() {
+		factory = processors.NewProcessorFactory(logr.Discard(), nil)
+	})
+
+	It("should create a processor from a spec", func() {
+		spec := json.RawMessage(`{"labels":[{"name":"my-label","value":"my-value"}]}`)
+		p, err := factory.Create(processors.ResourceLabelerProcessorType, &spec)
+		Expect(err).ToNot(HaveOccurred())
+
+		res := cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    "plain-text",
+			},
+		}
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, nil, inBuf)).To(Succeed())
+		outBuf := bytes.NewBuffer([]byte{})
+		Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+		_, actualRes, _, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualRes.Labels).To(HaveLen(1))
+		Expect(actualRes.Labels[0].Name).To(Equal("my-label"))
+		Expect(actualRes.Labels[0].Value).To(MatchJSON(`"my-value"`))
+	})
+
+	It("should create a resource labeler that expands environment variables", func() {
+		Expect(os.Setenv("COMPONENT_CLI_TEST_BUILD", "42")).To(Succeed())
+		defer os.Unsetenv("COMPONENT_CLI_TEST_BUILD")
+		spec := json.RawMessage(`{"labels":[{"name":"build","value":"${COMPONENT_CLI_TEST_BUILD}"}],"expandEnv":true,"strictEnv":true}`)
+		p, err := factory.Create(processors.ResourceLabelerProcessorType, &spec)
+		Expect(err).ToNot(HaveOccurred())
+
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, cdv2.Resource{}, nil, inBuf)).To(Succeed())
+		outBuf := bytes.NewBuffer([]byte{})
+		Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+		_, actualRes, _, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualRes.Labels).To(HaveLen(1))
+		Expect(actualRes.Labels[0].Value).To(MatchJSON(`"42"`))
+	})
+
+	It("should create a blob dropper with the external accesses of its spec", func() {
+		spec := json.RawMessage(`{"externalAccesses":[{"componentName":"example.com/other","resourceName":"my-res","access":{"type":"ociRegistry","imageReference":"example.com/other:v0.1.0"}},{"resourceName":"my-res","access":{"type":"ociRegistry","imageReference":"example.com/my-res:v0.1.0"}}]}`)
+		p, err := factory.Create(processors.BlobDropProcessorType, &spec)
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/my-component"
+		localAccess, err := cdv2.NewUnstructured(cdv2.NewLocalFilesystemBlobAccess("my-blob", "text/plain"))
+		Expect(err).ToNot(HaveOccurred())
+		res := cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    "plain-text",
+			},
+			Access: &localAccess,
+		}
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader([]byte("blob")), inBuf)).To(Succeed())
+		outBuf := bytes.NewBuffer([]byte{})
+		Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+		_, actualRes, blobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobReader).To(BeNil())
+		Expect(actualRes.Access.GetType()).To(Equal(cdv2.OCIRegistryType))
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		Expect(actualRes.Access.DecodeInto(ociAccess)).To(Succeed())
+		Expect(ociAccess.ImageReference).To(Equal("example.com/my-res:v0.1.0"))
+
+		res.Name = "unknown"
+		inBuf = bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader([]byte("blob")), inBuf)).To(Succeed())
+		Expect(p.Process(context.TODO(), inBuf, bytes.NewBuffer([]byte{}))).To(MatchError(`no external location known for resource "unknown"`))
+	})
+
+	It("should fail to create a blob dropper with an external access without access", func() {
+		spec := json.RawMessage(`{"externalAccesses":[{"resourceName":"my-res"}]}`)
+		_, err := factory.Create(processors.BlobDropProcessorType, &spec)
+		Expect(err).To(MatchError("external access 0: access must not be empty"))
+	})
+
+	It("should fail to create a cosign verifier for keyless verification", func() {
+		spec := json.RawMessage(`{"keyless":true,"certificateIdentity":"user@example.com","certificateOidcIssuer":"https://accounts.example.com"}`)
+		_, err := factory.Create(processors.CosignVerifyProcessorType, &spec)
+		Expect(err).To(MatchError("keyless cosign verification is not supported, a public key has to be defined"))
+	})
+
+	It("should create a processor without a spec", func() {
+		_, err := factory.Create(processors.EffectiveURLProcessorType, nil)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should fail for an invalid spec", func() {
+		spec := json.RawMessage(`{"mapping":"invalid"}`)
+		_, err := factory.Create(processors.LabelRenameProcessorType, &spec)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail for an unknown processor type", func() {
+		_, err := factory.Create("Unknown", nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
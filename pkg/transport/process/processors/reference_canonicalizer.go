@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	dockerreference "github.com/containerd/containerd/reference/docker"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type referenceCanonicalizer struct {
+	defaultRegistry string
+}
+
+// NewReferenceCanonicalizeProcessor returns a processor that expands the image references of
+// resources with an ociRegistry access to their fully-qualified form, e.g. "nginx" to "docker.io/library/nginx:latest".
+// References without a registry are resolved against defaultRegistry. If defaultRegistry is empty, Docker Hub is used.
+// The resource blob is forwarded unchanged.
+func NewReferenceCanonicalizeProcessor(defaultRegistry string) process.ResourceStreamProcessor {
+	obj := referenceCanonicalizer{
+		defaultRegistry: strings.TrimSuffix(defaultRegistry, "/"),
+	}
+	return &obj
+}
+
+func (p *referenceCanonicalizer) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if res.Access != nil && res.Access.GetType() == cdv2.OCIRegistryType {
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		if err := res.Access.DecodeInto(ociAccess); err != nil {
+			return fmt.Errorf("unable to decode resource access: %w", err)
+		}
+
+		canonicalRef, err := p.canonicalize(ociAccess.ImageReference)
+		if err != nil {
+			return fmt.Errorf("unable to canonicalize image reference %q: %w", ociAccess.ImageReference, err)
+		}
+
+		if canonicalRef != ociAccess.ImageReference {
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(canonicalRef))
+			if err != nil {
+				return fmt.Errorf("unable to create resource access object: %w", err)
+			}
+			res.Access = &acc
+		}
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// canonicalize returns the fully-qualified form of the reference following Docker's normalization rules.
+func (p *referenceCanonicalizer) canonicalize(ref string) (string, error) {
+	if len(p.defaultRegistry) != 0 && !hasRegistry(ref) {
+		ref = p.defaultRegistry + "/" + ref
+	}
+	named, err := dockerreference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", err
+	}
+	return dockerreference.TagNameOnly(named).String(), nil
+}
+
+// hasRegistry checks whether the first path component of the reference is a registry host.
+func hasRegistry(ref string) bool {
+	i := strings.IndexRune(ref, '/')
+	if i == -1 {
+		return false
+	}
+	return strings.ContainsAny(ref[:i], ".:") || ref[:i] == "localhost"
+}
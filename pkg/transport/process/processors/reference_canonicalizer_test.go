@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("referenceCanonicalizer", func() {
+
+	DescribeTable("should canonicalize oci references",
+		func(defaultRegistry, ref, expectedRef string) {
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(ref))
+			Expect(err).ToNot(HaveOccurred())
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    cdv2.OCIImageType,
+				},
+				Access: &acc,
+			}
+			resBytes := []byte("resource-blob")
+
+			inBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+			outBuf := bytes.NewBuffer([]byte{})
+			p := processors.NewReferenceCanonicalizeProcessor(defaultRegistry)
+			Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+			_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+			Expect(err).ToNot(HaveOccurred())
+			defer actualResBlobReader.Close()
+			actualResBlobBuf := bytes.NewBuffer([]byte{})
+			_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
+
+			ociAccess := &cdv2.OCIRegistryAccess{}
+			Expect(actualRes.Access.DecodeInto(ociAccess)).To(Succeed())
+			Expect(ociAccess.ImageReference).To(Equal(expectedRef))
+		},
+		Entry("official image", "", "nginx:latest", "docker.io/library/nginx:latest"),
+		Entry("image without tag", "", "nginx", "docker.io/library/nginx:latest"),
+		Entry("docker hub user image", "", "user/app:1.0.0", "docker.io/user/app:1.0.0"),
+		Entry("qualified image", "", "eu.gcr.io/my-project/app:1.0.0", "eu.gcr.io/my-project/app:1.0.0"),
+		Entry("qualified image with tag and digest", "",
+			"eu.gcr.io/app:1.0.0@sha256:7cc4b5aefd1d0cadf8d97d4350462ba51c694ebca145b08d7d41b41acc8db5aa",
+			"eu.gcr.io/app:1.0.0@sha256:7cc4b5aefd1d0cadf8d97d4350462ba51c694ebca145b08d7d41b41acc8db5aa"),
+		Entry("custom default registry", "registry.example.com", "app:1.0.0", "registry.example.com/app:1.0.0"),
+		Entry("custom default registry with qualified image", "registry.example.com", "eu.gcr.io/app:1.0.0", "eu.gcr.io/app:1.0.0"),
+	)
+
+})
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type relocator struct {
+	sourcePrefix string
+	targetPrefix string
+}
+
+// NewRelocationProcessor returns a processor that replaces the source prefix of image references
+// of resources with an ociRegistry access with the target prefix, e.g. to relocate images to a registry of an air-gapped environment.
+// The prefix only matches complete path segments, i.e. "example.com/a" matches "example.com/a/img:1.0.0"
+// but not "example.com/ab/img:1.0.0". Other references and access types are unchanged.
+// The resource blob is forwarded unchanged.
+func NewRelocationProcessor(sourcePrefix, targetPrefix string) process.ResourceStreamProcessor {
+	obj := relocator{
+		sourcePrefix: sourcePrefix,
+		targetPrefix: targetPrefix,
+	}
+	return &obj
+}
+
+func (p *relocator) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if res.Access != nil && res.Access.GetType() == cdv2.OCIRegistryType {
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		if err := res.Access.DecodeInto(ociAccess); err != nil {
+			return fmt.Errorf("unable to decode resource access: %w", err)
+		}
+
+		if hasReferencePrefix(ociAccess.ImageReference, p.sourcePrefix) {
+			ref := p.targetPrefix + strings.TrimPrefix(ociAccess.ImageReference, p.sourcePrefix)
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(ref))
+			if err != nil {
+				return fmt.Errorf("unable to create resource access object: %w", err)
+			}
+			res.Access = &acc
+		}
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// hasReferencePrefix checks whether the prefix matches complete path segments of the image reference.
+func hasReferencePrefix(ref, prefix string) bool {
+	if len(prefix) == 0 || !strings.HasPrefix(ref, prefix) {
+		return false
+	}
+	if len(ref) == len(prefix) || strings.HasSuffix(prefix, "/") {
+		return true
+	}
+	switch ref[len(prefix)] {
+	case '/', ':', '@':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("relocator", func() {
+
+	process := func(res cdv2.Resource) cdv2.Resource {
+		resBytes := []byte("resource-blob")
+
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+		outBuf := bytes.NewBuffer([]byte{})
+		p := processors.NewRelocationProcessor("eu.gcr.io/gardener-project", "registry.example.com/mirror")
+		Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+		_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		defer actualResBlobReader.Close()
+		actualResBlobBuf := bytes.NewBuffer([]byte{})
+		_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
+		return actualRes
+	}
+
+	DescribeTable("should relocate oci references",
+		func(ref, expectedRef string) {
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(ref))
+			Expect(err).ToNot(HaveOccurred())
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    cdv2.OCIImageType,
+				},
+				Access: &acc,
+			}
+
+			actualRes := process(res)
+			ociAccess := &cdv2.OCIRegistryAccess{}
+			Expect(actualRes.Access.DecodeInto(ociAccess)).To(Succeed())
+			Expect(ociAccess.ImageReference).To(Equal(expectedRef))
+		},
+		Entry("matching prefix", "eu.gcr.io/gardener-project/gardener/apiserver:v1.0.0", "registry.example.com/mirror/gardener/apiserver:v1.0.0"),
+		Entry("matching prefix with digest", "eu.gcr.io/gardener-project/img@sha256:abc", "registry.example.com/mirror/img@sha256:abc"),
+		Entry("non-matching prefix", "docker.io/library/alpine:3.15", "docker.io/library/alpine:3.15"),
+		Entry("partial path segment", "eu.gcr.io/gardener-project-dev/img:1.0.0", "eu.gcr.io/gardener-project-dev/img:1.0.0"),
+	)
+
+	It("should not modify resources with other access types", func() {
+		acc, err := cdv2.NewUnstructured(cdv2.NewWebAccess("https://eu.gcr.io/gardener-project/file"))
+		Expect(err).ToNot(HaveOccurred())
+		res := cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    "plain-text",
+			},
+			Access: &acc,
+		}
+
+		actualRes := process(res)
+		Expect(actualRes.Access.Raw).To(MatchJSON(res.Access.Raw))
+	})
+
+})
@@ -5,8 +5,12 @@ package processors
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"regexp"
+	"strings"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 
@@ -14,18 +18,65 @@ import (
 	"github.com/gardener/component-cli/pkg/transport/process/utils"
 )
 
+// LabelMode defines how a resource labeler handles labels that already exist on a resource.
+type LabelMode string
+
+const (
+	// LabelModeAppend appends the labels even if a label with the same name already exists.
+	LabelModeAppend LabelMode = "append"
+	// LabelModeOverwrite replaces existing labels with the same name in place.
+	LabelModeOverwrite LabelMode = "overwrite"
+)
+
+// EnvLookupFunc looks up the value of an environment variable.
+type EnvLookupFunc func(key string) (string, bool)
+
+// EnvExpansion defines how ${VAR} placeholders in label values are expanded.
+type EnvExpansion struct {
+	// Lookup looks up the value of a variable. Defaults to os.LookupEnv.
+	Lookup EnvLookupFunc
+	// Strict fails the processing if a variable is not set.
+	// Variables that are not set expand to an empty string otherwise.
+	Strict bool
+}
+
+var envPlaceholderRegexp = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+
 type resourceLabeler struct {
-	labels cdv2.Labels
+	mode      LabelMode
+	labels    cdv2.Labels
+	expansion *EnvExpansion
 }
 
 // NewResourceLabeler returns a processor that appends one or more labels to a resource
 func NewResourceLabeler(labels ...cdv2.Label) process.ResourceStreamProcessor {
+	return NewResourceLabelerWithMode(LabelModeAppend, labels...)
+}
+
+// NewResourceLabelerWithMode returns a processor that adds one or more labels to a resource.
+// The mode defines whether labels with the same name as an existing label are appended or replace the existing label.
+func NewResourceLabelerWithMode(mode LabelMode, labels ...cdv2.Label) process.ResourceStreamProcessor {
 	obj := resourceLabeler{
+		mode:   mode,
 		labels: labels,
 	}
 	return &obj
 }
 
+// NewResourceLabelerWithEnvExpansion returns a processor that adds one or more labels to a resource.
+// ${VAR} placeholders in the string values of the labels are expanded when a resource is processed.
+func NewResourceLabelerWithEnvExpansion(mode LabelMode, expansion EnvExpansion, labels ...cdv2.Label) process.ResourceStreamProcessor {
+	if expansion.Lookup == nil {
+		expansion.Lookup = os.LookupEnv
+	}
+	obj := resourceLabeler{
+		mode:      mode,
+		labels:    labels,
+		expansion: &expansion,
+	}
+	return &obj
+}
+
 func (p *resourceLabeler) Process(ctx context.Context, r io.Reader, w io.Writer) error {
 	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
 	if err != nil {
@@ -35,7 +86,22 @@ func (p *resourceLabeler) Process(ctx context.Context, r io.Reader, w io.Writer)
 		defer resBlobReader.Close()
 	}
 
-	res.Labels = append(res.Labels, p.labels...)
+	labels := p.labels
+	if p.expansion != nil {
+		labels, err = p.expansion.expandLabels(p.labels)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch p.mode {
+	case LabelModeOverwrite:
+		for _, label := range labels {
+			res.Labels = setLabel(res.Labels, label)
+		}
+	default:
+		res.Labels = append(res.Labels, labels...)
+	}
 
 	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
 		return fmt.Errorf("unable to write processor message: %w", err)
@@ -43,3 +109,73 @@ func (p *resourceLabeler) Process(ctx context.Context, r io.Reader, w io.Writer)
 
 	return nil
 }
+
+// expandLabels returns a copy of the labels with all placeholders in their values expanded.
+// Values without placeholders are kept as they are.
+func (e *EnvExpansion) expandLabels(labels cdv2.Labels) (cdv2.Labels, error) {
+	expanded := make(cdv2.Labels, len(labels))
+	for i, label := range labels {
+		expanded[i] = label
+		if !envPlaceholderRegexp.Match(label.Value) {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(label.Value, &value); err != nil {
+			return nil, fmt.Errorf("unable to decode value of label %q: %w", label.Name, err)
+		}
+		value, err := e.expandValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to expand value of label %q: %w", label.Name, err)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode value of label %q: %w", label.Name, err)
+		}
+		expanded[i].Value = data
+	}
+	return expanded, nil
+}
+
+// expandValue expands the placeholders of all strings of a decoded json value.
+func (e *EnvExpansion) expandValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return e.expandString(v)
+	case []interface{}:
+		for i := range v {
+			expanded, err := e.expandValue(v[i])
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+		return v, nil
+	case map[string]interface{}:
+		for key := range v {
+			expanded, err := e.expandValue(v[key])
+			if err != nil {
+				return nil, err
+			}
+			v[key] = expanded
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+func (e *EnvExpansion) expandString(s string) (string, error) {
+	var missing []string
+	expanded := envPlaceholderRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := envPlaceholderRegexp.FindStringSubmatch(placeholder)[1]
+		value, ok := e.Lookup(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if e.Strict && len(missing) != 0 {
+		return "", fmt.Errorf("environment variables %s are not set", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
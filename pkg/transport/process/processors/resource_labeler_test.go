@@ -8,6 +8,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"os"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	. "github.com/onsi/ginkgo"
@@ -73,5 +74,128 @@ var _ = Describe("resourceLabeler", func() {
 			Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
 		})
 
+		Context("with an existing label", func() {
+
+			var (
+				res    cdv2.Resource
+				newFoo cdv2.Label
+			)
+
+			BeforeEach(func() {
+				res = cdv2.Resource{
+					IdentityObjectMeta: cdv2.IdentityObjectMeta{
+						Name:    "my-res",
+						Version: "v0.1.0",
+						Type:    "ociImage",
+						Labels: cdv2.Labels{
+							{Name: "foo", Value: json.RawMessage(`"old"`)},
+							{Name: "bar", Value: json.RawMessage(`"true"`)},
+						},
+					},
+				}
+				newFoo = cdv2.Label{Name: "foo", Value: json.RawMessage(`"new"`)}
+			})
+
+			process := func(mode processors.LabelMode) cdv2.Resource {
+				inBuf := bytes.NewBuffer([]byte{})
+				Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, bytes.NewReader([]byte("resource-blob")), inBuf)).To(Succeed())
+
+				outBuf := bytes.NewBuffer([]byte{})
+				p := processors.NewResourceLabelerWithMode(mode, newFoo)
+				Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+				_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualResBlobReader.Close()).To(Succeed())
+				return actualRes
+			}
+
+			It("should append a duplicate label in append mode", func() {
+				actualRes := process(processors.LabelModeAppend)
+				Expect(actualRes.Labels).To(HaveLen(3))
+				Expect(actualRes.Labels[0].Value).To(MatchJSON(`"old"`))
+				Expect(actualRes.Labels[2].Name).To(Equal("foo"))
+				Expect(actualRes.Labels[2].Value).To(MatchJSON(`"new"`))
+			})
+
+			It("should replace the existing label in place in overwrite mode", func() {
+				actualRes := process(processors.LabelModeOverwrite)
+				Expect(actualRes.Labels).To(HaveLen(2))
+				Expect(actualRes.Labels[0].Name).To(Equal("foo"))
+				Expect(actualRes.Labels[0].Value).To(MatchJSON(`"new"`))
+				Expect(actualRes.Labels[1].Name).To(Equal("bar"))
+			})
+
+		})
+
+		Context("with environment variable expansion", func() {
+
+			process := func(expansion processors.EnvExpansion, labels ...cdv2.Label) (cdv2.Resource, error) {
+				res := cdv2.Resource{
+					IdentityObjectMeta: cdv2.IdentityObjectMeta{
+						Name:    "my-res",
+						Version: "v0.1.0",
+						Type:    "ociImage",
+					},
+				}
+				inBuf := bytes.NewBuffer([]byte{})
+				Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, bytes.NewReader([]byte("resource-blob")), inBuf)).To(Succeed())
+
+				outBuf := bytes.NewBuffer([]byte{})
+				p := processors.NewResourceLabelerWithEnvExpansion(processors.LabelModeAppend, expansion, labels...)
+				if err := p.Process(context.TODO(), inBuf, outBuf); err != nil {
+					return cdv2.Resource{}, err
+				}
+
+				_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualResBlobReader.Close()).To(Succeed())
+				return actualRes, nil
+			}
+
+			It("should expand placeholders from the environment", func() {
+				Expect(os.Setenv("COMPONENT_CLI_TEST_COMMIT", "abc123")).To(Succeed())
+				defer os.Unsetenv("COMPONENT_CLI_TEST_COMMIT")
+
+				actualRes, err := process(processors.EnvExpansion{},
+					cdv2.Label{Name: "commit", Value: json.RawMessage(`"sha-${COMPONENT_CLI_TEST_COMMIT}"`)})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualRes.Labels).To(HaveLen(1))
+				Expect(actualRes.Labels[0].Value).To(MatchJSON(`"sha-abc123"`))
+			})
+
+			It("should expand placeholders in nested values with an injected lookup", func() {
+				lookup := func(key string) (string, bool) {
+					vars := map[string]string{"BUILD": "42", "COMMIT": "abc123"}
+					value, ok := vars[key]
+					return value, ok
+				}
+				build := cdv2.Label{Name: "build", Value: json.RawMessage(`{"number":"${BUILD}","commits":["${COMMIT}"],"final":true}`)}
+				static := cdv2.Label{Name: "static", Value: json.RawMessage(`{"b": 1, "a": "$HOME"}`)}
+
+				actualRes, err := process(processors.EnvExpansion{Lookup: lookup}, build, static)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualRes.Labels).To(HaveLen(2))
+				Expect(actualRes.Labels[0].Value).To(MatchJSON(`{"number":"42","commits":["abc123"],"final":true}`))
+				Expect(actualRes.Labels[1].Value).To(MatchJSON(`{"b": 1, "a": "$HOME"}`))
+			})
+
+			It("should expand unset variables to an empty string", func() {
+				lookup := func(string) (string, bool) { return "", false }
+				actualRes, err := process(processors.EnvExpansion{Lookup: lookup},
+					cdv2.Label{Name: "commit", Value: json.RawMessage(`"sha-${COMMIT}"`)})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualRes.Labels[0].Value).To(MatchJSON(`"sha-"`))
+			})
+
+			It("should fail for unset variables in strict mode", func() {
+				lookup := func(string) (string, bool) { return "", false }
+				_, err := process(processors.EnvExpansion{Lookup: lookup, Strict: true},
+					cdv2.Label{Name: "commit", Value: json.RawMessage(`"${COMMIT}-${BUILD}"`)})
+				Expect(err).To(MatchError(`unable to expand value of label "commit": environment variables COMMIT, BUILD are not set`))
+			})
+
+		})
+
 	})
 })
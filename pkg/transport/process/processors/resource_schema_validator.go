@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type resourceSchemaValidator struct {
+	schemas map[string]*gojsonschema.Schema
+}
+
+// NewResourceSchemaProcessor returns a processor that validates resources against the JSON Schema
+// that is defined for the resource type. schemasByType maps a resource type to a JSON Schema document.
+// Resources of types without a schema are forwarded without validation.
+func NewResourceSchemaProcessor(schemasByType map[string]string) (process.ResourceStreamProcessor, error) {
+	schemas := map[string]*gojsonschema.Schema{}
+	for resType, schema := range schemasByType {
+		compiled, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+		if err != nil {
+			return nil, fmt.Errorf("unable to compile schema for resource type %q: %w", resType, err)
+		}
+		schemas[resType] = compiled
+	}
+
+	obj := resourceSchemaValidator{
+		schemas: schemas,
+	}
+	return &obj, nil
+}
+
+func (p *resourceSchemaValidator) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if schema, ok := p.schemas[res.Type]; ok {
+		data, err := json.Marshal(res)
+		if err != nil {
+			return fmt.Errorf("unable to marshal resource: %w", err)
+		}
+		result, err := schema.Validate(gojsonschema.NewBytesLoader(data))
+		if err != nil {
+			return fmt.Errorf("unable to validate resource %q: %w", res.Name, err)
+		}
+		if !result.Valid() {
+			errs := make([]string, len(result.Errors()))
+			for i, resErr := range result.Errors() {
+				errs[i] = fmt.Sprintf("%s: %s", resErr.Field(), resErr.Description())
+			}
+			return fmt.Errorf("resource %q of type %q is invalid:\n%s", res.Name, res.Type, strings.Join(errs, "\n"))
+		}
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
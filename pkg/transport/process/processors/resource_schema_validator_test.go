@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("resourceSchemaValidator", func() {
+
+	const labelSchema = `{
+  "type": "object",
+  "required": ["labels"],
+  "properties": {
+    "labels": {
+      "type": "array",
+      "minItems": 1
+    }
+  }
+}`
+
+	process := func(schemas map[string]string, res cdv2.Resource) (cdv2.Resource, error) {
+		resBytes := []byte("resource-blob")
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+		p, err := processors.NewResourceSchemaProcessor(schemas)
+		Expect(err).ToNot(HaveOccurred())
+		outBuf := bytes.NewBuffer([]byte{})
+		if err := p.Process(context.TODO(), inBuf, outBuf); err != nil {
+			return cdv2.Resource{}, err
+		}
+
+		_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		defer actualResBlobReader.Close()
+		actualResBlobBuf := bytes.NewBuffer([]byte{})
+		_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
+		return actualRes, nil
+	}
+
+	newResource := func(resType string, labels ...cdv2.Label) cdv2.Resource {
+		return cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    resType,
+				Labels:  labels,
+			},
+			Relation: cdv2.ExternalRelation,
+		}
+	}
+
+	It("should forward a valid resource", func() {
+		res := newResource(cdv2.OCIImageType, cdv2.Label{Name: "my-label", Value: []byte(`"val"`)})
+		actualRes, err := process(map[string]string{cdv2.OCIImageType: labelSchema}, res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualRes.Name).To(Equal(res.Name))
+		Expect(actualRes.Labels).To(HaveLen(1))
+	})
+
+	It("should return an error with the invalid path for an invalid resource", func() {
+		res := newResource(cdv2.OCIImageType)
+		_, err := process(map[string]string{cdv2.OCIImageType: labelSchema}, res)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("labels"))
+	})
+
+	It("should forward resources of types without a schema", func() {
+		res := newResource("helm.io/chart")
+		actualRes, err := process(map[string]string{cdv2.OCIImageType: labelSchema}, res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualRes.Name).To(Equal(res.Name))
+	})
+
+	It("should return an error for an invalid schema", func() {
+		_, err := processors.NewResourceSchemaProcessor(map[string]string{cdv2.OCIImageType: `{"type": 1}`})
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type versionAligner struct {
+	alignVersions bool
+}
+
+// NewVersionAlignmentProcessor returns a processor that enforces that the version of a local resource
+// equals the version of its component.
+// If alignVersions is set, the version of a divergent resource is set to the component version.
+// Otherwise the processor fails for divergent resources. The resource blob is forwarded unchanged.
+func NewVersionAlignmentProcessor(alignVersions bool) process.ResourceStreamProcessor {
+	obj := versionAligner{
+		alignVersions: alignVersions,
+	}
+	return &obj
+}
+
+func (p *versionAligner) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if res.Relation == cdv2.LocalRelation && res.Version != cd.Version {
+		if !p.alignVersions {
+			return fmt.Errorf("version %q of local resource %q does not match component version %q", res.Version, res.Name, cd.Version)
+		}
+		res.Version = cd.Version
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("versionAligner", func() {
+
+	newComponentDescriptor := func() cdv2.ComponentDescriptor {
+		cd := cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/my-component"
+		cd.Version = "v0.2.0"
+		return cd
+	}
+
+	newResource := func(name, version string, relation cdv2.ResourceRelation) cdv2.Resource {
+		return cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    name,
+				Version: version,
+				Type:    cdv2.OCIImageType,
+			},
+			Relation: relation,
+		}
+	}
+
+	process := func(alignVersions bool, res cdv2.Resource) (cdv2.Resource, error) {
+		resBytes := []byte("resource-blob")
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(newComponentDescriptor(), res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+		outBuf := bytes.NewBuffer([]byte{})
+		p := processors.NewVersionAlignmentProcessor(alignVersions)
+		if err := p.Process(context.TODO(), inBuf, outBuf); err != nil {
+			return cdv2.Resource{}, err
+		}
+
+		_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		defer actualResBlobReader.Close()
+		actualResBlobBuf := bytes.NewBuffer([]byte{})
+		_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
+		return actualRes, nil
+	}
+
+	It("should forward a local resource with the component version", func() {
+		res, err := process(false, newResource("my-res", "v0.2.0", cdv2.LocalRelation))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.Version).To(Equal("v0.2.0"))
+	})
+
+	It("should ignore external resources", func() {
+		res, err := process(false, newResource("my-res", "v1.0.0", cdv2.ExternalRelation))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.Version).To(Equal("v1.0.0"))
+	})
+
+	It("should fail for a local resource with a divergent version", func() {
+		_, err := process(false, newResource("my-res", "v0.1.0", cdv2.LocalRelation))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("my-res"))
+	})
+
+	It("should align the version of a local resource", func() {
+		res, err := process(true, newResource("my-res", "v0.1.0", cdv2.LocalRelation))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.Version).To(Equal("v0.2.0"))
+	})
+
+})
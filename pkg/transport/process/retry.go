@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetryOptions defines how an operation is retried.
+// The zero value runs an operation exactly once without a timeout.
+type RetryOptions struct {
+	// Retries is the number of retries after a failed attempt.
+	Retries int
+	// Backoff is the duration that is waited before the first retry.
+	// The duration is doubled for every further retry.
+	Backoff time.Duration
+	// Timeout is the timeout of a single attempt. Attempts have no timeout if it is 0.
+	Timeout time.Duration
+}
+
+// Retry runs the operation until it succeeds, all retries failed or the context is done.
+// The error of the last attempt is returned.
+func Retry(ctx context.Context, opts RetryOptions, op func(ctx context.Context) error) error {
+	backoff := opts.Backoff
+	var err error
+	for attempt := 0; attempt <= opts.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w (last error: %s)", ctx.Err(), err.Error())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if err = runAttempt(ctx, opts.Timeout, op); err == nil {
+			return nil
+		}
+	}
+	if opts.Retries > 0 {
+		return fmt.Errorf("failed after %d attempts: %w", opts.Retries+1, err)
+	}
+	return err
+}
+
+func runAttempt(ctx context.Context, timeout time.Duration, op func(ctx context.Context) error) error {
+	if timeout == 0 {
+		return op(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return op(ctx)
+}
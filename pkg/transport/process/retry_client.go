@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// retryingClient is an oci client that retries all registry operations.
+type retryingClient struct {
+	ociclient.Client
+	opts RetryOptions
+}
+
+// NewRetryingClient returns an oci client that retries the registry operations of the given client,
+// e.g. the fetches and uploads of resource blobs by downloaders and uploaders.
+// The client is returned unchanged if the options define neither retries nor a timeout.
+// Pushed blobs are read again for every attempt, so the stores of the push options must be readable multiple times.
+func NewRetryingClient(client ociclient.Client, opts RetryOptions) ociclient.Client {
+	if opts.Retries == 0 && opts.Timeout == 0 {
+		return client
+	}
+	return &retryingClient{
+		Client: client,
+		opts:   opts,
+	}
+}
+
+func (c *retryingClient) Resolve(ctx context.Context, ref string) (name string, desc ocispecv1.Descriptor, err error) {
+	err = Retry(ctx, c.opts, func(ctx context.Context) error {
+		name, desc, err = c.Client.Resolve(ctx, ref)
+		return err
+	})
+	return name, desc, err
+}
+
+// Fetch fetches the blob into a temporary file that is only copied to the writer after a successful attempt,
+// so that the writer never receives the partial blob of a failed attempt.
+func (c *retryingClient) Fetch(ctx context.Context, ref string, desc ocispecv1.Descriptor, writer io.Writer) error {
+	tmpfile, err := ioutil.TempFile("", "")
+	if err != nil {
+		return fmt.Errorf("unable to create tempfile: %w", err)
+	}
+	defer func() {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+	}()
+
+	err = Retry(ctx, c.opts, func(ctx context.Context) error {
+		if _, err := tmpfile.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+		}
+		if err := tmpfile.Truncate(0); err != nil {
+			return fmt.Errorf("unable to truncate tempfile: %w", err)
+		}
+		return c.Client.Fetch(ctx, ref, desc, tmpfile)
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmpfile.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+	}
+	if _, err := io.Copy(writer, tmpfile); err != nil {
+		return fmt.Errorf("unable to copy blob: %w", err)
+	}
+	return nil
+}
+
+func (c *retryingClient) PushBlob(ctx context.Context, ref string, desc ocispecv1.Descriptor, opts ...ociclient.PushOption) error {
+	return Retry(ctx, c.opts, func(ctx context.Context) error {
+		return c.Client.PushBlob(ctx, ref, desc, opts...)
+	})
+}
+
+func (c *retryingClient) GetRawManifest(ctx context.Context, ref string) (desc ocispecv1.Descriptor, data []byte, err error) {
+	err = Retry(ctx, c.opts, func(ctx context.Context) error {
+		desc, data, err = c.Client.GetRawManifest(ctx, ref)
+		return err
+	})
+	return desc, data, err
+}
+
+func (c *retryingClient) PushRawManifest(ctx context.Context, ref string, desc ocispecv1.Descriptor, rawManifest []byte, opts ...ociclient.PushOption) error {
+	return Retry(ctx, c.opts, func(ctx context.Context) error {
+		return c.Client.PushRawManifest(ctx, ref, desc, rawManifest, opts...)
+	})
+}
+
+func (c *retryingClient) GetManifest(ctx context.Context, ref string) (manifest *ocispecv1.Manifest, err error) {
+	err = Retry(ctx, c.opts, func(ctx context.Context) error {
+		manifest, err = c.Client.GetManifest(ctx, ref)
+		return err
+	})
+	return manifest, err
+}
+
+func (c *retryingClient) PushManifest(ctx context.Context, ref string, manifest *ocispecv1.Manifest, opts ...ociclient.PushOption) error {
+	return Retry(ctx, c.opts, func(ctx context.Context) error {
+		return c.Client.PushManifest(ctx, ref, manifest, opts...)
+	})
+}
+
+func (c *retryingClient) GetOCIArtifact(ctx context.Context, ref string) (artifact *oci.Artifact, err error) {
+	err = Retry(ctx, c.opts, func(ctx context.Context) error {
+		artifact, err = c.Client.GetOCIArtifact(ctx, ref)
+		return err
+	})
+	return artifact, err
+}
+
+func (c *retryingClient) PushOCIArtifact(ctx context.Context, ref string, artifact *oci.Artifact, opts ...ociclient.PushOption) error {
+	return Retry(ctx, c.opts, func(ctx context.Context) error {
+		return c.Client.PushOCIArtifact(ctx, ref, artifact, opts...)
+	})
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package process_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/transport/process"
+)
+
+// flakyClient is an oci client whose blob fetches and uploads fail the first failures calls.
+// Failed fetches write a partial blob.
+type flakyClient struct {
+	ociclient.Client
+	failures int
+	calls    int
+	blob     []byte
+	pushed   [][]byte
+}
+
+func (c *flakyClient) Fetch(_ context.Context, _ string, _ ocispecv1.Descriptor, writer io.Writer) error {
+	c.calls++
+	if c.calls <= c.failures {
+		if _, err := writer.Write(c.blob[:len(c.blob)/2]); err != nil {
+			return err
+		}
+		return errors.New("connection reset")
+	}
+	_, err := writer.Write(c.blob)
+	return err
+}
+
+func (c *flakyClient) PushBlob(_ context.Context, _ string, desc ocispecv1.Descriptor, opts ...ociclient.PushOption) error {
+	c.calls++
+	options := &ociclient.PushOptions{}
+	options.ApplyOptions(opts)
+	reader, err := options.Store.Get(desc)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	c.pushed = append(c.pushed, data)
+	if c.calls <= c.failures {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+var _ = Describe("RetryingClient", func() {
+
+	retry := process.RetryOptions{Retries: 2, Backoff: time.Millisecond}
+
+	It("should not wrap the client if nothing is retried", func() {
+		client := &flakyClient{}
+		Expect(process.NewRetryingClient(client, process.RetryOptions{})).To(BeIdenticalTo(client))
+	})
+
+	It("should retry a failed blob fetch and only write the complete blob", func() {
+		fake := &flakyClient{failures: 2, blob: []byte("complete blob")}
+		client := process.NewRetryingClient(fake, retry)
+
+		var buf bytes.Buffer
+		Expect(client.Fetch(context.TODO(), "example.com/repo:v1", ocispecv1.Descriptor{}, &buf)).To(Succeed())
+		Expect(fake.calls).To(Equal(3))
+		Expect(buf.String()).To(Equal("complete blob"))
+	})
+
+	It("should return the error of the last fetch after the configured number of retries", func() {
+		fake := &flakyClient{failures: 10, blob: []byte("complete blob")}
+		client := process.NewRetryingClient(fake, retry)
+
+		var buf bytes.Buffer
+		err := client.Fetch(context.TODO(), "example.com/repo:v1", ocispecv1.Descriptor{}, &buf)
+		Expect(err).To(MatchError(ContainSubstring("failed after 3 attempts: connection reset")))
+		Expect(fake.calls).To(Equal(3))
+		Expect(buf.Len()).To(Equal(0))
+	})
+
+	It("should retry a failed blob upload with the complete blob", func() {
+		fake := &flakyClient{failures: 1}
+		client := process.NewRetryingClient(fake, retry)
+
+		blob := bytes.NewReader([]byte("blob"))
+		store := ociclient.GenericStore(func(_ context.Context, _ ocispecv1.Descriptor, writer io.Writer) error {
+			if _, err := blob.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			_, err := io.Copy(writer, blob)
+			return err
+		})
+		Expect(client.PushBlob(context.TODO(), "example.com/repo:v1", ocispecv1.Descriptor{}, ociclient.WithStore(store))).To(Succeed())
+		Expect(fake.calls).To(Equal(2))
+		Expect(fake.pushed).To(Equal([][]byte{[]byte("blob"), []byte("blob")}))
+	})
+
+})
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package process_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+)
+
+// flakyOperation fails the first failures calls.
+type flakyOperation struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyOperation) Run(ctx context.Context) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("temporary failure")
+	}
+	return nil
+}
+
+var _ = Describe("Retry", func() {
+
+	It("should run an operation exactly once by default", func() {
+		op := &flakyOperation{failures: 1}
+		err := process.Retry(context.TODO(), process.RetryOptions{}, op.Run)
+		Expect(err).To(MatchError("temporary failure"))
+		Expect(op.calls).To(Equal(1))
+	})
+
+	It("should retry a flaky operation until it succeeds", func() {
+		op := &flakyOperation{failures: 2}
+		Expect(process.Retry(context.TODO(), process.RetryOptions{Retries: 3, Backoff: time.Millisecond}, op.Run)).To(Succeed())
+		Expect(op.calls).To(Equal(3))
+	})
+
+	It("should return the last error after the configured number of retries", func() {
+		op := &flakyOperation{failures: 10}
+		err := process.Retry(context.TODO(), process.RetryOptions{Retries: 2, Backoff: time.Millisecond}, op.Run)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("failed after 3 attempts: temporary failure"))
+		Expect(op.calls).To(Equal(3))
+	})
+
+	It("should apply the timeout to every attempt", func() {
+		calls := 0
+		err := process.Retry(context.TODO(), process.RetryOptions{Retries: 1, Timeout: 10 * time.Millisecond}, func(ctx context.Context) error {
+			calls++
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should stop retrying if the context is done", func() {
+		ctx, cancel := context.WithCancel(context.TODO())
+		op := &flakyOperation{failures: 10}
+		err := process.Retry(ctx, process.RetryOptions{Retries: 5, Backoff: time.Hour}, func(ctx context.Context) error {
+			defer cancel()
+			return op.Run(ctx)
+		})
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(op.calls).To(Equal(1))
+	})
+
+})
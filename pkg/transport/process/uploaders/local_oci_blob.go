@@ -98,10 +98,14 @@ func (d *localOCIBlobUploader) Process(ctx context.Context, r io.Reader, w io.Wr
 	return nil
 }
 
-func (d *localOCIBlobUploader) uploadLocalOCIBlob(ctx context.Context, cd *cdv2.ComponentDescriptor, res cdv2.Resource, r io.Reader, desc ocispecv1.Descriptor) error {
+func (d *localOCIBlobUploader) uploadLocalOCIBlob(ctx context.Context, cd *cdv2.ComponentDescriptor, res cdv2.Resource, r io.ReadSeeker, desc ocispecv1.Descriptor) error {
 	targetRef := utils.CalculateBlobUploadRef(d.targetCtx, cd.Name, cd.Version)
 
+	// the blob is read from the beginning for every attempt of a retried upload.
 	store := ociclient.GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("unable to seek to beginning of blob: %w", err)
+		}
 		_, err := io.Copy(writer, r)
 		return err
 	})
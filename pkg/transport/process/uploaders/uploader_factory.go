@@ -29,9 +29,11 @@ const (
 // - Add Go file to uploaders package which contains the source code of the new uploader
 // - Add string constant for new uploader type -> will be used in UploaderFactory.Create()
 // - Add source code for creating new uploader to UploaderFactory.Create() method
-func NewUploaderFactory(client ociclient.Client, ocicache cache.Cache, targetCtx cdv2.OCIRegistryRepository) *UploaderFactory {
+//
+// The registry operations of the created uploaders are retried with the given retry options.
+func NewUploaderFactory(client ociclient.Client, ocicache cache.Cache, targetCtx cdv2.OCIRegistryRepository, retry process.RetryOptions) *UploaderFactory {
 	return &UploaderFactory{
-		client:    client,
+		client:    process.NewRetryingClient(client, retry),
 		cache:     ocicache,
 		targetCtx: targetCtx,
 	}
@@ -31,6 +31,8 @@ const (
 // WriteProcessorMessage writes a component descriptor, resource and resource blob as a processor
 // message (tar archive with fixed filenames for component descriptor, resource, and resource blob)
 // which can be consumed by processors.
+// The resource blob is streamed in chunks. Seekable readers, like the one returned by ReadProcessorMessage,
+// are copied directly, other readers are spooled to a temporary file as the size has to be known upfront.
 func WriteProcessorMessage(cd cdv2.ComponentDescriptor, res cdv2.Resource, resourceBlobReader io.Reader, w io.Writer) error {
 	tw := tar.NewWriter(w)
 	defer tw.Close()
@@ -66,12 +68,21 @@ func WriteProcessorMessage(cd cdv2.ComponentDescriptor, res cdv2.Resource, resou
 // (tar archive with fixed filenames for component descriptor, resource, and resource blob) which is
 // produced by processors. The resource blob reader can be nil. If a non-nil value is returned, it must
 // be closed by the caller.
+// The resource blob is spooled to a temporary file which is removed when the reader is closed.
 func ReadProcessorMessage(r io.Reader) (*cdv2.ComponentDescriptor, cdv2.Resource, io.ReadSeekCloser, error) {
 	tr := tar.NewReader(r)
 
 	var cd *cdv2.ComponentDescriptor
 	var res cdv2.Resource
-	var f *os.File
+	var f *tempFile
+
+	// fail closes and removes the spooled resource blob on errors.
+	fail := func(err error) (*cdv2.ComponentDescriptor, cdv2.Resource, io.ReadSeekCloser, error) {
+		if f != nil {
+			_ = f.Close()
+		}
+		return nil, cdv2.Resource{}, nil, err
+	}
 
 	for {
 		header, err := tr.Next()
@@ -79,24 +90,29 @@ func ReadProcessorMessage(r io.Reader) (*cdv2.ComponentDescriptor, cdv2.Resource
 			if err == io.EOF {
 				break
 			}
-			return nil, cdv2.Resource{}, nil, fmt.Errorf("unable to read tar header: %w", err)
+			return fail(fmt.Errorf("unable to read tar header: %w", err))
 		}
 
 		switch header.Name {
 		case ResourceFile:
 			if res, err = readResource(tr); err != nil {
-				return nil, cdv2.Resource{}, nil, fmt.Errorf("unable to read %s: %w", ResourceFile, err)
+				return fail(fmt.Errorf("unable to read %s: %w", ResourceFile, err))
 			}
 		case ComponentDescriptorFile:
 			if cd, err = readComponentDescriptor(tr); err != nil {
-				return nil, cdv2.Resource{}, nil, fmt.Errorf("unable to read %s: %w", ComponentDescriptorFile, err)
+				return fail(fmt.Errorf("unable to read %s: %w", ComponentDescriptorFile, err))
 			}
 		case ResourceBlobFile:
-			if f, err = ioutil.TempFile("", ""); err != nil {
-				return nil, cdv2.Resource{}, nil, fmt.Errorf("unable to create tempfile: %w", err)
+			if f != nil {
+				_ = f.Close()
 			}
+			tmpfile, err := ioutil.TempFile("", "")
+			if err != nil {
+				return fail(fmt.Errorf("unable to create tempfile: %w", err))
+			}
+			f = &tempFile{File: tmpfile}
 			if _, err := io.Copy(f, tr); err != nil {
-				return nil, cdv2.Resource{}, nil, fmt.Errorf("unable to read %s: %w", ResourceBlobFile, err)
+				return fail(fmt.Errorf("unable to read %s: %w", ResourceBlobFile, err))
 			}
 		}
 	}
@@ -106,12 +122,25 @@ func ReadProcessorMessage(r io.Reader) (*cdv2.ComponentDescriptor, cdv2.Resource
 	}
 
 	if _, err := f.Seek(0, io.SeekStart); err != nil {
-		return nil, cdv2.Resource{}, nil, fmt.Errorf("unable to seek to beginning of resource blob file: %w", err)
+		return fail(fmt.Errorf("unable to seek to beginning of resource blob file: %w", err))
 	}
 
 	return cd, res, f, nil
 }
 
+// tempFile is a temporary file that is removed when it is closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	closeErr := f.File.Close()
+	if err := os.Remove(f.Name()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to remove temporary file: %w", err)
+	}
+	return closeErr
+}
+
 func readResource(r *tar.Reader) (cdv2.Resource, error) {
 	buf := bytes.NewBuffer([]byte{})
 	if _, err := io.Copy(buf, r); err != nil {
@@ -5,13 +5,18 @@ package utils_test
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"os"
+	"runtime"
 	"strings"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
 	"github.com/gardener/component-cli/pkg/transport/process/utils"
 )
 
@@ -51,8 +56,156 @@ var _ = Describe("util", func() {
 			_, err = io.Copy(resourceBlobBuf, resourceBlobReader)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(resourceBlobBuf.String()).To(Equal(resourceData))
+			Expect(resourceBlobReader.Close()).To(Succeed())
+		})
+
+		It("should remove the spooled resource blob when the reader is closed", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+			cd := cdv2.ComponentDescriptor{}
+
+			processMsgBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, strings.NewReader("test-data"), processMsgBuf)).To(Succeed())
+
+			_, _, resourceBlobReader, err := utils.ReadProcessorMessage(processMsgBuf)
+			Expect(err).ToNot(HaveOccurred())
+			f, ok := resourceBlobReader.(interface{ Name() string })
+			Expect(ok).To(BeTrue())
+			_, err = os.Stat(f.Name())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resourceBlobReader.Close()).To(Succeed())
+			_, err = os.Stat(f.Name())
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("should remove the spooled resource blob if the message cannot be read", func() {
+			tmpDir, err := os.MkdirTemp("", "processor-message-")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(tmpDir)
+			previousTmpDir, isSet := os.LookupEnv("TMPDIR")
+			Expect(os.Setenv("TMPDIR", tmpDir)).To(Succeed())
+			defer func() {
+				if isSet {
+					os.Setenv("TMPDIR", previousTmpDir)
+				} else {
+					os.Unsetenv("TMPDIR")
+				}
+			}()
+
+			processMsgBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, cdv2.Resource{}, strings.NewReader(strings.Repeat("x", 4096)), processMsgBuf)).To(Succeed())
+			// the message is truncated in the middle of the resource blob.
+			truncated := bytes.NewReader(processMsgBuf.Bytes()[:processMsgBuf.Len()-2048])
+
+			_, _, resourceBlobReader, err := utils.ReadProcessorMessage(truncated)
+			Expect(err).To(HaveOccurred())
+			Expect(resourceBlobReader).To(BeNil())
+			entries, err := os.ReadDir(tmpDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("should stream a large resource blob through a chain of processors without buffering it in memory", func() {
+			const blobSize = 64 * 1024 * 1024
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{res},
+				},
+			}
+			tmpDir, err := os.MkdirTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(tmpDir)
+			oldTmpDir, hasTmpDir := os.LookupEnv("TMPDIR")
+			Expect(os.Setenv("TMPDIR", tmpDir)).To(Succeed())
+			defer func() {
+				if hasTmpDir {
+					_ = os.Setenv("TMPDIR", oldTmpDir)
+					return
+				}
+				_ = os.Unsetenv("TMPDIR")
+			}()
+
+			chain := []process.ResourceStreamProcessor{
+				processors.NewResourceLabeler(cdv2.Label{Name: "first", Value: []byte(`"a"`)}),
+				processors.NewResourceLabeler(cdv2.Label{Name: "second", Value: []byte(`"b"`)}),
+			}
+
+			blob := &generatingReader{size: blobSize}
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+
+			input, inputWriter := io.Pipe()
+			go func() {
+				_ = inputWriter.CloseWithError(utils.WriteProcessorMessage(cd, res, blob, inputWriter))
+			}()
+
+			var r io.Reader = input
+			errs := make(chan error, len(chain))
+			for _, p := range chain {
+				pr, pw := io.Pipe()
+				go func(p process.ResourceStreamProcessor, in io.Reader, out *io.PipeWriter) {
+					err := p.Process(context.TODO(), in, out)
+					errs <- err
+					_ = out.CloseWithError(err)
+				}(p, r, pw)
+				r = pr
+			}
+
+			_, actualRes, resourceBlobReader, err := utils.ReadProcessorMessage(r)
+			Expect(err).ToNot(HaveOccurred())
+			for range chain {
+				Expect(<-errs).ToNot(HaveOccurred())
+			}
+
+			n, err := io.Copy(io.Discard, resourceBlobReader)
+			Expect(err).ToNot(HaveOccurred())
+			runtime.ReadMemStats(&after)
+
+			Expect(n).To(BeEquivalentTo(blobSize))
+			Expect(blob.read).To(BeEquivalentTo(blobSize))
+			Expect(actualRes.Labels).To(HaveLen(2))
+			Expect(after.TotalAlloc - before.TotalAlloc).To(BeNumerically("<", blobSize/4))
+
+			Expect(resourceBlobReader.Close()).To(Succeed())
+			entries, err := os.ReadDir(tmpDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
 		})
 
 	})
 
 })
+
+// generatingReader lazily generates size bytes of data and counts the bytes that have been read.
+type generatingReader struct {
+	size int64
+	read int64
+}
+
+func (r *generatingReader) Read(p []byte) (int, error) {
+	if r.read >= r.size {
+		return 0, io.EOF
+	}
+	if remaining := r.size - r.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	for i := range p {
+		p[i] = byte(r.read + int64(i))
+	}
+	r.read += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines that are printed around a change.
+const diffContextLines = 3
+
+// diffLine is a line of a diff with its operation (' ', '-' or '+').
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// WriteUnifiedDiff writes the line differences of the old and new data in the unified diff format.
+// Nothing is written if the data is equal.
+func WriteUnifiedDiff(w io.Writer, oldName, newName string, oldData, newData []byte) error {
+	lines := diffLines(splitLines(oldData), splitLines(newData))
+	changed := false
+	for _, line := range lines {
+		if line.op != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- %s\n+++ %s\n", oldName, newName)
+	oldLine, newLine := 1, 1
+	for start := 0; start < len(lines); {
+		// find the next change
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		// extend the hunk until the unchanged lines between two changes exceed the context of both
+		end := first
+		for i := first; i < len(lines); i++ {
+			if lines[i].op != ' ' {
+				end = i + 1
+				continue
+			}
+			if i-end >= 2*diffContextLines {
+				break
+			}
+		}
+		hunkStart := first - diffContextLines
+		if hunkStart < start {
+			hunkStart = start
+		}
+		hunkEnd := end + diffContextLines
+		if hunkEnd > len(lines) {
+			hunkEnd = len(lines)
+		}
+
+		// advance the line numbers to the beginning of the hunk
+		for _, line := range lines[start:hunkStart] {
+			oldLine, newLine = advanceLine(line.op, oldLine, newLine)
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[hunkStart:hunkEnd] {
+			oldCount, newCount = advanceLine(line.op, oldCount, newCount)
+		}
+		fmt.Fprintf(bw, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, line := range lines[hunkStart:hunkEnd] {
+			fmt.Fprintf(bw, "%c%s\n", line.op, line.text)
+		}
+		oldLine += oldCount
+		newLine += newCount
+		start = hunkEnd
+	}
+	return bw.Flush()
+}
+
+// diffLines computes the line operations to transform the old lines into the new lines
+// based on their longest common subsequence.
+func diffLines(oldLines, newLines []string) []diffLine {
+	// lcs[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]diffLine, 0, len(oldLines)+len(newLines))
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			lines = append(lines, diffLine{op: ' ', text: oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{op: '-', text: oldLines[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{op: '+', text: newLines[j]})
+			j++
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		lines = append(lines, diffLine{op: '-', text: oldLines[i]})
+	}
+	for ; j < len(newLines); j++ {
+		lines = append(lines, diffLine{op: '+', text: newLines[j]})
+	}
+	return lines
+}
+
+// advanceLine counts the line of the given operation for the old and the new data.
+func advanceLine(op byte, oldLine, newLine int) (int, int) {
+	switch op {
+	case '-':
+		return oldLine + 1, newLine
+	case '+':
+		return oldLine, newLine + 1
+	default:
+		return oldLine + 1, newLine + 1
+	}
+}
+
+// hunkRange formats the range of a hunk. Empty ranges start at the line before the hunk.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("WriteUnifiedDiff", func() {
+
+	It("should write nothing if the data is equal", func() {
+		var buf bytes.Buffer
+		Expect(utils.WriteUnifiedDiff(&buf, "a", "b", []byte("a\nb\n"), []byte("a\nb\n"))).To(Succeed())
+		Expect(buf.String()).To(BeEmpty())
+	})
+
+	It("should write changed lines with context", func() {
+		oldData := []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n")
+		newData := []byte("1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n")
+		var buf bytes.Buffer
+		Expect(utils.WriteUnifiedDiff(&buf, "old", "new", oldData, newData)).To(Succeed())
+		Expect(buf.String()).To(Equal(`--- old
++++ new
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -13,3 +13,4 @@
+ 13
+ 14
+ 15
++16
+`))
+	})
+
+	It("should write all lines of new data as added", func() {
+		var buf bytes.Buffer
+		Expect(utils.WriteUnifiedDiff(&buf, "old", "new", nil, []byte("a\nb\n"))).To(Succeed())
+		Expect(buf.String()).To(Equal("--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n"))
+	})
+
+})
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// ReplaceFile renames the file at src to dst and replaces an existing file at dst.
+// Some filesystems, e.g. in-memory filesystems, refuse to rename a file to an existing path,
+// on these the existing file is removed before the rename.
+func ReplaceFile(fs vfs.FileSystem, src, dst string) error {
+	err := fs.Rename(src, dst)
+	if err == nil || !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	if err := fs.Remove(dst); err != nil {
+		return err
+	}
+	return fs.Rename(src, dst)
+}
+
+// WriteFileAtomic writes the data to a temporary file next to the given path that then replaces the file,
+// so that readers never see a partially written file.
+func WriteFileAtomic(fs vfs.FileSystem, path string, data []byte, perm os.FileMode) error {
+	file, err := vfs.TempFile(fs, filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	tmpPath := file.Name()
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fs.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = ReplaceFile(fs, tmpPath, path)
+	}
+	if err != nil {
+		_ = fs.Remove(tmpPath)
+		return fmt.Errorf("unable to write %q: %w", path, err)
+	}
+	return nil
+}
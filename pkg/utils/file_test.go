@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils_test
+
+import (
+	"os"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("File", func() {
+
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(vfs.WriteFile(fs, "/new", []byte("new"), os.ModePerm)).To(Succeed())
+	})
+
+	It("should replace an existing file", func() {
+		Expect(vfs.WriteFile(fs, "/file", []byte("old"), os.ModePerm)).To(Succeed())
+		Expect(utils.ReplaceFile(fs, "/new", "/file")).To(Succeed())
+
+		data, err := vfs.ReadFile(fs, "/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("new"))
+		_, err = fs.Stat("/new")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should rename a file to a path that does not exist", func() {
+		Expect(utils.ReplaceFile(fs, "/new", "/file")).To(Succeed())
+
+		data, err := vfs.ReadFile(fs, "/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("new"))
+	})
+
+	It("should fail if the source does not exist", func() {
+		Expect(utils.ReplaceFile(fs, "/missing", "/file")).To(HaveOccurred())
+	})
+
+	It("should atomically replace a file without leaving temporary files", func() {
+		Expect(vfs.WriteFile(fs, "/file", []byte("old"), os.ModePerm)).To(Succeed())
+		Expect(utils.WriteFileAtomic(fs, "/file", []byte("content"), 0640)).To(Succeed())
+
+		data, err := vfs.ReadFile(fs, "/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("content"))
+		info, err := fs.Stat("/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0640)))
+		infos, err := vfs.ReadDir(fs, "/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(infos).To(HaveLen(2))
+	})
+
+})
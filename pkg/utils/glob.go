@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// HasGlobMeta returns whether the path contains any of the glob meta characters of filepath.Match.
+func HasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
+
+// Glob returns the names of all files of the filesystem that match the pattern like filepath.Glob.
+// The pattern syntax is the one of filepath.Match, the matches are sorted.
+// The only possible error is filepath.ErrBadPattern or an error of the filesystem.
+func Glob(fs vfs.FileSystem, pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	if !HasGlobMeta(pattern) {
+		if _, err := fs.Lstat(pattern); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return []string{pattern}, nil
+	}
+
+	dir, file := filepath.Split(pattern)
+	dir = cleanGlobPath(dir)
+	dirs := []string{dir}
+	if HasGlobMeta(dir) {
+		var err error
+		dirs, err = Glob(fs, dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var matches []string
+	for _, dir := range dirs {
+		info, err := fs.Stat(dir)
+		if err != nil || !info.IsDir() {
+			// like filepath.Glob, paths that are no directories cannot contain matches.
+			continue
+		}
+		infos, err := vfs.ReadDir(fs, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if matched, _ := filepath.Match(file, info.Name()); matched {
+				matches = append(matches, filepath.Join(dir, info.Name()))
+			}
+		}
+	}
+	return matches, nil
+}
+
+// cleanGlobPath prepares the directory of a pattern for the matching.
+func cleanGlobPath(path string) string {
+	switch path {
+	case "":
+		return "."
+	case string(filepath.Separator):
+		return path
+	default:
+		return path[:len(path)-1]
+	}
+}
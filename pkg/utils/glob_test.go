@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("Glob", func() {
+
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		for _, path := range []string{"/cas/a/ca.tar", "/cas/b/ca.tgz", "/cas/b/other.txt", "/cas/c.tar"} {
+			Expect(fs.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+			Expect(vfs.WriteFile(fs, path, []byte("data"), os.ModePerm)).To(Succeed())
+		}
+	})
+
+	It("should match the pattern per path segment", func() {
+		Expect(utils.Glob(fs, "/cas/*/ca.*")).To(Equal([]string{"/cas/a/ca.tar", "/cas/b/ca.tgz"}))
+		Expect(utils.Glob(fs, "/cas/*.tar")).To(Equal([]string{"/cas/c.tar"}))
+		Expect(utils.Glob(fs, "/cas/[ab]")).To(Equal([]string{"/cas/a", "/cas/b"}))
+	})
+
+	It("should return a path without meta characters if it exists", func() {
+		Expect(utils.Glob(fs, "/cas/c.tar")).To(Equal([]string{"/cas/c.tar"}))
+		Expect(utils.Glob(fs, "/cas/d.tar")).To(BeEmpty())
+	})
+
+	It("should not match below files", func() {
+		Expect(utils.Glob(fs, "/cas/*/*/x")).To(BeEmpty())
+	})
+
+	It("should reject malformed patterns", func() {
+		_, err := utils.Glob(fs, "/cas/[")
+		Expect(err).To(MatchError(filepath.ErrBadPattern))
+	})
+
+})
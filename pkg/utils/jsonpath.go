@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is a single step of a jsonpath expression.
+// Either a field of an object, an index of a list or a wildcard for all values.
+type jsonPathStep struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// JSONPath evaluates a simple jsonpath expression on a generic json object
+// as it is returned by json.Unmarshal into an interface{}.
+// Supported are field selectors (".name"), list indices ("[0]") and wildcards ("[*]" or ".*"),
+// optionally enclosed in braces and starting with "$", e.g. "{.component.resources[*].name}".
+// All matching values are returned.
+func JSONPath(obj interface{}, path string) ([]interface{}, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	current := []interface{}{obj}
+	for _, step := range steps {
+		next := []interface{}{}
+		for _, value := range current {
+			values, err := step.apply(value)
+			if err != nil {
+				return nil, fmt.Errorf("unable to evaluate %q: %w", path, err)
+			}
+			next = append(next, values...)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// FormatJSONPathValue formats a value that is returned by JSONPath.
+// Strings are returned as they are, all other values are encoded as json.
+func FormatJSONPathValue(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	expr := strings.TrimSpace(path)
+	if strings.HasPrefix(expr, "{") && strings.HasSuffix(expr, "}") {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	expr = strings.TrimPrefix(expr, "$")
+
+	steps := []jsonPathStep{}
+	for len(expr) != 0 {
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			end := strings.IndexAny(expr, ".[")
+			if end == -1 {
+				end = len(expr)
+			}
+			field := expr[:end]
+			expr = expr[end:]
+			if len(field) == 0 {
+				// allow a single "." that selects the root object
+				if len(expr) == 0 && len(steps) == 0 {
+					continue
+				}
+				return nil, fmt.Errorf("invalid jsonpath %q: empty field name", path)
+			}
+			if field == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+				continue
+			}
+			steps = append(steps, jsonPathStep{field: field})
+		case '[':
+			end := strings.Index(expr, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid jsonpath %q: missing closing bracket", path)
+			}
+			selector := strings.TrimSpace(expr[1:end])
+			expr = expr[end+1:]
+			if selector == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+				continue
+			}
+			if quoted := strings.Trim(selector, `'"`); len(quoted) == len(selector)-2 {
+				steps = append(steps, jsonPathStep{field: quoted})
+				continue
+			}
+			index, err := strconv.Atoi(selector)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: unsupported selector %q", path, selector)
+			}
+			steps = append(steps, jsonPathStep{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("invalid jsonpath %q: unexpected character %q", path, expr[0])
+		}
+	}
+	return steps, nil
+}
+
+func (s jsonPathStep) apply(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			values := make([]interface{}, 0, len(v))
+			for _, key := range sortedMapKeys(v) {
+				values = append(values, v[key])
+			}
+			return values, nil
+		}
+		if s.isIndex {
+			return nil, fmt.Errorf("unable to select index %d of an object", s.index)
+		}
+		field, ok := v[s.field]
+		if !ok {
+			return nil, fmt.Errorf("field %q not found", s.field)
+		}
+		return []interface{}{field}, nil
+	case []interface{}:
+		if s.wildcard {
+			return v, nil
+		}
+		if !s.isIndex {
+			return nil, fmt.Errorf("unable to select field %q of a list", s.field)
+		}
+		if s.index >= len(v) {
+			return nil, fmt.Errorf("index %d out of range of list with length %d", s.index, len(v))
+		}
+		return []interface{}{v[s.index]}, nil
+	default:
+		if s.wildcard {
+			return nil, fmt.Errorf("unable to select all values of %v", value)
+		}
+		if s.isIndex {
+			return nil, fmt.Errorf("unable to select index %d of %v", s.index, value)
+		}
+		return nil, fmt.Errorf("unable to select field %q of %v", s.field, value)
+	}
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package utils_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("JSONPath", func() {
+
+	var obj interface{}
+
+	BeforeEach(func() {
+		Expect(json.Unmarshal([]byte(`{
+  "component": {
+    "name": "example.com/a",
+    "resources": [
+      {"name": "image", "labels": [{"name": "team", "value": {"id": 1}}]},
+      {"name": "chart"}
+    ]
+  }
+}`), &obj)).To(Succeed())
+	})
+
+	It("should select fields, indices and wildcards", func() {
+		Expect(utils.JSONPath(obj, "{.component.name}")).To(Equal([]interface{}{"example.com/a"}))
+		Expect(utils.JSONPath(obj, "$.component.resources[1].name")).To(Equal([]interface{}{"chart"}))
+		Expect(utils.JSONPath(obj, ".component.resources[*].name")).To(Equal([]interface{}{"image", "chart"}))
+		Expect(utils.JSONPath(obj, ".component['name']")).To(Equal([]interface{}{"example.com/a"}))
+		Expect(utils.JSONPath(obj, ".")).To(Equal([]interface{}{obj}))
+	})
+
+	It("should format non-string values as json", func() {
+		values, err := utils.JSONPath(obj, ".component.resources[0].labels[0].value")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(values).To(HaveLen(1))
+		Expect(utils.FormatJSONPathValue(values[0])).To(Equal(`{"id":1}`))
+	})
+
+	It("should fail for missing fields and invalid expressions", func() {
+		_, err := utils.JSONPath(obj, ".component.resources[*].labels")
+		Expect(err).To(MatchError(ContainSubstring(`field "labels" not found`)))
+		_, err = utils.JSONPath(obj, ".component.resources[5]")
+		Expect(err).To(MatchError(ContainSubstring("index 5 out of range")))
+		_, err = utils.JSONPath(obj, ".component.resources[?(@.name)]")
+		Expect(err).To(MatchError(ContainSubstring("unsupported selector")))
+	})
+
+})
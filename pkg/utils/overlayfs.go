@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// NewOverlayFS creates a filesystem that writes all modifications to an in-memory layer on top of the base filesystem.
+// Relative paths are resolved against the working directory of the base filesystem.
+// The given paths of the base filesystem are hidden, so that they can be replaced in the in-memory layer,
+// e.g. a file can be replaced by a directory.
+func NewOverlayFS(base vfs.FileSystem, hidden ...string) (vfs.FileSystem, error) {
+	wd, err := base.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	overlay := &overlayFS{wd: wd}
+	if len(hidden) != 0 {
+		masked := &maskedFS{FileSystem: base, hidden: make([]string, len(hidden))}
+		for i, path := range hidden {
+			masked.hidden[i] = overlay.abs(base, path)
+		}
+		base = masked
+	}
+	overlay.FileSystem = layerfs.New(memoryfs.New(), base)
+	return overlay, nil
+}
+
+// overlayFS resolves relative paths against a working directory
+// as the layer filesystem resolves them against its root.
+type overlayFS struct {
+	vfs.FileSystem
+	wd string
+}
+
+var _ vfs.FileSystem = &overlayFS{}
+
+func (o *overlayFS) abs(fs vfs.FileSystem, name string) string {
+	if vfs.IsAbs(fs, name) {
+		return name
+	}
+	return vfs.Join(fs, o.wd, name)
+}
+
+func (o *overlayFS) Create(name string) (vfs.File, error) {
+	return o.FileSystem.Create(o.abs(o.FileSystem, name))
+}
+
+func (o *overlayFS) Mkdir(name string, perm os.FileMode) error {
+	return o.FileSystem.Mkdir(o.abs(o.FileSystem, name), perm)
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	return o.FileSystem.MkdirAll(o.abs(o.FileSystem, path), perm)
+}
+
+func (o *overlayFS) Open(name string) (vfs.File, error) {
+	return o.FileSystem.Open(o.abs(o.FileSystem, name))
+}
+
+func (o *overlayFS) OpenFile(name string, flags int, perm os.FileMode) (vfs.File, error) {
+	return o.FileSystem.OpenFile(o.abs(o.FileSystem, name), flags, perm)
+}
+
+func (o *overlayFS) Remove(name string) error {
+	return o.FileSystem.Remove(o.abs(o.FileSystem, name))
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	return o.FileSystem.RemoveAll(o.abs(o.FileSystem, path))
+}
+
+// Rename renames a file by copying it as the layer filesystem does not implement renames.
+// An existing file at the new path is replaced.
+// The copy is only written to the in-memory layer, so it is never visible partially to other processes.
+func (o *overlayFS) Rename(oldname, newname string) error {
+	oldname, newname = o.abs(o.FileSystem, oldname), o.abs(o.FileSystem, newname)
+	info, err := o.FileSystem.Lstat(oldname)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	if !info.Mode().IsRegular() {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: errors.New("only regular files can be renamed")}
+	}
+	if err := vfs.CopyFile(o.FileSystem, oldname, o.FileSystem, newname); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	return o.FileSystem.Remove(oldname)
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	return o.FileSystem.Stat(o.abs(o.FileSystem, name))
+}
+
+func (o *overlayFS) Lstat(name string) (os.FileInfo, error) {
+	return o.FileSystem.Lstat(o.abs(o.FileSystem, name))
+}
+
+func (o *overlayFS) Symlink(oldname, newname string) error {
+	return o.FileSystem.Symlink(oldname, o.abs(o.FileSystem, newname))
+}
+
+func (o *overlayFS) Readlink(name string) (string, error) {
+	return o.FileSystem.Readlink(o.abs(o.FileSystem, name))
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	return o.FileSystem.Chmod(o.abs(o.FileSystem, name), mode)
+}
+
+func (o *overlayFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return o.FileSystem.Chtimes(o.abs(o.FileSystem, name), atime, mtime)
+}
+
+func (o *overlayFS) Getwd() (string, error) {
+	return o.wd, nil
+}
+
+// maskedFS is a read-only view of a filesystem that hides the given absolute paths and their content.
+// It is only used as base of a layer filesystem that writes all modifications to its layer.
+type maskedFS struct {
+	vfs.FileSystem
+	hidden []string
+}
+
+func (m *maskedFS) isHidden(name string) bool {
+	name = vfs.Join(m.FileSystem, name)
+	for _, hidden := range m.hidden {
+		if name == hidden || strings.HasPrefix(name, hidden+vfs.PathSeparatorString) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *maskedFS) Open(name string) (vfs.File, error) {
+	if m.isHidden(name) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return m.FileSystem.Open(name)
+}
+
+func (m *maskedFS) OpenFile(name string, flags int, perm os.FileMode) (vfs.File, error) {
+	if m.isHidden(name) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return m.FileSystem.OpenFile(name, flags, perm)
+}
+
+func (m *maskedFS) Stat(name string) (os.FileInfo, error) {
+	if m.isHidden(name) {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	return m.FileSystem.Stat(name)
+}
+
+func (m *maskedFS) Lstat(name string) (os.FileInfo, error) {
+	if m.isHidden(name) {
+		return nil, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+	}
+	return m.FileSystem.Lstat(name)
+}
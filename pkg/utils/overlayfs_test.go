@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils_test
+
+import (
+	"os"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("OverlayFS", func() {
+
+	It("should write modifications only to memory", func() {
+		base := memoryfs.New()
+		Expect(vfs.WriteFile(base, "/a", []byte("a"), os.ModePerm)).To(Succeed())
+
+		fs, err := utils.NewOverlayFS(base)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(fs, "/a", []byte("modified"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/b", []byte("b"), os.ModePerm)).To(Succeed())
+
+		Expect(vfs.ReadFile(fs, "/a")).To(Equal([]byte("modified")))
+		Expect(vfs.ReadFile(base, "/a")).To(Equal([]byte("a")))
+		_, err = base.Stat("/b")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should resolve relative paths against the working directory of the base filesystem", func() {
+		fs, err := utils.NewOverlayFS(osfs.New())
+		Expect(err).ToNot(HaveOccurred())
+		expected, err := os.ReadFile("overlayfs_test.go")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.ReadFile(fs, "overlayfs_test.go")).To(Equal(expected))
+	})
+
+	It("should replace a hidden file by a directory", func() {
+		base := memoryfs.New()
+		Expect(vfs.WriteFile(base, "/archive.tar", []byte("tar"), os.ModePerm)).To(Succeed())
+
+		fs, err := utils.NewOverlayFS(base, "/archive.tar")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fs.Stat("/archive.tar")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		Expect(fs.MkdirAll("/archive.tar/blobs", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/archive.tar/blobs/blob", []byte("blob"), os.ModePerm)).To(Succeed())
+		entries, err := vfs.ReadDir(fs, "/archive.tar/blobs")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(vfs.ReadFile(base, "/archive.tar")).To(Equal([]byte("tar")))
+	})
+
+	It("should replace a file by a rename", func() {
+		base := memoryfs.New()
+		Expect(vfs.WriteFile(base, "/a", []byte("a"), os.ModePerm)).To(Succeed())
+
+		fs, err := utils.NewOverlayFS(base)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(fs, "/a.tmp", []byte("modified"), os.ModePerm)).To(Succeed())
+		Expect(fs.Rename("/a.tmp", "/a")).To(Succeed())
+
+		Expect(vfs.ReadFile(fs, "/a")).To(Equal([]byte("modified")))
+		_, err = fs.Stat("/a.tmp")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		Expect(vfs.ReadFile(base, "/a")).To(Equal([]byte("a")))
+		Expect(fs.Rename("/missing", "/a")).To(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// StdinPath is the path that explicitly requests to read the input from stdin.
+const StdinPath = "-"
+
+// Stdin returns stdin as reader for an input that is requested with "-".
+// An error is returned if stdin is an interactive terminal
+// as reading it would block until the user closes the input.
+func Stdin() (io.Reader, error) {
+	if IsTerminal(os.Stdin) {
+		return nil, errors.New("unable to read from stdin: stdin is an interactive terminal, pipe or redirect the input to it")
+	}
+	return os.Stdin, nil
+}
@@ -0,0 +1,9 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import "golang.org/x/sys/unix"
+
+const ioctlReadTermios = unix.TIOCGETA
@@ -0,0 +1,20 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package utils
+
+import "os"
+
+// IsTerminal checks whether the file is an interactive terminal.
+// Without terminal ioctls every character device is treated as terminal.
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils_test
+
+import (
+	"os"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("IsTerminal", func() {
+
+	It("should not detect a pipe as terminal", func() {
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		defer r.Close()
+		defer w.Close()
+		Expect(utils.IsTerminal(r)).To(BeFalse())
+	})
+
+	It("should not detect a redirected file as terminal", func() {
+		file, err := os.Open("./stdin.go")
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+		Expect(utils.IsTerminal(file)).To(BeFalse())
+	})
+
+	It("should not detect a character device that is no terminal as terminal", func() {
+		if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+			Skip("terminals can only be detected on linux and darwin")
+		}
+		file, err := os.Open(os.DevNull)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+		Expect(utils.IsTerminal(file)).To(BeFalse())
+	})
+
+	It("should detect a pseudo terminal", func() {
+		if runtime.GOOS != "linux" {
+			Skip("pseudo terminals are only opened on linux")
+		}
+		file, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+		if err != nil {
+			Skip("pseudo terminals are not available: " + err.Error())
+		}
+		defer file.Close()
+		Expect(utils.IsTerminal(file)).To(BeTrue())
+	})
+
+})
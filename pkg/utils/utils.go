@@ -179,7 +179,9 @@ func BytesString(bytes uint64, accuracy int) string {
 	return fmt.Sprintf("%s %s", stringValue, unit)
 }
 
-// WriteFileToTARArchive writes a new file with name=filename and content=inputReader to outputWriter
+// WriteFileToTARArchive writes a new file with name=filename and content=inputReader to outputWriter.
+// The content is streamed in chunks. As the size of a tar entry has to be known upfront,
+// the content of readers that cannot seek is spooled to a temporary file first.
 func WriteFileToTARArchive(filename string, inputReader io.Reader, outputWriter *tar.Writer) error {
 	if filename == "" {
 		return errors.New("filename must not be empty")
@@ -193,24 +195,34 @@ func WriteFileToTARArchive(filename string, inputReader io.Reader, outputWriter
 		return errors.New("outputWriter must not be nil")
 	}
 
-	tempfile, err := ioutil.TempFile("", "")
-	if err != nil {
-		return fmt.Errorf("unable to create tempfile: %w", err)
+	content, ok := inputReader.(io.ReadSeeker)
+	if !ok {
+		tempfile, err := ioutil.TempFile("", "")
+		if err != nil {
+			return fmt.Errorf("unable to create tempfile: %w", err)
+		}
+		defer func() {
+			_ = tempfile.Close()
+			_ = os.Remove(tempfile.Name())
+		}()
+
+		if _, err := io.Copy(tempfile, inputReader); err != nil {
+			return fmt.Errorf("unable to copy content to tempfile: %w", err)
+		}
+		if _, err := tempfile.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+		}
+		content = tempfile
 	}
-	defer tempfile.Close()
 
-	fsize, err := io.Copy(tempfile, inputReader)
+	fsize, err := remainingSize(content)
 	if err != nil {
-		return fmt.Errorf("unable to copy content to tempfile: %w", err)
-	}
-
-	if _, err := tempfile.Seek(0, io.SeekStart); err != nil {
-		return fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+		return err
 	}
 
 	header := tar.Header{
 		Name:    filename,
-		Size:    int64(fsize),
+		Size:    fsize,
 		Mode:    0600,
 		ModTime: time.Now(),
 	}
@@ -219,13 +231,30 @@ func WriteFileToTARArchive(filename string, inputReader io.Reader, outputWriter
 		return fmt.Errorf("unable to write tar header: %w", err)
 	}
 
-	if _, err := io.Copy(outputWriter, tempfile); err != nil {
+	if _, err := io.CopyN(outputWriter, content, fsize); err != nil {
 		return fmt.Errorf("unable to write file to tar archive: %w", err)
 	}
 
 	return nil
 }
 
+// remainingSize returns the number of bytes between the current offset and the end of the reader.
+// The offset of the reader is unchanged.
+func remainingSize(r io.Seeker) (int64, error) {
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get current offset: %w", err)
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, fmt.Errorf("unable to seek to end: %w", err)
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("unable to seek to current offset: %w", err)
+	}
+	return end - offset, nil
+}
+
 // TargetOCIArtifactRef calculates the target reference for
 func TargetOCIArtifactRef(targetRepo, ref string, keepOrigHost bool) (string, error) {
 	if !strings.Contains(targetRepo, "://") {